@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/anthonyrawlins/bzzz/discovery"
+	"github.com/anthonyrawlins/bzzz/monitoring"
+	"github.com/anthonyrawlins/bzzz/p2p"
+	"github.com/anthonyrawlins/bzzz/pubsub"
+)
+
+// observe joins the mesh as a read-only peer and prints coordination traffic.
+// It never claims tasks or publishes messages.
+func main() {
+	serviceTag := flag.String("service-tag", "bzzz-peer-discovery", "mDNS service tag used to find peers")
+	bzzzTopic := flag.String("bzzz-topic", "bzzz/coordination/v1", "Bzzz coordination topic")
+	antennaeTopic := flag.String("antennae-topic", "antennae/meta-discussion/v1", "Antennae meta-discussion topic")
+	types := flag.String("types", "", "Comma-separated message types to show (default: all)")
+	extraTopics := flag.String("topics", "", "Comma-separated extra topics to watch, e.g. bzzz/meta/issue/42,"+pubsub.TelemetryTopic)
+	flag.Parse()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	node, err := p2p.NewNode(ctx)
+	if err != nil {
+		log.Fatalf("Failed to create P2P node: %v", err)
+	}
+	defer node.Close()
+
+	mdnsDiscovery, err := discovery.NewMDNSDiscovery(ctx, node.Host(), *serviceTag)
+	if err != nil {
+		log.Fatalf("Failed to create mDNS discovery: %v", err)
+	}
+	defer mdnsDiscovery.Close()
+
+	ps, err := pubsub.NewPubSub(ctx, node.Host(), *bzzzTopic, *antennaeTopic)
+	if err != nil {
+		log.Fatalf("Failed to create PubSub: %v", err)
+	}
+	defer ps.Close()
+
+	var typeFilter []string
+	if *types != "" {
+		typeFilter = strings.Split(*types, ",")
+	}
+	observer := monitoring.NewObserver(os.Stdout, typeFilter)
+	ps.AddBzzzMessageHandler(observer.HandleMessage)
+	ps.AddAntennaeMessageHandler(observer.HandleMessage)
+
+	for _, topic := range strings.Split(*extraTopics, ",") {
+		if topic = strings.TrimSpace(topic); topic == "" {
+			continue
+		}
+		if err := ps.JoinDynamicTopic(topic); err != nil {
+			log.Fatalf("Failed to join topic %s: %v", topic, err)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "👀 Observing %s and %s as %s (Ctrl+C to stop)\n", *bzzzTopic, *antennaeTopic, node.ID().ShortString())
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -62,7 +63,7 @@ func main() {
 
 	// Initialize and start task simulator
 	fmt.Println("🎭 Starting task simulator...")
-	simulator := test.NewTaskSimulator(ps, ctx)
+	simulator := test.NewTaskSimulator(ps, ctx, test.SimulatorSeed())
 	simulator.Start()
 	defer simulator.Stop()
 
@@ -233,9 +234,9 @@ func simulateAgentResponses(ctx context.Context, ps *pubsub.PubSub, scenario tes
 
 // printFinalResults shows the final monitoring results
 func printFinalResults(monitor *monitoring.AntennaeMonitor) {
-	fmt.Println("\n" + "="*60)
+	fmt.Println("\n" + strings.Repeat("=", 60))
 	fmt.Println("📊 FINAL ANTENNAE MONITORING RESULTS")
-	fmt.Println("="*60)
+	fmt.Println(strings.Repeat("=", 60))
 
 	metrics := monitor.GetMetrics()
 	
@@ -261,6 +262,10 @@ func printFinalResults(monitor *monitoring.AntennaeMonitor) {
 		fmt.Printf("📈 Average Session Duration: %v\n", metrics.AverageSessionDuration.Round(time.Second))
 	}
 
+	cumulative := monitor.GetCumulativeMetrics()
+	fmt.Printf("🗂️ Across all runs: %d sessions (%d completed), %d messages\n",
+		cumulative.TotalSessions, cumulative.CompletedSessions, cumulative.TotalMessages)
+
 	fmt.Println("\n✅ Monitoring data saved to /tmp/bzzz_logs/")
 	fmt.Println("   Check activity and metrics files for detailed logs")
 }
\ No newline at end of file
@@ -96,7 +96,7 @@ func runTaskSimulator(ctx context.Context, ps *pubsub.PubSub) {
 	fmt.Println("\n🎭 Running Task Simulator")
 	fmt.Println("========================")
 
-	simulator := test.NewTaskSimulator(ps, ctx)
+	simulator := test.NewTaskSimulator(ps, ctx, test.SimulatorSeed())
 	simulator.Start()
 
 	fmt.Println("📊 Simulator Status:")
@@ -132,8 +132,7 @@ func runInteractiveMode(ctx context.Context, ps *pubsub.PubSub, node *p2p.Node)
 	fmt.Println("\n🎮 Interactive Testing Mode")
 	fmt.Println("===========================")
 
-	simulator := test.NewTaskSimulator(ps, ctx)
-	testSuite := test.NewAntennaeTestSuite(ctx, ps)
+	simulator := test.NewTaskSimulator(ps, ctx, test.SimulatorSeed())
 
 	fmt.Println("Available commands:")
 	fmt.Println("  'start' - Start task simulator")
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/executor"
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/identity"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// cliCommands are run instead of the node when named as the first argument
+var cliCommands = map[string]func(args []string) int{
+	"diagnose":         diagnoseCommand,
+	"register-project": registerProjectCommand,
+	"replay":           replayCommand,
+	"rotate-identity":  rotateIdentityCommand,
+	"validate":         validateCommand,
+}
+
+// runCLICommand runs a subcommand and reports whether one was found
+func runCLICommand(args []string) (exitCode int, found bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	command, exists := cliCommands[args[0]]
+	if !exists {
+		return 0, false
+	}
+	return command(args[1:]), true
+}
+
+// registerProjectCommand registers a GitHub repository with Hive and enables Bzzz on it
+func registerProjectCommand(args []string) int {
+	flags := flag.NewFlagSet("register-project", flag.ContinueOnError)
+	gitURL := flags.String("git-url", "", "GitHub repository URL (https or ssh)")
+	name := flags.String("name", "", "Project name (defaults to the repository name)")
+	description := flags.String("description", "", "Project description")
+	private := flags.Bool("private", false, "Repository is private")
+	readyToClaim := flags.Bool("ready", true, "Let agents start claiming tasks immediately")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.LoadConfig("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	// Repositories on the configured Enterprise server are as good as github.com's
+	_, repository, err := hive.ParseGitURL(*gitURL, cfg.GitHub.EnterpriseHost())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 2
+	}
+	if *name == "" {
+		*name = repository
+	}
+
+	ctx := context.Background()
+	hiveClient := hive.NewHiveClient(cfg.HiveAPI.BaseURL, cfg.HiveAPI.APIKey)
+	if host := cfg.GitHub.EnterpriseHost(); host != "" {
+		hiveClient.GitHubHosts = []string{host}
+	}
+
+	project, err := hiveClient.RegisterProject(ctx, hive.ProjectRegistrationRequest{
+		Name:         *name,
+		Description:  *description,
+		GitURL:       *gitURL,
+		PrivateRepo:  *private,
+		BzzzEnabled:  true,
+		AutoActivate: *readyToClaim,
+	})
+	switch {
+	case errors.Is(err, hive.ErrProjectExists) && project != nil:
+		fmt.Printf("ℹ️ %s is already registered as project %d, enabling Bzzz\n", *gitURL, project.ID)
+	case errors.Is(err, hive.ErrProjectExists):
+		fmt.Fprintf(os.Stderr, "❌ %s is already registered, but Hive did not say which project it is\n", *gitURL)
+		return 1
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "❌ Failed to register project: %v\n", err)
+		return 1
+	default:
+		fmt.Printf("✅ Registered %s as project %d\n", *gitURL, project.ID)
+	}
+
+	if err := hiveClient.SetProjectActivation(ctx, project.ID, hive.ProjectActivationRequest{
+		BzzzEnabled:  true,
+		ReadyToClaim: *readyToClaim,
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to enable Bzzz on project %d: %v\n", project.ID, err)
+		return 1
+	}
+
+	fmt.Printf("🐝 Bzzz enabled on project %d (ready to claim: %t)\n", project.ID, *readyToClaim)
+	return 0
+}
+
+// diagnoseCommand runs a canned task through the reasoning pipeline in a throwaway
+// sandbox and reports which stages work
+func diagnoseCommand(args []string) int {
+	flags := flag.NewFlagSet("diagnose", flag.ContinueOnError)
+	timeout := flags.Duration("timeout", 5*time.Minute, "Give up on the run after this long")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.LoadConfig("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	fmt.Println("🩺 Running reasoning pipeline diagnostics...")
+	report := executor.RunDiagnostics(ctx, &cfg.Agent)
+	fmt.Print(report)
+	if !report.Passed() {
+		return 1
+	}
+	return 0
+}
+
+// rotateIdentityCommand replaces the node's libp2p key after the operator confirms
+func rotateIdentityCommand(args []string) int {
+	flags := flag.NewFlagSet("rotate-identity", flag.ContinueOnError)
+	keyFile := flags.String("key-file", "", "Identity key to rotate (defaults to p2p.identity_key_file)")
+	yes := flags.Bool("yes", false, "Rotate without asking for confirmation")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *keyFile == "" {
+		cfg, err := config.LoadConfig("")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to load configuration: %v\n", err)
+			return 1
+		}
+		*keyFile = cfg.P2P.IdentityKeyFile
+		if *keyFile == "" {
+			*keyFile = identity.DefaultPath()
+		}
+	}
+
+	current, err := identity.Load(*keyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	currentID, _ := current.PeerID()
+
+	fmt.Printf("🔑 Current identity %s (%s)\n", currentID, *keyFile)
+	fmt.Println("⚠️ Rotating changes this node's peer ID, and its agent ID if that is derived from the peer ID.")
+	if !*yes && !confirm(os.Stdin, "Type 'rotate' to continue: ", "rotate") {
+		fmt.Println("Aborted, identity unchanged")
+		return 1
+	}
+
+	rotated, err := identity.Rotate(*keyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to rotate identity: %v\n", err)
+		return 1
+	}
+	rotatedID, _ := rotated.PeerID()
+	fmt.Printf("✅ New identity %s; the previous key is kept at %s.old\n", rotatedID, *keyFile)
+	fmt.Println("Restart the node to start using it.")
+	return 0
+}
+
+// validateCommand checks a config file and explains every problem with it, so
+// mistakes are caught before a node is deployed with it
+func validateCommand(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bzzz validate <config.yaml>")
+		fmt.Fprintln(os.Stderr, "Checks the file on top of the defaults; BZZZ_* environment overrides are not applied.")
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	configPath := flags.Arg(0)
+
+	problems, err := config.ValidateFile(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	if len(problems) == 0 {
+		fmt.Printf("✅ %s is valid\n", configPath)
+		return 0
+	}
+
+	fmt.Printf("❌ %s has %d problem(s):\n\n", configPath, len(problems))
+	for _, problem := range problems {
+		location := "not set in file"
+		if problem.Line > 0 {
+			location = fmt.Sprintf("%s:%d", configPath, problem.Line)
+		}
+		fmt.Printf("  • %s (%s)\n", problem.Error(), location)
+		if problem.Suggestion != "" {
+			fmt.Printf("    fix: %s\n", problem.Suggestion)
+		}
+	}
+	return 1
+}
+
+// confirm prompts and reports whether the operator typed the expected answer
+func confirm(in io.Reader, prompt, expected string) bool {
+	fmt.Print(prompt)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	return strings.TrimSpace(answer) == expected
+}
+
+// replayCommand re-runs a task recorded with agent.record_dir in a fresh sandbox,
+// using the recorded model responses, to reproduce a failure deterministically
+func replayCommand(args []string) int {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	timeout := flags.Duration("timeout", 30*time.Minute, "Give up on the replay after this long")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bzzz replay [-timeout 30m] <recording.json>")
+		fmt.Fprintln(os.Stderr, "Set GITHUB_TOKEN to clone a private repository.")
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	recording, err := executor.LoadRecording(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	recording.Task.GitHubToken = os.Getenv("GITHUB_TOKEN")
+
+	cfg, err := config.LoadConfig("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	fmt.Printf("📼 Replaying task #%d (%d recorded steps)\n", recording.Task.Number, len(recording.Steps))
+	hlog := logging.NewHypercoreLog(peer.ID("replay"))
+	transcript, err := executor.ReplayTask(ctx, recording, &cfg.Agent, hlog)
+	if transcript != nil {
+		for _, step := range transcript.Steps {
+			fmt.Printf("\n$ %s\n%s\n", step.Command, step.Output)
+		}
+	}
+	if recording.Error != "" {
+		fmt.Printf("\nRecorded outcome: %s\n", recording.Error)
+	}
+	if err != nil {
+		fmt.Printf("Replayed outcome: %v\n", err)
+		return 1
+	}
+	fmt.Println("Replayed outcome: completed")
+	return 0
+}
@@ -0,0 +1,106 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	maxBatchFiles     = 50      // Files one batch may write
+	maxBatchFileBytes = 1 << 20 // Size of any one file in a batch
+)
+
+// fileBatch is a multi-file change the model applies in one iteration: every file
+// is written together, then the commands run in order
+type fileBatch struct {
+	Files []struct {
+		Path    string `json:"path"`
+		Content string `json:"content"`
+	} `json:"files"`
+	Commands []string `json:"commands"`
+}
+
+// treeWriter writes several files into the working copy at once
+type treeWriter interface {
+	WriteTree(files map[string][]byte) error
+}
+
+// WriteTree passes batches through to the wrapped runner when it supports them
+func (r *unshallowRunner) WriteTree(files map[string][]byte) error {
+	writer, ok := r.commandRunner.(treeWriter)
+	if !ok {
+		return fmt.Errorf("runner cannot write files")
+	}
+	return writer.WriteTree(files)
+}
+
+// applyBatch validates and applies a BATCH response, returning what to tell the
+// agent. Nothing is written unless the whole batch is valid.
+func applyBatch(runner commandRunner, response string) string {
+	writer, ok := runner.(treeWriter)
+	if !ok {
+		return "Batches are not supported here. Make the changes one command at a time."
+	}
+
+	batch, files, err := parseBatch(strings.TrimSpace(strings.TrimPrefix(response, "BATCH")))
+	if err != nil {
+		return fmt.Sprintf("The batch was rejected and nothing was changed: %v", err)
+	}
+	if err := writer.WriteTree(files); err != nil {
+		return fmt.Sprintf("Writing the batch failed: %v", err)
+	}
+
+	output := fmt.Sprintf("Wrote %d files.\n", len(files))
+	for _, command := range batch.Commands {
+		result, err := runner.RunCommand(command)
+		if err != nil {
+			return output + fmt.Sprintf("Command %q failed: %v\nThe remaining commands were not run.", command, err)
+		}
+		output += fmt.Sprintf("$ %s\nExit code: %d\nStdout: %s\nStderr: %s\n", command, result.ExitCode, result.StdOut, result.StdErr)
+		if result.ExitCode != 0 || result.TimedOut {
+			return output + "The remaining commands were not run."
+		}
+	}
+	return output
+}
+
+// parseBatch decodes a batch and checks every file in it is safe to write
+func parseBatch(raw string) (*fileBatch, map[string][]byte, error) {
+	var batch fileBatch
+	if err := json.Unmarshal([]byte(raw), &batch); err != nil {
+		return nil, nil, fmt.Errorf("it is not valid JSON: %w", err)
+	}
+	if len(batch.Files) == 0 {
+		return nil, nil, fmt.Errorf("it writes no files")
+	}
+	if len(batch.Files) > maxBatchFiles {
+		return nil, nil, fmt.Errorf("it writes %d files, more than the %d allowed", len(batch.Files), maxBatchFiles)
+	}
+
+	files := make(map[string][]byte, len(batch.Files))
+	for _, file := range batch.Files {
+		clean := path.Clean(file.Path)
+		switch {
+		case file.Path == "" || clean == ".":
+			return nil, nil, fmt.Errorf("a file has no path")
+		case path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../"):
+			return nil, nil, fmt.Errorf("%s is outside the repository", file.Path)
+		case clean == ".git" || strings.HasPrefix(clean, ".git/"):
+			return nil, nil, fmt.Errorf("%s is inside .git", file.Path)
+		case len(file.Content) > maxBatchFileBytes:
+			return nil, nil, fmt.Errorf("%s is larger than %d bytes", file.Path, maxBatchFileBytes)
+		}
+		if _, duplicate := files[clean]; duplicate {
+			return nil, nil, fmt.Errorf("%s is written more than once", file.Path)
+		}
+		files[clean] = []byte(file.Content)
+	}
+	for _, command := range batch.Commands {
+		if strings.TrimSpace(command) == "" {
+			return nil, nil, fmt.Errorf("it contains an empty command")
+		}
+	}
+	return &batch, files, nil
+}
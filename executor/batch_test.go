@@ -0,0 +1,79 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// treeRunner records batch writes and the commands run after them
+type treeRunner struct {
+	commandLog
+	writes []map[string][]byte
+}
+
+func (r *treeRunner) WriteTree(files map[string][]byte) error {
+	r.writes = append(r.writes, files)
+	return nil
+}
+
+func TestBatchOfFileWritesAppliesInOneIteration(t *testing.T) {
+	batch := `BATCH
+{"files": [
+  {"path": "api/handler.go", "content": "package api\n"},
+  {"path": "api/handler_test.go", "content": "package api\n"},
+  {"path": "docs/api.md", "content": "# API\n"}
+], "commands": ["gofmt -l api"]}`
+	responses := []string{batch, "TASK_COMPLETE"}
+	next := func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		response := responses[0]
+		responses = responses[1:]
+		return response, nil
+	}
+
+	task := &types.EnhancedTask{Number: 21, Title: "Add the handler"}
+	hlog := logging.NewHypercoreLog(peer.ID("test"))
+	runner := &treeRunner{}
+	transcript := &Transcript{}
+	if err := runDevelopmentLoop(context.Background(), runner, task, hlog, next, "", nil, transcript); err != nil {
+		t.Fatalf("runDevelopmentLoop returned error: %v", err)
+	}
+
+	if len(runner.writes) != 1 || len(runner.writes[0]) != 3 {
+		t.Fatalf("expected the three files to be written together, got %d writes", len(runner.writes))
+	}
+	if string(runner.writes[0]["docs/api.md"]) != "# API\n" {
+		t.Errorf("unexpected content written: %q", runner.writes[0]["docs/api.md"])
+	}
+	if len(runner.commands) != 1 || runner.commands[0] != "gofmt -l api" {
+		t.Errorf("expected the batch's command to run after the writes, ran %q", runner.commands)
+	}
+	if len(transcript.Steps) != 2 || !strings.HasPrefix(transcript.Steps[0].Output, "Wrote 3 files") {
+		t.Fatalf("expected the batch to take a single iteration, got %+v", transcript.Steps)
+	}
+}
+
+func TestInvalidBatchWritesNothing(t *testing.T) {
+	runner := &treeRunner{}
+	for _, batch := range []string{
+		`BATCH {"files": [{"path": "ok.go", "content": "package ok"}, {"path": "../escape.sh", "content": "rm -rf /"}]}`,
+		`BATCH {"files": [{"path": ".git/hooks/pre-commit", "content": "exit 0"}]}`,
+		`BATCH {"files": [{"path": "a.go"}, {"path": "./a.go"}]}`,
+		`BATCH not json`,
+	} {
+		if output := applyBatch(runner, batch); !strings.Contains(output, "rejected") {
+			t.Errorf("expected %s to be rejected, got %q", batch, output)
+		}
+	}
+	if len(runner.writes) != 0 {
+		t.Fatalf("an invalid batch was written: %v", runner.writes)
+	}
+
+	if output := applyBatch(&verifyRunner{}, `BATCH {"files": [{"path": "a.go"}]}`); !strings.Contains(output, "not supported") {
+		t.Errorf("expected runners without batch support to say so, got %q", output)
+	}
+}
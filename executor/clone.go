@@ -0,0 +1,217 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/sandbox"
+)
+
+// shallowErrorHints appear in git errors caused by missing history in a shallow clone
+var shallowErrorHints = []string{"shallow", "no merge base", "bad object", "unknown revision"}
+
+// cloneCommand builds the clone command for a task. A depth makes it shallow, and
+// sparse checkout limits the working tree to the task's "paths" context, if any.
+func cloneCommand(task *types.EnhancedTask, cloneConfig config.CloneConfig) string {
+	args := []string{"git", "clone"}
+	if cloneConfig.Depth > 0 {
+		args = append(args, fmt.Sprintf("--depth %d", cloneConfig.Depth))
+	}
+	paths := sparsePaths(task)
+	if cloneConfig.SparseCheckout && len(paths) > 0 {
+		args = append(args, "--sparse")
+	}
+	args = append(args, shellQuote(task.GitURL), ".")
+
+	command := strings.Join(args, " ")
+	if cloneConfig.SparseCheckout && len(paths) > 0 {
+		quoted := make([]string, len(paths))
+		for i, path := range paths {
+			quoted[i] = shellQuote(path)
+		}
+		command += " && git sparse-checkout set " + strings.Join(quoted, " ")
+	}
+	return command
+}
+
+// cloneAuthHints appear in git errors that retrying with the same credentials won't fix
+var cloneAuthHints = []string{
+	"authentication failed",
+	"could not read username",
+	"could not read password",
+	"terminal prompts disabled",
+	"permission denied",
+	"repository not found",
+	"the requested url returned error: 401",
+	"the requested url returned error: 403",
+}
+
+// CloneAuthError is returned when the sandbox can't clone the repository with the
+// credentials it was given
+type CloneAuthError struct {
+	Err error
+}
+
+func (e *CloneAuthError) Error() string {
+	return fmt.Sprintf("repository access denied: %v", e.Err)
+}
+
+func (e *CloneAuthError) Unwrap() error {
+	return e.Err
+}
+
+// cloneRepository clones the task repository into the sandbox and checks the
+// result is a usable checkout. A clone that fails or leaves a broken workspace is
+// retried once from scratch, unless git was refused access.
+func cloneRepository(runner commandRunner, task *types.EnhancedTask, cloneConfig config.CloneConfig) error {
+	err := cloneAndVerify(runner, task, cloneConfig)
+	if err == nil {
+		return nil
+	}
+	if isCloneAuthError(err) {
+		return &CloneAuthError{Err: err}
+	}
+
+	fmt.Printf("⚠️ Clone of task #%d failed, retrying with a fresh clone: %v\n", task.Number, err)
+	runner.RunCommand("find . -mindepth 1 -delete")
+	if err := cloneAndVerify(runner, task, cloneConfig); err != nil {
+		if isCloneAuthError(err) {
+			return &CloneAuthError{Err: err}
+		}
+		return err
+	}
+	return nil
+}
+
+// cloneAndVerify makes one attempt at a clone. If a shallow or sparse clone
+// fails, the working directory is cleared and a full clone is tried.
+func cloneAndVerify(runner commandRunner, task *types.EnhancedTask, cloneConfig config.CloneConfig) error {
+	command := cloneCommand(task, cloneConfig)
+	fullClone := fmt.Sprintf("git clone %s .", shellQuote(task.GitURL))
+
+	result, err := runner.RunCommand(command)
+	if err == nil && result.ExitCode == 0 && !result.TimedOut {
+		return verifyClone(runner, task, cloneConfig)
+	}
+	if command == fullClone {
+		return cloneError(result, err)
+	}
+
+	fmt.Printf("⚠️ Partial clone of task #%d failed, falling back to a full clone\n", task.Number)
+	runner.RunCommand("find . -mindepth 1 -delete")
+	result, err = runner.RunCommand(fullClone)
+	if err != nil || result.ExitCode != 0 || result.TimedOut {
+		return cloneError(result, err)
+	}
+	return verifyClone(runner, task, cloneConfig)
+}
+
+// verifyClone checks the workspace holds a checked-out repository, and the task's
+// paths if the clone was sparse. A clone cut short can exit cleanly yet leave
+// nothing usable behind. A repository with no commits at all is fine: the task
+// may be to give it its first.
+func verifyClone(runner commandRunner, task *types.EnhancedTask, cloneConfig config.CloneConfig) error {
+	checkedOut := []string{
+		"git rev-parse --verify --quiet HEAD >/dev/null",
+		`test -n "$(git ls-files | head -n 1)"`,
+	}
+	if cloneConfig.SparseCheckout {
+		for _, path := range sparsePaths(task) {
+			checkedOut = append(checkedOut, "test -e "+shellQuote(path))
+		}
+	}
+	empty := `test -z "$(git ls-remote --heads origin)"`
+
+	result, err := runner.RunCommand(fmt.Sprintf("test -d .git && { { %s; } || %s; }", strings.Join(checkedOut, " && "), empty))
+	if err != nil {
+		return fmt.Errorf("failed to verify clone: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("clone left an incomplete workspace: %s", strings.TrimSpace(result.StdErr))
+	}
+	return nil
+}
+
+// cloneError describes a failed clone from the runner error or git's output
+func cloneError(result *sandbox.CommandResult, err error) error {
+	if err != nil {
+		return err
+	}
+	if result.TimedOut {
+		return fmt.Errorf("git clone timed out: %s", strings.TrimSpace(result.StdErr))
+	}
+	return fmt.Errorf("git clone exited with code %d: %s", result.ExitCode, strings.TrimSpace(result.StdErr))
+}
+
+// isCloneAuthError reports whether a clone failed because git was refused access
+func isCloneAuthError(err error) bool {
+	message := strings.ToLower(err.Error())
+	for _, hint := range cloneAuthHints {
+		if strings.Contains(message, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// sparsePaths reads the directories a task touches from its "paths" context
+func sparsePaths(task *types.EnhancedTask) []string {
+	var paths []string
+	switch value := task.Context["paths"].(type) {
+	case []string:
+		paths = value
+	case []interface{}:
+		for _, item := range value {
+			if path, ok := item.(string); ok {
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	var cleaned []string
+	for _, path := range paths {
+		if path = strings.Trim(strings.TrimSpace(path), "/"); path != "" && !strings.Contains(path, "..") {
+			cleaned = append(cleaned, path)
+		}
+	}
+	return cleaned
+}
+
+// unshallowRunner deepens a shallow clone the first time a command fails for lack
+// of history, then retries that command. Later commands see the full history.
+type unshallowRunner struct {
+	commandRunner
+	unshallowed bool
+}
+
+func (r *unshallowRunner) RunCommand(command string) (*sandbox.CommandResult, error) {
+	result, err := r.commandRunner.RunCommand(command)
+	if err != nil || r.unshallowed || result.ExitCode == 0 || !isShallowError(result.StdErr) {
+		return result, err
+	}
+
+	r.unshallowed = true
+	fmt.Printf("📚 Command needs git history, fetching the full history\n")
+	if fetch, err := r.commandRunner.RunCommand("git fetch --unshallow"); err != nil || fetch.ExitCode != 0 {
+		return result, nil // Report the original failure to the agent
+	}
+	return r.commandRunner.RunCommand(command)
+}
+
+// isShallowError reports whether git output suggests missing history
+func isShallowError(output string) bool {
+	output = strings.ToLower(output)
+	for _, hint := range shallowErrorHints {
+		if strings.Contains(output, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// shellQuote single-quotes a value for the sandbox shell
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,200 @@
+package executor
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/sandbox"
+)
+
+// cloneRunner fails any clone matching failPrefix and records every command
+type cloneRunner struct {
+	failPrefix string
+	commands   []string
+}
+
+func (r *cloneRunner) RunCommand(command string) (*sandbox.CommandResult, error) {
+	r.commands = append(r.commands, command)
+	if r.failPrefix != "" && strings.HasPrefix(command, r.failPrefix) {
+		return &sandbox.CommandResult{StdErr: "fatal: dumb http transport does not support shallow capabilities", ExitCode: 128}, nil
+	}
+	return &sandbox.CommandResult{}, nil
+}
+
+func TestCloneCommandUsesConfiguredDepth(t *testing.T) {
+	task := &types.EnhancedTask{
+		GitURL:  "https://github.com/acme/monorepo.git",
+		Context: map[string]interface{}{"paths": []interface{}{"services/billing/", "../etc", "docs"}},
+	}
+
+	got := cloneCommand(task, config.CloneConfig{Depth: 1})
+	if got != "git clone --depth 1 'https://github.com/acme/monorepo.git' ." {
+		t.Fatalf("unexpected shallow clone command: %s", got)
+	}
+
+	got = cloneCommand(task, config.CloneConfig{Depth: 5, SparseCheckout: true})
+	want := "git clone --depth 5 --sparse 'https://github.com/acme/monorepo.git' . && git sparse-checkout set 'services/billing' 'docs'"
+	if got != want {
+		t.Fatalf("unexpected sparse clone command:\n got: %s\nwant: %s", got, want)
+	}
+
+	if got := cloneCommand(task, config.CloneConfig{}); got != "git clone 'https://github.com/acme/monorepo.git' ." {
+		t.Fatalf("expected a full clone by default, got %s", got)
+	}
+}
+
+func TestShallowCloneFallsBackToFullClone(t *testing.T) {
+	task := &types.EnhancedTask{Number: 9, GitURL: "https://git.example.com/acme/widgets.git"}
+	runner := &cloneRunner{failPrefix: "git clone --depth"}
+
+	if err := cloneRepository(runner, task, config.CloneConfig{Depth: 1}); err != nil {
+		t.Fatalf("cloneRepository returned error: %v", err)
+	}
+	last := runner.commands[len(runner.commands)-2] // The clone is followed by its verification
+	if last != "git clone 'https://git.example.com/acme/widgets.git' ." {
+		t.Fatalf("expected a full clone after the shallow clone failed, ran %v", runner.commands)
+	}
+}
+
+func TestUnshallowRunnerFetchesHistoryOnce(t *testing.T) {
+	inner := &cloneRunner{failPrefix: "git log"}
+	runner := &unshallowRunner{commandRunner: inner}
+
+	runner.RunCommand("git log --oneline main..HEAD")
+	runner.RunCommand("git log -1")
+
+	fetches := 0
+	for _, command := range inner.commands {
+		if command == "git fetch --unshallow" {
+			fetches++
+		}
+	}
+	if fetches != 1 {
+		t.Fatalf("expected history to be fetched once, ran %v", inner.commands)
+	}
+}
+
+// flakyCloneRunner fails the first clone the way a dropped connection does
+type flakyCloneRunner struct {
+	stderr   string
+	clones   int
+	commands []string
+}
+
+func (r *flakyCloneRunner) RunCommand(command string) (*sandbox.CommandResult, error) {
+	r.commands = append(r.commands, command)
+	if strings.HasPrefix(command, "git clone") {
+		r.clones++
+		if r.clones == 1 {
+			return &sandbox.CommandResult{StdErr: r.stderr, ExitCode: 128}, nil
+		}
+	}
+	return &sandbox.CommandResult{}, nil
+}
+
+func TestTransientCloneFailureIsRetriedWithAFreshClone(t *testing.T) {
+	task := &types.EnhancedTask{Number: 9, GitURL: "https://git.example.com/acme/widgets.git"}
+	runner := &flakyCloneRunner{stderr: "error: RPC failed; curl 56 GnuTLS recv error (-9)\nfatal: early EOF"}
+
+	if err := cloneRepository(runner, task, config.CloneConfig{}); err != nil {
+		t.Fatalf("expected the retried clone to succeed, got %v", err)
+	}
+	if runner.clones != 2 {
+		t.Fatalf("expected exactly one retry, ran %v", runner.commands)
+	}
+	if runner.commands[1] != "find . -mindepth 1 -delete" {
+		t.Fatalf("expected the workspace to be cleared before retrying, ran %v", runner.commands)
+	}
+	if last := runner.commands[len(runner.commands)-1]; !strings.Contains(last, "test -d .git") {
+		t.Fatalf("expected the retried clone to be verified, ran %v", runner.commands)
+	}
+
+	// Refused credentials won't get better on a second try
+	runner = &flakyCloneRunner{stderr: "fatal: could not read Username for 'https://git.example.com': terminal prompts disabled"}
+	err := cloneRepository(runner, task, config.CloneConfig{})
+	var authErr *CloneAuthError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("expected a CloneAuthError, got %v", err)
+	}
+	if runner.clones != 1 {
+		t.Fatalf("expected an auth failure not to be retried, ran %v", runner.commands)
+	}
+}
+
+// shellRunner runs commands with the local shell in dir
+type shellRunner struct {
+	dir string
+}
+
+func (r *shellRunner) RunCommand(command string) (*sandbox.CommandResult, error) {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = r.dir
+	var stdout, stderr strings.Builder
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	result := &sandbox.CommandResult{}
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, err
+		}
+		result.ExitCode = exitErr.ExitCode()
+	}
+	result.StdOut, result.StdErr = stdout.String(), stderr.String()
+	return result, nil
+}
+
+func TestCloneOfAnEmptyRepositoryIsValid(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := t.TempDir()
+	origin, work := filepath.Join(root, "origin.git"), filepath.Join(root, "work")
+	if output, err := exec.Command("git", "init", "-q", "--bare", origin).CombinedOutput(); err != nil {
+		t.Fatalf("failed to create the origin: %v: %s", err, output)
+	}
+	if output, err := exec.Command("git", "clone", "-q", origin, work).CombinedOutput(); err != nil {
+		t.Fatalf("failed to clone the origin: %v: %s", err, output)
+	}
+
+	task := &types.EnhancedTask{Number: 3}
+	runner := &shellRunner{dir: work}
+	if err := verifyClone(runner, task, config.CloneConfig{}); err != nil {
+		t.Fatalf("expected a clone of a repository with no commits to be valid, got %v", err)
+	}
+
+	// The task's first commit is measured from nothing
+	for _, command := range []string{"echo hello > README.md", "git add .", "git -c user.name=bzzz -c user.email=bzzz@localhost commit -q -m first"} {
+		if err := runChecked(runner, command); err != nil {
+			t.Fatal(err)
+		}
+	}
+	since, err := mergeBase(runner, "origin/HEAD")
+	if err != nil {
+		t.Fatalf("mergeBase returned error: %v", err)
+	}
+	if stats, err := changeDiffStats(runner, since); err != nil || stats.FilesChanged != 1 {
+		t.Fatalf("expected the first commit to count as the whole change, got %+v, err %v", stats, err)
+	}
+	runChecked(runner, "git update-ref -d HEAD && git rm -q --cached README.md && rm README.md")
+
+	// Once the origin has commits, a clone without them is incomplete
+	seed := filepath.Join(root, "seed")
+	for _, args := range [][]string{
+		{"init", "-q", seed},
+		{"-C", seed, "commit", "-q", "--allow-empty", "-m", "first"},
+		{"-C", seed, "push", "-q", origin, "HEAD:refs/heads/main"},
+	} {
+		command := exec.Command("git", append([]string{"-c", "user.name=bzzz", "-c", "user.email=bzzz@localhost"}, args...)...)
+		if output, err := command.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, output)
+		}
+	}
+	if err := verifyClone(runner, task, config.CloneConfig{}); err == nil {
+		t.Fatal("expected a clone missing the origin's commits to be incomplete")
+	}
+}
@@ -0,0 +1,81 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+)
+
+// confidencePattern matches the self-rating line the command prompt asks for, e.g. "CONFIDENCE: 85"
+var confidencePattern = regexp.MustCompile(`(?im)^\s*confidence:\s*(\d+(?:\.\d+)?)\s*%?\s*$`)
+
+// LowConfidenceError is returned when the model isn't sure enough of a command
+// for the agent to run it without a human confirming it
+type LowConfidenceError struct {
+	Command    string
+	Confidence float64
+	Threshold  float64
+}
+
+func (e *LowConfidenceError) Error() string {
+	return fmt.Sprintf("model is only %.0f%% confident in command %q, below the %.0f%% needed to run it unattended",
+		e.Confidence*100, e.Command, e.Threshold*100)
+}
+
+// confidenceGated strips the model's self-rating from each response and refuses
+// commands rated below threshold. A command without a rating counts as rated 0,
+// so a model can't skip the gate by leaving the rating out. The completion
+// keywords pass through; a threshold of 0 never refuses.
+func confidenceGated(next nextCommandFunc, threshold float64) nextCommandFunc {
+	return func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		response, err := next(ctx, task, lastOutput)
+		if err != nil {
+			return "", err
+		}
+		command, confidence, rated := parseConfidence(response)
+		if threshold <= 0 || confidence >= threshold || isCompletionKeyword(command) {
+			return command, nil
+		}
+
+		if !rated {
+			fmt.Printf("🤔 Not running %q for task #%d: the model gave no confidence rating\n", command, task.Number)
+		} else {
+			fmt.Printf("🤔 Not running %q for task #%d: confidence %.2f is below %.2f\n", command, task.Number, confidence, threshold)
+		}
+		return "", &LowConfidenceError{Command: command, Confidence: confidence, Threshold: threshold}
+	}
+}
+
+// parseConfidence splits a response into its command and its confidence from 0 to 1.
+// rated is false when the response has no confidence line.
+func parseConfidence(response string) (command string, confidence float64, rated bool) {
+	// The rating comes last; earlier matches may be inside a batch's file contents
+	matches := confidencePattern.FindAllStringSubmatchIndex(response, -1)
+	if matches == nil {
+		return strings.TrimSpace(response), 0, false
+	}
+	match := matches[len(matches)-1]
+	value, err := strconv.ParseFloat(response[match[2]:match[3]], 64)
+	if err != nil {
+		return strings.TrimSpace(response), 0, false
+	}
+	if value > 1 {
+		value /= 100 // Rated out of 100 as asked, rather than as a fraction
+	}
+	command = strings.TrimSpace(response[:match[0]] + response[match[1]:])
+	return command, value, true
+}
+
+// isCompletionKeyword reports whether the response is a keyword rather than a command to run
+func isCompletionKeyword(command string) bool {
+	for _, keyword := range []string{"TASK_COMPLETE", "ITEM_COMPLETE", "STEP_COMPLETE"} {
+		if strings.HasPrefix(command, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/sandbox"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// commandLog records every command it is asked to run
+type commandLog struct {
+	commands []string
+}
+
+func (r *commandLog) RunCommand(command string) (*sandbox.CommandResult, error) {
+	r.commands = append(r.commands, command)
+	return &sandbox.CommandResult{}, nil
+}
+
+func TestLowConfidenceCommandIsEscalatedNotRun(t *testing.T) {
+	thresholds := config.ConfidenceConfig{Default: 0.3, TaskTypes: map[string]float64{"migration": 0.8}}
+	next := func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		return "psql -c 'DROP TABLE users'\nCONFIDENCE: 55", nil
+	}
+
+	task := &types.EnhancedTask{Number: 8, Title: "Migrate the users table", TaskType: "migration"}
+	hlog := logging.NewHypercoreLog(peer.ID("test"))
+	runner := &commandLog{}
+
+	err := runDevelopmentLoop(context.Background(), runner, task, hlog, confidenceGated(next, thresholds.Threshold(task.TaskType)), "", nil, nil)
+	var confidenceErr *LowConfidenceError
+	if !errors.As(err, &confidenceErr) {
+		t.Fatalf("expected a LowConfidenceError, got %v", err)
+	}
+	if confidenceErr.Command != "psql -c 'DROP TABLE users'" || confidenceErr.Confidence != 0.55 || confidenceErr.Threshold != 0.8 {
+		t.Errorf("unexpected error details: %+v", confidenceErr)
+	}
+	if len(runner.commands) != 0 {
+		t.Fatalf("a low-confidence command was run: %q", runner.commands)
+	}
+
+	// The same rating clears the lower default threshold, and the rating is stripped
+	task.TaskType = "docs"
+	gated := confidenceGated(next, thresholds.Threshold(task.TaskType))
+	if command, err := gated(context.Background(), task, ""); err != nil || command != "psql -c 'DROP TABLE users'" {
+		t.Fatalf("expected the command to pass the default threshold without its rating, got %q, %v", command, err)
+	}
+
+	// Leaving the rating out doesn't get a command past the gate
+	unrated := func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		return "psql -c 'DROP TABLE users'", nil
+	}
+	if _, err := confidenceGated(unrated, 0.3)(context.Background(), task, ""); !errors.As(err, &confidenceErr) {
+		t.Fatalf("expected an unrated command to be refused, got %v", err)
+	}
+	if command, err := confidenceGated(unrated, 0)(context.Background(), task, ""); err != nil || command != "psql -c 'DROP TABLE users'" {
+		t.Fatalf("expected no threshold to let an unrated command through, got %q, %v", command, err)
+	}
+}
@@ -0,0 +1,179 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+)
+
+const (
+	maxConflictFiles     = 3     // More conflicting files than this aren't simple enough to resolve unattended
+	maxConflictFileBytes = 20000 // Nor are files larger than this
+	maxRebaseRounds      = 5     // Commits whose conflicts we'll work through in one rebase
+)
+
+// resolveFunc returns a conflicted file's content with its conflicts resolved
+type resolveFunc func(ctx context.Context, task *types.EnhancedTask, path, content string) (string, error)
+
+// modelResolver returns a resolveFunc backed by the given model
+func modelResolver(model string) resolveFunc {
+	if model == "" {
+		model = defaultCommandModel
+	}
+	return func(ctx context.Context, task *types.EnhancedTask, path, content string) (string, error) {
+		response, err := generateFor(ctx, task, model, buildResolvePrompt(task, path, content))
+		if err != nil {
+			return "", err
+		}
+		return stripCodeFence(response), nil
+	}
+}
+
+// baseRef names the remote branch a task's pull request merges into
+func baseRef(task *types.EnhancedTask) string {
+	if task.Repository.Branch != "" {
+		return "origin/" + task.Repository.Branch
+	}
+	return "origin/HEAD"
+}
+
+// syncWithBase rebases the committed task branch onto the latest base branch,
+// before anything is scanned, measured or pushed, so its pull request merges
+// cleanly. Simple conflicts are resolved with resolve; others leave the branch as
+// it was and are reported as conflicted for a human. If the rebase moved the
+// branch, verifyCommand must pass again or the rebase is undone.
+func syncWithBase(ctx context.Context, runner commandRunner, task *types.EnhancedTask, resolve resolveFunc, verifyCommand string) types.MergeStatus {
+	baseRef := baseRef(task)
+	fetch := "git fetch origin"
+	if task.Repository.Branch != "" {
+		fetch += " " + shellQuote(task.Repository.Branch)
+	}
+	if result, err := runner.RunCommand(fetch); err != nil || result.ExitCode != 0 {
+		fmt.Printf("⚠️ Couldn't fetch the base branch for task #%d, skipping the conflict check\n", task.Number)
+		return types.MergeStatus{}
+	}
+
+	before, err := gitOutput(runner, "git rev-parse HEAD")
+	if err != nil {
+		fmt.Printf("⚠️ Couldn't read task #%d's branch, skipping the conflict check: %v\n", task.Number, err)
+		return types.MergeStatus{}
+	}
+	before = strings.TrimSpace(before)
+	status := types.MergeStatus{State: types.MergeClean}
+	result, err := runner.RunCommand("git rebase " + shellQuote(baseRef))
+	for round := 0; err == nil && result.ExitCode != 0; round++ {
+		conflicts := conflictedFiles(runner)
+		status.Conflicts = append(status.Conflicts, conflicts...)
+		if round >= maxRebaseRounds || len(conflicts) == 0 || !resolveConflicts(ctx, runner, task, conflicts, resolve) {
+			runner.RunCommand("git rebase --abort")
+			fmt.Printf("⚔️ Task #%d conflicts with %s in %s, leaving it for a human\n", task.Number, baseRef, strings.Join(status.Conflicts, ", "))
+			return types.MergeStatus{State: types.MergeConflicted, Conflicts: status.Conflicts}
+		}
+		status.State = types.MergeResolved
+		result, err = runner.RunCommand("GIT_EDITOR=true git rebase --continue")
+	}
+	if err != nil {
+		runner.RunCommand("git rebase --abort")
+		fmt.Printf("⚠️ Rebase of task #%d failed, skipping the conflict check: %v\n", task.Number, err)
+		return types.MergeStatus{}
+	}
+
+	// Work that now sits on a different base has to pass verification again,
+	// resolved conflicts most of all
+	after, _ := gitOutput(runner, "git rev-parse HEAD")
+	if strings.TrimSpace(after) == before || verifyCommand == "" {
+		return status
+	}
+	if output, passed := runVerification(runner, verifyCommand); !passed {
+		runner.RunCommand("git reset --hard " + shellQuote(before))
+		fmt.Printf("⚔️ Task #%d fails verification on top of %s, leaving it for a human: %s\n", task.Number, baseRef, output)
+		return types.MergeStatus{State: types.MergeConflicted, Conflicts: status.Conflicts}
+	}
+	if status.State == types.MergeResolved {
+		fmt.Printf("🤝 Resolved task #%d conflicts with %s in %s\n", task.Number, baseRef, strings.Join(status.Conflicts, ", "))
+	}
+	return status
+}
+
+// conflictedFiles lists the files a stopped rebase left with conflicts
+func conflictedFiles(runner commandRunner) []string {
+	result, err := runner.RunCommand("git diff --name-only --diff-filter=U")
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, line := range strings.Split(result.StdOut, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files
+}
+
+// resolveConflicts resolves and stages each conflicted file, reporting whether
+// all of them were simple enough to resolve
+func resolveConflicts(ctx context.Context, runner commandRunner, task *types.EnhancedTask, files []string, resolve resolveFunc) bool {
+	writer, ok := runner.(treeWriter)
+	if resolve == nil || !ok || len(files) > maxConflictFiles {
+		return false
+	}
+
+	resolved := make(map[string][]byte, len(files))
+	for _, file := range files {
+		content, err := runner.RunCommand("cat -- " + shellQuote(file))
+		if err != nil || content.ExitCode != 0 || len(content.StdOut) > maxConflictFileBytes {
+			return false
+		}
+		merged, err := resolve(ctx, task, file, content.StdOut)
+		if err != nil || hasConflictMarkers(merged) {
+			return false
+		}
+		resolved[file] = []byte(merged)
+	}
+	if err := writer.WriteTree(resolved); err != nil {
+		return false
+	}
+	for _, file := range files {
+		if result, err := runner.RunCommand("git add -- " + shellQuote(file)); err != nil || result.ExitCode != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hasConflictMarkers reports whether content still has unresolved conflict markers
+func hasConflictMarkers(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "<<<<<<< ") || strings.HasPrefix(line, ">>>>>>> ") || line == "=======" {
+			return true
+		}
+	}
+	return false
+}
+
+// stripCodeFence removes a markdown code fence the model may wrap a file in
+func stripCodeFence(response string) string {
+	trimmed := strings.TrimSpace(response)
+	if !strings.HasPrefix(trimmed, "```") {
+		return response
+	}
+	trimmed = strings.TrimSuffix(trimmed, "```")
+	if newline := strings.Index(trimmed, "\n"); newline >= 0 {
+		return trimmed[newline+1:]
+	}
+	return ""
+}
+
+// buildResolvePrompt asks the model to merge both sides of a conflicted file
+func buildResolvePrompt(task *types.EnhancedTask, path, content string) string {
+	return fmt.Sprintf(
+		"You are resolving a git merge conflict while rebasing work for a task onto the latest base branch.\n\n"+
+			"TASK: %s\n%s\n\n"+
+			"FILE %s, WITH CONFLICT MARKERS:\n---\n%s\n---\n\n"+
+			"The side after '<<<<<<<' is the base branch's latest version; the side after '=======' is the task's change. "+
+			"Keep the intent of both. Respond with the complete resolved file only, without conflict markers or commentary.",
+		task.Title, task.Description, path, content,
+	)
+}
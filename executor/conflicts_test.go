@@ -0,0 +1,110 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/sandbox"
+)
+
+const conflictedHandler = `package api
+
+<<<<<<< HEAD
+const timeout = 30
+=======
+const timeout = 10
+>>>>>>> feat: resolve task #31
+`
+
+// rebaseRunner acts as a working copy whose base branch has advanced with a
+// conflicting change to api/handler.go
+type rebaseRunner struct {
+	treeRunner
+	head       string
+	verifyFail bool
+}
+
+func (r *rebaseRunner) RunCommand(command string) (*sandbox.CommandResult, error) {
+	r.commandLog.RunCommand(command)
+	switch command {
+	case "git rev-parse HEAD":
+		return &sandbox.CommandResult{StdOut: r.head + "\n"}, nil
+	case "git rebase 'origin/main'":
+		return &sandbox.CommandResult{StdErr: "CONFLICT (content): Merge conflict in api/handler.go", ExitCode: 1}, nil
+	case "git diff --name-only --diff-filter=U":
+		return &sandbox.CommandResult{StdOut: "api/handler.go\n"}, nil
+	case "cat -- 'api/handler.go'":
+		return &sandbox.CommandResult{StdOut: conflictedHandler}, nil
+	case "GIT_EDITOR=true git rebase --continue":
+		r.head = "rebased"
+	case "make test":
+		if r.verifyFail {
+			return &sandbox.CommandResult{StdErr: "FAIL api", ExitCode: 1}, nil
+		}
+	}
+	return &sandbox.CommandResult{}, nil
+}
+
+func (r *rebaseRunner) ran(command string) bool {
+	for _, c := range r.commands {
+		if c == command {
+			return true
+		}
+	}
+	return false
+}
+
+func TestConflictingBaseUpdateTakesConflictPath(t *testing.T) {
+	task := &types.EnhancedTask{Number: 31, Title: "Lower the timeout", Repository: hive.Repository{Branch: "main"}}
+
+	// Without a resolution the rebase is abandoned and the branch left for a human
+	runner := &rebaseRunner{head: "original"}
+	status := syncWithBase(context.Background(), runner, task, nil, "make test")
+	if status.State != types.MergeConflicted || len(status.Conflicts) != 1 || status.Conflicts[0] != "api/handler.go" {
+		t.Fatalf("expected the conflict to be reported, got %+v", status)
+	}
+	if !runner.ran("git rebase --abort") {
+		t.Fatalf("expected the rebase to be aborted, ran %q", runner.commands)
+	}
+
+	// A resolution that still has markers isn't accepted either
+	runner = &rebaseRunner{head: "original"}
+	unresolved := func(ctx context.Context, task *types.EnhancedTask, path, content string) (string, error) {
+		return content, nil
+	}
+	if status := syncWithBase(context.Background(), runner, task, unresolved, "make test"); status.State != types.MergeConflicted || len(runner.writes) != 0 {
+		t.Fatalf("expected a resolution with conflict markers to be rejected, got %+v", status)
+	}
+
+	// A simple conflict is resolved, the rebase finished and the result verified
+	runner = &rebaseRunner{head: "original"}
+	resolve := func(ctx context.Context, task *types.EnhancedTask, path, content string) (string, error) {
+		if !strings.Contains(content, "<<<<<<< HEAD") {
+			t.Errorf("resolver was not given the conflicted file: %q", content)
+		}
+		return "package api\n\nconst timeout = 10\n", nil
+	}
+	status = syncWithBase(context.Background(), runner, task, resolve, "make test")
+	if status.State != types.MergeResolved {
+		t.Fatalf("expected the conflict to be resolved, got %+v", status)
+	}
+	if len(runner.writes) != 1 || string(runner.writes[0]["api/handler.go"]) != "package api\n\nconst timeout = 10\n" {
+		t.Fatalf("expected the resolved file to be written, got %q", runner.writes)
+	}
+	if !runner.ran("git add -- 'api/handler.go'") || !runner.ran("make test") {
+		t.Fatalf("expected the resolution to be staged and verified, ran %q", runner.commands)
+	}
+	if runner.ran("git push") {
+		t.Fatalf("syncing must leave pushing to after the secret scan, ran %q", runner.commands)
+	}
+
+	// A resolution that breaks the build is undone and left for a human
+	runner = &rebaseRunner{head: "original", verifyFail: true}
+	status = syncWithBase(context.Background(), runner, task, resolve, "make test")
+	if status.State != types.MergeConflicted || !runner.ran("git reset --hard 'original'") {
+		t.Fatalf("expected a resolution failing verification to be undone, got %+v after %q", status, runner.commands)
+	}
+}
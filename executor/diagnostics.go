@@ -0,0 +1,208 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/sandbox"
+)
+
+const (
+	// diagnosticsOrigin is a throwaway repository created inside the sandbox to clone from
+	diagnosticsOrigin = "/tmp/bzzz-diagnostics.git"
+	diagnosticsBranch = "bzzz/diagnostics"
+)
+
+// DiagnosticStage is the outcome of one stage of a diagnostics run
+type DiagnosticStage struct {
+	Name     string
+	Passed   bool
+	Skipped  bool // An earlier stage failed
+	Duration time.Duration
+	Detail   string
+}
+
+// DiagnosticsReport is the outcome of a diagnostics run
+type DiagnosticsReport struct {
+	Stages []DiagnosticStage
+}
+
+// Passed reports whether every stage succeeded
+func (r *DiagnosticsReport) Passed() bool {
+	for _, stage := range r.Stages {
+		if !stage.Passed {
+			return false
+		}
+	}
+	return len(r.Stages) > 0
+}
+
+// String summarises the run, one line per stage
+func (r *DiagnosticsReport) String() string {
+	var b strings.Builder
+	for _, stage := range r.Stages {
+		switch {
+		case stage.Skipped:
+			fmt.Fprintf(&b, "⏭️  %-8s skipped\n", stage.Name)
+		case stage.Passed:
+			fmt.Fprintf(&b, "✅ %-8s %8s  %s\n", stage.Name, stage.Duration.Round(time.Millisecond), stage.Detail)
+		default:
+			fmt.Fprintf(&b, "❌ %-8s %8s  %s\n", stage.Name, stage.Duration.Round(time.Millisecond), stage.Detail)
+		}
+	}
+	if r.Passed() {
+		b.WriteString("🩺 Reasoning pipeline is healthy\n")
+	} else {
+		b.WriteString("🩺 Reasoning pipeline is NOT healthy\n")
+	}
+	return b.String()
+}
+
+// diagnosticsSandbox is the part of a sandbox diagnostics drives
+type diagnosticsSandbox interface {
+	commandRunner
+	DestroySandbox() error
+}
+
+// diagnosticsEnv supplies the sandbox and reasoning a diagnostics run uses
+type diagnosticsEnv struct {
+	createSandbox func(ctx context.Context) (diagnosticsSandbox, error)
+	next          nextCommandFunc
+}
+
+// RunDiagnostics puts a canned task ("create hello.txt") through the executor's
+// pipeline in a throwaway sandbox: clone, ask the model for a command, run it and
+// commit the result. No real repository or task is touched.
+func RunDiagnostics(ctx context.Context, agentConfig *config.AgentConfig) *DiagnosticsReport {
+	return runDiagnostics(ctx, diagnosticsEnv{
+		createSandbox: func(ctx context.Context) (diagnosticsSandbox, error) {
+			sb, err := sandbox.CreateSandbox(ctx, "", agentConfig, sandbox.WithNetworkMode(sandbox.NetworkModeNone))
+			if err != nil {
+				return nil, err // Not a nil *Sandbox wrapped in the interface
+			}
+			return sb, nil
+		},
+		next: generateNextCommand,
+	})
+}
+
+// runDiagnostics runs the stages in order; after a failure the rest are skipped
+func runDiagnostics(ctx context.Context, env diagnosticsEnv) *DiagnosticsReport {
+	task := &types.EnhancedTask{
+		Title:       "Create a hello.txt file",
+		Description: "Create a file named hello.txt in the repository root containing the text 'Hello from Bzzz'. Run it as a single shell command.",
+		GitURL:      diagnosticsOrigin,
+		BranchName:  diagnosticsBranch,
+	}
+
+	report := &DiagnosticsReport{}
+	failed := false
+	stage := func(name string, run func() (string, error)) {
+		if failed {
+			report.Stages = append(report.Stages, DiagnosticStage{Name: name, Skipped: true})
+			return
+		}
+		started := time.Now()
+		detail, err := run()
+		result := DiagnosticStage{Name: name, Passed: err == nil, Duration: time.Since(started), Detail: detail}
+		if err != nil {
+			result.Detail = err.Error()
+			failed = true
+		}
+		report.Stages = append(report.Stages, result)
+	}
+
+	var sb diagnosticsSandbox
+	stage("sandbox", func() (string, error) {
+		var err error
+		sb, err = env.createSandbox(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to create sandbox: %w", err)
+		}
+		return "sandbox created", nil
+	})
+	if sb != nil {
+		defer sb.DestroySandbox()
+	}
+
+	stage("clone", func() (string, error) {
+		if err := runChecked(sb, seedDiagnosticsOrigin()); err != nil {
+			return "", fmt.Errorf("failed to create the test repository: %w", err)
+		}
+		if err := cloneRepository(sb, task, config.CloneConfig{}); err != nil {
+			return "", err
+		}
+		return "cloned test repository", nil
+	})
+
+	// The command prompt asks for a confidence rating; it's stripped, not gated on
+	next := confidenceGated(env.next, 0)
+	var command string
+	stage("reason", func() (string, error) {
+		var err error
+		command, err = next(ctx, task, "")
+		if err != nil {
+			return "", fmt.Errorf("model did not answer: %w", err)
+		}
+		if command == "" || strings.HasPrefix(command, "TASK_COMPLETE") || strings.HasPrefix(command, "ITEM_COMPLETE") {
+			return "", fmt.Errorf("model gave no command: %q", command)
+		}
+		return command, nil
+	})
+
+	stage("exec", func() (string, error) {
+		if err := runChecked(sb, command); err != nil {
+			return "", err
+		}
+		if err := runChecked(sb, "test -s hello.txt"); err != nil {
+			return "", fmt.Errorf("command ran but hello.txt was not created")
+		}
+		return "hello.txt created", nil
+	})
+
+	stage("commit", func() (string, error) {
+		stats, err := commitAndPush(sb, task.Number, baseRef(task), diagnosticsBranch, nil)
+		if err != nil {
+			return "", err
+		}
+		if err := runChecked(sb, fmt.Sprintf("git --git-dir=%s rev-parse --verify --quiet %s", diagnosticsOrigin, diagnosticsBranch)); err != nil {
+			return "", fmt.Errorf("commit did not reach the test repository")
+		}
+		return fmt.Sprintf("pushed %d file(s)", stats.FilesChanged), nil
+	})
+
+	return report
+}
+
+// seedDiagnosticsOrigin builds the command that creates the throwaway repository
+func seedDiagnosticsOrigin() string {
+	return strings.Join([]string{
+		"rm -rf " + diagnosticsOrigin + " /tmp/bzzz-diagnostics-seed",
+		"git init -q --bare " + diagnosticsOrigin,
+		"git init -q /tmp/bzzz-diagnostics-seed",
+		"echo '# Bzzz diagnostics' > /tmp/bzzz-diagnostics-seed/README.md",
+		"git -C /tmp/bzzz-diagnostics-seed add README.md",
+		"git -C /tmp/bzzz-diagnostics-seed -c user.name=bzzz -c user.email=bzzz@localhost commit -q -m 'Initial commit'",
+		"git -C /tmp/bzzz-diagnostics-seed push -q " + diagnosticsOrigin + " HEAD:refs/heads/main",
+		"git --git-dir=" + diagnosticsOrigin + " symbolic-ref HEAD refs/heads/main",
+	}, " && ")
+}
+
+// runChecked runs a command, treating a non-zero exit as an error
+func runChecked(runner commandRunner, command string) error {
+	result, err := runner.RunCommand(command)
+	if err != nil {
+		return err
+	}
+	if result.TimedOut {
+		return fmt.Errorf("%q timed out", command)
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("%q exited with code %d: %s", command, result.ExitCode, strings.TrimSpace(result.StdErr))
+	}
+	return nil
+}
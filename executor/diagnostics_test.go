@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/sandbox"
+)
+
+// diagnosticsRunner is a stub sandbox where every command succeeds
+type diagnosticsRunner struct {
+	commands  []string
+	destroyed bool
+}
+
+func (r *diagnosticsRunner) RunCommand(command string) (*sandbox.CommandResult, error) {
+	r.commands = append(r.commands, command)
+	if strings.HasPrefix(command, "git merge-base") {
+		return &sandbox.CommandResult{StdOut: "abc123\n"}, nil
+	}
+	return &sandbox.CommandResult{}, nil
+}
+
+func (r *diagnosticsRunner) DestroySandbox() error {
+	r.destroyed = true
+	return nil
+}
+
+func TestDiagnosticsReportsEachStage(t *testing.T) {
+	runner := &diagnosticsRunner{}
+	env := diagnosticsEnv{
+		createSandbox: func(ctx context.Context) (diagnosticsSandbox, error) { return runner, nil },
+		next: func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+			return "echo 'Hello from Bzzz' > hello.txt", nil
+		},
+	}
+
+	report := runDiagnostics(context.Background(), env)
+	if !report.Passed() {
+		t.Fatalf("expected every stage to pass:\n%s", report)
+	}
+	var names []string
+	for _, stage := range report.Stages {
+		names = append(names, stage.Name)
+	}
+	if strings.Join(names, ",") != "sandbox,clone,reason,exec,commit" {
+		t.Fatalf("unexpected stages: %v", names)
+	}
+	if !runner.destroyed {
+		t.Error("expected the throwaway sandbox to be destroyed")
+	}
+
+	// The rating the prompt asks the model for isn't run as part of the command
+	runner = &diagnosticsRunner{}
+	env.next = func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		return "echo 'Hello from Bzzz' > hello.txt\nCONFIDENCE: 90", nil
+	}
+	if report := runDiagnostics(context.Background(), env); !report.Passed() {
+		t.Fatalf("expected a rated reply to pass:\n%s", report)
+	}
+	for _, command := range runner.commands {
+		if strings.Contains(command, "CONFIDENCE") {
+			t.Fatalf("the confidence rating reached the shell: %q", command)
+		}
+	}
+
+	// A model that can't answer fails the reason stage and skips the rest
+	env.next = func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		return "", errors.New("connection refused")
+	}
+	report = runDiagnostics(context.Background(), env)
+	if report.Passed() {
+		t.Fatal("expected diagnostics to fail without a model")
+	}
+	reason, exec := report.Stages[2], report.Stages[3]
+	if reason.Passed || !strings.Contains(reason.Detail, "connection refused") {
+		t.Errorf("expected the reason stage to fail with the model error, got %+v", reason)
+	}
+	if !exec.Skipped || !report.Stages[4].Skipped {
+		t.Errorf("expected exec and commit to be skipped, got %+v", report.Stages[3:])
+	}
+	if summary := report.String(); !strings.Contains(summary, "NOT healthy") || !strings.Contains(summary, "❌ reason") {
+		t.Errorf("unexpected summary:\n%s", summary)
+	}
+}
@@ -0,0 +1,132 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+)
+
+// changeDiffStats measures everything the task changes since it branched, with
+// git's numstat output
+func changeDiffStats(runner commandRunner, since string) (types.DiffStats, error) {
+	output, err := gitOutput(runner, "git diff --cached --numstat "+since)
+	if err != nil {
+		return types.DiffStats{}, fmt.Errorf("failed to measure the task's changes: %w", err)
+	}
+	return parseNumstat(output), nil
+}
+
+// workingDiffStats measures the task's changes since it branched as they stand
+// in the working tree, including new files nothing has staged yet
+func workingDiffStats(runner commandRunner, since string) (types.DiffStats, error) {
+	if _, err := gitOutput(runner, "git add --all --intent-to-add"); err != nil {
+		return types.DiffStats{}, fmt.Errorf("failed to measure the task's changes: %w", err)
+	}
+	output, err := gitOutput(runner, "git diff --numstat "+since)
+	if err != nil {
+		return types.DiffStats{}, fmt.Errorf("failed to measure the task's changes: %w", err)
+	}
+	return parseNumstat(output), nil
+}
+
+// DiffLimitExceededError is returned when a task's changes outgrow the diff
+// limits before the agent has finished, so it can be split instead
+type DiffLimitExceededError struct {
+	Diff   types.DiffStats
+	Limits config.DiffLimits
+}
+
+func (e *DiffLimitExceededError) Error() string {
+	return fmt.Sprintf("changes outgrew the diff limits before the task was finished: %d files and %d lines changed, limits are %d files and %d lines",
+		e.Diff.FilesChanged, e.Diff.Lines(), e.Limits.MaxFiles, e.Limits.MaxLines)
+}
+
+// exceedsDiffLimits reports whether diff is over either of the limits that are set
+func exceedsDiffLimits(diff types.DiffStats, limits config.DiffLimits) bool {
+	return (limits.MaxFiles > 0 && diff.FilesChanged > limits.MaxFiles) || (limits.MaxLines > 0 && diff.Lines() > limits.MaxLines)
+}
+
+// diffGuardedNext stops the agent before its next command once the task's
+// changes since it branched from base exceed limits. A change that can't be
+// measured is let through; the push measures it again.
+func diffGuardedNext(next nextCommandFunc, runner commandRunner, base string, limits config.DiffLimits) nextCommandFunc {
+	var since string
+	return func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		var err error
+		if since == "" {
+			since, err = mergeBase(runner, base)
+		}
+		var diff types.DiffStats
+		if err == nil {
+			diff, err = workingDiffStats(runner, since)
+		}
+		switch {
+		case err != nil:
+			fmt.Printf("⚠️ Couldn't check task #%d against the diff limits: %v\n", task.Number, err)
+		case exceedsDiffLimits(diff, limits):
+			return "", &DiffLimitExceededError{Diff: diff, Limits: limits}
+		}
+		return next(ctx, task, lastOutput)
+	}
+}
+
+// emptyTree is git's hash of a tree with nothing in it, where the changes to a
+// repository that had no commits start from
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// mergeBase returns the commit the task branched from base at. Diffing the index
+// against it covers commits the agent made itself as well as what is staged.
+func mergeBase(runner commandRunner, base string) (string, error) {
+	output, err := gitOutput(runner, "git merge-base "+shellQuote(base)+" HEAD")
+	if err != nil {
+		if remotes, listErr := gitOutput(runner, "git branch -r"); listErr == nil && strings.TrimSpace(remotes) == "" {
+			return emptyTree, nil // The repository was empty when cloned
+		}
+		return "", fmt.Errorf("failed to find where the task branched from %s: %w", base, err)
+	}
+	since := strings.TrimSpace(output)
+	if since == "" {
+		return "", fmt.Errorf("failed to find where the task branched from %s", base)
+	}
+	return since, nil
+}
+
+// gitOutput runs a git command and returns its output, treating a non-zero exit
+// as an error so a failed diff is never mistaken for an empty one
+func gitOutput(runner commandRunner, command string) (string, error) {
+	result, err := runner.RunCommand(command)
+	if err != nil {
+		return "", err
+	}
+	if result.TimedOut {
+		return "", fmt.Errorf("%q timed out", command)
+	}
+	if result.ExitCode != 0 {
+		return "", fmt.Errorf("%q exited with code %d: %s", command, result.ExitCode, strings.TrimSpace(result.StdErr))
+	}
+	return result.StdOut, nil
+}
+
+// parseNumstat totals `git diff --numstat` lines ("added<TAB>removed<TAB>path").
+// Binary files report "-" for both counts and only count as a changed file.
+func parseNumstat(output string) types.DiffStats {
+	var stats types.DiffStats
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		stats.FilesChanged++
+		if added, err := strconv.Atoi(fields[0]); err == nil {
+			stats.LinesAdded += added
+		}
+		if removed, err := strconv.Atoi(fields[1]); err == nil {
+			stats.LinesRemoved += removed
+		}
+	}
+	return stats
+}
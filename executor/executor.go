@@ -2,13 +2,19 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/budget"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/supervisor"
+	"github.com/anthonyrawlins/bzzz/pkg/tracing"
 	"github.com/anthonyrawlins/bzzz/pkg/types"
 	"github.com/anthonyrawlins/bzzz/reasoning"
 	"github.com/anthonyrawlins/bzzz/sandbox"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const maxIterations = 10 // Prevents infinite loops
@@ -17,101 +23,493 @@ const maxIterations = 10 // Prevents infinite loops
 type ExecuteTaskResult struct {
 	BranchName string
 	Sandbox    *sandbox.Sandbox
+	Diff       types.DiffStats   // Size of the pushed change
+	Merge      types.MergeStatus // How the branch stands against the latest base branch
+	Usage      budget.Usage      // Reasoning spent on the task
+	Transcript *Transcript       // The commands the agent ran and what they returned
 }
 
 // ExecuteTask manages the entire lifecycle of a task using a sandboxed environment.
-// Returns sandbox reference so it can be destroyed after PR creation
-func ExecuteTask(ctx context.Context, task *types.EnhancedTask, hlog *logging.HypercoreLog, agentConfig *config.AgentConfig) (*ExecuteTaskResult, error) {
+// Model usage is counted against budgets, which may be nil. Returns sandbox
+// reference so it can be destroyed after PR creation
+func ExecuteTask(ctx context.Context, task *types.EnhancedTask, hlog *logging.HypercoreLog, agentConfig *config.AgentConfig, budgets *budget.Tracker) (*ExecuteTaskResult, error) {
+	if task.BranchName == "" {
+		return nil, fmt.Errorf("task #%d has no branch; it must be claimed first", task.Number)
+	}
+
+	// Count every generation made for this task against its budget
+	budgetKey := fmt.Sprintf("%d:%d", task.ProjectID, task.Number)
+	ctx = reasoning.WithStatsObserver(ctx, func(stats reasoning.GenerationStats) {
+		budgets.Record(budgetKey, stats)
+	})
+	defer budgets.Finish(budgetKey)
+
+	secretRules, err := compileSecretRules(agentConfig.SecretScan)
+	if err != nil {
+		return nil, err
+	}
+
 	// 1. Create the sandbox environment
-	sb, err := sandbox.CreateSandbox(ctx, "", agentConfig) // Use default image for now
+	sandboxOptions := sandboxNetworkOptions(task, agentConfig)
+	if task.GitHubToken != "" {
+		sandboxOptions = append(sandboxOptions, sandbox.WithGitHubToken(task.GitHubToken))
+	}
+	if env := taskEnv(task); len(env) > 0 {
+		sandboxOptions = append(sandboxOptions, sandbox.WithTaskEnv(env, agentConfig.Sandbox.EnvAllowlist))
+	}
+	sb, err := sandbox.CreateSandbox(ctx, task.Repository.SandboxImage, agentConfig, sandboxOptions...) // Falls back to the default image
 	if err != nil {
 		return nil, fmt.Errorf("failed to create sandbox: %w", err)
 	}
 	// NOTE: Do NOT defer destroy here - let caller handle it
 
-	// 2. Clone the repository inside the sandbox
-	cloneCmd := fmt.Sprintf("git clone %s .", task.GitURL)
-	if _, err := sb.RunCommand(cloneCmd); err != nil {
+	// Watch resource usage for as long as we're driving the sandbox; killing it
+	// for memory cancels the rest of the task
+	ctx, killTask := context.WithCancelCause(ctx)
+	defer killTask(nil)
+	monitorCtx, stopMonitor := context.WithCancel(ctx)
+	defer stopMonitor()
+	supervisor.Go(monitorCtx, "sandbox monitor", func() { monitorSandbox(monitorCtx, sb, task, hlog, agentConfig.Sandbox, killTask) })
+
+	// 2. Clone the repository inside the sandbox, shallow or sparse if configured
+	if err := cloneRepository(sb, task, agentConfig.Clone); err != nil {
 		sb.DestroySandbox() // Clean up on error
 		return nil, fmt.Errorf("failed to clone repository in sandbox: %w", err)
 	}
-	hlog.Append(logging.TaskProgress, map[string]interface{}{"task_id": task.Number, "status": "cloned repo"})
+	hlog.Append(logging.TaskProgress, map[string]interface{}{
+		"task_id":    task.Number,
+		"repository": task.RepositoryName(),
+		"status":     "cloned repo",
+	})
 
-	// 3. The main iterative development loop
-	var lastCommandOutput string
-	for i := 0; i < maxIterations; i++ {
-		// a. Generate the next command based on the task and previous output
-		nextCommand, err := generateNextCommand(ctx, task, lastCommandOutput)
-		if err != nil {
-			sb.DestroySandbox() // Clean up on error
-			return nil, fmt.Errorf("failed to generate next command: %w", err)
-		}
-
-		hlog.Append(logging.TaskProgress, map[string]interface{}{
-			"task_id":   task.Number,
-			"iteration": i,
-			"command":   nextCommand,
-		})
-
-		// b. Check for completion command
-		if strings.HasPrefix(nextCommand, "TASK_COMPLETE") {
-			fmt.Println("✅ Agent has determined the task is complete.")
-			break // Exit loop to proceed with PR creation
-		}
+	startCommit := headCommit(sb) // So a recording of the run can be replayed from the same code
 
-		// c. Execute the command in the sandbox
-		result, err := sb.RunCommand(nextCommand)
-		if err != nil {
-			// Log the error and feed it back to the agent
-			lastCommandOutput = fmt.Sprintf("Command failed: %v\nStdout: %s\nStderr: %s", err, result.StdOut, result.StdErr)
-			continue
-		}
+	// Summarise the repository so the agent's first command is informed
+	task.RepoContext = buildRepoContext(sb)
 
-		// d. Store the output for the next iteration
-		lastCommandOutput = fmt.Sprintf("Stdout: %s\nStderr: %s", result.StdOut, result.StdErr)
+	// Drive the task with the model that has done best on its type, or the selector's choice
+	if task.Model == "" {
+		task.Model = reasoning.SelectModelForTask(task.TaskType, buildCommandPrompt(task, ""))
+	}
+	if task.ReasoningTimeout == 0 {
+		task.ReasoningTimeout = agentConfig.ReasoningTimeout.For(task.TaskType)
 	}
 
-	// 4. Create a new branch and commit the changes
-	branchName := fmt.Sprintf("bzzz-task-%d", task.Number)
-	if _, err := sb.RunCommand(fmt.Sprintf("git checkout -b %s", branchName)); err != nil {
-		sb.DestroySandbox() // Clean up on error
-		return nil, fmt.Errorf("failed to create branch: %w", err)
+	// 3. The main iterative development loop, gated on the verify command
+	verifyCommand := agentConfig.VerifyCommand
+	if task.Repository.VerifyCommand != "" {
+		verifyCommand = task.Repository.VerifyCommand
+	}
+	var runner commandRunner = sb
+	if agentConfig.Clone.Depth > 0 {
+		runner = &unshallowRunner{commandRunner: sb}
 	}
-	if _, err := sb.RunCommand("git add ."); err != nil {
+	review := modelReviewer(reasoning.ReviewerModel())
+	next := plannedNext(generatePlan, generateNextCommand, hlog)
+	next = budgetedNext(confidenceGated(next, agentConfig.Confidence.Threshold(task.TaskType)), budgets, budgetKey)
+	if limits := agentConfig.MaxDiff; task.SplitWhenOversized && (limits.MaxFiles > 0 || limits.MaxLines > 0) {
+		next = diffGuardedNext(next, runner, baseRef(task), limits)
+	}
+	transcript := &Transcript{}
+	before := snapshotTask(task)
+	err = runDevelopmentLoop(ctx, runner, task, hlog, next, verifyCommand, review, transcript)
+	if cause := context.Cause(ctx); err != nil && errors.Is(cause, ErrSandboxMemoryKill) {
+		err = fmt.Errorf("task #%d stopped: %w", task.Number, cause)
+	}
+	recordRun(agentConfig.RecordDir, before, startCommit, verifyCommand, transcript, err)
+	if err != nil {
+		var budgetErr *budget.ExceededError
+		if errors.As(err, &budgetErr) {
+			fmt.Printf("💸 Task #%d aborted: %v\n", task.Number, budgetErr)
+			hlog.Append(logging.TaskFailed, map[string]interface{}{
+				"task_id":    task.Number,
+				"repository": task.RepositoryName(),
+				"reason":     "budget exceeded",
+				"details":    budgetErr.Error(),
+			})
+		}
 		sb.DestroySandbox() // Clean up on error
-		return nil, fmt.Errorf("failed to add files: %w", err)
+		return nil, err
 	}
-	commitCmd := fmt.Sprintf("git commit -m 'feat: resolve task #%d'", task.Number)
-	if _, err := sb.RunCommand(commitCmd); err != nil {
+
+	// 4. Commit the work on the task branch created at claim time
+	branchName := task.BranchName
+	if err := commitWork(runner, task.Number, branchName); err != nil {
 		sb.DestroySandbox() // Clean up on error
-		return nil, fmt.Errorf("failed to commit changes: %w", err)
+		return nil, err
+	}
+
+	// 5. Catch up with the base branch if it moved while we worked, so what gets
+	// scanned, measured and pushed is what the pull request will merge
+	merge := syncWithBase(ctx, runner, task, modelResolver(task.Model), verifyCommand)
+	if merge.State != types.MergeClean && merge.State != "" {
+		hlog.Append(logging.TaskProgress, map[string]interface{}{
+			"task_id":    task.Number,
+			"repository": task.RepositoryName(),
+			"status":     "merge " + merge.State,
+			"conflicts":  merge.Conflicts,
+		})
 	}
 
-	// 5. Push the new branch
-	if _, err := sb.RunCommand(fmt.Sprintf("git push origin %s", branchName)); err != nil {
+	// 6. Scan the changes and push them
+	diff, err := pushWork(runner, task.Number, baseRef(task), branchName, secretRules)
+	if err != nil {
+		var secretsErr *SecretsDetectedError
+		if errors.As(err, &secretsErr) {
+			hlog.Append(logging.TaskFailed, map[string]interface{}{
+				"task_id":    task.Number,
+				"repository": task.RepositoryName(),
+				"reason":     "secrets detected in the task's changes",
+				"details":    secretsErr.Error(),
+			})
+		}
 		sb.DestroySandbox() // Clean up on error
-		return nil, fmt.Errorf("failed to push branch: %w", err)
+		return nil, err
 	}
+	hlog.Append(logging.TaskProgress, map[string]interface{}{
+		"task_id":    task.Number,
+		"repository": task.RepositoryName(),
+		"status":     "pushed changes",
+	})
 
-	hlog.Append(logging.TaskProgress, map[string]interface{}{"task_id": task.Number, "status": "pushed changes"})
 	return &ExecuteTaskResult{
 		BranchName: branchName,
 		Sandbox:    sb,
+		Diff:       diff,
+		Merge:      merge,
+		Usage:      budgets.TaskUsage(budgetKey),
+		Transcript: transcript,
 	}, nil
 }
 
+// taskEnv reads the environment variables a task declares in its "env" context
+func taskEnv(task *types.EnhancedTask) map[string]string {
+	switch value := task.Context["env"].(type) {
+	case map[string]string:
+		return value
+	case map[string]interface{}:
+		env := make(map[string]string, len(value))
+		for name, v := range value {
+			env[name] = fmt.Sprint(v)
+		}
+		return env
+	}
+	return nil
+}
+
+// nextCommandFunc produces the agent's next shell command given the previous output
+type nextCommandFunc func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error)
+
+// budgetedNext stops the agent before its next command once the task or the
+// agent has spent its reasoning budget
+func budgetedNext(next nextCommandFunc, budgets *budget.Tracker, budgetKey string) nextCommandFunc {
+	return func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		if err := budgets.Check(budgetKey, task.TaskType); err != nil {
+			return "", err
+		}
+		return next(ctx, task, lastOutput)
+	}
+}
+
+// VerificationFailedError is returned when the verify command still fails after
+// the agent has used up its iterations
+type VerificationFailedError struct {
+	Command string
+	Output  string
+}
+
+func (e *VerificationFailedError) Error() string {
+	return fmt.Sprintf("verification command %q failed: %s", e.Command, e.Output)
+}
+
+// runDevelopmentLoop lets the agent issue commands until it declares the task
+// complete. When a verify command is set, completion only counts once it passes;
+// when a reviewer is set, it must also approve the diff. A failing run or requested
+// changes are fed back to the agent for another iteration. Each step is recorded
+// in transcript when it is not nil.
+func runDevelopmentLoop(ctx context.Context, runner commandRunner, task *types.EnhancedTask, hlog *logging.HypercoreLog, next nextCommandFunc, verifyCommand string, review reviewFunc, transcript *Transcript) error {
+	if transcript != nil {
+		next = transcript.recording(next)
+	}
+	var lastCommandOutput string
+	for i := 0; i < maxIterations; i++ {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("task #%d cancelled: %w", task.Number, err)
+		}
+
+		iterationCtx, span := tracing.Start(ctx, "executor.iteration", attribute.Int("executor.iteration", i))
+		done, output, err := runIteration(iterationCtx, runner, task, hlog, next, verifyCommand, review, i, lastCommandOutput)
+		tracing.End(span, err)
+		transcript.recordOutput(output)
+		if err != nil || done {
+			return err
+		}
+		lastCommandOutput = output
+	}
+
+	// Out of iterations: only hand the work on if it verifies
+	if verifyCommand == "" {
+		return nil
+	}
+	if output, passed := runVerification(runner, verifyCommand); !passed {
+		return &VerificationFailedError{Command: verifyCommand, Output: output}
+	}
+	return nil
+}
+
+// runIteration asks the agent for one command and acts on it, returning the output
+// to feed back next time. done is set once the agent has finished the task.
+func runIteration(ctx context.Context, runner commandRunner, task *types.EnhancedTask, hlog *logging.HypercoreLog, next nextCommandFunc, verifyCommand string, review reviewFunc, i int, lastOutput string) (done bool, output string, err error) {
+	// a. Generate the next command based on the task and previous output
+	nextCommand, err := next(ctx, task, lastOutput)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to generate next command: %w", err)
+	}
+
+	hlog.Append(logging.TaskProgress, map[string]interface{}{
+		"task_id":    task.Number,
+		"repository": task.RepositoryName(),
+		"iteration":  i,
+		"command":    nextCommand,
+	})
+
+	// b. Check for completion commands
+	if strings.HasPrefix(nextCommand, "ITEM_COMPLETE") {
+		return false, completeChecklistItem(task, hlog), nil
+	}
+	if strings.HasPrefix(nextCommand, "STEP_COMPLETE") {
+		return false, completePlanStep(task, hlog), nil
+	}
+	if strings.HasPrefix(nextCommand, "BATCH") {
+		return false, applyBatch(runner, nextCommand), nil
+	}
+	if strings.HasPrefix(nextCommand, "TASK_COMPLETE") {
+		if verifyCommand != "" {
+			output, passed := runVerification(runner, verifyCommand)
+			if !passed {
+				fmt.Printf("🔁 Verification failed for task #%d, asking the agent to fix it\n", task.Number)
+				hlog.Append(logging.TaskProgress, map[string]interface{}{
+					"task_id":    task.Number,
+					"repository": task.RepositoryName(),
+					"iteration":  i,
+					"status":     "verification failed",
+				})
+				return false, fmt.Sprintf("The task is NOT complete: the verification command `%s` failed. Fix the problems before responding with TASK_COMPLETE.\n%s", verifyCommand, output), nil
+			}
+		}
+
+		if review != nil {
+			if feedback := reviewChanges(ctx, runner, task, review); feedback != "" {
+				fmt.Printf("🔁 Reviewer requested changes for task #%d, asking the agent to address them\n", task.Number)
+				hlog.Append(logging.TaskProgress, map[string]interface{}{
+					"task_id":    task.Number,
+					"repository": task.RepositoryName(),
+					"iteration":  i,
+					"status":     "review requested changes",
+				})
+				return false, feedback, nil
+			}
+		}
+
+		fmt.Println("✅ Agent has determined the task is complete.")
+		return true, "", nil
+	}
+
+	// c. Execute the command in the sandbox
+	result, err := runner.RunCommand(nextCommand)
+	if err != nil {
+		// Log the error and feed it back to the agent
+		return false, fmt.Sprintf("Command failed: %v", err), nil
+	}
+	if result.TimedOut {
+		return false, fmt.Sprintf("Command timed out and was killed. Avoid interactive or long-running commands.\nStdout: %s\nStderr: %s", result.StdOut, result.StdErr), nil
+	}
+	if result.ExitCode != 0 {
+		return false, fmt.Sprintf("Command exited with code %d.\nStdout: %s\nStderr: %s", result.ExitCode, result.StdOut, result.StdErr), nil
+	}
+
+	// d. Store the output for the next iteration
+	return false, fmt.Sprintf("Stdout: %s\nStderr: %s", result.StdOut, result.StdErr), nil
+}
+
+// completeChecklistItem checks off the current checklist item and returns what
+// to tell the agent next
+func completeChecklistItem(task *types.EnhancedTask, hlog *logging.HypercoreLog) string {
+	item, ok := task.NextChecklistItem()
+	if !ok {
+		return "There are no unfinished checklist items. Respond with TASK_COMPLETE if the task is done."
+	}
+
+	item.Done = true
+	fmt.Printf("☑️ Task #%d checklist item %d done: %s\n", task.Number, item.Index+1, item.Text)
+	hlog.Append(logging.TaskProgress, map[string]interface{}{
+		"task_id":        task.Number,
+		"repository":     task.RepositoryName(),
+		"status":         "checklist item complete",
+		"checklist_item": item.Text,
+	})
+	if task.OnChecklistItemDone != nil {
+		task.OnChecklistItemDone(*item)
+	}
+
+	if next, ok := task.NextChecklistItem(); ok {
+		return fmt.Sprintf("Checklist item %q is done. Move on to the next item: %s", item.Text, next.Text)
+	}
+	return "Every checklist item is done. Finish up and respond with TASK_COMPLETE."
+}
+
+// runVerification runs the verify command and reports its output and whether it passed
+func runVerification(runner commandRunner, verifyCommand string) (string, bool) {
+	result, err := runner.RunCommand(verifyCommand)
+	if err != nil {
+		return fmt.Sprintf("Command failed: %v", err), false
+	}
+	output := fmt.Sprintf("Exit code: %d\nStdout: %s\nStderr: %s", result.ExitCode, result.StdOut, result.StdErr)
+	return output, result.ExitCode == 0 && !result.TimedOut
+}
+
+// commandRunner runs shell commands in a task's working copy
+type commandRunner interface {
+	RunCommand(command string) (*sandbox.CommandResult, error)
+}
+
+// commitAndPush commits the agent's work on the task branch and pushes it, returning
+// the size of the change
+func commitAndPush(runner commandRunner, taskNumber int, base, branchName string, secretRules []secretRule) (types.DiffStats, error) {
+	if err := commitWork(runner, taskNumber, branchName); err != nil {
+		return types.DiffStats{}, err
+	}
+	return pushWork(runner, taskNumber, base, branchName, secretRules)
+}
+
+// commitWork commits whatever the agent left uncommitted on the task branch
+func commitWork(runner commandRunner, taskNumber int, branchName string) error {
+	if _, err := runner.RunCommand(fmt.Sprintf("git checkout -B %s", branchName)); err != nil {
+		return fmt.Errorf("failed to create branch: %w", err)
+	}
+	if _, err := runner.RunCommand("git add ."); err != nil {
+		return fmt.Errorf("failed to add files: %w", err)
+	}
+	commitCmd := fmt.Sprintf("git commit -m 'feat: resolve task #%d'", taskNumber)
+	if _, err := runner.RunCommand(commitCmd); err != nil {
+		return fmt.Errorf("failed to commit changes: %w", err)
+	}
+	return nil
+}
+
+// pushWork pushes the task branch, returning the size of the change. Everything
+// the branch changes since it left base, whether committed by the agent or
+// staged, is scanned for secrets first; if any are found the branch is discarded
+// and nothing leaves the sandbox.
+func pushWork(runner commandRunner, taskNumber int, base, branchName string, secretRules []secretRule) (types.DiffStats, error) {
+	since, err := mergeBase(runner, base)
+	if err != nil {
+		return types.DiffStats{}, err
+	}
+
+	if len(secretRules) > 0 {
+		diff, err := gitOutput(runner, "git diff --cached --no-color --unified=0 "+since)
+		if err != nil {
+			return types.DiffStats{}, fmt.Errorf("failed to read the task's changes: %w", err)
+		}
+		if findings := scanDiff(diff, secretRules); len(findings) > 0 {
+			fmt.Printf("🚨 Possible secrets in task #%d changes, aborting push\n", taskNumber)
+			runner.RunCommand(fmt.Sprintf("git reset --hard && git checkout --detach && git branch -D %s", branchName))
+			return types.DiffStats{}, &SecretsDetectedError{Findings: findings}
+		}
+	}
+
+	stats, err := changeDiffStats(runner, since)
+	if err != nil {
+		return types.DiffStats{}, err
+	}
+	if _, err := runner.RunCommand(fmt.Sprintf("git push origin %s", branchName)); err != nil {
+		return types.DiffStats{}, fmt.Errorf("failed to push branch: %w", err)
+	}
+	return stats, nil
+}
+
+// sandboxNetworkOptions restricts networking for tasks from repositories we don't trust
+func sandboxNetworkOptions(task *types.EnhancedTask, agentConfig *config.AgentConfig) []sandbox.Option {
+	for _, owner := range agentConfig.Sandbox.TrustedOwners {
+		if strings.EqualFold(owner, task.Repository.Owner) {
+			return []sandbox.Option{sandbox.WithNetworkMode(agentConfig.Sandbox.NetworkMode)}
+		}
+	}
+
+	// Git still has to clone and push, so the network can't be "none": the
+	// restricted network's only way out is the allowlisted proxy
+	fmt.Printf("🔒 Task #%d is from untrusted owner %q, using restricted sandbox network\n", task.Number, task.Repository.Owner)
+	return []sandbox.Option{sandbox.WithRestrictedNetwork(agentConfig.Sandbox.UntrustedNetwork, agentConfig.Sandbox.ProxyURL)}
+}
+
+// defaultCommandModel drives tasks when no model has been chosen for them
+const defaultCommandModel = "phi3"
+
 // generateNextCommand uses the LLM to decide the next command to execute.
 func generateNextCommand(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
-	prompt := fmt.Sprintf(
+	prompt := buildCommandPrompt(task, lastOutput)
+
+	// Using the main reasoning engine to generate the command
+	model := task.Model
+	if model == "" {
+		model = defaultCommandModel
+	}
+	command, err := generateFor(ctx, task, model, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(command), nil
+}
+
+// generateFor asks a model about a task, allowing it the task's reasoning timeout
+func generateFor(ctx context.Context, task *types.EnhancedTask, model, prompt string) (string, error) {
+	return reasoning.GenerateResponseWithOptions(ctx, model, prompt, reasoning.GenerateOptions{Timeout: task.ReasoningTimeout})
+}
+
+// buildCommandPrompt asks the model for the next shell command given the task,
+// what we know about the repository, and the previous command's output.
+func buildCommandPrompt(task *types.EnhancedTask, lastOutput string) string {
+	repoContext := ""
+	if task.RepoContext != "" {
+		repoContext = "REPOSITORY CONTEXT:\n" + task.RepoContext + "\n"
+	}
+	if len(task.Checklist) > 0 {
+		repoContext += "CHECKLIST (work through the items in order; respond with 'ITEM_COMPLETE' when the current item is done):\n"
+		current, _ := task.NextChecklistItem()
+		for _, item := range task.Checklist {
+			mark := " "
+			if item.Done {
+				mark = "x"
+			}
+			repoContext += fmt.Sprintf("- [%s] %s", mark, item.Text)
+			if current != nil && item.Index == current.Index {
+				repoContext += "  <- current"
+			}
+			repoContext += "\n"
+		}
+		repoContext += "\n"
+	}
+	if len(task.Plan) > 0 {
+		repoContext += "PLAN (work through the steps in order; respond with 'STEP_COMPLETE' when the current step is done):\n" + formatPlan(task) + "\n"
+	}
+	if task.HumanGuidance != "" {
+		repoContext += "HUMAN GUIDANCE (reply to your escalation, follow it over your own plan):\n" + task.HumanGuidance + "\n\n"
+	}
+
+	return fmt.Sprintf(
 		"You are an AI developer agent in the Bzzz P2P distributed development network, working in a sandboxed shell environment.\n\n"+
 			"TASK DETAILS:\n"+
 			"Title: %s\nDescription: %s\n\n"+
+			"%s"+
 			"CAPABILITIES & RESOURCES:\n"+
 			"- You can issue shell commands to solve this GitHub issue\n"+
 			"- You are part of a collaborative P2P mesh with other AI agents\n"+
 			"- If stuck, you can ask for help by using keywords: 'stuck', 'help', 'clarification needed', 'manual intervention'\n"+
 			"- Complex problems automatically escalate to human experts via N8N webhooks\n"+
 			"- You have access to git, build tools, editors, and development utilities\n"+
+			"- To change several files at once, respond with 'BATCH' followed by JSON: {\"files\": [{\"path\": \"...\", \"content\": \"...\"}], \"commands\": [\"...\"]}; the files are written together, then the commands run in order\n"+
 			"- GitHub CLI (gh) is available for creating PRs: use 'gh pr create --title \"title\" --body \"description\"'\n"+
 			"- GitHub authentication is configured automatically\n"+
 			"- Work is preserved even if issues occur - your changes are committed and pushed\n\n"+
@@ -123,15 +521,8 @@ func generateNextCommand(ctx context.Context, task *types.EnhancedTask, lastOutp
 			"PREVIOUS OUTPUT:\n---\n%s\n---\n\n"+
 			"Based on this context, what is the single next shell command you should run?\n"+
 			"If you believe the task is complete and ready for a pull request, respond with 'TASK_COMPLETE'.\n"+
-			"If you need help, include relevant keywords in your response.",
-		task.Title, task.Description, lastOutput,
+			"If you need help, include relevant keywords in your response.\n"+
+			"End your response with a line 'CONFIDENCE: N', where N from 0 to 100 is how sure you are that the command is right.",
+		task.Title, task.Description, repoContext, lastOutput,
 	)
-
-	// Using the main reasoning engine to generate the command
-	command, err := reasoning.GenerateResponse(ctx, "phi3", prompt)
-	if err != nil {
-		return "", err
-	}
-
-	return strings.TrimSpace(command), nil
 }
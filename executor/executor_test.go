@@ -0,0 +1,273 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/budget"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/reasoning"
+	"github.com/anthonyrawlins/bzzz/sandbox"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// verifyRunner fails the verify command until fixed is set by a "fix" command
+type verifyRunner struct {
+	fixed    bool
+	verifies int
+}
+
+func (r *verifyRunner) RunCommand(command string) (*sandbox.CommandResult, error) {
+	switch command {
+	case "make test":
+		r.verifies++
+		if !r.fixed {
+			return &sandbox.CommandResult{StdErr: "FAIL: TestWidget", ExitCode: 1}, nil
+		}
+		return &sandbox.CommandResult{StdOut: "ok"}, nil
+	case "fix":
+		r.fixed = true
+	}
+	return &sandbox.CommandResult{}, nil
+}
+
+func TestDevelopmentLoopRetriesFailedVerification(t *testing.T) {
+	runner := &verifyRunner{}
+	var prompts []string
+	next := func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		prompts = append(prompts, lastOutput)
+		if strings.Contains(lastOutput, "FAIL: TestWidget") {
+			return "fix", nil
+		}
+		return "TASK_COMPLETE", nil
+	}
+
+	task := &types.EnhancedTask{Number: 3, Title: "Fix widget"}
+	hlog := logging.NewHypercoreLog(peer.ID("test"))
+
+	transcript := &Transcript{}
+	if err := runDevelopmentLoop(context.Background(), runner, task, hlog, next, "make test", nil, transcript); err != nil {
+		t.Fatalf("runDevelopmentLoop returned error: %v", err)
+	}
+	if runner.verifies != 2 {
+		t.Fatalf("expected verification to run twice, ran %d times", runner.verifies)
+	}
+	if len(prompts) != 3 || !strings.Contains(prompts[1], "verification command `make test` failed") {
+		t.Fatalf("expected the failed verification to be fed back to the agent, got prompts %q", prompts)
+	}
+	if len(transcript.Steps) != 3 || transcript.Steps[1].Command != "fix" || !strings.Contains(transcript.Steps[0].Output, "FAIL: TestWidget") {
+		t.Fatalf("expected each step and its output in the transcript, got %+v", transcript.Steps)
+	}
+}
+
+func TestDevelopmentLoopReportsPersistentFailure(t *testing.T) {
+	runner := &verifyRunner{}
+	next := func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		return "TASK_COMPLETE", nil
+	}
+
+	task := &types.EnhancedTask{Number: 4, Title: "Never passes"}
+	hlog := logging.NewHypercoreLog(peer.ID("test"))
+
+	err := runDevelopmentLoop(context.Background(), runner, task, hlog, next, "make test", nil, nil)
+	if _, ok := err.(*VerificationFailedError); !ok {
+		t.Fatalf("expected VerificationFailedError, got %v", err)
+	}
+}
+
+func TestDevelopmentLoopChecksOffChecklistItems(t *testing.T) {
+	task := &types.EnhancedTask{
+		Number: 5,
+		Title:  "Split the widget service",
+		Checklist: []types.ChecklistItem{
+			{Index: 0, Text: "Extract the storage layer", Done: true},
+			{Index: 1, Text: "Add a gRPC API"},
+			{Index: 2, Text: "Update the docs"},
+		},
+	}
+	var checked []int
+	task.OnChecklistItemDone = func(item types.ChecklistItem) {
+		checked = append(checked, item.Index)
+	}
+
+	responses := []string{"ITEM_COMPLETE", "ITEM_COMPLETE", "TASK_COMPLETE"}
+	var prompts []string
+	next := func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		prompts = append(prompts, lastOutput)
+		response := responses[0]
+		responses = responses[1:]
+		return response, nil
+	}
+
+	hlog := logging.NewHypercoreLog(peer.ID("test"))
+	if err := runDevelopmentLoop(context.Background(), &verifyRunner{fixed: true}, task, hlog, next, "", nil, nil); err != nil {
+		t.Fatalf("runDevelopmentLoop returned error: %v", err)
+	}
+	if len(checked) != 2 || checked[0] != 1 || checked[1] != 2 {
+		t.Fatalf("expected items 1 and 2 to be checked off in order, got %v", checked)
+	}
+	if !strings.Contains(prompts[1], "Move on to the next item: Update the docs") {
+		t.Fatalf("agent was not pointed at the next item: %q", prompts[1])
+	}
+	if _, remaining := task.NextChecklistItem(); remaining {
+		t.Fatalf("checklist should be finished: %+v", task.Checklist)
+	}
+}
+
+func TestParseNumstatCountsBinaryFiles(t *testing.T) {
+	stats := parseNumstat("12\t3\tmain.go\n-\t-\tlogo.png\n40\t0\tdocs/guide.md\n")
+	if stats.FilesChanged != 3 || stats.LinesAdded != 52 || stats.LinesRemoved != 3 {
+		t.Fatalf("unexpected diff stats: %+v", stats)
+	}
+}
+
+func TestDiffStatsCoverTheAgentsOwnCommits(t *testing.T) {
+	runner := &fakeRunner{}
+	since, err := mergeBase(runner, "origin/main")
+	if err != nil {
+		t.Fatalf("mergeBase returned error: %v", err)
+	}
+	if _, err := changeDiffStats(runner, since); err != nil {
+		t.Fatalf("changeDiffStats returned error: %v", err)
+	}
+	if !runner.ran("git diff --cached --numstat abc123") {
+		t.Fatalf("expected the stats to be measured from where the branch left its base, ran %v", runner.commands)
+	}
+
+	// A diff git couldn't produce is an error, not an empty change
+	runner = &fakeRunner{failing: "git diff"}
+	if _, err := changeDiffStats(runner, since); err == nil {
+		t.Fatal("expected a failed diff to be reported")
+	}
+}
+
+func TestDiffGuardStopsATaskThatOutgrowsTheLimits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	runner := &shellRunner{dir: t.TempDir()}
+	for _, command := range []string{
+		"git init -q && git -c user.name=bzzz -c user.email=bzzz@localhost commit -q --allow-empty -m base",
+		"git update-ref refs/remotes/origin/main HEAD",
+	} {
+		if err := runChecked(runner, command); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	asked := 0
+	next := diffGuardedNext(func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		asked++
+		return "echo", nil
+	}, runner, "origin/main", config.DiffLimits{MaxFiles: 2})
+	task := &types.EnhancedTask{Number: 8}
+
+	runChecked(runner, "touch one two")
+	if _, err := next(context.Background(), task, ""); err != nil {
+		t.Fatalf("expected a change within the limits to carry on, got %v", err)
+	}
+
+	// New files count before anything stages them
+	runChecked(runner, "touch three")
+	_, err := next(context.Background(), task, "")
+	var oversizedErr *DiffLimitExceededError
+	if !errors.As(err, &oversizedErr) {
+		t.Fatalf("expected the guard to trip, got %v", err)
+	}
+	if oversizedErr.Diff.FilesChanged != 3 || asked != 1 {
+		t.Fatalf("expected 3 files changed and the model asked once, got %+v and %d", oversizedErr.Diff, asked)
+	}
+}
+
+func TestDevelopmentLoopAddressesReviewerChanges(t *testing.T) {
+	var prompts []string
+	next := func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		prompts = append(prompts, lastOutput)
+		if strings.Contains(lastOutput, "REVIEW COMMENTS") {
+			return "fix", nil
+		}
+		return "TASK_COMPLETE", nil
+	}
+
+	reviews := 0
+	review := func(ctx context.Context, task *types.EnhancedTask, diff string) (*Review, error) {
+		reviews++
+		if reviews == 1 {
+			return parseReview("REQUEST_CHANGES\nThe widget cache is never invalidated."), nil
+		}
+		return parseReview("APPROVE"), nil
+	}
+
+	task := &types.EnhancedTask{Number: 4, Title: "Cache widgets"}
+	hlog := logging.NewHypercoreLog(peer.ID("test"))
+	if err := runDevelopmentLoop(context.Background(), &verifyRunner{fixed: true}, task, hlog, next, "", review, nil); err != nil {
+		t.Fatalf("runDevelopmentLoop returned error: %v", err)
+	}
+	if reviews != 2 {
+		t.Fatalf("expected the reviewer to be asked twice, asked %d times", reviews)
+	}
+	if len(prompts) != 3 || !strings.Contains(prompts[1], "The widget cache is never invalidated.") {
+		t.Fatalf("expected the review comments to start another iteration, got prompts %q", prompts)
+	}
+}
+
+func TestDevelopmentLoopAbortsOverTokenBudget(t *testing.T) {
+	budgets := budget.NewTracker(config.BudgetConfig{
+		Default:   config.Budget{MaxTokens: 100000},
+		TaskTypes: map[string]config.Budget{"documentation": {MaxTokens: 1000}},
+	})
+	task := &types.EnhancedTask{Number: 5, ProjectID: 7, TaskType: "documentation", Title: "Document the API"}
+
+	// Each command costs 400 tokens of model usage
+	calls := 0
+	next := budgetedNext(func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		calls++
+		budgets.Record("7:5", reasoning.GenerationStats{Model: "phi3", PromptTokens: 300, CompletionTokens: 100})
+		return "ls", nil
+	}, budgets, "7:5")
+
+	hlog := logging.NewHypercoreLog(peer.ID("test"))
+	err := runDevelopmentLoop(context.Background(), &verifyRunner{fixed: true}, task, hlog, next, "", nil, nil)
+
+	var budgetErr *budget.ExceededError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("expected a budget exceeded error, got %v", err)
+	}
+	if budgetErr.Scope != "task" || !strings.Contains(err.Error(), "budget exceeded: 1200 tokens used, limit is 1000") {
+		t.Fatalf("unexpected budget error: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected the agent to stop once over budget, generated %d commands", calls)
+	}
+}
+
+func TestUntrustedTasksGetANetworkThatReachesTheProxy(t *testing.T) {
+	agentConfig := &config.AgentConfig{Sandbox: config.SandboxConfig{
+		NetworkMode:      "bridge",
+		UntrustedNetwork: "bzzz-untrusted",
+		ProxyURL:         "http://bzzz-egress-proxy:3128",
+		TrustedOwners:    []string{"acme"},
+	}}
+	options := func(owner string) *sandbox.Options {
+		task := &types.EnhancedTask{Number: 42, Repository: hive.Repository{Owner: owner, Repository: "widgets"}}
+		applied := &sandbox.Options{}
+		for _, option := range sandboxNetworkOptions(task, agentConfig) {
+			option(applied)
+		}
+		return applied
+	}
+
+	if trusted := options("ACME"); trusted.NetworkMode != "bridge" || trusted.ProxyURL != "" {
+		t.Errorf("expected a trusted owner's task on the usual network, got %+v", trusted)
+	}
+	untrusted := options("mallory")
+	if untrusted.NetworkMode != "bzzz-untrusted" || untrusted.ProxyURL != "http://bzzz-egress-proxy:3128" {
+		t.Errorf("expected an untrusted task on the restricted network with the proxy, got %+v", untrusted)
+	}
+}
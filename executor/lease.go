@@ -0,0 +1,41 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RenewFunc extends the lease on the task being executed
+type RenewFunc func(ctx context.Context) error
+
+// StartLeaseRenewer renews a task's claim lease every interval until the returned
+// stop function is called or ctx is done. A failed renewal is retried on the
+// next tick; if the agent dies the renewals stop and the lease lapses so another
+// agent can reclaim the task.
+func StartLeaseRenewer(ctx context.Context, taskNumber int, interval time.Duration, renew RenewFunc) (stop func()) {
+	renewCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-renewCtx.Done():
+				return
+			case <-ticker.C:
+				if err := renew(renewCtx); err != nil && renewCtx.Err() == nil {
+					fmt.Printf("⚠️ Failed to renew claim lease for task #%d: %v\n", taskNumber, err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,105 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/sandbox"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	sandboxCPUPercent = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bzzz_sandbox_cpu_percent",
+		Help: "CPU usage of a task sandbox as a percentage of one core.",
+	}, []string{"project", "task"})
+	sandboxMemoryBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bzzz_sandbox_memory_bytes",
+		Help: "Memory in use by a task sandbox, excluding page cache.",
+	}, []string{"project", "task"})
+	sandboxNetworkBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bzzz_sandbox_network_bytes",
+		Help: "Network bytes transferred by a task sandbox.",
+	}, []string{"project", "task", "direction"})
+	sandboxMemoryKills = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "bzzz_sandbox_memory_kills_total",
+		Help: "Sandboxes killed for approaching their memory limit.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(sandboxCPUPercent, sandboxMemoryBytes, sandboxNetworkBytes, sandboxMemoryKills)
+}
+
+// ErrSandboxMemoryKill is the cause of a task's context being cancelled when its
+// sandbox was killed for approaching its memory limit
+var ErrSandboxMemoryKill = errors.New("sandbox killed for approaching its memory limit")
+
+// monitorSandbox periodically samples sandbox resource usage until ctx is done.
+// If memory usage crosses the configured threshold the container is killed so a
+// runaway task can't take the host down with it, and the task is cancelled
+// through killTask so it stops driving a dead sandbox.
+func monitorSandbox(ctx context.Context, sb *sandbox.Sandbox, task *types.EnhancedTask, hlog *logging.HypercoreLog, cfg config.SandboxConfig, killTask context.CancelCauseFunc) {
+	interval := cfg.StatsInterval
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	taskNumber := task.Number
+	project, number := strconv.Itoa(task.ProjectID), strconv.Itoa(task.Number)
+	defer func() {
+		sandboxCPUPercent.DeleteLabelValues(project, number)
+		sandboxMemoryBytes.DeleteLabelValues(project, number)
+		sandboxNetworkBytes.DeleteLabelValues(project, number, "rx")
+		sandboxNetworkBytes.DeleteLabelValues(project, number, "tx")
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stats, err := sb.Stats()
+		if err != nil {
+			fmt.Printf("⚠️ Failed to read sandbox stats for task #%d: %v\n", taskNumber, err)
+			continue
+		}
+
+		fmt.Printf("📈 Sandbox task #%d: cpu=%.1f%% mem=%d/%d MiB net rx=%d tx=%d bytes\n",
+			taskNumber, stats.CPUPercent, stats.MemoryUsage/(1024*1024), stats.MemoryLimit/(1024*1024),
+			stats.NetworkRx, stats.NetworkTx)
+
+		sandboxCPUPercent.WithLabelValues(project, number).Set(stats.CPUPercent)
+		sandboxMemoryBytes.WithLabelValues(project, number).Set(float64(stats.MemoryUsage))
+		sandboxNetworkBytes.WithLabelValues(project, number, "rx").Set(float64(stats.NetworkRx))
+		sandboxNetworkBytes.WithLabelValues(project, number, "tx").Set(float64(stats.NetworkTx))
+
+		if cfg.MemoryKillThreshold > 0 && stats.MemoryFraction() >= cfg.MemoryKillThreshold {
+			fmt.Printf("💀 Sandbox for task #%d is at %.0f%% of its memory limit, killing it\n",
+				taskNumber, stats.MemoryFraction()*100)
+			hlog.Append(logging.TaskFailed, map[string]interface{}{
+				"task_id":      taskNumber,
+				"repository":   task.RepositoryName(),
+				"reason":       "sandbox memory limit",
+				"memory_usage": stats.MemoryUsage,
+				"memory_limit": stats.MemoryLimit,
+			})
+			sandboxMemoryKills.Inc()
+			if err := sb.Kill(); err != nil {
+				fmt.Printf("❌ %v\n", err)
+			}
+			killTask(ErrSandboxMemoryKill)
+			return
+		}
+	}
+}
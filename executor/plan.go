@@ -0,0 +1,170 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+)
+
+const (
+	maxStepFailures = 3 // Failed commands in a row before the agent re-plans
+	maxReplans      = 2 // Re-plans per task, so a hopeless task can't plan forever
+)
+
+// planStepPattern matches a numbered plan line such as "1. Run the tests" or "2) Fix the handler"
+var planStepPattern = regexp.MustCompile(`^\s*\d+[.)]\s+(.+)$`)
+
+// planFunc asks the model for a numbered plan for the task. failure describes why
+// the previous plan went wrong, or is empty for the first plan.
+type planFunc func(ctx context.Context, task *types.EnhancedTask, failure string) ([]string, error)
+
+// plannedNext has the agent plan the task before its first command, and plan again
+// when the commands for a step keep failing. Without a plan, next works as before.
+func plannedNext(plan planFunc, next nextCommandFunc, hlog *logging.HypercoreLog) nextCommandFunc {
+	planned, failures, replans := false, 0, 0
+	return func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		switch {
+		case !planned:
+			planned = true
+			makePlan(ctx, plan, task, hlog, "")
+		case commandFailed(lastOutput):
+			failures++
+			if failures >= maxStepFailures && replans < maxReplans && len(task.Plan) > 0 {
+				failures = 0
+				replans++
+				failure := fmt.Sprintf("Step %d (%s) failed %d times in a row. Last output:\n%s", task.PlanStep+1, task.Plan[task.PlanStep], maxStepFailures, lastOutput)
+				makePlan(ctx, plan, task, hlog, failure)
+			}
+		default:
+			failures = 0
+		}
+		return next(ctx, task, lastOutput)
+	}
+}
+
+// makePlan replaces the task's plan with a fresh one. Planning is best effort:
+// if it fails the agent carries on with its current plan, or none.
+func makePlan(ctx context.Context, plan planFunc, task *types.EnhancedTask, hlog *logging.HypercoreLog, failure string) {
+	steps, err := plan(ctx, task, failure)
+	if err != nil {
+		fmt.Printf("⚠️ Failed to plan task #%d, continuing without a new plan: %v\n", task.Number, err)
+		return
+	}
+
+	task.Plan, task.PlanStep = steps, 0
+	status := "planned"
+	if failure != "" {
+		status = "re-planned"
+	}
+	fmt.Printf("🗺️ Task #%d %s:\n%s", task.Number, status, formatPlan(task))
+	hlog.Append(logging.TaskProgress, map[string]interface{}{
+		"task_id":    task.Number,
+		"repository": task.RepositoryName(),
+		"status":     status,
+		"plan":       steps,
+	})
+	if task.OnPlanned != nil {
+		task.OnPlanned(steps)
+	}
+}
+
+// completePlanStep moves on to the next plan step and returns what to tell the agent next
+func completePlanStep(task *types.EnhancedTask, hlog *logging.HypercoreLog) string {
+	if task.PlanStep >= len(task.Plan) {
+		return "There are no plan steps left. Respond with TASK_COMPLETE if the task is done."
+	}
+
+	done := task.Plan[task.PlanStep]
+	task.PlanStep++
+	fmt.Printf("👣 Task #%d plan step %d done: %s\n", task.Number, task.PlanStep, done)
+	hlog.Append(logging.TaskProgress, map[string]interface{}{
+		"task_id":    task.Number,
+		"repository": task.RepositoryName(),
+		"status":     "plan step complete",
+		"plan_step":  done,
+	})
+
+	if task.PlanStep < len(task.Plan) {
+		return fmt.Sprintf("Step %q is done. Move on to step %d: %s", done, task.PlanStep+1, task.Plan[task.PlanStep])
+	}
+	return "Every plan step is done. Finish up and respond with TASK_COMPLETE."
+}
+
+// commandFailed reports whether the output fed back to the agent is a failure
+func commandFailed(output string) bool {
+	for _, prefix := range []string{"Command failed", "Command timed out", "Command exited with code", "The task is NOT complete"} {
+		if strings.HasPrefix(output, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatPlan lists the plan's steps, numbered, marking done and current steps
+func formatPlan(task *types.EnhancedTask) string {
+	var b strings.Builder
+	for i, step := range task.Plan {
+		mark := " "
+		if i < task.PlanStep {
+			mark = "x"
+		}
+		fmt.Fprintf(&b, "%d. [%s] %s", i+1, mark, step)
+		if i == task.PlanStep {
+			b.WriteString("  <- current")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// generatePlan asks the task's model for a numbered plan
+func generatePlan(ctx context.Context, task *types.EnhancedTask, failure string) ([]string, error) {
+	model := task.Model
+	if model == "" {
+		model = defaultCommandModel
+	}
+	response, err := generateFor(ctx, task, model, buildPlanPrompt(task, failure))
+	if err != nil {
+		return nil, err
+	}
+	steps := parsePlan(response)
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("model returned no numbered steps: %q", response)
+	}
+	return steps, nil
+}
+
+// parsePlan extracts the numbered steps from a model's plan
+func parsePlan(response string) []string {
+	var steps []string
+	for _, line := range strings.Split(response, "\n") {
+		if match := planStepPattern.FindStringSubmatch(line); match != nil {
+			steps = append(steps, strings.TrimSpace(match[1]))
+		}
+	}
+	return steps
+}
+
+// buildPlanPrompt asks the model to break the task into steps before it runs any commands
+func buildPlanPrompt(task *types.EnhancedTask, failure string) string {
+	var b strings.Builder
+	b.WriteString("You are an AI developer agent in the Bzzz P2P distributed development network, about to work on a task in a sandboxed shell environment.\n\n")
+	fmt.Fprintf(&b, "TASK DETAILS:\nTitle: %s\nDescription: %s\n\n", task.Title, task.Description)
+	if task.RepoContext != "" {
+		b.WriteString("REPOSITORY CONTEXT:\n" + task.RepoContext + "\n")
+	}
+	if task.HumanGuidance != "" {
+		b.WriteString("HUMAN GUIDANCE (follow it over your own ideas):\n" + task.HumanGuidance + "\n\n")
+	}
+	if failure != "" {
+		b.WriteString("YOUR PREVIOUS PLAN:\n" + formatPlan(task) + "\nIT WENT WRONG:\n" + failure + "\n\n")
+		b.WriteString("Make a new plan that gets around the problem, starting from where the work is now.\n")
+	}
+	b.WriteString("Write a short plan for completing the task as a numbered list, one concrete step per line (e.g. \"1. Find where the config is parsed\").\n")
+	b.WriteString("Respond with the numbered list only.")
+	return b.String()
+}
@@ -0,0 +1,97 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/sandbox"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestAgentPlansBeforeItsFirstCommand(t *testing.T) {
+	var planned [][]string
+	plan := func(ctx context.Context, task *types.EnhancedTask, failure string) ([]string, error) {
+		return parsePlan("Here is my plan:\n1. Run the failing test\n2) Fix the parser\n3. Re-run the tests"), nil
+	}
+	// The agent follows whatever step the prompt marks as current
+	next := func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		prompt := buildCommandPrompt(task, lastOutput)
+		switch {
+		case strings.Contains(prompt, "Run the failing test  <- current"):
+			if strings.Contains(lastOutput, "FAIL") {
+				return "STEP_COMPLETE", nil
+			}
+			return "go test ./parser", nil
+		case strings.Contains(prompt, "Fix the parser  <- current"):
+			return "STEP_COMPLETE", nil
+		}
+		return "TASK_COMPLETE", nil
+	}
+
+	task := &types.EnhancedTask{Number: 12, Title: "Parser crashes on empty input"}
+	task.OnPlanned = func(plan []string) { planned = append(planned, plan) }
+	hlog := logging.NewHypercoreLog(peer.ID("test"))
+	runner := &scriptedRunner{results: map[string]*sandbox.CommandResult{
+		"go test ./parser": {StdOut: "FAIL: TestEmpty", ExitCode: 1},
+	}}
+
+	transcript := &Transcript{}
+	if err := runDevelopmentLoop(context.Background(), runner, task, hlog, plannedNext(plan, next, hlog), "", nil, transcript); err != nil {
+		t.Fatalf("runDevelopmentLoop returned error: %v", err)
+	}
+
+	if len(planned) != 1 || len(task.Plan) != 3 || task.Plan[1] != "Fix the parser" {
+		t.Fatalf("expected a three step plan to be made once, got %q (shared %d times)", task.Plan, len(planned))
+	}
+	if len(transcript.Steps) == 0 || transcript.Steps[0].Command != "go test ./parser" {
+		t.Fatalf("expected the first command to carry out step one, got %+v", transcript.Steps)
+	}
+	if task.PlanStep != 2 {
+		t.Errorf("expected two steps to be completed, at step %d", task.PlanStep)
+	}
+}
+
+func TestAgentReplansWhenStepKeepsFailing(t *testing.T) {
+	var failures []string
+	plan := func(ctx context.Context, task *types.EnhancedTask, failure string) ([]string, error) {
+		failures = append(failures, failure)
+		if failure == "" {
+			return []string{"Install the linter"}, nil
+		}
+		return []string{"Use the vendored linter"}, nil
+	}
+	next := func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		if task.Plan[0] == "Use the vendored linter" {
+			return "TASK_COMPLETE", nil
+		}
+		return "apt-get install linter", nil
+	}
+
+	task := &types.EnhancedTask{Number: 13, Title: "Lint the repo"}
+	hlog := logging.NewHypercoreLog(peer.ID("test"))
+	runner := &scriptedRunner{results: map[string]*sandbox.CommandResult{
+		"apt-get install linter": {StdErr: "permission denied", ExitCode: 100},
+	}}
+
+	if err := runDevelopmentLoop(context.Background(), runner, task, hlog, plannedNext(plan, next, hlog), "", nil, nil); err != nil {
+		t.Fatalf("runDevelopmentLoop returned error: %v", err)
+	}
+	if len(failures) != 2 || !strings.Contains(failures[1], "failed 3 times") || !strings.Contains(failures[1], "permission denied") {
+		t.Fatalf("expected one re-plan after three failures, got %q", failures)
+	}
+}
+
+// scriptedRunner returns canned results by command, succeeding silently otherwise
+type scriptedRunner struct {
+	results map[string]*sandbox.CommandResult
+}
+
+func (r *scriptedRunner) RunCommand(command string) (*sandbox.CommandResult, error) {
+	if result, ok := r.results[command]; ok {
+		return result, nil
+	}
+	return &sandbox.CommandResult{}, nil
+}
@@ -0,0 +1,191 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/sandbox"
+)
+
+// Recording is a task run saved so a developer can replay it without the model
+type Recording struct {
+	Task          *types.EnhancedTask `json:"task"`             // As it was before the run, without its GitHub token
+	Commit        string              `json:"commit,omitempty"` // The commit the run started from
+	VerifyCommand string              `json:"verify_command,omitempty"`
+	Steps         []TranscriptStep    `json:"steps"`           // The model's responses in order, with their output
+	Error         string              `json:"error,omitempty"` // How the run failed, if it did
+	RecordedAt    time.Time           `json:"recorded_at"`
+}
+
+// snapshotTask copies a task before its run changes it, dropping its credentials
+// and the values of its environment variables
+func snapshotTask(task *types.EnhancedTask) *types.EnhancedTask {
+	snapshot := *task
+	snapshot.GitHubToken = ""
+	snapshot.OnChecklistItemDone = nil
+	snapshot.OnPlanned = nil
+	snapshot.Checklist = append([]types.ChecklistItem(nil), task.Checklist...)
+	if env := taskEnv(task); len(env) > 0 {
+		snapshot.Context = make(map[string]interface{}, len(task.Context))
+		for key, value := range task.Context {
+			snapshot.Context[key] = value
+		}
+		names := make(map[string]string, len(env))
+		for name := range env {
+			names[name] = ""
+		}
+		snapshot.Context["env"] = names
+	}
+	return &snapshot
+}
+
+// saveRecording writes a run to dir, named after its task, and returns the path
+func saveRecording(dir string, recording *Recording) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	data, err := json.MarshalIndent(recording, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode recording: %w", err)
+	}
+	name := fmt.Sprintf("task-%d-%d-%d.json", recording.Task.ProjectID, recording.Task.Number, recording.RecordedAt.Unix())
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write recording: %w", err)
+	}
+	return path, nil
+}
+
+// recordRun saves a run when agent.record_dir is set; failing to is only logged
+func recordRun(dir string, task *types.EnhancedTask, commit, verifyCommand string, transcript *Transcript, runErr error) {
+	if dir == "" {
+		return
+	}
+	recording := &Recording{Task: task, Commit: commit, VerifyCommand: verifyCommand, Steps: transcript.Steps, RecordedAt: time.Now()}
+	if runErr != nil {
+		recording.Error = runErr.Error()
+	}
+	path, err := saveRecording(dir, recording)
+	if err != nil {
+		fmt.Printf("⚠️ Failed to record task #%d: %v\n", task.Number, err)
+		return
+	}
+	fmt.Printf("📼 Recorded task #%d run to %s\n", task.Number, path)
+}
+
+// headCommit returns the commit checked out in the working copy, or "" if git
+// can't tell
+func headCommit(runner commandRunner) string {
+	output, err := gitOutput(runner, "git rev-parse HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(output)
+}
+
+// checkoutCommit puts the working copy on commit, fetching it first if the clone
+// doesn't have it, e.g. because the branch has moved on since
+func checkoutCommit(runner commandRunner, commit string) error {
+	checkout := "git checkout -q --detach " + shellQuote(commit)
+	if runChecked(runner, checkout) == nil {
+		return nil
+	}
+	if err := runChecked(runner, "git fetch -q origin "+shellQuote(commit)); err != nil {
+		return fmt.Errorf("failed to fetch recorded commit %s: %w", commit, err)
+	}
+	if err := runChecked(runner, checkout); err != nil {
+		return fmt.Errorf("failed to check out recorded commit %s: %w", commit, err)
+	}
+	return nil
+}
+
+// LoadRecording reads a run saved with agent.record_dir
+func LoadRecording(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	var recording Recording
+	if err := json.Unmarshal(data, &recording); err != nil {
+		return nil, fmt.Errorf("failed to parse recording %s: %w", path, err)
+	}
+	if recording.Task == nil {
+		return nil, fmt.Errorf("recording %s has no task", path)
+	}
+	return &recording, nil
+}
+
+// replayedResponses stands in for the model, answering with the recorded responses in order
+func (r *Recording) replayedResponses() nextCommandFunc {
+	next := 0
+	return func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		if next >= len(r.Steps) {
+			return "", fmt.Errorf("recording has no response for step %d", next+1)
+		}
+		command := r.Steps[next].Command
+		next++
+		return command, nil
+	}
+}
+
+// replay re-runs the recorded development loop on runner. Reviews aren't
+// recorded, so the replay runs without the reviewer model.
+func (r *Recording) replay(ctx context.Context, runner commandRunner, task *types.EnhancedTask, hlog *logging.HypercoreLog) (*Transcript, error) {
+	transcript := &Transcript{}
+	err := runDevelopmentLoop(ctx, runner, task, hlog, r.replayedResponses(), r.VerifyCommand, nil, transcript)
+	return transcript, err
+}
+
+// ReplayTask reproduces a recorded run in a fresh sandbox, feeding the executor
+// the recorded model responses instead of asking Ollama. Set the task's
+// GitHubToken first if its repository is private. The replay starts from the
+// commit the run did, even if the branch has moved on. Recordings keep only the
+// names of task environment variables; their values come from the local
+// environment. Nothing is committed or pushed.
+func ReplayTask(ctx context.Context, recording *Recording, agentConfig *config.AgentConfig, hlog *logging.HypercoreLog) (*Transcript, error) {
+	task := snapshotTask(recording.Task)
+	task.GitHubToken = recording.Task.GitHubToken
+	if env := taskEnv(task); len(env) > 0 {
+		for name := range env {
+			env[name] = os.Getenv(name)
+		}
+		task.Context["env"] = env
+	}
+
+	options := sandboxNetworkOptions(task, agentConfig)
+	if task.GitHubToken != "" {
+		options = append(options, sandbox.WithGitHubToken(task.GitHubToken))
+	}
+	if env := taskEnv(task); len(env) > 0 {
+		options = append(options, sandbox.WithTaskEnv(env, agentConfig.Sandbox.EnvAllowlist))
+	}
+	sb, err := sandbox.CreateSandbox(ctx, task.Repository.SandboxImage, agentConfig, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox: %w", err)
+	}
+	defer sb.DestroySandbox()
+
+	if err := cloneRepository(sb, task, agentConfig.Clone); err != nil {
+		return nil, fmt.Errorf("failed to clone repository in sandbox: %w", err)
+	}
+	if recording.Commit != "" {
+		if err := checkoutCommit(sb, recording.Commit); err != nil {
+			return nil, err
+		}
+	}
+	task.RepoContext = buildRepoContext(sb)
+
+	var runner commandRunner = sb
+	if agentConfig.Clone.Depth > 0 {
+		runner = &unshallowRunner{commandRunner: sb}
+	}
+	return recording.replay(ctx, runner, task, hlog)
+}
@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestReplayReproducesRecordedCommands(t *testing.T) {
+	hlog := logging.NewHypercoreLog(peer.ID("test"))
+	task := &types.EnhancedTask{Number: 42, ProjectID: 7, Title: "Fix the flaky test", GitHubToken: "ghp_secret"}
+
+	responses := []string{"ls -la", "go test ./... -run TestFlaky", "sed -i 's/Sleep(1)/Sleep(10)/' flaky_test.go", "TASK_COMPLETE"}
+	model := func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		response := responses[0]
+		responses = responses[1:]
+		return response, nil
+	}
+
+	// Record a run
+	recorded := &fakeRunner{}
+	transcript := &Transcript{}
+	before := snapshotTask(task)
+	err := runDevelopmentLoop(context.Background(), recorded, task, hlog, model, "", nil, transcript)
+	if err != nil {
+		t.Fatalf("recorded run failed: %v", err)
+	}
+	dir := t.TempDir()
+	recordRun(dir, before, "abc123", "", transcript, err)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "task-7-42-*.json"))
+	if len(files) != 1 {
+		t.Fatalf("expected one recording, got %v", files)
+	}
+	if data, _ := os.ReadFile(files[0]); strings.Contains(string(data), "ghp_secret") {
+		t.Fatal("recording contains the task's GitHub token")
+	}
+
+	// Replay it without the model
+	recording, err := LoadRecording(files[0])
+	if err != nil {
+		t.Fatalf("failed to load recording: %v", err)
+	}
+	if recording.Commit != "abc123" {
+		t.Fatalf("expected the recording to keep the commit the run started from, got %q", recording.Commit)
+	}
+	replayed := &fakeRunner{}
+	if _, err := recording.replay(context.Background(), replayed, snapshotTask(recording.Task), hlog); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+
+	if strings.Join(replayed.commands, "\n") != strings.Join(recorded.commands, "\n") {
+		t.Fatalf("replay ran %q, recorded run ran %q", replayed.commands, recorded.commands)
+	}
+	if len(replayed.commands) != 3 {
+		t.Fatalf("expected the three recorded commands, got %q", replayed.commands)
+	}
+}
+
+func TestReplayChecksOutTheRecordedCommit(t *testing.T) {
+	// The branch has moved on, so the commit has to be fetched before it can be checked out
+	runner := &fakeRunner{failing: "git checkout"}
+	if err := checkoutCommit(runner, "abc123"); err == nil {
+		t.Fatal("expected a commit that can't be checked out to fail the replay")
+	}
+	if !runner.ran("git fetch -q origin 'abc123'") {
+		t.Fatalf("expected the missing commit to be fetched, ran %q", runner.commands)
+	}
+
+	runner = &fakeRunner{}
+	if err := checkoutCommit(runner, "abc123"); err != nil || runner.ran("git fetch") {
+		t.Fatalf("expected a commit the clone has to be checked out directly, ran %q, err %v", runner.commands, err)
+	}
+}
@@ -0,0 +1,109 @@
+package executor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Upper bound on how much of a file is quoted into the prompt
+const repoContextFileLines = 20
+
+// languageMarkers maps files at the repository root to the language and build system they imply
+var languageMarkers = []struct {
+	file     string
+	language string
+}{
+	{"go.mod", "Go (go modules)"},
+	{"package.json", "JavaScript/TypeScript (npm)"},
+	{"Cargo.toml", "Rust (cargo)"},
+	{"pyproject.toml", "Python (pyproject)"},
+	{"requirements.txt", "Python (pip)"},
+	{"pom.xml", "Java (maven)"},
+	{"build.gradle", "Java/Kotlin (gradle)"},
+	{"Gemfile", "Ruby (bundler)"},
+}
+
+var makeTarget = regexp.MustCompile(`(?m)^([A-Za-z0-9_.-]+):`)
+
+// buildRepoContext inspects a freshly cloned working copy and summarises its
+// language, build system and layout so the agent doesn't start blind.
+func buildRepoContext(runner commandRunner) string {
+	listing, ok := runQuiet(runner, "ls -1A")
+	if !ok {
+		return ""
+	}
+	files := make(map[string]bool)
+	var entries []string
+	for _, name := range strings.Split(strings.TrimSpace(listing), "\n") {
+		if name = strings.TrimSpace(name); name != "" && name != ".git" {
+			files[name] = true
+			entries = append(entries, name)
+		}
+	}
+
+	var summary strings.Builder
+	var languages []string
+	for _, marker := range languageMarkers {
+		if files[marker.file] {
+			languages = append(languages, marker.language)
+		}
+	}
+	if len(languages) > 0 {
+		fmt.Fprintf(&summary, "Language: %s\n", strings.Join(languages, ", "))
+	}
+
+	if files["go.mod"] {
+		if goMod, ok := runQuiet(runner, headCommand("go.mod")); ok {
+			for _, line := range strings.Split(goMod, "\n") {
+				line = strings.TrimSpace(line)
+				if strings.HasPrefix(line, "module ") || strings.HasPrefix(line, "go ") {
+					fmt.Fprintf(&summary, "go.mod: %s\n", line)
+				}
+			}
+		}
+	}
+	if files["package.json"] {
+		if packageJSON, ok := runQuiet(runner, headCommand("package.json")); ok {
+			fmt.Fprintf(&summary, "package.json:\n%s\n", strings.TrimSpace(packageJSON))
+		}
+	}
+	if files["Makefile"] {
+		if makefile, ok := runQuiet(runner, "cat Makefile"); ok {
+			var targets []string
+			for _, m := range makeTarget.FindAllStringSubmatch(makefile, -1) {
+				if !strings.HasPrefix(m[1], ".") {
+					targets = append(targets, m[1])
+				}
+			}
+			if len(targets) > 0 {
+				fmt.Fprintf(&summary, "Makefile targets: %s\n", strings.Join(targets, ", "))
+			}
+		}
+	}
+	for _, readme := range []string{"README.md", "README", "README.rst"} {
+		if files[readme] {
+			if text, ok := runQuiet(runner, headCommand(readme)); ok {
+				fmt.Fprintf(&summary, "%s (start):\n%s\n", readme, strings.TrimSpace(text))
+			}
+			break
+		}
+	}
+
+	fmt.Fprintf(&summary, "Top-level entries: %s\n", strings.Join(entries, " "))
+	return summary.String()
+}
+
+// headCommand prints the start of a file
+func headCommand(file string) string {
+	return fmt.Sprintf("head -n %d %s", repoContextFileLines, file)
+}
+
+// runQuiet runs a command and returns its stdout if it succeeded
+func runQuiet(runner commandRunner, command string) (string, bool) {
+	result, err := runner.RunCommand(command)
+	if err != nil || result.ExitCode != 0 || result.TimedOut {
+		return "", false
+	}
+	return result.StdOut, true
+}
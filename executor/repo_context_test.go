@@ -0,0 +1,64 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/sandbox"
+)
+
+// fileRunner answers ls and head/cat commands from an in-memory working copy
+type fileRunner map[string]string
+
+func (fr fileRunner) RunCommand(command string) (*sandbox.CommandResult, error) {
+	if command == "ls -1A" {
+		var names []string
+		for name := range fr {
+			names = append(names, name)
+		}
+		return &sandbox.CommandResult{StdOut: ".git\n" + strings.Join(names, "\n") + "\n"}, nil
+	}
+	fields := strings.Fields(command)
+	if content, ok := fr[fields[len(fields)-1]]; ok {
+		return &sandbox.CommandResult{StdOut: content}, nil
+	}
+	return &sandbox.CommandResult{StdErr: "No such file", ExitCode: 1}, nil
+}
+
+func TestRepoContextDetectsGoModule(t *testing.T) {
+	runner := fileRunner{
+		"go.mod":    "module github.com/acme/widgets\n\ngo 1.22\n\nrequire github.com/stretchr/testify v1.9.0\n",
+		"Makefile":  ".PHONY: build\nbuild:\n\tgo build ./...\ntest: build\n\tgo test ./...\n",
+		"README.md": "# Widgets\nA widget service.\n",
+		"cmd":       "",
+	}
+
+	context := buildRepoContext(runner)
+	for _, want := range []string{
+		"Language: Go (go modules)",
+		"go.mod: module github.com/acme/widgets",
+		"go.mod: go 1.22",
+		"Makefile targets: build, test",
+		"# Widgets",
+	} {
+		if !strings.Contains(context, want) {
+			t.Errorf("repo context is missing %q:\n%s", want, context)
+		}
+	}
+	if strings.Contains(context, ".git") {
+		t.Errorf("repo context should not list .git:\n%s", context)
+	}
+
+	prompt := buildCommandPrompt(&types.EnhancedTask{Title: "Add widget", RepoContext: context}, "")
+	if !strings.Contains(prompt, "REPOSITORY CONTEXT:\nLanguage: Go") {
+		t.Errorf("prompt does not include the repository context:\n%s", prompt)
+	}
+}
+
+func TestCommandPromptIncludesHumanGuidance(t *testing.T) {
+	prompt := buildCommandPrompt(&types.EnhancedTask{Title: "Add widget", HumanGuidance: "Use the v2 API"}, "")
+	if !strings.Contains(prompt, "HUMAN GUIDANCE") || !strings.Contains(prompt, "Use the v2 API") {
+		t.Fatalf("prompt is missing the human guidance:\n%s", prompt)
+	}
+}
@@ -0,0 +1,96 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+)
+
+// maxReviewDiffLen keeps the diff sent to the reviewer within a small model's context
+const maxReviewDiffLen = 12000
+
+// Review is a reviewer model's verdict on the agent's changes
+type Review struct {
+	Approved bool
+	Comments string
+}
+
+// reviewFunc reviews the agent's diff against the task before a PR is opened
+type reviewFunc func(ctx context.Context, task *types.EnhancedTask, diff string) (*Review, error)
+
+// modelReviewer returns a reviewFunc backed by the given model, or nil when no
+// reviewer model is configured
+func modelReviewer(model string) reviewFunc {
+	if model == "" {
+		return nil
+	}
+	return func(ctx context.Context, task *types.EnhancedTask, diff string) (*Review, error) {
+		response, err := generateFor(ctx, task, model, buildReviewPrompt(task, diff))
+		if err != nil {
+			return nil, err
+		}
+		return parseReview(response), nil
+	}
+}
+
+// reviewChanges stages the agent's work and asks the reviewer about it. It returns
+// what to tell the agent when changes are requested, or "" when the work is approved.
+// A reviewer that can't be reached doesn't block the task.
+func reviewChanges(ctx context.Context, runner commandRunner, task *types.EnhancedTask, review reviewFunc) string {
+	result, err := runner.RunCommand("git add . && git diff --cached --no-color")
+	if err != nil {
+		fmt.Printf("⚠️ Failed to read diff for review of task #%d: %v\n", task.Number, err)
+		return ""
+	}
+
+	verdict, err := review(ctx, task, result.StdOut)
+	if err != nil {
+		fmt.Printf("⚠️ Reviewer failed for task #%d, continuing without review: %v\n", task.Number, err)
+		return ""
+	}
+	if verdict.Approved {
+		return ""
+	}
+	return fmt.Sprintf("The task is NOT complete: a reviewer requested changes. Address their comments before responding with TASK_COMPLETE.\nREVIEW COMMENTS:\n%s", verdict.Comments)
+}
+
+// buildReviewPrompt asks the reviewer to judge the diff against the task requirements
+func buildReviewPrompt(task *types.EnhancedTask, diff string) string {
+	if len(diff) > maxReviewDiffLen {
+		diff = diff[:maxReviewDiffLen] + "\n... (diff truncated)"
+	}
+	requirements := ""
+	for _, req := range task.Requirements {
+		requirements += "- " + req + "\n"
+	}
+	if requirements == "" {
+		requirements = "(none listed beyond the description)\n"
+	}
+
+	return fmt.Sprintf(
+		"You are reviewing another AI agent's changes before they are opened as a pull request.\n\n"+
+			"TASK:\nTitle: %s\nDescription: %s\n\nREQUIREMENTS:\n%s\n"+
+			"DIFF:\n---\n%s\n---\n\n"+
+			"Does this change correctly and completely resolve the task without unrelated changes?\n"+
+			"Respond with 'APPROVE' on the first line if it is ready, or 'REQUEST_CHANGES' on the first line "+
+			"followed by specific comments the agent should address.",
+		task.Title, task.Description, requirements, diff,
+	)
+}
+
+// parseReview reads a reviewer response. Anything but an explicit request for
+// changes counts as approval, so a rambling reviewer can't stall a task.
+func parseReview(response string) *Review {
+	response = strings.TrimSpace(response)
+	firstLine, comments, _ := strings.Cut(response, "\n")
+	if strings.Contains(strings.ToUpper(firstLine), "REQUEST_CHANGES") {
+		comments = strings.TrimSpace(comments)
+		if comments == "" {
+			comments = "The reviewer requested changes without further detail; re-check the task requirements."
+		}
+		return &Review{Comments: comments}
+	}
+	return &Review{Approved: true, Comments: strings.TrimSpace(comments)}
+}
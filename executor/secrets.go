@@ -0,0 +1,133 @@
+package executor
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+)
+
+// defaultSecretRules are always applied when secret scanning is enabled
+var defaultSecretRules = map[string]string{
+	"aws-access-key":     `\b(AKIA|ASIA)[0-9A-Z]{16}\b`,
+	"github-token":       `\b(ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36,}\b|\bgithub_pat_[A-Za-z0-9_]{22,}\b`,
+	"private-key":        `-----BEGIN ((RSA|DSA|EC|OPENSSH|PGP) )?PRIVATE KEY( BLOCK)?-----`,
+	"slack-token":        `\bxox[baprs]-[A-Za-z0-9-]{10,}\b`,
+	"openai-key":         `\bsk-[A-Za-z0-9_-]{20,}\b`,
+	"generic-assignment": `(?i)\b(api[_-]?key|secret|password|passwd|token)\b\s*[:=]\s*["'][^"'\s]{12,}["']`,
+}
+
+// secretRule is a compiled secret detection pattern
+type secretRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// SecretFinding describes a likely secret in an added line of the diff
+type SecretFinding struct {
+	Rule string
+	File string
+	Line int
+}
+
+// SecretsDetectedError is returned when the pre-push scan finds likely secrets
+type SecretsDetectedError struct {
+	Findings []SecretFinding
+}
+
+func (e *SecretsDetectedError) Error() string {
+	locations := make([]string, 0, len(e.Findings))
+	for _, f := range e.Findings {
+		locations = append(locations, fmt.Sprintf("%s:%d (%s)", f.File, f.Line, f.Rule))
+	}
+	return fmt.Sprintf("possible secrets detected in the task's changes: %s", strings.Join(locations, ", "))
+}
+
+// compileSecretRules merges the built-in rules with any configured ones
+func compileSecretRules(cfg config.SecretScanConfig) ([]secretRule, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	patterns := make(map[string]string, len(defaultSecretRules)+len(cfg.Rules))
+	for name, pattern := range defaultSecretRules {
+		patterns[name] = pattern
+	}
+	for name, pattern := range cfg.Rules {
+		patterns[name] = pattern
+	}
+
+	names := make([]string, 0, len(patterns))
+	for name := range patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rules := make([]secretRule, 0, len(names))
+	for _, name := range names {
+		re, err := regexp.Compile(patterns[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret rule %q: %w", name, err)
+		}
+		rules = append(rules, secretRule{Name: name, Pattern: re})
+	}
+	return rules, nil
+}
+
+// RedactSecrets replaces whatever the secret rules match in text with the rule's
+// name, for output that is about to leave the sandbox. The built-in rules apply
+// even when the pre-push scan is disabled; an invalid configured rule is skipped.
+func RedactSecrets(text string, cfg config.SecretScanConfig) string {
+	cfg.Enabled = true
+	rules, err := compileSecretRules(cfg)
+	if err != nil {
+		rules, _ = compileSecretRules(config.SecretScanConfig{Enabled: true})
+	}
+	for _, rule := range rules {
+		text = rule.Pattern.ReplaceAllString(text, "[REDACTED:"+rule.Name+"]")
+	}
+	return text
+}
+
+// scanDiff checks the lines added by a unified diff against the secret rules
+func scanDiff(diff string, rules []secretRule) []SecretFinding {
+	var findings []SecretFinding
+	file := ""
+	line := 0
+
+	for _, text := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(text, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(text, "+++ "), "b/")
+		case strings.HasPrefix(text, "@@"):
+			line = hunkStartLine(text)
+		case strings.HasPrefix(text, "+"):
+			for _, rule := range rules {
+				if rule.Pattern.MatchString(text[1:]) {
+					findings = append(findings, SecretFinding{Rule: rule.Name, File: file, Line: line})
+				}
+			}
+			line++
+		case strings.HasPrefix(text, " "):
+			line++
+		}
+	}
+	return findings
+}
+
+// hunkStartLine extracts the new-file start line from a hunk header like "@@ -1,2 +3,4 @@"
+func hunkStartLine(header string) int {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return 0
+	}
+	start := strings.SplitN(strings.TrimPrefix(fields[2], "+"), ",", 2)[0]
+	n, err := strconv.Atoi(start)
+	if err != nil {
+		return 0
+	}
+	return n
+}
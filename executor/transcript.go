@@ -0,0 +1,52 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+)
+
+// maxTranscriptOutput caps how much of each step's output a transcript keeps
+const maxTranscriptOutput = 2000
+
+// TranscriptStep is one command the agent chose and what came back from it
+type TranscriptStep struct {
+	Iteration int    `json:"iteration"`
+	Command   string `json:"command"`          // The model's response: a shell command or a completion marker
+	Output    string `json:"output,omitempty"` // Summary of what was fed back to the model, empty once the task is done
+}
+
+// Transcript records how the agent worked through a task, for reviewers
+type Transcript struct {
+	Steps []TranscriptStep
+}
+
+// recording wraps next so every command the model produces starts a new step
+func (t *Transcript) recording(next nextCommandFunc) nextCommandFunc {
+	return func(ctx context.Context, task *types.EnhancedTask, lastOutput string) (string, error) {
+		command, err := next(ctx, task, lastOutput)
+		if err == nil {
+			t.Steps = append(t.Steps, TranscriptStep{Iteration: len(t.Steps), Command: command})
+		}
+		return command, err
+	}
+}
+
+// recordOutput attaches the output of the latest step
+func (t *Transcript) recordOutput(output string) {
+	if t == nil || len(t.Steps) == 0 {
+		return
+	}
+	t.Steps[len(t.Steps)-1].Output = summarizeOutput(output)
+}
+
+// summarizeOutput keeps the start and end of long output, where errors usually are
+func summarizeOutput(output string) string {
+	if len(output) <= maxTranscriptOutput {
+		return output
+	}
+	half := maxTranscriptOutput / 2
+	omitted := len(output) - 2*half
+	return fmt.Sprintf("%s\n... %d bytes omitted ...\n%s", output[:half], omitted, output[len(output)-half:])
+}
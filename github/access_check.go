@@ -0,0 +1,60 @@
+package github
+
+import (
+	"fmt"
+	"time"
+)
+
+// accessCheckInterval is how often the agent confirms it can still reach each repository
+const accessCheckInterval = 2 * time.Minute
+
+// accessCheckLoop periodically drops repositories whose access has been revoked,
+// so the agent stops claiming tasks it could never push or comment on
+func (hi *Integration) accessCheckLoop() {
+	ticker := time.NewTicker(accessCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-hi.ctx.Done():
+			return
+		case <-ticker.C:
+			hi.recheckRepositoryAccess()
+		}
+	}
+}
+
+// recheckRepositoryAccess asks GitHub for each repository and drops the ones the
+// token can no longer see. Outages and rate limits don't mean access was lost,
+// so only permission and not-found answers drop a repository.
+func (hi *Integration) recheckRepositoryAccess() {
+	hi.repositoryLock.RLock()
+	repositories := hi.uniqueRepositories()
+	hi.repositoryLock.RUnlock()
+
+	for _, repoClient := range repositories {
+		err := repoClient.Client.verifyAccess()
+		if err == nil {
+			continue
+		}
+		reason := classifyGitHubError(err)
+		if reason != "permission_denied" && reason != "not_found" {
+			fmt.Printf("⚠️ Could not re-check access to %s/%s: %v\n",
+				repoClient.Repository.Owner, repoClient.Repository.Repository, err)
+			continue
+		}
+
+		// Every project sharing the client loses it; a sync may have replaced the
+		// client while we were checking
+		projects := hi.repositoryProjects(repoClient)
+		hi.repositoryLock.Lock()
+		for _, projectID := range projects {
+			if hi.repositories[projectID] == repoClient {
+				delete(hi.repositories, projectID)
+			}
+		}
+		hi.repositoryLock.Unlock()
+		fmt.Printf("🔒 Dropped repository %s/%s (Project IDs: %v): access lost (%s): %v\n",
+			repoClient.Repository.Owner, repoClient.Repository.Repository, projects, reason, err)
+	}
+}
@@ -0,0 +1,62 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	gh "github.com/google/go-github/v57/github"
+)
+
+func TestRepositoryDroppedWhenAccessCheckStartsFailing(t *testing.T) {
+	var revoked atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/acme/widgets":
+			if revoked.Load() {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"message":"Not Found"}`)
+				return
+			}
+			fmt.Fprint(w, `{"name":"widgets"}`)
+		case "/repos/acme/gadgets":
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprint(w, `{"message":"Server Error"}`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := func(projectID int, name string) *RepositoryClient {
+		return &RepositoryClient{
+			Client:     &Client{client: ghClient, ctx: context.Background(), config: &Config{Owner: "acme", Repository: name}},
+			Repository: hive.Repository{ProjectID: projectID, Owner: "acme", Repository: name},
+		}
+	}
+	hi := &Integration{
+		ctx:          context.Background(),
+		repositories: map[int]*RepositoryClient{7: repoClient(7, "widgets"), 8: repoClient(8, "gadgets")},
+	}
+
+	hi.recheckRepositoryAccess()
+	if len(hi.repositories) != 2 {
+		t.Fatalf("expected both repositories kept while access works, got %d", len(hi.repositories))
+	}
+
+	revoked.Store(true)
+	hi.recheckRepositoryAccess()
+	if _, ok := hi.repositories[7]; ok {
+		t.Fatal("repository whose access was revoked was not dropped")
+	}
+	if _, ok := hi.repositories[8]; !ok {
+		t.Fatal("repository was dropped because GitHub was unavailable")
+	}
+}
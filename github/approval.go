@@ -0,0 +1,78 @@
+package github
+
+import (
+	"fmt"
+
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/google/go-github/v57/github"
+)
+
+// DefaultApprovalLabel is added by a human to let agents work on a proposed task
+const DefaultApprovalLabel = "bzzz-approved"
+
+// ProposedLabel marks tasks an agent has proposed and is waiting to have approved
+const ProposedLabel = "bzzz-proposed"
+
+// approvalLabel returns the label that approves proposed tasks
+func (hi *Integration) approvalLabel() string {
+	if hi.config.ApprovalLabel != "" {
+		return hi.config.ApprovalLabel
+	}
+	return DefaultApprovalLabel
+}
+
+// approvedTasks drops tasks from repositories that require approval until a
+// human adds the approval label. The first time such a task is seen it is
+// proposed on the issue instead of claimed.
+func (hi *Integration) approvedTasks(tasks []*types.EnhancedTask) []*types.EnhancedTask {
+	var approved []*types.EnhancedTask
+	for _, task := range tasks {
+		if !task.Repository.RequireApproval || hasLabel(task, hi.approvalLabel()) {
+			approved = append(approved, task)
+			continue
+		}
+		if !hasLabel(task, ProposedLabel) {
+			hi.proposeTask(task)
+		}
+	}
+	return approved
+}
+
+// proposeTask asks humans to approve a task before any agent claims it
+func (hi *Integration) proposeTask(task *types.EnhancedTask) {
+	hi.repositoryLock.RLock()
+	repoClient, exists := hi.repositories[task.ProjectID]
+	hi.repositoryLock.RUnlock()
+	if !exists {
+		return
+	}
+
+	if err := repoClient.Client.ProposeTask(task.Number, hi.config.AgentID, hi.approvalLabel()); err != nil {
+		fmt.Printf("⚠️ Failed to propose task #%d: %v\n", task.Number, err)
+		return
+	}
+	task.Labels = append(task.Labels, ProposedLabel)
+	fmt.Printf("🙏 Proposed task #%d in %s/%s, waiting for the %s label\n",
+		task.Number, task.Repository.Owner, task.Repository.Repository, hi.approvalLabel())
+}
+
+// hasLabel reports whether a task carries a label
+func hasLabel(task *types.EnhancedTask, label string) bool {
+	for _, l := range task.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// ProposeTask comments on an issue that an agent wants to work on it and marks
+// it as proposed, so it is only claimed once a human adds approvalLabel
+func (c *Client) ProposeTask(issueNumber int, agentID, approvalLabel string) error {
+	body := fmt.Sprintf("🐝 **Bzzz agent `%s` would like to work on this task.**\n\nThis repository requires approval before agents start. Add the `%s` label to approve; remove the `%s` label to have it proposed again.",
+		agentID, approvalLabel, ProposedLabel)
+	if _, _, err := c.client.Issues.CreateComment(c.ctx, c.config.Owner, c.config.Repository, issueNumber, &github.IssueComment{Body: &body}); err != nil {
+		return fmt.Errorf("failed to comment on issue: %w", err)
+	}
+	return c.AddLabel(issueNumber, ProposedLabel)
+}
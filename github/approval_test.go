@@ -0,0 +1,170 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	gh "github.com/google/go-github/v57/github"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	blankhost "github.com/libp2p/go-libp2p/p2p/host/blank"
+	"github.com/libp2p/go-libp2p/p2p/host/eventbus"
+	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoremem"
+	"github.com/libp2p/go-libp2p/p2p/net/swarm"
+)
+
+// newTestPubSub creates a PubSub on a host with no transports, enough to publish claim intents locally
+func newTestPubSub(t *testing.T) *pubsub.PubSub {
+	t.Helper()
+
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	peerstore, err := pstoremem.NewPeerstore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	peerstore.AddPrivKey(id, priv)
+	peerstore.AddPubKey(id, priv.GetPublic())
+
+	network, err := swarm.NewSwarm(id, peerstore, eventbus.NewBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := blankhost.NewBlankHost(network)
+	t.Cleanup(func() { h.Close() })
+
+	ps, err := pubsub.NewPubSub(context.Background(), h, "bzzz/test/coordination", "antennae/test/meta-discussion")
+	if err != nil {
+		t.Fatalf("failed to create PubSub: %v", err)
+	}
+	t.Cleanup(func() { ps.Close() })
+	return ps
+}
+
+func TestTaskWaitsForApprovalLabel(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	issueLabels := `[{"name":"bzzz-task"}]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		labels := issueLabels
+		mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/issues":
+			fmt.Fprintf(w, `[{"number":5,"title":"Rotate the signing keys","labels":%s}]`, labels)
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/issues/5":
+			fmt.Fprintf(w, `{"number":5,"labels":%s}`, labels)
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/git/ref/heads/main":
+			fmt.Fprint(w, `{"ref":"refs/heads/main","object":{"sha":"abc123"}}`)
+		case strings.HasPrefix(r.URL.Path, "/api/bzzz/projects/7/claims"):
+			fmt.Fprint(w, `[]`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client: &Client{
+			client: ghClient,
+			ctx:    context.Background(),
+			config: &Config{Owner: "acme", Repository: "widgets", BaseBranch: "main", TaskLabel: "bzzz-task", InProgressLabel: "in-progress"},
+		},
+		Repository: hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets", RequireApproval: true},
+	}
+
+	executed := make(chan *types.EnhancedTask, 1)
+	hi := &Integration{
+		ctx:          context.Background(),
+		pubsub:       newTestPubSub(t),
+		config:       &IntegrationConfig{AgentID: "agent-a", Capabilities: []string{"general"}},
+		agentConfig:  &config.AgentConfig{ClaimIntentWindow: 10 * time.Millisecond},
+		hlog:         logging.NewHypercoreLog(peer.ID("test")),
+		hiveClient:   hive.NewHiveClient(server.URL, ""),
+		failures:     newFailureTracker(filepath.Join(t.TempDir(), "failures.json")),
+		repositories: map[int]*RepositoryClient{7: repoClient},
+		claimIntents: make(map[string]map[string]*claimIntent),
+		execute: func(ctx context.Context, task *types.EnhancedTask, repoClient *RepositoryClient) {
+			executed <- task
+		},
+	}
+	poll := func() {
+		mu.Lock()
+		requests = nil
+		mu.Unlock()
+		hi.pollRepositories([]*RepositoryClient{repoClient})
+	}
+	sawRequest := func(want string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, request := range requests {
+			if request == want {
+				return true
+			}
+		}
+		return false
+	}
+
+	// First sighting: the task is proposed, not claimed
+	poll()
+	if !sawRequest("POST /repos/acme/widgets/issues/5/comments") || !sawRequest("POST /repos/acme/widgets/issues/5/labels") {
+		t.Fatalf("expected a proposal comment and label, got %v", requests)
+	}
+	if sawRequest("PATCH /repos/acme/widgets/issues/5") {
+		t.Fatal("task was claimed before approval")
+	}
+
+	// Still waiting: no second proposal and no claim
+	mu.Lock()
+	issueLabels = `[{"name":"bzzz-task"},{"name":"` + ProposedLabel + `"}]`
+	mu.Unlock()
+	poll()
+	if sawRequest("POST /repos/acme/widgets/issues/5/comments") || sawRequest("PATCH /repos/acme/widgets/issues/5") {
+		t.Fatalf("task should be left alone while awaiting approval, got %v", requests)
+	}
+	select {
+	case <-executed:
+		t.Fatal("task executed without approval")
+	default:
+	}
+
+	// A human approves: the task is claimed and executed
+	mu.Lock()
+	issueLabels = `[{"name":"bzzz-task"},{"name":"` + ProposedLabel + `"},{"name":"` + DefaultApprovalLabel + `"}]`
+	mu.Unlock()
+	poll()
+	select {
+	case task := <-executed:
+		if task.Number != 5 {
+			t.Fatalf("executed task #%d, want #5", task.Number)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("approved task was not executed")
+	}
+	if !sawRequest("PATCH /repos/acme/widgets/issues/5") {
+		t.Errorf("approved task was not claimed on GitHub, got %v", requests)
+	}
+}
@@ -0,0 +1,284 @@
+package github
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// ErrTaskNotRunning is returned when cancelling a task this agent isn't running
+var ErrTaskNotRunning = errors.New("task is not running on this agent")
+
+// runningTask is a task being executed, with the means to stop it
+type runningTask struct {
+	task        *types.EnhancedTask
+	startedAt   time.Time
+	cancel      context.CancelFunc
+	cancelledBy string // Set once cancelled; why, or who asked
+	reannounce  bool   // Offer the task to the mesh again once it is released
+	keepIssue   bool   // The issue was closed or reassigned on GitHub; leave it alone
+}
+
+// RunningTaskInfo describes a running task for the control API
+type RunningTaskInfo struct {
+	ID         string    `json:"id"` // "projectID:taskNumber"
+	ProjectID  int       `json:"project_id"`
+	TaskNumber int       `json:"task_number"`
+	Title      string    `json:"title"`
+	Repository string    `json:"repository"`
+	Branch     string    `json:"branch,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	Cancelling bool      `json:"cancelling"`
+}
+
+// registerRunning gives a task its own cancellable context for the length of its execution
+func (hi *Integration) registerRunning(task *types.EnhancedTask) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(hi.ctx)
+	key := taskKey(task.ProjectID, task.Number)
+
+	hi.runningLock.Lock()
+	if hi.running == nil {
+		hi.running = make(map[string]*runningTask)
+	}
+	hi.running[key] = &runningTask{task: task, startedAt: time.Now(), cancel: cancel}
+	hi.runningLock.Unlock()
+
+	return ctx, func() {
+		hi.runningLock.Lock()
+		delete(hi.running, key)
+		hi.runningLock.Unlock()
+		cancel()
+	}
+}
+
+// CancelTask stops a task this agent is running. Its sandbox is destroyed and its
+// claim released as the execution unwinds.
+func (hi *Integration) CancelTask(projectID, taskNumber int, reason string) error {
+	hi.runningLock.Lock()
+	running, exists := hi.running[taskKey(projectID, taskNumber)]
+	if exists && running.cancelledBy == "" {
+		running.cancelledBy = reason
+	}
+	hi.runningLock.Unlock()
+	if !exists {
+		return ErrTaskNotRunning
+	}
+
+	fmt.Printf("🛑 Cancelling task #%d: %s\n", taskNumber, reason)
+	running.cancel()
+	return nil
+}
+
+// cancelReason returns why a task was cancelled, or "" if it wasn't
+func (hi *Integration) cancelReason(task *types.EnhancedTask) string {
+	hi.runningLock.Lock()
+	defer hi.runningLock.Unlock()
+	if running, exists := hi.running[taskKey(task.ProjectID, task.Number)]; exists {
+		return running.cancelledBy
+	}
+	return ""
+}
+
+// RunningTasks lists the tasks this agent is executing
+func (hi *Integration) RunningTasks() []RunningTaskInfo {
+	hi.runningLock.Lock()
+	defer hi.runningLock.Unlock()
+
+	tasks := make([]RunningTaskInfo, 0, len(hi.running))
+	for key, running := range hi.running {
+		tasks = append(tasks, RunningTaskInfo{
+			ID:         key,
+			ProjectID:  running.task.ProjectID,
+			TaskNumber: running.task.Number,
+			Title:      running.task.Title,
+			Repository: fmt.Sprintf("%s/%s", running.task.Repository.Owner, running.task.Repository.Repository),
+			Branch:     running.task.BranchName,
+			StartedAt:  running.startedAt,
+			Cancelling: running.cancelledBy != "",
+		})
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].StartedAt.Before(tasks[j].StartedAt) })
+	return tasks
+}
+
+// releaseCancelledTask gives a cancelled task back so it can be picked up again
+func (hi *Integration) releaseCancelledTask(task *types.EnhancedTask, repoClient *RepositoryClient, reason string) {
+	if reason == "" {
+		reason = "agent shutting down"
+	}
+	fmt.Printf("🛑 Task #%d cancelled, releasing claim\n", task.Number)
+	hi.hlog.Append(logging.TaskFailed, map[string]interface{}{
+		"task_id":    task.Number,
+		"repository": task.RepositoryName(),
+		"reason":     "cancelled",
+		"details":    reason,
+	})
+
+	// Releasing an issue humans closed or reassigned would undo their change
+	if !hi.keepsIssue(task) {
+		if err := repoClient.Client.ReleaseTask(task.Number); err != nil {
+			fmt.Printf("⚠️ Failed to release cancelled task #%d: %v\n", task.Number, err)
+		}
+	}
+	if err := hi.hiveClient.UpdateTaskStatus(hi.ctx, task.ProjectID, task.Number, "cancelled", map[string]interface{}{
+		"agent_id": hi.config.AgentID,
+		"reason":   reason,
+	}); err != nil {
+		fmt.Printf("⚠️ Failed to report task cancellation to Hive: %v\n", err)
+	}
+	if hi.shouldReannounce(task) && !hi.keepsIssue(task) {
+		hi.announceTask(task, reason)
+	}
+}
+
+// RequestCancel cancels a task here if we're running it, and otherwise asks the
+// mesh so whichever agent is running it stops
+func (hi *Integration) RequestCancel(projectID, taskNumber int, reason string) (local bool, err error) {
+	if err := hi.CancelTask(projectID, taskNumber, reason); err == nil {
+		return true, nil
+	}
+
+	err = hi.pubsub.PublishBzzzMessage(pubsub.TaskCancel, map[string]interface{}{
+		"project_id":   projectID,
+		"task_number":  taskNumber,
+		"reason":       reason,
+		"requested_by": hi.config.AgentID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to broadcast task cancellation: %w", err)
+	}
+	return false, nil
+}
+
+// isOperator reports whether a message's signed author may control this agent
+func (hi *Integration) isOperator(from peer.ID) bool {
+	for _, operator := range hi.config.OperatorPeers {
+		if operator == from {
+			return true
+		}
+	}
+	return false
+}
+
+// handleTaskCancel stops a task when an operator's node asks for it to be cancelled
+func (hi *Integration) handleTaskCancel(msg pubsub.Message, from peer.ID) {
+	if !hi.isOperator(from) {
+		fmt.Printf("🚫 Ignoring task cancellation from %s: not an operator peer\n", from.ShortString())
+		return
+	}
+	projectID, _ := msg.Data["project_id"].(float64)
+	taskNumber, _ := msg.Data["task_number"].(float64)
+	reason, _ := msg.Data["reason"].(string)
+	if reason == "" {
+		reason = fmt.Sprintf("cancelled by %s", from.ShortString())
+	}
+
+	if err := hi.CancelTask(int(projectID), int(taskNumber), reason); err != nil && !errors.Is(err, ErrTaskNotRunning) {
+		fmt.Printf("⚠️ Failed to cancel task #%d: %v\n", int(taskNumber), err)
+	}
+}
+
+// TaskControlHandler serves the running-task API. Requests must carry token as a
+// bearer token.
+//
+//	GET  /tasks              lists running tasks
+//	GET  /tasks/{id}         describes one running task
+//	POST /tasks/{id}/cancel  cancels a task here or, if another agent runs it, via the mesh
+//
+// An id is "projectID:taskNumber"; cancelling by remote id requires the full form.
+func (hi *Integration) TaskControlHandler(token []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer := []byte(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if subtle.ConstantTimeCompare(bearer, token) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/tasks"), "/"), "/")
+		switch {
+		case parts[0] == "" && r.Method == http.MethodGet:
+			writeJSON(w, http.StatusOK, hi.RunningTasks())
+
+		case len(parts) == 1 && r.Method == http.MethodGet:
+			for _, task := range hi.RunningTasks() {
+				if task.ID == parts[0] || strconv.Itoa(task.TaskNumber) == parts[0] {
+					writeJSON(w, http.StatusOK, task)
+					return
+				}
+			}
+			http.Error(w, "task is not running on this agent", http.StatusNotFound)
+
+		case len(parts) == 2 && parts[1] == "cancel" && r.Method == http.MethodPost:
+			projectID, taskNumber, ok := hi.resolveTaskID(parts[0])
+			if !ok {
+				http.Error(w, "unknown task; use projectID:taskNumber", http.StatusNotFound)
+				return
+			}
+			var body struct {
+				Reason string `json:"reason"`
+			}
+			json.NewDecoder(r.Body).Decode(&body) // The reason is optional
+			if body.Reason == "" {
+				body.Reason = "cancelled by operator"
+			}
+
+			local, err := hi.RequestCancel(projectID, taskNumber, body.Reason)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			writeJSON(w, http.StatusAccepted, map[string]interface{}{
+				"id":        taskKey(projectID, taskNumber),
+				"cancelled": local,
+				"broadcast": !local,
+			})
+
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	})
+}
+
+// resolveTaskID parses "projectID:taskNumber", or a bare task number matching
+// exactly one running task
+func (hi *Integration) resolveTaskID(id string) (projectID, taskNumber int, ok bool) {
+	if project, number, found := strings.Cut(id, ":"); found {
+		projectID, err1 := strconv.Atoi(project)
+		taskNumber, err2 := strconv.Atoi(number)
+		return projectID, taskNumber, err1 == nil && err2 == nil
+	}
+
+	number, err := strconv.Atoi(id)
+	if err != nil {
+		return 0, 0, false
+	}
+	var matches []RunningTaskInfo
+	for _, task := range hi.RunningTasks() {
+		if task.TaskNumber == number {
+			matches = append(matches, task)
+		}
+	}
+	if len(matches) != 1 {
+		return 0, 0, false
+	}
+	return matches[0].ProjectID, number, true
+}
+
+// writeJSON writes value as a JSON response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,147 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/executor"
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/budget"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	gh "github.com/google/go-github/v57/github"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestCancelRunningTaskCleansUp(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, body))
+		mu.Unlock()
+
+		if r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/issues/42" {
+			fmt.Fprint(w, `{"number":42,"assignees":[{"login":"bzzz-bot"}],"labels":[{"name":"in-progress"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client: &Client{
+			client: ghClient,
+			ctx:    context.Background(),
+			config: &Config{Owner: "acme", Repository: "widgets", InProgressLabel: "in-progress"},
+		},
+		Repository: hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets"},
+	}
+
+	started := make(chan struct{})
+	sandboxDestroyed := make(chan struct{})
+	hi := &Integration{
+		ctx:        context.Background(),
+		pubsub:     newTestPubSub(t),
+		config:     &IntegrationConfig{AgentID: "agent-a"},
+		hlog:       logging.NewHypercoreLog(peer.ID("test")),
+		hiveClient: hive.NewHiveClient(server.URL, ""),
+		runExecutor: func(ctx context.Context, task *types.EnhancedTask, hlog *logging.HypercoreLog, agentConfig *config.AgentConfig, budgets *budget.Tracker) (*executor.ExecuteTaskResult, error) {
+			close(started)
+			<-ctx.Done()
+			close(sandboxDestroyed) // ExecuteTask destroys its sandbox on any error
+			return nil, fmt.Errorf("command cancelled: %w", ctx.Err())
+		},
+	}
+	task := &types.EnhancedTask{Number: 42, ProjectID: 7, Title: "Runaway task", Repository: repoClient.Repository, BranchName: "bzzz/task-42"}
+
+	hi.startExecution(context.Background(), task, repoClient)
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("task did not start")
+	}
+
+	handler := hi.TaskControlHandler([]byte("s3cret"))
+	call := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"reason":"looping on the same test"}`))
+		req.Header.Set("Authorization", "Bearer s3cret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var listed []RunningTaskInfo
+	if rec := call(http.MethodGet, "/tasks"); rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &listed) != nil || len(listed) != 1 || listed[0].ID != "7:42" {
+		t.Fatalf("expected task 7:42 to be listed as running, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := call(http.MethodPost, "/tasks/42/cancel"); rec.Code != http.StatusAccepted || !strings.Contains(rec.Body.String(), `"cancelled":true`) {
+		t.Fatalf("expected the task to be cancelled locally, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	select {
+	case <-sandboxDestroyed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancellation did not reach the executor")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(hi.RunningTasks()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("cancelled task is still registered as running")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	joined := strings.Join(requests, "\n")
+	mu.Unlock()
+	for _, want := range []string{
+		"DELETE /repos/acme/widgets/issues/42/assignees",
+		"DELETE /repos/acme/widgets/issues/42/labels/in-progress",
+		`"status":"cancelled"`,
+		"looping on the same test",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected %q in requests:\n%s", want, joined)
+		}
+	}
+
+	// A task running elsewhere is cancelled over the mesh
+	if rec := call(http.MethodPost, "/tasks/7:99/cancel"); rec.Code != http.StatusAccepted || !strings.Contains(rec.Body.String(), `"broadcast":true`) {
+		t.Errorf("expected a remote cancel to be broadcast, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestMeshCancellationRequiresAnOperator(t *testing.T) {
+	hi := &Integration{
+		ctx:    context.Background(),
+		config: &IntegrationConfig{AgentID: "agent-a", OperatorPeers: []peer.ID{"operator"}},
+	}
+	task := &types.EnhancedTask{Number: 42, ProjectID: 7}
+	ctx, done := hi.registerRunning(task)
+	defer done()
+
+	cancel := pubsub.Message{Type: pubsub.TaskCancel, Data: map[string]interface{}{"project_id": 7.0, "task_number": 42.0}}
+	hi.handleBzzzMessage(cancel, peer.ID("stranger"))
+	if ctx.Err() != nil {
+		t.Fatal("task cancelled for a peer that isn't an operator")
+	}
+	hi.handleBzzzMessage(cancel, peer.ID("operator"))
+	if ctx.Err() == nil {
+		t.Fatal("task not cancelled for an operator")
+	}
+}
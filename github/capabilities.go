@@ -0,0 +1,116 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// capabilities returns the agent's current capabilities
+func (hi *Integration) capabilities() []string {
+	hi.capabilitiesLock.RLock()
+	defer hi.capabilitiesLock.RUnlock()
+	return hi.config.Capabilities
+}
+
+// SetCapabilities changes the agent's capabilities while it runs. Running tasks
+// the agent can no longer handle are stopped, released and announced again so a
+// better-suited agent can pick them up.
+func (hi *Integration) SetCapabilities(capabilities []string) {
+	updated := append([]string(nil), capabilities...)
+	hi.capabilitiesLock.Lock()
+	hi.config.Capabilities = updated
+	hi.capabilitiesLock.Unlock()
+	fmt.Printf("🔄 Agent %s capabilities are now %v\n", hi.config.AgentID, updated)
+
+	hi.reassessRunningTasks()
+}
+
+// reassessRunningTasks releases running tasks that no longer fit the agent's capabilities
+func (hi *Integration) reassessRunningTasks() {
+	hi.runningLock.Lock()
+	var misfits []*runningTask
+	for _, running := range hi.running {
+		if running.cancelledBy == "" && !hi.canHandleTask(running.task) {
+			running.cancelledBy = fmt.Sprintf("capabilities changed, agent no longer handles %q tasks", running.task.TaskType)
+			if missing := hi.missingCapabilities(running.task); len(missing) > 0 {
+				running.cancelledBy = fmt.Sprintf("capabilities changed, agent lacks required %s", strings.Join(missing, ", "))
+			}
+			running.reannounce = true
+			misfits = append(misfits, running)
+		}
+	}
+	hi.runningLock.Unlock()
+
+	for _, running := range misfits {
+		fmt.Printf("🔄 Releasing task #%d: %s\n", running.task.Number, running.cancelledBy)
+		hi.hlog.Append(logging.TaskProgress, map[string]interface{}{
+			"task_id":    running.task.Number,
+			"repository": running.task.RepositoryName(),
+			"status":     "released after capability change",
+			"reason":     running.cancelledBy,
+		})
+		running.cancel() // The claim is released as the execution unwinds
+	}
+}
+
+// shouldReannounce reports whether a released task should be offered to the mesh again
+func (hi *Integration) shouldReannounce(task *types.EnhancedTask) bool {
+	hi.runningLock.Lock()
+	defer hi.runningLock.Unlock()
+	running, exists := hi.running[taskKey(task.ProjectID, task.Number)]
+	return exists && running.reannounce
+}
+
+// announceTask offers a task to the rest of the mesh, such as one this agent has given up
+func (hi *Integration) announceTask(task *types.EnhancedTask, reason string) {
+	err := hi.pubsub.PublishBzzzMessage(pubsub.TaskAnnouncement, map[string]interface{}{
+		"task": map[string]interface{}{
+			"number":     task.Number,
+			"project_id": task.ProjectID,
+			"title":      task.Title,
+			"task_type":  task.TaskType,
+			"priority":   task.Priority,
+		},
+		"repository": map[string]interface{}{
+			"name":       fmt.Sprintf("%s/%s", task.Repository.Owner, task.Repository.Repository),
+			"project_id": task.ProjectID,
+		},
+		"released_by": hi.config.AgentID,
+		"reason":      reason,
+	})
+	if err != nil {
+		fmt.Printf("⚠️ Failed to announce task #%d: %v\n", task.Number, err)
+		return
+	}
+	hi.hlog.Append(logging.TaskAnnounced, map[string]interface{}{
+		"task_id":    task.Number,
+		"repository": task.RepositoryName(),
+		"reason":     reason,
+	})
+}
+
+// handleTaskAnnouncement polls the announced task's repository straight away, so
+// a task another agent split off or released is picked up without waiting for
+// the next poll
+func (hi *Integration) handleTaskAnnouncement(msg pubsub.Message, from peer.ID) {
+	if releasedBy, _ := msg.Data["released_by"].(string); releasedBy == hi.config.AgentID {
+		return
+	}
+	repository, _ := msg.Data["repository"].(map[string]interface{})
+	projectID, _ := repository["project_id"].(float64)
+	hi.repositoryLock.RLock()
+	repoClient, exists := hi.repositories[int(projectID)]
+	hi.repositoryLock.RUnlock()
+	if !exists {
+		return
+	}
+
+	reason, _ := msg.Data["reason"].(string)
+	fmt.Printf("📣 Task announced in %s/%s (%s), polling now\n", repoClient.Repository.Owner, repoClient.Repository.Repository, reason)
+	go hi.pollRepositories([]*RepositoryClient{repoClient})
+}
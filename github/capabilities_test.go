@@ -0,0 +1,192 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/executor"
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/budget"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	gh "github.com/google/go-github/v57/github"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestCapabilityChangeReleasesTasksThatNoLongerFit(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, body))
+		mu.Unlock()
+
+		if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/repos/acme/widgets/issues/") {
+			fmt.Fprint(w, `{"assignees":[{"login":"bzzz-bot"}],"labels":[{"name":"in-progress"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client: &Client{
+			client: ghClient,
+			ctx:    context.Background(),
+			config: &Config{Owner: "acme", Repository: "widgets", InProgressLabel: "in-progress"},
+		},
+		Repository: hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets"},
+	}
+
+	var stopped sync.Map // Task number -> true once its executor was stopped
+	hi := &Integration{
+		ctx:        context.Background(),
+		pubsub:     newTestPubSub(t),
+		config:     &IntegrationConfig{AgentID: "agent-a", Capabilities: []string{"frontend", "backend"}},
+		hlog:       logging.NewHypercoreLog(peer.ID("test")),
+		hiveClient: hive.NewHiveClient(server.URL, ""),
+		runExecutor: func(ctx context.Context, task *types.EnhancedTask, hlog *logging.HypercoreLog, agentConfig *config.AgentConfig, budgets *budget.Tracker) (*executor.ExecuteTaskResult, error) {
+			<-ctx.Done()
+			stopped.Store(task.Number, true)
+			return nil, ctx.Err()
+		},
+	}
+	frontend := &types.EnhancedTask{Number: 42, ProjectID: 7, TaskType: "frontend", Title: "Fix the navbar", Repository: repoClient.Repository}
+	backend := &types.EnhancedTask{Number: 43, ProjectID: 7, TaskType: "backend", Title: "Fix the API", Repository: repoClient.Repository}
+	hi.startExecution(context.Background(), frontend, repoClient)
+	hi.startExecution(context.Background(), backend, repoClient)
+	defer hi.CancelTask(7, 43, "test finished")
+
+	hi.SetCapabilities([]string{"backend"})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(hi.RunningTasks()) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected only the backend task to keep running, got %+v", hi.RunningTasks())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if running := hi.RunningTasks(); running[0].TaskNumber != 43 {
+		t.Fatalf("expected the backend task to keep running, got #%d", running[0].TaskNumber)
+	}
+	if _, ok := stopped.Load(43); ok {
+		t.Fatal("the backend task still fits and should not have been stopped")
+	}
+
+	mu.Lock()
+	joined := strings.Join(requests, "\n")
+	mu.Unlock()
+	for _, want := range []string{
+		"DELETE /repos/acme/widgets/issues/42/assignees",
+		"DELETE /repos/acme/widgets/issues/42/labels/in-progress",
+		`"status":"cancelled"`,
+		`no longer handles \"frontend\" tasks`,
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected %q in requests:\n%s", want, joined)
+		}
+	}
+	if strings.Contains(joined, "issues/43/assignees") {
+		t.Errorf("the backend task should keep its claim:\n%s", joined)
+	}
+}
+
+func TestTaskAnnouncementPollsItsRepository(t *testing.T) {
+	polled := make(chan string, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/issues" {
+			polled <- r.URL.Path
+		}
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client:     &Client{client: ghClient, ctx: context.Background(), config: &Config{Owner: "acme", Repository: "widgets", TaskLabel: "bzzz-task"}},
+		Repository: hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets"},
+	}
+	hi := &Integration{
+		ctx:          context.Background(),
+		hiveClient:   hive.NewHiveClient(server.URL, ""),
+		config:       &IntegrationConfig{AgentID: "agent-b", Capabilities: []string{"general"}},
+		pollBackoff:  newPollBackoff(time.Second, 5*time.Second),
+		repositories: map[int]*RepositoryClient{7: repoClient},
+	}
+	announce := func(releasedBy string, projectID float64) {
+		hi.handleBzzzMessage(pubsub.Message{Type: pubsub.TaskAnnouncement, Data: map[string]interface{}{
+			"task":        map[string]interface{}{"number": float64(101), "project_id": projectID},
+			"repository":  map[string]interface{}{"name": "acme/widgets", "project_id": projectID},
+			"released_by": releasedBy,
+			"reason":      "split from #42",
+		}}, peer.ID("agent-a"))
+	}
+
+	// Our own announcements and other projects' tasks are left to the usual poll
+	announce("agent-b", 7)
+	announce("agent-a", 9)
+	select {
+	case <-polled:
+		t.Fatal("expected no poll for our own announcement or an unknown project")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	announce("agent-a", 7)
+	select {
+	case <-polled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected an announced task's repository to be polled straight away")
+	}
+}
+
+func TestRequiredCapabilitiesAreStrict(t *testing.T) {
+	client := &Client{config: &Config{}}
+	issue := &gh.Issue{
+		Number: gh.Int(5),
+		Title:  gh.String("Audit the token handling"),
+		Body:   gh.String("Check how tokens are stored.\n\nbzzz-requires: security\n"),
+		Labels: []*gh.Label{{Name: gh.String("type-general")}},
+	}
+	task := newEnhancedTask(&RepositoryClient{}, client.issueToTask(issue))
+	if len(task.RequiredCapabilities) != 1 || task.RequiredCapabilities[0] != "security" {
+		t.Fatalf("expected the issue to require security, got %v", task.RequiredCapabilities)
+	}
+
+	generalist := &Integration{
+		config:   &IntegrationConfig{AgentID: "generalist", Capabilities: []string{"general", "code-generation"}},
+		failures: newFailureTracker(filepath.Join(t.TempDir(), "failures.json")),
+	}
+	if got := generalist.filterSuitableTasks([]*types.EnhancedTask{task}); len(got) != 0 {
+		t.Fatal("an agent without the security capability should not take the task")
+	}
+
+	auditor := &Integration{
+		config:   &IntegrationConfig{AgentID: "auditor", Capabilities: []string{"general", "Security"}},
+		failures: newFailureTracker(filepath.Join(t.TempDir(), "failures.json")),
+	}
+	if got := auditor.filterSuitableTasks([]*types.EnhancedTask{task}); len(got) != 1 {
+		t.Fatal("an agent with the security capability should take the task")
+	}
+
+	// The requirement can also come from a label
+	issue.Body = gh.String("Check how tokens are stored.")
+	issue.Labels = append(issue.Labels, &gh.Label{Name: gh.String("bzzz-requires: security, go")})
+	if got := client.issueToTask(issue).RequiredCapabilities; len(got) != 2 || got[0] != "security" || got[1] != "go" {
+		t.Fatalf("expected security and go from the label, got %v", got)
+	}
+}
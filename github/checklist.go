@@ -0,0 +1,106 @@
+package github
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/google/go-github/v57/github"
+)
+
+// checklistPattern matches a markdown task list item such as "- [ ] Add tests"
+var checklistPattern = regexp.MustCompile(`^(\s*[-*+]\s+\[)([ xX])(\]\s+)(.*\S)\s*$`)
+
+// parseChecklist extracts the task list items from an issue body, skipping fenced code blocks
+func parseChecklist(body string) []types.ChecklistItem {
+	var items []types.ChecklistItem
+	forEachChecklistLine(body, func(lines []string, i int, match []string) {
+		items = append(items, types.ChecklistItem{
+			Index: len(items),
+			Text:  match[4],
+			Done:  match[2] != " ",
+		})
+	})
+	return items
+}
+
+// checkOffChecklistItem returns body with the index-th checklist item ticked
+func checkOffChecklistItem(body string, index int) (string, error) {
+	count := 0
+	found := false
+	lines := forEachChecklistLine(body, func(lines []string, i int, match []string) {
+		if count == index {
+			lines[i] = match[1] + "x" + match[3] + match[4]
+			found = true
+		}
+		count++
+	})
+	if !found {
+		return body, fmt.Errorf("checklist item %d not found", index)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// forEachChecklistLine calls fn for every checklist line outside code fences and
+// returns the (possibly modified) lines
+func forEachChecklistLine(body string, fn func(lines []string, i int, match []string)) []string {
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	inFence := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if match := checklistPattern.FindStringSubmatch(line); match != nil {
+			fn(lines, i, match)
+		}
+	}
+	return lines
+}
+
+// CheckOffChecklistItem ticks the index-th checklist item in an issue's body
+func (c *Client) CheckOffChecklistItem(issueNumber, index int) error {
+	issue, _, err := c.client.Issues.Get(c.ctx, c.config.Owner, c.config.Repository, issueNumber)
+	if err != nil {
+		return fmt.Errorf("failed to get issue: %w", err)
+	}
+
+	body, err := checkOffChecklistItem(issue.GetBody(), index)
+	if err != nil {
+		return err
+	}
+	if body == issue.GetBody() {
+		return nil // Already ticked
+	}
+
+	_, _, err = c.client.Issues.Edit(c.ctx, c.config.Owner, c.config.Repository, issueNumber, &github.IssueRequest{Body: &body})
+	if err != nil {
+		return fmt.Errorf("failed to update issue body: %w", err)
+	}
+	return nil
+}
+
+// reportChecklistProgress ticks a finished checklist item on the issue and reports progress to Hive
+func (hi *Integration) reportChecklistProgress(task *types.EnhancedTask, repoClient *RepositoryClient, item types.ChecklistItem) {
+	if err := repoClient.Client.CheckOffChecklistItem(task.Number, item.Index); err != nil {
+		fmt.Printf("⚠️ Failed to check off item %d on task #%d: %v\n", item.Index+1, task.Number, err)
+	}
+
+	done := 0
+	for _, other := range task.Checklist {
+		if other.Done {
+			done++
+		}
+	}
+	if err := hi.hiveClient.UpdateTaskStatus(hi.ctx, task.ProjectID, task.Number, "in_progress", map[string]interface{}{
+		"checklist_item":  item.Text,
+		"checklist_done":  done,
+		"checklist_total": len(task.Checklist),
+	}); err != nil {
+		fmt.Printf("⚠️ Failed to report checklist progress to Hive: %v\n", err)
+	}
+}
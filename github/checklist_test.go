@@ -0,0 +1,69 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	gh "github.com/google/go-github/v57/github"
+)
+
+const checklistIssueBody = "Split the widget service.\n\n" +
+	"- [x] Extract the storage layer\n" +
+	"- [ ] Add a gRPC API\n" +
+	"* [ ] Update the docs  \n" +
+	"```\n- [ ] not a task, just an example\n```\n"
+
+func TestChecklistItemsAreParsedAndCheckedOff(t *testing.T) {
+	items := parseChecklist(checklistIssueBody)
+	if len(items) != 3 {
+		t.Fatalf("expected 3 checklist items, got %+v", items)
+	}
+	if !items[0].Done || items[1].Done || items[2].Done {
+		t.Fatalf("unexpected done flags: %+v", items)
+	}
+	if items[1].Text != "Add a gRPC API" || items[2].Text != "Update the docs" || items[2].Index != 2 {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+
+	var editedBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			body, _ := json.Marshal(checklistIssueBody)
+			fmt.Fprintf(w, `{"number":5,"body":%s}`, body)
+		case http.MethodPatch:
+			var request struct {
+				Body string `json:"body"`
+			}
+			json.NewDecoder(r.Body).Decode(&request)
+			editedBody = request.Body
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	client := &Client{client: ghClient, ctx: context.Background(), config: &Config{Owner: "acme", Repository: "widgets"}}
+
+	if err := client.CheckOffChecklistItem(5, 1); err != nil {
+		t.Fatalf("CheckOffChecklistItem returned error: %v", err)
+	}
+	if !strings.Contains(editedBody, "- [x] Add a gRPC API\n") {
+		t.Fatalf("item was not checked off:\n%s", editedBody)
+	}
+	if !strings.Contains(editedBody, "* [ ] Update the docs") || !strings.Contains(editedBody, "- [ ] not a task") {
+		t.Fatalf("other lines should be left alone:\n%s", editedBody)
+	}
+
+	after := parseChecklist(editedBody)
+	if !after[1].Done || after[2].Done {
+		t.Fatalf("unexpected items after check-off: %+v", after)
+	}
+}
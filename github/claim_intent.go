@@ -0,0 +1,188 @@
+package github
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const defaultClaimIntentWindow = 3 * time.Second
+
+// affinityBonus outweighs any capability score, so an agent whose specialization
+// a repository prefers wins arbitration for that repository's tasks
+const affinityBonus = 10.0
+
+// claimIntent records an agent's announced intention to claim a task
+type claimIntent struct {
+	AgentID    string
+	PeerID     string // Peer that announced the intent
+	Score      float64
+	ReceivedAt time.Time
+}
+
+// contender returns the intent as a bid for the resolver
+func (ci *claimIntent) contender() Contender {
+	return Contender{AgentID: ci.AgentID, PeerID: ci.PeerID, Score: ci.Score}
+}
+
+// taskKey identifies a task across repositories
+func taskKey(projectID, taskNumber int) string {
+	return fmt.Sprintf("%d:%d", projectID, taskNumber)
+}
+
+// claimIntentWindow returns how long to wait for competing intents
+func (hi *Integration) claimIntentWindow() time.Duration {
+	if hi.agentConfig != nil && hi.agentConfig.ClaimIntentWindow > 0 {
+		return hi.agentConfig.ClaimIntentWindow
+	}
+	return defaultClaimIntentWindow
+}
+
+// taskMatchScore rates how well this agent's capabilities and specialization fit a task
+func (hi *Integration) taskMatchScore(task *types.EnhancedTask) float64 {
+	score := 0.0
+	if hi.hasAffinity(task) {
+		score += affinityBonus
+	}
+	for _, capability := range hi.capabilities() {
+		switch {
+		case capability == task.TaskType:
+			score += 1.0
+		case capability == "general" || capability == "task-coordination":
+			score += 0.25
+		}
+	}
+	return score
+}
+
+// hasAffinity reports whether the task's repository prefers this agent's specialization
+func (hi *Integration) hasAffinity(task *types.EnhancedTask) bool {
+	if hi.agentConfig == nil || hi.agentConfig.Specialization == "" {
+		return false
+	}
+	preferred := hi.agentConfig.Affinity[fmt.Sprintf("%s/%s", task.Repository.Owner, task.Repository.Repository)]
+	preferred = append(preferred, hi.agentConfig.Affinity[strconv.Itoa(task.ProjectID)]...)
+	for _, specialization := range preferred {
+		if strings.EqualFold(specialization, hi.agentConfig.Specialization) {
+			return true
+		}
+	}
+	return false
+}
+
+// arbitrateClaim announces our intent to claim a task on the Bzzz topic, waits
+// for the arbitration window and reports whether we won. Agents that lose the
+// arbitration back off without touching GitHub.
+func (hi *Integration) arbitrateClaim(task *types.EnhancedTask) bool {
+	key := taskKey(task.ProjectID, task.Number)
+	ours := &claimIntent{
+		AgentID:    hi.config.AgentID,
+		PeerID:     hi.pubsub.ID().String(),
+		Score:      hi.taskMatchScore(task),
+		ReceivedAt: time.Now(),
+	}
+	hi.recordClaimIntent(key, ours)
+
+	intent := map[string]interface{}{
+		"task_key":    key,
+		"project_id":  task.ProjectID,
+		"task_number": task.Number,
+		"agent_id":    ours.AgentID,
+		"score":       ours.Score,
+	}
+	if err := hi.pubsub.PublishBzzzMessage(pubsub.ClaimIntent, intent); err != nil {
+		// Without an announcement we can't arbitrate; fall back to claiming directly
+		fmt.Printf("⚠️ Failed to announce claim intent for task #%d: %v\n", task.Number, err)
+		return true
+	}
+
+	select {
+	case <-hi.ctx.Done():
+		return false
+	case <-time.After(hi.claimIntentWindow()):
+	}
+
+	if winner := hi.resolveClaim(key, ours); winner != ours {
+		fmt.Printf("🤚 Backing off task #%d: agent %s is better matched (%.2f vs %.2f)\n",
+			task.Number, winner.AgentID, winner.Score, ours.Score)
+		return false
+	}
+	return true
+}
+
+// resolveClaim picks the winning intent for a task among ours and any
+// competing intents received during the window, then forgets the task.
+func (hi *Integration) resolveClaim(key string, ours *claimIntent) *claimIntent {
+	hi.claimIntentLock.Lock()
+	defer hi.claimIntentLock.Unlock()
+
+	intents := map[string]*claimIntent{ours.AgentID: ours}
+	contenders := []Contender{ours.contender()}
+	for agentID, competitor := range hi.claimIntents[key] {
+		if agentID != ours.AgentID {
+			intents[agentID] = competitor
+			contenders = append(contenders, competitor.contender())
+		}
+	}
+	delete(hi.claimIntents, key)
+
+	// Map iteration order is random; give the resolver the same order on every agent
+	sort.Slice(contenders, func(i, j int) bool { return contenders[i].AgentID < contenders[j].AgentID })
+
+	resolver := hi.resolver
+	if resolver == nil {
+		resolver = ScoreResolver{}
+	}
+	winner, exists := intents[resolver.Resolve(key, contenders).AgentID]
+	if !exists {
+		return ours
+	}
+	return winner
+}
+
+// recordClaimIntent stores an intent and prunes intents that are too old to matter
+func (hi *Integration) recordClaimIntent(key string, intent *claimIntent) {
+	hi.claimIntentLock.Lock()
+	defer hi.claimIntentLock.Unlock()
+
+	cutoff := time.Now().Add(-2 * hi.claimIntentWindow())
+	for k, intents := range hi.claimIntents {
+		for agentID, existing := range intents {
+			if existing.ReceivedAt.Before(cutoff) {
+				delete(intents, agentID)
+			}
+		}
+		if len(intents) == 0 {
+			delete(hi.claimIntents, k)
+		}
+	}
+
+	if hi.claimIntents[key] == nil {
+		hi.claimIntents[key] = make(map[string]*claimIntent)
+	}
+	hi.claimIntents[key][intent.AgentID] = intent
+}
+
+// handleClaimIntent records a competing intent announced by another agent
+func (hi *Integration) handleClaimIntent(msg pubsub.Message, from peer.ID) {
+	key, _ := msg.Data["task_key"].(string)
+	agentID, _ := msg.Data["agent_id"].(string)
+	score, _ := msg.Data["score"].(float64)
+	if key == "" || agentID == "" || agentID == hi.config.AgentID {
+		return
+	}
+
+	hi.recordClaimIntent(key, &claimIntent{
+		AgentID:    agentID,
+		PeerID:     msg.From, // The originator; from is only the peer that relayed it
+		Score:      score,
+		ReceivedAt: time.Now(),
+	})
+	fmt.Printf("📣 Claim intent for task %s from %s (agent %s, score %.2f)\n", key, from.ShortString(), agentID, score)
+}
@@ -0,0 +1,326 @@
+package github
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/sec"
+	blankhost "github.com/libp2p/go-libp2p/p2p/host/blank"
+	"github.com/libp2p/go-libp2p/p2p/host/eventbus"
+	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoremem"
+	"github.com/libp2p/go-libp2p/p2p/muxer/yamux"
+	"github.com/libp2p/go-libp2p/p2p/net/swarm"
+	tptu "github.com/libp2p/go-libp2p/p2p/net/upgrader"
+	"github.com/libp2p/go-libp2p/p2p/security/noise"
+	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func newTestIntegration(agentID string, capabilities []string) *Integration {
+	return &Integration{
+		ctx:          context.Background(),
+		config:       &IntegrationConfig{AgentID: agentID, Capabilities: capabilities},
+		claimIntents: make(map[string]map[string]*claimIntent),
+	}
+}
+
+// announce delivers an agent's intent to a peer the way the Bzzz topic would
+func announce(to *Integration, key string, intent *claimIntent) {
+	to.handleBzzzMessage(pubsub.Message{
+		Type: pubsub.ClaimIntent,
+		From: intent.PeerID,
+		Data: map[string]interface{}{
+			"task_key": key,
+			"agent_id": intent.AgentID,
+			"score":    intent.Score,
+		},
+	}, peer.ID(""))
+}
+
+func TestClaimIntentBetterMatchWins(t *testing.T) {
+	task := &types.EnhancedTask{
+		Number:     42,
+		TaskType:   "code-generation",
+		ProjectID:  7,
+		Repository: hive.Repository{Owner: "acme", Repository: "widgets"},
+	}
+	key := taskKey(task.ProjectID, task.Number)
+
+	specialist := newTestIntegration("agent-b", []string{"code-generation", "general"})
+	generalist := newTestIntegration("agent-a", []string{"general"})
+
+	specialistIntent := &claimIntent{AgentID: "agent-b", Score: specialist.taskMatchScore(task)}
+	generalistIntent := &claimIntent{AgentID: "agent-a", Score: generalist.taskMatchScore(task)}
+
+	specialist.recordClaimIntent(key, specialistIntent)
+	generalist.recordClaimIntent(key, generalistIntent)
+	announce(specialist, key, generalistIntent)
+	announce(generalist, key, specialistIntent)
+
+	if winner := specialist.resolveClaim(key, specialistIntent); winner != specialistIntent {
+		t.Fatalf("specialist should win its own arbitration, got %s", winner.AgentID)
+	}
+	if winner := generalist.resolveClaim(key, generalistIntent); winner.AgentID != "agent-b" {
+		t.Fatalf("generalist should back off in favour of agent-b, got %s", winner.AgentID)
+	}
+	if _, ok := generalist.claimIntents[key]; ok {
+		t.Fatalf("resolved task should be forgotten")
+	}
+}
+
+func TestClaimIntentTieBreaksOnAgentID(t *testing.T) {
+	low := &claimIntent{AgentID: "agent-a", Score: 1}
+	high := &claimIntent{AgentID: "agent-b", Score: 1}
+
+	for _, contenders := range [][]Contender{
+		{low.contender(), high.contender()},
+		{high.contender(), low.contender()},
+	} {
+		if winner := (ScoreResolver{}).Resolve("7:42", contenders); winner.AgentID != "agent-a" {
+			t.Fatalf("equal scores should be broken by the lower agent ID, got %s", winner.AgentID)
+		}
+	}
+}
+
+func TestRepositoryAffinityWinsArbitration(t *testing.T) {
+	task := &types.EnhancedTask{
+		Number:     42,
+		TaskType:   "code-generation",
+		ProjectID:  7,
+		Repository: hive.Repository{Owner: "acme", Repository: "gpu-kernels"},
+	}
+	key := taskKey(task.ProjectID, task.Number)
+
+	affine := newTestIntegration("agent-z", []string{"general"})
+	affine.agentConfig = &config.AgentConfig{
+		Specialization: "gpu",
+		Affinity:       map[string][]string{"acme/gpu-kernels": {"GPU"}},
+	}
+	generalist := newTestIntegration("agent-a", []string{"code-generation", "general"})
+	generalist.agentConfig = &config.AgentConfig{Specialization: "general_developer", Affinity: affine.agentConfig.Affinity}
+
+	affineIntent := &claimIntent{AgentID: "agent-z", Score: affine.taskMatchScore(task)}
+	generalistIntent := &claimIntent{AgentID: "agent-a", Score: generalist.taskMatchScore(task)}
+	generalist.recordClaimIntent(key, generalistIntent)
+	announce(generalist, key, affineIntent)
+
+	if winner := generalist.resolveClaim(key, generalistIntent); winner.AgentID != "agent-z" {
+		t.Fatalf("generalist should defer to the agent with affinity for acme/gpu-kernels, got %s", winner.AgentID)
+	}
+
+	// Affinity can also be keyed by Hive project ID
+	byProject := newTestIntegration("agent-y", []string{"general"})
+	byProject.agentConfig = &config.AgentConfig{Specialization: "gpu", Affinity: map[string][]string{"7": {"gpu"}}}
+	if !byProject.hasAffinity(task) {
+		t.Error("expected affinity to match on project ID")
+	}
+}
+
+func TestBestScoredOfThreeContendersWins(t *testing.T) {
+	task := &types.EnhancedTask{
+		Number:     42,
+		TaskType:   "testing",
+		ProjectID:  7,
+		Repository: hive.Repository{Owner: "acme", Repository: "widgets"},
+	}
+	key := taskKey(task.ProjectID, task.Number)
+
+	agents := []*Integration{
+		newTestIntegration("agent-a", []string{"general"}),
+		newTestIntegration("agent-b", []string{"testing", "general"}),
+		newTestIntegration("agent-c", []string{"code-generation"}),
+	}
+	intents := make([]*claimIntent, len(agents))
+	for i, agent := range agents {
+		intents[i] = &claimIntent{
+			AgentID: agent.config.AgentID,
+			PeerID:  "peer-" + agent.config.AgentID,
+			Score:   agent.taskMatchScore(task),
+		}
+		agent.recordClaimIntent(key, intents[i])
+	}
+	// Everyone hears everyone else's intent
+	for i, agent := range agents {
+		for j, intent := range intents {
+			if i != j {
+				announce(agent, key, intent)
+			}
+		}
+	}
+
+	for i, agent := range agents {
+		if winner := agent.resolveClaim(key, intents[i]); winner.AgentID != "agent-b" {
+			t.Errorf("%s resolved the task to %s, want agent-b", agent.config.AgentID, winner.AgentID)
+		}
+	}
+}
+
+func TestScoreResolverBreaksTiesByPeerID(t *testing.T) {
+	winner := ScoreResolver{}.Resolve("7:42", []Contender{
+		{AgentID: "agent-a", PeerID: "QmZ", Score: 1},
+		{AgentID: "agent-b", PeerID: "QmA", Score: 1},
+		{AgentID: "agent-c", PeerID: "QmM", Score: 1},
+	})
+	if winner.AgentID != "agent-b" {
+		t.Errorf("expected the lowest peer ID to win a tie, got %s", winner.AgentID)
+	}
+}
+
+// newListeningTestHost creates a host listening on loopback TCP, so PubSubs on
+// separate hosts can gossip to each other
+func newListeningTestHost(t *testing.T) host.Host {
+	t.Helper()
+
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	peerstore, err := pstoremem.NewPeerstore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	peerstore.AddPrivKey(id, priv)
+	peerstore.AddPubKey(id, priv.GetPublic())
+
+	network, err := swarm.NewSwarm(id, peerstore, eventbus.NewBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	muxers := []tptu.StreamMuxer{{ID: yamux.ID, Muxer: yamux.DefaultTransport}}
+	security, err := noise.New(noise.ID, priv, muxers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upgrader, err := tptu.New([]sec.SecureTransport{security}, muxers, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport, err := tcp.NewTCPTransport(upgrader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := network.AddTransport(transport); err != nil {
+		t.Fatal(err)
+	}
+	if err := network.Listen(ma.StringCast("/ip4/127.0.0.1/tcp/0")); err != nil {
+		t.Fatal(err)
+	}
+	h := blankhost.NewBlankHost(network)
+	t.Cleanup(func() { h.Close() })
+	return h
+}
+
+// newConnectedTestPubSubs creates two PubSubs on connected hosts and waits until
+// Bzzz messages get through both ways
+func newConnectedTestPubSubs(t *testing.T) (*pubsub.PubSub, *pubsub.PubSub) {
+	t.Helper()
+
+	hosts := []host.Host{newListeningTestHost(t), newListeningTestHost(t)}
+	nodes := make([]*pubsub.PubSub, len(hosts))
+	for i, h := range hosts {
+		ps, err := pubsub.NewPubSub(context.Background(), h, "bzzz/test/coordination", "antennae/test/meta-discussion")
+		if err != nil {
+			t.Fatalf("failed to create PubSub: %v", err)
+		}
+		t.Cleanup(func() { ps.Close() })
+		nodes[i] = ps
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := hosts[0].Connect(ctx, peer.AddrInfo{ID: hosts[1].ID(), Addrs: hosts[1].Addrs()}); err != nil {
+		t.Fatalf("failed to connect the test hosts: %v", err)
+	}
+
+	// Gossip isn't flowing as soon as the hosts connect, so probe until it is
+	const probe = pubsub.MessageType("test_probe")
+	for i, ps := range nodes {
+		arrived := make(chan struct{}, 1)
+		ps.AddBzzzMessageHandler(func(msg pubsub.Message, from peer.ID) {
+			if msg.Type == probe {
+				select {
+				case arrived <- struct{}{}:
+				default:
+				}
+			}
+		})
+		sender := nodes[1-i]
+		for probing := true; probing; {
+			sender.PublishBzzzMessage(probe, map[string]interface{}{})
+			select {
+			case <-arrived:
+				probing = false
+			case <-time.After(50 * time.Millisecond):
+			case <-ctx.Done():
+				t.Fatal("gossip never got through between the test hosts")
+			}
+		}
+	}
+	return nodes[0], nodes[1]
+}
+
+func TestTwoAgentsArbitrateAClaimOverTheMesh(t *testing.T) {
+	task := &types.EnhancedTask{
+		Number:     42,
+		TaskType:   "code-generation",
+		ProjectID:  7,
+		Repository: hive.Repository{Owner: "acme", Repository: "widgets"},
+	}
+	generalistPS, specialistPS := newConnectedTestPubSubs(t)
+
+	newAgent := func(ps *pubsub.PubSub, agentID string, capabilities []string) *Integration {
+		agent := newTestIntegration(agentID, capabilities)
+		agent.pubsub = ps
+		agent.agentConfig = &config.AgentConfig{ClaimIntentWindow: 500 * time.Millisecond}
+		ps.AddBzzzMessageHandler(agent.handleBzzzMessage)
+		return agent
+	}
+	generalist := newAgent(generalistPS, "agent-a", []string{"general"})
+	specialist := newAgent(specialistPS, "agent-b", []string{"code-generation", "general"})
+
+	// Another component on the generalist's node listens to the same topic;
+	// registering it must not unhook the agent
+	var mu sync.Mutex
+	var observed []string
+	generalistPS.AddBzzzMessageHandler(func(msg pubsub.Message, from peer.ID) {
+		mu.Lock()
+		defer mu.Unlock()
+		observed = append(observed, string(msg.Type))
+	})
+
+	var wg sync.WaitGroup
+	won := make(map[string]bool)
+	for _, agent := range []*Integration{generalist, specialist} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			claimed := agent.arbitrateClaim(task)
+			mu.Lock()
+			won[agent.config.AgentID] = claimed
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !won["agent-b"] || won["agent-a"] {
+		t.Fatalf("expected only the better-matched agent-b to claim, got %v", won)
+	}
+	if len(observed) == 0 || observed[0] != string(pubsub.ClaimIntent) {
+		t.Fatalf("expected the second handler to see agent-b's claim intent too, got %v", observed)
+	}
+}
@@ -0,0 +1,69 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/executor"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+)
+
+const defaultClaimLease = 5 * time.Minute
+
+// claimLease returns how long a claim lasts in Hive without renewal
+func (hi *Integration) claimLease() time.Duration {
+	if hi.agentConfig != nil && hi.agentConfig.ClaimLease > 0 {
+		return hi.agentConfig.ClaimLease
+	}
+	return defaultClaimLease
+}
+
+// startLeaseRenewal renews our lease on a task a few times per lease period. If
+// another agent has reclaimed the task since our lease lapsed, it owns the task
+// now and our run is stopped.
+func (hi *Integration) startLeaseRenewal(task *types.EnhancedTask) (stop func()) {
+	lease := hi.claimLease()
+	return executor.StartLeaseRenewer(hi.ctx, task.Number, lease/3, func(ctx context.Context) error {
+		err := hi.hiveClient.RenewClaim(ctx, task.ProjectID, task.Number, hi.config.AgentID, lease)
+		if errors.Is(err, hive.ErrClaimHeld) {
+			hi.abandonTask(task, "another agent reclaimed the task after our lease lapsed")
+		}
+		return err
+	})
+}
+
+// getReclaimableTasks finds tasks whose claiming agent let its lease expire. They
+// are still assigned on GitHub, so they never show up as available tasks.
+func (hi *Integration) getReclaimableTasks(repoClient *RepositoryClient) []*types.EnhancedTask {
+	claims, err := hi.hiveClient.GetClaims(hi.ctx, repoClient.Repository.ProjectID)
+	if err != nil {
+		fmt.Printf("⚠️ Failed to get claim leases for %s/%s: %v\n",
+			repoClient.Repository.Owner, repoClient.Repository.Repository, err)
+		return nil
+	}
+
+	var reclaimable []*types.EnhancedTask
+	now := time.Now()
+	for _, claim := range claims {
+		if claim.AgentID == hi.config.AgentID || !claim.Expired(now) {
+			continue
+		}
+
+		task, err := repoClient.Client.GetTask(claim.TaskNumber)
+		if err != nil {
+			fmt.Printf("⚠️ Failed to get task #%d with expired lease: %v\n", claim.TaskNumber, err)
+			continue
+		}
+		if task.State != "open" {
+			continue
+		}
+
+		enhancedTask := newEnhancedTask(repoClient, task)
+		enhancedTask.ReclaimedFrom = claim.AgentID
+		reclaimable = append(reclaimable, enhancedTask)
+	}
+	return reclaimable
+}
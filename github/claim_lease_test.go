@@ -0,0 +1,182 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	gh "github.com/google/go-github/v57/github"
+)
+
+// fakeLeaseHive serves the Hive claim endpoints with lease semantics, plus the
+// GitHub issue lookup used when reclaiming
+type fakeLeaseHive struct {
+	mu     sync.Mutex
+	leases map[int]*hive.ClaimLease
+}
+
+func (f *fakeLeaseHive) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && (r.URL.Path == "/api/bzzz/projects/7/claim" || r.URL.Path == "/api/bzzz/projects/7/claim/renew"):
+		var req hive.TaskClaimRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if held, ok := f.leases[req.TaskNumber]; ok && held.AgentID != req.AgentID && !held.Expired(time.Now()) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.leases[req.TaskNumber] = &hive.ClaimLease{TaskNumber: req.TaskNumber, AgentID: req.AgentID, LeaseExpiresAt: req.LeaseExpiresAt}
+	case r.Method == http.MethodPost && r.URL.Path == "/api/bzzz/projects/7/claim/release":
+		var req hive.TaskClaimRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if held, ok := f.leases[req.TaskNumber]; ok && held.AgentID != req.AgentID {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		delete(f.leases, req.TaskNumber)
+	case r.URL.Path == "/api/bzzz/projects/7/claims":
+		claims := []hive.ClaimLease{}
+		for _, lease := range f.leases {
+			claims = append(claims, *lease)
+		}
+		json.NewEncoder(w).Encode(claims)
+	case r.URL.Path == "/repos/acme/widgets/issues/42":
+		fmt.Fprint(w, `{"number":42,"state":"open","title":"Abandoned task","assignees":[{"login":"bzzz-agent"}]}`)
+	default:
+		fmt.Fprint(w, `{}`)
+	}
+}
+
+// expire simulates the claiming agent dying: its lease lapses without renewal
+func (f *fakeLeaseHive) expire(taskNumber int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.leases[taskNumber].LeaseExpiresAt = time.Now().Add(-time.Second).Unix()
+}
+
+func TestExpiredLeaseIsReclaimedByAnotherAgent(t *testing.T) {
+	fake := &fakeLeaseHive{leases: make(map[int]*hive.ClaimLease)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client: &Client{
+			client: ghClient,
+			ctx:    context.Background(),
+			config: &Config{Owner: "acme", Repository: "widgets", InProgressLabel: "in-progress"},
+		},
+		Repository: hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets"},
+	}
+
+	hiveClient := hive.NewHiveClient(server.URL, "")
+	newAgent := func(agentID string) *Integration {
+		return &Integration{
+			ctx:         context.Background(),
+			hiveClient:  hiveClient,
+			config:      &IntegrationConfig{AgentID: agentID},
+			agentConfig: &config.AgentConfig{ClaimLease: time.Minute},
+		}
+	}
+	agentA, agentB := newAgent("agent-a"), newAgent("agent-b")
+	ctx := context.Background()
+
+	if err := hiveClient.ClaimTask(ctx, 7, 42, "agent-a", agentA.claimLease()); err != nil {
+		t.Fatalf("agent-a failed to claim: %v", err)
+	}
+	if err := hiveClient.ClaimTask(ctx, 7, 42, "agent-b", agentB.claimLease()); !errors.Is(err, hive.ErrClaimHeld) {
+		t.Fatalf("expected agent-b to be refused while the lease is live, got %v", err)
+	}
+	if tasks := agentB.getReclaimableTasks(repoClient); len(tasks) != 0 {
+		t.Fatalf("live lease should not be reclaimable, got %d task(s)", len(tasks))
+	}
+
+	fake.expire(42)
+
+	tasks := agentB.getReclaimableTasks(repoClient)
+	if len(tasks) != 1 || tasks[0].Number != 42 || tasks[0].ReclaimedFrom != "agent-a" {
+		t.Fatalf("expected task #42 to be reclaimable from agent-a, got %+v", tasks)
+	}
+	if err := hiveClient.ClaimTask(ctx, 7, 42, "agent-b", agentB.claimLease()); err != nil {
+		t.Fatalf("agent-b failed to reclaim expired task: %v", err)
+	}
+
+	// agent-a coming back late finds it has lost the task
+	if err := hiveClient.RenewClaim(ctx, 7, 42, "agent-a", agentA.claimLease()); !errors.Is(err, hive.ErrClaimHeld) {
+		t.Fatalf("expected agent-a's renewal to be refused, got %v", err)
+	}
+
+	// and stops working on it, leaving the issue to agent-b
+	agentA.agentConfig.ClaimLease = 30 * time.Millisecond
+	task := tasks[0]
+	runCtx, done := agentA.registerRunning(task)
+	defer done()
+	stopRenewing := agentA.startLeaseRenewal(task)
+	defer stopRenewing()
+	select {
+	case <-runCtx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent-a kept running a task it lost the lease on")
+	}
+	if !agentA.keepsIssue(task) {
+		t.Error("agent-a should leave the issue to the agent that reclaimed it")
+	}
+}
+
+func TestFailedGitHubClaimGivesBackTheHiveLease(t *testing.T) {
+	fake := &fakeLeaseHive{leases: make(map[int]*hive.ClaimLease)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/acme/widgets/issues/42" {
+			// Someone assigned the issue by hand, so the GitHub claim fails
+			fmt.Fprint(w, `{"number":42,"state":"open","assignee":{"login":"octocat"}}`)
+			return
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client: &Client{
+			client: ghClient,
+			ctx:    context.Background(),
+			config: &Config{Owner: "acme", Repository: "widgets", InProgressLabel: "in-progress"},
+		},
+		Repository: hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets"},
+	}
+	hiveClient := hive.NewHiveClient(server.URL, "")
+	agent := &Integration{
+		ctx:         context.Background(),
+		hiveClient:  hiveClient,
+		config:      &IntegrationConfig{AgentID: "agent-a"},
+		agentConfig: &config.AgentConfig{ClaimLease: time.Minute},
+	}
+	task := &types.EnhancedTask{Number: 42, ProjectID: 7, Repository: repoClient.Repository}
+
+	if err := agent.claimTask(context.Background(), task, repoClient); !errors.Is(err, ErrTaskAlreadyClaimed) {
+		t.Fatalf("expected the GitHub claim to fail, got %v", err)
+	}
+	fake.mu.Lock()
+	_, leased := fake.leases[42]
+	fake.mu.Unlock()
+	if leased {
+		t.Fatal("expected the Hive lease to be given back when the GitHub claim failed")
+	}
+	if err := hiveClient.ClaimTask(context.Background(), 7, 42, "agent-b", time.Minute); err != nil {
+		t.Fatalf("expected another agent to be able to claim the task at once, got %v", err)
+	}
+}
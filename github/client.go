@@ -3,13 +3,39 @@ package github
 import (
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/anthonyrawlins/bzzz/pkg/types"
 	"github.com/google/go-github/v57/github"
 	"golang.org/x/oauth2"
 )
 
+// Task branch creation is retried a few times before the claim is abandoned
+const branchCreateAttempts = 3
+
+var branchRetryDelay = 2 * time.Second
+
+// ErrTaskAlreadyClaimed is wrapped when a task is already assigned on GitHub
+var ErrTaskAlreadyClaimed = errors.New("task already claimed")
+
+// ErrRepoAccess is wrapped when the token cannot see or modify the repository
+var ErrRepoAccess = errors.New("cannot access repository")
+
+// wrapRepoAccess marks permission and not-found errors as ErrRepoAccess
+func wrapRepoAccess(err error) error {
+	switch classifyGitHubError(err) {
+	case "permission_denied", "not_found":
+		return fmt.Errorf("%w: %w", ErrRepoAccess, err)
+	}
+	return err
+}
+
 // Client wraps the GitHub API client for Bzzz task management
 type Client struct {
 	client *github.Client
@@ -27,10 +53,21 @@ type Config struct {
 	TaskLabel       string // Label for Bzzz tasks
 	InProgressLabel string // Label for tasks in progress
 	CompletedLabel  string // Label for completed tasks
+	NeedsHumanLabel string // Label for tasks handed over to humans
 	
 	// Branch management
 	BaseBranch string // Base branch for task branches
 	BranchPrefix string // Prefix for task branches
+	
+	// Assignment
+	Assignee string // GitHub username claimed tasks are assigned to
+
+	// GitHub Enterprise Server; empty BaseURL means github.com
+	BaseURL   string // e.g. https://github.example.com/api/v3/; /api/v3/ is added if missing
+	UploadURL string // Empty derives https://<host>/api/uploads/ from BaseURL
+
+	// Claim comment and pull request text; nil uses the built-in English text
+	Templates *Templates
 }
 
 // NewClient creates a new GitHub client for Bzzz integration
@@ -53,6 +90,9 @@ func NewClient(ctx context.Context, config *Config) (*Client, error) {
 	if config.CompletedLabel == "" {
 		config.CompletedLabel = "completed"
 	}
+	if config.NeedsHumanLabel == "" {
+		config.NeedsHumanLabel = DefaultNeedsHumanLabel
+	}
 	if config.BaseBranch == "" {
 		config.BaseBranch = "main"
 	}
@@ -65,9 +105,13 @@ func NewClient(ctx context.Context, config *Config) (*Client, error) {
 		&oauth2.Token{AccessToken: config.AccessToken},
 	)
 	tc := oauth2.NewClient(ctx, ts)
+	ghClient, err := newGitHubClient(tc, config)
+	if err != nil {
+		return nil, err
+	}
 	
 	client := &Client{
-		client: github.NewClient(tc),
+		client: ghClient,
 		ctx:    ctx,
 		config: config,
 	}
@@ -82,15 +126,57 @@ func NewClient(ctx context.Context, config *Config) (*Client, error) {
 		return nil, fmt.Errorf("failed to verify base branch: %w", err)
 	}
 	
+	// Make sure the labels we filter and tag with exist
+	if err := client.ensureLabels(); err != nil {
+		fmt.Printf("⚠️ Failed to set up labels in %s/%s: %v\n", config.Owner, config.Repository, err)
+	}
+	
+	return client, nil
+}
+
+// newGitHubClient creates an API client for github.com, or for the GitHub
+// Enterprise Server at config.BaseURL when one is set
+func newGitHubClient(httpClient *http.Client, config *Config) (*github.Client, error) {
+	if config.BaseURL == "" {
+		return github.NewClient(httpClient), nil
+	}
+	if err := ValidateEnterpriseURL(config.BaseURL); err != nil {
+		return nil, err
+	}
+
+	uploadURL := config.UploadURL
+	if uploadURL == "" {
+		base, _ := url.Parse(config.BaseURL)
+		uploadURL = (&url.URL{Scheme: base.Scheme, Host: base.Host, Path: "/api/uploads/"}).String()
+	} else if err := ValidateEnterpriseURL(uploadURL); err != nil {
+		return nil, err
+	}
+
+	client, err := github.NewClient(httpClient).WithEnterpriseURLs(config.BaseURL, uploadURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitHub Enterprise client for %s: %w", config.BaseURL, err)
+	}
 	return client, nil
 }
 
+// ValidateEnterpriseURL checks that a GitHub Enterprise URL is an absolute http(s) URL
+func ValidateEnterpriseURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid GitHub Enterprise URL %q: %w", raw, err)
+	}
+	if (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return fmt.Errorf("invalid GitHub Enterprise URL %q: must be an http(s) URL with a host", raw)
+	}
+	return nil
+}
+
 // verifyAccess checks if we can access the configured repository
 func (c *Client) verifyAccess() error {
 	_, _, err := c.client.Repositories.Get(c.ctx, c.config.Owner, c.config.Repository)
 	if err != nil {
-		return fmt.Errorf("cannot access repository %s/%s: %w", 
-			c.config.Owner, c.config.Repository, err)
+		return fmt.Errorf("%w %s/%s: %w",
+			ErrRepoAccess, c.config.Owner, c.config.Repository, err)
 	}
 	return nil
 }
@@ -114,6 +200,44 @@ func (c *Client) verifyBaseBranch() error {
 	return nil
 }
 
+// labelColors are used when creating missing labels
+var labelColors = map[string]string{
+	"task":        "fbca04",
+	"in-progress": "0e8a16",
+	"completed":   "5319e7",
+	"needs-human": "d93f0b",
+}
+
+// ensureLabels creates any configured label that doesn't exist in the repository yet
+func (c *Client) ensureLabels() error {
+	labels := []struct{ name, kind string }{
+		{c.config.TaskLabel, "task"},
+		{c.config.InProgressLabel, "in-progress"},
+		{c.config.CompletedLabel, "completed"},
+		{c.config.NeedsHumanLabel, "needs-human"},
+	}
+	
+	for _, label := range labels {
+		_, resp, err := c.client.Issues.GetLabel(c.ctx, c.config.Owner, c.config.Repository, label.name)
+		if err == nil {
+			continue
+		}
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("failed to check label %s: %w", label.name, err)
+		}
+		
+		color := labelColors[label.kind]
+		if _, _, err := c.client.Issues.CreateLabel(c.ctx, c.config.Owner, c.config.Repository, &github.Label{
+			Name:  &label.name,
+			Color: &color,
+		}); err != nil {
+			return fmt.Errorf("failed to create label %s: %w", label.name, err)
+		}
+		fmt.Printf("🏷️ Created label %s in %s/%s\n", label.name, c.config.Owner, c.config.Repository)
+	}
+	return nil
+}
+
 // Task represents a Bzzz task as a GitHub issue
 type Task struct {
 	ID          int64     `json:"id"`
@@ -132,19 +256,23 @@ type Task struct {
 	Requirements []string          `json:"requirements"`
 	Deliverables []string          `json:"deliverables"`
 	Context      map[string]interface{} `json:"context"`
+
+	// Capabilities an agent must have to claim the task, from a "bzzz-requires" field or label
+	RequiredCapabilities []string `json:"required_capabilities,omitempty"`
 }
 
 // CreateTask creates a new GitHub issue for a Bzzz task
 func (c *Client) CreateTask(task *Task) (*Task, error) {
-	// Prepare issue request
+	// Prepare issue request, with any extra labels the task carries
+	labels := append([]string{
+		c.config.TaskLabel,
+		fmt.Sprintf("priority-%d", task.Priority),
+		fmt.Sprintf("type-%s", task.TaskType),
+	}, task.Labels...)
 	issue := &github.IssueRequest{
-		Title: &task.Title,
-		Body:  github.String(c.formatTaskBody(task)),
-		Labels: &[]string{
-			c.config.TaskLabel,
-			fmt.Sprintf("priority-%d", task.Priority),
-			fmt.Sprintf("type-%s", task.TaskType),
-		},
+		Title:  &task.Title,
+		Body:   github.String(c.formatTaskBody(task)),
+		Labels: &labels,
 	}
 	
 	// Create the issue
@@ -172,12 +300,22 @@ func (c *Client) ClaimTask(issueNumber int, agentID string) (*Task, error) {
 		issueNumber,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get issue: %w", err)
+		return nil, fmt.Errorf("failed to get issue: %w", wrapRepoAccess(err))
 	}
 	
 	// Check if already assigned
 	if issue.Assignee != nil {
-		return nil, fmt.Errorf("task already assigned to %s", issue.Assignee.GetLogin())
+		return nil, fmt.Errorf("%w: assigned to %s", ErrTaskAlreadyClaimed, issue.Assignee.GetLogin())
+	}
+
+	// An agent whose assignment failed still labels and comments on the issue
+	if c.hasInProgressLabel(issue) {
+		return nil, fmt.Errorf("%w: labelled %s", ErrTaskAlreadyClaimed, c.config.InProgressLabel)
+	}
+	if claimant, err := c.claimingAgent(issueNumber); err != nil {
+		fmt.Printf("⚠️ Failed to check task #%d for claim comments: %v\n", issueNumber, err)
+	} else if claimant != "" && claimant != agentID {
+		return nil, fmt.Errorf("%w: claimed by agent %s", ErrTaskAlreadyClaimed, claimant)
 	}
 	
 	// Attempt atomic assignment using GitHub's native assignment
@@ -204,11 +342,26 @@ func (c *Client) ClaimTask(issueNumber int, agentID string) (*Task, error) {
 		issueRequest,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to claim task: %w", err)
+		return nil, fmt.Errorf("failed to claim task: %w", wrapRepoAccess(err))
+	}
+	
+	// Create the task branch; without it the claim is useless, so undo it
+	if err := c.createTaskBranch(issueNumber, agentID); err != nil {
+		fmt.Printf("❌ Failed to create branch for task #%d, releasing claim: %v\n", issueNumber, err)
+		if releaseErr := c.ReleaseTask(issueNumber); releaseErr != nil {
+			fmt.Printf("⚠️ Failed to release task #%d: %v\n", issueNumber, releaseErr)
+		}
+		return nil, fmt.Errorf("failed to create task branch: %w", err)
 	}
 	
 	// Add a comment to track which Bzzz agent claimed this task
-	claimComment := fmt.Sprintf("🐝 **Task claimed by Bzzz agent:** `%s`\n\nThis task has been automatically claimed by the Bzzz P2P task coordination system.", agentID)
+	claimComment := render(c.templates().ClaimComment, defaultTemplates.ClaimComment, TemplateData{
+		AgentID:     agentID,
+		IssueNumber: issueNumber,
+		Title:       updatedIssue.GetTitle(),
+		Repository:  c.config.Owner + "/" + c.config.Repository,
+		Branch:      c.TaskBranchName(issueNumber, agentID),
+	}) + "\n\n" + claimMarker(agentID)
 	commentRequest := &github.IssueComment{
 		Body: &claimComment,
 	}
@@ -224,12 +377,6 @@ func (c *Client) ClaimTask(issueNumber int, agentID string) (*Task, error) {
 		fmt.Printf("⚠️ Failed to add claim comment: %v\n", err)
 	}
 	
-	// Create a task branch
-	if err := c.createTaskBranch(issueNumber, agentID); err != nil {
-		// Log error but don't fail the claim
-		fmt.Printf("⚠️ Failed to create task branch: %v\n", err)
-	}
-	
 	return c.issueToTask(updatedIssue), nil
 }
 
@@ -292,7 +439,83 @@ func (c *Client) CompleteTask(issueNumber int, agentID string, results map[strin
 	return nil
 }
 
-// ListAvailableTasks returns unassigned Bzzz tasks
+// AddLabel adds a label to an issue
+func (c *Client) AddLabel(issueNumber int, label string) error {
+	_, _, err := c.client.Issues.AddLabelsToIssue(
+		c.ctx,
+		c.config.Owner,
+		c.config.Repository,
+		issueNumber,
+		[]string{label},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add label %s: %w", label, err)
+	}
+	return nil
+}
+
+// RemoveLabel removes a label from an issue
+func (c *Client) RemoveLabel(issueNumber int, label string) error {
+	_, err := c.client.Issues.RemoveLabelForIssue(
+		c.ctx,
+		c.config.Owner,
+		c.config.Repository,
+		issueNumber,
+		label,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove label %s: %w", label, err)
+	}
+	return nil
+}
+
+// ReleaseTask unassigns a claimed task and drops its in-progress label so it can be picked up again
+func (c *Client) ReleaseTask(issueNumber int) error {
+	issue, _, err := c.client.Issues.Get(
+		c.ctx,
+		c.config.Owner,
+		c.config.Repository,
+		issueNumber,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get issue: %w", err)
+	}
+	
+	assignees := make([]string, 0, len(issue.Assignees))
+	for _, assignee := range issue.Assignees {
+		assignees = append(assignees, assignee.GetLogin())
+	}
+	if len(assignees) > 0 {
+		if _, _, err := c.client.Issues.RemoveAssignees(c.ctx, c.config.Owner, c.config.Repository, issueNumber, assignees); err != nil {
+			return fmt.Errorf("failed to unassign issue: %w", err)
+		}
+	}
+	
+	if err := c.retireClaimComments(issueNumber); err != nil {
+		fmt.Printf("⚠️ Failed to retire claim comments on task #%d: %v\n", issueNumber, err)
+	}
+
+	if c.hasInProgressLabel(issue) {
+		return c.RemoveLabel(issueNumber, c.config.InProgressLabel)
+	}
+	return nil
+}
+
+// GetTask fetches a single task by issue number
+func (c *Client) GetTask(issueNumber int) (*Task, error) {
+	issue, _, err := c.client.Issues.Get(
+		c.ctx,
+		c.config.Owner,
+		c.config.Repository,
+		issueNumber,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get issue: %w", err)
+	}
+	return c.issueToTask(issue), nil
+}
+
+// ListAvailableTasks returns unassigned Bzzz tasks that no agent has labelled in progress
 func (c *Client) ListAvailableTasks() ([]*Task, error) {
 	// Search for open issues with Bzzz task label and no assignee
 	opts := &github.IssueListByRepoOptions{
@@ -316,6 +539,11 @@ func (c *Client) ListAvailableTasks() ([]*Task, error) {
 	
 	tasks := make([]*Task, 0, len(issues))
 	for _, issue := range issues {
+		// Unassigned but labelled means another agent's assignment failed
+		if c.hasInProgressLabel(issue) {
+			fmt.Printf("⏭️ Skipping task #%d: already %s\n", issue.GetNumber(), c.config.InProgressLabel)
+			continue
+		}
 		tasks = append(tasks, c.issueToTask(issue))
 	}
 	
@@ -328,11 +556,30 @@ func hashAgentID(agentID string) string {
 	return fmt.Sprintf("%x", hash[:8]) // Use first 8 bytes (16 hex chars)
 }
 
-// createTaskBranch creates a new branch for task work
+// TaskBranchName returns the branch an agent works on for a task. The same name
+// is created on claim and pushed to by the executor.
+func (c *Client) TaskBranchName(issueNumber int, agentID string) string {
+	return fmt.Sprintf("%s%d-%s", c.config.BranchPrefix, issueNumber, hashAgentID(agentID))
+}
+
+// createTaskBranch creates the task branch, retrying transient failures
 func (c *Client) createTaskBranch(issueNumber int, agentID string) error {
-	hashedAgentID := hashAgentID(agentID)
-	branchName := fmt.Sprintf("%s%d-%s", c.config.BranchPrefix, issueNumber, hashedAgentID)
-	
+	var err error
+	for attempt := 1; attempt <= branchCreateAttempts; attempt++ {
+		if err = c.createBranch(c.TaskBranchName(issueNumber, agentID)); err == nil {
+			return nil
+		}
+		if attempt < branchCreateAttempts {
+			fmt.Printf("⚠️ Branch creation attempt %d for task #%d failed: %v\n", attempt, issueNumber, err)
+			time.Sleep(branchRetryDelay * time.Duration(attempt))
+		}
+	}
+	return err
+}
+
+// createBranch creates a branch off the base branch. A branch that already
+// exists (e.g. from an earlier claim by the same agent) is reused.
+func (c *Client) createBranch(branchName string) error {
 	// Get the base branch reference
 	baseRef, _, err := c.client.Git.GetRef(
 		c.ctx,
@@ -358,6 +605,12 @@ func (c *Client) createTaskBranch(issueNumber int, agentID string) error {
 		c.config.Repository,
 		newRef,
 	)
+	var ghErr *github.ErrorResponse
+	if errors.As(err, &ghErr) && ghErr.Response.StatusCode == http.StatusUnprocessableEntity &&
+		strings.Contains(strings.ToLower(ghErr.Message), "already exists") {
+		fmt.Printf("🌿 Reusing existing task branch: %s\n", branchName)
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create branch: %w", err)
 	}
@@ -366,18 +619,63 @@ func (c *Client) createTaskBranch(issueNumber int, agentID string) error {
 	return nil
 }
 
+// PullRequestOptions describes how a task's pull request should be opened
+type PullRequestOptions struct {
+	Draft        bool              // Open as a draft that a human must mark ready
+	Diff         *types.DiffStats  // Size of the change, shown in the description
+	ReviewReason string            // Why a human needs to look before this is merged
+	Merge        types.MergeStatus // How the branch stands against the latest base branch
+	Model        string            // Reasoning model that did the work, recorded for outcome tracking
+	TaskType     string
+	IssueTitle   string // For the title and body templates
+}
+
+// formatFileList formats file names for a pull request description
+func formatFileList(files []string) string {
+	if len(files) == 0 {
+		return "some files"
+	}
+	return "`" + strings.Join(files, "`, `") + "`"
+}
+
 // CreatePullRequest creates a new pull request for a completed task.
-func (c *Client) CreatePullRequest(issueNumber int, branchName, agentID string) (*github.PullRequest, error) {
-	title := fmt.Sprintf("fix: resolve issue #%d via bzzz agent %s", issueNumber, agentID)
-	body := fmt.Sprintf("This pull request resolves issue #%d, and was automatically generated by the Bzzz agent `%s`.", issueNumber, agentID)
+func (c *Client) CreatePullRequest(issueNumber int, branchName, agentID string, opts PullRequestOptions) (*github.PullRequest, error) {
+	data := TemplateData{
+		AgentID:     agentID,
+		IssueNumber: issueNumber,
+		Title:       opts.IssueTitle,
+		Repository:  c.config.Owner + "/" + c.config.Repository,
+		Branch:      branchName,
+	}
+	title := render(c.templates().PullRequestTitle, defaultTemplates.PullRequestTitle, data)
+	body := render(c.templates().PullRequestBody, defaultTemplates.PullRequestBody, data)
+	if opts.Diff != nil {
+		body += fmt.Sprintf("\n\n**Diff size:** %d files changed, +%d/-%d lines", opts.Diff.FilesChanged, opts.Diff.LinesAdded, opts.Diff.LinesRemoved)
+	}
+	if opts.ReviewReason != "" {
+		body += fmt.Sprintf("\n\n⚠️ **Human review required:** %s", opts.ReviewReason)
+	}
+	switch opts.Merge.State {
+	case types.MergeClean:
+		body += "\n\n**Base branch:** rebased onto the latest base branch without conflicts"
+	case types.MergeResolved:
+		body += fmt.Sprintf("\n\n**Base branch:** conflicts in %s were resolved automatically; please check them", formatFileList(opts.Merge.Conflicts))
+	case types.MergeConflicted:
+		body += fmt.Sprintf("\n\n⚔️ **Merge conflicts:** %s conflict with the latest base branch and need resolving", formatFileList(opts.Merge.Conflicts))
+	}
+	if opts.Model != "" {
+		body += "\n\n" + outcomeMarker(opts.Model, opts.TaskType)
+	}
 	head := branchName
 	base := c.config.BaseBranch
+	draft := opts.Draft
 
 	pr := &github.NewPullRequest{
 		Title: &title,
 		Body:  &body,
 		Head:  &head,
 		Base:  &base,
+		Draft: &draft,
 	}
 
 	newPR, _, err := c.client.PullRequests.Create(c.ctx, c.config.Owner, c.config.Repository, pr)
@@ -388,6 +686,78 @@ func (c *Client) CreatePullRequest(issueNumber int, branchName, agentID string)
 	return newPR, nil
 }
 
+// MarkReady promotes a draft pull request to ready for review, e.g. once its checks
+// pass. The REST API can't change draft state, so this goes through GraphQL.
+func (c *Client) MarkReady(prNumber int) error {
+	pr, _, err := c.client.PullRequests.Get(c.ctx, c.config.Owner, c.config.Repository, prNumber)
+	if err != nil {
+		return fmt.Errorf("failed to get pull request #%d: %w", prNumber, err)
+	}
+	if !pr.GetDraft() {
+		return nil
+	}
+
+	mutation := map[string]interface{}{
+		"query":     `mutation($id: ID!) { markPullRequestReadyForReview(input: {pullRequestId: $id}) { pullRequest { isDraft } } }`,
+		"variables": map[string]interface{}{"id": pr.GetNodeID()},
+	}
+	req, err := c.client.NewRequest("POST", graphQLURL(c.client.BaseURL), mutation)
+	if err != nil {
+		return fmt.Errorf("failed to build ready-for-review request: %w", err)
+	}
+
+	var result struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := c.client.Do(c.ctx, req, &result); err != nil {
+		return fmt.Errorf("failed to mark pull request #%d ready: %w", prNumber, err)
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("failed to mark pull request #%d ready: %s", prNumber, result.Errors[0].Message)
+	}
+	return nil
+}
+
+// graphQLURL returns the GraphQL endpoint beside the REST API at base:
+// api.github.com serves it at /graphql, Enterprise Server at /api/graphql
+func graphQLURL(base *url.URL) string {
+	endpoint := *base
+	path := strings.TrimSuffix(endpoint.Path, "/")
+	if strings.HasSuffix(path, "/api/v3") {
+		path = strings.TrimSuffix(path, "/v3")
+	}
+	endpoint.Path = path + "/graphql"
+	return endpoint.String()
+}
+
+// CommentOnPullRequest posts a comment on a pull request's conversation
+func (c *Client) CommentOnPullRequest(prNumber int, body string) error {
+	_, _, err := c.client.Issues.CreateComment(c.ctx, c.config.Owner, c.config.Repository, prNumber, &github.IssueComment{Body: &body})
+	if err != nil {
+		return fmt.Errorf("failed to comment on pull request #%d: %w", prNumber, err)
+	}
+	return nil
+}
+
+// CommentOnIssue posts a comment on an issue
+func (c *Client) CommentOnIssue(issueNumber int, body string) error {
+	_, _, err := c.client.Issues.CreateComment(c.ctx, c.config.Owner, c.config.Repository, issueNumber, &github.IssueComment{Body: &body})
+	if err != nil {
+		return fmt.Errorf("failed to comment on issue #%d: %w", issueNumber, err)
+	}
+	return nil
+}
+
+// DeleteBranch removes a branch from the repository
+func (c *Client) DeleteBranch(branchName string) error {
+	if _, err := c.client.Git.DeleteRef(c.ctx, c.config.Owner, c.config.Repository, "heads/"+branchName); err != nil {
+		return fmt.Errorf("failed to delete branch %s: %w", branchName, err)
+	}
+	return nil
+}
+
 // formatTaskBody formats task details into GitHub issue body
 func (c *Client) formatTaskBody(task *Task) string {
 	body := fmt.Sprintf("**Task Type:** %s\n", task.TaskType)
@@ -450,5 +820,27 @@ func (c *Client) issueToTask(issue *github.Issue) *Task {
 		task.Assignee = issue.Assignee.GetLogin()
 	}
 	
+	// Structured details from the body, then the labels CreateTask adds
+	meta := parseIssueBody(task.Description)
+	task.TaskType = meta.TaskType
+	task.Priority = meta.Priority
+	task.Requirements = meta.Requirements
+	task.Deliverables = meta.Deliverables
+	task.RequiredCapabilities = meta.RequiredCapabilities
+	if len(meta.Env) > 0 {
+		task.Context = map[string]interface{}{"env": meta.Env}
+	}
+	for _, label := range task.Labels {
+		if taskType := strings.TrimPrefix(label, "type-"); taskType != label && task.TaskType == "" {
+			task.TaskType = taskType
+		}
+		if priority, err := strconv.Atoi(strings.TrimPrefix(label, "priority-")); err == nil && task.Priority == 0 {
+			task.Priority = priority
+		}
+		if required, isRequirement := requiredCapabilitiesLabel(label); isRequirement {
+			task.RequiredCapabilities = mergeCapabilities(task.RequiredCapabilities, required)
+		}
+	}
+	
 	return task
 }
\ No newline at end of file
@@ -0,0 +1,313 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	gh "github.com/google/go-github/v57/github"
+)
+
+// recordingGitHub is a fake GitHub API that records requests. createRefStatus
+// controls how branch creation responds.
+type recordingGitHub struct {
+	mu              sync.Mutex
+	requests        []string
+	createRefStatus int
+	claimed         bool
+}
+
+func (f *recordingGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	f.requests = append(f.requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, body))
+	claimed := f.claimed
+	if r.Method == http.MethodPatch {
+		f.claimed = true
+		claimed = true
+	}
+	f.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/repos/acme/widgets/issues/42" && claimed:
+		fmt.Fprint(w, `{"number":42,"state":"open","assignees":[{"login":"bzzz-agent"}],"labels":[{"name":"bzzz-task"},{"name":"in-progress"}]}`)
+	case r.URL.Path == "/repos/acme/widgets/issues/42":
+		fmt.Fprint(w, `{"number":42,"state":"open","labels":[{"name":"bzzz-task"}]}`)
+	case r.URL.Path == "/repos/acme/widgets/git/ref/heads/main":
+		fmt.Fprint(w, `{"ref":"refs/heads/main","object":{"sha":"abc123"}}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/widgets/git/refs":
+		w.WriteHeader(f.createRefStatus)
+		fmt.Fprint(w, `{"message":"server error"}`)
+	default:
+		fmt.Fprint(w, `{}`)
+	}
+}
+
+func (f *recordingGitHub) sawRequest(prefix string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, req := range f.requests {
+		if strings.HasPrefix(req, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	return &Client{
+		client: ghClient,
+		ctx:    context.Background(),
+		config: &Config{
+			Owner:           "acme",
+			Repository:      "widgets",
+			TaskLabel:       "bzzz-task",
+			InProgressLabel: "in-progress",
+			BaseBranch:      "main",
+			BranchPrefix:    "bzzz/task-",
+			Assignee:        "bzzz-agent",
+		},
+	}
+}
+
+func TestClaimTaskCreatesTaskBranch(t *testing.T) {
+	fake := &recordingGitHub{createRefStatus: http.StatusCreated}
+	client := newTestClient(t, fake)
+
+	if _, err := client.ClaimTask(42, "agent-a"); err != nil {
+		t.Fatalf("ClaimTask failed: %v", err)
+	}
+
+	branch := client.TaskBranchName(42, "agent-a")
+	if !strings.HasPrefix(branch, "bzzz/task-42-") {
+		t.Errorf("unexpected branch name %q", branch)
+	}
+	if !fake.sawRequest(fmt.Sprintf(`POST /repos/acme/widgets/git/refs {"ref":"refs/heads/%s"`, branch)) {
+		t.Errorf("expected branch %s to be created, got %v", branch, fake.requests)
+	}
+}
+
+func TestClaimTaskReleasesClaimWhenBranchFails(t *testing.T) {
+	branchRetryDelay = 0
+	fake := &recordingGitHub{createRefStatus: http.StatusInternalServerError}
+	client := newTestClient(t, fake)
+
+	if _, err := client.ClaimTask(42, "agent-a"); err == nil {
+		t.Fatal("expected ClaimTask to fail when the branch can't be created")
+	}
+
+	if !fake.sawRequest("DELETE /repos/acme/widgets/issues/42/assignees") {
+		t.Error("expected the assignment to be rolled back")
+	}
+	if !fake.sawRequest("DELETE /repos/acme/widgets/issues/42/labels/in-progress") {
+		t.Error("expected the in-progress label to be removed")
+	}
+	if fake.sawRequest("POST /repos/acme/widgets/issues/42/comments") {
+		t.Error("a failed claim should not leave a claim comment")
+	}
+}
+
+func TestClaimTaskErrorsAreTyped(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/acme/widgets/issues/42":
+			fmt.Fprint(w, `{"number":42,"state":"open","assignee":{"login":"someone-else"}}`)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message":"Resource not accessible by integration"}`)
+		}
+	}))
+
+	if _, err := client.ClaimTask(42, "agent-a"); !errors.Is(err, ErrTaskAlreadyClaimed) {
+		t.Errorf("expected ErrTaskAlreadyClaimed, got %v", err)
+	}
+	if _, err := client.ClaimTask(43, "agent-a"); !errors.Is(err, ErrRepoAccess) {
+		t.Errorf("expected ErrRepoAccess, got %v", err)
+	}
+	if err := client.verifyAccess(); !errors.Is(err, ErrRepoAccess) {
+		t.Errorf("expected verifyAccess to return ErrRepoAccess, got %v", err)
+	}
+}
+
+func TestListAvailableTasksUsesConfiguredLabel(t *testing.T) {
+	var query url.Values
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/acme/widgets/issues" {
+			query = r.URL.Query()
+		}
+		fmt.Fprint(w, `[]`)
+	}))
+	client.config.TaskLabel = "agent-work"
+
+	if _, err := client.ListAvailableTasks(); err != nil {
+		t.Fatalf("ListAvailableTasks failed: %v", err)
+	}
+	if got := query.Get("labels"); got != "agent-work" {
+		t.Errorf("expected issues filtered by label agent-work, got %q", got)
+	}
+}
+
+func TestEnsureLabelsCreatesMissingLabels(t *testing.T) {
+	fake := &recordingGitHub{}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/labels/needs-review" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+			return
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	client.config.CompletedLabel = "done"
+	client.config.NeedsHumanLabel = "needs-review"
+
+	if err := client.ensureLabels(); err != nil {
+		t.Fatalf("ensureLabels failed: %v", err)
+	}
+	if !fake.sawRequest(`POST /repos/acme/widgets/labels {"name":"needs-review"`) {
+		t.Errorf("expected missing label to be created, got %v", fake.requests)
+	}
+	if fake.sawRequest(`POST /repos/acme/widgets/labels {"name":"done"`) {
+		t.Error("existing labels should not be recreated")
+	}
+}
+
+func TestDraftPullRequestWhenFlagSet(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, body))
+		mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/widgets/pulls":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"number":3,"draft":true}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/pulls/3":
+			fmt.Fprint(w, `{"number":3,"node_id":"PR_kwDO3","draft":true}`)
+		case r.URL.Path == "/graphql":
+			fmt.Fprint(w, `{"data":{"markPullRequestReadyForReview":{"pullRequest":{"isDraft":false}}}}`)
+		}
+	}))
+	hi := &Integration{config: &IntegrationConfig{AgentID: "agent-a", DraftPullRequests: true}}
+	task := &types.EnhancedTask{Number: 42}
+
+	if _, err := hi.openPullRequest(task, &RepositoryClient{Client: client}, "bzzz/task-42", types.DiffStats{FilesChanged: 1}, types.MergeStatus{}); err != nil {
+		t.Fatalf("openPullRequest failed: %v", err)
+	}
+	if err := client.MarkReady(3); err != nil {
+		t.Fatalf("MarkReady failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 3 {
+		t.Fatalf("expected create, get and GraphQL requests, got %v", requests)
+	}
+	if !strings.HasPrefix(requests[0], "POST /repos/acme/widgets/pulls ") || !strings.Contains(requests[0], `"draft":true`) {
+		t.Errorf("expected a draft pull request, got %s", requests[0])
+	}
+	if !strings.HasPrefix(requests[2], "POST /graphql ") || !strings.Contains(requests[2], "markPullRequestReadyForReview") || !strings.Contains(requests[2], `"id":"PR_kwDO3"`) {
+		t.Errorf("expected the draft to be marked ready through GraphQL, got %s", requests[2])
+	}
+}
+
+func TestEnterpriseClientUsesConfiguredBaseURL(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/api/v3/repos/acme/widgets/git/ref/heads/main":
+			fmt.Fprint(w, `{"ref":"refs/heads/main","object":{"sha":"abc123"}}`)
+		case "/api/v3/repos/acme/widgets/pulls/3":
+			fmt.Fprint(w, `{"number":3,"node_id":"PR_kwDO3","draft":true}`)
+		case "/api/graphql":
+			fmt.Fprint(w, `{"data":{"markPullRequestReadyForReview":{"pullRequest":{"isDraft":false}}}}`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(context.Background(), &Config{
+		AccessToken: "token",
+		Owner:       "acme",
+		Repository:  "widgets",
+		BaseURL:     server.URL,
+	})
+	if err != nil {
+		t.Fatalf("failed to create enterprise client: %v", err)
+	}
+	if got := client.client.BaseURL.String(); got != server.URL+"/api/v3/" {
+		t.Fatalf("expected base URL %s/api/v3/, got %s", server.URL, got)
+	}
+	if got := client.client.UploadURL.String(); got != server.URL+"/api/uploads/" {
+		t.Fatalf("expected upload URL %s/api/uploads/, got %s", server.URL, got)
+	}
+	mu.Lock()
+	if len(paths) == 0 || paths[0] != "/api/v3/repos/acme/widgets" {
+		t.Fatalf("expected the access check to go to the enterprise API, got %v", paths)
+	}
+	mu.Unlock()
+
+	// Enterprise serves GraphQL beside the REST API, not under it
+	if err := client.MarkReady(3); err != nil {
+		t.Fatalf("MarkReady failed: %v", err)
+	}
+	mu.Lock()
+	if last := paths[len(paths)-1]; last != "/api/graphql" {
+		t.Errorf("expected the ready-for-review mutation to go to /api/graphql, went to %s", last)
+	}
+	mu.Unlock()
+
+	if _, err := NewClient(context.Background(), &Config{AccessToken: "token", Owner: "acme", Repository: "widgets", BaseURL: "github.example.com"}); err == nil {
+		t.Fatal("expected a base URL without a scheme to be rejected")
+	}
+}
+
+func TestTasksAlreadyInProgressAreSkipped(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/acme/widgets/issues":
+			// #7's claimant labelled it but its assignment failed
+			fmt.Fprint(w, `[{"number":7,"state":"open","labels":[{"name":"bzzz-task"},{"name":"in-progress"}]},{"number":8,"state":"open","labels":[{"name":"bzzz-task"}]}]`)
+		case "/repos/acme/widgets/issues/8":
+			fmt.Fprint(w, `{"number":8,"state":"open","labels":[{"name":"bzzz-task"}]}`)
+		case "/repos/acme/widgets/issues/8/comments":
+			fmt.Fprint(w, `[{"id":1,"body":"Task claimed\n\n<!-- bzzz-claim agent:agent-b -->"}]`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+
+	tasks, err := client.ListAvailableTasks()
+	if err != nil {
+		t.Fatalf("ListAvailableTasks failed: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Number != 8 {
+		t.Fatalf("expected only task #8 to be available, got %+v", tasks)
+	}
+
+	// #8 has no label, but another agent's claim comment is still in force
+	if _, err := client.ClaimTask(8, "agent-a"); !errors.Is(err, ErrTaskAlreadyClaimed) {
+		t.Fatalf("expected the claim comment to block the claim, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package github
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+)
+
+// dependencyLinePattern matches lines such as "Depends on #12" or "Blocked by: acme/api#3, #4"
+var dependencyLinePattern = regexp.MustCompile(`(?i)^\s*(?:[-*+]\s+)?(?:depends on|blocked by)\s*:?\s*(.+)$`)
+
+// issueRefPattern matches "#12" or "owner/repo#12"
+var issueRefPattern = regexp.MustCompile(`(?:([\w.-]+/[\w.-]+))?#(\d+)`)
+
+// parseDependencies extracts the issues a task depends on from its body, skipping fenced code blocks
+func parseDependencies(body string) []types.TaskDependency {
+	var dependencies []types.TaskDependency
+	seen := make(map[types.TaskDependency]bool)
+	inFence := false
+	for _, line := range strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		match := dependencyLinePattern.FindStringSubmatch(line)
+		if inFence || match == nil {
+			continue
+		}
+		for _, ref := range issueRefPattern.FindAllStringSubmatch(match[1], -1) {
+			number, err := strconv.Atoi(ref[2])
+			if err != nil {
+				continue
+			}
+			dependency := types.TaskDependency{Repository: ref[1], Number: number}
+			if !seen[dependency] {
+				seen[dependency] = true
+				dependencies = append(dependencies, dependency)
+			}
+		}
+	}
+	return dependencies
+}
+
+// openDependencies returns the task's dependencies that aren't closed yet.
+// Dependencies that can't be checked count as open so the task never starts early.
+func (hi *Integration) openDependencies(task *types.EnhancedTask, repoClient *RepositoryClient) []string {
+	var open []string
+	for _, dependency := range task.Dependencies {
+		client := hi.dependencyClient(dependency, repoClient)
+		if client == nil {
+			open = append(open, dependency.String()+" (repository not watched)")
+			continue
+		}
+		issue, err := client.Client.GetTask(dependency.Number)
+		if err != nil {
+			open = append(open, fmt.Sprintf("%s (%v)", dependency, err))
+			continue
+		}
+		if issue.State != "closed" {
+			open = append(open, dependency.String())
+			hi.notifyWait(task, dependency, client)
+		}
+	}
+	return open
+}
+
+// WaitListener is told that task can't be claimed yet because it waits on
+// blocking, an open issue in the repository of Hive project blockingProjectID
+type WaitListener func(task *types.EnhancedTask, blocking types.TaskDependency, blockingProjectID int)
+
+// SetWaitListener tells listener about every task left waiting on an open
+// dependency, e.g. so coordination sessions can catch circular waits
+func (hi *Integration) SetWaitListener(listener WaitListener) {
+	hi.waitListener.Store(&listener)
+}
+
+// notifyWait tells the wait listener, if any, that task waits on dependency
+func (hi *Integration) notifyWait(task *types.EnhancedTask, dependency types.TaskDependency, client *RepositoryClient) {
+	listener := hi.waitListener.Load()
+	if listener == nil {
+		return
+	}
+	dependency.Repository = client.Repository.Owner + "/" + client.Repository.Repository
+	(*listener)(task, dependency, client.Repository.ProjectID)
+}
+
+// dependencyClient finds the repository client that can look up a dependency
+func (hi *Integration) dependencyClient(dependency types.TaskDependency, repoClient *RepositoryClient) *RepositoryClient {
+	if dependency.Repository == "" {
+		return repoClient
+	}
+	hi.repositoryLock.RLock()
+	defer hi.repositoryLock.RUnlock()
+	for _, candidate := range hi.repositories {
+		name := candidate.Repository.Owner + "/" + candidate.Repository.Repository
+		if strings.EqualFold(name, dependency.Repository) {
+			return candidate
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	gh "github.com/google/go-github/v57/github"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestParseDependencies(t *testing.T) {
+	body := "Rotate the keys.\n\nDepends on #3, acme/api#12\n- Blocked by: #3\n\n```\ndepends on #99\n```\nSee #40 for context."
+
+	want := []types.TaskDependency{{Number: 3}, {Repository: "acme/api", Number: 12}}
+	if got := parseDependencies(body); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDependencies() = %v, want %v", got, want)
+	}
+}
+
+func TestBlockedTaskWaitsForDependency(t *testing.T) {
+	var mu sync.Mutex
+	dependencyState := "open"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		state := dependencyState
+		mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/issues":
+			fmt.Fprint(w, `[{"number":5,"title":"Use the new keys","body":"Depends on #3","labels":[{"name":"bzzz-task"}]}]`)
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/issues/3":
+			fmt.Fprintf(w, `{"number":3,"state":%q}`, state)
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/issues/5":
+			fmt.Fprint(w, `{"number":5,"state":"open"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/git/ref/heads/main":
+			fmt.Fprint(w, `{"ref":"refs/heads/main","object":{"sha":"abc123"}}`)
+		case r.URL.Path == "/api/bzzz/projects/7/claims":
+			fmt.Fprint(w, `[]`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client: &Client{
+			client: ghClient,
+			ctx:    context.Background(),
+			config: &Config{Owner: "acme", Repository: "widgets", BaseBranch: "main", TaskLabel: "bzzz-task", InProgressLabel: "in-progress"},
+		},
+		Repository: hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets"},
+	}
+
+	executed := make(chan *types.EnhancedTask, 1)
+	hi := &Integration{
+		ctx:          context.Background(),
+		pubsub:       newTestPubSub(t),
+		config:       &IntegrationConfig{AgentID: "agent-a", Capabilities: []string{"general"}},
+		agentConfig:  &config.AgentConfig{ClaimIntentWindow: 10 * time.Millisecond},
+		hlog:         logging.NewHypercoreLog(peer.ID("test")),
+		hiveClient:   hive.NewHiveClient(server.URL, ""),
+		failures:     newFailureTracker(filepath.Join(t.TempDir(), "failures.json")),
+		repositories: map[int]*RepositoryClient{7: repoClient},
+		claimIntents: make(map[string]map[string]*claimIntent),
+		execute: func(ctx context.Context, task *types.EnhancedTask, repoClient *RepositoryClient) {
+			executed <- task
+		},
+	}
+
+	var waits []string
+	hi.SetWaitListener(func(task *types.EnhancedTask, blocking types.TaskDependency, blockingProjectID int) {
+		waits = append(waits, fmt.Sprintf("#%d waits on %s in project %d", task.Number, blocking, blockingProjectID))
+	})
+
+	hi.pollRepositories([]*RepositoryClient{repoClient})
+	select {
+	case <-executed:
+		t.Fatal("task executed while its dependency was open")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if want := []string{"#5 waits on acme/widgets#3 in project 7"}; !reflect.DeepEqual(waits, want) {
+		t.Fatalf("wait listener told %v, want %v", waits, want)
+	}
+
+	mu.Lock()
+	dependencyState = "closed"
+	mu.Unlock()
+	hi.pollRepositories([]*RepositoryClient{repoClient})
+	select {
+	case task := <-executed:
+		if task.Number != 5 {
+			t.Fatalf("executed task #%d, want #5", task.Number)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("task was not executed once its dependency closed")
+	}
+}
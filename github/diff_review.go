@@ -0,0 +1,81 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/google/go-github/v57/github"
+)
+
+// DraftLabel on an issue asks for the task's pull request to be opened as a draft
+const DraftLabel = "bzzz-draft"
+
+// diffReviewReason explains why a change exceeds the configured diff limits, or
+// returns "" when it is small enough to open for review as usual
+func (hi *Integration) diffReviewReason(diff types.DiffStats) string {
+	if hi.agentConfig == nil {
+		return ""
+	}
+	limits := hi.agentConfig.MaxDiff
+
+	var reasons []string
+	if limits.MaxFiles > 0 && diff.FilesChanged > limits.MaxFiles {
+		reasons = append(reasons, fmt.Sprintf("%d files changed, limit is %d", diff.FilesChanged, limits.MaxFiles))
+	}
+	if limits.MaxLines > 0 && diff.Lines() > limits.MaxLines {
+		reasons = append(reasons, fmt.Sprintf("%d lines changed, limit is %d", diff.Lines(), limits.MaxLines))
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// wantsDraft reports whether a task's PR should open as a draft: set for every task
+// in config, per repository in Hive, or per task with the draft label
+func (hi *Integration) wantsDraft(task *types.EnhancedTask) bool {
+	return hi.config.DraftPullRequests || task.Repository.DraftPullRequests || hasLabel(task, DraftLabel)
+}
+
+// openPullRequest opens the pull request for a task's pushed branch. Changes over
+// the diff limits, and branches that conflict with the base branch, are opened as
+// drafts and escalated for human review instead of being offered as ready to merge.
+func (hi *Integration) openPullRequest(task *types.EnhancedTask, repoClient *RepositoryClient, branch string, diff types.DiffStats, merge types.MergeStatus) (*github.PullRequest, error) {
+	reviewReason := hi.diffReviewReason(diff)
+	conflicted := merge.State == types.MergeConflicted
+	pr, err := repoClient.Client.CreatePullRequest(task.Number, branch, hi.config.AgentID, PullRequestOptions{
+		Draft:        reviewReason != "" || conflicted || hi.wantsDraft(task),
+		Diff:         &diff,
+		ReviewReason: reviewReason,
+		Merge:        merge,
+		Model:        task.Model,
+		TaskType:     task.TaskType,
+		IssueTitle:   task.Title,
+	})
+	if err != nil {
+		return pr, err
+	}
+
+	if conflicted {
+		fmt.Printf("⚔️ Task #%d conflicts with the base branch, opened draft PR for a human to resolve\n", task.Number)
+		escalation := newMergeConflictEscalation(task, branch, pr.GetHTMLURL(), merge.Conflicts)
+		hi.requestAssistance(task, escalation, pubsub.TaskTopic(task.Number))
+	}
+	if reviewReason == "" {
+		return pr, nil
+	}
+
+	fmt.Printf("📏 Task #%d change is too large to proceed automatically (%s), opened draft PR for review\n", task.Number, reviewReason)
+	hi.hlog.Append(logging.TaskProgress, map[string]interface{}{
+		"task_id":       task.Number,
+		"repository":    task.RepositoryName(),
+		"status":        "awaiting human review",
+		"reason":        reviewReason,
+		"files_changed": diff.FilesChanged,
+		"lines_added":   diff.LinesAdded,
+		"lines_removed": diff.LinesRemoved,
+	})
+	escalation := newOversizedDiffEscalation(task, branch, pr.GetHTMLURL(), diff, reviewReason)
+	hi.requestAssistance(task, escalation, pubsub.TaskTopic(task.Number))
+	return pr, nil
+}
@@ -0,0 +1,103 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	gh "github.com/google/go-github/v57/github"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestOversizedDiffOpensDraftAndEscalates(t *testing.T) {
+	var mu sync.Mutex
+	var created []gh.NewPullRequest
+	var escalations []EscalationReason
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/repos/acme/widgets/pulls":
+			var pr gh.NewPullRequest
+			json.NewDecoder(r.Body).Decode(&pr)
+			created = append(created, pr)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"number":%d,"html_url":"https://github.com/acme/widgets/pull/%d","draft":%t}`, len(created), len(created), pr.GetDraft())
+		case "/escalate":
+			var payload struct {
+				Escalation EscalationReason `json:"escalation"`
+			}
+			json.NewDecoder(r.Body).Decode(&payload)
+			escalations = append(escalations, payload.Escalation)
+		}
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client: &Client{
+			client: ghClient,
+			ctx:    context.Background(),
+			config: &Config{Owner: "acme", Repository: "widgets", BaseBranch: "main"},
+		},
+		Repository: hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets"},
+	}
+	hi := &Integration{
+		ctx:         context.Background(),
+		pubsub:      newTestPubSub(t),
+		config:      &IntegrationConfig{AgentID: "agent-a", EscalationWebhook: server.URL + "/escalate"},
+		agentConfig: &config.AgentConfig{MaxDiff: config.DiffLimits{MaxFiles: 10, MaxLines: 500}},
+		hlog:        logging.NewHypercoreLog(peer.ID("test")),
+	}
+	task := &types.EnhancedTask{Number: 42, ProjectID: 7, Repository: repoClient.Repository}
+
+	// A sprawling change is opened as a draft and a human is asked to review it
+	large := types.DiffStats{FilesChanged: 40, LinesAdded: 2500, LinesRemoved: 300}
+	pr, err := hi.openPullRequest(task, repoClient, "bzzz/task-42", large, types.MergeStatus{})
+	if err != nil {
+		t.Fatalf("openPullRequest returned error: %v", err)
+	}
+	if !pr.GetDraft() {
+		t.Errorf("expected an oversized change to open as a draft")
+	}
+	mu.Lock()
+	if len(escalations) != 1 || escalations[0].Kind != EscalationOversizedDiff {
+		t.Fatalf("expected one oversized diff escalation, got %+v", escalations)
+	}
+	if escalations[0].PullRequest != pr.GetHTMLURL() || escalations[0].Diff == nil || escalations[0].Diff.LinesAdded != 2500 {
+		t.Errorf("escalation is missing the draft or diff metrics: %+v", escalations[0])
+	}
+	if body := created[0].GetBody(); !strings.Contains(body, "40 files changed, +2500/-300 lines") || !strings.Contains(body, "Human review required") {
+		t.Errorf("PR description is missing the diff metrics: %q", body)
+	}
+	mu.Unlock()
+
+	// A small change proceeds as a normal, ready pull request
+	small := types.DiffStats{FilesChanged: 2, LinesAdded: 30, LinesRemoved: 4}
+	pr, err = hi.openPullRequest(task, repoClient, "bzzz/task-42", small, types.MergeStatus{})
+	if err != nil {
+		t.Fatalf("openPullRequest returned error: %v", err)
+	}
+	if pr.GetDraft() {
+		t.Errorf("expected a small change to open ready for review")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(escalations) != 1 {
+		t.Errorf("a small change should not be escalated, got %+v", escalations)
+	}
+	if body := created[1].GetBody(); !strings.Contains(body, "2 files changed, +30/-4 lines") || strings.Contains(body, "Human review required") {
+		t.Errorf("unexpected PR description for a small change: %q", body)
+	}
+}
@@ -0,0 +1,166 @@
+package github
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/tracing"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+)
+
+// escalationTTL is how long an escalation waits for a human reply before it is
+// forgotten; a variable so tests can expire escalations straight away
+var escalationTTL = 7 * 24 * time.Hour
+
+// pendingEscalation is a stalled task waiting for a human reply
+type pendingEscalation struct {
+	ID        string
+	Task      *types.EnhancedTask
+	Reason    string
+	CreatedAt time.Time
+}
+
+// EscalationResponse is a human's reply to an escalation, posted back by N8N or Hive
+type EscalationResponse struct {
+	EscalationID string `json:"escalation_id"`
+	Guidance     string `json:"guidance"`
+	Responder    string `json:"responder,omitempty"`
+}
+
+// recordEscalation remembers an escalated task and returns its escalation ID
+func (hi *Integration) recordEscalation(task *types.EnhancedTask, reason string) string {
+	escalationID := fmt.Sprintf("%s-%d-%d", hi.config.AgentID, task.Number, time.Now().UnixNano())
+
+	hi.escalationLock.Lock()
+	defer hi.escalationLock.Unlock()
+	if hi.escalations == nil {
+		hi.escalations = make(map[string]*pendingEscalation)
+	}
+	hi.expireEscalations()
+	hi.escalations[escalationID] = &pendingEscalation{
+		ID:        escalationID,
+		Task:      task,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+	return escalationID
+}
+
+// expireEscalations forgets escalations nobody has replied to within
+// escalationTTL; callers must hold escalationLock
+func (hi *Integration) expireEscalations() {
+	for id, escalation := range hi.escalations {
+		if time.Since(escalation.CreatedAt) > escalationTTL {
+			delete(hi.escalations, id)
+		}
+	}
+}
+
+// takeEscalation removes an escalation and any others raised for the same task,
+// so a task is resumed at most once however many times it was escalated.
+func (hi *Integration) takeEscalation(escalationID string) (*pendingEscalation, bool) {
+	hi.escalationLock.Lock()
+	defer hi.escalationLock.Unlock()
+
+	hi.expireEscalations()
+	escalation, exists := hi.escalations[escalationID]
+	if !exists {
+		return nil, false
+	}
+	key := taskKey(escalation.Task.ProjectID, escalation.Task.Number)
+	for id, other := range hi.escalations {
+		if taskKey(other.Task.ProjectID, other.Task.Number) == key {
+			delete(hi.escalations, id)
+		}
+	}
+	return escalation, true
+}
+
+// ResumeEscalation re-claims an escalated task and runs it again with the
+// human's guidance added to the prompt.
+func (hi *Integration) ResumeEscalation(response EscalationResponse) error {
+	if strings.TrimSpace(response.Guidance) == "" {
+		return fmt.Errorf("escalation response has no guidance")
+	}
+	escalation, exists := hi.takeEscalation(response.EscalationID)
+	if !exists {
+		return fmt.Errorf("unknown escalation: %s", response.EscalationID)
+	}
+	task := escalation.Task
+
+	hi.repositoryLock.RLock()
+	repoClient, exists := hi.repositories[task.ProjectID]
+	hi.repositoryLock.RUnlock()
+	if !exists {
+		return fmt.Errorf("repository client not found for project %d", task.ProjectID)
+	}
+
+	fmt.Printf("🙋 Human guidance received for task #%d (%s), resuming\n", task.Number, response.EscalationID)
+	hi.hlog.Append(logging.TaskHelpReceived, map[string]interface{}{
+		"task_id":       task.Number,
+		"repository":    task.RepositoryName(),
+		"escalation_id": response.EscalationID,
+		"responder":     response.Responder,
+	})
+
+	// The reply lifts the cooldown and hands the task back to the agents
+	hi.failures.recordSuccess(taskKey(task.ProjectID, task.Number))
+	if err := repoClient.Client.RemoveLabel(task.Number, hi.needsHumanLabel()); err != nil {
+		fmt.Printf("⚠️ Failed to remove %s label from task #%d: %v\n", hi.needsHumanLabel(), task.Number, err)
+	}
+	if err := repoClient.Client.ReleaseTask(task.Number); err != nil {
+		return fmt.Errorf("failed to release task #%d: %w", task.Number, err)
+	}
+
+	task.HumanGuidance = response.Guidance
+	ctx, span := hi.startTaskSpan(task)
+	if err := hi.claimTask(ctx, task, repoClient); err != nil {
+		tracing.End(span, err)
+		return fmt.Errorf("failed to re-claim task #%d: %w", task.Number, err)
+	}
+	hi.startExecution(ctx, task, repoClient)
+	return nil
+}
+
+// EscalationResponseHandler receives human replies to escalations and resumes
+// the stalled task. Requests must carry token as a bearer token.
+func (hi *Integration) EscalationResponseHandler(token []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		bearer := []byte(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if subtle.ConstantTimeCompare(bearer, token) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		var response EscalationResponse
+		if err := json.NewDecoder(r.Body).Decode(&response); err != nil || strings.TrimSpace(response.Guidance) == "" {
+			http.Error(w, "invalid escalation response", http.StatusBadRequest)
+			return
+		}
+
+		hi.escalationLock.Lock()
+		hi.expireEscalations()
+		_, exists := hi.escalations[response.EscalationID]
+		hi.escalationLock.Unlock()
+		if !exists {
+			http.Error(w, "unknown escalation", http.StatusNotFound)
+			return
+		}
+
+		if err := hi.ResumeEscalation(response); err != nil {
+			fmt.Printf("❌ Failed to resume escalation %s: %v\n", response.EscalationID, err)
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
@@ -0,0 +1,154 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/google/go-github/v57/github"
+)
+
+// EscalationKind classifies why an agent asked for help, so N8N can route each kind differently
+type EscalationKind string
+
+const (
+	EscalationPRCreationFailure EscalationKind = "pr_creation_failure"
+	EscalationOversizedDiff     EscalationKind = "oversized_diff"
+	EscalationMergeConflict     EscalationKind = "merge_conflict"
+)
+
+// EscalationReason is the structured context behind a request for assistance
+type EscalationReason struct {
+	EscalationID string           `json:"escalation_id,omitempty"` // Quoted back in a human's reply, see EscalationResponse
+	Kind         EscalationKind   `json:"kind"`
+	TaskID       int              `json:"task_id"`
+	ProjectID    int              `json:"project_id,omitempty"`
+	Repository   string           `json:"repository"`
+	Branch       string           `json:"branch,omitempty"`
+	GitHubError  string           `json:"github_error,omitempty"`
+	ErrorClass   string           `json:"error_class,omitempty"`  // See classifyGitHubError
+	PullRequest  string           `json:"pull_request,omitempty"` // Draft awaiting review, if one was opened
+	Diff         *types.DiffStats `json:"diff,omitempty"`
+	Message      string           `json:"message"` // Human-readable summary
+}
+
+// newPRFailureEscalation describes a pull request that could not be opened for finished work
+func newPRFailureEscalation(task *types.EnhancedTask, branch string, err error) EscalationReason {
+	return EscalationReason{
+		Kind:        EscalationPRCreationFailure,
+		TaskID:      task.Number,
+		ProjectID:   task.ProjectID,
+		Repository:  fmt.Sprintf("%s/%s", task.Repository.Owner, task.Repository.Repository),
+		Branch:      branch,
+		GitHubError: err.Error(),
+		ErrorClass:  classifyGitHubError(err),
+		Message: fmt.Sprintf("Failed to create pull request: %v. Task execution completed successfully and work is preserved in branch '%s', but PR creation failed.",
+			err, branch),
+	}
+}
+
+// newOversizedDiffEscalation asks a human to review a change too large to open as ready
+func newOversizedDiffEscalation(task *types.EnhancedTask, branch, prURL string, diff types.DiffStats, why string) EscalationReason {
+	return EscalationReason{
+		Kind:        EscalationOversizedDiff,
+		TaskID:      task.Number,
+		ProjectID:   task.ProjectID,
+		Repository:  fmt.Sprintf("%s/%s", task.Repository.Owner, task.Repository.Repository),
+		Branch:      branch,
+		PullRequest: prURL,
+		Diff:        &diff,
+		Message:     fmt.Sprintf("Change for task #%d is too large to proceed automatically (%s); opened as a draft pull request for human review.", task.Number, why),
+	}
+}
+
+// newMergeConflictEscalation asks a human to resolve conflicts with the base branch
+// that were too involved to resolve automatically
+func newMergeConflictEscalation(task *types.EnhancedTask, branch, prURL string, conflicts []string) EscalationReason {
+	return EscalationReason{
+		Kind:        EscalationMergeConflict,
+		TaskID:      task.Number,
+		ProjectID:   task.ProjectID,
+		Repository:  fmt.Sprintf("%s/%s", task.Repository.Owner, task.Repository.Repository),
+		Branch:      branch,
+		PullRequest: prURL,
+		Message:     fmt.Sprintf("Branch for task #%d conflicts with the latest base branch in %s; opened as a draft pull request for a human to resolve.", task.Number, strings.Join(conflicts, ", ")),
+	}
+}
+
+// classifyGitHubError buckets a GitHub API error into a coarse, routable class
+func classifyGitHubError(err error) string {
+	var rateLimitErr *github.RateLimitError
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &rateLimitErr) || errors.As(err, &abuseErr) {
+		return "rate_limited"
+	}
+
+	var responseErr *github.ErrorResponse
+	if !errors.As(err, &responseErr) || responseErr.Response == nil {
+		return "unknown"
+	}
+	switch status := responseErr.Response.StatusCode; {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return "permission_denied"
+	case status == http.StatusNotFound:
+		return "not_found"
+	case status == http.StatusUnprocessableEntity:
+		return "validation_failed" // e.g. a PR already exists or the branch has no commits
+	case status >= 500:
+		return "github_unavailable"
+	}
+	return "unknown"
+}
+
+// escalationRoute returns where an escalation for the reason's repository goes:
+// its own route if it has one, by name or Hive project ID, else the global webhook
+func (hi *Integration) escalationRoute(reason EscalationReason) config.EscalationRoute {
+	if route, ok := hi.config.EscalationRoutes[reason.Repository]; ok {
+		return route
+	}
+	if route, ok := hi.config.EscalationRoutes[strconv.Itoa(reason.ProjectID)]; ok && reason.ProjectID != 0 {
+		return route
+	}
+	return config.EscalationRoute{Webhook: hi.config.EscalationWebhook}
+}
+
+// sendEscalationWebhook posts a structured escalation to the N8N escalation
+// webhook for the escalation's repository
+func (hi *Integration) sendEscalationWebhook(reason EscalationReason) error {
+	route := hi.escalationRoute(reason)
+	if route.Webhook == "" {
+		return nil
+	}
+
+	body := map[string]interface{}{
+		"agent_id":   hi.config.AgentID,
+		"escalation": reason,
+		"timestamp":  time.Now().Unix(),
+	}
+	if len(route.Metadata) > 0 {
+		body["routing"] = route.Metadata
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal escalation: %w", err)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(route.Webhook, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to send escalation webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("escalation webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	gh "github.com/google/go-github/v57/github"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestPRFailureEscalationIsStructured(t *testing.T) {
+	githubServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		fmt.Fprint(w, `{"message":"Validation Failed","errors":[{"message":"A pull request already exists for acme:bzzz/task-42"}]}`)
+	}))
+	defer githubServer.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(githubServer.URL + "/")
+	client := &Client{
+		client: ghClient,
+		ctx:    context.Background(),
+		config: &Config{Owner: "acme", Repository: "widgets", BaseBranch: "main"},
+	}
+	_, prErr := client.CreatePullRequest(42, "bzzz/task-42", "agent-a", PullRequestOptions{})
+	if prErr == nil {
+		t.Fatal("expected pull request creation to fail")
+	}
+
+	received := make(chan map[string]json.RawMessage, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]json.RawMessage
+		json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload
+	}))
+	defer webhook.Close()
+
+	hi := &Integration{
+		ctx:    context.Background(),
+		config: &IntegrationConfig{AgentID: "agent-a", EscalationWebhook: webhook.URL},
+	}
+	task := &types.EnhancedTask{Number: 42, Repository: hive.Repository{Owner: "acme", Repository: "widgets"}}
+
+	reason := newPRFailureEscalation(task, "bzzz/task-42", prErr)
+	if err := hi.sendEscalationWebhook(reason); err != nil {
+		t.Fatalf("failed to send escalation: %v", err)
+	}
+
+	var sent EscalationReason
+	if err := json.Unmarshal((<-received)["escalation"], &sent); err != nil {
+		t.Fatalf("webhook payload has no structured escalation: %v", err)
+	}
+	if sent.Kind != EscalationPRCreationFailure {
+		t.Errorf("expected kind %s, got %s", EscalationPRCreationFailure, sent.Kind)
+	}
+	if sent.Branch != "bzzz/task-42" || sent.TaskID != 42 || sent.Repository != "acme/widgets" {
+		t.Errorf("escalation lost task context: %+v", sent)
+	}
+	if sent.ErrorClass != "validation_failed" {
+		t.Errorf("expected a 422 to be classified as validation_failed, got %s", sent.ErrorClass)
+	}
+	if sent.GitHubError == "" {
+		t.Errorf("escalation is missing the GitHub error")
+	}
+}
+
+func TestStandaloneHelpRequestEscalatesToHumans(t *testing.T) {
+	statuses := make(chan hive.TaskStatusUpdate, 1)
+	hiveServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/bzzz/projects/7/status" {
+			var update hive.TaskStatusUpdate
+			json.NewDecoder(r.Body).Decode(&update)
+			statuses <- update
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer hiveServer.Close()
+	received := make(chan EscalationReason, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Escalation EscalationReason `json:"escalation"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload.Escalation
+	}))
+	defer webhook.Close()
+
+	hi := &Integration{
+		ctx:        context.Background(),
+		pubsub:     newTestPubSub(t), // No peers connected
+		config:     &IntegrationConfig{AgentID: "agent-a", MinCoordinationPeers: 1, EscalationWebhook: webhook.URL},
+		hlog:       logging.NewHypercoreLog(peer.ID("test")),
+		hiveClient: hive.NewHiveClient(hiveServer.URL, ""),
+	}
+	task := &types.EnhancedTask{Number: 42, ProjectID: 7, Title: "Rotate the signing keys", Repository: hive.Repository{Owner: "acme", Repository: "widgets"}}
+
+	start := time.Now()
+	hi.requestAssistance(task, newPRFailureEscalation(task, "bzzz/task-42", fmt.Errorf("boom")), pubsub.TaskTopic(42))
+
+	select {
+	case update := <-statuses:
+		if update.Status != "escalated" || update.TaskNumber != 42 {
+			t.Fatalf("expected task #42 to be escalated, got %+v", update)
+		}
+	default:
+		t.Fatal("expected a standalone agent to escalate to humans straight away")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("escalation waited %s for help that could never come", elapsed)
+	}
+	if len(hi.escalations) != 1 {
+		t.Fatalf("expected the escalation to be recorded for a human reply, got %d", len(hi.escalations))
+	}
+	if sent := <-received; hi.escalations[sent.EscalationID] == nil {
+		t.Errorf("webhook escalation ID %q does not match a recorded escalation", sent.EscalationID)
+	}
+
+	// Escalations nobody answers are forgotten once the next one is recorded
+	defer func(ttl time.Duration) { escalationTTL = ttl }(escalationTTL)
+	escalationTTL = 0
+	hi.recordEscalation(task, "still stuck")
+	if len(hi.escalations) != 1 {
+		t.Errorf("expected the unanswered escalation to expire, got %d pending", len(hi.escalations))
+	}
+}
+
+func TestEscalationIsRoutedToRepositoryWebhook(t *testing.T) {
+	received := make(chan string, 2)
+	routing := make(chan map[string]string, 2)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Routing map[string]string `json:"routing"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		received <- r.URL.Path
+		routing <- payload.Routing
+	}))
+	defer webhook.Close()
+
+	hi := &Integration{
+		ctx: context.Background(),
+		config: &IntegrationConfig{
+			AgentID:           "agent-a",
+			EscalationWebhook: webhook.URL + "/global",
+			EscalationRoutes: map[string]config.EscalationRoute{
+				"acme/payments": {Webhook: webhook.URL + "/team-a", Metadata: map[string]string{"team": "payments"}},
+			},
+		},
+	}
+
+	payments := &types.EnhancedTask{Number: 5, ProjectID: 3, Repository: hive.Repository{Owner: "acme", Repository: "payments"}}
+	if err := hi.sendEscalationWebhook(newPRFailureEscalation(payments, "bzzz/task-5", fmt.Errorf("boom"))); err != nil {
+		t.Fatalf("failed to send escalation: %v", err)
+	}
+	if path := <-received; path != "/team-a" {
+		t.Fatalf("expected the payments escalation to go to team A's webhook, went to %s", path)
+	}
+	if meta := <-routing; meta["team"] != "payments" {
+		t.Errorf("expected the route's metadata to be sent, got %v", meta)
+	}
+
+	// Repositories without a route still use the global webhook
+	widgets := &types.EnhancedTask{Number: 6, ProjectID: 4, Repository: hive.Repository{Owner: "acme", Repository: "widgets"}}
+	if err := hi.sendEscalationWebhook(newPRFailureEscalation(widgets, "bzzz/task-6", fmt.Errorf("boom"))); err != nil {
+		t.Fatalf("failed to send escalation: %v", err)
+	}
+	if path := <-received; path != "/global" {
+		t.Fatalf("expected an unrouted escalation to go to the global webhook, went to %s", path)
+	}
+}
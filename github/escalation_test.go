@@ -0,0 +1,142 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	gh "github.com/google/go-github/v57/github"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestHumanReplyResumesEscalatedTask(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	var escalationStatus string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+		if r.URL.Path == "/api/bzzz/projects/7/status" && strings.Contains(string(body), `"escalated"`) {
+			escalationStatus = string(body)
+		}
+		mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/issues/42":
+			fmt.Fprint(w, `{"number":42,"labels":[{"name":"`+DefaultNeedsHumanLabel+`"}]}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/git/ref/heads/main":
+			fmt.Fprint(w, `{"ref":"refs/heads/main","object":{"sha":"abc123"}}`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client: &Client{
+			client: ghClient,
+			ctx:    context.Background(),
+			config: &Config{Owner: "acme", Repository: "widgets", BaseBranch: "main", InProgressLabel: "in-progress", Assignee: "bzzz-bot"},
+		},
+	}
+
+	resumed := make(chan *types.EnhancedTask, 1)
+	hi := &Integration{
+		ctx:          context.Background(),
+		config:       &IntegrationConfig{AgentID: "agent-a", Capabilities: []string{"general"}},
+		agentConfig:  &config.AgentConfig{MaxTaskFailures: 1, FailureCooldown: time.Hour},
+		hlog:         logging.NewHypercoreLog(peer.ID("test")),
+		hiveClient:   hive.NewHiveClient(server.URL, ""),
+		failures:     newFailureTracker(filepath.Join(t.TempDir(), "failures.json")),
+		repositories: map[int]*RepositoryClient{7: repoClient},
+		execute: func(ctx context.Context, task *types.EnhancedTask, repoClient *RepositoryClient) {
+			resumed <- task
+		},
+	}
+	task := &types.EnhancedTask{Number: 42, ProjectID: 7, TaskType: "general", Title: "Ambiguous task"}
+
+	// One failure trips the breaker and escalates the task
+	hi.handleTaskFailure(task, repoClient, "unclear requirements")
+	if got := hi.filterSuitableTasks([]*types.EnhancedTask{task}); len(got) != 0 {
+		t.Fatalf("escalated task should not be claimable")
+	}
+
+	hi.escalationLock.Lock()
+	var escalationID string
+	for id := range hi.escalations {
+		escalationID = id
+	}
+	hi.escalationLock.Unlock()
+	if escalationID == "" {
+		t.Fatalf("escalation was not recorded")
+	}
+	mu.Lock()
+	if !strings.Contains(escalationStatus, escalationID) {
+		t.Errorf("escalation ID %s not reported to Hive: %s", escalationID, escalationStatus)
+	}
+	mu.Unlock()
+
+	handler := hi.EscalationResponseHandler([]byte("s3cret"))
+	reply := func(token, id string) int {
+		body := fmt.Sprintf(`{"escalation_id":%q,"guidance":"Use the v2 API, not v1","responder":"alice"}`, id)
+		req := httptest.NewRequest(http.MethodPost, "/escalations/response", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := reply("wrong", escalationID); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a bad token, got %d", code)
+	}
+	if code := reply("s3cret", "agent-a-99-1"); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown escalation, got %d", code)
+	}
+	if code := reply("s3cret", escalationID); code != http.StatusAccepted {
+		t.Fatalf("expected 202 for a human reply, got %d", code)
+	}
+
+	select {
+	case got := <-resumed:
+		if got.HumanGuidance != "Use the v2 API, not v1" {
+			t.Fatalf("resumed task has guidance %q", got.HumanGuidance)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("task was not resumed")
+	}
+
+	mu.Lock()
+	joined := strings.Join(requests, "\n")
+	mu.Unlock()
+	for _, want := range []string{
+		"DELETE /repos/acme/widgets/issues/42/labels/" + DefaultNeedsHumanLabel,
+		"POST /api/bzzz/projects/7/claim",
+		"PATCH /repos/acme/widgets/issues/42",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected request %q, got:\n%s", want, joined)
+		}
+	}
+
+	if hi.failures.coolingDown(taskKey(task.ProjectID, task.Number)) {
+		t.Errorf("reply should lift the failure cooldown")
+	}
+	if code := reply("s3cret", escalationID); code != http.StatusNotFound {
+		t.Errorf("a second reply to the same escalation should be rejected, got %d", code)
+	}
+}
@@ -0,0 +1,115 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultNeedsHumanLabel marks tasks that agents have given up on
+const DefaultNeedsHumanLabel = "bzzz-needs-human"
+
+// taskFailure records how often a task has failed on this agent
+type taskFailure struct {
+	Count         int       `json:"count"`
+	LastFailure   time.Time `json:"last_failure"`
+	LastReason    string    `json:"last_reason"`
+	CooldownUntil time.Time `json:"cooldown_until,omitempty"`
+}
+
+// failureTracker keeps per-task failure counts, persisted so restarts don't reset them
+type failureTracker struct {
+	path     string
+	failures map[string]*taskFailure // "projectID:taskID" -> failures
+	lock     sync.Mutex
+}
+
+// getFailureFile returns the path to store task failures for an agent
+func getFailureFile(agentID string) string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config", "bzzz", fmt.Sprintf("task-failures-%s.json", agentID))
+}
+
+// newFailureTracker loads any previously recorded failures from path
+func newFailureTracker(path string) *failureTracker {
+	ft := &failureTracker{
+		path:     path,
+		failures: make(map[string]*taskFailure),
+	}
+
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &ft.failures); err != nil {
+			fmt.Printf("⚠️ Ignoring unreadable task failure file %s: %v\n", path, err)
+			ft.failures = make(map[string]*taskFailure)
+		}
+	}
+	return ft
+}
+
+// recordFailure counts a failure and reports whether the task has now reached
+// maxFailures, in which case it enters a cooldown and its count starts over.
+func (ft *failureTracker) recordFailure(key, reason string, maxFailures int, cooldown time.Duration) (int, bool) {
+	ft.lock.Lock()
+	defer ft.lock.Unlock()
+
+	failure, exists := ft.failures[key]
+	if !exists {
+		failure = &taskFailure{}
+		ft.failures[key] = failure
+	}
+	failure.Count++
+	failure.LastFailure = time.Now()
+	failure.LastReason = reason
+
+	count := failure.Count
+	tripped := maxFailures > 0 && count >= maxFailures
+	if tripped {
+		failure.Count = 0
+		failure.CooldownUntil = time.Now().Add(cooldown)
+	}
+
+	ft.save()
+	return count, tripped
+}
+
+// recordSuccess forgets a task's failure history
+func (ft *failureTracker) recordSuccess(key string) {
+	ft.lock.Lock()
+	defer ft.lock.Unlock()
+
+	if _, exists := ft.failures[key]; exists {
+		delete(ft.failures, key)
+		ft.save()
+	}
+}
+
+// coolingDown reports whether a task should not be claimed right now
+func (ft *failureTracker) coolingDown(key string) bool {
+	ft.lock.Lock()
+	defer ft.lock.Unlock()
+
+	failure, exists := ft.failures[key]
+	return exists && time.Now().Before(failure.CooldownUntil)
+}
+
+// save writes the failure records to disk; callers must hold the lock
+func (ft *failureTracker) save() {
+	if ft.path == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(ft.path), 0755); err != nil {
+		fmt.Printf("⚠️ Failed to persist task failures: %v\n", err)
+		return
+	}
+	data, err := json.MarshalIndent(ft.failures, "", "  ")
+	if err != nil {
+		fmt.Printf("⚠️ Failed to persist task failures: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(ft.path, data, 0644); err != nil {
+		fmt.Printf("⚠️ Failed to persist task failures: %v\n", err)
+	}
+}
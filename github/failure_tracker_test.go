@@ -0,0 +1,154 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/executor"
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/budget"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	gh "github.com/google/go-github/v57/github"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestRepeatedFailureReleasesAndLabelsTask(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, body))
+		mu.Unlock()
+
+		if r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/issues/42" {
+			fmt.Fprint(w, `{"number":42,"assignees":[{"login":"bzzz-agent"}],"labels":[{"name":"in-progress"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client: &Client{
+			client: ghClient,
+			ctx:    context.Background(),
+			config: &Config{Owner: "acme", Repository: "widgets", InProgressLabel: "in-progress"},
+		},
+	}
+
+	hi := &Integration{
+		ctx:         context.Background(),
+		config:      &IntegrationConfig{AgentID: "agent-a", Capabilities: []string{"general"}},
+		agentConfig: &config.AgentConfig{MaxTaskFailures: 2, FailureCooldown: time.Hour},
+		hlog:        logging.NewHypercoreLog(peer.ID("test")),
+		hiveClient:  hive.NewHiveClient(server.URL, ""),
+		failures:    newFailureTracker(filepath.Join(t.TempDir(), "failures.json")),
+	}
+	task := &types.EnhancedTask{Number: 42, ProjectID: 7, TaskType: "general", Title: "Flaky task"}
+
+	hi.handleTaskFailure(task, repoClient, "build failed")
+	if got := hi.filterSuitableTasks([]*types.EnhancedTask{task}); len(got) != 1 {
+		t.Fatalf("task should still be claimable after one failure")
+	}
+
+	hi.handleTaskFailure(task, repoClient, "build failed again")
+
+	mu.Lock()
+	joined := strings.Join(requests, "\n")
+	mu.Unlock()
+	for _, want := range []string{
+		"DELETE /repos/acme/widgets/issues/42/assignees",
+		"DELETE /repos/acme/widgets/issues/42/labels/in-progress",
+		"POST /repos/acme/widgets/issues/42/labels [\"" + DefaultNeedsHumanLabel + "\"]",
+		"PUT /api/bzzz/projects/7/status",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected request %q, got:\n%s", want, joined)
+		}
+	}
+
+	if got := hi.filterSuitableTasks([]*types.EnhancedTask{task}); len(got) != 0 {
+		t.Fatalf("task should be excluded while cooling down")
+	}
+
+	// The cooldown survives a restart
+	reloaded := newFailureTracker(hi.failures.path)
+	if !reloaded.coolingDown(taskKey(task.ProjectID, task.Number)) {
+		t.Fatalf("cooldown was not persisted")
+	}
+}
+
+func TestNeedsHumanLabelExcludesTask(t *testing.T) {
+	hi := &Integration{
+		config:   &IntegrationConfig{AgentID: "agent-a", Capabilities: []string{"general"}},
+		failures: newFailureTracker(""),
+	}
+	task := &types.EnhancedTask{Number: 1, TaskType: "general", Labels: []string{DefaultNeedsHumanLabel}}
+
+	if got := hi.filterSuitableTasks([]*types.EnhancedTask{task}); len(got) != 0 {
+		t.Fatalf("task labeled %s should not be selected", DefaultNeedsHumanLabel)
+	}
+}
+
+func TestFailureNeedingReviewThatTripsTheThresholdEscalatesOnce(t *testing.T) {
+	var mu sync.Mutex
+	escalations := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/api/bzzz/projects/7/status" && strings.Contains(string(body), `"status":"escalated"`) {
+			mu.Lock()
+			escalations++
+			mu.Unlock()
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client: &Client{
+			client: ghClient,
+			ctx:    context.Background(),
+			config: &Config{Owner: "acme", Repository: "widgets", InProgressLabel: "in-progress"},
+		},
+		Repository: hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets"},
+	}
+
+	hi := &Integration{
+		ctx:         context.Background(),
+		pubsub:      newTestPubSub(t),
+		config:      &IntegrationConfig{AgentID: "agent-a", Capabilities: []string{"general"}},
+		agentConfig: &config.AgentConfig{MaxTaskFailures: 1, FailureCooldown: time.Hour},
+		hlog:        logging.NewHypercoreLog(peer.ID("test")),
+		hiveClient:  hive.NewHiveClient(server.URL, ""),
+		failures:    newFailureTracker(filepath.Join(t.TempDir(), "failures.json")),
+		runExecutor: func(ctx context.Context, task *types.EnhancedTask, hlog *logging.HypercoreLog, agentConfig *config.AgentConfig, budgets *budget.Tracker) (*executor.ExecuteTaskResult, error) {
+			return nil, &executor.VerificationFailedError{Command: "go test ./...", Output: "FAIL"}
+		},
+	}
+	task := &types.EnhancedTask{Number: 42, ProjectID: 7, TaskType: "general", Title: "Flaky task", Repository: repoClient.Repository}
+
+	// Failing verification needs a human, and so does reaching the failure limit
+	hi.executeTask(context.Background(), task, repoClient)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if escalations != 1 {
+		t.Fatalf("expected the task to be escalated once, got %d escalations", escalations)
+	}
+}
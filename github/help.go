@@ -0,0 +1,76 @@
+package github
+
+import (
+	"strings"
+
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// helperFits reports whether a peer offering help looks able to take the work on,
+// going by what it last broadcast. Peers we've heard nothing from get the benefit
+// of the doubt.
+func (hi *Integration) helperFits(peerID string, required []string) bool {
+	id, err := peer.Decode(peerID)
+	if err != nil {
+		return true
+	}
+	availability, known := hi.lookupPeer(id)
+	if !known {
+		return true
+	}
+	if !availability.Available || (availability.MaxTasks > 0 && availability.CurrentTasks >= availability.MaxTasks) {
+		return false
+	}
+	for _, capability := range required {
+		has := false
+		for _, advertised := range availability.Capabilities {
+			if strings.EqualFold(advertised, capability) {
+				has = true
+				break
+			}
+		}
+		if !has {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupPeer returns what a peer last broadcast about its workload and
+// capabilities. A peer only heard from in the capability exchange is taken to
+// be available.
+func (hi *Integration) lookupPeer(id peer.ID) (pubsub.PeerAvailability, bool) {
+	if hi.peerAvailability != nil {
+		return hi.peerAvailability(id)
+	}
+	if hi.pubsub == nil {
+		return pubsub.PeerAvailability{}, false
+	}
+	if availability, ok := hi.pubsub.AvailabilityOf(id); ok {
+		return availability, true
+	}
+	if caps, ok := hi.pubsub.CapabilitiesOf(id); ok {
+		return pubsub.PeerAvailability{
+			NodeID:         caps.NodeID,
+			Available:      true,
+			Capabilities:   caps.Capabilities,
+			Models:         caps.Models,
+			Specialization: caps.Specialization,
+			UpdatedAt:      caps.UpdatedAt,
+		}, true
+	}
+	return pubsub.PeerAvailability{}, false
+}
+
+// runningRequirements returns the capabilities a running task requires, by issue number
+func (hi *Integration) runningRequirements(taskID int) []string {
+	hi.runningLock.Lock()
+	defer hi.runningLock.Unlock()
+	for _, running := range hi.running {
+		if running.task.Number == taskID {
+			return running.task.RequiredCapabilities
+		}
+	}
+	return nil
+}
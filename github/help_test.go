@@ -0,0 +1,117 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/reputation"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// testPeerID returns a fresh, valid peer ID
+func testPeerID(t *testing.T) peer.ID {
+	t.Helper()
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestHelpGoesToAReputablePeerWithRoomAndCapabilities(t *testing.T) {
+	busy, unsuited, suited, unknown := testPeerID(t), testPeerID(t), testPeerID(t), testPeerID(t)
+	store := reputation.NewStore("")
+	for i := 0; i < 3; i++ {
+		store.Record(busy.String(), reputation.HelpAccepted)
+	}
+	for i := 0; i < 2; i++ {
+		store.Record(unsuited.String(), reputation.HelpAccepted)
+	}
+	store.Record(suited.String(), reputation.HelpAccepted)
+
+	broadcasts := map[peer.ID]pubsub.PeerAvailability{
+		busy:     {Available: true, CurrentTasks: 2, MaxTasks: 2, Capabilities: []string{"gpu-inference"}},
+		unsuited: {Available: true, MaxTasks: 2, Capabilities: []string{"code-generation"}},
+		suited:   {Available: true, MaxTasks: 2, Capabilities: []string{"GPU-Inference"}},
+	}
+	hi := &Integration{
+		reputation: store,
+		helpOffers: make(map[int][]string),
+		helpers:    make(map[int]string),
+		running: map[string]*runningTask{
+			taskKey(7, 42): {task: &types.EnhancedTask{Number: 42, Repository: hive.Repository{Owner: "acme", Repository: "widgets"}, RequiredCapabilities: []string{"gpu-inference"}}},
+			taskKey(7, 43): {task: &types.EnhancedTask{Number: 43, Repository: hive.Repository{Owner: "acme", Repository: "widgets"}, RequiredCapabilities: []string{"gpu-inference"}}},
+		},
+		peerAvailability: func(id peer.ID) (pubsub.PeerAvailability, bool) {
+			availability, ok := broadcasts[id]
+			return availability, ok
+		},
+	}
+
+	for _, id := range []peer.ID{busy, unsuited, suited} {
+		hi.addHelpOffer(42, id.String())
+	}
+	if helper := hi.acceptHelpOffer(42); helper != suited.String() {
+		t.Errorf("expected the peer with room and the required capability to be chosen, got %s", helper)
+	}
+
+	// With only unsuitable offers, the most reputable is still taken
+	hi.addHelpOffer(43, busy.String())
+	hi.addHelpOffer(43, unsuited.String())
+	if helper := hi.acceptHelpOffer(43); helper != busy.String() {
+		t.Errorf("expected the most reputable peer when none fits, got %s", helper)
+	}
+
+	// A peer never heard from gets the benefit of the doubt
+	hi.addHelpOffer(44, busy.String())
+	hi.addHelpOffer(44, unknown.String())
+	if helper := hi.acceptHelpOffer(44); helper != unknown.String() {
+		t.Errorf("expected a peer with no broadcast over a busy one, got %s", helper)
+	}
+}
+
+func TestOnlyWithdrawingAnOfferCostsHelpReputation(t *testing.T) {
+	helper, bystander := testPeerID(t), testPeerID(t)
+	store := reputation.NewStore("")
+	hi := &Integration{
+		reputation:       store,
+		helpOffers:       make(map[int][]string),
+		helpers:          make(map[int]string),
+		peerAvailability: func(peer.ID) (pubsub.PeerAvailability, bool) { return pubsub.PeerAvailability{}, false },
+	}
+	refusal := func(issueID int) pubsub.Message {
+		return pubsub.Message{Data: map[string]interface{}{"issue_id": float64(issueID), "can_help": false}}
+	}
+
+	// The task failing isn't the helper's doing
+	hi.addHelpOffer(42, helper.String())
+	hi.acceptHelpOffer(42)
+	hi.forgetHelper(42)
+	if _, recorded := store.GetRecord(helper.String()); recorded {
+		t.Fatal("a failed task must not count against its helper")
+	}
+
+	// Turning down a task it never offered to help with costs a peer nothing
+	hi.handleHelpResponse(refusal(43), bystander)
+	if _, recorded := store.GetRecord(bystander.String()); recorded {
+		t.Fatal("a peer that never offered must not lose reputation for declining")
+	}
+
+	// Backing out after being accepted does
+	hi.addHelpOffer(43, helper.String())
+	hi.acceptHelpOffer(43)
+	hi.handleHelpResponse(refusal(43), helper)
+	if record, _ := store.GetRecord(helper.String()); record.HelpRejected != 1 {
+		t.Fatalf("expected the withdrawn offer to be recorded once, got %+v", record)
+	}
+	if _, stillHelping := hi.helpers[43]; stillHelping {
+		t.Fatal("expected the peer to be dropped as the task's helper")
+	}
+}
@@ -0,0 +1,54 @@
+package github
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+)
+
+// Idle is closed when the agent has gone agent.idle_shutdown without claiming a
+// task and has nothing running, so an ephemeral node can exit and be reclaimed
+func (hi *Integration) Idle() <-chan struct{} {
+	return hi.idle
+}
+
+// recordActivity restarts the idle clock
+func (hi *Integration) recordActivity() {
+	hi.lastActivity.Store(time.Now().UnixNano())
+}
+
+// idleShutdownLoop signals Idle once the agent has had no work for idleAfter
+func (hi *Integration) idleShutdownLoop(idleAfter time.Duration) {
+	hi.recordActivity() // Idle time counts from startup
+	checkEvery := idleAfter / 10
+	if checkEvery > time.Minute {
+		checkEvery = time.Minute
+	}
+	ticker := time.NewTicker(checkEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-hi.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		idleFor := time.Since(time.Unix(0, hi.lastActivity.Load()))
+		if idleFor < idleAfter || len(hi.RunningTasks()) > 0 {
+			continue
+		}
+
+		// Stop claiming first so nothing starts while the node shuts down
+		hi.Pause("idle shutdown")
+		fmt.Printf("💤 Agent %s idle for %s, shutting down\n", hi.config.AgentID, idleFor.Round(time.Second))
+		hi.hlog.Append(logging.PeerLeft, map[string]interface{}{
+			"agent_id": hi.config.AgentID,
+			"reason":   "idle shutdown",
+			"idle_for": idleFor.String(),
+		})
+		close(hi.idle)
+		return
+	}
+}
@@ -0,0 +1,52 @@
+package github
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestIdleSignalFiresOnlyAfterIdlePeriodWithNoWork(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hi := &Integration{
+		ctx:    ctx,
+		config: &IntegrationConfig{AgentID: "agent-a"},
+		hlog:   logging.NewHypercoreLog(peer.ID("test")),
+		idle:   make(chan struct{}),
+	}
+
+	// A running task keeps the agent alive however long it takes
+	_, done := hi.registerRunning(&types.EnhancedTask{Number: 42, ProjectID: 7})
+	const idleAfter = 50 * time.Millisecond
+	started := time.Now()
+	go hi.idleShutdownLoop(idleAfter)
+
+	select {
+	case <-hi.Idle():
+		t.Fatal("idle signal fired while a task was running")
+	case <-time.After(4 * idleAfter):
+	}
+
+	done()
+	hi.recordActivity() // As startExecution does when a task finishes
+	finished := time.Now()
+	select {
+	case <-hi.Idle():
+	case <-time.After(2 * time.Second):
+		t.Fatal("idle signal did not fire once the agent had no work")
+	}
+	if waited := time.Since(finished); waited < idleAfter {
+		t.Errorf("idle signal fired %s after the last task finished, before the %s idle period", waited, idleAfter)
+	}
+	if time.Since(started) < 5*idleAfter {
+		t.Error("idle signal fired too early")
+	}
+	if !hi.Paused() {
+		t.Error("expected the agent to stop claiming before shutting down")
+	}
+}
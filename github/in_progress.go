@@ -0,0 +1,86 @@
+package github
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// Claim comments carry a hidden marker naming the claiming agent, whatever their
+// template says, so other agents can tell the task is being worked. Releasing
+// the task retires the marker.
+const (
+	claimMarkerFormat = "<!-- bzzz-claim agent:%s -->"
+	retiredMarker     = "<!-- bzzz-claim-released"
+)
+
+var claimMarkerPattern = regexp.MustCompile(`<!-- bzzz-claim agent:(\S+) -->`)
+
+// claimMarker is the marker appended to agentID's claim comment
+func claimMarker(agentID string) string {
+	return fmt.Sprintf(claimMarkerFormat, agentID)
+}
+
+// hasInProgressLabel reports whether an issue is labelled as being worked on,
+// which an agent whose assignment failed still leaves behind
+func (c *Client) hasInProgressLabel(issue *github.Issue) bool {
+	for _, label := range issue.Labels {
+		if label.GetName() == c.config.InProgressLabel {
+			return true
+		}
+	}
+	return false
+}
+
+// claimingAgent returns the agent whose claim comment on an issue is still in
+// force, or "" if there is none
+func (c *Client) claimingAgent(issueNumber int) (string, error) {
+	comments, err := c.listComments(issueNumber)
+	if err != nil {
+		return "", err
+	}
+	agentID := ""
+	for _, comment := range comments {
+		if match := claimMarkerPattern.FindStringSubmatch(comment.GetBody()); match != nil {
+			agentID = match[1]
+		}
+	}
+	return agentID, nil
+}
+
+// retireClaimComments marks every live claim comment on an issue as released
+func (c *Client) retireClaimComments(issueNumber int) error {
+	comments, err := c.listComments(issueNumber)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		if !claimMarkerPattern.MatchString(comment.GetBody()) {
+			continue
+		}
+		body := strings.ReplaceAll(comment.GetBody(), "<!-- bzzz-claim agent:", retiredMarker+" agent:")
+		if _, _, err := c.client.Issues.EditComment(c.ctx, c.config.Owner, c.config.Repository, comment.GetID(), &github.IssueComment{Body: &body}); err != nil {
+			return fmt.Errorf("failed to retire claim comment: %w", err)
+		}
+	}
+	return nil
+}
+
+// listComments returns an issue's comments, oldest first
+func (c *Client) listComments(issueNumber int) ([]*github.IssueComment, error) {
+	var all []*github.IssueComment
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := c.client.Issues.ListComments(c.ctx, c.config.Owner, c.config.Repository, issueNumber, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list comments: %w", err)
+		}
+		all = append(all, comments...)
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -2,23 +2,37 @@ package github
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/anthonyrawlins/bzzz/executor"
 	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/budget"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
 	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/reputation"
+	"github.com/anthonyrawlins/bzzz/pkg/supervisor"
+	"github.com/anthonyrawlins/bzzz/pkg/tracing"
 	"github.com/anthonyrawlins/bzzz/pkg/types"
 	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/anthonyrawlins/bzzz/reasoning"
+	"github.com/google/go-github/v57/github"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Integration handles dynamic repository discovery via Hive API
 type Integration struct {
 	hiveClient *hive.HiveClient
-	githubToken string
+	tokens *TokenResolver
 	pubsub *pubsub.PubSub
 	hlog *logging.HypercoreLog
 	ctx context.Context
@@ -32,6 +46,124 @@ type Integration struct {
 	// Conversation tracking
 	activeDiscussions map[string]*Conversation // "projectID:taskID" -> conversation
 	discussionLock sync.RWMutex
+
+	// Claim arbitration
+	claimIntents map[string]map[string]*claimIntent // "projectID:taskID" -> agentID -> intent
+	claimIntentLock sync.Mutex
+	resolver Resolver // Arbitrates competing claims; nil means ScoreResolver
+
+	// Repeated failure tracking
+	failures *failureTracker
+
+	// Help offers, chosen by peer reputation
+	reputation *reputation.Store
+	helpOffers map[int][]string // taskID -> offering peer IDs
+	helpers map[int]string // taskID -> accepted helper peer ID
+	helpLock sync.Mutex
+	peerAvailability func(id peer.ID) (pubsub.PeerAvailability, bool) // What a peer last broadcast; nil means the pubsub registries
+
+	// Escalations awaiting a human reply
+	escalations map[string]*pendingEscalation // escalationID -> escalation
+	escalationLock sync.Mutex
+
+	// Serializes task polling
+	pollLock sync.Mutex
+
+	// Widens the poll interval while no suitable tasks turn up
+	pollBackoff *pollBackoff
+
+	// Reasoning budgets per task and for the agent
+	budgets *budget.Tracker
+
+	// Closed once the reasoning models are configured; polling waits for it. nil doesn't wait
+	reasoningReady <-chan struct{}
+
+	// Set while an operator has paused claiming new tasks
+	paused atomic.Bool
+
+	// Set once the agent starts shutting down; no more tasks are claimed after it
+	stopped atomic.Bool
+
+	// Idle shutdown for ephemeral agents
+	lastActivity atomic.Int64 // Unix nanoseconds of the last claim or finished task
+	idle chan struct{} // Closed when the agent shuts down for lack of work
+
+	// Told about tasks left waiting on their dependencies; nil tells nobody
+	waitListener atomic.Pointer[WaitListener]
+
+	// Tasks being executed, so they can be listed and cancelled
+	running map[string]*runningTask // "projectID:taskID" -> task
+	runningLock sync.Mutex
+	executions sync.WaitGroup // Task executions still unwinding, for shutdown to wait on
+
+	// Guards config.Capabilities, which can change while the agent runs
+	capabilitiesLock sync.RWMutex
+
+	// Proposes sub-tasks for a task too big for one run; nil means proposeSplit
+	splitTask func(ctx context.Context, task *types.EnhancedTask, attempted *types.DiffStats) ([]SubTask, error)
+
+	// Runs claimed tasks; nil means executeTask
+	execute func(ctx context.Context, task *types.EnhancedTask, repoClient *RepositoryClient)
+
+	// Drives a task in its sandbox; nil means executor.ExecuteTask
+	runExecutor func(ctx context.Context, task *types.EnhancedTask, hlog *logging.HypercoreLog, agentConfig *config.AgentConfig, budgets *budget.Tracker) (*executor.ExecuteTaskResult, error)
+}
+
+// IntegrationConfig holds configuration for Hive-based GitHub integration
+type IntegrationConfig struct {
+	AgentID      string
+	Capabilities []string
+	PollInterval time.Duration
+	MaxTasks     int
+	Assignee     string // GitHub username used when claiming issues
+
+	MaxPollInterval time.Duration // Cap for the poll backoff while no suitable tasks turn up
+
+	DraftPullRequests bool // Open every task PR as a draft; repositories and tasks can also ask for drafts
+
+	DeleteFailedBranches bool // Delete a task's branch when its execution fails, rather than leave it for inspection
+
+	SplitOversizedTasks bool // File sub-issues for tasks the reasoning model judges too big for one run, or that outgrow the diff limits
+
+	EscalationWebhook string // N8N webhook that receives structured escalations; empty disables it
+
+	// Repository ("owner/repo" or Hive project ID) -> webhook and routing metadata
+	// for its escalations, taking precedence over EscalationWebhook
+	EscalationRoutes map[string]config.EscalationRoute
+
+	MinCoordinationPeers int // Peers needed before asking the mesh for help; with fewer the agent escalates straight to humans. 0 always asks.
+
+	OperatorPeers []peer.ID // Peers whose task cancel and agent pause requests are obeyed; empty obeys none
+
+	// Only tasks with a priority in this band are considered; 0 leaves that end open
+	MinPriority int
+	MaxPriority int
+
+	OwnerTokens map[string]string // Repository owner -> GitHub token, for owners the default token can't access
+
+	// GitHub Enterprise Server API and upload URLs; empty uses github.com
+	GitHubBaseURL   string
+	GitHubUploadURL string
+
+	// Label conventions; empty values fall back to the client defaults
+	TaskLabel       string
+	InProgressLabel string
+	CompletedLabel  string
+	NeedsHumanLabel string
+	ApprovalLabel   string // Approves proposed tasks in repositories that require approval
+
+	// Claim comment and pull request text; nil uses the built-in English text
+	Templates *Templates
+}
+
+// Conversation represents a meta-discussion conversation about a task
+type Conversation struct {
+	TaskID          int
+	TaskTitle       string
+	TaskDescription string
+	History         []string
+	LastUpdated     time.Time
+	IsEscalated     bool
 }
 
 // RepositoryClient wraps a GitHub client for a specific repository
@@ -49,10 +181,13 @@ func NewIntegration(ctx context.Context, hiveClient *hive.HiveClient, githubToke
 	if config.MaxTasks == 0 {
 		config.MaxTasks = 3
 	}
+	if config.MaxPollInterval < config.PollInterval {
+		config.MaxPollInterval = config.PollInterval
+	}
 
 	return &Integration{
 		hiveClient:        hiveClient,
-		githubToken:       githubToken,
+		tokens:            NewTokenResolver(githubToken, config.OwnerTokens),
 		pubsub:            ps,
 		hlog:              hlog,
 		ctx:               ctx,
@@ -60,19 +195,50 @@ func NewIntegration(ctx context.Context, hiveClient *hive.HiveClient, githubToke
 		agentConfig:       agentConfig,
 		repositories:      make(map[int]*RepositoryClient),
 		activeDiscussions: make(map[string]*Conversation),
+		claimIntents:      make(map[string]map[string]*claimIntent),
+		failures:          newFailureTracker(getFailureFile(config.AgentID)),
+		reputation:        reputation.NewStore(getReputationFile(config.AgentID)),
+		helpOffers:        make(map[int][]string),
+		helpers:           make(map[int]string),
+		escalations:       make(map[string]*pendingEscalation),
+		running:           make(map[string]*runningTask),
+		pollBackoff:       newPollBackoff(config.PollInterval, config.MaxPollInterval),
+		budgets:           budget.NewTracker(agentConfig.Budget),
+		reasoningReady:    reasoning.Configured(),
+		idle:              make(chan struct{}),
 	}
 }
 
+// Reputation returns the store of peer reputations this agent keeps
+func (hi *Integration) Reputation() *reputation.Store {
+	return hi.reputation
+}
+
+// getReputationFile returns the path to store peer reputation for an agent
+func getReputationFile(agentID string) string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config", "bzzz", fmt.Sprintf("peer-reputation-%s.json", agentID))
+}
+
 // Start begins the Hive-GitHub integration
 func (hi *Integration) Start() {
 	fmt.Printf("🔗 Starting Hive-GitHub integration for agent: %s\n", hi.config.AgentID)
 	
 	// Register the handler for incoming meta-discussion messages
-	hi.pubsub.SetAntennaeMessageHandler(hi.handleMetaDiscussion)
+	hi.pubsub.AddAntennaeMessageHandler(hi.handleMetaDiscussion)
+
+	// Register the handler for Bzzz coordination messages (claim intents etc.)
+	hi.pubsub.AddBzzzMessageHandler(hi.handleBzzzMessage)
 	
 	// Start repository discovery and task polling
-	go hi.repositoryDiscoveryLoop()
-	go hi.taskPollingLoop()
+	// Supervised so a panic restarts the loop rather than silently ending it
+	supervisor.Go(hi.ctx, "repository discovery", hi.repositoryDiscoveryLoop)
+	supervisor.Go(hi.ctx, "task polling", hi.taskPollingLoop)
+	supervisor.Go(hi.ctx, "access check", hi.accessCheckLoop)
+
+	if hi.agentConfig.IdleShutdown > 0 {
+		supervisor.Go(hi.ctx, "idle shutdown", func() { hi.idleShutdownLoop(hi.agentConfig.IdleShutdown) })
+	}
 }
 
 // repositoryDiscoveryLoop periodically discovers active repositories from Hive
@@ -104,76 +270,195 @@ func (hi *Integration) syncRepositories() {
 	hi.repositoryLock.Lock()
 	defer hi.repositoryLock.Unlock()
 	
-	// Track which repositories we've seen
-	currentRepos := make(map[int]bool)
-	
+	// Track which projects we've seen
+	current := make(map[int]hive.Repository, len(repositories))
 	for _, repo := range repositories {
-		currentRepos[repo.ProjectID] = true
-		
-		// Check if we already have a client for this repository
-		if _, exists := hi.repositories[repo.ProjectID]; !exists {
-			// Create new GitHub client for this repository
-			githubConfig := &Config{
-				AccessToken: hi.githubToken,
-				Owner:       repo.Owner,
-				Repository:  repo.Repository,
-				BaseBranch:  repo.Branch,
-			}
-			
-			client, err := NewClient(hi.ctx, githubConfig)
-			if err != nil {
-				fmt.Printf("❌ Failed to create GitHub client for %s/%s: %v\n", repo.Owner, repo.Repository, err)
-				continue
-			}
-			
-			hi.repositories[repo.ProjectID] = &RepositoryClient{
-				Client:     client,
-				Repository: repo,
-				LastSync:   time.Now(),
-			}
-			
-			fmt.Printf("✅ Added repository: %s/%s (Project ID: %d)\n", repo.Owner, repo.Repository, repo.ProjectID)
-		}
+		current[repo.ProjectID] = repo
 	}
 	
-	// Remove repositories that are no longer active
+	// Remove projects that are no longer active
 	for projectID := range hi.repositories {
-		if !currentRepos[projectID] {
+		if _, active := current[projectID]; !active {
 			delete(hi.repositories, projectID)
 			fmt.Printf("🗑️ Removed inactive repository (Project ID: %d)\n", projectID)
 		}
 	}
 	
-	fmt.Printf("📊 Repository sync complete: %d active repositories\n", len(hi.repositories))
+	// A shared client whose project went away is handed to one of the projects
+	// still using it, so its tasks report to a live project
+	rehomed := make(map[*RepositoryClient]*RepositoryClient)
+	for projectID, repoClient := range hi.repositories {
+		if _, active := current[repoClient.Repository.ProjectID]; active {
+			continue
+		}
+		if rehomed[repoClient] == nil {
+			rehomed[repoClient] = &RepositoryClient{Client: repoClient.Client, Repository: current[projectID], LastSync: repoClient.LastSync}
+		}
+		hi.repositories[projectID] = rehomed[repoClient]
+	}
+	
+	// Projects pointing at the same GitHub repository share one client, so its
+	// issues are polled, and claimed, once
+	shared := make(map[string]*RepositoryClient)
+	for _, repoClient := range hi.repositories {
+		shared[repositoryKey(repoClient.Repository)] = repoClient
+	}
+	
+	for _, repo := range repositories {
+		if _, exists := hi.repositories[repo.ProjectID]; exists {
+			continue
+		}
+		if repoClient, exists := shared[repositoryKey(repo)]; exists {
+			hi.repositories[repo.ProjectID] = repoClient
+			fmt.Printf("🔗 Project %d shares %s/%s with project %d\n", repo.ProjectID, repo.Owner, repo.Repository, repoClient.Repository.ProjectID)
+			if !sameTaskSettings(repo, repoClient.Repository) {
+				fmt.Printf("⚠️ Project %d's task settings differ from project %d's; its tasks run with project %d's\n", repo.ProjectID, repoClient.Repository.ProjectID, repoClient.Repository.ProjectID)
+			}
+			continue
+		}
+		
+		// Create new GitHub client for this repository
+		client, err := NewClient(hi.ctx, hi.repositoryConfig(repo))
+		if err != nil {
+			fmt.Printf("❌ Failed to create GitHub client for %s/%s: %v\n", repo.Owner, repo.Repository, err)
+			continue
+		}
+		
+		repoClient := &RepositoryClient{
+			Client:     client,
+			Repository: repo,
+			LastSync:   time.Now(),
+		}
+		hi.repositories[repo.ProjectID] = repoClient
+		shared[repositoryKey(repo)] = repoClient
+		
+		fmt.Printf("✅ Added repository: %s/%s (Project ID: %d)\n", repo.Owner, repo.Repository, repo.ProjectID)
+	}
+	
+	fmt.Printf("📊 Repository sync complete: %d active repositories across %d projects\n", len(shared), len(hi.repositories))
+}
+
+// repositoryKey identifies a GitHub repository and the branch tasks are based on,
+// whichever Hive projects point at them. Projects on different branches keep
+// their own clients so each task branches from, and merges into, its own base.
+func repositoryKey(repo hive.Repository) string {
+	return strings.ToLower(repo.Owner+"/"+repo.Repository) + "@" + repo.Branch
+}
+
+// sameTaskSettings reports whether two projects would run a task the same way
+func sameTaskSettings(a, b hive.Repository) bool {
+	return a.SandboxImage == b.SandboxImage && a.VerifyCommand == b.VerifyCommand &&
+		a.RequireApproval == b.RequireApproval && a.DraftPullRequests == b.DraftPullRequests
+}
+
+// uniqueRepositories returns each repository client once, however many projects
+// share it; callers must hold repositoryLock
+func (hi *Integration) uniqueRepositories() []*RepositoryClient {
+	seen := make(map[*RepositoryClient]bool, len(hi.repositories))
+	repositories := make([]*RepositoryClient, 0, len(hi.repositories))
+	for _, repoClient := range hi.repositories {
+		if !seen[repoClient] {
+			seen[repoClient] = true
+			repositories = append(repositories, repoClient)
+		}
+	}
+	return repositories
+}
+
+// repositoryProjects returns the IDs of the projects sharing a repository client
+func (hi *Integration) repositoryProjects(repoClient *RepositoryClient) []int {
+	hi.repositoryLock.RLock()
+	defer hi.repositoryLock.RUnlock()
+
+	var projects []int
+	for projectID, candidate := range hi.repositories {
+		if candidate == repoClient {
+			projects = append(projects, projectID)
+		}
+	}
+	sort.Ints(projects)
+	return projects
+}
+
+// repositoryConfig builds the GitHub client configuration for a repository,
+// using the token mapped to its owner
+func (hi *Integration) repositoryConfig(repo hive.Repository) *Config {
+	return &Config{
+		AccessToken: hi.tokens.TokenFor(repo.Owner),
+		Owner:       repo.Owner,
+		Repository:  repo.Repository,
+		BaseBranch:  repo.Branch,
+		Assignee:    hi.config.Assignee,
+		BaseURL:     hi.config.GitHubBaseURL,
+		UploadURL:   hi.config.GitHubUploadURL,
+
+		TaskLabel:       hi.config.TaskLabel,
+		InProgressLabel: hi.config.InProgressLabel,
+		CompletedLabel:  hi.config.CompletedLabel,
+		NeedsHumanLabel: hi.config.NeedsHumanLabel,
+
+		Templates: hi.config.Templates,
+	}
 }
 
-// taskPollingLoop periodically polls all repositories for available tasks
+// taskPollingLoop periodically polls all repositories for available tasks,
+// backing off while polls keep coming up empty
 func (hi *Integration) taskPollingLoop() {
-	ticker := time.NewTicker(hi.config.PollInterval)
-	defer ticker.Stop()
+	// Tasks can't run until the reasoning models are configured
+	if hi.reasoningReady != nil {
+		select {
+		case <-hi.ctx.Done():
+			return
+		case <-hi.reasoningReady:
+		}
+	}
+
+	timer := time.NewTimer(hi.pollBackoff.interval())
+	defer timer.Stop()
 	
 	for {
 		select {
 		case <-hi.ctx.Done():
 			return
-		case <-ticker.C:
+		case <-hi.pollBackoff.reset:
+			// Work turned up elsewhere (e.g. a webhook poll), so stop waiting out the backoff
+		case <-timer.C:
 			hi.pollAllRepositories()
 		}
+		timer.Reset(hi.pollBackoff.interval())
 	}
 }
 
 // pollAllRepositories checks all active repositories for available tasks
 func (hi *Integration) pollAllRepositories() {
 	hi.repositoryLock.RLock()
-	repositories := make([]*RepositoryClient, 0, len(hi.repositories))
-	for _, repo := range hi.repositories {
-		repositories = append(repositories, repo)
-	}
+	repositories := hi.uniqueRepositories()
 	hi.repositoryLock.RUnlock()
 	
-	if len(repositories) == 0 {
+	hi.pollRepositories(repositories)
+}
+
+// pollRepositories looks for available tasks in the given repositories and claims the best one
+func (hi *Integration) pollRepositories(repositories []*RepositoryClient) {
+	if len(repositories) == 0 || hi.Paused() || hi.stopped.Load() {
+		return
+	}
+	if !hi.reasoningConfigured() {
+		fmt.Printf("⏳ Reasoning models not configured yet, skipping poll\n")
 		return
 	}
+	// A task claimed now would only be aborted at its first model call
+	if err := hi.budgets.CheckAgent(); err != nil {
+		fmt.Printf("💸 Not claiming new tasks until the budget window rolls over: %v\n", err)
+		return
+	}
+	
+	// Timer and webhook-triggered polls must not race to claim the same task
+	hi.pollLock.Lock()
+	defer hi.pollLock.Unlock()
+	if hi.stopped.Load() {
+		return // StopPolling ran while this poll waited for the lock
+	}
 	
 	fmt.Printf("🔍 Polling %d repositories for available tasks...\n", len(repositories))
 	
@@ -188,24 +473,47 @@ func (hi *Integration) pollAllRepositories() {
 			continue
 		}
 		allTasks = append(allTasks, tasks...)
+		allTasks = append(allTasks, hi.getReclaimableTasks(repoClient)...)
 	}
 	
 	if len(allTasks) == 0 {
+		hi.recordEmptyPoll()
 		return
 	}
 	
 	fmt.Printf("📋 Found %d total available tasks across all repositories\n", len(allTasks))
 	
 	// Apply filtering and selection
-	suitableTasks := hi.filterSuitableTasks(allTasks)
+	suitableTasks := hi.approvedTasks(hi.filterSuitableTasks(allTasks))
 	if len(suitableTasks) == 0 {
-		fmt.Printf("⚠️ No suitable tasks for agent capabilities: %v\n", hi.config.Capabilities)
+		fmt.Printf("⚠️ No suitable tasks for agent capabilities: %v\n", hi.capabilities())
+		hi.recordEmptyPoll()
 		return
 	}
+	hi.pollBackoff.recordWork()
 	
-	// Select and claim the highest priority task
-	task := suitableTasks[0]
-	hi.claimAndExecuteTask(task)
+	// Claim the highest priority task that isn't waiting on other tasks
+	for _, task := range suitableTasks {
+		if hi.stopped.Load() {
+			return
+		}
+		if hi.claimAndExecuteTask(task) {
+			return
+		}
+	}
+}
+
+// reasoningConfigured reports whether the reasoning models are ready for tasks to use
+func (hi *Integration) reasoningConfigured() bool {
+	if hi.reasoningReady == nil {
+		return true
+	}
+	select {
+	case <-hi.reasoningReady:
+		return true
+	default:
+		return false
+	}
 }
 
 // getRepositoryTasks fetches available tasks from a specific repository
@@ -219,47 +527,94 @@ func (hi *Integration) getRepositoryTasks(repoClient *RepositoryClient) ([]*type
 	// Convert to enhanced tasks with project context
 	var enhancedTasks []*types.EnhancedTask
 	for _, task := range githubTasks {
-		enhancedTask := &types.EnhancedTask{
-			ID:          task.ID,
-			Number:      task.Number,
-			Title:       task.Title,
-			Description: task.Description,
-			State:       task.State,
-			Labels:      task.Labels,
-			Assignee:    task.Assignee,
-			CreatedAt:   task.CreatedAt,
-			UpdatedAt:   task.UpdatedAt,
-			TaskType:    task.TaskType,
-			Priority:    task.Priority,
-			Requirements: task.Requirements,
-			Deliverables: task.Deliverables,
-			Context:     task.Context,
-			ProjectID:  repoClient.Repository.ProjectID,
-			GitURL:     repoClient.Repository.GitURL,
-			Repository: repoClient.Repository,
-		}
-		enhancedTasks = append(enhancedTasks, enhancedTask)
+		enhancedTasks = append(enhancedTasks, newEnhancedTask(repoClient, task))
 	}
 	
-		return enhancedTasks, nil
+	return enhancedTasks, nil
+}
+
+// newEnhancedTask adds project context to a GitHub task
+func newEnhancedTask(repoClient *RepositoryClient, task *Task) *types.EnhancedTask {
+	return &types.EnhancedTask{
+		ID:                   task.ID,
+		Number:               task.Number,
+		Title:                task.Title,
+		Description:          task.Description,
+		State:                task.State,
+		Labels:               task.Labels,
+		Assignee:             task.Assignee,
+		CreatedAt:            task.CreatedAt,
+		UpdatedAt:            task.UpdatedAt,
+		TaskType:             task.TaskType,
+		Priority:             task.Priority,
+		Requirements:         task.Requirements,
+		Deliverables:         task.Deliverables,
+		Context:              task.Context,
+		RequiredCapabilities: task.RequiredCapabilities,
+		Checklist:            parseChecklist(task.Description),
+		Dependencies:         parseDependencies(task.Description),
+		ProjectID:            repoClient.Repository.ProjectID,
+		GitURL:               repoClient.Repository.GitURL,
+		Repository:           repoClient.Repository,
+	}
 }
 
-// filterSuitableTasks filters tasks based on agent capabilities
+// filterSuitableTasks filters tasks based on agent capabilities and priority
+// band, highest priority first
 func (hi *Integration) filterSuitableTasks(tasks []*types.EnhancedTask) []*types.EnhancedTask {
 	var suitable []*types.EnhancedTask
 	
 	for _, task := range tasks {
-		if hi.canHandleTaskType(task.TaskType) {
+		if hi.needsHuman(task) || !hi.inPriorityBand(task.Priority) {
+			continue
+		}
+		if hi.canHandleTask(task) {
 			suitable = append(suitable, task)
 		}
 	}
 	
+	sort.SliceStable(suitable, func(i, j int) bool { return suitable[i].Priority > suitable[j].Priority })
 	return suitable
 }
 
+// inPriorityBand reports whether a task's priority is within the agent's configured band
+func (hi *Integration) inPriorityBand(priority int) bool {
+	if hi.config.MinPriority > 0 && priority < hi.config.MinPriority {
+		return false
+	}
+	return hi.config.MaxPriority == 0 || priority <= hi.config.MaxPriority
+}
+
+// canHandleTask checks the task's type and any capabilities its issue requires.
+// Required capabilities are strict: "general" doesn't stand in for them.
+func (hi *Integration) canHandleTask(task *types.EnhancedTask) bool {
+	if !hi.canHandleTaskType(task.TaskType) {
+		return false
+	}
+	return len(hi.missingCapabilities(task)) == 0
+}
+
+// missingCapabilities returns the capabilities a task requires that this agent lacks
+func (hi *Integration) missingCapabilities(task *types.EnhancedTask) []string {
+	var missing []string
+	for _, required := range task.RequiredCapabilities {
+		has := false
+		for _, capability := range hi.capabilities() {
+			if strings.EqualFold(capability, required) {
+				has = true
+				break
+			}
+		}
+		if !has {
+			missing = append(missing, required)
+		}
+	}
+	return missing
+}
+
 // canHandleTaskType checks if this agent can handle the given task type
 func (hi *Integration) canHandleTaskType(taskType string) bool {
-	for _, capability := range hi.config.Capabilities {
+	for _, capability := range hi.capabilities() {
 		if capability == taskType || capability == "general" || capability == "task-coordination" {
 			return true
 		}
@@ -267,78 +622,286 @@ func (hi *Integration) canHandleTaskType(taskType string) bool {
 	return false
 }
 
-// claimAndExecuteTask claims a task and begins execution
-func (hi *Integration) claimAndExecuteTask(task *types.EnhancedTask) {
+// needsHuman reports whether a task has been handed to humans or is cooling down after repeated failures
+func (hi *Integration) needsHuman(task *types.EnhancedTask) bool {
+	for _, label := range task.Labels {
+		if label == hi.needsHumanLabel() {
+			return true
+		}
+	}
+	return hi.failures.coolingDown(taskKey(task.ProjectID, task.Number))
+}
+
+// needsHumanLabel returns the label applied to tasks handed over to humans
+func (hi *Integration) needsHumanLabel() string {
+	if hi.config.NeedsHumanLabel != "" {
+		return hi.config.NeedsHumanLabel
+	}
+	return DefaultNeedsHumanLabel
+}
+
+// handleTaskFailure counts a failed execution. Once a task has failed too many
+// times it is released, labeled for humans, escalated and not re-claimed until
+// its cooldown ends. It reports whether the task was escalated.
+func (hi *Integration) handleTaskFailure(task *types.EnhancedTask, repoClient *RepositoryClient, reason string) bool {
+	maxFailures, cooldown := 2, 24*time.Hour
+	if hi.agentConfig != nil {
+		maxFailures, cooldown = hi.agentConfig.MaxTaskFailures, hi.agentConfig.FailureCooldown
+	}
+
+	count, tripped := hi.failures.recordFailure(taskKey(task.ProjectID, task.Number), reason, maxFailures, cooldown)
+	if !tripped {
+		fmt.Printf("⚠️ Task #%d has failed %d time(s)\n", task.Number, count)
+		return false
+	}
+
+	fmt.Printf("🛑 Task #%d failed %d times, releasing it for human attention\n", task.Number, count)
+	if err := repoClient.Client.ReleaseTask(task.Number); err != nil {
+		fmt.Printf("⚠️ Failed to release task #%d: %v\n", task.Number, err)
+	}
+	if err := repoClient.Client.AddLabel(task.Number, hi.needsHumanLabel()); err != nil {
+		fmt.Printf("⚠️ Failed to label task #%d: %v\n", task.Number, err)
+	}
+
+	hi.triggerHumanEscalation(task, &Conversation{TaskID: task.Number, TaskTitle: task.Title},
+		fmt.Sprintf("Task failed %d times in a row, last error: %s", count, reason))
+	return true
+}
+
+// claimAndExecuteTask claims a task and begins execution. It returns false
+// without claiming if the task is still waiting on its dependencies.
+func (hi *Integration) claimAndExecuteTask(task *types.EnhancedTask) bool {
 	hi.repositoryLock.RLock()
 	repoClient, exists := hi.repositories[task.ProjectID]
 	hi.repositoryLock.RUnlock()
 	
 	if !exists {
 		fmt.Printf("❌ Repository client not found for project %d\n", task.ProjectID)
-		return
+		return true
+	}
+
+	if open := hi.openDependencies(task, repoClient); len(open) > 0 {
+		fmt.Printf("⏳ Task #%d is waiting on %s\n", task.Number, strings.Join(open, ", "))
+		return false
+	}
+
+	// Announce our intent and back off if a better-matched agent wants the task
+	if !hi.arbitrateClaim(task) {
+		return true
+	}
+
+	ctx, span := hi.startTaskSpan(task)
+	err := hi.claimTask(ctx, task, repoClient)
+	if err != nil {
+		tracing.End(span, err)
+	}
+	switch {
+	case err == nil:
+		hi.startExecution(ctx, task, repoClient)
+	case errors.Is(err, hive.ErrClaimHeld), errors.Is(err, ErrTaskAlreadyClaimed):
+		// Another agent got there first, which is normal on a busy mesh
+		fmt.Printf("🤚 Task #%d is claimed by another agent\n", task.Number)
+	case errors.Is(err, ErrRepoAccess):
+		// Retrying won't help until someone fixes the token, so count it towards escalation
+		fmt.Printf("🔒 No access to claim task #%d: %v\n", task.Number, err)
+		hi.handleTaskFailure(task, repoClient, err.Error())
+	default:
+		fmt.Printf("❌ Failed to claim task #%d: %v\n", task.Number, err)
+	}
+	return true
+}
+
+// claimTask takes the Hive lease and the GitHub assignment for a task
+func (hi *Integration) claimTask(ctx context.Context, task *types.EnhancedTask, repoClient *RepositoryClient) (err error) {
+	ctx, span := tracing.Start(ctx, "claim", taskAttributes(task)...)
+	defer func() { tracing.End(span, err) }()
+
+	// Take the lease in Hive first so two agents can't both reclaim an abandoned task
+	if err := hi.hiveClient.ClaimTask(ctx, task.ProjectID, task.Number, hi.config.AgentID, hi.claimLease()); err != nil {
+		switch {
+		case errors.Is(err, hive.ErrClaimHeld):
+			return err
+		case errors.Is(err, hive.ErrHiveUnavailable):
+			// The GitHub assignment still keeps other agents off the task
+			fmt.Printf("⚠️ Hive unavailable, claiming task #%d on GitHub only: %v\n", task.Number, err)
+		default:
+			fmt.Printf("⚠️ Failed to report task claim to Hive: %v\n", err)
+		}
+	}
+
+	// An expired lease leaves the dead agent's assignment behind on GitHub
+	if task.ReclaimedFrom != "" {
+		fmt.Printf("♻️ Reclaiming task #%d from agent %s after its lease expired\n", task.Number, task.ReclaimedFrom)
+		release := func() error { return repoClient.Client.ReleaseTask(task.Number) }
+		if err := traceGitHub(ctx, "release_task", task, release); err != nil {
+			return fmt.Errorf("failed to release task #%d for reclaim: %w", task.Number, err)
+		}
 	}
 	
 	// Claim the task in GitHub
-	_, err := repoClient.Client.ClaimTask(task.Number, hi.config.AgentID)
-	if err != nil {
-		fmt.Printf("❌ Failed to claim task %d in %s/%s: %v\n", 
-			task.Number, task.Repository.Owner, task.Repository.Repository, err)
-		return
+	claim := func() error {
+		_, err := repoClient.Client.ClaimTask(task.Number, hi.config.AgentID)
+		return err
+	}
+	if err := traceGitHub(ctx, "claim_task", task, claim); err != nil {
+		hi.releaseHiveClaim(ctx, task) // Hive mustn't keep a lease on a task we never took
+		return fmt.Errorf("failed to claim task in %s/%s: %w",
+			task.Repository.Owner, task.Repository.Repository, err)
 	}
 	
+	task.BranchName = repoClient.Client.TaskBranchName(task.Number, hi.config.AgentID)
+	task.GitHubToken = repoClient.Client.config.AccessToken
+	
 	fmt.Printf("✋ Claimed task #%d from %s/%s: %s\n", 
 		task.Number, task.Repository.Owner, task.Repository.Repository, task.Title)
 	
 	// Log the claim
 	hi.hlog.Append(logging.TaskClaimed, map[string]interface{}{
-		"task_id":    task.Number,
-		"repository": fmt.Sprintf("%s/%s", task.Repository.Owner, task.Repository.Repository),
-		"title":      task.Title,
+		"task_id":     task.Number,
+		"repository":  task.RepositoryName(),
+		"title":       task.Title,
+		"agent_id":    hi.config.AgentID,
+		"match_score": hi.taskMatchScore(task), // Why this agent took it
+		"task_type":   task.TaskType,
 	})
+	return nil
+}
 
-	// Report claim to Hive
-	if err := hi.hiveClient.ClaimTask(hi.ctx, task.ProjectID, task.Number, hi.config.AgentID); err != nil {
-		fmt.Printf("⚠️ Failed to report task claim to Hive: %v\n", err)
+// startExecution runs a claimed task in the background, ending the task span
+// in ctx once the run finishes
+func (hi *Integration) startExecution(ctx context.Context, task *types.EnhancedTask, repoClient *RepositoryClient) {
+	execute := hi.executeTask
+	if hi.execute != nil {
+		execute = hi.execute
 	}
-	
-	// Start task execution
-	go hi.executeTask(task, repoClient)
+	span := trace.SpanFromContext(ctx)
+	runCtx, done := hi.registerRunning(task)
+	runCtx = trace.ContextWithSpan(runCtx, span)
+	hi.recordActivity()
+	hi.executions.Add(1)
+	go func() {
+		defer hi.executions.Done()
+		defer done()
+		defer span.End()
+		defer hi.recordActivity() // A long task shouldn't count as idle time
+		execute(runCtx, task, repoClient)
+	}()
 }
 
-// executeTask executes a claimed task with reasoning and coordination
-func (hi *Integration) executeTask(task *types.EnhancedTask, repoClient *RepositoryClient) {
+// executeTask executes a claimed task with reasoning and coordination. Cancelling
+// ctx stops the run, destroys its sandbox and releases the claim.
+func (hi *Integration) executeTask(ctx context.Context, task *types.EnhancedTask, repoClient *RepositoryClient) {
 	// Define the dynamic topic for this task
-	taskTopic := fmt.Sprintf("bzzz/meta/issue/%d", task.Number)
+	taskTopic := pubsub.TaskTopic(task.Number)
 	hi.pubsub.JoinDynamicTopic(taskTopic)
 	defer hi.pubsub.LeaveDynamicTopic(taskTopic)
 
 	fmt.Printf("🚀 Starting execution of task #%d in sandbox...\n", task.Number)
 
+	// Keep our claim alive for as long as we're working on the task
+	stopRenewing := hi.startLeaseRenewal(task)
+	defer stopRenewing()
+
+	// Stop if humans close or reassign the issue while we work on it
+	supervisor.Go(ctx, "issue watch", func() { hi.watchIssue(ctx, task, repoClient) })
+
+	// Hand oversized tasks to the mesh as smaller sub-tasks instead of attempting them whole
+	if hi.splitOversizedTask(ctx, task, repoClient, nil) {
+		return
+	}
+	task.SplitWhenOversized = hi.splittable(task) // Or once it turns out to be
+
+	// Tick off checklist items on the issue and in Hive as the agent finishes them
+	task.OnChecklistItemDone = func(item types.ChecklistItem) {
+		hi.reportChecklistProgress(task, repoClient, item)
+	}
+
+	// Let peers following the task see how the agent means to go about it
+	if hi.agentConfig != nil && hi.agentConfig.SharePlans {
+		task.OnPlanned = func(plan []string) {
+			hi.pubsub.PublishToDynamicTopic(taskTopic, pubsub.TaskProgress, map[string]interface{}{
+				"task_id": task.Number,
+				"status":  "planned",
+				"plan":    plan,
+			})
+		}
+	}
+
 	// The executor now handles the entire iterative process.
-	result, err := executor.ExecuteTask(hi.ctx, task, hi.hlog, hi.agentConfig)
+	runExecutor := executor.ExecuteTask
+	if hi.runExecutor != nil {
+		runExecutor = hi.runExecutor
+	}
+	result, err := runExecutor(ctx, task, hi.hlog, hi.agentConfig, hi.budgets)
+	if err != nil && ctx.Err() != nil {
+		hi.releaseCancelledTask(task, repoClient, hi.cancelReason(task))
+		return
+	}
+	var oversizedErr *executor.DiffLimitExceededError
+	if errors.As(err, &oversizedErr) {
+		fmt.Printf("📏 Task #%d outgrew the diff limits before it was finished, splitting it\n", task.Number)
+		if hi.splitOversizedTask(ctx, task, repoClient, &oversizedErr.Diff) {
+			return
+		}
+	}
 	if err != nil {
 		fmt.Printf("❌ Failed to execute task #%d: %v\n", task.Number, err)
-		hi.hlog.Append(logging.TaskFailed, map[string]interface{}{"task_id": task.Number, "reason": "task execution failed in sandbox"})
+		hi.hlog.Append(logging.TaskFailed, map[string]interface{}{
+			"task_id":    task.Number,
+			"repository": task.RepositoryName(),
+			"reason":     "task execution failed in sandbox",
+		})
+
+		reasoning.RecordOutcome(task.Model, task.TaskType, reasoning.OutcomeFailed)
+		hi.rollbackClaim(ctx, task, repoClient, err.Error())
+		escalated := hi.handleTaskFailure(task, repoClient, err.Error())
+
+		// Leaked secrets, work that never verifies, runaway reasoning, revoked
+		// repository access and commands the model isn't sure of need a human to
+		// review, unless the failure count already escalated the task
+		var secretsErr *executor.SecretsDetectedError
+		var verifyErr *executor.VerificationFailedError
+		var budgetErr *budget.ExceededError
+		var cloneAuthErr *executor.CloneAuthError
+		var confidenceErr *executor.LowConfidenceError
+		if !escalated && (errors.As(err, &secretsErr) || errors.As(err, &verifyErr) || errors.As(err, &budgetErr) || errors.As(err, &cloneAuthErr) || errors.As(err, &confidenceErr)) {
+			hi.triggerHumanEscalation(task, &Conversation{TaskID: task.Number, TaskTitle: task.Title}, err.Error())
+		}
+		hi.forgetHelper(task.Number) // The task failing isn't the helper refusing
 		return
 	}
+	hi.failures.recordSuccess(taskKey(task.ProjectID, task.Number))
+	hi.recordHelperOutcome(task.Number, reputation.HelpAccepted)
 
 	// Ensure sandbox cleanup happens regardless of PR creation success/failure
 	defer result.Sandbox.DestroySandbox()
 
+	// Don't open a PR for work an operator stopped
+	if ctx.Err() != nil {
+		hi.releaseCancelledTask(task, repoClient, hi.cancelReason(task))
+		return
+	}
+
 	// Create a pull request
-	pr, err := repoClient.Client.CreatePullRequest(task.Number, result.BranchName, hi.config.AgentID)
+	var pr *github.PullRequest
+	err = traceGitHub(ctx, "create_pull_request", task, func() (err error) {
+		pr, err = hi.openPullRequest(task, repoClient, result.BranchName, result.Diff, result.Merge)
+		return err
+	})
 	if err != nil {
 		fmt.Printf("❌ Failed to create pull request for task #%d: %v\n", task.Number, err)
 		fmt.Printf("📝 Note: Branch '%s' has been pushed to repository and work is preserved\n", result.BranchName)
 		
 		// Escalate PR creation failure to humans via N8N webhook
-		escalationReason := fmt.Sprintf("Failed to create pull request: %v. Task execution completed successfully and work is preserved in branch '%s', but PR creation failed.", err, result.BranchName)
-		hi.requestAssistance(task, escalationReason, fmt.Sprintf("bzzz/meta/issue/%d", task.Number))
+		escalationReason := newPRFailureEscalation(task, result.BranchName, err)
+		hi.requestAssistance(task, escalationReason, pubsub.TaskTopic(task.Number))
 		
 		hi.hlog.Append(logging.TaskFailed, map[string]interface{}{
 			"task_id": task.Number, 
+			"repository": task.RepositoryName(),
 			"reason": "failed to create pull request",
 			"branch_name": result.BranchName,
+			"error_class": escalationReason.ErrorClass,
 			"work_preserved": true,
 			"escalated": true,
 		})
@@ -347,34 +910,60 @@ func (hi *Integration) executeTask(task *types.EnhancedTask, repoClient *Reposit
 
 	fmt.Printf("✅ Successfully created pull request for task #%d: %s\n", task.Number, pr.GetHTMLURL())
 	hi.hlog.Append(logging.TaskCompleted, map[string]interface{}{
-		"task_id":   task.Number,
-		"pr_url":    pr.GetHTMLURL(),
-		"pr_number": pr.GetNumber(),
+		"task_id":    task.Number,
+		"repository": task.RepositoryName(),
+		"pr_url":     pr.GetHTMLURL(),
+		"pr_number":  pr.GetNumber(),
 	})
+	hi.postTranscript(task, repoClient, pr.GetNumber(), result.Transcript)
 
 	// Report completion to Hive
-	if err := hi.hiveClient.UpdateTaskStatus(hi.ctx, task.ProjectID, task.Number, "completed", map[string]interface{}{
+	if err := hi.hiveClient.UpdateTaskStatus(hi.traced(ctx), task.ProjectID, task.Number, "completed", map[string]interface{}{
 		"pull_request_url": pr.GetHTMLURL(),
+		"draft":            pr.GetDraft(),
+		"diff":             result.Diff,
+		"reasoning_usage":  result.Usage,
 	}); err != nil {
 		fmt.Printf("⚠️ Failed to report task completion to Hive: %v\n", err)
 	}
 }
 
-// requestAssistance publishes a help request to the task-specific topic.
-func (hi *Integration) requestAssistance(task *types.EnhancedTask, reason, topic string) {
-	fmt.Printf("🆘 Agent %s is requesting assistance for task #%d: %s\n", hi.config.AgentID, task.Number, reason)
+// requestAssistance publishes a help request to the task-specific topic and
+// forwards the structured reason to the escalation webhook. Without enough peers
+// to answer, the task is escalated to humans instead.
+func (hi *Integration) requestAssistance(task *types.EnhancedTask, reason EscalationReason, topic string) {
+	fmt.Printf("🆘 Agent %s is requesting assistance for task #%d: %s\n", hi.config.AgentID, task.Number, reason.Message)
 	hi.hlog.Append(logging.TaskHelpRequested, map[string]interface{}{
-		"task_id": task.Number,
-		"reason":  reason,
+		"task_id":    task.Number,
+		"repository": task.RepositoryName(),
+		"reason":     reason.Message,
+		"kind":       string(reason.Kind),
 	})
 
+	// Nobody would answer a help request, so go straight to humans
+	if peers, needed := len(hi.pubsub.AntennaePeers()), hi.config.MinCoordinationPeers; peers < needed {
+		fmt.Printf("🧍 Only %d of %d peers needed to coordinate, escalating task #%d to humans\n", peers, needed, task.Number)
+		reason.EscalationID = hi.triggerHumanEscalation(task, &Conversation{TaskID: task.Number, TaskTitle: task.Title}, reason.Message)
+		if err := hi.sendEscalationWebhook(reason); err != nil {
+			fmt.Printf("⚠️ Failed to notify escalation webhook for task #%d: %v\n", task.Number, err)
+		}
+		return
+	}
+
+	// A human may answer the webhook before any peer does
+	reason.EscalationID = hi.recordEscalation(task, reason.Message)
 	helpRequest := map[string]interface{}{
 		"issue_id":   task.Number,
-		"repository": fmt.Sprintf("%s/%s", task.Repository.Owner, task.Repository.Repository),
-		"reason":     reason,
+		"repository": task.RepositoryName(),
+		"reason":     reason.Message,
+		"escalation": reason,
 	}
 
 	hi.pubsub.PublishToDynamicTopic(topic, pubsub.TaskHelpRequest, helpRequest)
+
+	if err := hi.sendEscalationWebhook(reason); err != nil {
+		fmt.Printf("⚠️ Failed to notify escalation webhook for task #%d: %v\n", task.Number, err)
+	}
 }
 
 // handleMetaDiscussion handles all incoming messages from dynamic and static topics.
@@ -389,46 +978,171 @@ func (hi *Integration) handleMetaDiscussion(msg pubsub.Message, from peer.ID) {
 	}
 }
 
+// handleBzzzMessage dispatches Bzzz coordination messages relevant to the integration
+func (hi *Integration) handleBzzzMessage(msg pubsub.Message, from peer.ID) {
+	switch msg.Type {
+	case pubsub.ClaimIntent:
+		hi.handleClaimIntent(msg, from)
+	case pubsub.TaskCancel:
+		hi.handleTaskCancel(msg, from)
+	case pubsub.AgentPause, pubsub.AgentResume:
+		hi.handleAgentPause(msg, from)
+	case pubsub.TaskAnnouncement:
+		hi.handleTaskAnnouncement(msg, from)
+	}
+}
+
 // handleHelpRequest is called when another agent requests assistance.
 func (hi *Integration) handleHelpRequest(msg pubsub.Message, from peer.ID) {
-	issueID, _ := msg.Data["issue_id"].(float64)
+	issueID := float64(messageIssueID(msg))
 	reason, _ := msg.Data["reason"].(string)
+	repository, _ := msg.Data["repository"].(string)
 	fmt.Printf("🙋 Received help request for task #%d from %s: %s\n", int(issueID), from.ShortString(), reason)
 
-	// Simple logic: if we are not busy, we can help.
+	// A paused agent isn't taking on more work, so it turns the request down
 	// TODO: A more advanced agent would check its capabilities against the reason.
-	canHelp := true // Placeholder for more complex logic
+	canHelp := !hi.Paused()
 
 	if canHelp {
 		fmt.Printf("✅ Agent %s can help with task #%d\n", hi.config.AgentID, int(issueID))
 		hi.hlog.Append(logging.TaskHelpOffered, map[string]interface{}{
 			"task_id":      int(issueID),
+			"repository":   repository,
 			"requester_id": from.ShortString(),
 		})
+	} else {
+		fmt.Printf("🙅 Agent %s is paused, turning down help with task #%d\n", hi.config.AgentID, int(issueID))
+	}
 
-		response := map[string]interface{}{
-			"issue_id":     issueID,
-			"can_help":     true,
-			"capabilities": hi.config.Capabilities,
-		}
-		taskTopic := fmt.Sprintf("bzzz/meta/issue/%d", int(issueID))
-		hi.pubsub.PublishToDynamicTopic(taskTopic, pubsub.TaskHelpResponse, response)
+	response := map[string]interface{}{
+		"issue_id":     issueID,
+		"repository":   repository,
+		"can_help":     canHelp,
+		"capabilities": hi.capabilities(),
+	}
+	// Answer on the topic the request came in on, which we're already joined to
+	taskTopic := msg.Topic
+	if _, isTaskTopic := pubsub.TaskNumberFromTopic(taskTopic); !isTaskTopic {
+		taskTopic = pubsub.TaskTopic(int(issueID))
 	}
+	hi.pubsub.PublishToDynamicTopic(taskTopic, pubsub.TaskHelpResponse, response)
 }
 
 // handleHelpResponse is called when an agent receives an offer for help.
 func (hi *Integration) handleHelpResponse(msg pubsub.Message, from peer.ID) {
-	issueID, _ := msg.Data["issue_id"].(float64)
+	issueID := messageIssueID(msg)
 	canHelp, _ := msg.Data["can_help"].(bool)
+	repository, _ := msg.Data["repository"].(string)
 
-	if canHelp {
-		fmt.Printf("🤝 Received help offer for task #%d from %s\n", int(issueID), from.ShortString())
-		hi.hlog.Append(logging.TaskHelpReceived, map[string]interface{}{
-			"task_id":   int(issueID),
-			"helper_id": from.ShortString(),
+	if !canHelp {
+		hi.helpRefused(issueID, from.String())
+		return
+	}
+
+	fmt.Printf("🤝 Received help offer for task #%d from %s\n", issueID, from.ShortString())
+	hi.hlog.Append(logging.TaskHelpReceived, map[string]interface{}{
+		"task_id":    issueID,
+		"repository": repository,
+		"helper_id":  from.ShortString(),
+	})
+
+	// Collect offers for a short window, then take the most reputable helper
+	if hi.addHelpOffer(issueID, from.String()) {
+		time.AfterFunc(hi.claimIntentWindow(), func() {
+			hi.acceptHelpOffer(issueID)
 		})
-		// In a full implementation, the agent would now delegate a sub-task
-		// or use the helper's capabilities. For now, we just log it.
+	}
+}
+
+// messageIssueID returns the issue a meta-discussion message is about, taken from
+// its payload or else from the task topic it arrived on
+func messageIssueID(msg pubsub.Message) int {
+	if issueID, ok := msg.Data["issue_id"].(float64); ok {
+		return int(issueID)
+	}
+	issueID, _ := pubsub.TaskNumberFromTopic(msg.Topic)
+	return issueID
+}
+
+// addHelpOffer records a help offer and reports whether it is the first for the task
+func (hi *Integration) addHelpOffer(taskID int, peerID string) bool {
+	hi.helpLock.Lock()
+	defer hi.helpLock.Unlock()
+
+	if _, accepted := hi.helpers[taskID]; accepted {
+		return false
+	}
+	for _, existing := range hi.helpOffers[taskID] {
+		if existing == peerID {
+			return false
+		}
+	}
+	hi.helpOffers[taskID] = append(hi.helpOffers[taskID], peerID)
+	return len(hi.helpOffers[taskID]) == 1
+}
+
+// acceptHelpOffer picks the most reputable peer among the offers received for a
+// task, passing over peers whose last broadcast says they're busy or lack the
+// capabilities the task requires unless nobody else offered
+func (hi *Integration) acceptHelpOffer(taskID int) string {
+	required := hi.runningRequirements(taskID)
+
+	hi.helpLock.Lock()
+	defer hi.helpLock.Unlock()
+
+	offers := hi.helpOffers[taskID]
+	if len(offers) == 0 {
+		return ""
+	}
+	delete(hi.helpOffers, taskID)
+
+	ranked := hi.reputation.Rank(offers)
+	helper := ranked[0]
+	for _, candidate := range ranked {
+		if hi.helperFits(candidate, required) {
+			helper = candidate
+			break
+		}
+	}
+	hi.helpers[taskID] = helper
+	fmt.Printf("🤝 Accepted help for task #%d from %s (reputation %.2f, %d offer(s))\n",
+		taskID, helper, hi.reputation.Score(helper), len(offers))
+	return helper
+}
+
+// recordHelperOutcome credits or debits the peer whose help we accepted for a task
+func (hi *Integration) recordHelperOutcome(taskID int, outcome reputation.Outcome) {
+	if helper := hi.forgetHelper(taskID); helper != "" {
+		hi.reputation.Record(helper, outcome)
+	}
+}
+
+// forgetHelper drops the peer whose help we accepted for a task, returning it
+func (hi *Integration) forgetHelper(taskID int) string {
+	hi.helpLock.Lock()
+	defer hi.helpLock.Unlock()
+	helper := hi.helpers[taskID]
+	delete(hi.helpers, taskID)
+	return helper
+}
+
+// helpRefused drops a peer that turns down a task it had offered to help with,
+// costing it reputation. A peer that never offered loses nothing by saying no.
+func (hi *Integration) helpRefused(taskID int, peerID string) {
+	hi.helpLock.Lock()
+	offered := hi.helpers[taskID] == peerID
+	if offered {
+		delete(hi.helpers, taskID)
+	}
+	if i := slices.Index(hi.helpOffers[taskID], peerID); i >= 0 {
+		hi.helpOffers[taskID] = slices.Delete(hi.helpOffers[taskID], i, i+1)
+		offered = true
+	}
+	hi.helpLock.Unlock()
+
+	if offered {
+		fmt.Printf("🙅 %s withdrew its offer of help with task #%d\n", peerID, taskID)
+		hi.reputation.Record(peerID, reputation.HelpRejected)
 	}
 }
 
@@ -452,21 +1166,27 @@ func (hi *Integration) shouldEscalate(response string, history []string) bool {
 	return false
 }
 
-// triggerHumanEscalation sends escalation to Hive and N8N
-func (hi *Integration) triggerHumanEscalation(projectID int, convo *Conversation, reason string) {
+// triggerHumanEscalation sends escalation to Hive and N8N. The returned
+// escalation ID correlates a human's reply with the stalled task.
+func (hi *Integration) triggerHumanEscalation(task *types.EnhancedTask, convo *Conversation, reason string) string {
+	escalationID := hi.recordEscalation(task, reason)
 	hi.hlog.Append(logging.Escalation, map[string]interface{}{
-		"task_id": convo.TaskID,
-		"reason":  reason,
+		"task_id":       convo.TaskID,
+		"repository":    task.RepositoryName(),
+		"reason":        reason,
+		"escalation_id": escalationID,
 	})
 
 	// Report to Hive system
-	if err := hi.hiveClient.UpdateTaskStatus(hi.ctx, projectID, convo.TaskID, "escalated", map[string]interface{}{
+	if err := hi.hiveClient.UpdateTaskStatus(hi.ctx, task.ProjectID, convo.TaskID, "escalated", map[string]interface{}{
 		"escalation_reason": reason,
 		"conversation_length": len(convo.History),
 		"escalated_by": hi.config.AgentID,
+		"escalation_id": escalationID,
 	}); err != nil {
 		fmt.Printf("⚠️ Failed to report escalation to Hive: %v\n", err)
 	}
 	
-	fmt.Printf("✅ Task #%d in project %d escalated for human intervention\n", convo.TaskID, projectID)
+	fmt.Printf("✅ Task #%d in project %d escalated for human intervention (%s)\n", convo.TaskID, task.ProjectID, escalationID)
+	return escalationID
 }
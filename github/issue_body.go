@@ -0,0 +1,219 @@
+package github
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// issueMetadata is the structured task information found in an issue body
+type issueMetadata struct {
+	TaskType     string   `yaml:"task_type"`
+	Type         string   `yaml:"type"` // Alias for task_type
+	Priority     int      `yaml:"priority"`
+	Requirements []string `yaml:"requirements"`
+	Deliverables []string `yaml:"deliverables"`
+
+	// Variables the task wants in its sandbox; only the agent's allowlisted names are passed
+	Env map[string]string `yaml:"env"`
+
+	// Capabilities an agent must have to claim the task, e.g. "bzzz-requires: go,testing"
+	RequiredCapabilities capabilityList `yaml:"bzzz-requires"`
+}
+
+// capabilityList is a list of capabilities, written in YAML either as a list or
+// as a comma-separated string
+type capabilityList []string
+
+// UnmarshalYAML accepts both "go,testing" and [go, testing]
+func (l *capabilityList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		*l = mergeCapabilities(nil, list)
+		return nil
+	}
+	var text string
+	if err := unmarshal(&text); err != nil {
+		return err
+	}
+	*l = splitCapabilities(text)
+	return nil
+}
+
+// requiredCapabilitiesLabel parses a "bzzz-requires: go,testing" label
+func requiredCapabilitiesLabel(label string) ([]string, bool) {
+	name, value, found := strings.Cut(label, ":")
+	if !found || !strings.EqualFold(strings.TrimSpace(name), "bzzz-requires") {
+		return nil, false
+	}
+	return splitCapabilities(value), true
+}
+
+// splitCapabilities parses a comma-separated capability list
+func splitCapabilities(text string) []string {
+	return mergeCapabilities(nil, strings.Split(text, ","))
+}
+
+// mergeCapabilities adds capabilities to a list, normalised to lower case and
+// without blanks or duplicates
+func mergeCapabilities(list, capabilities []string) []string {
+	for _, capability := range capabilities {
+		capability = strings.ToLower(strings.Trim(strings.TrimSpace(capability), "`"))
+		if capability != "" && !slices.Contains(list, capability) {
+			list = append(list, capability)
+		}
+	}
+	return list
+}
+
+var (
+	// headingPattern matches markdown headings such as "## Requirements"
+	headingPattern = regexp.MustCompile(`^#{1,6}\s+(.+?)\s*#*$`)
+
+	// boldFieldPattern matches the "**Task Type:** code" lines formatTaskBody writes
+	boldFieldPattern = regexp.MustCompile(`^\*\*([^*]+?):?\*\*:?\s*(.*)$`)
+
+	// plainFieldPattern matches "Priority: 3" style lines for the fields we know
+	plainFieldPattern = regexp.MustCompile(`(?i)^(task type|type|priority|requirements|deliverables|acceptance criteria|bzzz-requires|required capabilities)\s*:\s*(.*)$`)
+
+	// listItemPattern matches bullet, numbered and checkbox list items
+	listItemPattern = regexp.MustCompile(`^(?:[-*+]|\d+[.)])\s+(?:\[[ xX]\]\s+)?(.+)$`)
+
+	numberPattern = regexp.MustCompile(`\d+`)
+)
+
+// parseIssueBody extracts task metadata from an issue body. It understands YAML
+// frontmatter, the format formatTaskBody writes and "## Requirements" style
+// sections; anything missing is left empty. Frontmatter wins over the body.
+func parseIssueBody(body string) issueMetadata {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+
+	var meta issueMetadata
+	if frontmatter, rest, ok := splitFrontmatter(body); ok {
+		yaml.Unmarshal([]byte(frontmatter), &meta) // Malformed frontmatter is ignored like any other text
+		body = rest
+	}
+	if meta.TaskType == "" {
+		meta.TaskType = meta.Type
+	}
+	meta.Type = ""
+
+	var found issueMetadata
+	section := ""
+	inFence := false
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		if name, value, isHeader := sectionHeader(trimmed); isHeader {
+			section = name
+			if value != "" {
+				section = found.set(section, value)
+			}
+			continue
+		}
+		if trimmed == "---" {
+			section = ""
+			continue
+		}
+		if trimmed == "" || section == "" {
+			continue
+		}
+		if match := listItemPattern.FindStringSubmatch(trimmed); match != nil {
+			section = found.set(section, match[1])
+		} else if section == "type" || section == "priority" {
+			section = found.set(section, trimmed)
+		}
+	}
+
+	if meta.TaskType == "" {
+		meta.TaskType = found.TaskType
+	}
+	if meta.Priority == 0 {
+		meta.Priority = found.Priority
+	}
+	if len(meta.Requirements) == 0 {
+		meta.Requirements = found.Requirements
+	}
+	if len(meta.Deliverables) == 0 {
+		meta.Deliverables = found.Deliverables
+	}
+	if len(meta.RequiredCapabilities) == 0 {
+		meta.RequiredCapabilities = found.RequiredCapabilities
+	}
+	return meta
+}
+
+// set records a value for a section and returns the section that continues
+// afterwards; single-value fields end as soon as they are set
+func (m *issueMetadata) set(section, value string) string {
+	value = strings.Trim(strings.TrimSpace(value), "`")
+	switch section {
+	case "type":
+		m.TaskType = strings.ToLower(value)
+		return ""
+	case "priority":
+		if number := numberPattern.FindString(value); number != "" {
+			m.Priority, _ = strconv.Atoi(number)
+		}
+		return ""
+	case "requirements":
+		m.Requirements = append(m.Requirements, value)
+	case "deliverables":
+		m.Deliverables = append(m.Deliverables, value)
+	case "requires":
+		m.RequiredCapabilities = mergeCapabilities(m.RequiredCapabilities, strings.Split(value, ","))
+	}
+	return section
+}
+
+// sectionHeader recognises a line that starts a section, returning the field it
+// introduces ("" for sections we don't parse) and any value on the same line
+func sectionHeader(line string) (name, value string, ok bool) {
+	var title string
+	if match := headingPattern.FindStringSubmatch(line); match != nil {
+		title = match[1]
+	} else if match := boldFieldPattern.FindStringSubmatch(line); match != nil {
+		title, value = match[1], match[2]
+	} else if match := plainFieldPattern.FindStringSubmatch(line); match != nil {
+		title, value = match[1], match[2]
+	} else {
+		return "", "", false
+	}
+
+	switch strings.ToLower(strings.TrimSuffix(strings.TrimSpace(title), ":")) {
+	case "task type", "type":
+		name = "type"
+	case "priority":
+		name = "priority"
+	case "requirements":
+		name = "requirements"
+	case "deliverables", "acceptance criteria":
+		name = "deliverables"
+	case "bzzz-requires", "required capabilities":
+		name = "requires"
+	}
+	return name, strings.TrimSpace(value), true
+}
+
+// splitFrontmatter separates a leading "---" delimited YAML block from the rest of the body
+func splitFrontmatter(body string) (frontmatter, rest string, ok bool) {
+	if !strings.HasPrefix(body, "---\n") {
+		return "", body, false
+	}
+	end := strings.Index(body[4:], "\n---")
+	if end < 0 {
+		return "", body, false
+	}
+	rest = strings.TrimPrefix(body[4+end+4:], "\n")
+	return body[4 : 4+end], rest, true
+}
@@ -0,0 +1,80 @@
+package github
+
+import (
+	"reflect"
+	"testing"
+
+	gh "github.com/google/go-github/v57/github"
+)
+
+func TestParseIssueBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want issueMetadata
+	}{
+		{
+			name: "bzzz format",
+			body: (&Client{}).formatTaskBody(&Task{
+				TaskType:     "code-generation",
+				Priority:     3,
+				Description:  "Add retries to the uploader.",
+				Requirements: []string{"Retry 5xx responses", "Back off exponentially"},
+				Deliverables: []string{"uploader.go changes"},
+			}),
+			want: issueMetadata{
+				TaskType:     "code-generation",
+				Priority:     3,
+				Requirements: []string{"Retry 5xx responses", "Back off exponentially"},
+				Deliverables: []string{"uploader.go changes"},
+			},
+		},
+		{
+			name: "yaml frontmatter",
+			body: "---\ntype: testing\npriority: 2\nrequirements:\n  - Cover the parser\n---\nThe parser has no tests.\n\n## Deliverables\n- parser_test.go\n",
+			want: issueMetadata{
+				TaskType:     "testing",
+				Priority:     2,
+				Requirements: []string{"Cover the parser"},
+				Deliverables: []string{"parser_test.go"},
+			},
+		},
+		{
+			name: "markdown sections",
+			body: "Login is slow.\n\n## Task Type\nPerformance\n\n### Requirements\n1. Profile the handler\n2. Cache sessions\n\n## Acceptance Criteria\n- [ ] p95 under 200ms\n- [x] No new dependencies\n\n## Notes\n- not a requirement\n",
+			want: issueMetadata{
+				TaskType:     "performance",
+				Requirements: []string{"Profile the handler", "Cache sessions"},
+				Deliverables: []string{"p95 under 200ms", "No new dependencies"},
+			},
+		},
+		{
+			name: "required capabilities",
+			body: "---\nbzzz-requires: Go, testing\n---\nThe parser has no tests.\n",
+			want: issueMetadata{RequiredCapabilities: capabilityList{"go", "testing"}},
+		},
+		{
+			name: "free text",
+			body: "Something is broken, please take a look.\n\n```\nPriority: 9\n```\n",
+			want: issueMetadata{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseIssueBody(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseIssueBody() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIssueToTaskFallsBackToLabels(t *testing.T) {
+	task := (&Client{}).issueToTask(&gh.Issue{
+		Body:   gh.String("Fix the flaky test."),
+		Labels: []*gh.Label{{Name: gh.String("bzzz-task")}, {Name: gh.String("type-testing")}, {Name: gh.String("priority-4")}},
+	})
+	if task.TaskType != "testing" || task.Priority != 4 {
+		t.Errorf("expected type and priority from labels, got %q and %d", task.TaskType, task.Priority)
+	}
+}
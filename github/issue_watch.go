@@ -0,0 +1,75 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+)
+
+// issueCheckInterval is how often a running task's issue is checked for having
+// been closed or reassigned out from under the agent
+var issueCheckInterval = time.Minute
+
+// watchIssue stops a running task once its issue is closed or assigned to
+// someone else, rather than letting the agent finish work nobody wants
+func (hi *Integration) watchIssue(ctx context.Context, task *types.EnhancedTask, repoClient *RepositoryClient) {
+	ticker := time.NewTicker(issueCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if reason := hi.issueNoLongerOurs(task, repoClient); reason != "" {
+				fmt.Printf("📪 Task #%d %s, abandoning it\n", task.Number, reason)
+				hi.abandonTask(task, reason)
+				return
+			}
+		}
+	}
+}
+
+// issueNoLongerOurs returns why the agent should stop working on a task's
+// issue, or "" if it's still open and assigned to us. Failing to check isn't a
+// reason to stop.
+func (hi *Integration) issueNoLongerOurs(task *types.EnhancedTask, repoClient *RepositoryClient) string {
+	current, err := repoClient.Client.GetTask(task.Number)
+	if err != nil {
+		fmt.Printf("⚠️ Failed to check whether task #%d is still open: %v\n", task.Number, err)
+		return ""
+	}
+	if current.State == "closed" {
+		return "issue was closed"
+	}
+	if hi.config.Assignee != "" && current.Assignee != hi.config.Assignee {
+		if current.Assignee == "" {
+			return "issue was unassigned"
+		}
+		return fmt.Sprintf("issue was reassigned to %s", current.Assignee)
+	}
+	return ""
+}
+
+// abandonTask cancels a running task whose issue humans have taken back. The
+// issue is left as they set it, so its claim isn't released or the task
+// offered to the mesh again.
+func (hi *Integration) abandonTask(task *types.EnhancedTask, reason string) {
+	hi.runningLock.Lock()
+	if running, exists := hi.running[taskKey(task.ProjectID, task.Number)]; exists {
+		running.keepIssue = true
+	}
+	hi.runningLock.Unlock()
+
+	hi.CancelTask(task.ProjectID, task.Number, reason)
+}
+
+// keepsIssue reports whether a cancelled task's issue should be left as it is
+func (hi *Integration) keepsIssue(task *types.EnhancedTask) bool {
+	hi.runningLock.Lock()
+	defer hi.runningLock.Unlock()
+	running, exists := hi.running[taskKey(task.ProjectID, task.Number)]
+	return exists && running.keepIssue
+}
@@ -0,0 +1,115 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/executor"
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/budget"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	gh "github.com/google/go-github/v57/github"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestClosedIssueAbortsExecution(t *testing.T) {
+	issueCheckInterval = 10 * time.Millisecond
+	defer func() { issueCheckInterval = time.Minute }()
+
+	var closed atomic.Bool
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, body))
+		mu.Unlock()
+
+		if r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/issues/42" {
+			state := "open"
+			if closed.Load() {
+				state = "closed"
+			}
+			fmt.Fprintf(w, `{"number":42,"state":%q,"assignee":{"login":"bzzz-bot"},"labels":[{"name":"in-progress"}]}`, state)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client: &Client{
+			client: ghClient,
+			ctx:    context.Background(),
+			config: &Config{Owner: "acme", Repository: "widgets", InProgressLabel: "in-progress"},
+		},
+		Repository: hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets"},
+	}
+
+	started := make(chan struct{})
+	sandboxDestroyed := make(chan struct{})
+	hi := &Integration{
+		ctx:        context.Background(),
+		pubsub:     newTestPubSub(t),
+		config:     &IntegrationConfig{AgentID: "agent-a", Assignee: "bzzz-bot"},
+		hlog:       logging.NewHypercoreLog(peer.ID("test")),
+		hiveClient: hive.NewHiveClient(server.URL, ""),
+		runExecutor: func(ctx context.Context, task *types.EnhancedTask, hlog *logging.HypercoreLog, agentConfig *config.AgentConfig, budgets *budget.Tracker) (*executor.ExecuteTaskResult, error) {
+			close(started)
+			<-ctx.Done()
+			close(sandboxDestroyed) // ExecuteTask destroys its sandbox on any error
+			return nil, fmt.Errorf("command cancelled: %w", ctx.Err())
+		},
+	}
+	task := &types.EnhancedTask{Number: 42, ProjectID: 7, Title: "Long task", Repository: repoClient.Repository}
+
+	hi.startExecution(context.Background(), task, repoClient)
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("task did not start")
+	}
+
+	// A human closes the issue while the agent is still working
+	time.Sleep(50 * time.Millisecond)
+	if len(hi.RunningTasks()) != 1 {
+		t.Fatal("task was aborted while its issue was still open")
+	}
+	closed.Store(true)
+
+	select {
+	case <-sandboxDestroyed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("closing the issue did not abort the execution")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(hi.RunningTasks()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("aborted task is still registered as running")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	joined := strings.Join(requests, "\n")
+	mu.Unlock()
+	if !strings.Contains(joined, `"status":"cancelled"`) || !strings.Contains(joined, "issue was closed") {
+		t.Errorf("expected the abort and its reason to be reported to Hive:\n%s", joined)
+	}
+	if strings.Contains(joined, "DELETE /repos/acme/widgets/issues/42/assignees") {
+		t.Errorf("closed issue should be left as it is, not released:\n%s", joined)
+	}
+}
@@ -0,0 +1,37 @@
+package github
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/anthonyrawlins/bzzz/reasoning"
+	"github.com/google/go-github/v57/github"
+)
+
+// outcomeMarkerPattern finds the hidden note in a task PR's description naming
+// the model that did the work
+var outcomeMarkerPattern = regexp.MustCompile(`<!-- bzzz-outcome model=(\S+) task_type=(\S*) -->`)
+
+// outcomeMarker records the model and task type in a PR description, so the PR's
+// fate can be credited to the model even after the agent restarts
+func outcomeMarker(model, taskType string) string {
+	return fmt.Sprintf("<!-- bzzz-outcome model=%s task_type=%s -->", model, taskType)
+}
+
+// recordPullRequestOutcome credits a closed task PR to the model that wrote it:
+// merged counts for the model, closed unmerged against it
+func recordPullRequestOutcome(pr *github.PullRequest) bool {
+	match := outcomeMarkerPattern.FindStringSubmatch(pr.GetBody())
+	if match == nil {
+		return false
+	}
+	model, taskType := match[1], match[2]
+
+	outcome := reasoning.OutcomeRejected
+	if pr.GetMerged() {
+		outcome = reasoning.OutcomeMerged
+	}
+	fmt.Printf("📊 PR #%d by %s was %s\n", pr.GetNumber(), model, outcome)
+	reasoning.RecordOutcome(model, taskType, outcome)
+	return true
+}
@@ -0,0 +1,178 @@
+package github
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Pause stops the agent claiming new tasks. Running tasks carry on and the agent
+// keeps taking part in coordination.
+func (hi *Integration) Pause(reason string) {
+	if !hi.paused.Swap(true) {
+		fmt.Printf("⏸️ Agent %s paused, not claiming new tasks: %s\n", hi.config.AgentID, reason)
+	}
+}
+
+// Resume lets a paused agent claim tasks again
+func (hi *Integration) Resume() {
+	if hi.paused.Swap(false) {
+		fmt.Printf("▶️ Agent %s resumed\n", hi.config.AgentID)
+		hi.pollBackoff.recordWork() // Poll soon rather than after a backed-off interval
+	}
+}
+
+// Paused reports whether the agent has been paused
+func (hi *Integration) Paused() bool {
+	return hi.paused.Load()
+}
+
+// RequestPause pauses or resumes an agent: this one if agentID is ours or empty,
+// otherwise by asking it over the mesh
+func (hi *Integration) RequestPause(agentID string, pause bool, reason string) (local bool, err error) {
+	if agentID == "" || agentID == hi.config.AgentID {
+		if pause {
+			hi.Pause(reason)
+		} else {
+			hi.Resume()
+		}
+		return true, nil
+	}
+
+	msgType := pubsub.AgentResume
+	if pause {
+		msgType = pubsub.AgentPause
+	}
+	err = hi.pubsub.PublishBzzzMessage(msgType, map[string]interface{}{
+		"agent_id":     agentID,
+		"reason":       reason,
+		"requested_by": hi.config.AgentID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to broadcast %s: %w", msgType, err)
+	}
+	return false, nil
+}
+
+// handleAgentPause pauses or resumes this agent when an operator's node asks it to
+func (hi *Integration) handleAgentPause(msg pubsub.Message, from peer.ID) {
+	if agentID, _ := msg.Data["agent_id"].(string); agentID != hi.config.AgentID {
+		return
+	}
+	if !hi.isOperator(from) {
+		fmt.Printf("🚫 Ignoring %s from %s: not an operator peer\n", msg.Type, from.ShortString())
+		return
+	}
+	if msg.Type == pubsub.AgentResume {
+		hi.Resume()
+		return
+	}
+	reason, _ := msg.Data["reason"].(string)
+	if reason == "" {
+		reason = fmt.Sprintf("paused by %s", from.ShortString())
+	}
+	hi.Pause(reason)
+}
+
+// AgentControlHandler serves the agent pause API. Requests must carry token as a
+// bearer token.
+//
+//	GET  /agent             reports whether this agent is paused
+//	POST /agent/pause       stops claiming new tasks; running tasks finish
+//	POST /agent/resume      starts claiming again
+//	GET  /agent/introspect  describes this agent's internal state
+//
+// A POST body of {"agent_id": "..."} targets another agent via the mesh, as does
+// ?peer=<peer ID> for introspection.
+func (hi *Integration) AgentControlHandler(token []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer := []byte(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if subtle.ConstantTimeCompare(bearer, token) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		action := strings.Trim(strings.TrimPrefix(r.URL.Path, "/agent"), "/")
+		switch {
+		case action == "" && r.Method == http.MethodGet:
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"agent_id": hi.config.AgentID,
+				"paused":   hi.Paused(),
+			})
+
+		case (action == "pause" || action == "resume") && r.Method == http.MethodPost:
+			var body struct {
+				AgentID string `json:"agent_id"`
+				Reason  string `json:"reason"`
+			}
+			json.NewDecoder(r.Body).Decode(&body) // Both fields are optional
+			if body.Reason == "" {
+				body.Reason = "paused by operator"
+			}
+
+			local, err := hi.RequestPause(body.AgentID, action == "pause", body.Reason)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			agentID := body.AgentID
+			if agentID == "" {
+				agentID = hi.config.AgentID
+			}
+			writeJSON(w, http.StatusAccepted, map[string]interface{}{
+				"agent_id":  agentID,
+				"paused":    action == "pause",
+				"broadcast": !local,
+			})
+
+		case action == "introspect" && r.Method == http.MethodGet:
+			hi.serveIntrospection(w, r)
+
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	})
+}
+
+// introspectionTimeout is how long to wait for a peer to describe itself
+const introspectionTimeout = 10 * time.Second
+
+// serveIntrospection answers GET /agent/introspect, asking the peer named in the
+// query over the mesh
+func (hi *Integration) serveIntrospection(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.Query().Get("peer")
+	if target == "" {
+		introspection, ok := hi.pubsub.LocalIntrospection()
+		if !ok {
+			http.Error(w, "introspection is not enabled", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, introspection)
+		return
+	}
+
+	id, err := peer.Decode(target)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid peer ID: %v", err), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), introspectionTimeout)
+	defer cancel()
+	introspection, err := hi.pubsub.Introspect(ctx, id)
+	switch {
+	case errors.Is(err, pubsub.ErrIntrospectionDenied):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusGatewayTimeout)
+	default:
+		writeJSON(w, http.StatusOK, introspection)
+	}
+}
@@ -0,0 +1,118 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	gh "github.com/google/go-github/v57/github"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestPausedAgentSkipsClaimingButKeepsCoordinating(t *testing.T) {
+	var mu sync.Mutex
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/issues":
+			mu.Lock()
+			polls++
+			mu.Unlock()
+			fmt.Fprint(w, `[{"number":5,"title":"Rotate the signing keys","labels":[{"name":"bzzz-task"}]}]`)
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/issues/5":
+			fmt.Fprint(w, `{"number":5,"state":"open"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/git/ref/heads/main":
+			fmt.Fprint(w, `{"ref":"refs/heads/main","object":{"sha":"abc123"}}`)
+		case r.URL.Path == "/api/bzzz/projects/7/claims":
+			fmt.Fprint(w, `[]`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client: &Client{
+			client: ghClient,
+			ctx:    context.Background(),
+			config: &Config{Owner: "acme", Repository: "widgets", BaseBranch: "main", TaskLabel: "bzzz-task", InProgressLabel: "in-progress"},
+		},
+		Repository: hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets"},
+	}
+
+	executed := make(chan *types.EnhancedTask, 1)
+	hi := &Integration{
+		ctx:          context.Background(),
+		pubsub:       newTestPubSub(t),
+		config:       &IntegrationConfig{AgentID: "agent-a", Capabilities: []string{"general"}, OperatorPeers: []peer.ID{"operator"}},
+		agentConfig:  &config.AgentConfig{ClaimIntentWindow: 10 * time.Millisecond},
+		hlog:         logging.NewHypercoreLog(peer.ID("test")),
+		hiveClient:   hive.NewHiveClient(server.URL, ""),
+		failures:     newFailureTracker(filepath.Join(t.TempDir(), "failures.json")),
+		repositories: map[int]*RepositoryClient{7: repoClient},
+		claimIntents: make(map[string]map[string]*claimIntent),
+		execute: func(ctx context.Context, task *types.EnhancedTask, repoClient *RepositoryClient) {
+			executed <- task
+		},
+	}
+
+	// Only operators' nodes may pause an agent
+	hi.handleBzzzMessage(pubsub.Message{Type: pubsub.AgentPause, Data: map[string]interface{}{"agent_id": "agent-a"}}, peer.ID("stranger"))
+	if hi.Paused() {
+		t.Fatal("agent paused for a peer that isn't an operator")
+	}
+
+	// Paused over the mesh: no polling or claiming
+	hi.handleBzzzMessage(pubsub.Message{Type: pubsub.AgentPause, Data: map[string]interface{}{"agent_id": "agent-a"}}, peer.ID("operator"))
+	if !hi.Paused() {
+		t.Fatal("agent did not pause")
+	}
+	hi.pollRepositories([]*RepositoryClient{repoClient})
+	mu.Lock()
+	if polls != 0 {
+		t.Errorf("paused agent polled for tasks %d times", polls)
+	}
+	mu.Unlock()
+
+	// Coordination still works: competing claim intents are tracked
+	hi.handleBzzzMessage(pubsub.Message{
+		Type: pubsub.ClaimIntent,
+		Data: map[string]interface{}{"task_key": "7:9", "agent_id": "agent-b", "score": 1.0},
+	}, peer.ID(""))
+	hi.claimIntentLock.Lock()
+	_, tracked := hi.claimIntents["7:9"]["agent-b"]
+	hi.claimIntentLock.Unlock()
+	if !tracked {
+		t.Error("paused agent stopped tracking claim intents")
+	}
+
+	// Messages for other agents are ignored
+	hi.handleBzzzMessage(pubsub.Message{Type: pubsub.AgentResume, Data: map[string]interface{}{"agent_id": "agent-b"}}, peer.ID("operator"))
+	if !hi.Paused() {
+		t.Fatal("agent resumed on a message meant for another agent")
+	}
+
+	hi.Resume()
+	hi.pollRepositories([]*RepositoryClient{repoClient})
+	select {
+	case task := <-executed:
+		if task.Number != 5 {
+			t.Fatalf("executed task #%d, want #5", task.Number)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("resumed agent did not claim the task")
+	}
+}
@@ -0,0 +1,82 @@
+package github
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// pollBackoff doubles the task poll interval after each poll that finds no
+// suitable work, up to a cap, and snaps back as soon as work turns up.
+type pollBackoff struct {
+	base       time.Duration
+	max        time.Duration
+	emptyPolls int
+	mu         sync.Mutex
+
+	reset chan struct{} // Signalled when a backed-off interval snaps back to base
+}
+
+// newPollBackoff creates a backoff starting at base and never exceeding max
+func newPollBackoff(base, max time.Duration) *pollBackoff {
+	if max < base {
+		max = base
+	}
+	return &pollBackoff{
+		base:  base,
+		max:   max,
+		reset: make(chan struct{}, 1),
+	}
+}
+
+// interval returns how long to wait before the next poll
+func (b *pollBackoff) interval() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	interval := b.base
+	for i := 0; i < b.emptyPolls && interval < b.max; i++ {
+		interval *= 2
+	}
+	if interval > b.max {
+		interval = b.max
+	}
+	return interval
+}
+
+// recordEmpty notes a poll that found no suitable task
+func (b *pollBackoff) recordEmpty() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.emptyPolls++
+}
+
+// recordWork notes a poll that found a suitable task and drops back to the base interval
+func (b *pollBackoff) recordWork() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	backedOff := b.emptyPolls > 0
+	b.emptyPolls = 0
+	b.mu.Unlock()
+
+	if backedOff {
+		select {
+		case b.reset <- struct{}{}:
+		default: // A reset is already pending
+		}
+	}
+}
+
+// recordEmptyPoll widens the poll interval after a poll with nothing for us to do
+func (hi *Integration) recordEmptyPoll() {
+	if hi.pollBackoff == nil {
+		return
+	}
+	hi.pollBackoff.recordEmpty()
+	fmt.Printf("💤 No suitable tasks, next poll in %v\n", hi.pollBackoff.interval())
+}
@@ -0,0 +1,158 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pkg/budget"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/reasoning"
+	gh "github.com/google/go-github/v57/github"
+)
+
+func TestPollBackoffGrowsOnEmptyPollsAndResetsOnWork(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`) // No open tasks
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client: &Client{
+			client: ghClient,
+			ctx:    context.Background(),
+			config: &Config{Owner: "acme", Repository: "widgets", TaskLabel: "bzzz-task"},
+		},
+		Repository: hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets"},
+	}
+	hi := &Integration{
+		ctx:         context.Background(),
+		hiveClient:  hive.NewHiveClient(server.URL, ""),
+		config:      &IntegrationConfig{AgentID: "agent-a", Capabilities: []string{"general"}},
+		pollBackoff: newPollBackoff(time.Second, 5*time.Second),
+	}
+
+	want := []time.Duration{2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, expected := range want {
+		hi.pollRepositories([]*RepositoryClient{repoClient})
+		if got := hi.pollBackoff.interval(); got != expected {
+			t.Fatalf("after %d empty polls: expected interval %v, got %v", i+1, expected, got)
+		}
+	}
+
+	hi.pollBackoff.recordWork()
+	if got := hi.pollBackoff.interval(); got != time.Second {
+		t.Fatalf("expected interval to snap back to 1s, got %v", got)
+	}
+	select {
+	case <-hi.pollBackoff.reset:
+	default:
+		t.Fatal("polling loop was not told to reset its timer")
+	}
+
+	// Work at the base interval needs no reset
+	hi.pollBackoff.recordWork()
+	select {
+	case <-hi.pollBackoff.reset:
+		t.Fatal("unexpected reset while not backed off")
+	default:
+	}
+}
+
+func TestPollingWaitsForReasoningToBeConfigured(t *testing.T) {
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/acme/widgets/issues" {
+			atomic.AddInt32(&polls, 1)
+		}
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client: &Client{
+			client: ghClient,
+			ctx:    context.Background(),
+			config: &Config{Owner: "acme", Repository: "widgets", TaskLabel: "bzzz-task"},
+		},
+		Repository: hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets"},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ready := make(chan struct{})
+	hi := &Integration{
+		ctx:            ctx,
+		hiveClient:     hive.NewHiveClient(server.URL, ""),
+		config:         &IntegrationConfig{AgentID: "agent-a", Capabilities: []string{"general"}},
+		repositories:   map[int]*RepositoryClient{7: repoClient},
+		pollBackoff:    newPollBackoff(10*time.Millisecond, 10*time.Millisecond),
+		reasoningReady: ready,
+	}
+
+	go hi.taskPollingLoop()
+	hi.pollRepositories([]*RepositoryClient{repoClient}) // e.g. a webhook arriving early
+	time.Sleep(100 * time.Millisecond)
+	if n := atomic.LoadInt32(&polls); n != 0 {
+		t.Fatalf("polled %d times before reasoning was configured", n)
+	}
+
+	close(ready)
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt32(&polls) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("polling did not start once reasoning was configured")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAgentOverBudgetClaimsNothing(t *testing.T) {
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/acme/widgets/issues" {
+			atomic.AddInt32(&polls, 1)
+		}
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client: &Client{
+			client: ghClient,
+			ctx:    context.Background(),
+			config: &Config{Owner: "acme", Repository: "widgets", TaskLabel: "bzzz-task"},
+		},
+		Repository: hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets"},
+	}
+	budgets := budget.NewTracker(config.BudgetConfig{Agent: config.Budget{MaxTokens: 1000}, AgentWindow: time.Hour})
+	hi := &Integration{
+		ctx:         context.Background(),
+		hiveClient:  hive.NewHiveClient(server.URL, ""),
+		config:      &IntegrationConfig{AgentID: "agent-a", Capabilities: []string{"general"}},
+		pollBackoff: newPollBackoff(time.Second, 5*time.Second),
+		budgets:     budgets,
+	}
+
+	hi.pollRepositories([]*RepositoryClient{repoClient})
+	if atomic.LoadInt32(&polls) != 1 {
+		t.Fatal("expected an agent within its budget to poll")
+	}
+
+	budgets.Record("7:5", reasoning.GenerationStats{PromptTokens: 900, CompletionTokens: 200})
+	hi.pollRepositories([]*RepositoryClient{repoClient})
+	if n := atomic.LoadInt32(&polls); n != 1 {
+		t.Fatalf("expected an agent over its budget not to look for tasks, polled %d times", n)
+	}
+}
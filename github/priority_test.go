@@ -0,0 +1,26 @@
+package github
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+)
+
+func TestPriorityBandSkipsLowPriorityTasks(t *testing.T) {
+	hi := &Integration{
+		config:   &IntegrationConfig{AgentID: "on-call", Capabilities: []string{"general"}, MinPriority: 8},
+		failures: newFailureTracker(filepath.Join(t.TempDir(), "failures.json")),
+	}
+	cleanup := &types.EnhancedTask{Number: 1, TaskType: "general", Priority: 3, Title: "Tidy imports"}
+	urgent := &types.EnhancedTask{Number: 2, TaskType: "general", Priority: 8, Title: "Fix outage"}
+	critical := &types.EnhancedTask{Number: 3, TaskType: "general", Priority: 10, Title: "Fix data loss"}
+
+	got := hi.filterSuitableTasks([]*types.EnhancedTask{cleanup, urgent, critical})
+	if len(got) != 2 {
+		t.Fatalf("expected only the two tasks with priority >= 8, got %d", len(got))
+	}
+	if got[0] != critical || got[1] != urgent {
+		t.Fatalf("expected tasks ordered by priority, got #%d then #%d", got[0].Number, got[1].Number)
+	}
+}
@@ -0,0 +1,128 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestProjectsOnSameRepositoryShareOneClient(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	claimed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/api/v3")
+		mu.Lock()
+		requests = append(requests, r.Method+" "+path)
+		if r.Method == http.MethodPatch {
+			claimed = true
+		}
+		isClaimed := claimed
+		mu.Unlock()
+
+		switch {
+		case path == "/api/bzzz/active-repos":
+			fmt.Fprint(w, `{"repositories":[
+				{"project_id":7,"owner":"acme","repository":"widgets","branch":"main","bzzz_enabled":true},
+				{"project_id":8,"owner":"Acme","repository":"Widgets","branch":"main","bzzz_enabled":true}
+			]}`)
+		case r.Method == http.MethodGet && path == "/repos/acme/widgets/issues" && !isClaimed:
+			fmt.Fprint(w, `[{"number":5,"title":"Rotate the signing keys","labels":[{"name":"bzzz-task"}]}]`)
+		case r.Method == http.MethodGet && path == "/repos/acme/widgets/issues":
+			fmt.Fprint(w, `[]`)
+		case r.Method == http.MethodGet && path == "/repos/acme/widgets/issues/5":
+			fmt.Fprint(w, `{"number":5,"state":"open","labels":[{"name":"bzzz-task"}]}`)
+		case r.Method == http.MethodGet && path == "/repos/acme/widgets/git/ref/heads/main":
+			fmt.Fprint(w, `{"ref":"refs/heads/main","object":{"sha":"abc123"}}`)
+		case strings.HasSuffix(path, "/claims"):
+			fmt.Fprint(w, `[]`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer server.Close()
+
+	executed := make(chan *types.EnhancedTask, 2)
+	hi := &Integration{
+		ctx:          context.Background(),
+		pubsub:       newTestPubSub(t),
+		config:       &IntegrationConfig{AgentID: "agent-a", Capabilities: []string{"general"}, GitHubBaseURL: server.URL + "/api/v3/"},
+		agentConfig:  &config.AgentConfig{ClaimIntentWindow: 10 * time.Millisecond},
+		hlog:         logging.NewHypercoreLog(peer.ID("test")),
+		hiveClient:   hive.NewHiveClient(server.URL, ""),
+		tokens:       NewTokenResolver("test-token", nil),
+		failures:     newFailureTracker(filepath.Join(t.TempDir(), "failures.json")),
+		repositories: make(map[int]*RepositoryClient),
+		claimIntents: make(map[string]map[string]*claimIntent),
+		execute: func(ctx context.Context, task *types.EnhancedTask, repoClient *RepositoryClient) {
+			executed <- task
+		},
+	}
+
+	hi.syncRepositories()
+	if len(hi.repositories) != 2 || hi.repositories[7] != hi.repositories[8] {
+		t.Fatalf("expected both projects to share one client, got %v", hi.repositories)
+	}
+	if projects := hi.repositoryProjects(hi.repositories[7]); len(projects) != 2 {
+		t.Errorf("expected the client to be associated with both projects, got %v", projects)
+	}
+
+	hi.pollAllRepositories()
+	hi.pollAllRepositories()
+
+	select {
+	case task := <-executed:
+		if task.Number != 5 {
+			t.Fatalf("executed task #%d, want #5", task.Number)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("task was not executed")
+	}
+	select {
+	case task := <-executed:
+		t.Fatalf("task #%d was executed twice", task.Number)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	listed, claims := 0, 0
+	for _, request := range requests {
+		switch request {
+		case "GET /repos/acme/widgets/issues":
+			listed++
+		case "PATCH /repos/acme/widgets/issues/5":
+			claims++
+		}
+	}
+	if listed != 2 {
+		t.Errorf("expected the issues to be listed once per poll, listed %d times in 2 polls", listed)
+	}
+	if claims != 1 {
+		t.Errorf("expected a single claim, got %d", claims)
+	}
+}
+
+func TestProjectsOnDifferentBranchesKeepTheirOwnClients(t *testing.T) {
+	trunk := hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets", Branch: "main"}
+	release := hive.Repository{ProjectID: 9, Owner: "Acme", Repository: "widgets", Branch: "release-2.x"}
+	if repositoryKey(trunk) == repositoryKey(release) {
+		t.Fatal("expected projects on different branches not to share a client")
+	}
+	release.Branch = "main"
+	if repositoryKey(trunk) != repositoryKey(release) {
+		t.Fatal("expected projects on the same repository and branch to share a client")
+	}
+}
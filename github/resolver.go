@@ -0,0 +1,49 @@
+package github
+
+// Contender is one agent's bid for a task during claim arbitration
+type Contender struct {
+	AgentID string
+	PeerID  string  // Empty when the bid's sender isn't known
+	Score   float64 // How well the agent fits the task
+}
+
+// Resolver picks which of the agents contending for a task gets to claim it.
+// Every agent runs its resolver over the same bids and acts on the result without
+// further messages, so a resolver must be deterministic and all agents must use
+// the same one.
+type Resolver interface {
+	Resolve(taskKey string, contenders []Contender) Contender
+}
+
+// ScoreResolver awards a task to the best-matched agent. Ties go to the lower
+// peer ID, then the lower agent ID.
+type ScoreResolver struct{}
+
+// Resolve returns the contender with the highest score
+func (ScoreResolver) Resolve(taskKey string, contenders []Contender) Contender {
+	winner := contenders[0]
+	for _, contender := range contenders[1:] {
+		if outranks(contender, winner) {
+			winner = contender
+		}
+	}
+	return winner
+}
+
+// outranks compares two bids by score, breaking ties deterministically
+func outranks(a, b Contender) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if a.PeerID != "" && b.PeerID != "" && a.PeerID != b.PeerID {
+		return a.PeerID < b.PeerID
+	}
+	return a.AgentID < b.AgentID
+}
+
+// SetResolver replaces the strategy used to arbitrate competing claims
+func (hi *Integration) SetResolver(resolver Resolver) {
+	hi.claimIntentLock.Lock()
+	defer hi.claimIntentLock.Unlock()
+	hi.resolver = resolver
+}
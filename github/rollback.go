@@ -0,0 +1,66 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/anthonyrawlins/bzzz/executor"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+)
+
+// maxPublicReasonLength is how much of a failure's detail is posted on its issue;
+// the whole of it only goes to the agent's log
+const maxPublicReasonLength = 500
+
+// rollbackClaim undoes what claiming did after an execution fails: the Hive
+// lease is given back, the issue is unassigned and loses its in-progress label,
+// its branch is deleted if configured, and a comment says why the agent gave up
+func (hi *Integration) rollbackClaim(ctx context.Context, task *types.EnhancedTask, repoClient *RepositoryClient, reason string) {
+	fmt.Printf("↩️ Rolling back claim on task #%d: %s\n", task.Number, reason)
+	if err := repoClient.Client.ReleaseTask(task.Number); err != nil {
+		fmt.Printf("⚠️ Failed to release task #%d: %v\n", task.Number, err)
+	}
+	hi.releaseHiveClaim(ctx, task)
+
+	branchNote := ""
+	if task.BranchName != "" {
+		if hi.config.DeleteFailedBranches {
+			if err := repoClient.Client.DeleteBranch(task.BranchName); err != nil {
+				fmt.Printf("⚠️ Failed to delete branch %s for task #%d: %v\n", task.BranchName, task.Number, err)
+			} else {
+				branchNote = fmt.Sprintf("\n\nThe branch `%s` has been deleted.", task.BranchName)
+			}
+		} else {
+			branchNote = fmt.Sprintf("\n\nThe branch `%s` has been left for inspection.", task.BranchName)
+		}
+	}
+
+	// Errors can carry command output, so the issue only gets a scrubbed excerpt
+	var secretScan config.SecretScanConfig
+	if hi.agentConfig != nil {
+		secretScan = hi.agentConfig.SecretScan
+	}
+	public := executor.RedactSecrets(reason, secretScan)
+	if len(public) > maxPublicReasonLength {
+		public = strings.ToValidUTF8(public[:maxPublicReasonLength], "") + "\n[truncated, see the agent's log]"
+	}
+	fence := codeFence(public)
+	comment := fmt.Sprintf("⚠️ **Agent `%s` could not complete this task** and has released it.\n\n**Reason:**\n%s\n%s\n%s%s",
+		hi.config.AgentID, fence, public, fence, branchNote)
+	if err := repoClient.Client.CommentOnIssue(task.Number, comment); err != nil {
+		fmt.Printf("⚠️ Failed to comment on task #%d: %v\n", task.Number, err)
+	}
+}
+
+// releaseHiveClaim gives back the task's Hive lease, so other agents needn't wait
+// for it to run out. It outlives ctx, which may have ended with the task.
+func (hi *Integration) releaseHiveClaim(ctx context.Context, task *types.EnhancedTask) {
+	if hi.hiveClient == nil {
+		return
+	}
+	if err := hi.hiveClient.ReleaseClaim(hi.traced(ctx), task.ProjectID, task.Number, hi.config.AgentID); err != nil {
+		fmt.Printf("⚠️ Failed to release the Hive lease on task #%d: %v\n", task.Number, err)
+	}
+}
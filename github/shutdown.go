@@ -0,0 +1,37 @@
+package github
+
+import (
+	"context"
+	"fmt"
+)
+
+// StopPolling stops the agent claiming new tasks, waiting out a poll already
+// under way. Unlike Pause it can't be undone; it's the first step of shutting down.
+func (hi *Integration) StopPolling() {
+	if hi.stopped.Swap(true) {
+		return
+	}
+	hi.pollLock.Lock()
+	hi.pollLock.Unlock()
+	fmt.Printf("🛑 Agent %s stopped polling for tasks\n", hi.config.AgentID)
+}
+
+// ReleaseTasks cancels every task the agent is running so their claims are
+// released, and waits until they have unwound or ctx is done
+func (hi *Integration) ReleaseTasks(ctx context.Context) error {
+	for _, running := range hi.RunningTasks() {
+		hi.CancelTask(running.ProjectID, running.TaskNumber, "agent shutting down")
+	}
+
+	unwound := make(chan struct{})
+	go func() {
+		hi.executions.Wait()
+		close(unwound)
+	}()
+	select {
+	case <-unwound:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d tasks still releasing: %w", len(hi.RunningTasks()), ctx.Err())
+	}
+}
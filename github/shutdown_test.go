@@ -0,0 +1,101 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	gh "github.com/google/go-github/v57/github"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestPollRacingStopPollingClaimsNothing(t *testing.T) {
+	var hi *Integration
+	var listings atomic.Int32
+	stopDuringPoll := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/issues":
+			listings.Add(1)
+			if stopDuringPoll {
+				hi.stopped.Store(true) // Shutdown begins while the poll is collecting tasks
+			}
+			fmt.Fprint(w, `[{"number":5,"title":"Rotate the signing keys","labels":[{"name":"bzzz-task"}]}]`)
+		case r.URL.Path == "/api/bzzz/projects/7/claims":
+			fmt.Fprint(w, `[]`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client: &Client{
+			client: ghClient,
+			ctx:    context.Background(),
+			config: &Config{Owner: "acme", Repository: "widgets", BaseBranch: "main", TaskLabel: "bzzz-task", InProgressLabel: "in-progress"},
+		},
+		Repository: hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets"},
+	}
+	executed := make(chan *types.EnhancedTask, 1)
+	hi = &Integration{
+		ctx:          context.Background(),
+		pubsub:       newTestPubSub(t),
+		config:       &IntegrationConfig{AgentID: "agent-a", Capabilities: []string{"general"}},
+		agentConfig:  &config.AgentConfig{},
+		hlog:         logging.NewHypercoreLog(peer.ID("test")),
+		hiveClient:   hive.NewHiveClient(server.URL, ""),
+		failures:     newFailureTracker(filepath.Join(t.TempDir(), "failures.json")),
+		repositories: map[int]*RepositoryClient{7: repoClient},
+		claimIntents: make(map[string]map[string]*claimIntent),
+		execute: func(ctx context.Context, task *types.EnhancedTask, repoClient *RepositoryClient) {
+			executed <- task
+		},
+	}
+	claimed := func() bool {
+		select {
+		case <-executed:
+			return true
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}
+
+	// A poll waiting for the lock when polling stops gives up once it gets it
+	hi.pollLock.Lock()
+	waiting := make(chan struct{})
+	go func() {
+		hi.pollRepositories([]*RepositoryClient{repoClient})
+		close(waiting)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	hi.stopped.Store(true)
+	hi.pollLock.Unlock()
+	<-waiting
+	if listings.Load() != 0 || claimed() {
+		t.Fatal("a poll that waited out StopPolling went on to look for tasks")
+	}
+
+	// A poll already under way doesn't claim once polling stops
+	hi.stopped.Store(false)
+	stopDuringPoll = true
+	hi.pollRepositories([]*RepositoryClient{repoClient})
+	if listings.Load() != 1 {
+		t.Fatalf("expected the poll to list tasks once, got %d", listings.Load())
+	}
+	if claimed() {
+		t.Fatal("the poll claimed a task after polling stopped")
+	}
+}
@@ -0,0 +1,200 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/reasoning"
+)
+
+const (
+	// SplitLabel marks an issue that was split into sub-tasks. It leaves the task
+	// pool and tracks its sub-tasks instead.
+	SplitLabel = "bzzz-split"
+
+	// SubTaskLabel marks an issue filed as part of a larger one, so it is never split again
+	SubTaskLabel = "bzzz-subtask"
+)
+
+// maxSubTasks caps how many issues one task is split into
+const maxSubTasks = 6
+
+// SubTask is a piece of a larger task, as proposed by the reasoning model
+type SubTask struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// splittable reports whether a task may be split into sub-tasks: splitting is
+// on, and the task is neither a sub-task nor already split
+func (hi *Integration) splittable(task *types.EnhancedTask) bool {
+	return hi.config.SplitOversizedTasks && !hasLabel(task, SubTaskLabel) && !hasLabel(task, SplitLabel)
+}
+
+// splitOversizedTask asks whether a task is too big for one run and, if so,
+// files its sub-tasks, announces them to the mesh and retires the original.
+// attempted is how much a first attempt changed before it outgrew the diff
+// limits, or nil before any attempt. It returns true when the task was split
+// and there is nothing left to execute.
+func (hi *Integration) splitOversizedTask(ctx context.Context, task *types.EnhancedTask, repoClient *RepositoryClient, attempted *types.DiffStats) bool {
+	if !hi.splittable(task) {
+		return false
+	}
+	propose := hi.proposeSplit
+	if hi.splitTask != nil {
+		propose = hi.splitTask
+	}
+	subTasks, err := propose(ctx, task, attempted)
+	if err != nil {
+		fmt.Printf("⚠️ Couldn't judge whether task #%d needs splitting, attempting it whole: %v\n", task.Number, err)
+		return false
+	}
+	if len(subTasks) == 0 {
+		return false
+	}
+
+	fmt.Printf("✂️ Task #%d is too big for one run, splitting it into %d sub-tasks\n", task.Number, len(subTasks))
+	taskType := task.TaskType
+	if taskType == "" {
+		taskType = "general"
+	}
+	var filed []*Task
+	var failed []string
+	for _, subTask := range subTasks {
+		created, err := repoClient.Client.CreateTask(&Task{
+			Title:       subTask.Title,
+			Description: fmt.Sprintf("%s\n\nPart of #%d", subTask.Description, task.Number),
+			TaskType:    taskType,
+			Priority:    task.Priority,
+			Labels:      []string{SubTaskLabel},
+		})
+		if err != nil {
+			fmt.Printf("❌ Failed to file sub-task %q of task #%d: %v\n", subTask.Title, task.Number, err)
+			failed = append(failed, subTask.Title)
+			continue
+		}
+		filed = append(filed, created)
+	}
+	if len(filed) == 0 {
+		fmt.Printf("⚠️ No sub-tasks of task #%d could be filed, attempting it whole\n", task.Number)
+		return false
+	}
+
+	hi.retireSplitTask(ctx, task, repoClient, filed, failed)
+	for _, created := range filed {
+		hi.announceTask(newEnhancedTask(repoClient, created), fmt.Sprintf("split from #%d", task.Number))
+	}
+	return true
+}
+
+// retireSplitTask links a split task to its sub-tasks and takes it out of the task pool
+func (hi *Integration) retireSplitTask(ctx context.Context, task *types.EnhancedTask, repoClient *RepositoryClient, filed []*Task, failed []string) {
+	numbers := make([]int, 0, len(filed))
+	var comment strings.Builder
+	fmt.Fprintf(&comment, "✂️ **Agent `%s` split this task** into smaller sub-tasks for agents across the mesh to pick up:\n\n", hi.config.AgentID)
+	for _, created := range filed {
+		numbers = append(numbers, created.Number)
+		fmt.Fprintf(&comment, "- [ ] #%d %s\n", created.Number, created.Title)
+	}
+	if len(failed) > 0 {
+		fmt.Fprintf(&comment, "\nThese sub-tasks couldn't be filed and need creating by hand:\n")
+		for _, title := range failed {
+			fmt.Fprintf(&comment, "- %s\n", title)
+		}
+	}
+	if err := repoClient.Client.CommentOnIssue(task.Number, comment.String()); err != nil {
+		fmt.Printf("⚠️ Failed to link task #%d to its sub-tasks: %v\n", task.Number, err)
+	}
+
+	if err := repoClient.Client.AddLabel(task.Number, SplitLabel); err != nil {
+		fmt.Printf("⚠️ Failed to label split task #%d: %v\n", task.Number, err)
+	}
+	if err := repoClient.Client.RemoveLabel(task.Number, repoClient.Client.config.TaskLabel); err != nil {
+		fmt.Printf("⚠️ Failed to take split task #%d out of the task pool: %v\n", task.Number, err)
+	}
+	if err := repoClient.Client.ReleaseTask(task.Number); err != nil {
+		fmt.Printf("⚠️ Failed to release split task #%d: %v\n", task.Number, err)
+	}
+
+	hi.hlog.Append(logging.TaskProgress, map[string]interface{}{
+		"task_id":    task.Number,
+		"repository": task.RepositoryName(),
+		"status":     "split into sub-tasks",
+		"sub_tasks":  numbers,
+	})
+	if err := hi.hiveClient.UpdateTaskStatus(hi.traced(ctx), task.ProjectID, task.Number, "split", map[string]interface{}{
+		"agent_id":  hi.config.AgentID,
+		"sub_tasks": numbers,
+	}); err != nil {
+		fmt.Printf("⚠️ Failed to report task split to Hive: %v\n", err)
+	}
+}
+
+// proposeSplit asks the reasoning model whether a task is too big for one run,
+// returning the sub-tasks it suggests, or none if the task can be done whole
+func (hi *Integration) proposeSplit(ctx context.Context, task *types.EnhancedTask, attempted *types.DiffStats) ([]SubTask, error) {
+	response, err := reasoning.GenerateResponseSmart(ctx, hi.buildSplitPrompt(task, attempted))
+	if err != nil {
+		return nil, err
+	}
+	return parseSplitResponse(response)
+}
+
+// buildSplitPrompt asks for a verdict on the task's size, against the diff limits
+// if any are set, and with what an attempt that outgrew them changed
+func (hi *Integration) buildSplitPrompt(task *types.EnhancedTask, attempted *types.DiffStats) string {
+	limit := "a single focused pull request"
+	if hi.agentConfig != nil {
+		if maxDiff := hi.agentConfig.MaxDiff; maxDiff.MaxFiles > 0 || maxDiff.MaxLines > 0 {
+			limit = fmt.Sprintf("a single pull request of at most %d files and %d changed lines", maxDiff.MaxFiles, maxDiff.MaxLines)
+		}
+	}
+	var history string
+	if attempted != nil {
+		history = fmt.Sprintf("\nAn attempt at the whole task had already changed %d files and %d lines, more than that allows, before it was finished.\n",
+			attempted.FilesChanged, attempted.Lines())
+	}
+	return fmt.Sprintf(`You are planning work for an autonomous coding agent. Decide whether this task can be done as %s.
+
+TASK #%d: %s
+%s
+%s
+If it can, respond with exactly PROCEED.
+If it is too big, respond with SPLIT followed by a JSON array of 2 to %d independent sub-tasks, each an object with "title" and "description" fields. Each description must stand on its own.`,
+		limit, task.Number, task.Title, task.Description, history, maxSubTasks)
+}
+
+// parseSplitResponse reads the model's verdict. Anything other than a split into
+// at least two sub-tasks means the task is attempted whole.
+func parseSplitResponse(response string) ([]SubTask, error) {
+	response = strings.TrimSpace(response)
+	if !strings.HasPrefix(strings.ToUpper(response), "SPLIT") {
+		return nil, nil
+	}
+	start, end := strings.Index(response, "["), strings.LastIndex(response, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("split proposal has no list of sub-tasks")
+	}
+
+	var proposed []SubTask
+	if err := json.Unmarshal([]byte(response[start:end+1]), &proposed); err != nil {
+		return nil, fmt.Errorf("failed to parse split proposal: %w", err)
+	}
+	var subTasks []SubTask
+	for _, subTask := range proposed {
+		if strings.TrimSpace(subTask.Title) != "" {
+			subTasks = append(subTasks, subTask)
+		}
+	}
+	if len(subTasks) < 2 {
+		return nil, nil
+	}
+	if len(subTasks) > maxSubTasks {
+		subTasks = subTasks[:maxSubTasks]
+	}
+	return subTasks, nil
+}
@@ -0,0 +1,217 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/executor"
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/budget"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	gh "github.com/google/go-github/v57/github"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestOversizedTaskIsSplitIntoAnnouncedSubIssues(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	var created []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, body))
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/widgets/issues":
+			var issue map[string]interface{}
+			json.Unmarshal(body, &issue)
+			created = append(created, issue)
+			number := 100 + len(created)
+			fmt.Fprintf(w, `{"number":%d,"title":%q,"body":%q,"state":"open"}`, number, issue["title"], issue["body"])
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/issues/42":
+			fmt.Fprint(w, `{"number":42,"assignees":[{"login":"bzzz-bot"}],"labels":[{"name":"bzzz-task"},{"name":"in-progress"}]}`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client: &Client{
+			client: ghClient,
+			ctx:    context.Background(),
+			config: &Config{Owner: "acme", Repository: "widgets", TaskLabel: "bzzz-task", InProgressLabel: "in-progress"},
+		},
+		Repository: hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets"},
+	}
+
+	executed := false
+	hi := &Integration{
+		ctx:         context.Background(),
+		pubsub:      newTestPubSub(t),
+		config:      &IntegrationConfig{AgentID: "agent-a", SplitOversizedTasks: true},
+		agentConfig: &config.AgentConfig{MaxTaskFailures: 3},
+		hlog:        logging.NewHypercoreLog(peer.ID("test")),
+		hiveClient:  hive.NewHiveClient(server.URL, ""),
+		failures:    newFailureTracker(filepath.Join(t.TempDir(), "failures.json")),
+		splitTask: func(ctx context.Context, task *types.EnhancedTask, attempted *types.DiffStats) ([]SubTask, error) {
+			return parseSplitResponse(`SPLIT
+[{"title": "Add the widgets table migration", "description": "Create the widgets table."},
+ {"title": "Serve widgets over the API", "description": "Add GET /widgets."}]`)
+		},
+		runExecutor: func(ctx context.Context, task *types.EnhancedTask, hlog *logging.HypercoreLog, agentConfig *config.AgentConfig, budgets *budget.Tracker) (*executor.ExecuteTaskResult, error) {
+			executed = true
+			return nil, fmt.Errorf("the split task should not be executed")
+		},
+	}
+	task := &types.EnhancedTask{Number: 42, ProjectID: 7, TaskType: "backend", Priority: 2, Title: "Build the widgets feature", Repository: repoClient.Repository, BranchName: "bzzz/task-42"}
+
+	hi.executeTask(context.Background(), task, repoClient)
+
+	if executed {
+		t.Fatal("the oversized task was executed instead of split")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(created) != 2 {
+		t.Fatalf("expected 2 sub-issues, got %d", len(created))
+	}
+	for i, issue := range created {
+		if body, _ := issue["body"].(string); !strings.Contains(body, "Part of #42") {
+			t.Errorf("sub-issue %d is not linked to its parent: %q", i, body)
+		}
+		labels, _ := json.Marshal(issue["labels"])
+		for _, want := range []string{`"bzzz-task"`, `"type-backend"`, `"priority-2"`, `"` + SubTaskLabel + `"`} {
+			if !strings.Contains(string(labels), want) {
+				t.Errorf("sub-issue %d is missing label %s: %s", i, want, labels)
+			}
+		}
+	}
+
+	joined := strings.Join(requests, "\n")
+	for _, want := range []string{
+		"POST /repos/acme/widgets/issues/42/comments",
+		"- [ ] #101 Add the widgets table migration",
+		"- [ ] #102 Serve widgets over the API",
+		"POST /repos/acme/widgets/issues/42/labels",
+		"DELETE /repos/acme/widgets/issues/42/labels/bzzz-task",
+		"DELETE /repos/acme/widgets/issues/42/assignees",
+		`"status":"split"`,
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected %q in requests:\n%s", want, joined)
+		}
+	}
+
+	announced, err := hi.hlog.GetEntriesByType(logging.TaskAnnounced)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(announced) != 2 {
+		t.Fatalf("expected both sub-tasks announced to the mesh, got %d announcements", len(announced))
+	}
+	for i, entry := range announced {
+		if number := entry.Data["task_id"]; number != 101+i {
+			t.Errorf("announcement %d is for task %v", i, number)
+		}
+		if entry.Data["reason"] != "split from #42" {
+			t.Errorf("announcement %d has reason %v", i, entry.Data["reason"])
+		}
+	}
+}
+
+func TestSplitResponseWithoutSubTasksProceedsWhole(t *testing.T) {
+	for _, response := range []string{
+		"PROCEED",
+		"SPLIT\n[{\"title\": \"Everything\", \"description\": \"The whole task.\"}]",
+	} {
+		subTasks, err := parseSplitResponse(response)
+		if err != nil || subTasks != nil {
+			t.Errorf("%q: expected the task to proceed whole, got %v, %v", response, subTasks, err)
+		}
+	}
+}
+
+func TestTaskOutgrowingTheDiffLimitsIsSplit(t *testing.T) {
+	var mu sync.Mutex
+	created := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/repos/acme/widgets/issues" {
+			mu.Lock()
+			created++
+			fmt.Fprintf(w, `{"number":%d,"state":"open"}`, 100+created)
+			mu.Unlock()
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client: &Client{
+			client: ghClient,
+			ctx:    context.Background(),
+			config: &Config{Owner: "acme", Repository: "widgets", TaskLabel: "bzzz-task", InProgressLabel: "in-progress"},
+		},
+		Repository: hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets"},
+	}
+
+	var proposals []*types.DiffStats
+	hi := &Integration{
+		ctx:         context.Background(),
+		pubsub:      newTestPubSub(t),
+		config:      &IntegrationConfig{AgentID: "agent-a", SplitOversizedTasks: true},
+		agentConfig: &config.AgentConfig{MaxTaskFailures: 3, MaxDiff: config.DiffLimits{MaxFiles: 10}},
+		hlog:        logging.NewHypercoreLog(peer.ID("test")),
+		hiveClient:  hive.NewHiveClient(server.URL, ""),
+		failures:    newFailureTracker(filepath.Join(t.TempDir(), "failures.json")),
+		splitTask: func(ctx context.Context, task *types.EnhancedTask, attempted *types.DiffStats) ([]SubTask, error) {
+			proposals = append(proposals, attempted)
+			if attempted == nil {
+				return nil, nil // Looks small enough to do whole
+			}
+			return []SubTask{{Title: "First half"}, {Title: "Second half"}}, nil
+		},
+		runExecutor: func(ctx context.Context, task *types.EnhancedTask, hlog *logging.HypercoreLog, agentConfig *config.AgentConfig, budgets *budget.Tracker) (*executor.ExecuteTaskResult, error) {
+			if !task.SplitWhenOversized {
+				t.Error("expected the run to stop once it outgrew the diff limits")
+			}
+			return nil, fmt.Errorf("failed to generate next command: %w", &executor.DiffLimitExceededError{
+				Diff:   types.DiffStats{FilesChanged: 14, LinesAdded: 900},
+				Limits: agentConfig.MaxDiff,
+			})
+		},
+	}
+	task := &types.EnhancedTask{Number: 42, ProjectID: 7, Title: "Build the widgets feature", Repository: repoClient.Repository, BranchName: "bzzz/task-42"}
+
+	hi.executeTask(context.Background(), task, repoClient)
+
+	if len(proposals) != 2 || proposals[1] == nil || proposals[1].FilesChanged != 14 {
+		t.Fatalf("expected a second split proposal told what the attempt changed, got %v", proposals)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if created != 2 {
+		t.Fatalf("expected the outgrown task to be split into 2 sub-issues, got %d", created)
+	}
+	if _, failed := hi.failures.failures[taskKey(7, 42)]; failed {
+		t.Error("a task split after outgrowing the limits should not count as a failure")
+	}
+}
@@ -0,0 +1,85 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Built-in text the agent posts on GitHub, used when no template is configured
+const (
+	defaultClaimComment     = "🐝 **Task claimed by Bzzz agent:** `{{.AgentID}}`\n\nThis task has been automatically claimed by the Bzzz P2P task coordination system."
+	defaultPullRequestTitle = "fix: resolve issue #{{.IssueNumber}} via bzzz agent {{.AgentID}}"
+	defaultPullRequestBody  = "This pull request resolves issue #{{.IssueNumber}}, and was automatically generated by the Bzzz agent `{{.AgentID}}`."
+)
+
+var defaultTemplates = &Templates{
+	ClaimComment:     template.Must(template.New("claim_comment").Parse(defaultClaimComment)),
+	PullRequestTitle: template.Must(template.New("pull_request_title").Parse(defaultPullRequestTitle)),
+	PullRequestBody:  template.Must(template.New("pull_request_body").Parse(defaultPullRequestBody)),
+}
+
+// TemplateData is what the claim comment and pull request templates can refer to
+type TemplateData struct {
+	AgentID     string
+	IssueNumber int
+	Title       string // The issue's title
+	Repository  string // "owner/repo"
+	Branch      string // The task branch
+}
+
+// Templates render the text the agent posts on GitHub, so teams can brand or
+// localize it. The pull request body is the opening paragraph; the diff size and
+// any review notes follow it.
+type Templates struct {
+	ClaimComment     *template.Template
+	PullRequestTitle *template.Template
+	PullRequestBody  *template.Template
+}
+
+// ParseTemplates parses the configured templates (see TemplateData for their
+// variables). Empty ones keep the built-in English text.
+func ParseTemplates(claimComment, pullRequestTitle, pullRequestBody string) (*Templates, error) {
+	templates := *defaultTemplates
+	for _, t := range []struct {
+		name string
+		text string
+		into **template.Template
+	}{
+		{"claim_comment", claimComment, &templates.ClaimComment},
+		{"pull_request_title", pullRequestTitle, &templates.PullRequestTitle},
+		{"pull_request_body", pullRequestBody, &templates.PullRequestBody},
+	} {
+		if t.text == "" {
+			continue
+		}
+		tmpl, err := template.New(t.name).Option("missingkey=error").Parse(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s template: %w", t.name, err)
+		}
+		*t.into = tmpl
+	}
+	return &templates, nil
+}
+
+// render fills in tmpl, falling back to the built-in fallback if it fails, so a
+// bad template never blocks a claim or pull request
+func render(tmpl, fallback *template.Template, data TemplateData) string {
+	var b strings.Builder
+	err := tmpl.Execute(&b, data)
+	if err == nil {
+		return b.String()
+	}
+	fmt.Printf("⚠️ Failed to render %s template, using the default: %v\n", tmpl.Name(), err)
+	b.Reset()
+	fallback.Execute(&b, data)
+	return b.String()
+}
+
+// templates returns the client's configured templates, or the built-in ones
+func (c *Client) templates() *Templates {
+	if c.config.Templates != nil {
+		return c.config.Templates
+	}
+	return defaultTemplates
+}
@@ -0,0 +1,37 @@
+package github
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestClaimCommentUsesConfiguredTemplate(t *testing.T) {
+	fake := &recordingGitHub{createRefStatus: http.StatusCreated}
+	client := newTestClient(t, fake)
+	templates, err := ParseTemplates("Tâche #{{.IssueNumber}} prise en charge par {{.AgentID}} ({{.Repository}}, branche {{.Branch}})", "", "")
+	if err != nil {
+		t.Fatalf("failed to parse templates: %v", err)
+	}
+	client.config.Templates = templates
+
+	if _, err := client.ClaimTask(42, "agent-a"); err != nil {
+		t.Fatalf("ClaimTask failed: %v", err)
+	}
+
+	want := "Tâche #42 prise en charge par agent-a (acme/widgets, branche " + client.TaskBranchName(42, "agent-a") + ")"
+	if !fake.sawRequest(`POST /repos/acme/widgets/issues/42/comments {"body":"` + want + `\n\n`) {
+		t.Fatalf("expected the claim comment to use the configured template, got %v", fake.requests)
+	}
+
+	// Templates left empty keep the built-in text
+	var b strings.Builder
+	templates.PullRequestTitle.Execute(&b, TemplateData{IssueNumber: 42, AgentID: "agent-a"})
+	if b.String() != "fix: resolve issue #42 via bzzz agent agent-a" {
+		t.Errorf("expected the default pull request title, got %q", b.String())
+	}
+
+	if _, err := ParseTemplates("{{.IssueNumber", "", ""); err == nil {
+		t.Error("expected an invalid template to be rejected")
+	}
+}
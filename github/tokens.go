@@ -0,0 +1,30 @@
+package github
+
+import "strings"
+
+// TokenResolver picks the GitHub token for a repository by its owner, so repositories
+// across organisations with different access can be served by one agent
+type TokenResolver struct {
+	defaultToken string
+	ownerTokens  map[string]string // Lower-cased owner -> token
+}
+
+// NewTokenResolver creates a resolver that falls back to defaultToken for owners without their own token
+func NewTokenResolver(defaultToken string, ownerTokens map[string]string) *TokenResolver {
+	resolver := &TokenResolver{
+		defaultToken: defaultToken,
+		ownerTokens:  make(map[string]string, len(ownerTokens)),
+	}
+	for owner, token := range ownerTokens {
+		resolver.ownerTokens[strings.ToLower(owner)] = token
+	}
+	return resolver
+}
+
+// TokenFor returns the token to use for repositories under owner
+func (r *TokenResolver) TokenFor(owner string) string {
+	if token, exists := r.ownerTokens[strings.ToLower(owner)]; exists && token != "" {
+		return token
+	}
+	return r.defaultToken
+}
@@ -0,0 +1,21 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+)
+
+func TestRepositoryUsesTokenMappedToItsOwner(t *testing.T) {
+	hi := &Integration{
+		config: &IntegrationConfig{AgentID: "agent-a"},
+		tokens: NewTokenResolver("default-token", map[string]string{"Acme": "acme-token"}),
+	}
+
+	if got := hi.repositoryConfig(hive.Repository{Owner: "acme", Repository: "widgets"}).AccessToken; got != "acme-token" {
+		t.Errorf("acme/widgets got token %q, want acme-token", got)
+	}
+	if got := hi.repositoryConfig(hive.Repository{Owner: "globex", Repository: "rockets"}).AccessToken; got != "default-token" {
+		t.Errorf("globex/rockets got token %q, want the default token", got)
+	}
+}
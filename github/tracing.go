@@ -0,0 +1,39 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthonyrawlins/bzzz/pkg/tracing"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startTaskSpan begins the root span covering a task from its claim to its pull request
+func (hi *Integration) startTaskSpan(task *types.EnhancedTask) (context.Context, trace.Span) {
+	return tracing.Start(hi.ctx, "task", taskAttributes(task)...)
+}
+
+// traced returns the integration's context carrying ctx's span, for calls that
+// must outlive a cancelled task but still belong to its trace
+func (hi *Integration) traced(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(hi.ctx, trace.SpanFromContext(ctx))
+}
+
+// traceGitHub runs a GitHub call inside a child span of ctx
+func traceGitHub(ctx context.Context, operation string, task *types.EnhancedTask, call func() error) error {
+	_, span := tracing.Start(ctx, "github."+operation, taskAttributes(task)...)
+	err := call()
+	tracing.End(span, err)
+	return err
+}
+
+// taskAttributes identifies a task on its spans
+func taskAttributes(task *types.EnhancedTask) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("bzzz.repository", fmt.Sprintf("%s/%s", task.Repository.Owner, task.Repository.Repository)),
+		attribute.Int("bzzz.task.number", task.Number),
+		attribute.Int("bzzz.project_id", task.ProjectID),
+	}
+}
@@ -0,0 +1,137 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/tracing"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+	"github.com/anthonyrawlins/bzzz/reasoning"
+	gh "github.com/google/go-github/v57/github"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTaskLifecycleFormsOneSpanTree(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/acme/widgets/issues/42" && r.Method == http.MethodGet:
+			fmt.Fprint(w, `{"number":42,"state":"open","title":"Traced task"}`)
+		case r.URL.Path == "/repos/acme/widgets/git/ref/heads/main":
+			fmt.Fprint(w, `{"ref":"refs/heads/main","object":{"sha":"abc123"}}`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	repoClient := &RepositoryClient{
+		Client: &Client{
+			client: ghClient,
+			ctx:    context.Background(),
+			config: &Config{Owner: "acme", Repository: "widgets", BaseBranch: "main", InProgressLabel: "in-progress"},
+		},
+		Repository: hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets"},
+	}
+
+	// The simulated executor runs two iterations, each asking the model for a command
+	executed := make(chan struct{})
+	hi := &Integration{
+		ctx:        context.Background(),
+		config:     &IntegrationConfig{AgentID: "agent-a"},
+		hlog:       logging.NewHypercoreLog(peer.ID("test")),
+		hiveClient: hive.NewHiveClient(server.URL, ""),
+		execute: func(ctx context.Context, task *types.EnhancedTask, repoClient *RepositoryClient) {
+			defer close(executed)
+			for i := 0; i < 2; i++ {
+				iterationCtx, span := tracing.Start(ctx, "executor.iteration")
+				reasoning.GenerateResponse(iterationCtx, "traced-model", fmt.Sprintf("step %d", i)) // Fails fast without Ollama, which is fine for the span
+				span.End()
+			}
+		},
+	}
+	task := &types.EnhancedTask{Number: 42, ProjectID: 7, Title: "Traced task", Repository: repoClient.Repository}
+
+	ctx, span := hi.startTaskSpan(task)
+	if err := hi.claimTask(ctx, task, repoClient); err != nil {
+		t.Fatalf("claim failed: %v", err)
+	}
+	hi.startExecution(ctx, task, repoClient)
+	select {
+	case <-executed:
+	case <-time.After(90 * time.Second):
+		t.Fatal("execution did not finish")
+	}
+
+	// The task span ends once the execution goroutine unwinds
+	deadline := time.Now().Add(5 * time.Second)
+	for len(exporter.GetSpans()) < 8 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	children := make(map[string][]string) // Parent span name -> child span names
+	names := make(map[string]string)      // Span ID -> name
+	spans := exporter.GetSpans()
+	for _, s := range spans {
+		names[s.SpanContext.SpanID().String()] = s.Name
+	}
+	for _, s := range spans {
+		if s.SpanContext.TraceID() != span.SpanContext().TraceID() {
+			t.Errorf("span %q is outside the task's trace", s.Name)
+		}
+		parent := ""
+		if s.Parent.IsValid() {
+			parent = names[s.Parent.SpanID().String()]
+		}
+		children[parent] = append(children[parent], s.Name)
+	}
+
+	expected := map[string][]string{
+		"":                   {"task"},
+		"task":               {"claim", "executor.iteration", "executor.iteration"},
+		"claim":              {"hive POST", "github.claim_task"},
+		"executor.iteration": {"reasoning.generate", "reasoning.generate"},
+	}
+	for parent, want := range expected {
+		if got := children[parent]; !sameNames(got, want) {
+			t.Errorf("children of %q = %v, want %v", parent, got, want)
+		}
+	}
+	if len(spans) != 8 {
+		t.Errorf("expected 8 spans, got %d: %v", len(spans), children)
+	}
+}
+
+// sameNames compares span names ignoring order
+func sameNames(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	counts := make(map[string]int)
+	for _, name := range got {
+		counts[name]++
+	}
+	for _, name := range want {
+		counts[name]--
+		if counts[name] < 0 {
+			return false
+		}
+	}
+	return true
+}
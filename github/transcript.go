@@ -0,0 +1,87 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anthonyrawlins/bzzz/executor"
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
+)
+
+const (
+	maxCommentLength    = 65536 // The most GitHub accepts in a comment body
+	maxTranscriptOutput = 4000  // The tail of each step's output that is kept
+)
+
+// postTranscript attaches the agent's working to its pull request as a collapsed
+// comment so reviewers can see how it arrived at the change
+func (hi *Integration) postTranscript(task *types.EnhancedTask, repoClient *RepositoryClient, prNumber int, transcript *executor.Transcript) {
+	if transcript == nil || len(transcript.Steps) == 0 {
+		return
+	}
+	var secretScan config.SecretScanConfig
+	if hi.agentConfig != nil {
+		secretScan = hi.agentConfig.SecretScan
+	}
+	// The comment is public: scrub what the agent saw before anyone else does
+	scrubbed := &executor.Transcript{Steps: make([]executor.TranscriptStep, len(transcript.Steps))}
+	for i, step := range transcript.Steps {
+		step.Command = executor.RedactSecrets(step.Command, secretScan)
+		step.Output = executor.RedactSecrets(step.Output, secretScan)
+		scrubbed.Steps[i] = step
+	}
+	if err := repoClient.Client.CommentOnPullRequest(prNumber, formatTranscriptComment(hi.config.AgentID, scrubbed)); err != nil {
+		fmt.Printf("⚠️ Failed to post transcript for task #%d: %v\n", task.Number, err)
+	}
+}
+
+// formatTranscriptComment renders a transcript inside a collapsible block. Steps
+// that would push it past GitHub's comment limit are left out.
+func formatTranscriptComment(agentID string, transcript *executor.Transcript) string {
+	header := fmt.Sprintf("🧠 **Execution transcript** from agent `%s` (%d steps)\n\n<details>\n<summary>Show how the agent worked through this task</summary>\n\n", agentID, len(transcript.Steps))
+	footer := "\n</details>\n"
+
+	var b strings.Builder
+	b.WriteString(header)
+	for i, step := range transcript.Steps {
+		entry := formatTranscriptStep(step)
+		omitted := fmt.Sprintf("_Transcript truncated: %d more steps omitted._\n", len(transcript.Steps)-i)
+		if b.Len()+len(entry)+len(omitted)+len(footer) > maxCommentLength {
+			b.WriteString(omitted)
+			break
+		}
+		b.WriteString(entry)
+	}
+	b.WriteString(footer)
+	return b.String()
+}
+
+// formatTranscriptStep renders one command and the tail of its output
+func formatTranscriptStep(step executor.TranscriptStep) string {
+	fence := codeFence(step.Command)
+	entry := fmt.Sprintf("**Step %d**\n%ssh\n%s\n%s\n", step.Iteration+1, fence, step.Command, fence)
+	if output := step.Output; output != "" {
+		if len(output) > maxTranscriptOutput {
+			output = fmt.Sprintf("[%d bytes omitted]\n%s", len(output)-maxTranscriptOutput, output[len(output)-maxTranscriptOutput:])
+		}
+		fence := codeFence(output)
+		entry += fmt.Sprintf("<details><summary>Output</summary>\n\n%s\n%s\n%s\n</details>\n", fence, output, fence)
+	}
+	return entry + "\n"
+}
+
+// codeFence returns a backtick fence longer than any run of backticks in content,
+// so nothing in it can close the block early
+func codeFence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
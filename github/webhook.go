@@ -0,0 +1,77 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// webhookPollActions are the issue events that can make a task claimable
+var webhookPollActions = map[string]bool{
+	"opened":     true,
+	"reopened":   true,
+	"labeled":    true,
+	"unassigned": true,
+}
+
+// WebhookHandler receives GitHub webhooks and polls the affected repository
+// right away instead of waiting for the next poll interval. Closed task pull
+// requests are credited to the model that wrote them. Payloads must be signed
+// with secret (X-Hub-Signature-256).
+func (hi *Integration) WebhookHandler(secret []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, err := github.ValidatePayload(r, secret)
+		if err != nil {
+			fmt.Printf("⚠️ Rejected GitHub webhook: %v\n", err)
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		event, err := github.ParseWebHook(github.WebHookType(r), payload)
+		if err != nil {
+			http.Error(w, "unsupported event", http.StatusBadRequest)
+			return
+		}
+
+		if prEvent, ok := event.(*github.PullRequestEvent); ok {
+			if prEvent.GetAction() == "closed" && recordPullRequestOutcome(prEvent.GetPullRequest()) {
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		issueEvent, ok := event.(*github.IssuesEvent)
+		if !ok || !webhookPollActions[issueEvent.GetAction()] {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		repo := issueEvent.GetRepo()
+		repoClient := hi.findRepository(repo.GetOwner().GetLogin(), repo.GetName())
+		if repoClient == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		fmt.Printf("🪝 Issue #%d %s in %s, polling now\n", issueEvent.GetIssue().GetNumber(), issueEvent.GetAction(), repo.GetFullName())
+		go hi.pollRepositories([]*RepositoryClient{repoClient})
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// findRepository returns the client for an active repository, if we have one
+func (hi *Integration) findRepository(owner, name string) *RepositoryClient {
+	hi.repositoryLock.RLock()
+	defer hi.repositoryLock.RUnlock()
+
+	for _, repoClient := range hi.repositories {
+		if strings.EqualFold(repoClient.Repository.Owner, owner) && strings.EqualFold(repoClient.Repository.Repository, name) {
+			return repoClient
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package github
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	gh "github.com/google/go-github/v57/github"
+)
+
+const testWebhookPayload = `{
+	"action": "labeled",
+	"issue": {"number": 42},
+	"repository": {"name": "widgets", "full_name": "acme/widgets", "owner": {"login": "acme"}}
+}`
+
+func signWebhook(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newWebhookRequest(signature string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/github", strings.NewReader(testWebhookPayload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", "issues")
+	req.Header.Set("X-Hub-Signature-256", signature)
+	return req
+}
+
+func TestWebhookTriggersRepositoryPoll(t *testing.T) {
+	polled := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/acme/widgets/issues" {
+			select {
+			case polled <- struct{}{}:
+			default:
+			}
+		}
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	ghClient := gh.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	hi := &Integration{
+		ctx:        context.Background(),
+		hiveClient: hive.NewHiveClient(server.URL, ""),
+		config:     &IntegrationConfig{AgentID: "agent-a"},
+		repositories: map[int]*RepositoryClient{
+			7: {
+				Client: &Client{
+					client: ghClient,
+					ctx:    context.Background(),
+					config: &Config{Owner: "acme", Repository: "widgets", TaskLabel: "bzzz-task"},
+				},
+				Repository: hive.Repository{ProjectID: 7, Owner: "acme", Repository: "widgets"},
+			},
+		},
+	}
+	handler := hi.WebhookHandler([]byte("s3cret"))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newWebhookRequest(signWebhook("wrong", testWebhookPayload)))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected unsigned webhook to be rejected, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, newWebhookRequest(signWebhook("s3cret", testWebhookPayload)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected webhook to be accepted, got %d", rec.Code)
+	}
+
+	select {
+	case <-polled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("repository was not polled after the webhook")
+	}
+}
@@ -22,6 +22,9 @@ type HypercoreLog struct {
 	
 	// Replication
 	replicators map[peer.ID]*Replicator
+
+	// Called with every entry appended after they subscribed
+	subscribers []func(LogEntry)
 }
 
 // LogEntry represents a single entry in the distributed log
@@ -84,7 +87,6 @@ func NewHypercoreLog(peerID peer.ID) *HypercoreLog {
 // Append adds a new entry to the log
 func (h *HypercoreLog) Append(logType LogType, data map[string]interface{}) (*LogEntry, error) {
 	h.mutex.Lock()
-	defer h.mutex.Unlock()
 	
 	index := uint64(len(h.entries))
 	
@@ -100,6 +102,7 @@ func (h *HypercoreLog) Append(logType LogType, data map[string]interface{}) (*Lo
 	// Calculate hash
 	entryHash, err := h.calculateEntryHash(entry)
 	if err != nil {
+		h.mutex.Unlock()
 		return nil, fmt.Errorf("failed to calculate entry hash: %w", err)
 	}
 	entry.Hash = entryHash
@@ -110,16 +113,35 @@ func (h *HypercoreLog) Append(logType LogType, data map[string]interface{}) (*Lo
 	// Append to log
 	h.entries = append(h.entries, entry)
 	h.headHash = entryHash
+	subscribers := h.subscribers
+	h.mutex.Unlock()
 	
 	fmt.Printf("📝 Log entry appended: %s [%d] by %s\n", 
 		logType, index, h.peerID.ShortString())
 	
 	// Trigger replication to connected peers
 	go h.replicateEntry(entry)
+
+	for _, subscriber := range subscribers {
+		subscriber(entry)
+	}
 	
 	return &entry, nil
 }
 
+// Subscribe calls fn with every entry appended from now on and returns the
+// entries already in the log, so a subscriber sees each entry exactly once.
+// fn runs on the appending goroutine and must not append to the log.
+func (h *HypercoreLog) Subscribe(fn func(LogEntry)) []LogEntry {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.subscribers = append(h.subscribers, fn)
+	existing := make([]LogEntry, len(h.entries))
+	copy(existing, h.entries)
+	return existing
+}
+
 // Get retrieves a log entry by index
 func (h *HypercoreLog) Get(index uint64) (*LogEntry, error) {
 	h.mutex.RLock()
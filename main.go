@@ -11,19 +11,34 @@ import (
 	"os/signal"
 	"path/filepath"
 	"reflect"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/anthonyrawlins/bzzz/discovery"
 	"github.com/anthonyrawlins/bzzz/github"
 	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/monitoring"
 	"github.com/anthonyrawlins/bzzz/p2p"
+	"github.com/anthonyrawlins/bzzz/pkg/audit"
 	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/coordination"
 	"github.com/anthonyrawlins/bzzz/pkg/hive"
+	"github.com/anthonyrawlins/bzzz/pkg/identity"
+	"github.com/anthonyrawlins/bzzz/pkg/shutdown"
+	"github.com/anthonyrawlins/bzzz/pkg/supervisor"
+	"github.com/anthonyrawlins/bzzz/pkg/tracing"
+	"github.com/anthonyrawlins/bzzz/pkg/types"
 	"github.com/anthonyrawlins/bzzz/pubsub"
 	"github.com/anthonyrawlins/bzzz/reasoning"
+	"github.com/anthonyrawlins/bzzz/sandbox"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// version is reported to peers in capability broadcasts and introspection replies
+const version = "0.2.0"
+
 // SimpleTaskTracker tracks active tasks for availability reporting
 type SimpleTaskTracker struct {
 	maxTasks    int
@@ -55,6 +70,10 @@ func (t *SimpleTaskTracker) RemoveTask(taskID string) {
 }
 
 func main() {
+	if exitCode, found := runCLICommand(os.Args[1:]); found {
+		os.Exit(exitCode)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -66,12 +85,15 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Initialize P2P node
-	node, err := p2p.NewNode(ctx)
+	// Initialize P2P node with a persisted identity so the peer ID (and derived agent ID) is stable
+	identityFile := cfg.P2P.IdentityKeyFile
+	if identityFile == "" {
+		identityFile = identity.DefaultPath()
+	}
+	node, err := p2p.NewNode(ctx, p2p.WithIdentityKeyFile(identityFile))
 	if err != nil {
 		log.Fatalf("Failed to create P2P node: %v", err)
 	}
-	defer node.Close()
 
 	// Apply node-specific configuration if agent ID is not set
 	if cfg.Agent.ID == "" {
@@ -101,6 +123,15 @@ func main() {
 		fmt.Printf("   %s/p2p/%s\n", addr, node.ID())
 	}
 
+	// Trace tasks from claim to pull request if an exporter is configured
+	shutdownTracing, err := tracing.Setup(cfg.Tracing, cfg.Agent.ID)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	if cfg.Tracing.Exporter != "" && cfg.Tracing.Exporter != "none" {
+		fmt.Printf("🔭 Tracing tasks to %s\n", cfg.Tracing.Exporter)
+	}
+
 	// Initialize Hypercore-style logger
 	hlog := logging.NewHypercoreLog(node.ID())
 	hlog.Append(logging.PeerJoined, map[string]interface{}{"status": "started"})
@@ -111,18 +142,26 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to create mDNS discovery: %v", err)
 	}
-	defer mdnsDiscovery.Close()
 
 	// Initialize PubSub
-	ps, err := pubsub.NewPubSub(ctx, node.Host(), "bzzz/coordination/v1", "antennae/meta-discussion/v1")
+	ps, err := pubsub.NewPubSub(ctx, node.Host(), cfg.P2P.BzzzTopic, cfg.P2P.AntennaeTopic)
 	if err != nil {
 		log.Fatalf("Failed to create PubSub: %v", err)
 	}
-	defer ps.Close()
+	ps.SetDynamicQueueSize(cfg.P2P.DynamicQueueSize)
+	ps.SetMaxMessageSize(cfg.P2P.MaxMessageSize)
+	ps.SetMessageRateLimit(cfg.P2P.MessageRateLimit, cfg.P2P.MessageBurst)
+	if err := ps.JoinTopics(cfg.P2P.ExtraTopics...); err != nil {
+		log.Fatalf("Failed to join extra topics: %v", err)
+	}
 
 	// === Hive & Dynamic Repository Integration ===
 	// Initialize Hive API client
 	hiveClient := hive.NewHiveClient(cfg.HiveAPI.BaseURL, cfg.HiveAPI.APIKey)
+	if cfg.HiveAPI.StatusBatchInterval > 0 {
+		hiveClient.EnableStatusBatching(cfg.HiveAPI.StatusBatchInterval)
+	}
+	hiveClient.EnableReportRetries(ctx, cfg.HiveAPI.DeadLetterFile)
 	
 	// Test Hive connectivity
 	if err := hiveClient.HealthCheck(ctx); err != nil {
@@ -132,6 +171,9 @@ func main() {
 		fmt.Printf("✅ Hive API connected\n")
 	}
 	
+	// Pre-pull sandbox images so the first task doesn't pay for the pull
+	go prepullSandboxImages(ctx, hiveClient, &cfg.Agent)
+	
 	// Get GitHub token from configuration
 	githubToken, err := cfg.GetGitHubToken()
 	if err != nil {
@@ -140,6 +182,9 @@ func main() {
 		githubToken = ""
 	}
 	
+	// Models must be configured before the integration can claim and run tasks
+	configureReasoning(cfg)
+	
 	// Initialize dynamic GitHub integration
 	var ghIntegration *github.Integration
 	if githubToken != "" {
@@ -154,12 +199,46 @@ func main() {
 			Capabilities: cfg.Agent.Capabilities,
 			PollInterval: cfg.Agent.PollInterval,
 			MaxTasks:     cfg.Agent.MaxTasks,
+			Assignee:     cfg.GitHub.Assignee,
+
+			GitHubBaseURL:   cfg.GitHub.BaseURL,
+			GitHubUploadURL: cfg.GitHub.UploadURL,
+
+			MaxPollInterval: cfg.Agent.MaxPollInterval,
+			MinPriority:     cfg.Agent.MinPriority,
+			MaxPriority:     cfg.Agent.MaxPriority,
+
+			DraftPullRequests:    cfg.GitHub.DraftPullRequests,
+			DeleteFailedBranches: cfg.GitHub.DeleteFailedBranches,
+			SplitOversizedTasks:  cfg.GitHub.SplitOversizedTasks,
+
+			EscalationWebhook:    cfg.P2P.EscalationWebhook,
+			EscalationRoutes:     cfg.P2P.EscalationRoutes,
+			MinCoordinationPeers: cfg.P2P.MinCoordinationPeers,
+			OperatorPeers:        decodePeerIDs(cfg.P2P.OperatorPeers),
+
+			TaskLabel:       cfg.GitHub.TaskLabel,
+			InProgressLabel: cfg.GitHub.InProgressLabel,
+			CompletedLabel:  cfg.GitHub.CompletedLabel,
+			NeedsHumanLabel: cfg.GitHub.NeedsHumanLabel,
+			ApprovalLabel:   cfg.GitHub.ApprovalLabel,
+		}
+		if templates, err := github.ParseTemplates(cfg.GitHub.ClaimComment, cfg.GitHub.PullRequestTitle, cfg.GitHub.PullRequestBody); err != nil {
+			fmt.Printf("⚠️ Using the default GitHub comment templates: %v\n", err)
+		} else {
+			integrationConfig.Templates = templates
+		}
+		if ownerTokens, err := cfg.GetOwnerGitHubTokens(); err != nil {
+			fmt.Printf("⚠️ Per-owner GitHub tokens not available, using the default token: %v\n", err)
+		} else {
+			integrationConfig.OwnerTokens = ownerTokens
 		}
 		
 		ghIntegration = github.NewIntegration(ctx, hiveClient, githubToken, ps, hlog, integrationConfig, &cfg.Agent)
 		
 		// Start the integration service
 		ghIntegration.Start()
+		ps.SetReputationStore(ghIntegration.Reputation())
 		fmt.Printf("✅ Dynamic repository integration active\n")
 	} else {
 		fmt.Printf("🔧 Repository integration skipped - no GitHub token\n")
@@ -173,37 +252,184 @@ func main() {
 		activeTasks: make(map[string]bool),
 	}
 
+	// Learn existing peers' capabilities now rather than waiting for them to rebroadcast.
+	// Availability broadcasts carry these capabilities too, so set them up first.
+	if err := ps.StartCapabilityExchange(localCapabilities(node.ID().ShortString(), cfg)); err != nil {
+		fmt.Printf("⚠️ Failed to start capability exchange: %v\n", err)
+	}
+
+	// Let trusted peers ask about this agent's state when debugging the mesh
+	ps.StartIntrospection(func() pubsub.Introspection {
+		return describeAgent(node.ID().ShortString(), cfg, ghIntegration)
+	}, decodePeerIDs(cfg.P2P.IntrospectionPeers))
+
+	// Coordination loops are stopped, and waited for, before pubsub closes under them
+	coordinationLoops := supervisor.NewGroup(ctx)
+	coordinationCtx := coordinationLoops.Context()
+
+	// Coordinate work across repositories, and keep campaigns moving as this
+	// agent claims and finishes their tasks
+	coordinator := coordination.NewMetaCoordinator(coordinationCtx, ps)
+	coordinator.SetSessionLimits(cfg.Coordination.SessionLimits)
+	if err := coordinator.SetPlanPrompt(cfg.Coordination.PlanPrompt); err != nil {
+		fmt.Printf("⚠️ Using the built-in coordination plan prompt: %v\n", err)
+	}
+	coordinator.SetMinPeers(cfg.P2P.MinCoordinationPeers)
+	coordinator.SetCampaignFile(getCampaignsFile(cfg.Agent.ID))
+	coordinator.FollowTaskLog(hlog)
+	if ghIntegration != nil {
+		coordinator.SetReputationStore(ghIntegration.Reputation())
+		ghIntegration.SetWaitListener(func(task *types.EnhancedTask, blocking types.TaskDependency, blockingProjectID int) {
+			coordinator.TaskWaiting(cfg.Agent.ID,
+				&coordination.TaskContext{ProjectID: task.ProjectID, TaskID: task.Number, Repository: task.RepositoryName(), Title: task.Title},
+				&coordination.TaskContext{ProjectID: blockingProjectID, TaskID: blocking.Number, Repository: blocking.Repository})
+		})
+	}
+
+	// Follow coordination sessions for the bzzz_coordination_* gauges on /metrics,
+	// carrying their totals over from earlier runs
+	antennaeMonitor, err := monitoring.NewAntennaeMonitor(coordinationCtx, ps, getAntennaeLogDir(cfg.Agent.ID))
+	if err != nil {
+		fmt.Printf("⚠️ Coordination metrics disabled: %v\n", err)
+	} else {
+		antennaeMonitor.Start()
+	}
+
 	// Announce capabilities
-	go announceAvailability(ps, node.ID().ShortString(), taskTracker)
-	go announceCapabilitiesOnChange(ps, node.ID().ShortString(), cfg)
+	paused := func() bool { return ghIntegration != nil && ghIntegration.Paused() }
+	coordinationLoops.Go("availability announcements", func(ctx context.Context) { announceAvailability(ctx, ps, node.ID().ShortString(), taskTracker, paused) })
+	coordinationLoops.Go("capability announcements", func(ctx context.Context) { announceCapabilitiesOnChange(ctx, ps, node.ID().ShortString(), cfg) })
 
 	// Start status reporting
-	go statusReporter(node)
+	coordinationLoops.Go("status reporter", func(ctx context.Context) { statusReporter(ctx, node) })
+
+	// Broadcast periodic activity rollups for cluster-wide dashboards
+	var telemetry *monitoring.TelemetryCollector
+	if cfg.P2P.TelemetryInterval > 0 {
+		telemetry = monitoring.NewTelemetryCollector()
+		ps.AddAntennaeMessageHandler(telemetry.HandleMessage)
+		telemetryReporter := monitoring.NewTelemetryReporter(ps, cfg.Agent.ID, hlog, cfg.P2P.TelemetryInterval, reasoning.ModelUsage)
+		telemetryReporter.SetCollector(telemetry)
+		coordinationLoops.Go("telemetry", telemetryReporter.Run)
+	}
 
 	fmt.Printf("🔍 Listening for peers on local network...\n")
 	fmt.Printf("📡 Ready for task coordination and meta-discussion\n")
 	fmt.Printf("🎯 Antennae collaborative reasoning enabled\n")
 
-	// Handle graceful shutdown
+	// Start the local HTTP API
+	apiMux := http.NewServeMux()
+	apiMux.Handle("/metrics", promhttp.Handler())
+	if ghIntegration != nil && cfg.GitHub.WebhookSecret != "" {
+		apiMux.Handle("/webhooks/github", ghIntegration.WebhookHandler([]byte(cfg.GitHub.WebhookSecret)))
+		fmt.Printf("🪝 GitHub webhooks accepted at /webhooks/github\n")
+	}
+	if ghIntegration != nil && cfg.API.EscalationToken != "" {
+		apiMux.Handle("/escalations/response", ghIntegration.EscalationResponseHandler([]byte(cfg.API.EscalationToken)))
+		fmt.Printf("🙋 Escalation replies accepted at /escalations/response\n")
+	}
+	if ghIntegration != nil && cfg.API.ControlToken != "" {
+		apiMux.Handle("/tasks", ghIntegration.TaskControlHandler([]byte(cfg.API.ControlToken)))
+		apiMux.Handle("/tasks/", ghIntegration.TaskControlHandler([]byte(cfg.API.ControlToken)))
+		apiMux.Handle("/agent", ghIntegration.AgentControlHandler([]byte(cfg.API.ControlToken)))
+		apiMux.Handle("/agent/", ghIntegration.AgentControlHandler([]byte(cfg.API.ControlToken)))
+		fmt.Printf("🎛️ Running tasks can be listed and cancelled at /tasks, and the agent paused at /agent\n")
+	}
+	if cfg.API.ControlToken != "" {
+		apiMux.Handle("/audit", audit.NewRecorder(hlog).Handler([]byte(cfg.API.ControlToken)))
+		fmt.Printf("🧾 Task audit trails exported at /audit\n")
+		apiMux.Handle("/campaigns", coordinator.CampaignHandler([]byte(cfg.API.ControlToken)))
+		fmt.Printf("🏁 Campaigns can be started and followed at /campaigns\n")
+	}
+	if telemetry != nil && cfg.API.ControlToken != "" {
+		apiMux.Handle("/telemetry", telemetry.Handler([]byte(cfg.API.ControlToken)))
+		fmt.Printf("📊 Cluster telemetry served at /telemetry\n")
+	}
+	if cfg.API.ListenAddr != "" {
+		go func() {
+			fmt.Printf("🌐 HTTP API listening on %s\n", cfg.API.ListenAddr)
+			if err := http.ListenAndServe(cfg.API.ListenAddr, apiMux); err != nil {
+				fmt.Printf("❌ HTTP API stopped: %v\n", err)
+			}
+		}()
+	}
+
+	// Handle graceful shutdown, and capability reloads on SIGHUP
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-	<-c
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	var idle <-chan struct{} // Never fires unless agent.idle_shutdown is set
+	if ghIntegration != nil {
+		idle = ghIntegration.Idle()
+	}
+waitForShutdown:
+	for {
+		select {
+		case <-c:
+			break waitForShutdown
+		case <-idle:
+			break waitForShutdown
+		case <-reload:
+			reloadCapabilities(cfg, ps, node.ID().ShortString(), ghIntegration)
+		}
+	}
 
 	fmt.Println("\n🛑 Shutting down Bzzz node...")
+	shutdown.Run([]shutdown.Step{
+		{Name: "task polling", Stop: func(ctx context.Context) error {
+			if ghIntegration != nil {
+				ghIntegration.StopPolling()
+			}
+			return nil
+		}},
+		{Name: "in-flight tasks", Timeout: time.Minute, Stop: func(ctx context.Context) error {
+			if ghIntegration == nil {
+				return nil
+			}
+			return ghIntegration.ReleaseTasks(ctx)
+		}},
+		{Name: "Hive reports", Stop: func(ctx context.Context) error {
+			if err := hiveClient.FlushStatusUpdates(ctx); err != nil {
+				fmt.Printf("⚠️ Failed to flush Hive status updates: %v\n", err)
+			}
+			hiveClient.StopReportRetries(ctx)
+			return nil
+		}},
+		{Name: "coordination", Stop: coordinationLoops.Stop},
+		{Name: "antennae monitor", Stop: func(ctx context.Context) error {
+			if antennaeMonitor != nil {
+				antennaeMonitor.Stop()
+			}
+			return nil
+		}},
+		{Name: "pubsub", Stop: func(ctx context.Context) error { return ps.Close() }},
+		{Name: "mDNS discovery", Stop: func(ctx context.Context) error { return mdnsDiscovery.Close() }},
+		{Name: "P2P node", Stop: func(ctx context.Context) error { return node.Close() }},
+		{Name: "tracing", Stop: shutdownTracing},
+	})
 }
 
-// announceAvailability broadcasts current working status for task assignment
-func announceAvailability(ps *pubsub.PubSub, nodeID string, taskTracker *SimpleTaskTracker) {
+// announceAvailability broadcasts current working status for task assignment until ctx is done
+func announceAvailability(ctx context.Context, ps *pubsub.PubSub, nodeID string, taskTracker *SimpleTaskTracker, paused func() bool) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
-	for ; ; <-ticker.C {
+	for {
 		currentTasks := taskTracker.GetActiveTasks()
 		maxTasks := taskTracker.GetMaxTasks()
 		isAvailable := len(currentTasks) < maxTasks
 		
+		// Tasks waiting for a sandbox slot mean the host is already full
+		queued := sandbox.Queued()
+
 		status := "ready"
-		if len(currentTasks) >= maxTasks {
+		if paused() {
+			// Finishing current work but not taking more
+			isAvailable = false
+			status = "paused"
+		} else if len(currentTasks) >= maxTasks || queued > 0 {
+			isAvailable = false
 			status = "busy"
 		} else if len(currentTasks) > 0 {
 			status = "working"
@@ -214,13 +440,21 @@ func announceAvailability(ps *pubsub.PubSub, nodeID string, taskTracker *SimpleT
 			"available_for_work": isAvailable,
 			"current_tasks":     len(currentTasks),
 			"max_tasks":         maxTasks,
+			"queued_tasks":      queued,
 			"last_activity":     time.Now().Unix(),
 			"status":            status,
+			"paused":            paused(),
 			"timestamp":         time.Now().Unix(),
 		}
-		if err := ps.PublishBzzzMessage(pubsub.AvailabilityBcast, availability); err != nil {
+		if err := ps.PublishAvailability(availability); err != nil {
 			fmt.Printf("❌ Failed to announce availability: %v\n", err)
 		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 	}
 }
 
@@ -307,13 +541,29 @@ func selectBestModel(webhookURL string, availableModels []string, prompt string)
 	return availableModels[0], nil
 }
 
-// announceCapabilitiesOnChange broadcasts capabilities only when they change
-func announceCapabilitiesOnChange(ps *pubsub.PubSub, nodeID string, cfg *config.Config) {
+// configureReasoning detects the models Ollama has and sets up the reasoning
+// module. It must finish before any task can run, so it isn't run in the background.
+func configureReasoning(cfg *config.Config) {
+	if cache := cfg.Reasoning.Cache; cache.TTL > 0 && cache.MaxEntries > 0 {
+		reasoning.EnableCache(cache.TTL, cache.MaxEntries)
+		fmt.Printf("🗃️ Caching up to %d reasoning responses for %v\n", cache.MaxEntries, cache.TTL)
+	}
+
+	reasoning.SetDefaultTimeout(cfg.Agent.ReasoningTimeout.Default)
+	if load := cfg.Reasoning.Load; load.DowngradeAbove > 0 {
+		reasoning.EnableLoadAwareSelection(load.DowngradeAbove, load.RestoreBelow, nil)
+		fmt.Printf("🌡️ Downgrading to lighter models above %.0f%% host load\n", load.DowngradeAbove*100)
+	}
+
+	// Prefer the models whose work has been merged, by task type, across restarts
+	reasoning.EnableOutcomeTracking(getModelOutcomesFile(cfg.Agent.ID))
+
 	// Detect available Ollama models and update config
 	availableModels, err := detectAvailableOllamaModels()
 	if err != nil {
 		fmt.Printf("⚠️ Failed to detect Ollama models: %v\n", err)
 		fmt.Printf("🔄 Using configured models: %v\n", cfg.Agent.Models)
+		reasoning.SetModelConfig(cfg.Agent.Models, cfg.Agent.ModelSelectionWebhook, cfg.Agent.DefaultReasoningModel)
 	} else {
 		// Filter configured models to only include available ones
 		validModels := make([]string, 0)
@@ -340,16 +590,123 @@ func announceCapabilitiesOnChange(ps *pubsub.PubSub, nodeID string, cfg *config.
 		
 		// Configure reasoning module with available models and webhook
 		reasoning.SetModelConfig(validModels, cfg.Agent.ModelSelectionWebhook, cfg.Agent.DefaultReasoningModel)
+
+		// Only review with a second model if Ollama actually has it
+		if reviewer := cfg.Reasoning.ReviewerModel; reviewer != "" {
+			reviewerAvailable := false
+			for _, model := range validModels {
+				if model == reviewer {
+					reviewerAvailable = true
+					break
+				}
+			}
+			if reviewerAvailable {
+				reasoning.SetReviewerModel(reviewer)
+				fmt.Printf("🧐 Changes will be reviewed by %s before opening PRs\n", reviewer)
+			} else {
+				fmt.Printf("⚠️ Reviewer model %s is not available, skipping review\n", reviewer)
+			}
+		}
+
+		// Pre-load the models so the first task doesn't time out waiting for them
+		if cfg.Agent.WarmUpModels {
+			go reasoning.WarmUpModels(context.Background(), validModels)
+		}
 	}
+}
 
-	// Get current capabilities
-	currentCaps := map[string]interface{}{
+// reloadCapabilities re-reads the agent's capabilities from configuration and
+// applies them to the running node
+func reloadCapabilities(cfg *config.Config, ps *pubsub.PubSub, nodeID string, ghIntegration *github.Integration) {
+	reloaded, err := config.LoadConfig("")
+	if err != nil {
+		fmt.Printf("❌ Failed to reload configuration: %v\n", err)
+		return
+	}
+	if len(reloaded.Agent.Capabilities) == 0 {
+		fmt.Printf("⚠️ Reloaded configuration has no capabilities, keeping %v\n", agentCapabilities(cfg))
+		return
+	}
+
+	capabilitiesLock.Lock()
+	cfg.Agent.Capabilities = reloaded.Agent.Capabilities
+	capabilitiesLock.Unlock()
+	ps.SetLocalCapabilities(localCapabilities(nodeID, cfg))
+	announceCapabilitiesOnChange(context.Background(), ps, nodeID, cfg)
+	if ghIntegration != nil {
+		ghIntegration.SetCapabilities(reloaded.Agent.Capabilities)
+	}
+}
+
+// capabilitiesLock guards cfg.Agent.Capabilities, which reloadCapabilities
+// replaces while the announcement loops and introspection read it
+var capabilitiesLock sync.RWMutex
+
+// agentCapabilities returns the agent's current capabilities
+func agentCapabilities(cfg *config.Config) []string {
+	capabilitiesLock.RLock()
+	defer capabilitiesLock.RUnlock()
+	return cfg.Agent.Capabilities
+}
+
+// decodePeerIDs parses configured peer IDs; checkConfig has already rejected bad ones
+func decodePeerIDs(ids []string) []peer.ID {
+	decoded := make([]peer.ID, 0, len(ids))
+	for _, id := range ids {
+		if parsed, err := peer.Decode(id); err == nil {
+			decoded = append(decoded, parsed)
+		}
+	}
+	return decoded
+}
+
+// localCapabilities describes this node's capabilities for broadcasts and query replies
+func localCapabilities(nodeID string, cfg *config.Config) map[string]interface{} {
+	return map[string]interface{}{
 		"node_id":      nodeID,
-		"capabilities": cfg.Agent.Capabilities,
+		"capabilities": agentCapabilities(cfg),
 		"models":       cfg.Agent.Models,
-		"version":      "0.2.0",
+		"version":      version,
 		"specialization": cfg.Agent.Specialization,
 	}
+}
+
+// describeAgent reports this agent's state to peers introspecting it. Only
+// settings that are safe to share are included.
+func describeAgent(nodeID string, cfg *config.Config, ghIntegration *github.Integration) pubsub.Introspection {
+	introspection := pubsub.Introspection{
+		NodeID:         nodeID,
+		AgentID:        cfg.Agent.ID,
+		Version:        version,
+		Capabilities:   agentCapabilities(cfg),
+		Models:         cfg.Agent.Models,
+		Specialization: cfg.Agent.Specialization,
+		MaxTasks:       cfg.Agent.MaxTasks,
+		EffectiveModel: reasoning.CurrentLoadStatus().EffectiveModel,
+		Config: map[string]interface{}{
+			"poll_interval":           cfg.Agent.PollInterval.String(),
+			"sandbox_image":           cfg.Agent.SandboxImage,
+			"default_reasoning_model": cfg.Agent.DefaultReasoningModel,
+			"min_priority":            cfg.Agent.MinPriority,
+			"max_priority":            cfg.Agent.MaxPriority,
+			"min_coordination_peers":  cfg.P2P.MinCoordinationPeers,
+			"extra_topics":            cfg.P2P.ExtraTopics,
+		},
+	}
+	if ghIntegration != nil {
+		introspection.Paused = ghIntegration.Paused()
+		for _, task := range ghIntegration.RunningTasks() {
+			introspection.Tasks = append(introspection.Tasks, task.ID+" "+task.Title)
+		}
+		introspection.ActiveTasks = len(introspection.Tasks)
+	}
+	return introspection
+}
+
+// announceCapabilitiesOnChange broadcasts capabilities only when they change
+func announceCapabilitiesOnChange(ctx context.Context, ps *pubsub.PubSub, nodeID string, cfg *config.Config) {
+	// Get current capabilities
+	currentCaps := localCapabilities(nodeID, cfg)
 
 	// Load stored capabilities from file
 	storedCaps, err := loadStoredCapabilities(nodeID)
@@ -358,7 +715,10 @@ func announceCapabilitiesOnChange(ps *pubsub.PubSub, nodeID string, cfg *config.
 		storedCaps = nil
 	}
 
-	// Check if capabilities have changed
+	// Check if capabilities have changed, unless shutdown began in the meantime
+	if ctx.Err() != nil {
+		return
+	}
 	if capabilitiesChanged(currentCaps, storedCaps) {
 		fmt.Printf("🔄 Capabilities changed, broadcasting update\n")
 		
@@ -379,14 +739,54 @@ func announceCapabilitiesOnChange(ps *pubsub.PubSub, nodeID string, cfg *config.
 	}
 }
 
-// statusReporter provides periodic status updates
-func statusReporter(node *p2p.Node) {
+// prepullSandboxImages pulls the default sandbox image and any per-repository images
+func prepullSandboxImages(ctx context.Context, hiveClient *hive.HiveClient, agentConfig *config.AgentConfig) {
+	images := []string{agentConfig.SandboxImage}
+	if repos, err := hiveClient.GetActiveRepositories(ctx); err == nil {
+		for _, repo := range repos {
+			if repo.SandboxImage != "" && !containsImage(images, repo.SandboxImage) {
+				images = append(images, repo.SandboxImage)
+			}
+		}
+	}
+
+	for _, image := range images {
+		if image == "" {
+			continue
+		}
+		if err := sandbox.ImagePull(ctx, image, agentConfig.Sandbox.Platform); err != nil {
+			fmt.Printf("⚠️ Failed to pre-pull sandbox image %s: %v\n", image, err)
+		}
+	}
+}
+
+// containsImage reports whether an image reference is already in the list
+func containsImage(images []string, image string) bool {
+	for _, existing := range images {
+		if existing == image {
+			return true
+		}
+	}
+	return false
+}
+
+// statusReporter provides periodic status updates until ctx is done
+func statusReporter(ctx context.Context, node *p2p.Node) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
-	for ; ; <-ticker.C {
+	for {
 		peers := node.ConnectedPeers()
 		fmt.Printf("📊 Status: %d connected peers\n", peers)
+		if load := reasoning.CurrentLoadStatus(); load.Downgraded {
+			fmt.Printf("🌡️ Host at %.0f%% load, using %s as the default model\n", load.Load*100, load.EffectiveModel)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 	}
 }
 
@@ -396,6 +796,25 @@ func getCapabilitiesFile(nodeID string) string {
 	return filepath.Join(homeDir, ".config", "bzzz", fmt.Sprintf("capabilities-%s.json", nodeID))
 }
 
+// getModelOutcomesFile returns the path to store how each model's tasks turned out
+func getModelOutcomesFile(agentID string) string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config", "bzzz", fmt.Sprintf("model-outcomes-%s.json", agentID))
+}
+
+// getCampaignsFile returns the path to store the campaigns this agent follows
+func getCampaignsFile(agentID string) string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config", "bzzz", fmt.Sprintf("campaigns-%s.json", agentID))
+}
+
+// getAntennaeLogDir returns where the antennae monitor keeps its activity log and
+// metrics snapshots for an agent
+func getAntennaeLogDir(agentID string) string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config", "bzzz", fmt.Sprintf("antennae-%s", agentID))
+}
+
 // loadStoredCapabilities loads previously stored capabilities from disk
 func loadStoredCapabilities(nodeID string) (map[string]interface{}, error) {
 	capFile := getCapabilitiesFile(nodeID)
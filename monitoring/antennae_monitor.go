@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
 )
 
 // AntennaeMonitor tracks and logs antennae coordination activity
@@ -19,7 +20,9 @@ type AntennaeMonitor struct {
 	logFile        *os.File
 	metricsFile    *os.File
 	activeSessions map[string]*CoordinationSession
-	metrics        *CoordinationMetrics
+	metrics        *CoordinationMetrics // This run only
+	carried        *CoordinationMetrics // Restored from earlier runs' snapshot
+	snapshotPath   string
 	mu             sync.RWMutex
 	isRunning      bool
 }
@@ -105,37 +108,51 @@ func NewAntennaeMonitor(ctx context.Context, ps *pubsub.PubSub, logDir string) (
 			StartTime:            time.Now(),
 			AgentParticipations: make(map[string]int),
 		},
+		snapshotPath:   filepath.Join(logDir, snapshotFileName),
 	}
+	monitor.carried = restoreSnapshot(monitor.snapshotPath)
 
 	fmt.Printf("📊 Antennae Monitor initialized\n")
 	fmt.Printf("   Activity Log: %s\n", logPath)
 	fmt.Printf("   Metrics File: %s\n", metricsPath)
+	if monitor.carried.TotalSessions > 0 {
+		fmt.Printf("   Carrying forward %d sessions from earlier runs\n", monitor.carried.TotalSessions)
+	}
 
 	return monitor, nil
 }
 
 // Start begins monitoring antennae coordination activity
 func (am *AntennaeMonitor) Start() {
+	am.mu.Lock()
 	if am.isRunning {
+		am.mu.Unlock()
 		return
 	}
 	am.isRunning = true
+	am.mu.Unlock()
 
 	fmt.Println("🔍 Starting Antennae coordination monitoring...")
 
+	if am.pubsub != nil {
+		am.pubsub.AddAntennaeMessageHandler(am.handleAntennaeMessage)
+		am.pubsub.AddBzzzMessageHandler(am.handleBzzzMessage)
+	}
+
 	// Start monitoring routines
-	go am.monitorCoordinationMessages()
-	go am.monitorTaskAnnouncements()
 	go am.periodicMetricsUpdate()
 	go am.sessionCleanup()
 }
 
 // Stop stops the monitoring system
 func (am *AntennaeMonitor) Stop() {
+	am.mu.Lock()
 	if !am.isRunning {
+		am.mu.Unlock()
 		return
 	}
 	am.isRunning = false
+	am.mu.Unlock()
 
 	// Save final metrics
 	am.saveMetrics()
@@ -151,52 +168,42 @@ func (am *AntennaeMonitor) Stop() {
 	fmt.Println("🛑 Antennae monitoring stopped")
 }
 
-// monitorCoordinationMessages listens for antennae meta-discussion messages
-func (am *AntennaeMonitor) monitorCoordinationMessages() {
-	// Subscribe to antennae topic
-	msgChan := make(chan pubsub.Message, 100)
-	
-	// This would be implemented with actual pubsub subscription
-	// For now, we'll simulate receiving messages
-	
-	for am.isRunning {
-		select {
-		case <-am.ctx.Done():
-			return
-		case msg := <-msgChan:
-			am.processCoordinationMessage(msg)
-		case <-time.After(1 * time.Second):
-			// Continue monitoring
-		}
+// running reports whether the monitor is between Start and Stop
+func (am *AntennaeMonitor) running() bool {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	return am.isRunning
+}
+
+// handleAntennaeMessage records antennae meta-discussion messages; telemetry
+// shares the handler through its dynamic topic and isn't coordination
+func (am *AntennaeMonitor) handleAntennaeMessage(msg pubsub.Message, from peer.ID) {
+	if msg.Type == pubsub.TelemetryReport {
+		return
 	}
+	am.processCoordinationMessage(msg)
 }
 
-// monitorTaskAnnouncements listens for task announcements
-func (am *AntennaeMonitor) monitorTaskAnnouncements() {
-	// Subscribe to bzzz coordination topic
-	msgChan := make(chan pubsub.Message, 100)
-	
-	for am.isRunning {
-		select {
-		case <-am.ctx.Done():
-			return
-		case msg := <-msgChan:
-			am.processTaskAnnouncement(msg)
-		case <-time.After(1 * time.Second):
-			// Continue monitoring
-		}
+// handleBzzzMessage records task announcements from the Bzzz topic
+func (am *AntennaeMonitor) handleBzzzMessage(msg pubsub.Message, from peer.ID) {
+	if msg.Type != pubsub.TaskAnnouncement {
+		return
 	}
+	am.processTaskAnnouncement(msg)
 }
 
 // processCoordinationMessage processes an antennae coordination message
 func (am *AntennaeMonitor) processCoordinationMessage(msg pubsub.Message) {
 	am.mu.Lock()
 	defer am.mu.Unlock()
+	if !am.isRunning {
+		return // Handlers stay registered after Stop; the log file is closed
+	}
 
 	coordMsg := CoordinationMessage{
 		Timestamp:   time.Now(),
 		FromAgent:   msg.From,
-		MessageType: msg.Type,
+		MessageType: string(msg.Type),
 		Content:     msg.Data,
 		Topic:       "antennae/meta-discussion",
 	}
@@ -232,6 +239,9 @@ func (am *AntennaeMonitor) processCoordinationMessage(msg pubsub.Message) {
 func (am *AntennaeMonitor) processTaskAnnouncement(msg pubsub.Message) {
 	am.mu.Lock()
 	defer am.mu.Unlock()
+	if !am.isRunning {
+		return
+	}
 
 	// Log the announcement
 	am.logActivity("task_announcement", msg.Data)
@@ -310,7 +320,7 @@ func (am *AntennaeMonitor) periodicMetricsUpdate() {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
-	for am.isRunning {
+	for am.running() {
 		select {
 		case <-am.ctx.Done():
 			return
@@ -326,7 +336,7 @@ func (am *AntennaeMonitor) sessionCleanup() {
 	ticker := time.NewTicker(5 * time.Minute)
 	defer ticker.Stop()
 
-	for am.isRunning {
+	for am.running() {
 		select {
 		case <-am.ctx.Done():
 			return
@@ -373,10 +383,10 @@ func (am *AntennaeMonitor) logActivity(activityType string, data interface{}) {
 	}
 }
 
-// saveMetrics saves current metrics to file
+// saveMetrics saves current metrics to file and snapshots the cumulative ones
 func (am *AntennaeMonitor) saveMetrics() {
-	am.mu.RLock()
-	defer am.mu.RUnlock()
+	am.mu.Lock()
+	defer am.mu.Unlock()
 
 	am.metrics.LastUpdated = time.Now()
 	
@@ -403,6 +413,7 @@ func (am *AntennaeMonitor) saveMetrics() {
 		am.metricsFile.Write(jsonBytes)
 		am.metricsFile.Sync()
 	}
+	am.saveSnapshot()
 }
 
 // printStatus prints current monitoring status
@@ -0,0 +1,114 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// maxObservedValueLen truncates long payload values so each message fits on a line
+const maxObservedValueLen = 80
+
+// Observer pretty-prints mesh traffic for an operator. It only reads messages;
+// it never claims tasks or publishes anything.
+type Observer struct {
+	out   io.Writer
+	types map[string]bool // Message types to print; empty prints everything
+	mu    sync.Mutex
+}
+
+// NewObserver creates an observer printing to out. types filters on the message
+// type or, for Antennae messages, the coordination message_type.
+func NewObserver(out io.Writer, types []string) *Observer {
+	o := &Observer{
+		out:   out,
+		types: make(map[string]bool),
+	}
+	for _, t := range types {
+		if t = strings.TrimSpace(t); t != "" {
+			o.types[t] = true
+		}
+	}
+	return o
+}
+
+// HandleMessage prints a message if it passes the type filter; it can be used
+// as the PubSub Bzzz and Antennae message handler.
+func (o *Observer) HandleMessage(msg pubsub.Message, from peer.ID) {
+	coordinationType, _ := msg.Data["message_type"].(string)
+	if len(o.types) > 0 && !o.types[string(msg.Type)] && !o.types[coordinationType] {
+		return
+	}
+
+	line := o.Format(msg, from)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	fmt.Fprintln(o.out, line)
+}
+
+// Format renders a message as a single line: time, type, sender and payload fields
+func (o *Observer) Format(msg pubsub.Message, from peer.ID) string {
+	timestamp := msg.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	kind := string(msg.Type)
+	if coordinationType, ok := msg.Data["message_type"].(string); ok && coordinationType != kind {
+		kind = fmt.Sprintf("%s/%s", kind, coordinationType)
+	}
+
+	sender := from.ShortString()
+	if agentID, ok := msg.Data["agent_id"].(string); ok && agentID != "" {
+		sender = fmt.Sprintf("%s (%s)", sender, agentID)
+	}
+
+	keys := make([]string, 0, len(msg.Data))
+	for key := range msg.Data {
+		if key != "message_type" && key != "agent_id" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	fields := make([]string, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, fmt.Sprintf("%s=%s", key, formatObservedValue(msg.Data[key])))
+	}
+
+	return fmt.Sprintf("[%s] %-40s %s %s", timestamp.Format("15:04:05"), kind, sender, strings.Join(fields, " "))
+}
+
+// formatObservedValue renders a payload value compactly
+func formatObservedValue(value interface{}) string {
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case float64, int, int64, bool, nil:
+		text = fmt.Sprintf("%v", v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			text = fmt.Sprintf("%v", v)
+		} else {
+			text = string(data)
+		}
+	}
+
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > maxObservedValueLen {
+		text = string(runes[:maxObservedValueLen-3]) + "..."
+	}
+	if strings.ContainsAny(text, " =") {
+		text = fmt.Sprintf("%q", text)
+	}
+	return text
+}
@@ -0,0 +1,57 @@
+package monitoring
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestObserverPrintsCoordinationMessage(t *testing.T) {
+	published := pubsub.Message{
+		Type:      pubsub.MetaDiscussion,
+		From:      "peer-a",
+		Timestamp: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+		Data: map[string]interface{}{
+			"message_type": "coordination_response",
+			"session_id":   "dep_1_2_3",
+			"agent_id":     "agent-a",
+			"response":     "I agree, ship the API change first",
+		},
+	}
+
+	// Decode the message as it arrives off the wire
+	wire, err := json.Marshal(published)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received pubsub.Message
+	if err := json.Unmarshal(wire, &received); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	observer := NewObserver(&out, []string{"coordination_response"})
+	observer.HandleMessage(received, peer.ID("peer-a"))
+	observer.HandleMessage(pubsub.Message{Type: pubsub.AvailabilityBcast, Data: map[string]interface{}{"status": "ready"}}, peer.ID("peer-b"))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected only the coordination message to be printed, got:\n%s", out.String())
+	}
+	for _, want := range []string{
+		"[15:04:05]",
+		"meta_discussion/coordination_response",
+		"(agent-a)",
+		"session_id=dep_1_2_3",
+		`response="I agree, ship the API change first"`,
+	} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("expected %q in %q", want, lines[0])
+		}
+	}
+}
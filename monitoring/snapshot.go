@@ -0,0 +1,150 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// snapshotFileName is where the monitor keeps its cumulative metrics, in its log
+// directory. The snapshot before it is kept alongside with a ".1" suffix.
+const snapshotFileName = "antennae_metrics_snapshot.json"
+
+var (
+	coordinationSessions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bzzz_coordination_sessions",
+		Help: "Antennae coordination sessions by state, for this run and across restarts.",
+	}, []string{"scope", "state"})
+	coordinationMessages = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bzzz_coordination_messages",
+		Help: "Antennae coordination messages seen, for this run and across restarts.",
+	}, []string{"scope"})
+	coordinationAnnouncements = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bzzz_coordination_task_announcements",
+		Help: "Task announcements seen, for this run and across restarts.",
+	}, []string{"scope"})
+	coordinationDependencies = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bzzz_coordination_dependencies_detected",
+		Help: "Task dependencies detected, for this run and across restarts.",
+	}, []string{"scope"})
+)
+
+func init() {
+	prometheus.MustRegister(coordinationSessions, coordinationMessages, coordinationAnnouncements, coordinationDependencies)
+}
+
+// metricsSnapshot is what the snapshot file holds
+type metricsSnapshot struct {
+	Cumulative CoordinationMetrics `json:"cumulative"` // Every run so far, this one included
+	Run        CoordinationMetrics `json:"run"`        // The run that wrote the snapshot
+	SavedAt    time.Time           `json:"saved_at"`
+}
+
+// restoreSnapshot loads the cumulative metrics of earlier runs from path, falling
+// back to the previous snapshot if the latest is unreadable. Without either,
+// counting starts from zero.
+func restoreSnapshot(path string) *CoordinationMetrics {
+	for _, candidate := range []string{path, path + ".1"} {
+		data, err := os.ReadFile(candidate)
+		if err != nil {
+			continue
+		}
+		var snapshot metricsSnapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			fmt.Printf("⚠️ Ignoring unreadable metrics snapshot %s: %v\n", candidate, err)
+			continue
+		}
+		carried := snapshot.Cumulative
+		carried.ActiveSessions = 0 // Sessions don't survive a restart
+		if carried.AgentParticipations == nil {
+			carried.AgentParticipations = make(map[string]int)
+		}
+		return &carried
+	}
+	return &CoordinationMetrics{AgentParticipations: make(map[string]int)}
+}
+
+// combineMetrics adds a run's counters to those carried over from earlier runs
+func combineMetrics(carried, run *CoordinationMetrics) CoordinationMetrics {
+	combined := CoordinationMetrics{
+		StartTime:              carried.StartTime,
+		TotalSessions:          carried.TotalSessions + run.TotalSessions,
+		ActiveSessions:         run.ActiveSessions,
+		CompletedSessions:      carried.CompletedSessions + run.CompletedSessions,
+		EscalatedSessions:      carried.EscalatedSessions + run.EscalatedSessions,
+		FailedSessions:         carried.FailedSessions + run.FailedSessions,
+		TotalMessages:          carried.TotalMessages + run.TotalMessages,
+		TaskAnnouncements:      carried.TaskAnnouncements + run.TaskAnnouncements,
+		DependenciesDetected:   carried.DependenciesDetected + run.DependenciesDetected,
+		AgentParticipations:    make(map[string]int),
+		AverageSessionDuration: run.AverageSessionDuration,
+		LastUpdated:            run.LastUpdated,
+	}
+	if combined.StartTime.IsZero() {
+		combined.StartTime = run.StartTime
+	}
+	for agent, count := range carried.AgentParticipations {
+		combined.AgentParticipations[agent] += count
+	}
+	for agent, count := range run.AgentParticipations {
+		combined.AgentParticipations[agent] += count
+	}
+	return combined
+}
+
+// saveSnapshot writes the cumulative and per-run metrics to the snapshot file,
+// keeping the one it replaces; callers must hold the lock
+func (am *AntennaeMonitor) saveSnapshot() {
+	if am.snapshotPath == "" {
+		return
+	}
+	snapshot := metricsSnapshot{
+		Cumulative: combineMetrics(am.carried, am.metrics),
+		Run:        *am.metrics,
+		SavedAt:    time.Now(),
+	}
+	exportMetrics("run", &snapshot.Run)
+	exportMetrics("cumulative", &snapshot.Cumulative)
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		fmt.Printf("⚠️ Failed to snapshot metrics: %v\n", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(am.snapshotPath), 0755); err != nil {
+		fmt.Printf("⚠️ Failed to snapshot metrics: %v\n", err)
+		return
+	}
+	if _, err := os.Stat(am.snapshotPath); err == nil {
+		if err := os.Rename(am.snapshotPath, am.snapshotPath+".1"); err != nil {
+			fmt.Printf("⚠️ Failed to rotate metrics snapshot: %v\n", err)
+		}
+	}
+	if err := os.WriteFile(am.snapshotPath, data, 0644); err != nil {
+		fmt.Printf("⚠️ Failed to snapshot metrics: %v\n", err)
+	}
+}
+
+// exportMetrics publishes metrics on the Prometheus endpoint under scope
+func exportMetrics(scope string, metrics *CoordinationMetrics) {
+	coordinationSessions.WithLabelValues(scope, "total").Set(float64(metrics.TotalSessions))
+	coordinationSessions.WithLabelValues(scope, "active").Set(float64(metrics.ActiveSessions))
+	coordinationSessions.WithLabelValues(scope, "completed").Set(float64(metrics.CompletedSessions))
+	coordinationSessions.WithLabelValues(scope, "escalated").Set(float64(metrics.EscalatedSessions))
+	coordinationSessions.WithLabelValues(scope, "failed").Set(float64(metrics.FailedSessions))
+	coordinationMessages.WithLabelValues(scope).Set(float64(metrics.TotalMessages))
+	coordinationAnnouncements.WithLabelValues(scope).Set(float64(metrics.TaskAnnouncements))
+	coordinationDependencies.WithLabelValues(scope).Set(float64(metrics.DependenciesDetected))
+}
+
+// GetCumulativeMetrics returns metrics counted across every run whose snapshot
+// was restored, this one included
+func (am *AntennaeMonitor) GetCumulativeMetrics() CoordinationMetrics {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	return combineMetrics(am.carried, am.metrics)
+}
@@ -0,0 +1,78 @@
+package monitoring
+
+import (
+	"context"
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCumulativeMetricsSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	// First run sees a few sessions and snapshots them on the way out
+	first, err := NewAntennaeMonitor(context.Background(), nil, dir)
+	if err != nil {
+		t.Fatalf("failed to create monitor: %v", err)
+	}
+	first.metrics.TotalSessions = 3
+	first.metrics.CompletedSessions = 2
+	first.metrics.TotalMessages = 10
+	first.metrics.AgentParticipations["agent-a"] = 4
+	first.isRunning = true
+	first.Stop()
+
+	// After a restart the run starts from zero but the totals carry forward
+	second, err := NewAntennaeMonitor(context.Background(), nil, dir)
+	if err != nil {
+		t.Fatalf("failed to create monitor: %v", err)
+	}
+	if run := second.GetMetrics(); run.TotalSessions != 0 || run.TotalMessages != 0 {
+		t.Fatalf("per-run metrics were not reset: %+v", run)
+	}
+	second.metrics.TotalSessions = 1
+	second.metrics.TotalMessages = 5
+	second.metrics.AgentParticipations["agent-a"] = 1
+	second.isRunning = true
+	second.Stop()
+
+	third, err := NewAntennaeMonitor(context.Background(), nil, dir)
+	if err != nil {
+		t.Fatalf("failed to create monitor: %v", err)
+	}
+	cumulative := third.GetCumulativeMetrics()
+	if cumulative.TotalSessions != 4 || cumulative.CompletedSessions != 2 || cumulative.TotalMessages != 15 {
+		t.Fatalf("counters did not carry across restarts: %+v", cumulative)
+	}
+	if cumulative.AgentParticipations["agent-a"] != 5 {
+		t.Fatalf("expected 5 participations for agent-a, got %d", cumulative.AgentParticipations["agent-a"])
+	}
+}
+
+func TestMonitorExportsMessagesItHandles(t *testing.T) {
+	monitor, err := NewAntennaeMonitor(context.Background(), nil, t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create monitor: %v", err)
+	}
+	monitor.Start()
+	defer monitor.Stop()
+
+	from := peer.ID("peer-a")
+	monitor.handleAntennaeMessage(pubsub.Message{Type: pubsub.MetaDiscussion, From: "agent-a", Data: map[string]interface{}{"session_id": "s1"}}, from)
+	monitor.handleAntennaeMessage(pubsub.Message{Type: pubsub.TelemetryReport, From: "agent-a", Data: map[string]interface{}{}}, from)
+	monitor.handleBzzzMessage(pubsub.Message{Type: pubsub.TaskAnnouncement, Data: map[string]interface{}{}}, from)
+	monitor.handleBzzzMessage(pubsub.Message{Type: pubsub.AvailabilityBcast, Data: map[string]interface{}{}}, from)
+	monitor.saveMetrics()
+
+	if got := testutil.ToFloat64(coordinationMessages.WithLabelValues("run")); got != 1 {
+		t.Errorf("expected 1 coordination message exported, got %v", got)
+	}
+	if got := testutil.ToFloat64(coordinationAnnouncements.WithLabelValues("run")); got != 1 {
+		t.Errorf("expected 1 task announcement exported, got %v", got)
+	}
+	if got := testutil.ToFloat64(coordinationSessions.WithLabelValues("cumulative", "total")); got != 1 {
+		t.Errorf("expected 1 session in the cumulative total, got %v", got)
+	}
+}
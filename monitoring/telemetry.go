@@ -0,0 +1,284 @@
+package monitoring
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// TelemetryReport is an agent's rollup of its own activity since startup
+type TelemetryReport struct {
+	AgentID           string         `json:"agent_id"`
+	TasksCompleted    int            `json:"tasks_completed"`
+	TasksFailed       int            `json:"tasks_failed"`
+	AverageIterations float64        `json:"average_iterations"` // Development loop iterations per executed task
+	ModelUsage        map[string]int `json:"model_usage"`        // Successful generations per model
+	Timestamp         int64          `json:"timestamp"`
+}
+
+// BuildTelemetryReport summarises an agent's Hypercore log and model usage
+func BuildTelemetryReport(agentID string, hlog *logging.HypercoreLog, modelUsage map[string]int) TelemetryReport {
+	report := TelemetryReport{
+		AgentID:    agentID,
+		ModelUsage: modelUsage,
+		Timestamp:  time.Now().Unix(),
+	}
+	if report.ModelUsage == nil {
+		report.ModelUsage = make(map[string]int)
+	}
+
+	completed, _ := hlog.GetEntriesByType(logging.TaskCompleted)
+	failed, _ := hlog.GetEntriesByType(logging.TaskFailed)
+	report.TasksCompleted = len(completed)
+	report.TasksFailed = len(failed)
+
+	// Iterations are logged zero-based, so a task ran for its highest iteration + 1
+	progress, _ := hlog.GetEntriesByType(logging.TaskProgress)
+	iterations := make(map[int]int)
+	for _, entry := range progress {
+		taskID, hasTask := toInt(entry.Data["task_id"])
+		iteration, hasIteration := toInt(entry.Data["iteration"])
+		if hasTask && hasIteration && iteration+1 > iterations[taskID] {
+			iterations[taskID] = iteration + 1
+		}
+	}
+	if len(iterations) > 0 {
+		total := 0
+		for _, count := range iterations {
+			total += count
+		}
+		report.AverageIterations = float64(total) / float64(len(iterations))
+	}
+
+	return report
+}
+
+// toData converts the report into a pubsub message payload
+func (r TelemetryReport) toData() map[string]interface{} {
+	return map[string]interface{}{
+		"agent_id":           r.AgentID,
+		"tasks_completed":    r.TasksCompleted,
+		"tasks_failed":       r.TasksFailed,
+		"average_iterations": r.AverageIterations,
+		"model_usage":        r.ModelUsage,
+		"timestamp":          r.Timestamp,
+	}
+}
+
+// ParseTelemetryReport decodes a telemetry report from a pubsub message
+func ParseTelemetryReport(msg pubsub.Message) (TelemetryReport, error) {
+	var report TelemetryReport
+	if msg.Type != pubsub.TelemetryReport {
+		return report, fmt.Errorf("not a telemetry report: %s", msg.Type)
+	}
+
+	data, err := json.Marshal(msg.Data)
+	if err != nil {
+		return report, fmt.Errorf("failed to marshal telemetry data: %w", err)
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return report, fmt.Errorf("failed to decode telemetry report: %w", err)
+	}
+	if report.AgentID == "" {
+		return report, fmt.Errorf("telemetry report has no agent_id")
+	}
+	return report, nil
+}
+
+// TelemetryPublisher is the part of PubSub the telemetry reporter needs
+type TelemetryPublisher interface {
+	JoinDynamicTopic(topicName string) error
+	LeaveDynamicTopic(topicName string) error
+	PublishToDynamicTopic(topicName string, msgType pubsub.MessageType, data map[string]interface{}) error
+}
+
+// TelemetryReporter periodically broadcasts this agent's telemetry report
+type TelemetryReporter struct {
+	publisher  TelemetryPublisher
+	agentID    string
+	hlog       *logging.HypercoreLog
+	interval   time.Duration
+	modelUsage func() map[string]int
+	collector  *TelemetryCollector // Also given our own reports, which pubsub doesn't deliver back to us
+}
+
+// NewTelemetryReporter creates a reporter that publishes every interval
+func NewTelemetryReporter(publisher TelemetryPublisher, agentID string, hlog *logging.HypercoreLog, interval time.Duration, modelUsage func() map[string]int) *TelemetryReporter {
+	return &TelemetryReporter{
+		publisher:  publisher,
+		agentID:    agentID,
+		hlog:       hlog,
+		interval:   interval,
+		modelUsage: modelUsage,
+	}
+}
+
+// SetCollector records each report this agent publishes in collector too, so the
+// cluster view it serves includes this agent
+func (tr *TelemetryReporter) SetCollector(collector *TelemetryCollector) {
+	tr.collector = collector
+}
+
+// Run joins the telemetry topic and publishes a report every interval until ctx is done
+func (tr *TelemetryReporter) Run(ctx context.Context) {
+	if err := tr.publisher.JoinDynamicTopic(pubsub.TelemetryTopic); err != nil {
+		fmt.Printf("❌ Failed to join telemetry topic: %v\n", err)
+		return
+	}
+	defer tr.publisher.LeaveDynamicTopic(pubsub.TelemetryTopic)
+
+	ticker := time.NewTicker(tr.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := tr.Publish(); err != nil {
+				fmt.Printf("❌ Failed to publish telemetry: %v\n", err)
+			}
+		}
+	}
+}
+
+// Publish broadcasts a snapshot of the agent's activity now
+func (tr *TelemetryReporter) Publish() error {
+	var usage map[string]int
+	if tr.modelUsage != nil {
+		usage = tr.modelUsage()
+	}
+	report := BuildTelemetryReport(tr.agentID, tr.hlog, usage)
+	if tr.collector != nil {
+		tr.collector.Record(report)
+	}
+	return tr.publisher.PublishToDynamicTopic(pubsub.TelemetryTopic, pubsub.TelemetryReport, report.toData())
+}
+
+// ClusterTelemetry aggregates the latest report from every agent
+type ClusterTelemetry struct {
+	Agents            int            `json:"agents"`
+	TasksCompleted    int            `json:"tasks_completed"`
+	TasksFailed       int            `json:"tasks_failed"`
+	AverageIterations float64        `json:"average_iterations"` // Weighted by each agent's completed tasks
+	ModelUsage        map[string]int `json:"model_usage"`
+}
+
+// TelemetryCollector keeps the latest telemetry report from each agent
+type TelemetryCollector struct {
+	reports map[string]TelemetryReport // agentID -> latest report
+	mu      sync.RWMutex
+}
+
+// NewTelemetryCollector creates an empty collector
+func NewTelemetryCollector() *TelemetryCollector {
+	return &TelemetryCollector{
+		reports: make(map[string]TelemetryReport),
+	}
+}
+
+// HandleMessage records telemetry reports and ignores any other message; it can
+// be used as, or called from, the PubSub Antennae message handler.
+func (tc *TelemetryCollector) HandleMessage(msg pubsub.Message, from peer.ID) {
+	if msg.Type != pubsub.TelemetryReport {
+		return
+	}
+	report, err := ParseTelemetryReport(msg)
+	if err != nil {
+		fmt.Printf("⚠️ Ignoring telemetry from %s: %v\n", from.ShortString(), err)
+		return
+	}
+	tc.Record(report)
+}
+
+// Record keeps report unless a newer one from the same agent is already held
+func (tc *TelemetryCollector) Record(report TelemetryReport) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if previous, exists := tc.reports[report.AgentID]; exists && previous.Timestamp > report.Timestamp {
+		return // Out-of-order delivery of an older report
+	}
+	tc.reports[report.AgentID] = report
+}
+
+// Reports returns the latest report from each agent
+func (tc *TelemetryCollector) Reports() map[string]TelemetryReport {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+
+	reports := make(map[string]TelemetryReport, len(tc.reports))
+	for agentID, report := range tc.reports {
+		reports[agentID] = report
+	}
+	return reports
+}
+
+// Summary aggregates the latest reports into cluster-wide totals
+func (tc *TelemetryCollector) Summary() ClusterTelemetry {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+
+	summary := ClusterTelemetry{
+		Agents:     len(tc.reports),
+		ModelUsage: make(map[string]int),
+	}
+	weightedIterations := 0.0
+	for _, report := range tc.reports {
+		summary.TasksCompleted += report.TasksCompleted
+		summary.TasksFailed += report.TasksFailed
+		weightedIterations += report.AverageIterations * float64(report.TasksCompleted)
+		for model, count := range report.ModelUsage {
+			summary.ModelUsage[model] += count
+		}
+	}
+	if summary.TasksCompleted > 0 {
+		summary.AverageIterations = weightedIterations / float64(summary.TasksCompleted)
+	}
+	return summary
+}
+
+// Handler serves the collected telemetry to callers presenting token as a
+// bearer token:
+//
+//	GET /telemetry  cluster-wide totals and the latest report from each agent
+func (tc *TelemetryCollector) Handler(token []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		bearer := []byte(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+		if subtle.ConstantTimeCompare(bearer, token) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		if req.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"cluster": tc.Summary(),
+			"agents":  tc.Reports(),
+		})
+	})
+}
+
+// toInt reads a number from log or message data, which may hold Go ints or JSON floats
+func toInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,144 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+var _ TelemetryPublisher = (*pubsub.PubSub)(nil)
+
+// capturePublisher records what the telemetry reporter publishes
+type capturePublisher struct {
+	topic   string
+	msgType pubsub.MessageType
+	data    map[string]interface{}
+}
+
+func (cp *capturePublisher) JoinDynamicTopic(topicName string) error  { return nil }
+func (cp *capturePublisher) LeaveDynamicTopic(topicName string) error { return nil }
+func (cp *capturePublisher) PublishToDynamicTopic(topicName string, msgType pubsub.MessageType, data map[string]interface{}) error {
+	cp.topic, cp.msgType, cp.data = topicName, msgType, data
+	return nil
+}
+
+func TestTelemetryReporterPublishesReport(t *testing.T) {
+	hlog := logging.NewHypercoreLog(peer.ID("agent-peer"))
+	for i := 0; i < 3; i++ {
+		hlog.Append(logging.TaskProgress, map[string]interface{}{"task_id": 1, "iteration": i})
+	}
+	hlog.Append(logging.TaskProgress, map[string]interface{}{"task_id": 1, "status": "pushed changes"})
+	hlog.Append(logging.TaskCompleted, map[string]interface{}{"task_id": 1})
+	hlog.Append(logging.TaskProgress, map[string]interface{}{"task_id": 2, "iteration": 0})
+	hlog.Append(logging.TaskFailed, map[string]interface{}{"task_id": 2})
+
+	publisher := &capturePublisher{}
+	reporter := NewTelemetryReporter(publisher, "agent-1", hlog, 0, func() map[string]int {
+		return map[string]int{"phi3": 4}
+	})
+	if err := reporter.Publish(); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	if publisher.topic != pubsub.TelemetryTopic || publisher.msgType != pubsub.TelemetryReport {
+		t.Fatalf("published %s on %s, expected %s on %s", publisher.msgType, publisher.topic, pubsub.TelemetryReport, pubsub.TelemetryTopic)
+	}
+	for _, field := range []string{"agent_id", "tasks_completed", "tasks_failed", "average_iterations", "model_usage", "timestamp"} {
+		if _, ok := publisher.data[field]; !ok {
+			t.Errorf("telemetry message is missing %q", field)
+		}
+	}
+
+	// Round-trip through JSON as the message would over the wire
+	wire, err := json.Marshal(pubsub.Message{Type: publisher.msgType, Data: publisher.data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received pubsub.Message
+	if err := json.Unmarshal(wire, &received); err != nil {
+		t.Fatal(err)
+	}
+	report, err := ParseTelemetryReport(received)
+	if err != nil {
+		t.Fatalf("ParseTelemetryReport failed: %v", err)
+	}
+	if report.AgentID != "agent-1" || report.TasksCompleted != 1 || report.TasksFailed != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if report.AverageIterations != 2 { // Task 1 ran 3 iterations, task 2 ran 1
+		t.Errorf("expected 2 average iterations, got %v", report.AverageIterations)
+	}
+	if report.ModelUsage["phi3"] != 4 {
+		t.Errorf("expected phi3 usage of 4, got %v", report.ModelUsage)
+	}
+}
+
+func TestTelemetryCollectorAggregatesLatestReports(t *testing.T) {
+	collector := NewTelemetryCollector()
+	send := func(report TelemetryReport) {
+		collector.HandleMessage(pubsub.Message{Type: pubsub.TelemetryReport, Data: report.toData()}, peer.ID("p"))
+	}
+
+	send(TelemetryReport{AgentID: "a", TasksCompleted: 2, AverageIterations: 4, ModelUsage: map[string]int{"phi3": 1}, Timestamp: 10})
+	send(TelemetryReport{AgentID: "b", TasksCompleted: 2, TasksFailed: 1, AverageIterations: 2, ModelUsage: map[string]int{"phi3": 2, "llama3": 1}, Timestamp: 10})
+	send(TelemetryReport{AgentID: "a", TasksCompleted: 1, Timestamp: 5}) // Stale, ignored
+	collector.HandleMessage(pubsub.Message{Type: pubsub.AvailabilityBcast, Data: map[string]interface{}{"agent_id": "c"}}, peer.ID("p"))
+
+	summary := collector.Summary()
+	if summary.Agents != 2 || summary.TasksCompleted != 4 || summary.TasksFailed != 1 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if summary.AverageIterations != 3 {
+		t.Errorf("expected 3 average iterations, got %v", summary.AverageIterations)
+	}
+	if summary.ModelUsage["phi3"] != 3 || summary.ModelUsage["llama3"] != 1 {
+		t.Errorf("unexpected model usage: %v", summary.ModelUsage)
+	}
+}
+
+func TestTelemetryHandlerServesClusterView(t *testing.T) {
+	collector := NewTelemetryCollector()
+	collector.HandleMessage(pubsub.Message{Type: pubsub.TelemetryReport, Data: TelemetryReport{AgentID: "peer-agent", TasksCompleted: 2, Timestamp: 10}.toData()}, peer.ID("p"))
+
+	// Our own report never comes back over pubsub; the reporter records it directly
+	hlog := logging.NewHypercoreLog(peer.ID("agent-peer"))
+	hlog.Append(logging.TaskCompleted, map[string]interface{}{"task_id": 1})
+	reporter := NewTelemetryReporter(&capturePublisher{}, "agent-1", hlog, 0, nil)
+	reporter.SetCollector(collector)
+	if err := reporter.Publish(); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	handler := collector.Handler([]byte("secret"))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/telemetry", nil))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a request without the token to be refused, got %d", recorder.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/telemetry", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body)
+	}
+	var body struct {
+		Cluster ClusterTelemetry           `json:"cluster"`
+		Agents  map[string]TelemetryReport `json:"agents"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode telemetry: %v", err)
+	}
+	if body.Cluster.Agents != 2 || body.Cluster.TasksCompleted != 3 {
+		t.Errorf("unexpected cluster totals: %+v", body.Cluster)
+	}
+	if _, ok := body.Agents["agent-1"]; !ok {
+		t.Errorf("expected this agent's own report to be served, got %v", body.Agents)
+	}
+}
@@ -20,7 +20,8 @@ type Config struct {
 	ConnectionTimeout time.Duration
 	
 	// Security configuration
-	EnableSecurity bool
+	EnableSecurity  bool
+	IdentityKeyFile string // Persisted private key; empty generates a new identity each start
 	
 	// Pubsub configuration
 	EnablePubsub           bool
@@ -111,6 +112,13 @@ func WithSecurity(enabled bool) Option {
 	}
 }
 
+// WithIdentityKeyFile keeps the node's private key in path so its peer ID is stable
+func WithIdentityKeyFile(path string) Option {
+	return func(c *Config) {
+		c.IdentityKeyFile = path
+	}
+}
+
 // WithPubsub enables or disables pubsub
 func WithPubsub(enabled bool) Option {
 	return func(c *Config) {
@@ -0,0 +1,41 @@
+package p2p
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestNodeIdentityIsStableAcrossRestarts(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "bzzz", "identity.key")
+	opts := []Option{
+		WithListenAddresses("/ip4/127.0.0.1/tcp/0"),
+		WithIdentityKeyFile(keyFile),
+	}
+
+	first, err := NewNode(context.Background(), opts...)
+	if err != nil {
+		t.Fatalf("failed to create first node: %v", err)
+	}
+	firstID := first.ID()
+	first.Close()
+
+	second, err := NewNode(context.Background(), opts...)
+	if err != nil {
+		t.Fatalf("failed to create second node: %v", err)
+	}
+	defer second.Close()
+
+	if second.ID() != firstID {
+		t.Fatalf("peer ID changed across restarts: %s then %s", firstID, second.ID())
+	}
+
+	other, err := NewNode(context.Background(), WithListenAddresses("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatalf("failed to create node without a key file: %v", err)
+	}
+	defer other.Close()
+	if other.ID() == firstID {
+		t.Fatalf("node without a key file should get a fresh identity")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/anthonyrawlins/bzzz/pkg/identity"
 	"github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -42,13 +43,22 @@ func NewNode(ctx context.Context, opts ...Option) (*Node, error) {
 	}
 
 	// Create libp2p host with security and transport options
-	h, err := libp2p.New(
+	hostOpts := []libp2p.Option{
 		libp2p.ListenAddrs(listenAddrs...),
 		libp2p.Security(noise.ID, noise.New),
 		libp2p.Transport(tcp.NewTCPTransport),
 		libp2p.DefaultMuxers,
 		libp2p.EnableRelay(),
-	)
+	}
+	if config.IdentityKeyFile != "" {
+		id, err := identity.LoadOrGenerate(config.IdentityKeyFile)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		hostOpts = append(hostOpts, libp2p.Identity(id.PrivateKey()))
+	}
+	h, err := libp2p.New(hostOpts...)
 	if err != nil {
 		cancel()
 		return nil, fmt.Errorf("failed to create libp2p host: %w", err)
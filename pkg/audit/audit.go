@@ -0,0 +1,212 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+)
+
+// Stages of a task's life as they appear in an audit trail
+const (
+	StageAnnounced     = "announced"
+	StageClaimed       = "claimed"
+	StageCommand       = "command"
+	StageProgress      = "progress"
+	StageCompleted     = "completed"
+	StageFailed        = "failed"
+	StageEscalated     = "escalated"
+	StageHelpRequested = "help_requested"
+	StageHelpOffered   = "help_offered"
+	StageHelpReceived  = "help_received"
+)
+
+// stages maps the log entries that make up an audit trail to their stage
+var stages = map[logging.LogType]string{
+	logging.TaskAnnounced:     StageAnnounced,
+	logging.TaskClaimed:       StageClaimed,
+	logging.TaskProgress:      StageProgress,
+	logging.TaskCompleted:     StageCompleted,
+	logging.TaskFailed:        StageFailed,
+	logging.Escalation:        StageEscalated,
+	logging.TaskHelpRequested: StageHelpRequested,
+	logging.TaskHelpOffered:   StageHelpOffered,
+	logging.TaskHelpReceived:  StageHelpReceived,
+}
+
+// Event is one decision or action recorded against a task
+type Event struct {
+	Index     uint64                 `json:"index"` // Position in the Hypercore log
+	Timestamp time.Time              `json:"timestamp"`
+	Author    string                 `json:"author"` // Peer that logged it
+	Stage     string                 `json:"stage"`
+	Detail    string                 `json:"detail,omitempty"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// Trail is everything the agent decided and did about one task
+type Trail struct {
+	TaskID     int     `json:"task_id"`
+	Repository string  `json:"repository,omitempty"`
+	Title      string  `json:"title,omitempty"`
+	ClaimedBy  string  `json:"claimed_by,omitempty"`
+	MatchScore float64 `json:"match_score,omitempty"` // Capability match that won the claim
+	Outcome    string  `json:"outcome,omitempty"`     // completed, failed or escalated; empty while the task is open
+	Events     []Event `json:"events"`
+}
+
+// trailKey identifies a task across repositories, whose issue numbers overlap
+type trailKey struct {
+	repository string
+	taskID     int
+}
+
+// Recorder builds per-task audit trails from a Hypercore log as entries are appended
+type Recorder struct {
+	mu     sync.RWMutex
+	trails map[trailKey]*Trail
+}
+
+// NewRecorder starts recording hlog, including the entries it already holds
+func NewRecorder(hlog *logging.HypercoreLog) *Recorder {
+	r := &Recorder{trails: make(map[trailKey]*Trail)}
+	for _, entry := range hlog.Subscribe(r.record) {
+		r.record(entry)
+	}
+	return r
+}
+
+// Trails returns every task's audit trail, ordered by repository then task
+func (r *Recorder) Trails() []Trail {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	trails := make([]Trail, 0, len(r.trails))
+	for _, trail := range r.trails {
+		trails = append(trails, copyTrail(trail))
+	}
+	sort.Slice(trails, func(i, j int) bool {
+		if trails[i].Repository != trails[j].Repository {
+			return trails[i].Repository < trails[j].Repository
+		}
+		return trails[i].TaskID < trails[j].TaskID
+	})
+	return trails
+}
+
+// Trail returns the audit trail of issue taskID in repository (owner/name)
+func (r *Recorder) Trail(repository string, taskID int) (Trail, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	trail, exists := r.trails[trailKey{repository, taskID}]
+	if !exists {
+		return Trail{}, false
+	}
+	return copyTrail(trail), true
+}
+
+// record adds a log entry to its task's trail; entries not about a task are ignored.
+// Entries without a repository come from agents that predate it being logged.
+func (r *Recorder) record(entry logging.LogEntry) {
+	stage, audited := stages[entry.Type]
+	taskID, hasTask := toInt(entry.Data["task_id"])
+	if !audited || !hasTask {
+		return
+	}
+	if _, isCommand := entry.Data["command"]; isCommand && entry.Type == logging.TaskProgress {
+		stage = StageCommand
+	}
+
+	repository, _ := entry.Data["repository"].(string)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := trailKey{repository, taskID}
+	trail, exists := r.trails[key]
+	if !exists {
+		trail = &Trail{TaskID: taskID, Repository: repository}
+		r.trails[key] = trail
+	}
+	if title, ok := entry.Data["title"].(string); ok && trail.Title == "" {
+		trail.Title = title
+	}
+	switch stage {
+	case StageClaimed:
+		trail.ClaimedBy, _ = entry.Data["agent_id"].(string)
+		trail.MatchScore, _ = toFloat(entry.Data["match_score"])
+		trail.Outcome = "" // A re-claim reopens the task
+	case StageCompleted, StageFailed, StageEscalated:
+		trail.Outcome = stage
+	}
+
+	// Subscribers can be called out of order when entries are appended concurrently
+	event := Event{
+		Index:     entry.Index,
+		Timestamp: entry.Timestamp,
+		Author:    entry.Author,
+		Stage:     stage,
+		Detail:    detail(stage, entry.Data),
+		Data:      entry.Data,
+	}
+	at := sort.Search(len(trail.Events), func(i int) bool { return trail.Events[i].Index > event.Index })
+	trail.Events = append(trail.Events, Event{})
+	copy(trail.Events[at+1:], trail.Events[at:])
+	trail.Events[at] = event
+}
+
+// detail summarises an event in a line, for CSV exports and quick reading
+func detail(stage string, data map[string]interface{}) string {
+	switch stage {
+	case StageClaimed:
+		if score, ok := toFloat(data["match_score"]); ok {
+			return fmt.Sprintf("claimed by %v with match score %.2f", data["agent_id"], score)
+		}
+	case StageCommand:
+		return fmt.Sprint(data["command"])
+	case StageCompleted:
+		if url, ok := data["pr_url"].(string); ok {
+			return url
+		}
+	}
+	for _, key := range []string{"reason", "status"} {
+		if value, ok := data[key].(string); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// copyTrail copies a trail so callers can't race with recording
+func copyTrail(trail *Trail) Trail {
+	copied := *trail
+	copied.Events = append([]Event(nil), trail.Events...)
+	return copied
+}
+
+// toInt reads a number from log data, which is float64 once it has been through JSON
+func toInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
+
+// toFloat reads a number from log data
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
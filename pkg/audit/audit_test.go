@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestAuditTrailCapturesClaimExecuteComplete(t *testing.T) {
+	hlog := logging.NewHypercoreLog(peer.ID("test"))
+	// Logged before the recorder started, so it has to be replayed
+	hlog.Append(logging.TaskClaimed, map[string]interface{}{
+		"task_id": 42, "repository": "acme/widgets", "title": "Rotate the signing keys",
+		"agent_id": "agent-a", "match_score": 1.25,
+	})
+	recorder := NewRecorder(hlog)
+
+	hlog.Append(logging.TaskProgress, map[string]interface{}{"task_id": 42, "repository": "acme/widgets", "status": "cloned repo"})
+	hlog.Append(logging.TaskProgress, map[string]interface{}{"task_id": 42, "repository": "acme/widgets", "iteration": 0, "command": "go test ./..."})
+	hlog.Append(logging.PeerJoined, map[string]interface{}{"peer_id": "peer-b"}) // Not about a task
+	// The same issue number in another repository is another task
+	hlog.Append(logging.TaskClaimed, map[string]interface{}{"task_id": 42, "repository": "acme/gadgets", "agent_id": "agent-b"})
+	hlog.Append(logging.TaskFailed, map[string]interface{}{"task_id": 42, "repository": "acme/gadgets", "reason": "budget exceeded"})
+	hlog.Append(logging.TaskCompleted, map[string]interface{}{"task_id": 42, "repository": "acme/widgets", "pr_url": "https://github.com/acme/widgets/pull/7"})
+	hlog.Append(logging.TaskClaimed, map[string]interface{}{"task_id": 43, "repository": "acme/widgets", "agent_id": "agent-a"})
+
+	handler := recorder.Handler([]byte("secret"))
+	get := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/audit?repository=acme/widgets&task=42")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var trails []Trail
+	if err := json.Unmarshal(rec.Body.Bytes(), &trails); err != nil {
+		t.Fatalf("export is not JSON: %v", err)
+	}
+	if len(trails) != 1 {
+		t.Fatalf("expected one trail, got %d", len(trails))
+	}
+	trail := trails[0]
+	if trail.Repository != "acme/widgets" || trail.ClaimedBy != "agent-a" || trail.MatchScore != 1.25 || trail.Outcome != StageCompleted {
+		t.Fatalf("trail lost the claim or outcome: %+v", trail)
+	}
+	var stagesSeen []string
+	for _, event := range trail.Events {
+		stagesSeen = append(stagesSeen, event.Stage)
+	}
+	want := []string{StageClaimed, StageProgress, StageCommand, StageCompleted}
+	if strings.Join(stagesSeen, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected stages %v, got %v", want, stagesSeen)
+	}
+
+	rec = get("/audit?task=42")
+	if err := json.Unmarshal(rec.Body.Bytes(), &trails); err != nil {
+		t.Fatalf("export is not JSON: %v", err)
+	}
+	if len(trails) != 2 || trails[0].Repository != "acme/gadgets" || trails[0].ClaimedBy != "agent-b" ||
+		trails[0].Outcome != StageFailed || len(trails[0].Events) != 2 {
+		t.Fatalf("expected issue #42 of each repository in its own trail, got %+v", trails)
+	}
+	if rec := get("/audit?repository=acme/doodads&task=42"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a repository without the task, got %d", rec.Code)
+	}
+
+	rec = get("/audit?format=csv")
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("export is not CSV: %v", err)
+	}
+	if len(rows) != 1+2+len(want)+1 { // Header, acme/gadgets#42, acme/widgets#42 and #43
+		t.Fatalf("expected %d CSV rows, got %d", len(want)+4, len(rows))
+	}
+	if rows[5][7] != StageCommand || rows[5][8] != "go test ./..." {
+		t.Fatalf("expected the command in the CSV export, got %v", rows[5])
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/audit", nil)
+	unauthorized := httptest.NewRecorder()
+	handler.ServeHTTP(unauthorized, req)
+	if unauthorized.Code != http.StatusUnauthorized {
+		t.Fatalf("expected export without a token to be refused, got %d", unauthorized.Code)
+	}
+}
@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"crypto/subtle"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvHeader names the columns of a CSV export, one row per event
+var csvHeader = []string{"task_id", "repository", "title", "outcome", "index", "timestamp", "author", "stage", "detail"}
+
+// WriteJSON writes audit trails as a JSON array
+func WriteJSON(w io.Writer, trails []Trail) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(trails)
+}
+
+// WriteCSV writes audit trails with one row per event
+func WriteCSV(w io.Writer, trails []Trail) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, trail := range trails {
+		for _, event := range trail.Events {
+			err := writer.Write([]string{
+				strconv.Itoa(trail.TaskID),
+				trail.Repository,
+				trail.Title,
+				trail.Outcome,
+				strconv.FormatUint(event.Index, 10),
+				event.Timestamp.UTC().Format(time.RFC3339Nano),
+				event.Author,
+				event.Stage,
+				event.Detail,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Handler exports audit trails to callers presenting token as a bearer token:
+//
+//	GET /audit                                 every task's trail as JSON
+//	GET /audit?task=42                         issue #42's trails in every repository
+//	GET /audit?repository=acme/widgets&task=42 one task's trail
+//	GET /audit?format=csv                      the same as CSV, one row per event
+func (r *Recorder) Handler(token []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		bearer := []byte(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+		if subtle.ConstantTimeCompare(bearer, token) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		if req.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := req.URL.Query()
+		trails := r.Trails()
+		if task := query.Get("task"); task != "" {
+			taskID, err := strconv.Atoi(task)
+			if err != nil {
+				http.Error(w, "task must be a task number", http.StatusBadRequest)
+				return
+			}
+			trails = slices.DeleteFunc(trails, func(trail Trail) bool {
+				return trail.TaskID != taskID || (query.Has("repository") && trail.Repository != query.Get("repository"))
+			})
+			if len(trails) == 0 {
+				http.Error(w, "no audit trail for task", http.StatusNotFound)
+				return
+			}
+		}
+
+		switch req.URL.Query().Get("format") {
+		case "", "json":
+			w.Header().Set("Content-Type", "application/json")
+			WriteJSON(w, trails)
+		case "csv":
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Set("Content-Disposition", `attachment; filename="bzzz-audit.csv"`)
+			WriteCSV(w, trails)
+		default:
+			http.Error(w, "format must be json or csv", http.StatusBadRequest)
+		}
+	})
+}
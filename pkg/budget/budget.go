@@ -0,0 +1,159 @@
+package budget
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/reasoning"
+)
+
+// Usage is reasoning spent so far
+type Usage struct {
+	Tokens    int           `json:"tokens"`
+	ModelTime time.Duration `json:"model_time"`
+}
+
+// add counts one generation
+func (u *Usage) add(stats reasoning.GenerationStats) {
+	u.Tokens += stats.Tokens()
+	u.ModelTime += stats.Duration
+}
+
+// exceeds describes which limit usage is over, or returns "" if none
+func (u Usage) exceeds(limit config.Budget) string {
+	if limit.MaxTokens > 0 && u.Tokens > limit.MaxTokens {
+		return fmt.Sprintf("%d tokens used, limit is %d", u.Tokens, limit.MaxTokens)
+	}
+	if limit.MaxModelTime > 0 && u.ModelTime > limit.MaxModelTime {
+		return fmt.Sprintf("%v of model time used, limit is %v", u.ModelTime.Round(time.Second), limit.MaxModelTime)
+	}
+	return ""
+}
+
+// ExceededError is returned once a task or the agent goes over its budget
+type ExceededError struct {
+	Scope  string // "task" or "agent"
+	Used   Usage
+	Limit  config.Budget
+	Reason string
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s budget exceeded: %s", e.Scope, e.Reason)
+}
+
+// Tracker counts reasoning usage per task and for the agent as a whole. A nil
+// Tracker tracks nothing and never reports a budget as exceeded.
+type Tracker struct {
+	config      config.BudgetConfig
+	tasks       map[string]*Usage // task key -> usage
+	agent       Usage
+	windowStart time.Time
+	mu          sync.Mutex
+}
+
+// NewTracker creates a tracker enforcing the given budgets
+func NewTracker(cfg config.BudgetConfig) *Tracker {
+	return &Tracker{
+		config:      cfg,
+		tasks:       make(map[string]*Usage),
+		windowStart: time.Now(),
+	}
+}
+
+// Record counts a generation made for a task
+func (t *Tracker) Record(taskKey string, stats reasoning.GenerationStats) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rollWindowLocked()
+	usage, exists := t.tasks[taskKey]
+	if !exists {
+		usage = &Usage{}
+		t.tasks[taskKey] = usage
+	}
+	usage.add(stats)
+	t.agent.add(stats)
+}
+
+// Check returns an *ExceededError if the task or the agent is over budget
+func (t *Tracker) Check(taskKey, taskType string) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rollWindowLocked()
+	limit := t.budgetFor(taskType)
+	if usage, exists := t.tasks[taskKey]; exists {
+		if reason := usage.exceeds(limit); reason != "" {
+			return &ExceededError{Scope: "task", Used: *usage, Limit: limit, Reason: reason}
+		}
+	}
+	if reason := t.agent.exceeds(t.config.Agent); reason != "" {
+		return &ExceededError{Scope: "agent", Used: t.agent, Limit: t.config.Agent, Reason: reason}
+	}
+	return nil
+}
+
+// CheckAgent returns an *ExceededError if the agent as a whole is over budget,
+// so it shouldn't take on new work until its window rolls over
+func (t *Tracker) CheckAgent() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rollWindowLocked()
+	if reason := t.agent.exceeds(t.config.Agent); reason != "" {
+		return &ExceededError{Scope: "agent", Used: t.agent, Limit: t.config.Agent, Reason: reason}
+	}
+	return nil
+}
+
+// TaskUsage returns what a task has spent so far
+func (t *Tracker) TaskUsage(taskKey string) Usage {
+	if t == nil {
+		return Usage{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if usage, exists := t.tasks[taskKey]; exists {
+		return *usage
+	}
+	return Usage{}
+}
+
+// Finish forgets a task's usage once it is done; the agent total keeps it
+func (t *Tracker) Finish(taskKey string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.tasks, taskKey)
+}
+
+// budgetFor returns the budget for a task type, falling back to the default
+func (t *Tracker) budgetFor(taskType string) config.Budget {
+	if limit, exists := t.config.TaskTypes[taskType]; exists {
+		return limit
+	}
+	return t.config.Default
+}
+
+// rollWindowLocked resets the agent total once its window has passed
+func (t *Tracker) rollWindowLocked() {
+	if t.config.AgentWindow > 0 && time.Since(t.windowStart) >= t.config.AgentWindow {
+		t.agent = Usage{}
+		t.windowStart = time.Now()
+	}
+}
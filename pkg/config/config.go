@@ -3,10 +3,16 @@ package config
 import (
 	"fmt"
 	"io/ioutil"
+	"maps"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"text/template"
 	"time"
 
+	"github.com/libp2p/go-libp2p/core/peer"
 	"gopkg.in/yaml.v2"
 )
 
@@ -17,6 +23,11 @@ type Config struct {
 	GitHub  GitHubConfig  `yaml:"github"`
 	P2P     P2PConfig     `yaml:"p2p"`
 	Logging LoggingConfig `yaml:"logging"`
+	API     APIConfig     `yaml:"api"`
+
+	Coordination CoordinationConfig `yaml:"coordination"`
+	Reasoning    ReasoningConfig    `yaml:"reasoning"`
+	Tracing      TracingConfig      `yaml:"tracing"`
 }
 
 // HiveAPIConfig holds Hive system integration settings
@@ -25,19 +36,140 @@ type HiveAPIConfig struct {
 	APIKey     string        `yaml:"api_key"`
 	Timeout    time.Duration `yaml:"timeout"`
 	RetryCount int           `yaml:"retry_count"`
+
+	StatusBatchInterval time.Duration `yaml:"status_batch_interval"` // Coalesce task status updates into one bulk request per interval; 0 sends each immediately
+	DeadLetterFile      string        `yaml:"dead_letter_file"`      // Where reports Hive never accepted are kept for reconciliation, with reports still queued beside it; empty uses ~/.config/bzzz/hive-dead-letter.jsonl
 }
 
 // AgentConfig holds agent-specific configuration
 type AgentConfig struct {
-	ID                    string        `yaml:"id"`
-	Capabilities          []string      `yaml:"capabilities"`
-	PollInterval          time.Duration `yaml:"poll_interval"`
-	MaxTasks              int           `yaml:"max_tasks"`
-	Models                []string      `yaml:"models"`
-	Specialization        string        `yaml:"specialization"`
-	ModelSelectionWebhook string        `yaml:"model_selection_webhook"`
-	DefaultReasoningModel string        `yaml:"default_reasoning_model"`
-	SandboxImage          string        `yaml:"sandbox_image"`
+	ID                    string           `yaml:"id"`
+	Capabilities          []string         `yaml:"capabilities"`
+	PollInterval          time.Duration    `yaml:"poll_interval"`
+	MaxPollInterval       time.Duration    `yaml:"max_poll_interval"` // Cap for the poll backoff while no suitable tasks turn up
+	MaxTasks              int              `yaml:"max_tasks"`
+	Models                []string         `yaml:"models"`
+	Specialization        string           `yaml:"specialization"`
+	ModelSelectionWebhook string           `yaml:"model_selection_webhook"`
+	DefaultReasoningModel string           `yaml:"default_reasoning_model"`
+	SandboxImage          string           `yaml:"sandbox_image"`
+	ClaimIntentWindow     time.Duration    `yaml:"claim_intent_window"`
+	Sandbox               SandboxConfig    `yaml:"sandbox"`
+	SecretScan            SecretScanConfig `yaml:"secret_scan"`
+	VerifyCommand         string           `yaml:"verify_command"`    // Build/test command that must pass before a PR is opened
+	MaxTaskFailures       int              `yaml:"max_task_failures"` // Failures before a task is released to humans
+	FailureCooldown       time.Duration    `yaml:"failure_cooldown"`  // How long a released task is left alone
+	ClaimLease            time.Duration    `yaml:"claim_lease"`       // How long a claim lasts without renewal
+	WarmUpModels          bool             `yaml:"warm_up_models"`    // Load each model into Ollama at startup so the first task isn't slow
+	MaxDiff               DiffLimits       `yaml:"max_diff"`          // Changes larger than this open as draft PRs for human review
+	Clone                 CloneConfig      `yaml:"clone"`
+	Budget                BudgetConfig     `yaml:"budget"`
+	IdleShutdown          time.Duration    `yaml:"idle_shutdown"`     // Exit after this long without work, for ephemeral nodes; 0 never does
+	RecordDir             string           `yaml:"record_dir"`        // Save each task run here so it can be replayed with `bzzz replay`; empty records nothing
+
+	// Priority band of tasks the agent takes, inclusive; 0 leaves that end open.
+	// An on-call agent might set min_priority: 8 to skip low-priority cleanup.
+	MinPriority int `yaml:"min_priority"`
+	MaxPriority int `yaml:"max_priority"`
+
+	// Share each task's plan on its task topic so peers can follow the agent's approach.
+	SharePlans bool `yaml:"share_plans"`
+
+	// Minimum confidence the model must rate a command before the agent runs it on
+	// its own; less confident commands are escalated for a human to confirm.
+	Confidence ConfidenceConfig `yaml:"confidence"`
+
+	// How long a single model call may take, e.g. longer for code generation than
+	// for a quick classification. Zero keeps the built-in 60s.
+	ReasoningTimeout TimeoutConfig `yaml:"reasoning_timeout"`
+
+	// Repository ("owner/repo" or Hive project ID) -> specializations preferred for its tasks.
+	// Agents with a preferred specialization win claim arbitration for those tasks.
+	Affinity map[string][]string `yaml:"affinity"`
+}
+
+// ConfidenceConfig sets the confidence thresholds, from 0 to 1, for running
+// commands autonomously. Zero disables the gate.
+type ConfidenceConfig struct {
+	Default   float64            `yaml:"default"`    // Applies to task types without their own threshold
+	TaskTypes map[string]float64 `yaml:"task_types"` // Task type -> threshold
+}
+
+// Threshold returns the confidence a task type's commands need
+func (c ConfidenceConfig) Threshold(taskType string) float64 {
+	if threshold, exists := c.TaskTypes[taskType]; exists {
+		return threshold
+	}
+	return c.Default
+}
+
+// TimeoutConfig sets a timeout by task type
+type TimeoutConfig struct {
+	Default   time.Duration            `yaml:"default"`    // Applies to task types without their own timeout
+	TaskTypes map[string]time.Duration `yaml:"task_types"` // Task type -> timeout
+}
+
+// For returns a task type's timeout
+func (c TimeoutConfig) For(taskType string) time.Duration {
+	if timeout, exists := c.TaskTypes[taskType]; exists {
+		return timeout
+	}
+	return c.Default
+}
+
+// BudgetConfig caps how much model time a task, and the agent overall, may spend
+type BudgetConfig struct {
+	Default     Budget            `yaml:"default"`      // Applies to task types without their own budget
+	TaskTypes   map[string]Budget `yaml:"task_types"`   // Task type -> budget
+	Agent       Budget            `yaml:"agent"`        // Across all tasks within agent_window
+	AgentWindow time.Duration     `yaml:"agent_window"` // How often the agent budget resets
+}
+
+// Budget limits reasoning usage. Zero disables a limit.
+type Budget struct {
+	MaxTokens    int           `yaml:"max_tokens"`     // Prompt plus completion tokens
+	MaxModelTime time.Duration `yaml:"max_model_time"` // Wall-clock time spent generating
+}
+
+// CloneConfig controls how task repositories are cloned into the sandbox
+type CloneConfig struct {
+	Depth          int  `yaml:"depth"`           // Shallow clone depth; 0 clones full history
+	SparseCheckout bool `yaml:"sparse_checkout"` // Only check out the paths named in a task's context
+}
+
+// DiffLimits caps how large a change an agent may open for review on its own.
+// Zero disables a limit.
+type DiffLimits struct {
+	MaxFiles int `yaml:"max_files"` // Files changed
+	MaxLines int `yaml:"max_lines"` // Lines added plus lines removed
+}
+
+// SecretScanConfig controls the secret scan run over staged changes before pushing
+type SecretScanConfig struct {
+	Enabled bool              `yaml:"enabled"`
+	Rules   map[string]string `yaml:"rules"` // Rule name -> regular expression, added to the built-in rules
+}
+
+// SandboxConfig holds settings for task sandbox containers
+type SandboxConfig struct {
+	NetworkMode      string   `yaml:"network_mode"`      // none, bridge or a custom network name for trusted tasks
+	UntrustedNetwork string   `yaml:"untrusted_network"` // Internal network for tasks from untrusted sources, created if missing; the proxy must be attached to it
+	ProxyURL         string   `yaml:"proxy_url"`         // Allowlisted proxy for git/package fetching, the untrusted network's only way out
+	TrustedOwners    []string `yaml:"trusted_owners"`    // Repository owners whose tasks are trusted
+
+	StatsInterval       time.Duration `yaml:"stats_interval"`        // How often resource usage is sampled
+	MemoryKillThreshold float64       `yaml:"memory_kill_threshold"` // Fraction of the memory limit at which the sandbox is killed
+	CommandTimeout      time.Duration `yaml:"command_timeout"`       // Default limit for a single sandbox command
+
+	Platform string `yaml:"platform"` // os/arch[/variant] the sandbox image runs as, e.g. linux/arm64; empty uses the image's own
+
+	MaxConcurrent int `yaml:"max_concurrent"` // Sandbox containers run at once on this host; more tasks wait for one to finish. 0 is unlimited
+
+	Caches map[string]string `yaml:"caches"` // Language (go, npm, pip) -> host directory shared by trusted sandboxes as their dependency cache; untrusted ones use a sibling "-untrusted" directory
+
+	// Environment variables tasks may set in their sandbox (from the issue's frontmatter
+	// env map); an entry ending in * allows a prefix. Anything else a task asks for is dropped.
+	EnvAllowlist []string `yaml:"env_allowlist"`
 }
 
 // GitHubConfig holds GitHub integration settings
@@ -47,14 +179,78 @@ type GitHubConfig struct {
 	Timeout      time.Duration `yaml:"timeout"`
 	RateLimit    bool          `yaml:"rate_limit"`
 	Assignee     string        `yaml:"assignee"`
+
+	OwnerTokenFiles map[string]string `yaml:"owner_token_files"` // Repository owner -> token file, for owners token_file can't access
+
+	DraftPullRequests bool `yaml:"draft_pull_requests"` // Open every task PR as a draft for a human to mark ready
+
+	DeleteFailedBranches bool `yaml:"delete_failed_branches"` // Delete a task's branch when its execution fails
+
+	SplitOversizedTasks bool `yaml:"split_oversized_tasks"` // Ask the model whether each task is too big for one run, or once its changes outgrow max_diff, and file sub-issues if so
+
+	// Label conventions used on GitHub issues
+	TaskLabel       string `yaml:"task_label"`
+	InProgressLabel string `yaml:"in_progress_label"`
+	CompletedLabel  string `yaml:"completed_label"`
+	NeedsHumanLabel string `yaml:"needs_human_label"`
+	ApprovalLabel   string `yaml:"approval_label"` // Approves proposed tasks in repositories that require approval
+
+	// Shared secret for GitHub webhooks; empty disables the webhook listener
+	WebhookSecret string `yaml:"webhook_secret"`
+
+	// GitHub Enterprise Server API, e.g. https://github.example.com/api/v3/; empty uses github.com
+	BaseURL   string `yaml:"base_url"`
+	UploadURL string `yaml:"upload_url"` // Empty derives https://<host>/api/uploads/ from base_url
+
+	// Go text/templates for what the agent posts, with .AgentID, .IssueNumber,
+	// .Title, .Repository and .Branch. Empty uses the built-in English text.
+	ClaimComment     string `yaml:"claim_comment"`
+	PullRequestTitle string `yaml:"pull_request_title"`
+	PullRequestBody  string `yaml:"pull_request_body"` // Opening paragraph; diff size and review notes follow it
+}
+
+// EnterpriseHost returns the GitHub Enterprise Server host from BaseURL, or ""
+// when the agent uses github.com
+func (c GitHubConfig) EnterpriseHost() string {
+	if c.BaseURL == "" {
+		return ""
+	}
+	parsed, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
 }
 
 // P2PConfig holds P2P networking configuration
 type P2PConfig struct {
-	ServiceTag       string        `yaml:"service_tag"`
-	BzzzTopic        string        `yaml:"bzzz_topic"`
-	AntennaeTopic    string        `yaml:"antennae_topic"`
-	DiscoveryTimeout time.Duration `yaml:"discovery_timeout"`
+	ServiceTag        string        `yaml:"service_tag"`
+	BzzzTopic         string        `yaml:"bzzz_topic"`
+	AntennaeTopic     string        `yaml:"antennae_topic"`
+	ExtraTopics       []string      `yaml:"extra_topics"`       // More coordination topics to join, e.g. a team's channel
+	DiscoveryTimeout  time.Duration `yaml:"discovery_timeout"`
+	DynamicQueueSize  int           `yaml:"dynamic_queue_size"` // Messages buffered per dynamic topic before the oldest are dropped
+	MaxMessageSize    int           `yaml:"max_message_size"`   // Largest message published whole, in bytes; bigger ones are chunked
+	MessageRateLimit  float64       `yaml:"message_rate_limit"` // Messages per second accepted from each peer; 0 disables the limit
+	MessageBurst      int           `yaml:"message_burst"`      // Messages a peer may send at once before the rate limit applies
+	TelemetryInterval time.Duration `yaml:"telemetry_interval"` // How often to broadcast a telemetry report; 0 disables it
+	IdentityKeyFile   string        `yaml:"identity_key_file"`  // libp2p private key kept across restarts; empty uses ~/.config/bzzz/identity.key
+
+	// Peers needed before help requests and coordination sessions start; with
+	// fewer the agent escalates straight to humans. 0 always coordinates.
+	MinCoordinationPeers int `yaml:"min_coordination_peers"`
+
+	// Peer IDs allowed to query this agent's internal state over the mesh; empty
+	// refuses every introspection request
+	IntrospectionPeers []string `yaml:"introspection_peers"`
+
+	// Peer IDs whose task cancel and agent pause requests this agent obeys, i.e.
+	// the agents operators run the control API on; empty ignores every request
+	OperatorPeers []string `yaml:"operator_peers"`
+
+	// Repository ("owner/repo" or Hive project ID) -> where its escalations are sent
+	// instead of escalation_webhook, so each team hears about its own repositories
+	EscalationRoutes map[string]EscalationRoute `yaml:"escalation_routes"`
 	
 	// Human escalation settings
 	EscalationWebhook       string   `yaml:"escalation_webhook"`
@@ -62,6 +258,12 @@ type P2PConfig struct {
 	ConversationLimit       int      `yaml:"conversation_limit"`
 }
 
+// EscalationRoute sends a repository's escalations to its own webhook
+type EscalationRoute struct {
+	Webhook  string            `yaml:"webhook"`
+	Metadata map[string]string `yaml:"metadata"` // Sent with each escalation for routing, e.g. team: payments
+}
+
 // LoggingConfig holds logging configuration
 type LoggingConfig struct {
 	Level      string `yaml:"level"`
@@ -70,6 +272,69 @@ type LoggingConfig struct {
 	Structured bool   `yaml:"structured"`
 }
 
+// TracingConfig controls where OpenTelemetry spans for the task lifecycle are sent
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter"`     // "none", "stdout" or "otlp"
+	Endpoint    string  `yaml:"endpoint"`     // OTLP/HTTP traces URL
+	SampleRatio float64 `yaml:"sample_ratio"` // Fraction of tasks traced, 0-1
+}
+
+// CoordinationConfig holds settings for multi-agent coordination sessions
+type CoordinationConfig struct {
+	// Limits per session type (dependency, conflict, planning); unset types and
+	// zero fields keep the coordinator's built-in limits
+	SessionLimits map[string]SessionLimits `yaml:"session_limits"`
+
+	// Go text/template for the coordination plan prompt, with .Task1, .Task2,
+	// .Relationship, .Reason, .Confidence, .SessionID and .SessionType. Empty
+	// uses the built-in prompt.
+	PlanPrompt string `yaml:"plan_prompt"`
+}
+
+// ReasoningConfig holds settings for the models behind the agent
+type ReasoningConfig struct {
+	// Second model that reviews the agent's diff before a PR is opened; it must be
+	// one of agent.models and differ from the executing model. Empty skips review.
+	ReviewerModel string `yaml:"reviewer_model"`
+
+	// Opt-in cache of responses to identical prompts
+	Cache ReasoningCacheConfig `yaml:"cache"`
+
+	// Swap in lighter models while the host is busy
+	Load LoadPolicyConfig `yaml:"load"`
+}
+
+// LoadPolicyConfig sets when model selection downgrades under host load, as CPU
+// or GPU utilization from 0 to 1. A zero downgrade_above disables it.
+type LoadPolicyConfig struct {
+	DowngradeAbove float64 `yaml:"downgrade_above"` // Use the lightest model above this utilization
+	RestoreBelow   float64 `yaml:"restore_below"`   // Go back to the usual models below this; 0 means downgrade_above
+}
+
+// ReasoningCacheConfig bounds the reasoning response cache; zero values disable it
+type ReasoningCacheConfig struct {
+	TTL        time.Duration `yaml:"ttl"`         // How long a response is reused
+	MaxEntries int           `yaml:"max_entries"` // Least recently used responses are evicted beyond this
+}
+
+// SessionLimits bounds a coordination session before it is escalated to humans
+type SessionLimits struct {
+	MaxDuration         time.Duration `yaml:"max_duration"`
+	MaxParticipants     int           `yaml:"max_participants"`
+	EscalationThreshold int           `yaml:"escalation_threshold"` // Messages before escalation
+}
+
+// APIConfig holds settings for the agent's local HTTP API (metrics and control endpoints)
+type APIConfig struct {
+	ListenAddr string `yaml:"listen_addr"` // Empty disables the HTTP API
+
+	// Bearer token N8N/Hive use to post escalation replies; empty disables the endpoint
+	EscalationToken string `yaml:"escalation_token"`
+
+	// Bearer token operators use to list and cancel running tasks; empty disables the endpoints
+	ControlToken string `yaml:"control_token"`
+}
+
 // LoadConfig loads configuration from file, environment variables, and defaults
 func LoadConfig(configPath string) (*Config, error) {
 	// Start with defaults
@@ -106,12 +371,41 @@ func getDefaultConfig() *Config {
 		Agent: AgentConfig{
 			Capabilities:          []string{"general", "reasoning", "task-coordination"},
 			PollInterval:          30 * time.Second,
+			MaxPollInterval:       10 * time.Minute,
 			MaxTasks:              3,
 			Models:                []string{"phi3", "llama3.1"},
 			Specialization:        "general_developer",
 			ModelSelectionWebhook: "https://n8n.home.deepblack.cloud/webhook/model-selection",
 			DefaultReasoningModel: "phi3",
 			SandboxImage:          "registry.home.deepblack.cloud/tony/bzzz-sandbox:latest",
+			ClaimIntentWindow:     3 * time.Second,
+			Sandbox: SandboxConfig{
+				NetworkMode:         "bridge",
+				UntrustedNetwork:    "bzzz-untrusted",
+				ProxyURL:            "http://bzzz-egress-proxy:3128",
+				TrustedOwners:       []string{"anthonyrawlins"},
+				StatsInterval:       15 * time.Second,
+				MemoryKillThreshold: 0.95,
+				CommandTimeout:      10 * time.Minute,
+			},
+			SecretScan: SecretScanConfig{
+				Enabled: true,
+			},
+			MaxTaskFailures: 2,
+			FailureCooldown: 24 * time.Hour,
+			ClaimLease:      5 * time.Minute,
+			WarmUpModels:    true,
+			MaxDiff: DiffLimits{
+				MaxFiles: 25,
+				MaxLines: 1000,
+			},
+			Budget: BudgetConfig{
+				Default: Budget{MaxTokens: 500000, MaxModelTime: 30 * time.Minute},
+				TaskTypes: map[string]Budget{
+					"documentation": {MaxTokens: 150000, MaxModelTime: 10 * time.Minute},
+				},
+				AgentWindow: 24 * time.Hour,
+			},
 		},
 		GitHub: GitHubConfig{
 			TokenFile: "/home/tony/AI/secrets/passwords_and_tokens/gh-token",
@@ -119,12 +413,24 @@ func getDefaultConfig() *Config {
 			Timeout:   30 * time.Second,
 			RateLimit: true,
 			Assignee:  "anthonyrawlins",
+
+			TaskLabel:       "bzzz-task",
+			InProgressLabel: "in-progress",
+			CompletedLabel:  "completed",
+			NeedsHumanLabel: "bzzz-needs-human",
+			ApprovalLabel:   "bzzz-approved",
 		},
 		P2P: P2PConfig{
 			ServiceTag:              "bzzz-peer-discovery",
 			BzzzTopic:               "bzzz/coordination/v1",
 			AntennaeTopic:           "antennae/meta-discussion/v1",
 			DiscoveryTimeout:        10 * time.Second,
+			DynamicQueueSize:        64,
+			MaxMessageSize:          512 << 10,
+			MessageRateLimit:        20,
+			MessageBurst:            100,
+			MinCoordinationPeers:    1,
+			TelemetryInterval:       5 * time.Minute,
 			EscalationWebhook:       "https://n8n.home.deepblack.cloud/webhook-test/human-escalation",
 			EscalationKeywords:      []string{"stuck", "help", "human", "escalate", "clarification needed", "manual intervention"},
 			ConversationLimit:       10,
@@ -135,6 +441,14 @@ func getDefaultConfig() *Config {
 			Output:     "stdout",
 			Structured: false,
 		},
+		API: APIConfig{
+			ListenAddr: "127.0.0.1:8089",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "http://localhost:4318/v1/traces",
+			SampleRatio: 1,
+		},
 	}
 }
 
@@ -172,6 +486,13 @@ func loadFromEnv(config *Config) error {
 	if specialization := os.Getenv("BZZZ_AGENT_SPECIALIZATION"); specialization != "" {
 		config.Agent.Specialization = specialization
 	}
+	if idleShutdown := os.Getenv("BZZZ_IDLE_SHUTDOWN"); idleShutdown != "" {
+		duration, err := time.ParseDuration(idleShutdown)
+		if err != nil {
+			return fmt.Errorf("invalid BZZZ_IDLE_SHUTDOWN: %w", err)
+		}
+		config.Agent.IdleShutdown = duration
+	}
 	if modelWebhook := os.Getenv("BZZZ_MODEL_SELECTION_WEBHOOK"); modelWebhook != "" {
 		config.Agent.ModelSelectionWebhook = modelWebhook
 	}
@@ -180,47 +501,278 @@ func loadFromEnv(config *Config) error {
 	if tokenFile := os.Getenv("BZZZ_GITHUB_TOKEN_FILE"); tokenFile != "" {
 		config.GitHub.TokenFile = tokenFile
 	}
+	if webhookSecret := os.Getenv("BZZZ_GITHUB_WEBHOOK_SECRET"); webhookSecret != "" {
+		config.GitHub.WebhookSecret = webhookSecret
+	}
+	if baseURL := os.Getenv("BZZZ_GITHUB_BASE_URL"); baseURL != "" {
+		config.GitHub.BaseURL = baseURL
+	}
+	if extraTopics := os.Getenv("BZZZ_EXTRA_TOPICS"); extraTopics != "" {
+		config.P2P.ExtraTopics = strings.Split(extraTopics, ",")
+	}
+	if identityKeyFile := os.Getenv("BZZZ_IDENTITY_KEY_FILE"); identityKeyFile != "" {
+		config.P2P.IdentityKeyFile = identityKeyFile
+	}
+	if escalationToken := os.Getenv("BZZZ_ESCALATION_TOKEN"); escalationToken != "" {
+		config.API.EscalationToken = escalationToken
+	}
+	if controlToken := os.Getenv("BZZZ_CONTROL_TOKEN"); controlToken != "" {
+		config.API.ControlToken = controlToken
+	}
 	
 	// P2P configuration
 	if webhook := os.Getenv("BZZZ_ESCALATION_WEBHOOK"); webhook != "" {
 		config.P2P.EscalationWebhook = webhook
 	}
 	
+	// API configuration
+	if listenAddr := os.Getenv("BZZZ_API_LISTEN_ADDR"); listenAddr != "" {
+		config.API.ListenAddr = listenAddr
+	}
+	
 	// Logging configuration
 	if level := os.Getenv("BZZZ_LOG_LEVEL"); level != "" {
 		config.Logging.Level = level
 	}
 	
+	// Tracing configuration
+	if exporter := os.Getenv("BZZZ_TRACING_EXPORTER"); exporter != "" {
+		config.Tracing.Exporter = exporter
+	}
+	if endpoint := os.Getenv("BZZZ_TRACING_ENDPOINT"); endpoint != "" {
+		config.Tracing.Endpoint = endpoint
+	}
+	
 	return nil
 }
 
-// validateConfig validates the configuration values
+// validateConfig validates the configuration values, reporting the first problem
 func validateConfig(config *Config) error {
+	if problems := checkConfig(config); len(problems) > 0 {
+		return problems[0]
+	}
+	return nil
+}
+
+// checkConfig returns every problem with the configuration values
+func checkConfig(config *Config) []Problem {
+	var problems []Problem
+	problem := func(path, suggestion, format string, args ...interface{}) {
+		problems = append(problems, Problem{Path: path, Message: fmt.Sprintf(format, args...), Suggestion: suggestion})
+	}
+
 	// Validate required fields
 	if config.HiveAPI.BaseURL == "" {
-		return fmt.Errorf("hive_api.base_url is required")
+		problem("hive_api.base_url", "set it to the Hive API's URL, e.g. https://hive.example.com", "is required")
 	}
 	
 	// Note: Agent.ID can be empty - it will be auto-generated from node ID in main.go
 	
 	if len(config.Agent.Capabilities) == 0 {
-		return fmt.Errorf("agent.capabilities cannot be empty")
+		problem("agent.capabilities", "list what the agent can do, e.g. [general, code-generation]", "cannot be empty")
 	}
 	
 	if config.Agent.PollInterval <= 0 {
-		return fmt.Errorf("agent.poll_interval must be positive")
+		problem("agent.poll_interval", "use a duration such as 30s", "must be positive")
+	}
+	
+	if config.Agent.MaxPollInterval < config.Agent.PollInterval {
+		problem("agent.max_poll_interval", "raise it to at least agent.poll_interval, or lower agent.poll_interval", "cannot be shorter than agent.poll_interval")
+	}
+	
+	if config.HiveAPI.StatusBatchInterval < 0 {
+		problem("hive_api.status_batch_interval", "use 0 to send status updates immediately", "cannot be negative")
 	}
 	
 	if config.Agent.MaxTasks <= 0 {
-		return fmt.Errorf("agent.max_tasks must be positive")
+		problem("agent.max_tasks", "set how many tasks the agent may run at once, e.g. 3", "must be positive")
+	}
+	
+	switch {
+	case config.Agent.Sandbox.UntrustedNetwork == "none":
+		problem("agent.sandbox.untrusted_network", "use an internal network the proxy is attached to, such as the default bzzz-untrusted",
+			"cannot be \"none\": tasks from untrusted owners could not clone or push")
+	case config.Agent.Sandbox.UntrustedNetwork != "bridge" && config.Agent.Sandbox.ProxyURL == "":
+		problem("agent.sandbox.proxy_url", "set the egress proxy attached to agent.sandbox.untrusted_network",
+			"is required: the untrusted network is internal, so the proxy is its only way out")
+	}
+	
+	if config.Agent.Sandbox.MemoryKillThreshold < 0 || config.Agent.Sandbox.MemoryKillThreshold > 1 {
+		problem("agent.sandbox.memory_kill_threshold", "use a fraction of the memory limit such as 0.9, or 0 to disable", "must be between 0 and 1")
+	}
+
+	for _, language := range slices.Sorted(maps.Keys(config.Agent.Sandbox.Caches)) {
+		if cacheDir := config.Agent.Sandbox.Caches[language]; !filepath.IsAbs(cacheDir) {
+			problem("agent.sandbox.caches."+language, "use an absolute host directory, e.g. /var/cache/bzzz/"+language, "must be an absolute path, got %q", cacheDir)
+		}
+	}
+
+	if platform := config.Agent.Sandbox.Platform; platform != "" {
+		parts := strings.Split(platform, "/")
+		if len(parts) < 2 || len(parts) > 3 || strings.Contains(platform, "//") || strings.HasSuffix(platform, "/") || parts[0] == "" {
+			problem("agent.sandbox.platform", "use a platform such as linux/amd64 or linux/arm64/v8", "must look like os/arch or os/arch/variant, got %q", platform)
+		}
+	}
+	
+	if config.Agent.Budget.AgentWindow < 0 {
+		problem("agent.budget.agent_window", "use a duration such as 24h", "cannot be negative")
+	}
+	if config.Agent.Budget.Agent != (Budget{}) && config.Agent.Budget.AgentWindow == 0 {
+		problem("agent.budget.agent_window", "set the window the agent budget applies to, e.g. 24h, or remove agent.budget.agent", "must be set when agent.budget.agent is")
+	}
+	
+	if config.Agent.MinPriority < 0 || config.Agent.MaxPriority < 0 {
+		problem("agent.min_priority", "use 0 to leave either end of the priority band open", "priorities cannot be negative")
+	}
+	if config.Agent.MaxPriority > 0 && config.Agent.MinPriority > config.Agent.MaxPriority {
+		problem("agent.min_priority", "lower agent.min_priority or raise agent.max_priority", "cannot be above agent.max_priority")
+	}
+	if threshold := config.Agent.Confidence.Default; threshold < 0 || threshold > 1 {
+		problem("agent.confidence.default", "use a value such as 0.6, or 0 to disable the gate", "must be between 0 and 1, got %v", threshold)
+	}
+	for _, taskType := range slices.Sorted(maps.Keys(config.Agent.Confidence.TaskTypes)) {
+		if threshold := config.Agent.Confidence.TaskTypes[taskType]; threshold < 0 || threshold > 1 {
+			problem("agent.confidence.task_types."+taskType, "use a value such as 0.6, or 0 to disable the gate", "must be between 0 and 1, got %v", threshold)
+		}
+	}
+	if config.Agent.ReasoningTimeout.Default < 0 {
+		problem("agent.reasoning_timeout.default", "use a duration such as 2m, or 0 for the built-in 60s", "cannot be negative")
+	}
+	for _, taskType := range slices.Sorted(maps.Keys(config.Agent.ReasoningTimeout.TaskTypes)) {
+		if timeout := config.Agent.ReasoningTimeout.TaskTypes[taskType]; timeout <= 0 {
+			problem("agent.reasoning_timeout.task_types."+taskType, "use a duration such as 5m, or remove the entry", "must be positive, got %v", timeout)
+		}
+	}
+	if config.Agent.Sandbox.MaxConcurrent < 0 {
+		problem("agent.sandbox.max_concurrent", "use 0 for no limit", "cannot be negative")
+	}
+	if config.Agent.IdleShutdown < 0 {
+		problem("agent.idle_shutdown", "use 0 to keep the agent running while idle", "cannot be negative")
+	}
+	
+	if config.Agent.Clone.Depth < 0 {
+		problem("agent.clone.depth", "use 0 for a full clone", "cannot be negative")
+	}
+	
+	if config.Agent.MaxDiff.MaxFiles < 0 || config.Agent.MaxDiff.MaxLines < 0 {
+		problem("agent.max_diff", "use 0 to leave a limit off", "limits cannot be negative")
+	}
+	
+	if config.P2P.DynamicQueueSize <= 0 {
+		problem("p2p.dynamic_queue_size", "the default is 64", "must be positive")
+	}
+	
+	for _, topic := range config.P2P.ExtraTopics {
+		if strings.TrimSpace(topic) == "" {
+			problem("p2p.extra_topics", "remove the empty entry", "cannot contain an empty topic")
+		}
+	}
+	
+	// Gossipsub drops anything over 1 MiB, and chunks need room for their envelope
+	if size := config.P2P.MaxMessageSize; size < 4<<10 || size > 1<<20 {
+		problem("p2p.max_message_size", "the default is 524288 (512KiB)", "must be between 4KiB and 1MiB, got %d", size)
+	}
+	
+	if config.P2P.MessageRateLimit < 0 {
+		problem("p2p.message_rate_limit", "use 0 to disable the limit", "cannot be negative")
+	}
+	if config.P2P.MessageRateLimit > 0 && config.P2P.MessageBurst < 64 {
+		problem("p2p.message_burst", "the default is 100", "must be at least 64, the most chunks one message is split into, when p2p.message_rate_limit is set")
+	}
+	
+	if config.P2P.MinCoordinationPeers < 0 {
+		problem("p2p.min_coordination_peers", "use 0 to always coordinate, even with no peers", "cannot be negative")
+	}
+	for _, id := range config.P2P.IntrospectionPeers {
+		if _, err := peer.Decode(id); err != nil {
+			problem("p2p.introspection_peers", "use full peer IDs as printed at startup, e.g. 12D3KooW...", "%q is not a peer ID", id)
+		}
+	}
+	for _, id := range config.P2P.OperatorPeers {
+		if _, err := peer.Decode(id); err != nil {
+			problem("p2p.operator_peers", "use full peer IDs as printed at startup, e.g. 12D3KooW...", "%q is not a peer ID", id)
+		}
+	}
+	for _, repo := range slices.Sorted(maps.Keys(config.P2P.EscalationRoutes)) {
+		route := config.P2P.EscalationRoutes[repo]
+		if parsed, err := url.Parse(route.Webhook); err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			problem("p2p.escalation_routes."+repo+".webhook", "use the team's N8N webhook URL", "is not an http(s) URL: %q", route.Webhook)
+		}
+	}
+	
+	for _, sessionType := range slices.Sorted(maps.Keys(config.Coordination.SessionLimits)) {
+		if limits := config.Coordination.SessionLimits[sessionType]; limits.MaxDuration < 0 || limits.MaxParticipants < 0 || limits.EscalationThreshold < 0 {
+			problem("coordination.session_limits."+sessionType, "use 0 to fall back to the default limit", "cannot be negative")
+		}
+	}
+	
+	for _, field := range []struct{ path, text string }{
+		{"github.claim_comment", config.GitHub.ClaimComment},
+		{"github.pull_request_title", config.GitHub.PullRequestTitle},
+		{"github.pull_request_body", config.GitHub.PullRequestBody},
+	} {
+		if _, err := template.New(field.path).Parse(field.text); err != nil {
+			problem(field.path, "variables look like {{.IssueNumber}} or {{.AgentID}}", "is not a valid template: %v", err)
+		}
+	}
+	if config.Coordination.PlanPrompt != "" {
+		if _, err := template.New("plan_prompt").Parse(config.Coordination.PlanPrompt); err != nil {
+			problem("coordination.plan_prompt", "variables look like {{.Task1.Title}}", "is not a valid template: %v", err)
+		}
+	}
+	
+	if config.Reasoning.Cache.TTL < 0 || config.Reasoning.Cache.MaxEntries < 0 {
+		problem("reasoning.cache", "use 0 to leave a limit off", "limits cannot be negative")
+	}
+	if load := config.Reasoning.Load; load.DowngradeAbove < 0 || load.DowngradeAbove > 1 || load.RestoreBelow < 0 || load.RestoreBelow > 1 {
+		problem("reasoning.load", "use utilizations such as downgrade_above: 0.9 and restore_below: 0.7", "thresholds must be between 0 and 1")
+	} else if load.RestoreBelow > load.DowngradeAbove && load.DowngradeAbove > 0 {
+		problem("reasoning.load.restore_below", "set it below downgrade_above so selection doesn't flap", "cannot be above downgrade_above")
+	}
+	
+	switch config.Tracing.Exporter {
+	case "", "none", "stdout":
+	case "otlp":
+		if config.Tracing.Endpoint == "" {
+			problem("tracing.endpoint", "set the collector's OTLP/HTTP traces URL, e.g. http://localhost:4318/v1/traces", "is required for the otlp exporter")
+		}
+	default:
+		problem("tracing.exporter", "use none, stdout or otlp", "must be none, stdout or otlp, got %q", config.Tracing.Exporter)
+	}
+	if config.Tracing.SampleRatio < 0 || config.Tracing.SampleRatio > 1 {
+		problem("tracing.sample_ratio", "use a fraction such as 0.1, or 1 to trace everything", "must be between 0 and 1")
+	}
+	
+	if reviewer := config.Reasoning.ReviewerModel; reviewer != "" {
+		if reviewer == config.Agent.DefaultReasoningModel {
+			problem("reasoning.reviewer_model", "pick a different model so the review is independent", "must differ from agent.default_reasoning_model")
+		}
+		if len(config.Agent.Models) > 0 && !contains(config.Agent.Models, reviewer) {
+			problem("reasoning.reviewer_model", "add it to agent.models or pick one of those", "%q is not in agent.models", reviewer)
+		}
 	}
 	
 	// Validate GitHub token file exists if specified
 	if config.GitHub.TokenFile != "" && !fileExists(config.GitHub.TokenFile) {
-		return fmt.Errorf("github token file does not exist: %s", config.GitHub.TokenFile)
+		problem("github.token_file", "point it at a readable file containing the token", "does not exist: %s", config.GitHub.TokenFile)
 	}
 	
-	return nil
+	for _, field := range []struct{ path, raw string }{
+		{"github.base_url", config.GitHub.BaseURL},
+		{"github.upload_url", config.GitHub.UploadURL},
+	} {
+		if field.raw == "" {
+			continue
+		}
+		if parsed, err := url.Parse(field.raw); err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			problem(field.path, "use the Enterprise server's API URL, e.g. https://github.example.com/api/v3/", "is not an http(s) URL: %q", field.raw)
+		}
+	}
+	if config.GitHub.UploadURL != "" && config.GitHub.BaseURL == "" {
+		problem("github.upload_url", "set github.base_url too, or remove upload_url to use github.com", "has no effect without github.base_url")
+	}
+	
+	return problems
 }
 
 // SaveConfig saves the configuration to a YAML file
@@ -251,12 +803,35 @@ func (c *Config) GetGitHubToken() (string, error) {
 	return strings.TrimSpace(string(tokenBytes)), nil
 }
 
+// GetOwnerGitHubTokens reads the per-owner GitHub tokens
+func (c *Config) GetOwnerGitHubTokens() (map[string]string, error) {
+	tokens := make(map[string]string, len(c.GitHub.OwnerTokenFiles))
+	for owner, tokenFile := range c.GitHub.OwnerTokenFiles {
+		tokenBytes, err := ioutil.ReadFile(tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GitHub token for %s: %w", owner, err)
+		}
+		tokens[owner] = strings.TrimSpace(string(tokenBytes))
+	}
+	return tokens, nil
+}
+
 // fileExists checks if a file exists
 func fileExists(filePath string) bool {
 	_, err := os.Stat(filePath)
 	return err == nil
 }
 
+// contains reports whether list holds value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
 // GenerateDefaultConfigFile creates a default configuration file
 func GenerateDefaultConfigFile(filePath string) error {
 	config := getDefaultConfig()
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Problem is one thing wrong with a configuration
+type Problem struct {
+	Path       string // YAML path of the field, e.g. agent.max_tasks
+	Line       int    // Line in the config file the field is on; 0 if it isn't set there
+	Message    string
+	Suggestion string // How to fix it, if there's an obvious way
+}
+
+// Error describes the problem the way validation always has, e.g. "agent.max_tasks must be positive"
+func (p Problem) Error() string {
+	if p.Path == "" {
+		return p.Message
+	}
+	return p.Path + " " + p.Message
+}
+
+// typeErrorLine picks the line number out of a YAML decoding error
+var typeErrorLine = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// ValidateFile checks a config file on top of the defaults, without the
+// environment overrides the node would also apply. Unknown fields, values of
+// the wrong type and invalid settings are all reported, in file order. The
+// error is only for a file that can't be read or isn't YAML at all.
+func ValidateFile(filePath string) ([]Problem, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var problems []Problem
+	config := getDefaultConfig()
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("failed to parse YAML config: %w", err)
+		}
+		// The rest of the file still decoded, so carry on and check it
+		for _, decodeErr := range typeErr.Errors {
+			problem := Problem{Message: decodeErr, Suggestion: "check the field name and value type against the documentation"}
+			if match := typeErrorLine.FindStringSubmatch(decodeErr); match != nil {
+				problem.Line, _ = strconv.Atoi(match[1])
+				problem.Message = match[2]
+			}
+			problems = append(problems, problem)
+		}
+	}
+
+	for _, problem := range checkConfig(config) {
+		problem.Line = findLine(data, problem.Path)
+		problems = append(problems, problem)
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+	return problems, nil
+}
+
+// findLine returns the line a dotted YAML path is set on, or 0 if it isn't in
+// the file. It follows block-style mappings by indentation, which is how config
+// files are written.
+func findLine(data []byte, path string) int {
+	if path == "" {
+		return 0
+	}
+	type level struct {
+		indent int
+		key    string
+	}
+	var stack []level
+
+	for i, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "-") {
+			continue
+		}
+		colon := strings.Index(trimmed, ":")
+		if colon <= 0 {
+			continue
+		}
+		indent := len(line) - len(trimmed)
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, level{indent: indent, key: strings.Trim(trimmed[:colon], `"'`)})
+
+		keys := make([]string, len(stack))
+		for j, l := range stack {
+			keys[j] = l.key
+		}
+		if strings.Join(keys, ".") == path {
+			return i + 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateFileReportsEachProblemWithItsLine(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenFile, []byte("ghp_test"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "bzzz.yaml")
+	contents := `github:
+  token_file: ` + tokenFile + `
+agent:
+  max_tasks: 0
+  poll_intervl: 10s
+  sandbox:
+    platform: linux
+tracing:
+  exporter: jaeger
+`
+	if err := os.WriteFile(configPath, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := ValidateFile(configPath)
+	if err != nil {
+		t.Fatalf("ValidateFile failed: %v", err)
+	}
+
+	want := []struct {
+		path string
+		line int
+	}{
+		{"agent.max_tasks", 4},
+		{"", 5}, // The misspelt field
+		{"agent.sandbox.platform", 7},
+		{"tracing.exporter", 9},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("expected %d problems, got %d: %v", len(want), len(problems), problems)
+	}
+	for i, expected := range want {
+		got := problems[i]
+		if got.Path != expected.path || got.Line != expected.line {
+			t.Errorf("problem %d: expected %q on line %d, got %q on line %d (%s)", i, expected.path, expected.line, got.Path, got.Line, got.Error())
+		}
+		if got.Suggestion == "" {
+			t.Errorf("problem %d (%s) has no suggested fix", i, got.Error())
+		}
+	}
+	if problems[0].Error() != "agent.max_tasks must be positive" {
+		t.Errorf("unexpected message %q", problems[0].Error())
+	}
+}
+
+func TestConfigProblemsComeOutInTheSameOrder(t *testing.T) {
+	config := getDefaultConfig()
+	config.Agent.Confidence.TaskTypes = map[string]float64{"frontend": 2, "backend": -1, "docs": 3, "infra": 9}
+	config.Agent.ReasoningTimeout.TaskTypes = map[string]time.Duration{"frontend": -1, "backend": -1}
+
+	want := []string{
+		"agent.confidence.task_types.backend",
+		"agent.confidence.task_types.docs",
+		"agent.confidence.task_types.frontend",
+		"agent.confidence.task_types.infra",
+		"agent.reasoning_timeout.task_types.backend",
+		"agent.reasoning_timeout.task_types.frontend",
+	}
+	for run := 0; run < 10; run++ {
+		var got []string
+		for _, problem := range checkConfig(config) {
+			if strings.Contains(problem.Path, "task_types") {
+				got = append(got, problem.Path)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("run %d: expected problems in order %v, got %v", run, want, got)
+		}
+	}
+}
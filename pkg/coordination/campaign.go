@@ -0,0 +1,446 @@
+package coordination
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Campaign tracks a multi-repository goal that spans many tasks, possibly over
+// days. Each task gets its own coordination session as it comes up; the campaign
+// outlives them and is only done once every task is.
+type Campaign struct {
+	ID        string          `json:"id"`
+	Goal      string          `json:"goal"`
+	Tasks     []*CampaignTask `json:"tasks"`
+	Status    string          `json:"status"` // active, completed
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// CampaignTask is one task a campaign needs done
+type CampaignTask struct {
+	Task      *TaskContext `json:"task"`
+	Status    string       `json:"status"`               // pending, active, completed, escalated
+	SessionID string       `json:"session_id,omitempty"` // The task's latest coordination session
+	UpdatedAt time.Time    `json:"updated_at"`           // When Status last changed, to merge updates from other nodes
+}
+
+// Progress returns how many of the campaign's tasks are completed, out of all of them
+func (c *Campaign) Progress() (completed, total int) {
+	for _, task := range c.Tasks {
+		if task.Status == "completed" {
+			completed++
+		}
+	}
+	return completed, len(c.Tasks)
+}
+
+// campaignSessionID names the coordination session for one of a campaign's tasks
+func campaignSessionID(campaignID string, task *TaskContext) string {
+	return fmt.Sprintf("campaign_%s_%d_%d", campaignID, task.ProjectID, task.TaskID)
+}
+
+// SetCampaignFile persists campaigns to path so they survive restarts, loading
+// any saved by an earlier run. Without it campaigns are kept in memory only.
+func (mc *MetaCoordinator) SetCampaignFile(path string) {
+	mc.campaignLock.Lock()
+	defer mc.campaignLock.Unlock()
+
+	mc.campaignPath = path
+	mc.campaigns = make(map[string]*Campaign)
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &mc.campaigns); err != nil {
+			fmt.Printf("⚠️ Ignoring unreadable campaign file %s: %v\n", path, err)
+			mc.campaigns = make(map[string]*Campaign)
+		}
+	}
+}
+
+// StartCampaign begins tracking a goal made up of tasks, none of them started
+// yet, and shares it with the other coordinators
+func (mc *MetaCoordinator) StartCampaign(id, goal string, tasks []*TaskContext) (*Campaign, error) {
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("campaign %s has no tasks", id)
+	}
+
+	mc.campaignLock.Lock()
+	if mc.campaigns == nil {
+		mc.campaigns = make(map[string]*Campaign)
+	}
+	if _, exists := mc.campaigns[id]; exists {
+		mc.campaignLock.Unlock()
+		return nil, fmt.Errorf("campaign %s already exists", id)
+	}
+
+	now := time.Now()
+	campaign := &Campaign{ID: id, Goal: goal, Status: "active", CreatedAt: now, UpdatedAt: now}
+	for _, task := range tasks {
+		campaign.Tasks = append(campaign.Tasks, &CampaignTask{Task: task, Status: "pending", UpdatedAt: now})
+	}
+	mc.campaigns[id] = campaign
+	mc.saveCampaigns()
+	shared := copyCampaign(campaign)
+	mc.campaignLock.Unlock()
+
+	mc.shareCampaign(shared)
+	fmt.Printf("🏁 Started campaign %s with %d tasks: %s\n", id, len(tasks), goal)
+	return &shared, nil
+}
+
+// StartCampaignSession opens a coordination session for one of a campaign's
+// tasks, e.g. once an agent claims it. The campaign stays active when the
+// session ends; only the task's own outcome settles it.
+func (mc *MetaCoordinator) StartCampaignSession(campaignID string, projectID, taskID int) (*CoordinationSession, error) {
+	mc.campaignLock.Lock()
+	campaign, exists := mc.campaigns[campaignID]
+	if !exists {
+		mc.campaignLock.Unlock()
+		return nil, fmt.Errorf("no campaign %s", campaignID)
+	}
+	var entry *CampaignTask
+	for _, task := range campaign.Tasks {
+		if task.Task.ProjectID == projectID && task.Task.TaskID == taskID {
+			entry = task
+		}
+	}
+	if entry == nil {
+		mc.campaignLock.Unlock()
+		return nil, fmt.Errorf("task %s is not part of campaign %s", taskKey(projectID, taskID), campaignID)
+	}
+	details := *entry.Task
+	task, goal, sessionID := &details, campaign.Goal, campaignSessionID(campaignID, entry.Task)
+	entry.SessionID = sessionID
+	setCampaignTaskStatus(campaign, entry, "active")
+	mc.saveCampaigns()
+	shared := copyCampaign(campaign)
+	mc.campaignLock.Unlock()
+
+	session := &CoordinationSession{
+		SessionID:     sessionID,
+		Type:          "planning",
+		Participants:  make(map[string]*Participant),
+		TasksInvolved: []*TaskContext{task},
+		Messages:      []CoordinationMessage{},
+		Status:        "active",
+		CreatedAt:     time.Now(),
+		LastActivity:  time.Now(),
+		Owner:         mc.selfID.String(),
+		Campaign:      campaignID,
+	}
+	if task.AgentID != "" {
+		session.Participants[task.AgentID] = &Participant{AgentID: task.AgentID, Repository: task.Repository, LastSeen: time.Now(), Active: true}
+	}
+
+	plan := fmt.Sprintf("Campaign %s: %s\nThis session covers task #%d in %s: %s", campaignID, goal, task.TaskID, task.Repository, task.Title)
+	session.Messages = append(session.Messages, planMessage(session.SessionID, plan, session.CreatedAt))
+
+	mc.sessionLock.Lock()
+	mc.activeSessions[session.SessionID] = session
+	mc.sessionLock.Unlock()
+
+	// Announced like any plan, so other nodes can take the session over, and
+	// with the campaign itself so whoever does knows where it stands
+	mc.broadcastToSession(session, map[string]interface{}{
+		"message_type":   "coordination_plan",
+		"session_id":     session.SessionID,
+		"session_type":   session.Type,
+		"campaign":       campaignID,
+		"campaign_state": shared,
+		"plan":           plan,
+		"tasks_involved": session.TasksInvolved,
+		"participants":   session.Participants,
+		"message":        fmt.Sprintf("Coordination session for campaign %s", campaignID),
+	})
+	fmt.Printf("🎯 Started session %s for campaign %s\n", session.SessionID, campaignID)
+	return session, nil
+}
+
+// campaignTaskStatuses maps the log entries that move a campaign task along to
+// the status they leave it in
+var campaignTaskStatuses = map[logging.LogType]string{
+	logging.TaskClaimed:   "active",
+	logging.TaskCompleted: "completed", // Logged once the task's pull request is open
+	logging.TaskFailed:    "pending",   // Up for another attempt
+	logging.Escalation:    "escalated",
+}
+
+// FollowTaskLog keeps campaigns up to date with this agent's work on their
+// tasks as it is logged to hlog: claiming a task opens its coordination
+// session, and the task's own outcome, not the session's, settles it
+func (mc *MetaCoordinator) FollowTaskLog(hlog *logging.HypercoreLog) {
+	for _, entry := range hlog.Subscribe(mc.campaignTaskLogged) {
+		mc.campaignTaskLogged(entry)
+	}
+}
+
+// campaignTaskLogged moves the campaign tasks a log entry is about along, and
+// shares the campaigns that changed
+func (mc *MetaCoordinator) campaignTaskLogged(entry logging.LogEntry) {
+	status, tracked := campaignTaskStatuses[entry.Type]
+	repository, _ := entry.Data["repository"].(string)
+	taskID, hasTask := toInt(entry.Data["task_id"])
+	if !tracked || !hasTask || repository == "" {
+		return
+	}
+
+	type claim struct {
+		campaignID string
+		task       *TaskContext
+	}
+	var claimed []claim
+	var changed []Campaign
+
+	mc.campaignLock.Lock()
+	for _, campaign := range mc.campaigns {
+		task := campaign.task(repository, taskID)
+		switch {
+		case task == nil:
+			continue
+		case entry.Type == logging.TaskClaimed:
+			if task.Status == "pending" {
+				task.Task.AgentID, _ = entry.Data["agent_id"].(string)
+				claimed = append(claimed, claim{campaign.ID, task.Task})
+			}
+		case task.Status == "completed", task.Status == "escalated" && status == "pending":
+			// A finished task stays finished, and a failure doesn't undo an escalation
+		default:
+			setCampaignTaskStatus(campaign, task, status)
+			changed = append(changed, copyCampaign(campaign))
+		}
+	}
+	if len(changed) > 0 {
+		mc.saveCampaigns()
+	}
+	mc.campaignLock.Unlock()
+
+	for _, campaign := range changed {
+		mc.shareCampaign(campaign)
+	}
+	for _, c := range claimed {
+		if _, err := mc.StartCampaignSession(c.campaignID, c.task.ProjectID, c.task.TaskID); err != nil {
+			fmt.Printf("❌ Failed to start a session for campaign %s: %v\n", c.campaignID, err)
+		}
+	}
+}
+
+// toInt reads a number from log data, which holds ints as logged and float64s
+// once decoded from JSON
+func toInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
+
+// task returns the campaign's entry for issue taskID in repository, or nil
+func (c *Campaign) task(repository string, taskID int) *CampaignTask {
+	for _, task := range c.Tasks {
+		if task.Task.Repository == repository && task.Task.TaskID == taskID {
+			return task
+		}
+	}
+	return nil
+}
+
+// setCampaignTaskStatus moves one of campaign's tasks to status, completing the
+// campaign once every task is; callers must hold campaignLock
+func setCampaignTaskStatus(campaign *Campaign, task *CampaignTask, status string) {
+	task.Status = status
+	task.UpdatedAt = time.Now()
+	campaign.UpdatedAt = task.UpdatedAt
+	if status == "completed" || status == "escalated" {
+		completed, total := campaign.Progress()
+		fmt.Printf("📈 Campaign %s: %d of %d tasks done\n", campaign.ID, completed, total)
+	}
+	settleCampaign(campaign)
+}
+
+// settleCampaign marks campaign completed once all of its tasks are
+func settleCampaign(campaign *Campaign) {
+	if completed, total := campaign.Progress(); completed == total && campaign.Status != "completed" {
+		campaign.Status = "completed"
+		fmt.Printf("🏆 Campaign %s completed: %s\n", campaign.ID, campaign.Goal)
+	}
+}
+
+// shareCampaign tells the other coordinators where campaign stands, so any of
+// them can carry on with it
+func (mc *MetaCoordinator) shareCampaign(campaign Campaign) {
+	err := mc.pubsub.PublishAntennaeMessage(pubsub.MetaDiscussion, map[string]interface{}{
+		"message_type": "campaign_update",
+		"campaign":     campaign,
+	})
+	if err != nil {
+		fmt.Printf("⚠️ Failed to share campaign %s: %v\n", campaign.ID, err)
+	}
+}
+
+// handleCampaignUpdate adopts what another coordinator knows about a campaign
+func (mc *MetaCoordinator) handleCampaignUpdate(msg pubsub.Message, from peer.ID) {
+	campaign, err := parseCampaign(msg.Data["campaign"])
+	if err != nil {
+		fmt.Printf("❌ Ignoring campaign update from %s: %v\n", from.ShortString(), err)
+		return
+	}
+	mc.adoptCampaign(campaign)
+}
+
+// parseCampaign reads a campaign shared over pubsub
+func parseCampaign(value interface{}) (*Campaign, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read campaign: %w", err)
+	}
+	var campaign Campaign
+	if err := json.Unmarshal(data, &campaign); err != nil {
+		return nil, fmt.Errorf("failed to read campaign: %w", err)
+	}
+	if campaign.ID == "" {
+		return nil, fmt.Errorf("campaign has no ID")
+	}
+	for _, task := range campaign.Tasks {
+		if task == nil || task.Task == nil {
+			return nil, fmt.Errorf("campaign %s has a task without details", campaign.ID)
+		}
+	}
+	return &campaign, nil
+}
+
+// adoptCampaign merges a campaign shared by another node into this node's
+// copy. Each task keeps whichever status was set last, so nodes working on
+// different tasks of the same campaign don't undo each other's progress.
+func (mc *MetaCoordinator) adoptCampaign(shared *Campaign) {
+	mc.campaignLock.Lock()
+	defer mc.campaignLock.Unlock()
+	if mc.campaigns == nil {
+		mc.campaigns = make(map[string]*Campaign)
+	}
+
+	campaign, known := mc.campaigns[shared.ID]
+	if !known {
+		settleCampaign(shared)
+		mc.campaigns[shared.ID] = shared
+		mc.saveCampaigns()
+		fmt.Printf("🏁 Following campaign %s: %s\n", shared.ID, shared.Goal)
+		return
+	}
+
+	changed := false
+	for _, theirs := range shared.Tasks {
+		ours := campaign.task(theirs.Task.Repository, theirs.Task.TaskID)
+		if ours == nil || !theirs.UpdatedAt.After(ours.UpdatedAt) {
+			continue
+		}
+		ours.Status, ours.SessionID, ours.UpdatedAt = theirs.Status, theirs.SessionID, theirs.UpdatedAt
+		if theirs.UpdatedAt.After(campaign.UpdatedAt) {
+			campaign.UpdatedAt = theirs.UpdatedAt
+		}
+		changed = true
+	}
+	if changed {
+		settleCampaign(campaign)
+		mc.saveCampaigns()
+	}
+}
+
+// Campaigns returns a copy of every campaign
+func (mc *MetaCoordinator) Campaigns() []Campaign {
+	mc.campaignLock.Lock()
+	defer mc.campaignLock.Unlock()
+
+	campaigns := make([]Campaign, 0, len(mc.campaigns))
+	for _, campaign := range mc.campaigns {
+		campaigns = append(campaigns, copyCampaign(campaign))
+	}
+	sort.Slice(campaigns, func(i, j int) bool { return campaigns[i].CreatedAt.Before(campaigns[j].CreatedAt) })
+	return campaigns
+}
+
+// CampaignHandler lets callers presenting token as a bearer token follow and
+// start campaigns:
+//
+//	GET  /campaigns  every campaign with its tasks' progress
+//	POST /campaigns  start one from {"id": ..., "goal": ..., "tasks": [...]}
+func (mc *MetaCoordinator) CampaignHandler(token []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer := []byte(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if subtle.ConstantTimeCompare(bearer, token) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(mc.Campaigns())
+
+		case http.MethodPost:
+			var body struct {
+				ID    string         `json:"id"`
+				Goal  string         `json:"goal"`
+				Tasks []*TaskContext `json:"tasks"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ID == "" || len(body.Tasks) == 0 || slices.Contains(body.Tasks, nil) {
+				http.Error(w, "expected a campaign with an id, goal and tasks", http.StatusBadRequest)
+				return
+			}
+			campaign, err := mc.StartCampaign(body.ID, body.Goal, body.Tasks)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(campaign)
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// copyCampaign copies campaign and its tasks; callers must hold campaignLock
+func copyCampaign(campaign *Campaign) Campaign {
+	copied := *campaign
+	copied.Tasks = make([]*CampaignTask, len(campaign.Tasks))
+	for i, task := range campaign.Tasks {
+		entry, details := *task, *task.Task
+		entry.Task = &details
+		copied.Tasks[i] = &entry
+	}
+	return copied
+}
+
+// saveCampaigns writes the campaigns to disk; callers must hold campaignLock
+func (mc *MetaCoordinator) saveCampaigns() {
+	if mc.campaignPath == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(mc.campaignPath), 0755); err != nil {
+		fmt.Printf("⚠️ Failed to persist campaigns: %v\n", err)
+		return
+	}
+	data, err := json.MarshalIndent(mc.campaigns, "", "  ")
+	if err != nil {
+		fmt.Printf("⚠️ Failed to persist campaigns: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(mc.campaignPath, data, 0644); err != nil {
+		fmt.Printf("⚠️ Failed to persist campaigns: %v\n", err)
+	}
+}
@@ -0,0 +1,130 @@
+package coordination
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/logging"
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func newCampaignCoordinator(t *testing.T) *MetaCoordinator {
+	ps := newTestPubSub(t)
+	return &MetaCoordinator{
+		pubsub:         ps,
+		ctx:            context.Background(),
+		activeSessions: make(map[string]*CoordinationSession),
+		selfID:         ps.ID(),
+	}
+}
+
+func TestCampaignTasksCompleteOnTheirOwnOutcome(t *testing.T) {
+	mc := newCampaignCoordinator(t)
+	path := filepath.Join(t.TempDir(), "campaigns.json")
+	mc.SetCampaignFile(path)
+	hlog := logging.NewHypercoreLog(mc.selfID)
+	mc.FollowTaskLog(hlog)
+
+	tasks := []*TaskContext{
+		{ProjectID: 1, TaskID: 10, Repository: "acme/api", Title: "Add v2 endpoint"},
+		{ProjectID: 2, TaskID: 20, Repository: "acme/client", Title: "Switch client to v2"},
+	}
+	if _, err := mc.StartCampaign("api-v2", "Move everything to the v2 API", tasks); err != nil {
+		t.Fatalf("failed to start campaign: %v", err)
+	}
+
+	// Claiming the task opens its session
+	hlog.Append(logging.TaskClaimed, map[string]interface{}{"task_id": 10, "repository": "acme/api", "agent_id": "agent-a"})
+	session, exists := mc.GetActiveSessions()[campaignSessionID("api-v2", tasks[0])]
+	if !exists {
+		t.Fatal("expected claiming a campaign task to open its session")
+	}
+	if session.Campaign != "api-v2" || session.Participants["agent-a"] == nil {
+		t.Fatalf("session not linked to its campaign and claimant: %q, %v", session.Campaign, session.Participants)
+	}
+
+	// The session agreeing on a plan doesn't get the task done
+	mc.resolveSession(session, "endpoint design agreed")
+	if status := mc.Campaigns()[0].Tasks[0].Status; status != "active" {
+		t.Fatalf("a resolved session must not complete its task, got %s", status)
+	}
+
+	// The same issue number in another repository is a different task
+	hlog.Append(logging.TaskCompleted, map[string]interface{}{"task_id": 10, "repository": "acme/other", "pr_number": 3})
+	hlog.Append(logging.TaskCompleted, map[string]interface{}{"task_id": 10, "repository": "acme/api", "pr_number": 4})
+
+	// Reload from disk, as a restarted node would
+	reloaded := &MetaCoordinator{}
+	reloaded.SetCampaignFile(path)
+
+	for _, m := range []*MetaCoordinator{mc, reloaded} {
+		campaigns := m.Campaigns()
+		if len(campaigns) != 1 {
+			t.Fatalf("expected 1 campaign, got %d", len(campaigns))
+		}
+		campaign := campaigns[0]
+		if campaign.Status != "active" {
+			t.Fatalf("campaign should stay active with a task left, got %s", campaign.Status)
+		}
+		if campaign.Tasks[0].Status != "completed" || campaign.Tasks[1].Status != "pending" {
+			t.Fatalf("unexpected task statuses: %s, %s", campaign.Tasks[0].Status, campaign.Tasks[1].Status)
+		}
+		if completed, total := campaign.Progress(); completed != 1 || total != 2 {
+			t.Fatalf("expected 1 of 2 tasks done, got %d of %d", completed, total)
+		}
+	}
+
+	// A later failure doesn't reopen a finished task
+	hlog.Append(logging.TaskFailed, map[string]interface{}{"task_id": 10, "repository": "acme/api", "reason": "cancelled"})
+	if status := mc.Campaigns()[0].Tasks[0].Status; status != "completed" {
+		t.Fatalf("a failure must not reopen a completed task, got %s", status)
+	}
+}
+
+func TestCampaignStateFollowsTheSessionToAnotherNode(t *testing.T) {
+	nodeA, nodeB := newCampaignCoordinator(t), newCampaignCoordinator(t)
+	logA, logB := logging.NewHypercoreLog(nodeA.selfID), logging.NewHypercoreLog(nodeB.selfID)
+	nodeA.FollowTaskLog(logA)
+	nodeB.FollowTaskLog(logB)
+	update := func(from *MetaCoordinator, to *MetaCoordinator) {
+		to.handleMetaMessage(pubsub.Message{Timestamp: time.Now(), Data: map[string]interface{}{
+			"message_type": "campaign_update",
+			"campaign":     from.Campaigns()[0],
+		}}, peer.ID("peer-a"))
+	}
+
+	tasks := []*TaskContext{
+		{ProjectID: 1, TaskID: 10, Repository: "acme/api", Title: "Add v2 endpoint"},
+		{ProjectID: 2, TaskID: 20, Repository: "acme/client", Title: "Switch client to v2"},
+	}
+	if _, err := nodeA.StartCampaign("api-v2", "Move everything to the v2 API", tasks); err != nil {
+		t.Fatalf("failed to start campaign: %v", err)
+	}
+	update(nodeA, nodeB)
+	if campaigns := nodeB.Campaigns(); len(campaigns) != 1 || campaigns[0].Goal != "Move everything to the v2 API" {
+		t.Fatalf("expected node B to learn of the campaign, got %+v", campaigns)
+	}
+
+	// Each node works on a different task; neither undoes the other's progress
+	logA.Append(logging.TaskClaimed, map[string]interface{}{"task_id": 10, "repository": "acme/api", "agent_id": "agent-a"})
+	logB.Append(logging.TaskClaimed, map[string]interface{}{"task_id": 20, "repository": "acme/client", "agent_id": "agent-b"})
+	logB.Append(logging.TaskCompleted, map[string]interface{}{"task_id": 20, "repository": "acme/client", "pr_number": 5})
+	update(nodeA, nodeB)
+	update(nodeB, nodeA)
+
+	// Node A leaves before its task is done; node B carries on and finishes it
+	logB.Append(logging.TaskCompleted, map[string]interface{}{"task_id": 10, "repository": "acme/api", "pr_number": 6})
+	campaign := nodeB.Campaigns()[0]
+	if campaign.Status != "completed" {
+		t.Fatalf("expected the campaign completed once both tasks are, got %s with %s, %s",
+			campaign.Status, campaign.Tasks[0].Status, campaign.Tasks[1].Status)
+	}
+
+	update(nodeB, nodeA)
+	if status := nodeA.Campaigns()[0].Status; status != "completed" {
+		t.Fatalf("expected node A to learn the campaign completed, got %s", status)
+	}
+}
@@ -0,0 +1,164 @@
+package coordination
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pubsub"
+)
+
+// taskKey identifies a task across repositories, matching DependencyDetector's keys
+func taskKey(projectID, taskID int) string {
+	return fmt.Sprintf("%d:%d", projectID, taskID)
+}
+
+// recordWait notes that a task in the session is blocked on another task, then
+// checks every active session for circular waiting. The waiting task is agentID's
+// unless waiting names it. A cycle can't resolve on its own, so the sessions
+// involved are escalated at once rather than left to time out.
+func (mc *MetaCoordinator) recordWait(session *CoordinationSession, agentID, waiting, blocking string) {
+	mc.sessionLock.Lock()
+	for _, task := range session.TasksInvolved {
+		if waiting == "" && task != nil && task.AgentID == agentID {
+			waiting = taskKey(task.ProjectID, task.TaskID)
+			break
+		}
+	}
+	if waiting == "" || waiting == blocking {
+		mc.sessionLock.Unlock()
+		return
+	}
+	if session.Waits == nil {
+		session.Waits = make(map[string]string)
+	}
+	session.Waits[waiting] = blocking
+
+	cycle, deadlocked := mc.findDeadlockLocked()
+	mc.sessionLock.Unlock()
+
+	if len(cycle) == 0 {
+		return
+	}
+	reason := fmt.Sprintf("Deadlock detected - circular wait %s - human intervention needed", strings.Join(cycle, " -> "))
+	for _, s := range deadlocked {
+		mc.escalateSession(s, reason)
+	}
+}
+
+// TaskWaiting tells the sessions waiting is part of that it can't start until
+// blocking is done, with a coordination response carrying waiting_on, so that
+// circular waits across sessions are caught. Each session hears of a wait once.
+func (mc *MetaCoordinator) TaskWaiting(agentID string, waiting, blocking *TaskContext) {
+	waitingKey, blockingKey := taskKey(waiting.ProjectID, waiting.TaskID), taskKey(blocking.ProjectID, blocking.TaskID)
+
+	mc.sessionLock.RLock()
+	var sessions []*CoordinationSession
+	for _, session := range mc.activeSessions {
+		if session.Status != "active" || session.Waits[waitingKey] == blockingKey {
+			continue
+		}
+		for _, task := range session.TasksInvolved {
+			if task != nil && taskKey(task.ProjectID, task.TaskID) == waitingKey {
+				sessions = append(sessions, session)
+				break
+			}
+		}
+	}
+	mc.sessionLock.RUnlock()
+
+	for _, session := range sessions {
+		data := map[string]interface{}{
+			"message_type": "coordination_response",
+			"session_id":   session.SessionID,
+			"agent_id":     agentID,
+			"response":     fmt.Sprintf("Task #%d in %s is waiting on #%d in %s", waiting.TaskID, waiting.Repository, blocking.TaskID, blocking.Repository),
+			"task":         map[string]interface{}{"project_id": waiting.ProjectID, "task_id": waiting.TaskID},
+			"waiting_on":   map[string]interface{}{"project_id": blocking.ProjectID, "task_id": blocking.TaskID},
+		}
+		mc.broadcastToSession(session, data)
+		// This node doesn't hear its own broadcasts
+		mc.handleCoordinationResponse(pubsub.Message{Timestamp: time.Now(), Data: data}, mc.selfID)
+	}
+}
+
+// findDeadlockLocked looks for a cycle in the wait-for graph built from all active
+// sessions. It returns the cycle (first task repeated at the end) and the sessions
+// whose waits form it. sessionLock must be held.
+func (mc *MetaCoordinator) findDeadlockLocked() ([]string, []*CoordinationSession) {
+	edges := make(map[string]map[string]*CoordinationSession) // waiting -> blocking -> session
+	for _, session := range mc.activeSessions {
+		if session.Status != "active" {
+			continue
+		}
+		for waiting, blocking := range session.Waits {
+			if edges[waiting] == nil {
+				edges[waiting] = make(map[string]*CoordinationSession)
+			}
+			edges[waiting][blocking] = session
+		}
+	}
+
+	// Walk tasks in a fixed order so every peer reports the same cycle
+	starts := make([]string, 0, len(edges))
+	for waiting := range edges {
+		starts = append(starts, waiting)
+	}
+	sort.Strings(starts)
+
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(task string) []string
+	visit = func(task string) []string {
+		state[task] = onPath
+		path = append(path, task)
+
+		next := make([]string, 0, len(edges[task]))
+		for blocking := range edges[task] {
+			next = append(next, blocking)
+		}
+		sort.Strings(next)
+		for _, blocking := range next {
+			switch state[blocking] {
+			case onPath:
+				for i, t := range path {
+					if t == blocking {
+						return append(append([]string{}, path[i:]...), blocking)
+					}
+				}
+			case unvisited:
+				if cycle := visit(blocking); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[task] = done
+		return nil
+	}
+
+	for _, start := range starts {
+		if state[start] != unvisited {
+			continue
+		}
+		if cycle := visit(start); cycle != nil {
+			seen := make(map[*CoordinationSession]bool)
+			var sessions []*CoordinationSession
+			for i := 0; i < len(cycle)-1; i++ {
+				if s := edges[cycle[i]][cycle[i+1]]; !seen[s] {
+					seen[s] = true
+					sessions = append(sessions, s)
+				}
+			}
+			return cycle, sessions
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,110 @@
+package coordination
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	blankhost "github.com/libp2p/go-libp2p/p2p/host/blank"
+	"github.com/libp2p/go-libp2p/p2p/host/eventbus"
+	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoremem"
+	"github.com/libp2p/go-libp2p/p2p/net/swarm"
+)
+
+// newTestPubSub creates a PubSub on a host with no transports, enough to publish escalations locally
+func newTestPubSub(t *testing.T) *pubsub.PubSub {
+	t.Helper()
+
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	peerstore, err := pstoremem.NewPeerstore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	peerstore.AddPrivKey(id, priv)
+	peerstore.AddPubKey(id, priv.GetPublic())
+
+	network, err := swarm.NewSwarm(id, peerstore, eventbus.NewBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := blankhost.NewBlankHost(network)
+	t.Cleanup(func() { h.Close() })
+
+	ps, err := pubsub.NewPubSub(context.Background(), h, "bzzz/test/coordination", "antennae/test/meta-discussion")
+	if err != nil {
+		t.Fatalf("failed to create PubSub: %v", err)
+	}
+	t.Cleanup(func() { ps.Close() })
+	return ps
+}
+
+func TestCircularWaitAcrossSessionsEscalatesAsDeadlock(t *testing.T) {
+	mc := &MetaCoordinator{
+		pubsub:         newTestPubSub(t),
+		ctx:            context.Background(),
+		activeSessions: make(map[string]*CoordinationSession),
+		reorderWindow:  time.Hour, // Keep responses buffered; detection must not wait for evaluation
+	}
+
+	apiTask := &TaskContext{ProjectID: 1, TaskID: 10, Repository: "acme/api", AgentID: "agent-a"}
+	clientTask := &TaskContext{ProjectID: 2, TaskID: 20, Repository: "acme/client", AgentID: "agent-b"}
+	newSession := func(id string, tasks ...*TaskContext) *CoordinationSession {
+		session := &CoordinationSession{
+			SessionID:     id,
+			Type:          "dependency",
+			Status:        "active",
+			CreatedAt:     time.Now(),
+			TasksInvolved: tasks,
+			Participants: map[string]*Participant{
+				"agent-a": {AgentID: "agent-a"},
+				"agent-b": {AgentID: "agent-b"},
+			},
+		}
+		mc.activeSessions[id] = session
+		return session
+	}
+	schema := newSession("dep_schema", apiTask)
+	contract := newSession("dep_contract", apiTask, clientTask)
+
+	respond := func(session *CoordinationSession, agentID string, waitingOn *TaskContext) {
+		mc.handleCoordinationResponse(pubsub.Message{
+			Timestamp: time.Now(),
+			Data: map[string]interface{}{
+				"session_id": session.SessionID,
+				"agent_id":   agentID,
+				"response":   "blocked until the other side lands",
+				"waiting_on": map[string]interface{}{"project_id": float64(waitingOn.ProjectID), "task_id": float64(waitingOn.TaskID)},
+			},
+		}, peer.ID(agentID))
+	}
+
+	// agent-a waits on agent-b's task: not a deadlock yet
+	respond(schema, "agent-a", clientTask)
+	if schema.Status != "active" {
+		t.Fatalf("a one-way wait should not escalate, got %s (%s)", schema.Status, schema.EscalationReason)
+	}
+
+	// agent-b's task waits on agent-a's, closing the cycle in the contract
+	// session, the only one agent-b's task is part of
+	mc.TaskWaiting("agent-b", clientTask, apiTask)
+	for _, session := range []*CoordinationSession{schema, contract} {
+		if session.Status != "escalated" {
+			t.Fatalf("session %s should be escalated, got %s", session.SessionID, session.Status)
+		}
+		if !strings.Contains(session.EscalationReason, "Deadlock detected") ||
+			!strings.Contains(session.EscalationReason, "1:10 -> 2:20 -> 1:10") {
+			t.Errorf("unexpected escalation reason for %s: %q", session.SessionID, session.EscalationReason)
+		}
+	}
+}
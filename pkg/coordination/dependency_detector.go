@@ -4,10 +4,11 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/anthonyrawlins/bzzz/pkg/supervisor"
 	"github.com/anthonyrawlins/bzzz/pubsub"
-	"github.com/libp2p/go-libp2p/core/peer"
 )
 
 // DependencyDetector analyzes tasks across repositories for relationships
@@ -16,6 +17,7 @@ type DependencyDetector struct {
 	ctx               context.Context
 	knownTasks        map[string]*TaskContext // taskKey -> context
 	dependencyRules   []DependencyRule
+	mu                sync.RWMutex // Protects knownTasks and dependencyRules
 	coordinationHops  int
 }
 
@@ -62,7 +64,7 @@ func NewDependencyDetector(ctx context.Context, ps *pubsub.PubSub) *DependencyDe
 	dd.initializeDependencyRules()
 	
 	// Subscribe to task announcements for dependency detection
-	go dd.listenForTaskAnnouncements()
+	supervisor.Go(ctx, "dependency detection", dd.listenForTaskAnnouncements)
 	
 	return dd
 }
@@ -153,7 +155,9 @@ func (dd *DependencyDetector) initializeDependencyRules() {
 // RegisterTask adds a task to the dependency tracking system
 func (dd *DependencyDetector) RegisterTask(task *TaskContext) {
 	taskKey := fmt.Sprintf("%d:%d", task.ProjectID, task.TaskID)
+	dd.mu.Lock()
 	dd.knownTasks[taskKey] = task
+	dd.mu.Unlock()
 	
 	fmt.Printf("🔍 Registered task for dependency detection: %s/%s #%d\n", 
 		task.Repository, task.Title, task.TaskID)
@@ -164,6 +168,9 @@ func (dd *DependencyDetector) RegisterTask(task *TaskContext) {
 
 // detectDependencies analyzes a new task against existing tasks for relationships
 func (dd *DependencyDetector) detectDependencies(newTask *TaskContext) {
+	// Find matches under the lock, but announce them after releasing it
+	var dependencies []*TaskDependency
+	dd.mu.RLock()
 	for _, existingTask := range dd.knownTasks {
 		// Skip self-comparison
 		if existingTask.TaskID == newTask.TaskID && existingTask.ProjectID == newTask.ProjectID {
@@ -178,19 +185,22 @@ func (dd *DependencyDetector) detectDependencies(newTask *TaskContext) {
 		// Apply dependency detection rules
 		for _, rule := range dd.dependencyRules {
 			if matches, reason := rule.Validator(newTask, existingTask); matches {
-				dependency := &TaskDependency{
+				dependencies = append(dependencies, &TaskDependency{
 					Task1:        newTask,
 					Task2:        existingTask,
 					Relationship: rule.Name,
 					Confidence:   0.8, // Could be improved with ML
 					Reason:       reason,
 					DetectedAt:   time.Now(),
-				}
-				
-				dd.announceDependency(dependency)
+				})
 			}
 		}
 	}
+	dd.mu.RUnlock()
+	
+	for _, dependency := range dependencies {
+		dd.announceDependency(dependency)
+	}
 }
 
 // announceDependency broadcasts a detected dependency for agent coordination
@@ -237,18 +247,30 @@ func (dd *DependencyDetector) listenForTaskAnnouncements() {
 	// and extract task context for dependency analysis
 }
 
-// GetKnownTasks returns all tasks currently being tracked
+// GetKnownTasks returns a copy of the tasks currently being tracked
 func (dd *DependencyDetector) GetKnownTasks() map[string]*TaskContext {
-	return dd.knownTasks
+	dd.mu.RLock()
+	defer dd.mu.RUnlock()
+	
+	tasks := make(map[string]*TaskContext, len(dd.knownTasks))
+	for taskKey, task := range dd.knownTasks {
+		tasks[taskKey] = task
+	}
+	return tasks
 }
 
-// GetDependencyRules returns the configured dependency detection rules
+// GetDependencyRules returns a copy of the configured dependency detection rules
 func (dd *DependencyDetector) GetDependencyRules() []DependencyRule {
-	return dd.dependencyRules
+	dd.mu.RLock()
+	defer dd.mu.RUnlock()
+	
+	return append([]DependencyRule(nil), dd.dependencyRules...)
 }
 
 // AddCustomRule allows adding project-specific dependency detection
 func (dd *DependencyDetector) AddCustomRule(rule DependencyRule) {
+	dd.mu.Lock()
 	dd.dependencyRules = append(dd.dependencyRules, rule)
+	dd.mu.Unlock()
 	fmt.Printf("➕ Added custom dependency rule: %s\n", rule.Name)
-}
\ No newline at end of file
+}
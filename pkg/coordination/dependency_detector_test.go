@@ -0,0 +1,63 @@
+package coordination
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestDependencyDetectorConcurrentRegistration(t *testing.T) {
+	dd := &DependencyDetector{
+		ctx:              context.Background(),
+		knownTasks:       make(map[string]*TaskContext),
+		coordinationHops: 3,
+	}
+	dd.initializeDependencyRules()
+
+	// Titles avoid every rule's keywords, so nothing is announced and no pubsub is needed
+	const workers, tasksPerWorker = 8, 25
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < tasksPerWorker; i++ {
+				dd.RegisterTask(&TaskContext{
+					ProjectID:  w,
+					TaskID:     i,
+					Repository: fmt.Sprintf("repo-%d", w),
+					Title:      "Tidy the readme",
+				})
+				dd.GetKnownTasks()
+				dd.GetDependencyRules()
+			}
+		}(w)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < tasksPerWorker; i++ {
+			dd.AddCustomRule(DependencyRule{
+				Name: fmt.Sprintf("custom-%d", i),
+				Validator: func(task1, task2 *TaskContext) (bool, string) {
+					return false, ""
+				},
+			})
+		}
+	}()
+	wg.Wait()
+
+	if got := len(dd.GetKnownTasks()); got != workers*tasksPerWorker {
+		t.Errorf("expected %d known tasks, got %d", workers*tasksPerWorker, got)
+	}
+	if got := len(dd.GetDependencyRules()); got != 4+tasksPerWorker {
+		t.Errorf("expected %d rules, got %d", 4+tasksPerWorker, got)
+	}
+
+	// The returned map is a copy
+	dd.GetKnownTasks()["extra"] = &TaskContext{}
+	if _, leaked := dd.GetKnownTasks()["extra"]; leaked {
+		t.Error("GetKnownTasks returned the internal map")
+	}
+}
@@ -0,0 +1,328 @@
+package coordination
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// coordinatorHeartbeatInterval is how often a node announces that it takes part
+// in coordination; a variable so tests can speed it up
+var coordinatorHeartbeatInterval = 10 * time.Second
+
+// coordinatorTimeout is how long a coordinator stays a candidate for leader
+// without announcing itself again
+func coordinatorTimeout() time.Duration {
+	return 3 * coordinatorHeartbeatInterval
+}
+
+// coordinatorHeartbeat is the last announcement heard from another coordinator
+type coordinatorHeartbeat struct {
+	seen   time.Time
+	leader string // Peer ID that coordinator takes to be the leader
+}
+
+// coordinationLeader picks the node that owns coordination sessions: the lowest
+// peer ID among this node and the coordinators that have announced themselves
+// recently, leaving out any that are known to have gone. Peers on the topic
+// that never announce, such as monitors, can't win.
+func (mc *MetaCoordinator) coordinationLeader(departed ...peer.ID) peer.ID {
+	leader := mc.selfID
+	for candidate := range mc.liveCoordinators() {
+		if !slices.Contains(departed, candidate) && candidate < leader {
+			leader = candidate
+		}
+	}
+	return leader
+}
+
+// leadershipConfirmed reports whether every coordinator this node hears from
+// last announced the same leader as leader. Nodes that haven't heard from each
+// other yet disagree for up to a heartbeat, and only one of them may act.
+func (mc *MetaCoordinator) leadershipConfirmed(leader peer.ID) bool {
+	for _, heartbeat := range mc.liveCoordinators() {
+		if heartbeat.leader != leader.String() {
+			return false
+		}
+	}
+	return true
+}
+
+// liveCoordinators returns the other coordinators heard from within the timeout
+func (mc *MetaCoordinator) liveCoordinators() map[peer.ID]coordinatorHeartbeat {
+	mc.coordinatorLock.Lock()
+	defer mc.coordinatorLock.Unlock()
+
+	live := make(map[peer.ID]coordinatorHeartbeat, len(mc.coordinators))
+	for id, heartbeat := range mc.coordinators {
+		if time.Since(heartbeat.seen) < coordinatorTimeout() {
+			live[id] = heartbeat
+		}
+	}
+	return live
+}
+
+// announceCoordinator tells the mesh this node coordinates, and whom it takes to
+// be the leader so the others can confirm they agree
+func (mc *MetaCoordinator) announceCoordinator() {
+	err := mc.pubsub.PublishAntennaeMessage(pubsub.MetaDiscussion, map[string]interface{}{
+		"message_type": "coordinator_heartbeat",
+		"leader":       mc.coordinationLeader().String(),
+	})
+	if err != nil {
+		fmt.Printf("⚠️ Failed to announce coordinator heartbeat: %v\n", err)
+	}
+}
+
+// coordinatorHeartbeatLoop announces this node, and notices coordinators that
+// stopped announcing themselves, until ctx is cancelled
+func (mc *MetaCoordinator) coordinatorHeartbeatLoop() {
+	ticker := time.NewTicker(coordinatorHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		mc.announceCoordinator()
+		select {
+		case <-mc.ctx.Done():
+			return
+		case <-ticker.C:
+			mc.expireCoordinators()
+		}
+	}
+}
+
+// handleCoordinatorHeartbeat records another coordinator's announcement. A
+// newcomer is answered straight away so it learns of this node, and of the
+// leader, without waiting for the next heartbeat.
+func (mc *MetaCoordinator) handleCoordinatorHeartbeat(msg pubsub.Message, from peer.ID) {
+	leader, _ := msg.Data["leader"].(string)
+	if mc.observeCoordinator(from, leader) {
+		fmt.Printf("🗳️ Coordinator %s joined, it takes %s to lead\n", from.ShortString(), leader)
+		mc.announceCoordinator()
+	}
+}
+
+// observeCoordinator records a sign of life from a coordinator and whom it takes
+// to lead, and reports whether it is new or had timed out
+func (mc *MetaCoordinator) observeCoordinator(id peer.ID, leader string) bool {
+	mc.coordinatorLock.Lock()
+	defer mc.coordinatorLock.Unlock()
+
+	if mc.coordinators == nil {
+		mc.coordinators = make(map[peer.ID]coordinatorHeartbeat)
+	}
+	previous, known := mc.coordinators[id]
+	mc.coordinators[id] = coordinatorHeartbeat{seen: time.Now(), leader: leader}
+	return !known || time.Since(previous.seen) >= coordinatorTimeout()
+}
+
+// coordinatorLive reports whether the coordinator with peer ID id (as a string,
+// the way session owners are kept) has been heard from within the timeout
+func (mc *MetaCoordinator) coordinatorLive(id string) bool {
+	for live := range mc.liveCoordinators() {
+		if live.String() == id {
+			return true
+		}
+	}
+	return false
+}
+
+// expireCoordinators forgets coordinators that stopped announcing themselves and
+// re-elects the owner of their sessions. Peers leaving the topic are only seen
+// for direct neighbours, so this is how a node further away is noticed leaving.
+func (mc *MetaCoordinator) expireCoordinators() {
+	mc.coordinatorLock.Lock()
+	var departed []peer.ID
+	for id, heartbeat := range mc.coordinators {
+		if time.Since(heartbeat.seen) >= coordinatorTimeout() {
+			departed = append(departed, id)
+		}
+	}
+	mc.coordinatorLock.Unlock()
+
+	for _, id := range departed {
+		fmt.Printf("💤 Coordinator %s stopped announcing itself\n", id.ShortString())
+		mc.handlePeerDeparture(id)
+	}
+}
+
+// SetMinPeers sets how many other nodes must be connected before coordination
+// sessions start (e.g. from cfg.P2P.MinCoordinationPeers); 0 always starts them
+func (mc *MetaCoordinator) SetMinPeers(n int) {
+	mc.coordinatorLock.Lock()
+	defer mc.coordinatorLock.Unlock()
+	mc.minPeers = n
+}
+
+// peersNeeded returns how many other nodes must take part before a session starts
+func (mc *MetaCoordinator) peersNeeded() int {
+	mc.coordinatorLock.Lock()
+	defer mc.coordinatorLock.Unlock()
+	return mc.minPeers
+}
+
+// peerCount returns how many other nodes are taking part in coordination
+func (mc *MetaCoordinator) peerCount() int {
+	return len(mc.liveCoordinators())
+}
+
+// ownsSession reports whether this node drives a session. Sessions without an
+// owner are local-only and always driven here.
+func (mc *MetaCoordinator) ownsSession(session *CoordinationSession) bool {
+	return session.Owner == "" || session.Owner == mc.selfID.String()
+}
+
+// dependencySessionID names the session for a dependency the same way on every
+// node and whichever way round the tasks were reported
+func dependencySessionID(dep *TaskDependency) string {
+	first, second := dep.Task1, dep.Task2
+	if second.ProjectID < first.ProjectID || (second.ProjectID == first.ProjectID && second.TaskID < first.TaskID) {
+		first, second = second, first
+	}
+	return fmt.Sprintf("dep_%d_%d_%d_%d", first.ProjectID, first.TaskID, second.ProjectID, second.TaskID)
+}
+
+// followSession keeps a copy of a session another node owns, from its plan
+// broadcast, so this node can take the session over if the owner leaves. from
+// is the plan's signed author: only a node that took itself to lead plans.
+func (mc *MetaCoordinator) followSession(msg pubsub.Message, from peer.ID) {
+	sessionID, ok := msg.Data["session_id"].(string)
+	if !ok {
+		return
+	}
+	mc.observeCoordinator(from, from.String())
+
+	var shared struct {
+		Type         string                  `json:"session_type"`
+		Plan         string                  `json:"plan"`
+		Tasks        []*TaskContext          `json:"tasks_involved"`
+		Participants map[string]*Participant `json:"participants"`
+		Campaign     string                  `json:"campaign"`
+	}
+	data, _ := json.Marshal(msg.Data)
+	if err := json.Unmarshal(data, &shared); err != nil {
+		fmt.Printf("❌ Failed to parse coordination plan for %s: %v\n", sessionID, err)
+		return
+	}
+	if shared.Type == "" {
+		shared.Type = "dependency"
+	}
+	if shared.Participants == nil {
+		shared.Participants = make(map[string]*Participant)
+	}
+	if state, ok := msg.Data["campaign_state"]; ok {
+		if campaign, err := parseCampaign(state); err == nil {
+			mc.adoptCampaign(campaign)
+		}
+	}
+
+	session := &CoordinationSession{
+		SessionID:     sessionID,
+		Type:          shared.Type,
+		Participants:  shared.Participants,
+		TasksInvolved: shared.Tasks,
+		Messages:      []CoordinationMessage{planMessage(sessionID, shared.Plan, msg.Timestamp)},
+		Status:        "active",
+		CreatedAt:     msg.Timestamp,
+		LastActivity:  time.Now(),
+		Owner:         from.String(),
+		Campaign:      shared.Campaign,
+	}
+
+	mc.sessionLock.Lock()
+	defer mc.sessionLock.Unlock()
+	if _, exists := mc.activeSessions[sessionID]; exists {
+		return
+	}
+	mc.activeSessions[sessionID] = session
+	fmt.Printf("👀 Following coordination session %s owned by %s\n", sessionID, from.ShortString())
+}
+
+// handleOwnershipTransfer records which node took over a session. A session
+// only changes hands once its current owner has gone, and only to the node this
+// one would elect in its place; anyone else claiming it is ignored.
+func (mc *MetaCoordinator) handleOwnershipTransfer(msg pubsub.Message, from peer.ID) {
+	sessionID, _ := msg.Data["session_id"].(string)
+	mc.sessionLock.Lock()
+	defer mc.sessionLock.Unlock()
+
+	session, exists := mc.activeSessions[sessionID]
+	if !exists || session.Owner == from.String() {
+		return
+	}
+	if mc.coordinatorLive(session.Owner) {
+		fmt.Printf("🚫 Ignoring %s's claim to session %s, its owner is still coordinating\n", from.ShortString(), sessionID)
+		return
+	}
+	if leader := mc.coordinationLeader(); leader != from {
+		fmt.Printf("🚫 Ignoring %s's claim to session %s, %s should take it over\n", from.ShortString(), sessionID, leader.ShortString())
+		return
+	}
+	session.Owner = from.String()
+	mc.observeCoordinator(from, from.String())
+}
+
+// handleSessionOutcome closes a followed session once its owner resolves or escalates it
+func (mc *MetaCoordinator) handleSessionOutcome(msg pubsub.Message, from peer.ID) {
+	sessionID, _ := msg.Data["session_id"].(string)
+	mc.sessionLock.Lock()
+	defer mc.sessionLock.Unlock()
+
+	session, exists := mc.activeSessions[sessionID]
+	if !exists || session.Owner != from.String() {
+		return
+	}
+	if resolution, ok := msg.Data["resolution"].(string); ok {
+		session.Status = "resolved"
+		session.Resolution = resolution
+	} else {
+		session.Status = "escalated"
+		session.EscalationReason, _ = msg.Data["escalation_reason"].(string)
+	}
+	session.LastActivity = time.Now()
+}
+
+// handlePeerDeparture re-elects the owner of every session the departed node
+// owned. If that is this node, it takes the session over and carries on from
+// the transcript it has been following.
+func (mc *MetaCoordinator) handlePeerDeparture(departed peer.ID) {
+	mc.coordinatorLock.Lock()
+	delete(mc.coordinators, departed)
+	mc.coordinatorLock.Unlock()
+
+	mc.sessionLock.Lock()
+	var orphaned []*CoordinationSession
+	for _, session := range mc.activeSessions {
+		if session.Status == "active" && session.Owner == departed.String() {
+			orphaned = append(orphaned, session)
+		}
+	}
+	mc.sessionLock.Unlock()
+	if len(orphaned) == 0 {
+		return
+	}
+
+	leader := mc.coordinationLeader(departed)
+	for _, session := range orphaned {
+		mc.sessionLock.Lock()
+		session.Owner = leader.String()
+		mc.sessionLock.Unlock()
+
+		if leader != mc.selfID {
+			fmt.Printf("🗳️ Session %s lost its owner %s, %s takes over\n", session.SessionID, departed.ShortString(), leader.ShortString())
+			continue
+		}
+
+		fmt.Printf("👑 Taking over coordination session %s from departed %s\n", session.SessionID, departed.ShortString())
+		mc.broadcastToSession(session, map[string]interface{}{
+			"message_type":   "session_ownership_transferred",
+			"session_id":     session.SessionID,
+			"previous_owner": departed.String(),
+		})
+		mc.evaluateSessionProgress(session)
+	}
+}
@@ -0,0 +1,149 @@
+package coordination
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestOnlyTheLeaderCreatesADependencySession(t *testing.T) {
+	// A cancelled context makes plan generation fail fast, before anything is broadcast
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	nodeA, nodeB := peer.ID("peer-a"), peer.ID("peer-b")
+	newCoordinator := func(self peer.ID) *MetaCoordinator {
+		return &MetaCoordinator{
+			pubsub:         newTestPubSub(t),
+			ctx:            ctx,
+			activeSessions: make(map[string]*CoordinationSession),
+			selfID:         self,
+			minPeers:       1,
+		}
+	}
+	coordinators := []*MetaCoordinator{newCoordinator(nodeA), newCoordinator(nodeB)}
+	heartbeat := func(leader peer.ID) pubsub.Message {
+		return pubsub.Message{Timestamp: time.Now(), Data: map[string]interface{}{
+			"message_type": "coordinator_heartbeat",
+			"leader":       leader.String(),
+		}}
+	}
+
+	detected := func(first, second *TaskContext) pubsub.Message {
+		return pubsub.Message{Timestamp: time.Now(), Data: map[string]interface{}{
+			"message_type": "dependency_detected",
+			"dependency": map[string]interface{}{
+				"task1":        first,
+				"task2":        second,
+				"relationship": "API_Contract",
+				"reason":       "both tasks change the widgets API",
+			},
+		}}
+	}
+	api := &TaskContext{TaskID: 12, ProjectID: 1, Repository: "acme/api", AgentID: "agent-a"}
+	web := &TaskContext{TaskID: 7, ProjectID: 2, Repository: "acme/web", AgentID: "agent-b"}
+
+	// Both nodes hear the dependency, reported each way round by different detectors
+	detectBoth := func() (sessions int) {
+		for _, mc := range coordinators {
+			mc.handleDependencyDetection(detected(api, web), peer.ID("detector-1"))
+			mc.handleDependencyDetection(detected(web, api), peer.ID("detector-2"))
+			sessions += len(mc.GetActiveSessions())
+		}
+		return sessions
+	}
+
+	// The detectors are on the topic too but never announce themselves, so
+	// neither can lead or count towards the peers needed. Node B hasn't heard of
+	// node A yet and takes itself to lead; until that is settled node A holds off.
+	coordinators[0].handleMetaMessage(heartbeat(nodeB), nodeB)
+	if sessions := detectBoth(); sessions != 0 {
+		t.Fatalf("expected no session while the leader is disputed, got %d", sessions)
+	}
+
+	coordinators[1].handleMetaMessage(heartbeat(nodeA), nodeA)
+	coordinators[0].handleMetaMessage(heartbeat(nodeA), nodeB)
+	if sessions := detectBoth(); sessions != 1 {
+		t.Fatalf("expected exactly one coordination session across both nodes, got %d", sessions)
+	}
+	if len(coordinators[0].GetActiveSessions()) != 1 {
+		t.Fatal("expected the node with the lowest peer ID to own the session")
+	}
+	if _, ok := coordinators[0].GetActiveSessions()["dep_1_12_2_7"]; !ok {
+		t.Fatalf("unexpected session IDs %v", coordinators[0].GetActiveSessions())
+	}
+}
+
+func TestSurvivingNodeTakesOverSessionWhenOwnerLeaves(t *testing.T) {
+	owner := peer.ID("peer-a")
+	mc := &MetaCoordinator{
+		pubsub:         newTestPubSub(t),
+		ctx:            context.Background(),
+		activeSessions: make(map[string]*CoordinationSession),
+		reorderWindow:  time.Hour, // Flushed manually below
+		selfID:         peer.ID("peer-b"),
+	}
+
+	// The owner broadcasts its plan; this node follows the session
+	mc.handleMetaMessage(pubsub.Message{Timestamp: time.Now(), Data: map[string]interface{}{
+		"message_type": "coordination_plan",
+		"session_id":   "dep_1_12_2_7",
+		"session_type": "dependency",
+		"plan":         "Land the widgets API change before the web client uses it.",
+		"tasks_involved": []interface{}{
+			map[string]interface{}{"task_id": 12, "project_id": 1, "repository": "acme/api", "agent_id": "agent-a"},
+			map[string]interface{}{"task_id": 7, "project_id": 2, "repository": "acme/web", "agent_id": "agent-b"},
+		},
+		"participants": map[string]interface{}{
+			"agent-a": map[string]interface{}{"agent_id": "agent-a"},
+			"agent-b": map[string]interface{}{"agent_id": "agent-b"},
+		},
+	}}, owner)
+
+	session, ok := mc.GetActiveSessions()["dep_1_12_2_7"]
+	if !ok {
+		t.Fatal("expected the node to follow the owner's session")
+	}
+	if session.Owner != owner.String() || len(session.Messages) != 1 {
+		t.Fatalf("unexpected followed session: owner %q, %d messages", session.Owner, len(session.Messages))
+	}
+
+	// Agreement arrives, but only the owner may resolve the session
+	mc.handleCoordinationResponse(pubsub.Message{Timestamp: time.Now(), Data: map[string]interface{}{
+		"session_id": "dep_1_12_2_7",
+		"agent_id":   "agent-b",
+		"response":   "Agree, I'll wait for the API change.",
+	}}, peer.ID("peer-c"))
+	mc.flushSessionMessages(session)
+	if session.Status != "active" {
+		t.Fatalf("a follower must not resolve the session, got status %q", session.Status)
+	}
+
+	// Nobody else can take the session over while its owner is still coordinating
+	transfer := pubsub.Message{Timestamp: time.Now(), Data: map[string]interface{}{
+		"message_type":   "session_ownership_transferred",
+		"session_id":     "dep_1_12_2_7",
+		"previous_owner": owner.String(),
+	}}
+	mc.handleMetaMessage(transfer, peer.ID("peer-c"))
+	if session.Owner != owner.String() {
+		t.Fatalf("expected a transfer from someone other than the owner to be ignored, owner is %q", session.Owner)
+	}
+
+	// The owner is too far across the mesh for this node to see it leave, but
+	// its heartbeats stop
+	mc.coordinatorLock.Lock()
+	mc.coordinators[owner] = coordinatorHeartbeat{seen: time.Now().Add(-coordinatorTimeout()), leader: owner.String()}
+	mc.coordinatorLock.Unlock()
+	mc.expireCoordinators()
+
+	if session.Owner != mc.selfID.String() {
+		t.Fatalf("expected this node to take ownership, owner is %q", session.Owner)
+	}
+	if session.Status != "resolved" {
+		t.Fatalf("expected the new owner to drive the session to resolution, got status %q", session.Status)
+	}
+}
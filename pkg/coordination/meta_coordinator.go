@@ -4,10 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pkg/reputation"
+	"github.com/anthonyrawlins/bzzz/pkg/supervisor"
 	"github.com/anthonyrawlins/bzzz/pubsub"
 	"github.com/anthonyrawlins/bzzz/reasoning"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -24,9 +30,26 @@ type MetaCoordinator struct {
 	sessionLock          sync.RWMutex
 	
 	// Configuration
-	maxSessionDuration   time.Duration
-	maxParticipants      int
-	escalationThreshold  int
+	sessionLimits        map[string]config.SessionLimits // session type -> limits, guarded by sessionLock
+	reorderWindow        time.Duration // How long session messages are buffered for reordering
+	planPrompt           *template.Template // Coordination plan prompt, guarded by sessionLock; nil means the built-in one
+
+	// Generates coordination plans; nil means reasoning.GenerateResponse
+	generate             func(ctx context.Context, model, prompt string) (string, error)
+
+	// Optional peer reputation, credited when participants reach consensus
+	reputation           atomic.Pointer[reputation.Store]
+
+	// Leader election: only the leader creates sessions and plans
+	selfID               peer.ID
+	coordinators         map[peer.ID]coordinatorHeartbeat // Other nodes taking part in coordination, guarded by coordinatorLock
+	coordinatorLock      sync.Mutex // Taken after sessionLock when both are held
+	minPeers             int // Other nodes needed before a session starts, guarded by coordinatorLock; 0 always starts one
+
+	// Long-lived goals spanning many tasks' sessions
+	campaigns            map[string]*Campaign // campaignID -> campaign
+	campaignPath         string // Where campaigns persist; empty keeps them in memory
+	campaignLock         sync.Mutex // Taken after sessionLock when both are held
 }
 
 // CoordinationSession represents an active multi-agent coordination
@@ -41,6 +64,13 @@ type CoordinationSession struct {
 	LastActivity        time.Time              `json:"last_activity"`
 	Resolution          string                 `json:"resolution,omitempty"`
 	EscalationReason    string                 `json:"escalation_reason,omitempty"`
+	Waits               map[string]string      `json:"waits,omitempty"` // Waiting task key -> task key it is blocked on
+	Owner               string                 `json:"owner,omitempty"` // Peer ID of the node driving the session; others follow along
+	Campaign            string                 `json:"campaign,omitempty"` // Campaign the session's task belongs to, if any
+
+	// Messages waiting out the reorder window before joining the transcript
+	pending      []CoordinationMessage
+	flushPending bool
 }
 
 // Participant represents an agent in a coordination session
@@ -61,6 +91,7 @@ type CoordinationMessage struct {
 	Content     string                 `json:"content"`
 	MessageType string                 `json:"message_type"` // proposal, question, agreement, concern
 	Timestamp   time.Time              `json:"timestamp"`
+	Sequence    uint64                 `json:"sequence,omitempty"` // Sender's sequence number
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 }
 
@@ -70,41 +101,64 @@ func NewMetaCoordinator(ctx context.Context, ps *pubsub.PubSub) *MetaCoordinator
 		pubsub:              ps,
 		ctx:                 ctx,
 		activeSessions:      make(map[string]*CoordinationSession),
-		maxSessionDuration:  30 * time.Minute,
-		maxParticipants:     5,
-		escalationThreshold: 10, // Max messages before escalation consideration
+		sessionLimits:       make(map[string]config.SessionLimits),
+		reorderWindow:       500 * time.Millisecond,
+		selfID:              ps.ID(),
+		minPeers:            1,
 	}
 	
 	// Initialize dependency detector
 	mc.dependencyDetector = NewDependencyDetector(ctx, ps)
 	
 	// Set up message handler for meta-discussions
-	ps.SetAntennaeMessageHandler(mc.handleMetaMessage)
+	ps.AddAntennaeMessageHandler(mc.handleMetaMessage)
+
+	// Take over sessions whose owner leaves the mesh; neighbours leaving are
+	// seen at once, nodes further away once their heartbeats stop
+	if err := ps.WatchAntennaePeers(mc.handlePeerDeparture); err != nil {
+		fmt.Printf("⚠️ Coordination sessions won't change owner when a node leaves: %v\n", err)
+	}
 	
-	// Start session management
-	go mc.sessionCleanupLoop()
+	// Start session management, and tell the other coordinators about this one
+	supervisor.Go(ctx, "session cleanup", mc.sessionCleanupLoop)
+	supervisor.Go(ctx, "coordinator heartbeat", mc.coordinatorHeartbeatLoop)
 	
 	fmt.Printf("🎯 Advanced Meta Coordinator initialized\n")
 	return mc
 }
 
+// SetReputationStore lets the coordinator credit peers that help reach consensus
+func (mc *MetaCoordinator) SetReputationStore(store *reputation.Store) {
+	mc.reputation.Store(store)
+}
+
 // handleMetaMessage processes incoming Antennae meta-discussion messages
 func (mc *MetaCoordinator) handleMetaMessage(msg pubsub.Message, from peer.ID) {
-	messageType, hasType := msg.Data[\"message_type\"].(string)
+	messageType, hasType := msg.Data["message_type"].(string)
 	if !hasType {
 		return // Not a coordination message
 	}
 	
 	switch messageType {
-	case \"dependency_detected\":
+	case "dependency_detected":
 		mc.handleDependencyDetection(msg, from)
-	case \"coordination_request\":
+	case "coordination_request":
 		mc.handleCoordinationRequest(msg, from)
-	case \"coordination_response\":
+	case "coordination_response":
 		mc.handleCoordinationResponse(msg, from)
-	case \"session_message\":
+	case "session_message":
 		mc.handleSessionMessage(msg, from)
-	case \"escalation_request\":
+	case "coordination_plan":
+		mc.followSession(msg, from)
+	case "campaign_update":
+		mc.handleCampaignUpdate(msg, from)
+	case "coordinator_heartbeat":
+		mc.handleCoordinatorHeartbeat(msg, from)
+	case "session_ownership_transferred":
+		mc.handleOwnershipTransfer(msg, from)
+	case "resolution", "escalation":
+		mc.handleSessionOutcome(msg, from)
+	case "escalation_request":
 		mc.handleEscalationRequest(msg, from)
 	default:
 		// Handle as general meta-discussion
@@ -114,7 +168,7 @@ func (mc *MetaCoordinator) handleMetaMessage(msg pubsub.Message, from peer.ID) {
 
 // handleDependencyDetection creates a coordination session for detected dependencies
 func (mc *MetaCoordinator) handleDependencyDetection(msg pubsub.Message, from peer.ID) {
-	dependency, hasDep := msg.Data[\"dependency\"]
+	dependency, hasDep := msg.Data["dependency"]
 	if !hasDep {
 		return
 	}
@@ -123,22 +177,40 @@ func (mc *MetaCoordinator) handleDependencyDetection(msg pubsub.Message, from pe
 	depBytes, _ := json.Marshal(dependency)
 	var dep TaskDependency
 	if err := json.Unmarshal(depBytes, &dep); err != nil {
-		fmt.Printf(\"❌ Failed to parse dependency: %v\\n\", err)
+		fmt.Printf("❌ Failed to parse dependency: %v\n", err)
 		return
 	}
 	
-	// Create coordination session
-	sessionID := fmt.Sprintf(\"dep_%d_%d_%d\", dep.Task1.ProjectID, dep.Task1.TaskID, time.Now().Unix())
-	
+	// A session nobody else can join would only wait out its limits and escalate
+	if peers, needed := mc.peerCount(), mc.peersNeeded(); peers < needed {
+		fmt.Printf("🧍 Not coordinating dependency %s: %d of %d peers needed\n", dep.Relationship, peers, needed)
+		return
+	}
+
+	// Several nodes may detect the same dependency; one of them coordinates it
+	leader := mc.coordinationLeader()
+	if leader != mc.selfID {
+		fmt.Printf("🗳️ Leaving coordination of dependency %s to leader %s\n", dep.Relationship, leader.ShortString())
+		return
+	}
+	if !mc.leadershipConfirmed(leader) {
+		fmt.Printf("🗳️ Not coordinating dependency %s until the other coordinators agree on the leader\n", dep.Relationship)
+		return
+	}
+
+	// Create coordination session, once per dependency
+	sessionID := dependencySessionID(&dep)
+
 	session := &CoordinationSession{
 		SessionID:     sessionID,
-		Type:          \"dependency\",
+		Type:          "dependency",
 		Participants:  make(map[string]*Participant),
 		TasksInvolved: []*TaskContext{dep.Task1, dep.Task2},
 		Messages:      []CoordinationMessage{},
-		Status:        \"active\",
+		Status:        "active",
 		CreatedAt:     time.Now(),
 		LastActivity:  time.Now(),
+		Owner:         mc.selfID.String(),
 	}
 	
 	// Add participants
@@ -156,10 +228,14 @@ func (mc *MetaCoordinator) handleDependencyDetection(msg pubsub.Message, from pe
 	}
 	
 	mc.sessionLock.Lock()
+	if existing, exists := mc.activeSessions[sessionID]; exists && existing.Status == "active" {
+		mc.sessionLock.Unlock()
+		return // Already coordinating this dependency
+	}
 	mc.activeSessions[sessionID] = session
 	mc.sessionLock.Unlock()
 	
-	fmt.Printf(\"🎯 Created coordination session %s for dependency: %s\\n\", sessionID, dep.Relationship)
+	fmt.Printf("🎯 Created coordination session %s for dependency: %s\n", sessionID, dep.Relationship)
 	
 	// Generate coordination plan
 	mc.generateCoordinationPlan(session, &dep)
@@ -167,74 +243,66 @@ func (mc *MetaCoordinator) handleDependencyDetection(msg pubsub.Message, from pe
 
 // generateCoordinationPlan creates an AI-generated plan for coordination
 func (mc *MetaCoordinator) generateCoordinationPlan(session *CoordinationSession, dep *TaskDependency) {
-	prompt := fmt.Sprintf(`
-You are an expert AI project coordinator managing a distributed development team.
-
-SITUATION:
-- A dependency has been detected between two tasks in different repositories
-- Task 1: %s/%s #%d (Agent: %s)
-- Task 2: %s/%s #%d (Agent: %s) 
-- Relationship: %s
-- Reason: %s
-
-COORDINATION REQUIRED:
-Generate a concise coordination plan that addresses:
-1. What specific coordination is needed between the agents
-2. What order should tasks be completed in (if any)
-3. What information/artifacts need to be shared
-4. What potential conflicts to watch for
-5. Success criteria for coordinated completion
-
-Keep the plan practical and actionable. Focus on specific next steps.`,
-		dep.Task1.Repository, dep.Task1.Title, dep.Task1.TaskID, dep.Task1.AgentID,
-		dep.Task2.Repository, dep.Task2.Title, dep.Task2.TaskID, dep.Task2.AgentID,
-		dep.Relationship, dep.Reason)
-	
-	plan, err := reasoning.GenerateResponse(mc.ctx, \"phi3\", prompt)
+	prompt, err := mc.renderPlanPrompt(session, dep)
 	if err != nil {
-		fmt.Printf(\"❌ Failed to generate coordination plan: %v\\n\", err)
+		fmt.Printf("❌ Failed to generate coordination plan: %v\n", err)
 		return
 	}
 	
-	// Create initial coordination message
-	coordMessage := CoordinationMessage{
-		MessageID:   fmt.Sprintf(\"plan_%d\", time.Now().Unix()),
-		FromAgentID: \"meta_coordinator\",
-		FromPeerID:  \"system\",
-		Content:     plan,
-		MessageType: \"proposal\",
-		Timestamp:   time.Now(),
-		Metadata: map[string]interface{}{
-			\"session_id\": session.SessionID,
-			\"plan_type\":  \"coordination\",
-		},
+	generate := mc.generate
+	if generate == nil {
+		generate = reasoning.GenerateResponse
+	}
+	// The same choice GenerateResponseSmart makes: the node's best reasoning model for this prompt
+	plan, err := generate(mc.ctx, reasoning.SelectModel(prompt), prompt)
+	if err != nil {
+		fmt.Printf("❌ Failed to generate coordination plan: %v\n", err)
+		return
 	}
 	
-	session.Messages = append(session.Messages, coordMessage)
+	// Create initial coordination message
+	session.Messages = append(session.Messages, planMessage(session.SessionID, plan, time.Now()))
 	
-	// Broadcast coordination plan to participants
+	// Broadcast coordination plan to participants, and to other nodes so they can take over the session
 	mc.broadcastToSession(session, map[string]interface{}{
-		\"message_type\":    \"coordination_plan\",
-		\"session_id\":      session.SessionID,
-		\"plan\":            plan,
-		\"tasks_involved\":  session.TasksInvolved,
-		\"participants\":    session.Participants,
-		\"message\":         fmt.Sprintf(\"Coordination plan generated for dependency: %s\", dep.Relationship),
+		"message_type":    "coordination_plan",
+		"session_id":      session.SessionID,
+		"session_type":    session.Type,
+		"plan":            plan,
+		"tasks_involved":  session.TasksInvolved,
+		"participants":    session.Participants,
+		"message":         fmt.Sprintf("Coordination plan generated for dependency: %s", dep.Relationship),
 	})
 	
-	fmt.Printf(\"📋 Generated and broadcasted coordination plan for session %s\\n\", session.SessionID)
+	fmt.Printf("📋 Generated and broadcasted coordination plan for session %s\n", session.SessionID)
+}
+
+// planMessage is the transcript entry for a session's coordination plan
+func planMessage(sessionID, plan string, at time.Time) CoordinationMessage {
+	return CoordinationMessage{
+		MessageID:   fmt.Sprintf("plan_%d", at.Unix()),
+		FromAgentID: "meta_coordinator",
+		FromPeerID:  "system",
+		Content:     plan,
+		MessageType: "proposal",
+		Timestamp:   at,
+		Metadata: map[string]interface{}{
+			"session_id": sessionID,
+			"plan_type":  "coordination",
+		},
+	}
 }
 
 // broadcastToSession sends a message to all participants in a session
 func (mc *MetaCoordinator) broadcastToSession(session *CoordinationSession, data map[string]interface{}) {
 	if err := mc.pubsub.PublishAntennaeMessage(pubsub.MetaDiscussion, data); err != nil {
-		fmt.Printf(\"❌ Failed to broadcast to session %s: %v\\n\", session.SessionID, err)
+		fmt.Printf("❌ Failed to broadcast to session %s: %v\n", session.SessionID, err)
 	}
 }
 
 // handleCoordinationResponse processes responses from agents in coordination
 func (mc *MetaCoordinator) handleCoordinationResponse(msg pubsub.Message, from peer.ID) {
-	sessionID, hasSession := msg.Data[\"session_id\"].(string)
+	sessionID, hasSession := msg.Data["session_id"].(string)
 	if !hasSession {
 		return
 	}
@@ -243,12 +311,12 @@ func (mc *MetaCoordinator) handleCoordinationResponse(msg pubsub.Message, from p
 	session, exists := mc.activeSessions[sessionID]
 	mc.sessionLock.RUnlock()
 	
-	if !exists || session.Status != \"active\" {
+	if !exists || session.Status != "active" {
 		return
 	}
 	
-	agentResponse, hasResponse := msg.Data[\"response\"].(string)
-	agentID, hasAgent := msg.Data[\"agent_id\"].(string)
+	agentResponse, hasResponse := msg.Data["response"].(string)
+	agentID, hasAgent := msg.Data["agent_id"].(string)
 	
 	if !hasResponse || !hasAgent {
 		return
@@ -260,35 +328,101 @@ func (mc *MetaCoordinator) handleCoordinationResponse(msg pubsub.Message, from p
 		participant.PeerID = from.ShortString()
 	}
 	
-	// Add message to session
+	// Add message to session, ordered by when the sender sent it rather than arrival
 	coordMessage := CoordinationMessage{
-		MessageID:   fmt.Sprintf(\"resp_%s_%d\", agentID, time.Now().Unix()),
+		MessageID:   fmt.Sprintf("resp_%s_%d_%d", agentID, msg.Timestamp.Unix(), msg.Sequence),
 		FromAgentID: agentID,
-		FromPeerID:  from.ShortString(),
+		FromPeerID:  from.String(), // Full ID, as peer reputation is kept under it
 		Content:     agentResponse,
-		MessageType: \"response\",
-		Timestamp:   time.Now(),
+		MessageType: "response",
+		Timestamp:   msg.Timestamp,
+		Sequence:    msg.Sequence,
 	}
 	
-	session.Messages = append(session.Messages, coordMessage)
-	session.LastActivity = time.Now()
-	
-	fmt.Printf(\"💬 Coordination response from %s in session %s\\n\", agentID, sessionID)
+	fmt.Printf("💬 Coordination response from %s in session %s\n", agentID, sessionID)
+
+	// An agent blocked on another task may close a circular wait across sessions
+	if waitingOn, ok := msg.Data["waiting_on"].(map[string]interface{}); ok {
+		projectID, _ := toInt(waitingOn["project_id"])
+		taskID, _ := toInt(waitingOn["task_id"])
+		var waiting string // The responding agent's own task unless the response names one
+		if task, ok := msg.Data["task"].(map[string]interface{}); ok {
+			waitingProject, _ := toInt(task["project_id"])
+			waitingTask, _ := toInt(task["task_id"])
+			waiting = taskKey(waitingProject, waitingTask)
+		}
+		mc.recordWait(session, agentID, waiting, taskKey(projectID, taskID))
+	}
 	
-	// Check if coordination is complete
-	mc.evaluateSessionProgress(session)
+	// Progress is evaluated once the buffered messages are flushed in order
+	mc.bufferSessionMessage(session, coordMessage)
+}
+
+// bufferSessionMessage holds a message briefly so stragglers delivered out of
+// order by gossipsub can be sorted into place before the session is evaluated.
+func (mc *MetaCoordinator) bufferSessionMessage(session *CoordinationSession, message CoordinationMessage) {
+	mc.sessionLock.Lock()
+	session.pending = append(session.pending, message)
+	session.LastActivity = time.Now()
+	scheduleFlush := !session.flushPending
+	session.flushPending = true
+	mc.sessionLock.Unlock()
+
+	if scheduleFlush {
+		time.AfterFunc(mc.reorderWindow, func() {
+			mc.flushSessionMessages(session)
+		})
+	}
+}
+
+// flushSessionMessages merges buffered messages into the transcript in order
+// and re-evaluates the session.
+func (mc *MetaCoordinator) flushSessionMessages(session *CoordinationSession) {
+	mc.sessionLock.Lock()
+	session.Messages = append(session.Messages, session.pending...)
+	session.pending = nil
+	session.flushPending = false
+	sortSessionMessages(session.Messages)
+	evaluate := session.Status == "active" && mc.ownsSession(session)
+	mc.sessionLock.Unlock()
+
+	// Only the owner decides when a session is resolved or escalated
+	if evaluate {
+		mc.evaluateSessionProgress(session)
+	}
+}
+
+// sortSessionMessages orders a transcript by (timestamp, sender, sequence) so
+// every peer sees the same order regardless of delivery order.
+func sortSessionMessages(messages []CoordinationMessage) {
+	sort.SliceStable(messages, func(i, j int) bool {
+		a, b := messages[i], messages[j]
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.Before(b.Timestamp)
+		}
+		if a.FromPeerID != b.FromPeerID {
+			return a.FromPeerID < b.FromPeerID
+		}
+		return a.Sequence < b.Sequence
+	})
 }
 
 // evaluateSessionProgress determines if a session needs escalation or can be resolved
 func (mc *MetaCoordinator) evaluateSessionProgress(session *CoordinationSession) {
-	// Check for escalation conditions
-	if len(session.Messages) >= mc.escalationThreshold {
-		mc.escalateSession(session, \"Message limit exceeded - human intervention needed\")
+	// Check for escalation conditions against this session type's limits
+	limits := mc.limitsFor(session.Type)
+	if len(session.Messages) >= limits.EscalationThreshold {
+		mc.escalateSession(session, "Message limit exceeded - human intervention needed")
 		return
 	}
 	
-	if time.Since(session.CreatedAt) > mc.maxSessionDuration {
-		mc.escalateSession(session, \"Session duration exceeded - human intervention needed\")
+	if time.Since(session.CreatedAt) > limits.MaxDuration {
+		mc.escalateSession(session, "Session duration exceeded - human intervention needed")
+		return
+	}
+
+	if len(session.Participants) > limits.MaxParticipants {
+		mc.escalateSession(session, "Too many participants - human intervention needed")
 		return
 	}
 	
@@ -301,34 +435,53 @@ func (mc *MetaCoordinator) evaluateSessionProgress(session *CoordinationSession)
 	agreementCount := 0
 	for _, msg := range recentMessages {
 		content := strings.ToLower(msg.Content)
-		if strings.Contains(content, \"agree\") || strings.Contains(content, \"sounds good\") ||
-		   strings.Contains(content, \"approved\") || strings.Contains(content, \"looks good\") {
+		if strings.Contains(content, "agree") || strings.Contains(content, "sounds good") ||
+		   strings.Contains(content, "approved") || strings.Contains(content, "looks good") {
 			agreementCount++
 		}
 	}
 	
 	// If majority agreement, consider resolved
 	if agreementCount >= len(session.Participants)-1 {
-		mc.resolveSession(session, \"Consensus reached among participants\")
+		mc.creditConsensusParticipants(session)
+		mc.resolveSession(session, "Consensus reached among participants")
+	}
+}
+
+// creditConsensusParticipants records consensus participation for every peer that spoke in the session
+func (mc *MetaCoordinator) creditConsensusParticipants(session *CoordinationSession) {
+	store := mc.reputation.Load()
+	if store == nil {
+		return
+	}
+
+	credited := make(map[string]bool)
+	for _, msg := range session.Messages {
+		if msg.FromPeerID == "" || credited[msg.FromPeerID] {
+			continue
+		}
+		credited[msg.FromPeerID] = true
+		store.Record(msg.FromPeerID, reputation.ConsensusParticipated)
 	}
 }
 
 // escalateSession escalates a session to human intervention
 func (mc *MetaCoordinator) escalateSession(session *CoordinationSession, reason string) {
-	session.Status = \"escalated\"
+	session.Status = "escalated"
 	session.EscalationReason = reason
 	
-	fmt.Printf(\"🚨 Escalating coordination session %s: %s\\n\", session.SessionID, reason)
+	fmt.Printf("🚨 Escalating coordination session %s: %s\n", session.SessionID, reason)
 	
 	// Create escalation message
 	escalationData := map[string]interface{}{
-		\"message_type\":       \"escalation\",
-		\"session_id\":         session.SessionID,
-		\"escalation_reason\":  reason,
-		\"session_summary\":    mc.generateSessionSummary(session),
-		\"participants\":       session.Participants,
-		\"tasks_involved\":     session.TasksInvolved,
-		\"requires_human\":     true,
+		"message_type":       "escalation",
+		"session_id":         session.SessionID,
+		"escalation_reason":  reason,
+		"session_summary":    mc.generateSessionSummary(session),
+		"participants":       session.Participants,
+		"tasks_involved":     session.TasksInvolved,
+		"requires_human":     true,
+		"session_limits":     mc.limitsFor(session.Type),
 	}
 	
 	mc.broadcastToSession(session, escalationData)
@@ -336,17 +489,17 @@ func (mc *MetaCoordinator) escalateSession(session *CoordinationSession, reason
 
 // resolveSession marks a session as successfully resolved
 func (mc *MetaCoordinator) resolveSession(session *CoordinationSession, resolution string) {
-	session.Status = \"resolved\"
+	session.Status = "resolved"
 	session.Resolution = resolution
 	
-	fmt.Printf(\"✅ Resolved coordination session %s: %s\\n\", session.SessionID, resolution)
+	fmt.Printf("✅ Resolved coordination session %s: %s\n", session.SessionID, resolution)
 	
 	// Broadcast resolution
 	resolutionData := map[string]interface{}{
-		\"message_type\": \"resolution\",
-		\"session_id\":   session.SessionID,
-		\"resolution\":   resolution,
-		\"summary\":      mc.generateSessionSummary(session),
+		"message_type": "resolution",
+		"session_id":   session.SessionID,
+		"resolution":   resolution,
+		"summary":      mc.generateSessionSummary(session),
 	}
 	
 	mc.broadcastToSession(session, resolutionData)
@@ -355,7 +508,7 @@ func (mc *MetaCoordinator) resolveSession(session *CoordinationSession, resoluti
 // generateSessionSummary creates a summary of the coordination session
 func (mc *MetaCoordinator) generateSessionSummary(session *CoordinationSession) string {
 	return fmt.Sprintf(
-		\"Session %s (%s): %d participants, %d messages, duration %v\",
+		"Session %s (%s): %d participants, %d messages, duration %v",
 		session.SessionID, session.Type, len(session.Participants),
 		len(session.Messages), time.Since(session.CreatedAt).Round(time.Minute))
 }
@@ -383,9 +536,9 @@ func (mc *MetaCoordinator) cleanupInactiveSessions() {
 	for sessionID, session := range mc.activeSessions {
 		// Remove sessions older than 2 hours or already resolved/escalated
 		if time.Since(session.LastActivity) > 2*time.Hour || 
-		   session.Status == \"resolved\" || session.Status == \"escalated\" {
+		   session.Status == "resolved" || session.Status == "escalated" {
 			delete(mc.activeSessions, sessionID)
-			fmt.Printf(\"🧹 Cleaned up session %s (status: %s)\\n\", sessionID, session.Status)
+			fmt.Printf("🧹 Cleaned up session %s (status: %s)\n", sessionID, session.Status)
 		}
 	}
 }
@@ -393,7 +546,7 @@ func (mc *MetaCoordinator) cleanupInactiveSessions() {
 // handleGeneralDiscussion processes general meta-discussion messages
 func (mc *MetaCoordinator) handleGeneralDiscussion(msg pubsub.Message, from peer.ID) {
 	// Handle non-coordination meta discussions
-	fmt.Printf(\"💭 General meta-discussion from %s: %v\\n\", from.ShortString(), msg.Data)
+	fmt.Printf("💭 General meta-discussion from %s: %v\n", from.ShortString(), msg.Data)
 }
 
 // GetActiveSessions returns current coordination sessions
@@ -410,7 +563,7 @@ func (mc *MetaCoordinator) GetActiveSessions() map[string]*CoordinationSession {
 
 // handleSessionMessage processes messages within coordination sessions
 func (mc *MetaCoordinator) handleSessionMessage(msg pubsub.Message, from peer.ID) {
-	sessionID, hasSession := msg.Data[\"session_id\"].(string)
+	sessionID, hasSession := msg.Data["session_id"].(string)
 	if !hasSession {
 		return
 	}
@@ -424,17 +577,17 @@ func (mc *MetaCoordinator) handleSessionMessage(msg pubsub.Message, from peer.ID
 	}
 	
 	session.LastActivity = time.Now()
-	fmt.Printf(\"📨 Session message in %s from %s\\n\", sessionID, from.ShortString())
+	fmt.Printf("📨 Session message in %s from %s\n", sessionID, from.ShortString())
 }
 
 // handleCoordinationRequest processes requests to start coordination
 func (mc *MetaCoordinator) handleCoordinationRequest(msg pubsub.Message, from peer.ID) {
-	fmt.Printf(\"🎯 Coordination request from %s\\n\", from.ShortString())
+	fmt.Printf("🎯 Coordination request from %s\n", from.ShortString())
 	// Implementation for handling coordination requests
 }
 
 // handleEscalationRequest processes escalation requests
 func (mc *MetaCoordinator) handleEscalationRequest(msg pubsub.Message, from peer.ID) {
-	fmt.Printf(\"🚨 Escalation request from %s\\n\", from.ShortString())
+	fmt.Printf("🚨 Escalation request from %s\n", from.ShortString())
 	// Implementation for handling escalation requests
 }
\ No newline at end of file
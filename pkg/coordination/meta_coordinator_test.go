@@ -0,0 +1,99 @@
+package coordination
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestSessionTranscriptReordersMessages(t *testing.T) {
+	mc := &MetaCoordinator{
+		ctx:                 context.Background(),
+		activeSessions:      make(map[string]*CoordinationSession),
+		sessionLimits: map[string]config.SessionLimits{
+			"dependency": {MaxDuration: time.Hour, MaxParticipants: 5, EscalationThreshold: 100},
+		},
+		reorderWindow:       time.Hour, // Flushed manually below
+	}
+	session := &CoordinationSession{
+		SessionID: "dep_1_2_3",
+		Type:      "dependency",
+		Status:    "active",
+		CreatedAt: time.Now(),
+		Participants: map[string]*Participant{
+			"agent-a": {AgentID: "agent-a"},
+			"agent-b": {AgentID: "agent-b"},
+			"agent-c": {AgentID: "agent-c"},
+		},
+	}
+	mc.activeSessions[session.SessionID] = session
+
+	base := time.Now()
+	sent := []pubsub.Message{
+		{Timestamp: base, Sequence: 1, Data: map[string]interface{}{"agent_id": "agent-a", "response": "first"}},
+		{Timestamp: base.Add(time.Second), Sequence: 7, Data: map[string]interface{}{"agent_id": "agent-b", "response": "second"}},
+		{Timestamp: base.Add(2 * time.Second), Sequence: 2, Data: map[string]interface{}{"agent_id": "agent-a", "response": "third"}},
+		{Timestamp: base.Add(2 * time.Second), Sequence: 3, Data: map[string]interface{}{"agent_id": "agent-a", "response": "fourth"}},
+	}
+	senders := map[string]peer.ID{"agent-a": peer.ID("peer-a"), "agent-b": peer.ID("peer-b")}
+
+	// Deliver in scrambled order
+	for _, i := range []int{3, 1, 0, 2} {
+		msg := sent[i]
+		msg.Data["session_id"] = session.SessionID
+		mc.handleCoordinationResponse(msg, senders[msg.Data["agent_id"].(string)])
+	}
+	mc.flushSessionMessages(session)
+
+	want := []string{"first", "second", "third", "fourth"}
+	if len(session.Messages) != len(want) {
+		t.Fatalf("expected %d messages in transcript, got %d", len(want), len(session.Messages))
+	}
+	for i, content := range want {
+		if session.Messages[i].Content != content {
+			t.Fatalf("transcript position %d: expected %q, got %q", i, content, session.Messages[i].Content)
+		}
+	}
+}
+
+func TestPlanningSessionOutlastsConflictThreshold(t *testing.T) {
+	mc := &MetaCoordinator{
+		ctx:            context.Background(),
+		activeSessions: make(map[string]*CoordinationSession),
+	}
+	session := &CoordinationSession{
+		SessionID: "plan_1",
+		Type:      "planning",
+		Status:    "active",
+		CreatedAt: time.Now().Add(-20 * time.Minute), // Past the conflict duration limit too
+		Participants: map[string]*Participant{
+			"agent-a": {AgentID: "agent-a"},
+			"agent-b": {AgentID: "agent-b"},
+			"agent-c": {AgentID: "agent-c"},
+			"agent-d": {AgentID: "agent-d"},
+		},
+	}
+	conflict := mc.limitsFor("conflict")
+	for i := 0; i < conflict.EscalationThreshold; i++ {
+		session.Messages = append(session.Messages, CoordinationMessage{Content: "still weighing the options"})
+	}
+
+	// No pubsub is set, so an escalation would panic while broadcasting
+	mc.evaluateSessionProgress(session)
+	if session.Status != "active" {
+		t.Fatalf("planning session should stay active at the conflict threshold, got %s (%s)", session.Status, session.EscalationReason)
+	}
+
+	mc.SetSessionLimits(map[string]config.SessionLimits{"planning": {EscalationThreshold: 12}})
+	planning := mc.limitsFor("planning")
+	if planning.EscalationThreshold != 12 || planning.MaxDuration != defaultSessionLimits["planning"].MaxDuration {
+		t.Fatalf("override should only replace the fields it sets, got %+v", planning)
+	}
+	if got := mc.limitsFor("conflict"); got != conflict {
+		t.Fatalf("conflict limits changed to %+v", got)
+	}
+}
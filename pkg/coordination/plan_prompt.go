@@ -0,0 +1,94 @@
+package coordination
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// defaultPlanPrompt asks for a coordination plan when no template is configured
+const defaultPlanPrompt = `
+You are an expert AI project coordinator managing a distributed development team.
+
+SITUATION:
+- A dependency has been detected between two tasks in different repositories
+- Task 1: {{.Task1.Repository}}/{{.Task1.Title}} #{{.Task1.TaskID}} (Agent: {{.Task1.AgentID}})
+- Task 2: {{.Task2.Repository}}/{{.Task2.Title}} #{{.Task2.TaskID}} (Agent: {{.Task2.AgentID}})
+- Relationship: {{.Relationship}}
+- Reason: {{.Reason}}
+
+COORDINATION REQUIRED:
+Generate a concise coordination plan that addresses:
+1. What specific coordination is needed between the agents
+2. What order should tasks be completed in (if any)
+3. What information/artifacts need to be shared
+4. What potential conflicts to watch for
+5. Success criteria for coordinated completion
+
+Keep the plan practical and actionable. Focus on specific next steps.`
+
+// planPromptTemplate is parsed once; SetPlanPrompt replaces it
+var planPromptTemplate = template.Must(template.New("plan_prompt").Parse(defaultPlanPrompt))
+
+// PlanPromptData is what a coordination plan template can refer to. Task1 and
+// Task2 carry the full task context, including Description and Keywords.
+type PlanPromptData struct {
+	SessionID    string
+	SessionType  string
+	Task1        *TaskContext
+	Task2        *TaskContext
+	Relationship string
+	Reason       string
+	Confidence   float64
+}
+
+// ParsePlanPrompt checks a coordination plan template (see PlanPromptData for its variables)
+func ParsePlanPrompt(text string) (*template.Template, error) {
+	tmpl, err := template.New("plan_prompt").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse coordination plan prompt: %w", err)
+	}
+	return tmpl, nil
+}
+
+// SetPlanPrompt replaces the prompt used to generate coordination plans (e.g.
+// from cfg.Coordination.PlanPrompt), so it can be tuned for a domain or
+// language. An empty template keeps the built-in prompt.
+func (mc *MetaCoordinator) SetPlanPrompt(text string) error {
+	if text == "" {
+		return nil
+	}
+	tmpl, err := ParsePlanPrompt(text)
+	if err != nil {
+		return err
+	}
+	mc.sessionLock.Lock()
+	mc.planPrompt = tmpl
+	mc.sessionLock.Unlock()
+	return nil
+}
+
+// renderPlanPrompt fills in the coordination plan template for a dependency
+func (mc *MetaCoordinator) renderPlanPrompt(session *CoordinationSession, dep *TaskDependency) (string, error) {
+	mc.sessionLock.RLock()
+	tmpl := mc.planPrompt
+	mc.sessionLock.RUnlock()
+	if tmpl == nil {
+		tmpl = planPromptTemplate
+	}
+
+	var b strings.Builder
+	err := tmpl.Execute(&b, PlanPromptData{
+		SessionID:    session.SessionID,
+		SessionType:  session.Type,
+		Task1:        dep.Task1,
+		Task2:        dep.Task2,
+		Relationship: dep.Relationship,
+		Reason:       dep.Reason,
+		Confidence:   dep.Confidence,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render coordination plan prompt: %w", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,45 @@
+package coordination
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/anthonyrawlins/bzzz/reasoning"
+)
+
+func TestCoordinationPlanUsesConfiguredPromptAndReasoningModel(t *testing.T) {
+	reasoning.SetModelConfig([]string{"qwen3:14b", "llama3.1"}, "", "qwen3:14b")
+
+	var gotModel, gotPrompt string
+	mc := &MetaCoordinator{
+		ctx: context.Background(),
+		generate: func(ctx context.Context, model, prompt string) (string, error) {
+			gotModel, gotPrompt = model, prompt
+			return "", errors.New("stop before broadcasting")
+		},
+	}
+	if err := mc.SetPlanPrompt("Koordiniere {{.Task1.Repository}}#{{.Task1.TaskID}} ({{.Task1.Description}}) mit {{.Task2.Repository}}#{{.Task2.TaskID}}: {{.Relationship}}, {{.Reason}}"); err != nil {
+		t.Fatalf("failed to set plan prompt: %v", err)
+	}
+
+	session := &CoordinationSession{SessionID: "dep_1_12_2_7", Type: "dependency"}
+	mc.generateCoordinationPlan(session, &TaskDependency{
+		Task1:        &TaskContext{TaskID: 12, Repository: "acme/api", Description: "rename the widgets endpoint"},
+		Task2:        &TaskContext{TaskID: 7, Repository: "acme/web"},
+		Relationship: "API_Contract",
+		Reason:       "both tasks change the widgets API",
+	})
+
+	want := "Koordiniere acme/api#12 (rename the widgets endpoint) mit acme/web#7: API_Contract, both tasks change the widgets API"
+	if gotPrompt != want {
+		t.Fatalf("expected the configured template to be used, got %q", gotPrompt)
+	}
+	if gotModel != "qwen3:14b" {
+		t.Fatalf("expected the configured reasoning model, got %q", gotModel)
+	}
+
+	if err := mc.SetPlanPrompt("{{.Task1.Title"); err == nil {
+		t.Fatal("expected an unparseable template to be rejected")
+	}
+}
@@ -0,0 +1,57 @@
+package coordination
+
+import (
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+)
+
+// defaultSessionLimits are the built-in limits per session type. Planning takes
+// longer and involves more agents than settling a conflict between two tasks.
+var defaultSessionLimits = map[string]config.SessionLimits{
+	"dependency": {MaxDuration: 30 * time.Minute, MaxParticipants: 5, EscalationThreshold: 10},
+	"conflict":   {MaxDuration: 15 * time.Minute, MaxParticipants: 3, EscalationThreshold: 8},
+	"planning":   {MaxDuration: 2 * time.Hour, MaxParticipants: 10, EscalationThreshold: 30},
+}
+
+// SetSessionLimits overrides the limits for the given session types (e.g. from
+// cfg.Coordination.SessionLimits). Zero fields keep the current limit.
+func (mc *MetaCoordinator) SetSessionLimits(limits map[string]config.SessionLimits) {
+	mc.sessionLock.Lock()
+	defer mc.sessionLock.Unlock()
+
+	if mc.sessionLimits == nil {
+		mc.sessionLimits = make(map[string]config.SessionLimits)
+	}
+	for sessionType, override := range limits {
+		current := mc.limitsLocked(sessionType)
+		if override.MaxDuration > 0 {
+			current.MaxDuration = override.MaxDuration
+		}
+		if override.MaxParticipants > 0 {
+			current.MaxParticipants = override.MaxParticipants
+		}
+		if override.EscalationThreshold > 0 {
+			current.EscalationThreshold = override.EscalationThreshold
+		}
+		mc.sessionLimits[sessionType] = current
+	}
+}
+
+// limitsFor returns the limits that apply to a session type
+func (mc *MetaCoordinator) limitsFor(sessionType string) config.SessionLimits {
+	mc.sessionLock.RLock()
+	defer mc.sessionLock.RUnlock()
+	return mc.limitsLocked(sessionType)
+}
+
+// limitsLocked looks up limits with sessionLock held; unknown types get the dependency limits
+func (mc *MetaCoordinator) limitsLocked(sessionType string) config.SessionLimits {
+	if limits, exists := mc.sessionLimits[sessionType]; exists {
+		return limits
+	}
+	if limits, exists := defaultSessionLimits[sessionType]; exists {
+		return limits
+	}
+	return defaultSessionLimits["dependency"]
+}
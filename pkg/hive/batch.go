@@ -0,0 +1,152 @@
+package hive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// BatchStatusItem is one task's status update within a bulk request
+type BatchStatusItem struct {
+	ProjectID int `json:"project_id"`
+	TaskStatusUpdate
+}
+
+// BatchStatusRequest is sent to /api/bzzz/batch-status
+type BatchStatusRequest struct {
+	Updates []BatchStatusItem `json:"updates"`
+}
+
+// statusBatcher coalesces status updates and sends them to Hive in bulk
+type statusBatcher struct {
+	client      *HiveClient
+	interval    time.Duration
+	pending     []BatchStatusItem
+	timer       *time.Timer
+	unsupported bool // Hive has no batch endpoint; send updates one by one
+	mu          sync.Mutex
+}
+
+// EnableStatusBatching queues status updates and sends them as one bulk request
+// every interval. Hive servers without the batch endpoint get per-item calls.
+func (c *HiveClient) EnableStatusBatching(interval time.Duration) {
+	c.batcher = &statusBatcher{client: c, interval: interval}
+}
+
+// FlushStatusUpdates sends any queued status updates now, e.g. before shutdown
+func (c *HiveClient) FlushStatusUpdates(ctx context.Context) error {
+	if c.batcher == nil {
+		return nil
+	}
+	return c.batcher.flush(ctx)
+}
+
+// enqueue queues an update and reports whether it was queued. A newer update
+// with the same status for the same task replaces the queued one.
+func (b *statusBatcher) enqueue(projectID int, update TaskStatusUpdate) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.unsupported {
+		return false
+	}
+
+	item := BatchStatusItem{ProjectID: projectID, TaskStatusUpdate: update}
+	replaced := false
+	for i, queued := range b.pending {
+		if queued.ProjectID == projectID && queued.TaskNumber == update.TaskNumber && queued.Status == update.Status {
+			b.pending[i] = item
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		b.pending = append(b.pending, item)
+	}
+
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.interval, func() {
+			if err := b.flush(context.Background()); err != nil {
+				fmt.Printf("⚠️ Failed to flush Hive status updates: %v\n", err)
+			}
+		})
+	}
+	return true
+}
+
+// flush sends the queued updates in one request, falling back to per-item calls
+func (b *statusBatcher) flush(ctx context.Context) error {
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	unsupported := b.unsupported
+	b.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if !unsupported {
+		supported, err := b.client.sendBatchStatus(ctx, pending)
+		if err == nil {
+			return nil
+		}
+		if !supported {
+			fmt.Printf("ℹ️ Hive has no batch status endpoint, sending status updates individually\n")
+			b.mu.Lock()
+			b.unsupported = true
+			b.mu.Unlock()
+		} else {
+			fmt.Printf("⚠️ Batch status update failed, retrying individually: %v\n", err)
+		}
+	}
+
+	var firstErr error
+	for _, item := range pending {
+		if err := b.client.deliverStatusUpdate(ctx, item.ProjectID, item.TaskStatusUpdate); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// sendBatchStatus posts a bulk status update; supported is false when Hive lacks the endpoint
+func (c *HiveClient) sendBatchStatus(ctx context.Context, items []BatchStatusItem) (supported bool, err error) {
+	url := fmt.Sprintf("%s/api/bzzz/batch-status", c.BaseURL)
+
+	jsonData, err := json.Marshal(BatchStatusRequest{Updates: items})
+	if err != nil {
+		return true, fmt.Errorf("failed to marshal batch status update: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return true, fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return true, unavailable(fmt.Errorf("failed to execute request: %w", err))
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusNoContent:
+		return true, nil
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return false, fmt.Errorf("batch status endpoint not supported (status %d)", resp.StatusCode)
+	}
+	return true, statusError(resp, "batch status update")
+}
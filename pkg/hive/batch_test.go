@@ -0,0 +1,106 @@
+package hive
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStatusUpdatesAreFlushedAsOneBatch(t *testing.T) {
+	var mu sync.Mutex
+	var batches []BatchStatusRequest
+	single := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/api/bzzz/batch-status" {
+			var batch BatchStatusRequest
+			json.NewDecoder(r.Body).Decode(&batch)
+			batches = append(batches, batch)
+			return
+		}
+		single++
+	}))
+	defer server.Close()
+
+	client := NewHiveClient(server.URL, "")
+	client.EnableStatusBatching(50 * time.Millisecond)
+
+	ctx := context.Background()
+	client.UpdateTaskStatus(ctx, 7, 1, "in_progress", map[string]interface{}{"checklist_done": 1})
+	client.UpdateTaskStatus(ctx, 7, 1, "in_progress", map[string]interface{}{"checklist_done": 2})
+	client.UpdateTaskStatus(ctx, 7, 2, "completed", nil)
+	client.UpdateTaskStatus(ctx, 8, 1, "escalated", nil)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		flushed := len(batches)
+		mu.Unlock()
+		if flushed > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 1 || single != 0 {
+		t.Fatalf("expected one batch request and no single updates, got %d batches and %d single", len(batches), single)
+	}
+	updates := batches[0].Updates
+	if len(updates) != 3 {
+		t.Fatalf("expected the repeated in_progress update to be coalesced into 3 updates, got %+v", updates)
+	}
+	if updates[0].ProjectID != 7 || updates[0].TaskNumber != 1 || updates[0].Results["checklist_done"] != float64(2) {
+		t.Errorf("expected the latest in_progress update to win, got %+v", updates[0])
+	}
+	if updates[2].ProjectID != 8 || updates[2].Status != "escalated" {
+		t.Errorf("unexpected final update %+v", updates[2])
+	}
+}
+
+func TestStatusBatchingFallsBackWithoutBatchEndpoint(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if r.URL.Path == "/api/bzzz/batch-status" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewHiveClient(server.URL, "")
+	client.EnableStatusBatching(time.Hour) // Flushed by hand below
+
+	ctx := context.Background()
+	client.UpdateTaskStatus(ctx, 7, 1, "claimed", nil)
+	client.UpdateTaskStatus(ctx, 7, 2, "claimed", nil)
+	if err := client.FlushStatusUpdates(ctx); err != nil {
+		t.Fatalf("fallback flush failed: %v", err)
+	}
+
+	// Once the endpoint is known to be missing, updates go straight out
+	if err := client.UpdateTaskStatus(ctx, 7, 3, "completed", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"POST /api/bzzz/batch-status", "PUT /api/bzzz/projects/7/status", "PUT /api/bzzz/projects/7/status", "PUT /api/bzzz/projects/7/status"}
+	if len(paths) != len(want) {
+		t.Fatalf("expected requests %v, got %v", want, paths)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Fatalf("expected requests %v, got %v", want, paths)
+		}
+	}
+}
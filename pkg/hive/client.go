@@ -1,20 +1,59 @@
 package hive
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
+
+	"github.com/anthonyrawlins/bzzz/pkg/tracing"
 )
 
+// ErrClaimHeld is returned when another agent holds a live lease on a task
+var ErrClaimHeld = errors.New("task is claimed by another agent")
+
+// ErrHiveUnavailable is wrapped by errors that mean Hive could not be reached
+// or failed on its side, as opposed to rejecting the request
+var ErrHiveUnavailable = errors.New("hive is unavailable")
+
+// unavailable marks err as a Hive outage
+func unavailable(err error) error {
+	return fmt.Errorf("%w: %w", ErrHiveUnavailable, err)
+}
+
+// statusError describes an unexpected response, marking server errors as outages
+func statusError(resp *http.Response, what string) error {
+	body, _ := io.ReadAll(resp.Body)
+	err := fmt.Errorf("%s failed with status %d: %s", what, resp.StatusCode, string(body))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return unavailable(err)
+	}
+	return err
+}
+
 // HiveClient provides integration with the Hive task coordination system
 type HiveClient struct {
 	BaseURL    string
 	APIKey     string
 	HTTPClient *http.Client
+
+	// GitHub Enterprise Server hosts whose repositories RegisterProject accepts
+	// besides github.com's
+	GitHubHosts []string
+
+	// Claims and status updates carry idempotency keys, so they are safe to retry
+	MaxRetries int
+	RetryDelay time.Duration // Grows linearly with each attempt
+
+	// Optional coalescing of status updates into bulk requests
+	batcher *statusBatcher
+
+	// Optional retries for claims and status updates Hive was unavailable for
+	reports *reportQueue
 }
 
 // NewHiveClient creates a new Hive API client
@@ -23,8 +62,11 @@ func NewHiveClient(baseURL, apiKey string) *HiveClient {
 		BaseURL: baseURL,
 		APIKey:  apiKey,
 		HTTPClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: tracing.Transport("hive", http.DefaultTransport), // Traces every call in the caller's span
 		},
+		MaxRetries: 2,
+		RetryDelay: time.Second,
 	}
 }
 
@@ -40,6 +82,10 @@ type Repository struct {
 	ReadyToClaim         bool   `json:"ready_to_claim"`
 	PrivateRepo          bool   `json:"private_repo"`
 	GitHubTokenRequired  bool   `json:"github_token_required"`
+	SandboxImage         string `json:"sandbox_image,omitempty"`       // Overrides the agent's default sandbox image
+	VerifyCommand        string `json:"verify_command,omitempty"`      // Overrides the agent's default verify command
+	RequireApproval      bool   `json:"require_approval,omitempty"`    // Agents propose tasks and wait for a human's approval label before claiming
+	DraftPullRequests    bool   `json:"draft_pull_requests,omitempty"` // Task PRs open as drafts for a human to mark ready
 }
 
 // ActiveRepositoriesResponse represents the response from /api/bzzz/active-repos
@@ -49,16 +95,33 @@ type ActiveRepositoriesResponse struct {
 
 // TaskClaimRequest represents a task claim request to Hive
 type TaskClaimRequest struct {
-	TaskNumber int    `json:"task_number"`
-	AgentID    string `json:"agent_id"`
-	ClaimedAt  int64  `json:"claimed_at"`
+	TaskNumber     int    `json:"task_number"`
+	AgentID        string `json:"agent_id"`
+	ClaimedAt      int64  `json:"claimed_at"`
+	LeaseExpiresAt int64  `json:"lease_expires_at,omitempty"`
+	IdempotencyKey string `json:"idempotency_key"` // Same for every attempt at one claim
+}
+
+// ClaimLease is an agent's time-limited hold on a task
+type ClaimLease struct {
+	TaskNumber     int    `json:"task_number"`
+	AgentID        string `json:"agent_id"`
+	LeaseExpiresAt int64  `json:"lease_expires_at"`
+}
+
+// Expired reports whether the lease has lapsed, meaning its agent stopped renewing it
+func (l ClaimLease) Expired(now time.Time) bool {
+	return l.LeaseExpiresAt > 0 && now.Unix() >= l.LeaseExpiresAt
 }
 
 // TaskStatusUpdate represents a task status update to Hive
 type TaskStatusUpdate struct {
+	TaskNumber int                   `json:"task_number,omitempty"`
 	Status    string                 `json:"status"`
 	UpdatedAt int64                  `json:"updated_at"`
 	Results   map[string]interface{} `json:"results,omitempty"`
+
+	IdempotencyKey string `json:"idempotency_key,omitempty"` // Same for every attempt at one update
 }
 
 // GetActiveRepositories fetches all repositories marked for Bzzz consumption
@@ -78,13 +141,12 @@ func (c *HiveClient) GetActiveRepositories(ctx context.Context) ([]Repository, e
 	
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, unavailable(fmt.Errorf("failed to execute request: %w", err))
 	}
 	defer resp.Body.Close()
 	
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, statusError(resp, "API request")
 	}
 	
 	var response ActiveRepositoriesResponse
@@ -111,13 +173,12 @@ func (c *HiveClient) GetProjectTasks(ctx context.Context, projectID int) ([]map[
 	
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, unavailable(fmt.Errorf("failed to execute request: %w", err))
 	}
 	defer resp.Body.Close()
 	
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, statusError(resp, "API request")
 	}
 	
 	var tasks []map[string]interface{}
@@ -128,24 +189,77 @@ func (c *HiveClient) GetProjectTasks(ctx context.Context, projectID int) ([]map[
 	return tasks, nil
 }
 
-// ClaimTask registers a task claim with the Hive system
-func (c *HiveClient) ClaimTask(ctx context.Context, projectID, taskID int, agentID string) error {
+// ClaimTask registers a task claim with the Hive system, holding it for the lease duration
+func (c *HiveClient) ClaimTask(ctx context.Context, projectID, taskID int, agentID string, lease time.Duration) error {
 	url := fmt.Sprintf("%s/api/bzzz/projects/%d/claim", c.BaseURL, projectID)
-	
+	claimRequest := newClaimRequest(taskID, agentID, lease, "claim")
+	err := c.postClaim(ctx, url, claimRequest)
+	return c.queueIfUnavailable(&queuedReport{ProjectID: projectID, Claim: &claimRequest}, err)
+}
+
+// RenewClaim extends an agent's lease on a task it is still working on
+func (c *HiveClient) RenewClaim(ctx context.Context, projectID, taskID int, agentID string, lease time.Duration) error {
+	url := fmt.Sprintf("%s/api/bzzz/projects/%d/claim/renew", c.BaseURL, projectID)
+	return c.postClaim(ctx, url, newClaimRequest(taskID, agentID, lease, "renew"))
+}
+
+// ReleaseClaim gives up an agent's lease on a task before it runs out, so other
+// agents can take the task straight away. A claim still queued for Hive is
+// dropped rather than sent.
+func (c *HiveClient) ReleaseClaim(ctx context.Context, projectID, taskID int, agentID string) error {
+	if c == nil {
+		return nil
+	}
+	c.DropQueuedClaim(projectID, taskID)
+	url := fmt.Sprintf("%s/api/bzzz/projects/%d/claim/release", c.BaseURL, projectID)
+	return c.postClaim(ctx, url, newClaimRequest(taskID, agentID, 0, "release"))
+}
+
+// newClaimRequest describes a claim or renewal starting now
+func newClaimRequest(taskID int, agentID string, lease time.Duration, action string) TaskClaimRequest {
+	now := time.Now()
 	claimRequest := TaskClaimRequest{
-		TaskNumber: taskID,
-		AgentID:    agentID,
-		ClaimedAt:  time.Now().Unix(),
+		TaskNumber:     taskID,
+		AgentID:        agentID,
+		ClaimedAt:      now.Unix(),
+		IdempotencyKey: newIdempotencyKey(agentID, taskID, action),
 	}
-	
+	if lease > 0 {
+		claimRequest.LeaseExpiresAt = now.Add(lease).Unix()
+	}
+	return claimRequest
+}
+
+// postClaim posts a claim or renewal; a conflict means another agent's lease is live
+func (c *HiveClient) postClaim(ctx context.Context, url string, claimRequest TaskClaimRequest) error {
 	jsonData, err := json.Marshal(claimRequest)
 	if err != nil {
 		return fmt.Errorf("failed to marshal claim request: %w", err)
 	}
 	
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	resp, err := c.sendIdempotent(ctx, "POST", url, jsonData, claimRequest.IdempotencyKey)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode == http.StatusConflict {
+		return ErrClaimHeld
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return statusError(resp, "claim request")
+	}
+	
+	return nil
+}
+
+// GetClaims fetches the current claim leases for a project
+func (c *HiveClient) GetClaims(ctx context.Context, projectID int) ([]ClaimLease, error) {
+	url := fmt.Sprintf("%s/api/bzzz/projects/%d/claims", c.BaseURL, projectID)
+	
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	
 	if c.APIKey != "" {
@@ -155,52 +269,65 @@ func (c *HiveClient) ClaimTask(ctx context.Context, projectID, taskID int, agent
 	
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
+		return nil, unavailable(fmt.Errorf("failed to execute request: %w", err))
 	}
 	defer resp.Body.Close()
 	
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("claim request failed with status %d: %s", resp.StatusCode, string(body))
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp, "API request")
 	}
 	
-	return nil
+	var claims []ClaimLease
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	
+	return claims, nil
 }
 
-// UpdateTaskStatus updates the task status in the Hive system
+// UpdateTaskStatus updates the task status in the Hive system. With batching
+// enabled the update is queued and sent with the next bulk flush.
 func (c *HiveClient) UpdateTaskStatus(ctx context.Context, projectID, taskID int, status string, results map[string]interface{}) error {
-	url := fmt.Sprintf("%s/api/bzzz/projects/%d/status", c.BaseURL, projectID)
-	
 	statusUpdate := TaskStatusUpdate{
-		Status:    status,
-		UpdatedAt: time.Now().Unix(),
-		Results:   results,
+		TaskNumber: taskID,
+		Status:     status,
+		UpdatedAt:  time.Now().Unix(),
+		Results:    results,
+
+		IdempotencyKey: newIdempotencyKey(strconv.Itoa(projectID), taskID, status),
 	}
 	
-	jsonData, err := json.Marshal(statusUpdate)
-	if err != nil {
-		return fmt.Errorf("failed to marshal status update: %w", err)
-	}
+	c.reports.supersede(projectID, statusUpdate)
 	
-	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	if c.batcher != nil && c.batcher.enqueue(projectID, statusUpdate) {
+		return nil
 	}
+	return c.deliverStatusUpdate(ctx, projectID, statusUpdate)
+}
+
+// deliverStatusUpdate sends a status update, queueing it for retry if Hive is unavailable
+func (c *HiveClient) deliverStatusUpdate(ctx context.Context, projectID int, statusUpdate TaskStatusUpdate) error {
+	err := c.sendStatusUpdate(ctx, projectID, statusUpdate)
+	return c.queueIfUnavailable(&queuedReport{ProjectID: projectID, Status: &statusUpdate}, err)
+}
+
+// sendStatusUpdate reports a single status update to Hive
+func (c *HiveClient) sendStatusUpdate(ctx context.Context, projectID int, statusUpdate TaskStatusUpdate) error {
+	url := fmt.Sprintf("%s/api/bzzz/projects/%d/status", c.BaseURL, projectID)
 	
-	if c.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	jsonData, err := json.Marshal(statusUpdate)
+	if err != nil {
+		return fmt.Errorf("failed to marshal status update: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
 	
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.sendIdempotent(ctx, "PUT", url, jsonData, statusUpdate.IdempotencyKey)
 	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 	
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("status update failed with status %d: %s", resp.StatusCode, string(body))
+		return statusError(resp, "status update")
 	}
 	
 	return nil
@@ -217,13 +344,14 @@ func (c *HiveClient) HealthCheck(ctx context.Context) error {
 	
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("health check request failed: %w", err)
+		return unavailable(fmt.Errorf("health check request failed: %w", err))
 	}
 	defer resp.Body.Close()
 	
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Hive API health check failed with status: %d", resp.StatusCode)
+		return statusError(resp, "Hive API health check")
 	}
 	
+	c.reachedHive()
 	return nil
 }
\ No newline at end of file
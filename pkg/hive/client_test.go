@@ -0,0 +1,81 @@
+package hive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOutagesAreErrHiveUnavailable(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	ctx := context.Background()
+
+	client := NewHiveClient(server.URL, "")
+	client.RetryDelay = time.Millisecond
+	if _, err := client.GetActiveRepositories(ctx); !errors.Is(err, ErrHiveUnavailable) {
+		t.Errorf("expected a 503 to be ErrHiveUnavailable, got %v", err)
+	}
+
+	status = http.StatusBadRequest
+	if err := client.ClaimTask(ctx, 1, 42, "agent-a", time.Minute); err == nil || errors.Is(err, ErrHiveUnavailable) {
+		t.Errorf("expected a 400 to be a plain error, got %v", err)
+	}
+
+	server.Close()
+	if err := client.UpdateTaskStatus(ctx, 1, 42, "completed", nil); !errors.Is(err, ErrHiveUnavailable) {
+		t.Errorf("expected an unreachable Hive to be ErrHiveUnavailable, got %v", err)
+	}
+}
+
+func TestRetriedClaimCarriesSameIdempotencyKey(t *testing.T) {
+	var mu sync.Mutex
+	var headerKeys, bodyKeys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var claim TaskClaimRequest
+		json.NewDecoder(r.Body).Decode(&claim)
+		mu.Lock()
+		defer mu.Unlock()
+		headerKeys = append(headerKeys, r.Header.Get(IdempotencyHeader))
+		bodyKeys = append(bodyKeys, claim.IdempotencyKey)
+		if len(headerKeys) < 3 {
+			w.WriteHeader(http.StatusBadGateway) // e.g. the claim landed but the proxy timed out
+		}
+	}))
+	defer server.Close()
+
+	client := NewHiveClient(server.URL, "")
+	client.RetryDelay = time.Millisecond
+	ctx := context.Background()
+	if err := client.ClaimTask(ctx, 1, 42, "agent-a", time.Minute); err != nil {
+		t.Fatalf("ClaimTask failed after retries: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(headerKeys) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(headerKeys))
+	}
+	for i := range headerKeys {
+		if headerKeys[i] == "" || headerKeys[i] != headerKeys[0] || bodyKeys[i] != headerKeys[0] {
+			t.Fatalf("attempts carried different idempotency keys: headers %v, bodies %v", headerKeys, bodyKeys)
+		}
+	}
+
+	// A new claim is a new operation with its own key
+	first := headerKeys[0]
+	headerKeys = headerKeys[:2] // Succeed straight away
+	mu.Unlock()
+	err := client.ClaimTask(ctx, 1, 42, "agent-a", time.Minute)
+	mu.Lock()
+	if err != nil || headerKeys[2] == first {
+		t.Errorf("expected a fresh key for a new claim, got %v (err %v)", headerKeys, err)
+	}
+}
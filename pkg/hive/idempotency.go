@@ -0,0 +1,62 @@
+package hive
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// IdempotencyHeader carries the key Hive uses to recognise a retried request
+const IdempotencyHeader = "Idempotency-Key"
+
+// newIdempotencyKey names one logical operation on a task. Every attempt at the
+// operation reuses the key, so Hive can drop a retry of a request that succeeded.
+func newIdempotencyKey(owner string, taskID int, action string) string {
+	nonce := make([]byte, 8)
+	rand.Read(nonce)
+	return fmt.Sprintf("%s-%d-%s-%x", owner, taskID, action, nonce)
+}
+
+// sendIdempotent sends a request carrying an idempotency key, retrying it with the
+// same key while Hive is unreachable or failing. Any other response is returned as-is.
+func (c *HiveClient) sendIdempotent(ctx context.Context, method, url string, body []byte, key string) (*http.Response, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, lastErr
+			case <-time.After(time.Duration(attempt) * c.RetryDelay):
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		if c.APIKey != "" {
+			req.Header.Set("Authorization", "Bearer "+c.APIKey)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(IdempotencyHeader, key)
+
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			lastErr = unavailable(fmt.Errorf("failed to execute request: %w", err))
+			continue
+		}
+		if resp.StatusCode >= http.StatusInternalServerError && attempt < c.MaxRetries {
+			lastErr = statusError(resp, "request")
+			resp.Body.Close()
+			continue
+		}
+		if resp.StatusCode < http.StatusInternalServerError {
+			c.reachedHive()
+		}
+		return resp, nil
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,122 @@
+package hive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// ErrProjectExists is returned when registering a repository Hive already knows about
+var ErrProjectExists = errors.New("project is already registered")
+
+// gitURLPatterns matches https and ssh URLs of repositories on host
+func gitURLPatterns(host string) []*regexp.Regexp {
+	quoted := regexp.QuoteMeta(host)
+	return []*regexp.Regexp{
+		regexp.MustCompile(`^https://` + quoted + `/([A-Za-z0-9_.-]+)/([A-Za-z0-9_.-]+?)(\.git)?/?$`),
+		regexp.MustCompile(`^git@` + quoted + `:([A-Za-z0-9_.-]+)/([A-Za-z0-9_.-]+?)(\.git)?$`),
+	}
+}
+
+// ParseGitURL validates a GitHub repository URL and returns its owner and
+// repository. URLs on github.com are accepted, as are those on any of hosts,
+// the GitHub Enterprise Server hosts in use.
+func ParseGitURL(gitURL string, hosts ...string) (owner, repository string, err error) {
+	gitURL = strings.TrimSpace(gitURL)
+	for _, host := range append([]string{"github.com"}, hosts...) {
+		if host == "" {
+			continue
+		}
+		for _, pattern := range gitURLPatterns(host) {
+			if m := pattern.FindStringSubmatch(gitURL); m != nil {
+				return m[1], m[2], nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("invalid GitHub repository URL: %q", gitURL)
+}
+
+// RegisterProject registers a repository with Hive. If it is already registered,
+// ErrProjectExists is returned along with the existing project when Hive reports it.
+func (c *HiveClient) RegisterProject(ctx context.Context, registration ProjectRegistrationRequest) (*Project, error) {
+	if _, _, err := ParseGitURL(registration.GitURL, c.GitHubHosts...); err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/api/bzzz/projects", c.BaseURL)
+
+	jsonData, err := json.Marshal(registration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal registration request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, unavailable(fmt.Errorf("failed to execute request: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		var existing Project
+		if err := json.NewDecoder(resp.Body).Decode(&existing); err == nil && existing.ID != 0 {
+			return &existing, ErrProjectExists
+		}
+		return nil, ErrProjectExists
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, statusError(resp, "registration")
+	}
+
+	var project Project
+	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &project, nil
+}
+
+// SetProjectActivation enables or disables Bzzz on a registered project
+func (c *HiveClient) SetProjectActivation(ctx context.Context, projectID int, activation ProjectActivationRequest) error {
+	url := fmt.Sprintf("%s/api/bzzz/projects/%d/activation", c.BaseURL, projectID)
+
+	jsonData, err := json.Marshal(activation)
+	if err != nil {
+		return fmt.Errorf("failed to marshal activation request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return unavailable(fmt.Errorf("failed to execute request: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp, "activation")
+	}
+
+	return nil
+}
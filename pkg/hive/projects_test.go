@@ -0,0 +1,107 @@
+package hive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseGitURL(t *testing.T) {
+	valid := map[string][2]string{
+		"https://github.com/acme/widgets":      {"acme", "widgets"},
+		"https://github.com/acme/widgets.git":  {"acme", "widgets"},
+		"git@github.com:acme/widgets.git":      {"acme", "widgets"},
+		"https://github.com/acme/my.repo-name": {"acme", "my.repo-name"},
+	}
+	for gitURL, want := range valid {
+		owner, repo, err := ParseGitURL(gitURL)
+		if err != nil || owner != want[0] || repo != want[1] {
+			t.Errorf("ParseGitURL(%q) = %q, %q, %v; want %q, %q", gitURL, owner, repo, err, want[0], want[1])
+		}
+	}
+
+	for _, gitURL := range []string{"", "github.com/acme/widgets", "https://gitlab.com/acme/widgets", "https://github.com/acme"} {
+		if _, _, err := ParseGitURL(gitURL); err == nil {
+			t.Errorf("expected ParseGitURL(%q) to fail", gitURL)
+		}
+	}
+
+	// Repositories on a configured Enterprise server are accepted alongside github.com's
+	for _, gitURL := range []string{"https://github.example.com/acme/widgets.git", "git@github.example.com:acme/widgets.git"} {
+		if _, _, err := ParseGitURL(gitURL); err == nil {
+			t.Errorf("expected ParseGitURL(%q) to fail without the Enterprise host", gitURL)
+		}
+		if owner, repo, err := ParseGitURL(gitURL, "github.example.com"); err != nil || owner != "acme" || repo != "widgets" {
+			t.Errorf("ParseGitURL(%q, enterprise) = %q, %q, %v", gitURL, owner, repo, err)
+		}
+	}
+}
+
+func TestRegisterAndActivateProject(t *testing.T) {
+	var registration ProjectRegistrationRequest
+	var activation ProjectActivationRequest
+	var activationPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/bzzz/projects":
+			json.NewDecoder(r.Body).Decode(&registration)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id": 12, "name": "widgets", "git_url": "https://github.com/acme/widgets"}`)
+		case r.Method == http.MethodPut:
+			activationPath = r.URL.Path
+			json.NewDecoder(r.Body).Decode(&activation)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewHiveClient(server.URL, "")
+	project, err := client.RegisterProject(context.Background(), ProjectRegistrationRequest{
+		Name:        "widgets",
+		GitURL:      "https://github.com/acme/widgets",
+		BzzzEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("RegisterProject failed: %v", err)
+	}
+	if project.ID != 12 || registration.GitURL != "https://github.com/acme/widgets" || !registration.BzzzEnabled {
+		t.Errorf("unexpected registration: project %+v, request %+v", project, registration)
+	}
+
+	if err := client.SetProjectActivation(context.Background(), project.ID, ProjectActivationRequest{BzzzEnabled: true, ReadyToClaim: true}); err != nil {
+		t.Fatalf("SetProjectActivation failed: %v", err)
+	}
+	if activationPath != "/api/bzzz/projects/12/activation" || !activation.BzzzEnabled || !activation.ReadyToClaim {
+		t.Errorf("unexpected activation: path %s, request %+v", activationPath, activation)
+	}
+}
+
+func TestRegisterProjectConflict(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprint(w, `{"id": 5, "name": "widgets"}`)
+	}))
+	defer server.Close()
+
+	project, err := NewHiveClient(server.URL, "").RegisterProject(context.Background(), ProjectRegistrationRequest{
+		Name:   "widgets",
+		GitURL: "git@github.com:acme/widgets.git",
+	})
+	if !errors.Is(err, ErrProjectExists) {
+		t.Fatalf("expected ErrProjectExists, got %v", err)
+	}
+	if project == nil || project.ID != 5 {
+		t.Errorf("expected the existing project to be returned, got %+v", project)
+	}
+}
+
+func TestRegisterProjectRejectsInvalidURL(t *testing.T) {
+	if _, err := NewHiveClient("http://unused", "").RegisterProject(context.Background(), ProjectRegistrationRequest{GitURL: "not a url"}); err == nil {
+		t.Fatal("expected invalid git URL to be rejected")
+	}
+}
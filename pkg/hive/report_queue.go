@@ -0,0 +1,427 @@
+package hive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pkg/supervisor"
+)
+
+// ErrReportQueued is wrapped by errors for reports that Hive couldn't take now
+// and that are queued to be retried
+var ErrReportQueued = errors.New("report queued for retry")
+
+// pendingReportsFileName is where queued reports are kept, next to the dead-letter file
+const pendingReportsFileName = "hive-pending-reports.json"
+
+const (
+	defaultReportAttempts   = 8
+	defaultReportRetryDelay = 5 * time.Second // Doubles with each failed attempt
+	maxReportRetryDelay     = 5 * time.Minute
+)
+
+// queuedReport is a claim or status report waiting for Hive to come back
+type queuedReport struct {
+	ProjectID int               `json:"project_id"`
+	Claim     *TaskClaimRequest `json:"claim,omitempty"`
+	Status    *TaskStatusUpdate `json:"status,omitempty"`
+	Attempts  int               `json:"attempts"`
+	LastError string            `json:"last_error,omitempty"`
+	QueuedAt  time.Time         `json:"queued_at"`
+	FailedAt  time.Time         `json:"failed_at,omitempty"` // Set when given up on
+
+	nextAttempt time.Time
+}
+
+// reportQueue retries reports Hive was unavailable for, backing off exponentially,
+// and writes those it gives up on to a dead-letter file for reconciliation. The
+// queue is kept on disk too, so a restarted agent retries its reports with the
+// idempotency keys they were first sent with.
+type reportQueue struct {
+	client         *HiveClient
+	deadLetterPath string
+	pendingPath    string
+	maxAttempts    int
+	retryDelay     time.Duration
+	pending        []*queuedReport
+	wake           chan struct{}
+	mu             sync.Mutex
+	deadLetterMu   sync.Mutex
+}
+
+// DefaultDeadLetterPath is where reports Hive never accepted are kept by default
+func DefaultDeadLetterPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config", "bzzz", "hive-dead-letter.jsonl")
+}
+
+// EnableReportRetries queues claims and status updates that fail because Hive is
+// unavailable and retries them until ctx ends. All queued reports are retried as
+// soon as any request reaches Hive again. Reports still failing after the last
+// attempt are appended to deadLetterPath; empty means DefaultDeadLetterPath.
+// Status updates queued before a restart are picked up from alongside it.
+func (c *HiveClient) EnableReportRetries(ctx context.Context, deadLetterPath string) {
+	if deadLetterPath == "" {
+		deadLetterPath = DefaultDeadLetterPath()
+	}
+	c.reports = &reportQueue{
+		client:         c,
+		deadLetterPath: deadLetterPath,
+		pendingPath:    filepath.Join(filepath.Dir(deadLetterPath), pendingReportsFileName),
+		maxAttempts:    defaultReportAttempts,
+		retryDelay:     defaultReportRetryDelay,
+		wake:           make(chan struct{}, 1),
+	}
+	queue := c.reports
+	queue.restorePending()
+	supervisor.Go(ctx, "hive report retries", func() { queue.run(ctx) })
+}
+
+// StopReportRetries makes a last attempt at every queued report, e.g. before
+// shutdown. The ones that still fail stay queued on disk for the next start.
+func (c *HiveClient) StopReportRetries(ctx context.Context) {
+	if c.reports == nil {
+		return
+	}
+	c.reports.retry(ctx, true)
+}
+
+// PendingReports returns how many reports are waiting to be retried
+func (c *HiveClient) PendingReports() int {
+	if c.reports == nil {
+		return 0
+	}
+	c.reports.mu.Lock()
+	defer c.reports.mu.Unlock()
+	return len(c.reports.pending)
+}
+
+// queueIfUnavailable queues a report that failed because Hive was unavailable,
+// marking the error so callers know it will be retried
+func (c *HiveClient) queueIfUnavailable(report *queuedReport, err error) error {
+	if err == nil || c.reports == nil || !errors.Is(err, ErrHiveUnavailable) {
+		return err
+	}
+	c.reports.add(report, err)
+	return fmt.Errorf("%w: %w", ErrReportQueued, err)
+}
+
+// reachedHive notes that a request got through, so queued reports go out now
+func (c *HiveClient) reachedHive() {
+	if c.reports != nil {
+		c.reports.flushSoon()
+	}
+}
+
+// DropQueuedClaim forgets a claim of a task queued while Hive was unavailable,
+// once the agent has let the task go, so it isn't replayed as a phantom lease
+func (c *HiveClient) DropQueuedClaim(projectID, taskID int) {
+	if c == nil || c.reports == nil {
+		return
+	}
+	c.reports.mu.Lock()
+	defer c.reports.mu.Unlock()
+	c.reports.dropLocked(projectID, taskID, false, true)
+}
+
+// claimEndingStatuses are the statuses after which a task's claim is over
+var claimEndingStatuses = map[string]bool{
+	"completed": true,
+	"cancelled": true,
+	"escalated": true,
+	"split":     true,
+}
+
+// supersede drops queued status reports for the task a newer status update is
+// about, and its queued claim if the update ends it, so replaying the queue
+// never puts Hive back to an older state
+func (q *reportQueue) supersede(projectID int, update TaskStatusUpdate) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.dropLocked(projectID, update.TaskNumber, true, claimEndingStatuses[update.Status])
+}
+
+// dropLocked removes a task's queued status reports if statuses is set and its
+// queued claim if claims is set; callers must hold the lock
+func (q *reportQueue) dropLocked(projectID, taskID int, statuses, claims bool) {
+	kept := q.pending[:0]
+	for _, queued := range q.pending {
+		sameTask := queued.ProjectID == projectID && queued.taskNumber() == taskID
+		if sameTask && ((statuses && queued.Status != nil) || (claims && queued.Claim != nil)) {
+			fmt.Printf("🗑️ Dropping queued %s, superseded\n", queued)
+			continue
+		}
+		kept = append(kept, queued)
+	}
+	if len(kept) != len(q.pending) {
+		q.pending = kept
+		q.savePendingLocked()
+	}
+}
+
+// add queues a report for its first retry, replacing any older queued status
+// report for the same task
+func (q *reportQueue) add(report *queuedReport, err error) {
+	now := time.Now()
+	report.Attempts = 1
+	report.LastError = err.Error()
+	report.QueuedAt = now
+	report.nextAttempt = now.Add(q.retryDelay)
+
+	q.mu.Lock()
+	if report.Status != nil {
+		q.dropLocked(report.ProjectID, report.Status.TaskNumber, true, claimEndingStatuses[report.Status.Status])
+	}
+	q.pending = append(q.pending, report)
+	q.savePendingLocked()
+	q.mu.Unlock()
+	fmt.Printf("📮 Hive unavailable, queued %s for retry\n", report)
+	q.nudge()
+}
+
+// nudge wakes the retry loop to reconsider when the next report is due
+func (q *reportQueue) nudge() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// flushSoon makes every queued report due and wakes the retry loop
+func (q *reportQueue) flushSoon() {
+	q.mu.Lock()
+	if len(q.pending) == 0 {
+		q.mu.Unlock()
+		return
+	}
+	for _, report := range q.pending {
+		report.nextAttempt = time.Time{}
+	}
+	q.mu.Unlock()
+	q.nudge()
+}
+
+// run retries reports as they fall due
+func (q *reportQueue) run(ctx context.Context) {
+	for {
+		timer := time.NewTimer(q.untilNextDue())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return // Whatever is queued stays on disk for the next start
+		case <-q.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+		q.retry(ctx, false)
+	}
+}
+
+// untilNextDue returns how long until the earliest queued report is due
+func (q *reportQueue) untilNextDue() time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	wait := maxReportRetryDelay
+	for _, report := range q.pending {
+		if until := time.Until(report.nextAttempt); until < wait {
+			wait = until
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// retry sends the reports that are due, or all of them if all is set
+func (q *reportQueue) retry(ctx context.Context, all bool) {
+	now := time.Now()
+	q.mu.Lock()
+	var due []*queuedReport
+	for _, report := range q.pending {
+		if all || !report.nextAttempt.After(now) {
+			due = append(due, report)
+		}
+	}
+	q.mu.Unlock()
+
+	for _, report := range due {
+		if !q.queued(report) {
+			continue // Superseded while earlier reports were sent
+		}
+		err := q.client.send(ctx, report)
+
+		q.mu.Lock()
+		switch {
+		case err == nil:
+			q.remove(report)
+			q.savePendingLocked()
+			q.mu.Unlock()
+			fmt.Printf("📬 Delivered queued %s to Hive\n", report)
+			continue
+		case errors.Is(err, ErrHiveUnavailable) && report.Attempts < q.maxAttempts:
+			report.Attempts++
+			report.LastError = err.Error()
+			report.nextAttempt = time.Now().Add(q.backoff(report.Attempts))
+			q.savePendingLocked()
+			q.mu.Unlock()
+			continue
+		}
+		// Out of attempts, or Hive rejected it outright; retrying won't help
+		q.remove(report)
+		report.LastError = err.Error()
+		q.savePendingLocked()
+		q.mu.Unlock()
+		q.deadLetter(report)
+	}
+}
+
+// queued reports whether a report is still waiting to be sent
+func (q *reportQueue) queued(report *queuedReport) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, queued := range q.pending {
+		if queued == report {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff doubles the retry delay with each attempt, up to a cap
+func (q *reportQueue) backoff(attempts int) time.Duration {
+	delay := q.retryDelay
+	for i := 1; i < attempts && delay < maxReportRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxReportRetryDelay {
+		delay = maxReportRetryDelay
+	}
+	return delay
+}
+
+// remove drops a report from the queue; callers hold the lock
+func (q *reportQueue) remove(report *queuedReport) {
+	for i, queued := range q.pending {
+		if queued == report {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// restorePending queues the status updates a previous run left pending, due at
+// once. Its claims are dropped: the tasks were let go when the agent stopped, and
+// replaying a claim would leave Hive with a lease nobody holds.
+func (q *reportQueue) restorePending() {
+	data, err := os.ReadFile(q.pendingPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("⚠️ Failed to read queued Hive reports: %v\n", err)
+		}
+		return
+	}
+	var restored []*queuedReport
+	if err := json.Unmarshal(data, &restored); err != nil {
+		fmt.Printf("⚠️ Ignoring unreadable queued Hive reports in %s: %v\n", q.pendingPath, err)
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, report := range restored {
+		if report.Status == nil {
+			continue
+		}
+		q.pending = append(q.pending, report)
+	}
+	q.savePendingLocked()
+	if len(q.pending) > 0 {
+		fmt.Printf("📮 Retrying %d Hive reports queued before the last shutdown\n", len(q.pending))
+	}
+}
+
+// savePendingLocked writes the queue to disk, with each report's idempotency
+// key; callers must hold the lock
+func (q *reportQueue) savePendingLocked() {
+	if len(q.pending) == 0 {
+		if err := os.Remove(q.pendingPath); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("⚠️ Failed to clear queued Hive reports: %v\n", err)
+		}
+		return
+	}
+	data, err := json.MarshalIndent(q.pending, "", "  ")
+	if err != nil {
+		fmt.Printf("⚠️ Failed to encode queued Hive reports: %v\n", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(q.pendingPath), 0755); err != nil {
+		fmt.Printf("⚠️ Failed to save queued Hive reports: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(q.pendingPath, data, 0644); err != nil {
+		fmt.Printf("⚠️ Failed to save queued Hive reports: %v\n", err)
+	}
+}
+
+// deadLetter appends a report Hive never accepted to the dead-letter file
+func (q *reportQueue) deadLetter(report *queuedReport) {
+	report.FailedAt = time.Now()
+	fmt.Printf("🪦 Giving up on %s after %d attempts, writing it to %s: %s\n", report, report.Attempts, q.deadLetterPath, report.LastError)
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		fmt.Printf("❌ Failed to encode dead-lettered Hive report: %v\n", err)
+		return
+	}
+
+	q.deadLetterMu.Lock()
+	defer q.deadLetterMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(q.deadLetterPath), 0755); err != nil {
+		fmt.Printf("❌ Failed to write Hive dead-letter file: %v\n", err)
+		return
+	}
+	file, err := os.OpenFile(q.deadLetterPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Printf("❌ Failed to write Hive dead-letter file: %v\n", err)
+		return
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		fmt.Printf("❌ Failed to write Hive dead-letter file: %v\n", err)
+	}
+}
+
+// send delivers a queued report with its original idempotency key
+func (c *HiveClient) send(ctx context.Context, report *queuedReport) error {
+	if report.Claim != nil {
+		url := fmt.Sprintf("%s/api/bzzz/projects/%d/claim", c.BaseURL, report.ProjectID)
+		return c.postClaim(ctx, url, *report.Claim)
+	}
+	return c.sendStatusUpdate(ctx, report.ProjectID, *report.Status)
+}
+
+// taskNumber is the task the report is about
+func (r *queuedReport) taskNumber() int {
+	if r.Claim != nil {
+		return r.Claim.TaskNumber
+	}
+	return r.Status.TaskNumber
+}
+
+// String describes the report for logs
+func (r *queuedReport) String() string {
+	if r.Claim != nil {
+		return fmt.Sprintf("claim of task #%d in project %d", r.Claim.TaskNumber, r.ProjectID)
+	}
+	return fmt.Sprintf("%q status of task #%d in project %d", r.Status.Status, r.Status.TaskNumber, r.ProjectID)
+}
@@ -0,0 +1,237 @@
+package hive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCompletionIsRetriedOnceHiveRecovers(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	var mu sync.Mutex
+	var delivered []TaskStatusUpdate
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var update TaskStatusUpdate
+		json.NewDecoder(r.Body).Decode(&update)
+		mu.Lock()
+		delivered = append(delivered, update)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	deadLetters := filepath.Join(t.TempDir(), "dead-letter.jsonl")
+	client := NewHiveClient(server.URL, "")
+	client.RetryDelay = time.Millisecond
+	client.EnableReportRetries(ctx, deadLetters)
+	client.reports.retryDelay = 20 * time.Millisecond
+
+	err := client.UpdateTaskStatus(ctx, 7, 42, "completed", map[string]interface{}{"pull_request_url": "https://github.com/acme/widgets/pull/9"})
+	if !errors.Is(err, ErrReportQueued) || !errors.Is(err, ErrHiveUnavailable) {
+		t.Fatalf("expected the completion to be queued while Hive is down, got %v", err)
+	}
+	if client.PendingReports() != 1 {
+		t.Fatalf("expected 1 pending report, got %d", client.PendingReports())
+	}
+
+	// Let a few retries fail and back off before Hive comes back
+	time.Sleep(100 * time.Millisecond)
+	down.Store(false)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for client.PendingReports() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the queued completion was never delivered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(delivered) != 1 || delivered[0].TaskNumber != 42 || delivered[0].Status != "completed" {
+		t.Fatalf("expected the completion delivered once, got %+v", delivered)
+	}
+	if delivered[0].Results["pull_request_url"] != "https://github.com/acme/widgets/pull/9" {
+		t.Errorf("the retried report lost its results: %+v", delivered[0].Results)
+	}
+	if _, err := os.Stat(deadLetters); !os.IsNotExist(err) {
+		t.Errorf("nothing should be dead-lettered, stat returned %v", err)
+	}
+}
+
+func TestReportsAreDeadLetteredAfterTheLastAttempt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	deadLetters := filepath.Join(t.TempDir(), "dead-letter.jsonl")
+	client := NewHiveClient(server.URL, "")
+	client.MaxRetries = 0
+	client.EnableReportRetries(ctx, deadLetters)
+	client.reports.retryDelay = 10 * time.Millisecond
+	client.reports.maxAttempts = 3
+
+	client.ClaimTask(ctx, 7, 42, "agent-a", time.Minute)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for client.PendingReports() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the claim was never given up on")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	data, err := os.ReadFile(deadLetters)
+	if err != nil {
+		t.Fatalf("expected a dead-letter file: %v", err)
+	}
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	if len(lines) != 1 {
+		t.Fatalf("expected one dead-lettered report, got %d", len(lines))
+	}
+	var report queuedReport
+	if err := json.Unmarshal(lines[0], &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Claim == nil || report.Claim.TaskNumber != 42 || report.ProjectID != 7 || report.Attempts != 3 || report.LastError == "" {
+		t.Errorf("unexpected dead-lettered report %+v", report)
+	}
+}
+
+func TestNewerReportsSupersedeQueuedOnes(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	var mu sync.Mutex
+	var delivered []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var update TaskStatusUpdate
+		json.NewDecoder(r.Body).Decode(&update)
+		mu.Lock()
+		delivered = append(delivered, r.URL.Path+" "+update.Status)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := NewHiveClient(server.URL, "")
+	client.MaxRetries = 0
+	client.EnableReportRetries(ctx, filepath.Join(t.TempDir(), "dead-letter.jsonl"))
+	client.reports.retryDelay = time.Hour // Only a request that gets through flushes the queue
+
+	client.ClaimTask(ctx, 7, 42, "agent-a", time.Minute)
+	client.UpdateTaskStatus(ctx, 7, 42, "in_progress", nil)
+	client.UpdateTaskStatus(ctx, 7, 43, "in_progress", nil)
+	client.UpdateTaskStatus(ctx, 7, 43, "escalated", nil)
+	if got := client.PendingReports(); got != 3 {
+		t.Fatalf("expected the claim and the latest status of each task queued, got %d reports", got)
+	}
+
+	// The agent gave up on #43 before Hive came back
+	client.DropQueuedClaim(7, 43)
+	client.ClaimTask(ctx, 7, 44, "agent-a", time.Minute)
+	client.DropQueuedClaim(7, 44)
+	if got := client.PendingReports(); got != 3 {
+		t.Fatalf("expected only #44's claim to be dropped, got %d reports", got)
+	}
+
+	// #42 finishes once Hive is back; its claim and older status mustn't be replayed after it
+	down.Store(false)
+	if err := client.UpdateTaskStatus(ctx, 7, 42, "completed", nil); err != nil {
+		t.Fatalf("UpdateTaskStatus failed: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for client.PendingReports() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the queued reports were never delivered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"/api/bzzz/projects/7/status completed", "/api/bzzz/projects/7/status escalated"}
+	if len(delivered) != len(want) || delivered[0] != want[0] || delivered[1] != want[1] {
+		t.Fatalf("expected %v delivered, got %v", want, delivered)
+	}
+}
+
+func TestQueuedReportsKeepTheirKeyAcrossRestart(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	var mu sync.Mutex
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		mu.Lock()
+		keys = append(keys, r.Header.Get(IdempotencyHeader))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	deadLetters := filepath.Join(t.TempDir(), "dead-letter.jsonl")
+	ctx, cancel := context.WithCancel(context.Background())
+	client := NewHiveClient(server.URL, "")
+	client.MaxRetries = 0
+	client.EnableReportRetries(ctx, deadLetters)
+	client.reports.retryDelay = time.Hour
+
+	client.ClaimTask(ctx, 7, 41, "agent-a", time.Minute)
+	client.UpdateTaskStatus(ctx, 7, 42, "completed", nil)
+	client.reports.mu.Lock()
+	sentKey := client.reports.pending[1].Status.IdempotencyKey
+	client.reports.mu.Unlock()
+	client.StopReportRetries(ctx)
+	cancel()
+
+	// The restarted agent sends the completion again with the key it was first sent with
+	down.Store(false)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	restarted := NewHiveClient(server.URL, "")
+	restarted.EnableReportRetries(ctx, deadLetters)
+	if got := restarted.PendingReports(); got != 1 {
+		t.Fatalf("expected the completion but not the claim to be restored, got %d reports", got)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for restarted.PendingReports() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the restored completion was never delivered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(keys) != 1 || keys[0] != sentKey {
+		t.Fatalf("expected the completion redelivered with key %q, got %v", sentKey, keys)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(deadLetters), pendingReportsFileName)); !os.IsNotExist(err) {
+		t.Errorf("expected the queue file cleared once delivered, stat returned %v", err)
+	}
+}
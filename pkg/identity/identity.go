@@ -0,0 +1,143 @@
+package identity
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// keyFileMode is the only permission a key file may have: owner read/write
+const keyFileMode = 0600
+
+// Identity is a node's persisted Ed25519 key pair; its peer ID derives from the public key
+type Identity struct {
+	path string
+	key  crypto.PrivKey
+}
+
+// DefaultPath returns where a node's private key is kept by default
+func DefaultPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config", "bzzz", "identity.key")
+}
+
+// Load reads the key at path. Keys readable by anyone but their owner are refused.
+func Load(path string) (*Identity, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read identity key: %w", err)
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		return nil, fmt.Errorf("identity key %s has permissions %04o; it must only be accessible by its owner (chmod 600)", path, perm)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read identity key: %w", err)
+	}
+	key, err := crypto.UnmarshalPrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode identity key %s: %w", path, err)
+	}
+	return &Identity{path: path, key: key}, nil
+}
+
+// Generate creates a new key and saves it to path, replacing any key already there
+func Generate(path string) (*Identity, error) {
+	key, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate identity key: %w", err)
+	}
+	id := &Identity{path: path, key: key}
+	if err := id.save(); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// LoadOrGenerate loads the key at path, generating one the first time
+func LoadOrGenerate(path string) (*Identity, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		id, err := Generate(path)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("🔑 Generated new node identity at %s\n", path)
+		return id, nil
+	}
+	return Load(path)
+}
+
+// Rotate replaces the key at path with a new one, keeping the old key alongside
+// it as path.old so the previous identity can be restored by hand.
+func Rotate(path string) (*Identity, error) {
+	if _, err := Load(path); err != nil {
+		return nil, fmt.Errorf("refusing to rotate: %w", err)
+	}
+	if err := os.Rename(path, path+".old"); err != nil {
+		return nil, fmt.Errorf("failed to back up identity key: %w", err)
+	}
+	return Generate(path)
+}
+
+// save writes the key with owner-only permissions, atomically replacing any existing file
+func (id *Identity) save() error {
+	data, err := crypto.MarshalPrivateKey(id.key)
+	if err != nil {
+		return fmt.Errorf("failed to encode identity key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(id.path), 0700); err != nil {
+		return fmt.Errorf("failed to create identity directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(id.path), ".identity-*")
+	if err != nil {
+		return fmt.Errorf("failed to save identity key: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if err := tmp.Chmod(keyFileMode); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save identity key: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save identity key: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save identity key: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), id.path); err != nil {
+		return fmt.Errorf("failed to save identity key: %w", err)
+	}
+	return nil
+}
+
+// Path returns the file the key is stored in
+func (id *Identity) Path() string {
+	return id.path
+}
+
+// PrivateKey returns the key to hand to libp2p.Identity
+func (id *Identity) PrivateKey() crypto.PrivKey {
+	return id.key
+}
+
+// PublicKey returns the key others use to verify this node's signatures
+func (id *Identity) PublicKey() crypto.PubKey {
+	return id.key.GetPublic()
+}
+
+// PeerID returns the libp2p peer ID this key produces
+func (id *Identity) PeerID() (peer.ID, error) {
+	return peer.IDFromPrivateKey(id.key)
+}
+
+// Sign signs data with the node's private key
+func (id *Identity) Sign(data []byte) ([]byte, error) {
+	return id.key.Sign(data)
+}
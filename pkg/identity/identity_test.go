@@ -0,0 +1,77 @@
+package identity
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bzzz", "identity.key")
+
+	generated, err := LoadOrGenerate(path)
+	if err != nil {
+		t.Fatalf("LoadOrGenerate failed: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != keyFileMode {
+		t.Fatalf("key saved with permissions %04o, want %04o", perm, keyFileMode)
+	}
+
+	loaded, err := LoadOrGenerate(path)
+	if err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	generatedID, _ := generated.PeerID()
+	loadedID, _ := loaded.PeerID()
+	if generatedID != loadedID {
+		t.Fatalf("peer ID changed on reload: %s then %s", generatedID, loadedID)
+	}
+
+	// Signatures made with the loaded key verify against the public key
+	signature, err := loaded.Sign([]byte("log entry"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := generated.PublicKey().Verify([]byte("log entry"), signature); err != nil || !ok {
+		t.Fatalf("signature did not verify: %v", err)
+	}
+
+	rotated, err := Rotate(path)
+	if err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	rotatedID, _ := rotated.PeerID()
+	if rotatedID == generatedID {
+		t.Fatalf("rotation kept the old identity")
+	}
+	previous, err := Load(path + ".old")
+	if err != nil {
+		t.Fatalf("previous key was not kept: %v", err)
+	}
+	if previousID, _ := previous.PeerID(); previousID != generatedID {
+		t.Fatalf("backup holds %s, want %s", previousID, generatedID)
+	}
+}
+
+func TestLoadRefusesExposedKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "identity.key")
+	if _, err := Generate(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "chmod 600") {
+		t.Fatalf("expected a world-readable key to be refused, got %v", err)
+	}
+	if _, err := Rotate(path); err == nil {
+		t.Fatalf("rotation should refuse an exposed key")
+	}
+}
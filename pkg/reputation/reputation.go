@@ -0,0 +1,163 @@
+package reputation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Outcome is something a peer did that affects its reputation
+type Outcome string
+
+const (
+	HelpAccepted          Outcome = "help_accepted"          // Help the peer gave was used
+	HelpRejected          Outcome = "help_rejected"          // The peer turned down a task it had offered to help with
+	DelegationSucceeded   Outcome = "delegation_succeeded"   // A delegated sub-task was completed
+	DelegationFailed      Outcome = "delegation_failed"      // A delegated sub-task was failed or abandoned
+	ConsensusParticipated Outcome = "consensus_participated" // The peer took part in reaching consensus
+	RateLimited           Outcome = "rate_limited"           // The peer flooded the mesh past the message rate limit
+)
+
+// neutralScore is the score of a peer we know nothing about
+const neutralScore = 0.5
+
+// PeerRecord holds the outcome counts for a single peer
+type PeerRecord struct {
+	HelpAccepted          int       `json:"help_accepted"`
+	HelpRejected          int       `json:"help_rejected"`
+	DelegationsSucceeded  int       `json:"delegations_succeeded"`
+	DelegationsFailed     int       `json:"delegations_failed"`
+	ConsensusParticipated int       `json:"consensus_participated"`
+	RateLimited           int       `json:"rate_limited"`
+	LastUpdated           time.Time `json:"last_updated"`
+}
+
+// Score rates the peer between 0 and 1. Delegations weigh more than help,
+// consensus participation counts a little and each bout of flooding counts like
+// rejected help; the smoothing keeps a single outcome from swinging a new peer
+// to either extreme.
+func (r *PeerRecord) Score() float64 {
+	positive := float64(r.HelpAccepted) + 2*float64(r.DelegationsSucceeded) + 0.5*float64(r.ConsensusParticipated)
+	negative := float64(r.HelpRejected) + 2*float64(r.DelegationsFailed) + float64(r.RateLimited)
+	return (positive + 1) / (positive + negative + 2)
+}
+
+// Store tracks peer reputations and persists them across restarts
+type Store struct {
+	path     string
+	peers    map[string]*PeerRecord // peerID -> record
+	peerLock sync.RWMutex
+}
+
+// NewStore creates a reputation store backed by path, loading any saved scores.
+// An empty path keeps scores in memory only.
+func NewStore(path string) *Store {
+	s := &Store{
+		path:  path,
+		peers: make(map[string]*PeerRecord),
+	}
+
+	if path == "" {
+		return s
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &s.peers); err != nil {
+			fmt.Printf("⚠️ Ignoring unreadable peer reputation file %s: %v\n", path, err)
+			s.peers = make(map[string]*PeerRecord)
+		}
+	}
+	return s
+}
+
+// Record notes an outcome for a peer and persists the updated scores
+func (s *Store) Record(peerID string, outcome Outcome) {
+	s.peerLock.Lock()
+	defer s.peerLock.Unlock()
+
+	record, exists := s.peers[peerID]
+	if !exists {
+		record = &PeerRecord{}
+		s.peers[peerID] = record
+	}
+
+	switch outcome {
+	case HelpAccepted:
+		record.HelpAccepted++
+	case HelpRejected:
+		record.HelpRejected++
+	case DelegationSucceeded:
+		record.DelegationsSucceeded++
+	case DelegationFailed:
+		record.DelegationsFailed++
+	case ConsensusParticipated:
+		record.ConsensusParticipated++
+	case RateLimited:
+		record.RateLimited++
+	default:
+		return
+	}
+	record.LastUpdated = time.Now()
+
+	s.save()
+}
+
+// Score returns a peer's reputation; unknown peers get a neutral score
+func (s *Store) Score(peerID string) float64 {
+	s.peerLock.RLock()
+	defer s.peerLock.RUnlock()
+
+	if record, exists := s.peers[peerID]; exists {
+		return record.Score()
+	}
+	return neutralScore
+}
+
+// Rank orders peers from most to least reputable, keeping the original order for ties
+func (s *Store) Rank(peerIDs []string) []string {
+	ranked := make([]string, len(peerIDs))
+	copy(ranked, peerIDs)
+
+	scores := make(map[string]float64, len(ranked))
+	for _, peerID := range ranked {
+		scores[peerID] = s.Score(peerID)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return scores[ranked[i]] > scores[ranked[j]]
+	})
+	return ranked
+}
+
+// GetRecord returns a copy of a peer's record
+func (s *Store) GetRecord(peerID string) (PeerRecord, bool) {
+	s.peerLock.RLock()
+	defer s.peerLock.RUnlock()
+
+	record, exists := s.peers[peerID]
+	if !exists {
+		return PeerRecord{}, false
+	}
+	return *record, true
+}
+
+// save writes the reputation records to disk; callers must hold the lock
+func (s *Store) save() {
+	if s.path == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		fmt.Printf("⚠️ Failed to persist peer reputation: %v\n", err)
+		return
+	}
+	data, err := json.MarshalIndent(s.peers, "", "  ")
+	if err != nil {
+		fmt.Printf("⚠️ Failed to persist peer reputation: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		fmt.Printf("⚠️ Failed to persist peer reputation: %v\n", err)
+	}
+}
@@ -0,0 +1,46 @@
+package reputation
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFailedDelegationsDeprioritizePeer(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "reputation.json"))
+
+	for i := 0; i < 3; i++ {
+		s.Record("flaky-peer", DelegationFailed)
+		s.Record("reliable-peer", DelegationSucceeded)
+	}
+
+	ranked := s.Rank([]string{"flaky-peer", "new-peer", "reliable-peer"})
+	want := []string{"reliable-peer", "new-peer", "flaky-peer"}
+	for i := range want {
+		if ranked[i] != want[i] {
+			t.Fatalf("expected ranking %v, got %v", want, ranked)
+		}
+	}
+
+	if s.Score("flaky-peer") >= neutralScore {
+		t.Errorf("flaky peer score %.2f should be below neutral", s.Score("flaky-peer"))
+	}
+}
+
+func TestScoresPersistAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reputation.json")
+	s := NewStore(path)
+	s.Record("peer-a", HelpAccepted)
+	s.Record("peer-a", ConsensusParticipated)
+
+	reloaded := NewStore(path)
+	record, exists := reloaded.GetRecord("peer-a")
+	if !exists {
+		t.Fatalf("peer-a record was not persisted")
+	}
+	if record.HelpAccepted != 1 || record.ConsensusParticipated != 1 {
+		t.Errorf("unexpected persisted record: %+v", record)
+	}
+	if reloaded.Score("peer-a") != s.Score("peer-a") {
+		t.Errorf("score changed across restart: %.2f vs %.2f", reloaded.Score("peer-a"), s.Score("peer-a"))
+	}
+}
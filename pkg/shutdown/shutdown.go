@@ -0,0 +1,52 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultTimeout bounds a step that doesn't set its own
+const DefaultTimeout = 10 * time.Second
+
+// Step is one component to tear down
+type Step struct {
+	Name    string
+	Timeout time.Duration // How long the step may take; 0 means DefaultTimeout
+	Stop    func(ctx context.Context) error
+}
+
+// Run tears components down one at a time in the order given, so nothing is
+// closed while an earlier component might still use it. A step that overruns its
+// timeout is abandoned and the next one started, so a hung component can't hold
+// up the rest of the shutdown.
+func Run(steps []Step) error {
+	var errs []error
+	for _, step := range steps {
+		if err := runStep(step); err != nil {
+			fmt.Printf("⚠️ Shutdown step %q: %v\n", step.Name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", step.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runStep runs one step, giving up on it once its timeout passes
+func runStep(step Step) error {
+	timeout := step.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- step.Stop(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("gave up after %s: %w", timeout, ctx.Err())
+	}
+}
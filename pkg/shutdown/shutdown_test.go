@@ -0,0 +1,105 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pkg/supervisor"
+)
+
+func TestStepsStopInOrderDespiteHungStep(t *testing.T) {
+	var mu sync.Mutex
+	var stopped []string
+	step := func(name string, stop func(ctx context.Context) error) Step {
+		return Step{Name: name, Timeout: 50 * time.Millisecond, Stop: func(ctx context.Context) error {
+			mu.Lock()
+			stopped = append(stopped, name)
+			mu.Unlock()
+			return stop(ctx)
+		}}
+	}
+	ok := func(context.Context) error { return nil }
+	hung := make(chan struct{})
+	defer close(hung)
+
+	finished := make(chan error, 1)
+	go func() {
+		finished <- Run([]Step{
+			step("polling", ok),
+			step("tasks", func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }),
+			step("coordination", ok),
+			step("pubsub", func(context.Context) error { <-hung; return nil }), // Ignores its deadline
+			step("node", ok),
+		})
+	}()
+
+	var err error
+	select {
+	case err = <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown deadlocked on a hung step")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the overrunning steps to be reported, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"polling", "tasks", "coordination", "pubsub", "node"}; !reflect.DeepEqual(stopped, want) {
+		t.Fatalf("expected steps to stop in order %v, got %v", want, stopped)
+	}
+}
+
+func TestCoordinationLoopsAreDoneBeforePubSubCloses(t *testing.T) {
+	var mu sync.Mutex
+	closed, publishedAfterClose := false, false
+	publish := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		publishedAfterClose = publishedAfterClose || closed
+	}
+
+	// Announcement loops as main runs them, one of them slow to wind down
+	loops := supervisor.NewGroup(context.Background())
+	for _, windDown := range []time.Duration{0, 20 * time.Millisecond} {
+		loops.Go("announcements", func(ctx context.Context) {
+			ticker := time.NewTicker(time.Millisecond)
+			defer ticker.Stop()
+			for {
+				publish()
+				select {
+				case <-ctx.Done():
+					time.Sleep(windDown)
+					publish() // A last announcement on the way out
+					return
+				case <-ticker.C:
+				}
+			}
+		})
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	err := Run([]Step{
+		{Name: "coordination", Stop: loops.Stop},
+		{Name: "pubsub", Stop: func(context.Context) error {
+			mu.Lock()
+			closed = true
+			mu.Unlock()
+			return nil
+		}},
+	})
+	if err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if publishedAfterClose {
+		t.Fatal("a coordination loop published after pubsub closed")
+	}
+}
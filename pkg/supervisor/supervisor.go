@@ -0,0 +1,118 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Backoff between restarts of a loop that keeps panicking. A loop that ran for
+// longer than maxBackoff before panicking starts again from initialBackoff.
+var (
+	initialBackoff = time.Second
+	maxBackoff     = time.Minute
+)
+
+var loopRestarts = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "bzzz_supervised_loop_restarts_total",
+	Help: "Background loops restarted after a panic.",
+}, []string{"loop"})
+
+func init() {
+	prometheus.MustRegister(loopRestarts)
+}
+
+// Go runs a background loop in its own goroutine. If the loop panics the panic
+// is logged and the loop restarted, with backoff, instead of the loop silently
+// dying; a loop that returns is done and isn't restarted. Restarts stop once
+// ctx is done.
+func Go(ctx context.Context, name string, loop func()) {
+	go supervise(ctx, name, loop)
+}
+
+// Group supervises loops that are stopped together, and can be waited on, such
+// as those that must be done before a component they use is closed
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	loops  sync.WaitGroup
+}
+
+// NewGroup returns an empty group whose loops are also stopped once parent is done
+func NewGroup(parent context.Context) *Group {
+	ctx, cancel := context.WithCancel(parent)
+	return &Group{ctx: ctx, cancel: cancel}
+}
+
+// Context is done once the group is stopped
+func (g *Group) Context() context.Context {
+	return g.ctx
+}
+
+// Go runs loop under supervision like Go does. loop must return once the
+// context it is given is done.
+func (g *Group) Go(name string, loop func(ctx context.Context)) {
+	g.loops.Add(1)
+	go func() {
+		defer g.loops.Done()
+		supervise(g.ctx, name, func() { loop(g.ctx) })
+	}()
+}
+
+// Stop tells the group's loops to return and waits for them to, or for ctx to be done
+func (g *Group) Stop(ctx context.Context) error {
+	g.cancel()
+	stopped := make(chan struct{})
+	go func() {
+		g.loops.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("loops still running: %w", ctx.Err())
+	}
+}
+
+// supervise runs loop until it returns normally or ctx is done
+func supervise(ctx context.Context, name string, loop func()) {
+	backoff := initialBackoff
+	for {
+		started := time.Now()
+		if !panicked(name, loop) {
+			return
+		}
+		if time.Since(started) > maxBackoff {
+			backoff = initialBackoff
+		}
+
+		loopRestarts.WithLabelValues(name).Inc()
+		fmt.Printf("🔁 Restarting %s loop in %s\n", name, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// panicked runs loop and reports whether it panicked
+func panicked(name string, loop func()) (didPanic bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("💥 %s loop panicked: %v\n%s", name, r, debug.Stack())
+			didPanic = true
+		}
+	}()
+	loop()
+	return false
+}
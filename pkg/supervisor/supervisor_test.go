@@ -0,0 +1,42 @@
+package supervisor
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPanickingLoopIsRecoveredAndRestarted(t *testing.T) {
+	initialBackoff, maxBackoff = time.Millisecond, 10*time.Millisecond
+	defer func() { initialBackoff, maxBackoff = time.Second, time.Minute }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runs atomic.Int32
+	done := make(chan struct{})
+	Go(ctx, "flaky", func() {
+		if runs.Add(1) < 3 {
+			panic("poll failed unexpectedly")
+		}
+		close(done) // Third run settles down and returns
+	})
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("loop was not restarted after panicking, ran %d time(s)", runs.Load())
+	}
+	if got := testutil.ToFloat64(loopRestarts.WithLabelValues("flaky")); got != 2 {
+		t.Fatalf("expected 2 restarts counted, got %v", got)
+	}
+
+	// A loop that returns normally is finished, not restarted
+	time.Sleep(50 * time.Millisecond)
+	if runs.Load() != 3 {
+		t.Fatalf("loop ran again after returning normally: %d runs", runs.Load())
+	}
+}
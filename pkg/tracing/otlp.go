@@ -0,0 +1,189 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// OTLPExporter sends spans to an OpenTelemetry collector using OTLP over HTTP
+// with the JSON encoding, which every collector accepts
+type OTLPExporter struct {
+	Endpoint   string // e.g. http://localhost:4318/v1/traces
+	HTTPClient *http.Client
+}
+
+// NewOTLPExporter creates an exporter that posts spans to endpoint
+func NewOTLPExporter(endpoint string) *OTLPExporter {
+	return &OTLPExporter{
+		Endpoint:   endpoint,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// OTLP/JSON messages, trimmed to the fields bzzz produces
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              int            `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Events            []otlpEvent    `json:"events,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpEvent struct {
+		Name         string         `json:"name"`
+		TimeUnixNano string         `json:"timeUnixNano"`
+		Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"` // int64 is a string in OTLP/JSON
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+// ExportSpans posts a batch of finished spans to the collector
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	// The provider has a single resource, so one ResourceSpans grouped by scope is enough
+	resourceSpans := otlpResourceSpans{Resource: otlpResource{Attributes: otlpAttributes(spans[0].Resource().Attributes())}}
+	scopes := make(map[string]int)
+	for _, span := range spans {
+		scope := span.InstrumentationScope()
+		i, exists := scopes[scope.Name]
+		if !exists {
+			i = len(resourceSpans.ScopeSpans)
+			scopes[scope.Name] = i
+			resourceSpans.ScopeSpans = append(resourceSpans.ScopeSpans, otlpScopeSpans{
+				Scope: otlpScope{Name: scope.Name, Version: scope.Version},
+			})
+		}
+		resourceSpans.ScopeSpans[i].Spans = append(resourceSpans.ScopeSpans[i].Spans, otlpSpanFrom(span))
+	}
+
+	body, err := json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{resourceSpans}})
+	if err != nil {
+		return fmt.Errorf("failed to marshal spans: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("collector rejected spans with status %d: %s", resp.StatusCode, message)
+	}
+	return nil
+}
+
+// Shutdown has nothing to release; the provider flushes before calling it
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// otlpSpanFrom converts a finished span to its OTLP form
+func otlpSpanFrom(span sdktrace.ReadOnlySpan) otlpSpan {
+	converted := otlpSpan{
+		TraceID:           span.SpanContext().TraceID().String(),
+		SpanID:            span.SpanContext().SpanID().String(),
+		Name:              span.Name(),
+		Kind:              int(span.SpanKind()), // The API and OTLP number kinds alike
+		StartTimeUnixNano: strconv.FormatInt(span.StartTime().UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.EndTime().UnixNano(), 10),
+		Attributes:        otlpAttributes(span.Attributes()),
+	}
+	if span.Parent().IsValid() {
+		converted.ParentSpanID = span.Parent().SpanID().String()
+	}
+	for _, event := range span.Events() {
+		converted.Events = append(converted.Events, otlpEvent{
+			Name:         event.Name,
+			TimeUnixNano: strconv.FormatInt(event.Time.UnixNano(), 10),
+			Attributes:   otlpAttributes(event.Attributes),
+		})
+	}
+
+	// OTLP numbers status codes differently from the API
+	switch span.Status().Code {
+	case codes.Ok:
+		converted.Status.Code = 1
+	case codes.Error:
+		converted.Status = otlpStatus{Code: 2, Message: span.Status().Description}
+	}
+	return converted
+}
+
+// otlpAttributes converts attributes, flattening slices to their string form
+func otlpAttributes(attrs []attribute.KeyValue) []otlpKeyValue {
+	converted := make([]otlpKeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		var value otlpValue
+		switch attr.Value.Type() {
+		case attribute.BOOL:
+			b := attr.Value.AsBool()
+			value.BoolValue = &b
+		case attribute.INT64:
+			i := strconv.FormatInt(attr.Value.AsInt64(), 10)
+			value.IntValue = &i
+		case attribute.FLOAT64:
+			f := attr.Value.AsFloat64()
+			value.DoubleValue = &f
+		default:
+			s := attr.Value.Emit()
+			value.StringValue = &s
+		}
+		converted = append(converted, otlpKeyValue{Key: string(attr.Key), Value: value})
+	}
+	return converted
+}
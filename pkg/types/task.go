@@ -1,6 +1,7 @@
 package types
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/anthonyrawlins/bzzz/pkg/hive"
@@ -28,8 +29,115 @@ type EnhancedTask struct {
 	Deliverables []string
 	Context      map[string]interface{}
 
+	// RequiredCapabilities must all be among an agent's capabilities for it to claim the task.
+	RequiredCapabilities []string
+
 	// Hive-integration fields providing repository context.
 	ProjectID  int
 	GitURL     string
 	Repository hive.Repository
+
+	// ReclaimedFrom names the agent whose expired claim lease is being taken over.
+	ReclaimedFrom string
+
+	// BranchName is the task branch created when the task was claimed.
+	BranchName string
+
+	// GitHubToken authenticates git in the sandbox when the repository's owner
+	// has its own token; empty uses the agent's default token.
+	GitHubToken string
+
+	// RepoContext summarises the cloned repository (language, build system,
+	// layout) for the reasoning prompt.
+	RepoContext string
+
+	// HumanGuidance is a human's reply to an escalation, set when the task is resumed.
+	HumanGuidance string
+
+	// Model is the reasoning model driving the task, chosen when execution starts.
+	Model string
+
+	// SplitWhenOversized stops the run as soon as the task's changes exceed the
+	// agent's diff limits, so the task can be split instead of finished whole.
+	SplitWhenOversized bool
+
+	// ReasoningTimeout bounds each model call made for the task; 0 uses the reasoning default.
+	ReasoningTimeout time.Duration
+
+	// Dependencies are the tasks the issue body says must be closed before this one starts.
+	Dependencies []TaskDependency
+
+	// Checklist holds the `- [ ]` sub-tasks from the issue body, worked through in order.
+	Checklist []ChecklistItem
+
+	// OnChecklistItemDone, if set, is called when the agent finishes a checklist item.
+	OnChecklistItemDone func(item ChecklistItem) `json:"-"`
+
+	// Plan is the agent's numbered steps for the task, made before its first command.
+	Plan []string
+
+	// PlanStep is the index of the plan step being worked on.
+	PlanStep int
+
+	// OnPlanned, if set, is called whenever the agent makes or remakes its plan.
+	OnPlanned func(plan []string) `json:"-"`
+}
+
+// TaskDependency refers to another issue a task is blocked by.
+type TaskDependency struct {
+	Repository string // "owner/repo", or empty for the task's own repository
+	Number     int
+}
+
+// String formats the dependency as a GitHub issue reference.
+func (d TaskDependency) String() string {
+	return fmt.Sprintf("%s#%d", d.Repository, d.Number)
+}
+
+// ChecklistItem is one markdown checkbox from a task's issue body.
+type ChecklistItem struct {
+	Index int // Position among the issue's checklist items
+	Text  string
+	Done  bool
+}
+
+// RepositoryName returns the task's repository as owner/name.
+func (t *EnhancedTask) RepositoryName() string {
+	return fmt.Sprintf("%s/%s", t.Repository.Owner, t.Repository.Repository)
+}
+
+// NextChecklistItem returns the first unfinished checklist item, if any.
+func (t *EnhancedTask) NextChecklistItem() (*ChecklistItem, bool) {
+	for i := range t.Checklist {
+		if !t.Checklist[i].Done {
+			return &t.Checklist[i], true
+		}
+	}
+	return nil, false
+}
+
+// DiffStats summarises the size of a task's staged changes.
+type DiffStats struct {
+	FilesChanged int `json:"files_changed"`
+	LinesAdded   int `json:"lines_added"`
+	LinesRemoved int `json:"lines_removed"`
+}
+
+// Lines returns the total number of lines touched.
+func (d DiffStats) Lines() int {
+	return d.LinesAdded + d.LinesRemoved
+}
+
+// Merge states of a task branch against the latest base branch.
+const (
+	MergeClean      = "clean"      // Rebased onto the base branch without conflicts
+	MergeResolved   = "resolved"   // Conflicts with the base branch were resolved automatically
+	MergeConflicted = "conflicted" // Conflicts with the base branch need a human
+)
+
+// MergeStatus records how a task branch stands against the latest base branch.
+// An empty State means it couldn't be checked.
+type MergeStatus struct {
+	State     string   `json:"state,omitempty"`
+	Conflicts []string `json:"conflicts,omitempty"` // Files that conflicted
 }
@@ -0,0 +1,116 @@
+package pubsub
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Availability broadcasts go out every few seconds from every node, so the
+// capability lists they carry are kept short
+const (
+	maxAdvertisedItems  = 32 // Entries per list
+	maxAdvertisedLength = 64 // Bytes per entry
+)
+
+// PeerAvailability is what a peer last said about its workload and what it can do
+type PeerAvailability struct {
+	NodeID         string
+	Available      bool
+	Status         string // ready, working, busy or paused
+	CurrentTasks   int
+	MaxTasks       int
+	QueuedTasks    int // Tasks waiting for a sandbox slot on the peer's host
+	Capabilities   []string
+	Models         []string
+	Specialization string
+	UpdatedAt      time.Time
+}
+
+// PublishAvailability broadcasts the agent's workload along with the
+// capabilities, specialization and models it set for the capability exchange,
+// so a peer deciding whether to delegate can tell if the agent suits the work
+func (p *PubSub) PublishAvailability(availability map[string]interface{}) error {
+	p.capsMux.RLock()
+	local := p.localCaps
+	p.capsMux.RUnlock()
+
+	message := make(map[string]interface{}, len(availability)+3)
+	for key, value := range availability {
+		message[key] = value
+	}
+	message["capabilities"] = boundedList(stringsField(local, "capabilities"))
+	message["models"] = boundedList(stringsField(local, "models"))
+	message["specialization"] = bounded(stringField(local, "specialization"))
+	return p.PublishBzzzMessage(AvailabilityBcast, message)
+}
+
+// PeerAvailability returns the last availability heard from every peer
+func (p *PubSub) PeerAvailability() map[peer.ID]PeerAvailability {
+	p.capsMux.RLock()
+	defer p.capsMux.RUnlock()
+	peers := make(map[peer.ID]PeerAvailability, len(p.peerAvail))
+	for id, availability := range p.peerAvail {
+		peers[id] = availability
+	}
+	return peers
+}
+
+// AvailabilityOf returns the last availability one peer broadcast
+func (p *PubSub) AvailabilityOf(id peer.ID) (PeerAvailability, bool) {
+	p.capsMux.RLock()
+	defer p.capsMux.RUnlock()
+	availability, ok := p.peerAvail[id]
+	return availability, ok
+}
+
+// recordAvailability keeps a peer's availability broadcast
+func (p *PubSub) recordAvailability(msg Message, from peer.ID) {
+	availability := PeerAvailability{
+		NodeID:         stringField(msg.Data, "node_id"),
+		Status:         stringField(msg.Data, "status"),
+		CurrentTasks:   intField(msg.Data, "current_tasks"),
+		MaxTasks:       intField(msg.Data, "max_tasks"),
+		QueuedTasks:    intField(msg.Data, "queued_tasks"),
+		Capabilities:   boundedList(stringsField(msg.Data, "capabilities")),
+		Models:         boundedList(stringsField(msg.Data, "models")),
+		Specialization: bounded(stringField(msg.Data, "specialization")),
+		UpdatedAt:      msg.Timestamp,
+	}
+	availability.Available, _ = msg.Data["available_for_work"].(bool)
+
+	p.capsMux.Lock()
+	p.peerAvail[from] = availability
+	p.capsMux.Unlock()
+}
+
+// boundedList keeps the first maxAdvertisedItems entries, each bounded
+func boundedList(values []string) []string {
+	if len(values) > maxAdvertisedItems {
+		values = values[:maxAdvertisedItems]
+	}
+	result := make([]string, len(values))
+	for i, value := range values {
+		result[i] = bounded(value)
+	}
+	return result
+}
+
+// bounded cuts a value down to maxAdvertisedLength bytes
+func bounded(value string) string {
+	if len(value) > maxAdvertisedLength {
+		return value[:maxAdvertisedLength]
+	}
+	return value
+}
+
+// intField reads a number from decoded message data
+func intField(data map[string]interface{}, key string) int {
+	switch value := data[key].(type) {
+	case int:
+		return value
+	case float64:
+		return int(value)
+	}
+	return 0
+}
@@ -0,0 +1,209 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pkg/supervisor"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+// capabilityProtocol lets a peer ask another for its capabilities directly, so
+// a node joining costs one exchange with each neighbour rather than a
+// broadcast that every node answers to everyone
+const capabilityProtocol = protocol.ID("/bzzz/capabilities/1.0.0")
+
+// capabilityExchangeTimeout bounds one direct capability exchange
+const capabilityExchangeTimeout = 10 * time.Second
+
+// PeerCapabilities is what a peer last said it can do
+type PeerCapabilities struct {
+	NodeID         string
+	Capabilities   []string
+	Models         []string
+	Specialization string
+	Version        string
+	UpdatedAt      time.Time
+}
+
+// StartCapabilityExchange answers capability requests with caps and asks each
+// peer on the Bzzz topic for theirs, now and as they join, so the registry
+// fills without waiting for peers to rebroadcast.
+func (p *PubSub) StartCapabilityExchange(caps map[string]interface{}) error {
+	p.SetLocalCapabilities(caps)
+	p.host.SetStreamHandler(capabilityProtocol, p.serveCapabilities)
+
+	events, err := p.bzzzTopic.EventHandler()
+	if err != nil {
+		return fmt.Errorf("failed to watch Bzzz topic peers: %w", err)
+	}
+	supervisor.Go(p.ctx, "capability exchange", func() { p.watchCapabilityPeers(events) })
+	for _, id := range p.bzzzTopic.ListPeers() {
+		go p.learnCapabilities(id)
+	}
+	return nil
+}
+
+// SetLocalCapabilities changes the capabilities sent in reply to requests
+func (p *PubSub) SetLocalCapabilities(caps map[string]interface{}) {
+	p.capsMux.Lock()
+	p.localCaps = caps
+	p.capsMux.Unlock()
+}
+
+// FetchCapabilities asks one peer directly for its capabilities and records them
+func (p *PubSub) FetchCapabilities(ctx context.Context, id peer.ID) (PeerCapabilities, error) {
+	ctx, cancel := context.WithTimeout(ctx, capabilityExchangeTimeout)
+	defer cancel()
+
+	stream, err := p.host.NewStream(ctx, id, capabilityProtocol)
+	if err != nil {
+		return PeerCapabilities{}, fmt.Errorf("failed to open a stream to %s: %w", id.ShortString(), err)
+	}
+	defer stream.Close()
+	stream.SetReadDeadline(time.Now().Add(capabilityExchangeTimeout))
+
+	var msg Message
+	if err := json.NewDecoder(io.LimitReader(stream, int64(p.maxMessageSize))).Decode(&msg); err != nil {
+		stream.Reset()
+		return PeerCapabilities{}, fmt.Errorf("failed to read capabilities from %s: %w", id.ShortString(), err)
+	}
+	if msg.Type != CapabilityBcast {
+		return PeerCapabilities{}, fmt.Errorf("unexpected %s reply from %s", msg.Type, id.ShortString())
+	}
+	caps := capabilitiesFrom(msg)
+	p.capsMux.Lock()
+	p.peerCaps[id] = caps
+	p.capsMux.Unlock()
+	return caps, nil
+}
+
+// serveCapabilities answers a peer's direct capability request
+func (p *PubSub) serveCapabilities(stream network.Stream) {
+	defer stream.Close()
+
+	p.capsMux.RLock()
+	local := p.localCaps
+	p.capsMux.RUnlock()
+	if local == nil {
+		stream.Reset()
+		return
+	}
+
+	reply := make(map[string]interface{}, len(local)+1)
+	for key, value := range local {
+		reply[key] = value
+	}
+	reply["reason"] = "query_reply"
+	stream.SetWriteDeadline(time.Now().Add(capabilityExchangeTimeout))
+	if err := json.NewEncoder(stream).Encode(p.newMessage(CapabilityBcast, reply)); err != nil {
+		fmt.Printf("❌ Failed to send capabilities to %s: %v\n", stream.Conn().RemotePeer().ShortString(), err)
+		stream.Reset()
+	}
+}
+
+// learnCapabilities fetches a peer's capabilities, logging rather than returning failures
+func (p *PubSub) learnCapabilities(id peer.ID) {
+	if _, err := p.FetchCapabilities(p.ctx, id); err != nil && p.ctx.Err() == nil {
+		fmt.Printf("⚠️ Failed to learn capabilities of %s: %v\n", id.ShortString(), err)
+	}
+}
+
+// PeerCapabilities returns the capabilities of every peer heard from
+func (p *PubSub) PeerCapabilities() map[peer.ID]PeerCapabilities {
+	p.capsMux.RLock()
+	defer p.capsMux.RUnlock()
+	peers := make(map[peer.ID]PeerCapabilities, len(p.peerCaps))
+	for id, caps := range p.peerCaps {
+		peers[id] = caps
+	}
+	return peers
+}
+
+// CapabilitiesOf returns what one peer last said it can do
+func (p *PubSub) CapabilitiesOf(id peer.ID) (PeerCapabilities, bool) {
+	p.capsMux.RLock()
+	defer p.capsMux.RUnlock()
+	caps, ok := p.peerCaps[id]
+	return caps, ok
+}
+
+// watchCapabilityPeers asks peers that join for their capabilities and forgets peers that leave
+func (p *PubSub) watchCapabilityPeers(events *pubsub.TopicEventHandler) {
+	defer events.Cancel()
+
+	for {
+		event, err := events.NextPeerEvent(p.ctx)
+		if err != nil {
+			return
+		}
+		switch event.Type {
+		case pubsub.PeerJoin:
+			if _, known := p.CapabilitiesOf(event.Peer); !known {
+				go p.learnCapabilities(event.Peer)
+			}
+		case pubsub.PeerLeave:
+			p.capsMux.Lock()
+			delete(p.peerCaps, event.Peer)
+			delete(p.peerAvail, event.Peer)
+			p.capsMux.Unlock()
+			p.limiter.forget(event.Peer)
+		}
+	}
+}
+
+// observeCapabilities records capability and availability broadcasts. from must
+// be the signed author, since that's who the entry is recorded against.
+func (p *PubSub) observeCapabilities(msg Message, from peer.ID) {
+	switch msg.Type {
+	case CapabilityBcast:
+		caps := capabilitiesFrom(msg)
+		p.capsMux.Lock()
+		p.peerCaps[from] = caps
+		p.capsMux.Unlock()
+
+	case AvailabilityBcast:
+		p.recordAvailability(msg, from)
+	}
+}
+
+// capabilitiesFrom reads a capability broadcast
+func capabilitiesFrom(msg Message) PeerCapabilities {
+	return PeerCapabilities{
+		NodeID:         stringField(msg.Data, "node_id"),
+		Capabilities:   stringsField(msg.Data, "capabilities"),
+		Models:         stringsField(msg.Data, "models"),
+		Specialization: stringField(msg.Data, "specialization"),
+		Version:        stringField(msg.Data, "version"),
+		UpdatedAt:      msg.Timestamp,
+	}
+}
+
+// stringField reads a string from decoded message data
+func stringField(data map[string]interface{}, key string) string {
+	value, _ := data[key].(string)
+	return value
+}
+
+// stringsField reads a list of strings from decoded message data
+func stringsField(data map[string]interface{}, key string) []string {
+	switch values := data[key].(type) {
+	case []string:
+		return values
+	case []interface{}:
+		result := make([]string, 0, len(values))
+		for _, value := range values {
+			if s, ok := value.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
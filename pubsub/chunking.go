@@ -0,0 +1,237 @@
+package pubsub
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+// MessageChunk carries one piece of a message too large to publish in one go
+const MessageChunk MessageType = "message_chunk"
+
+const (
+	// DefaultMaxMessageSize stays well under gossipsub's 1 MiB limit, which also
+	// has to fit the signature and RPC framing
+	DefaultMaxMessageSize = 512 << 10
+
+	// MinMaxMessageSize leaves room for a chunk's envelope
+	MinMaxMessageSize = 4 << 10
+
+	// maxChunks bounds how large a chunked message can get, for sender and receiver alike
+	maxChunks = 64
+
+	// chunkEnvelopeSize is reserved in each chunk for the message around the payload
+	chunkEnvelopeSize = 1024
+
+	// chunkTimeout is how long a partly received message is kept waiting for the rest
+	chunkTimeout = time.Minute
+
+	// maxPartialPerPeer bounds how many chunked messages one sender can have
+	// partly received at a time
+	maxPartialPerPeer = 4
+)
+
+// maxPartialBytes bounds the chunk payloads buffered across every partly
+// received message; a variable so tests can lower it
+var maxPartialBytes = 32 << 20
+
+// ErrMessageTooLarge is returned when a message won't fit even after chunking
+var ErrMessageTooLarge = errors.New("message too large to publish")
+
+// SetMaxMessageSize sets the largest message published as-is; bigger ones are chunked
+func (p *PubSub) SetMaxMessageSize(size int) {
+	p.chunksMux.Lock()
+	defer p.chunksMux.Unlock()
+	p.maxMessageSize = size
+}
+
+// publish sends a message on a topic, splitting it into chunks if it is over the size limit
+func (p *PubSub) publish(topic *pubsub.Topic, msg Message) error {
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	chunks, err := p.chunkMessage(msgBytes)
+	if err != nil {
+		return fmt.Errorf("failed to publish %s message: %w", msg.Type, err)
+	}
+	for _, chunk := range chunks {
+		if err := topic.Publish(p.ctx, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chunkMessage returns msgBytes unchanged if it fits, otherwise the encoded chunks to publish in its place
+func (p *PubSub) chunkMessage(msgBytes []byte) ([][]byte, error) {
+	p.chunksMux.Lock()
+	maxSize := p.maxMessageSize
+	p.chunksMux.Unlock()
+
+	if len(msgBytes) <= maxSize {
+		return [][]byte{msgBytes}, nil
+	}
+
+	// Payloads are base64 encoded, which grows them by a third
+	payloadSize := (maxSize - chunkEnvelopeSize) / 4 * 3
+	total := (len(msgBytes) + payloadSize - 1) / payloadSize
+	if total > maxChunks {
+		return nil, fmt.Errorf("%w: %d bytes would need %d chunks of at most %d bytes (limit %d)",
+			ErrMessageTooLarge, len(msgBytes), total, maxSize, maxChunks)
+	}
+
+	chunkID := fmt.Sprintf("%s-%d", p.host.ID(), time.Now().UnixNano())
+	chunks := make([][]byte, 0, total)
+	for index := 0; index < total; index++ {
+		end := (index + 1) * payloadSize
+		if end > len(msgBytes) {
+			end = len(msgBytes)
+		}
+		chunk := p.newMessage(MessageChunk, map[string]interface{}{
+			"chunk_id": chunkID,
+			"index":    index,
+			"total":    total,
+			"payload":  base64.StdEncoding.EncodeToString(msgBytes[index*payloadSize : end]),
+		})
+		chunkBytes, err := json.Marshal(chunk)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal message chunk: %w", err)
+		}
+		chunks = append(chunks, chunkBytes)
+	}
+	return chunks, nil
+}
+
+// partialMessage collects the chunks of one message as they arrive
+type partialMessage struct {
+	sender   peer.ID
+	parts    [][]byte
+	received int
+	size     int // Payload bytes received so far
+	started  time.Time
+}
+
+// reassemble adds a chunk to its message and returns the message once every chunk
+// has arrived. Messages that aren't chunks are returned as they are. from must
+// be the signed author, so one peer can't interfere with another's chunks.
+func (p *PubSub) reassemble(msg Message, from peer.ID) (Message, bool) {
+	if msg.Type != MessageChunk {
+		return msg, true
+	}
+
+	chunkID, _ := msg.Data["chunk_id"].(string)
+	rawIndex, _ := msg.Data["index"].(float64)
+	rawTotal, _ := msg.Data["total"].(float64)
+	encoded, _ := msg.Data["payload"].(string)
+	payload, err := base64.StdEncoding.DecodeString(encoded)
+	if chunkID == "" || rawIndex != math.Trunc(rawIndex) || rawTotal != math.Trunc(rawTotal) ||
+		rawTotal < 1 || rawTotal > maxChunks || rawIndex < 0 || rawIndex >= rawTotal || err != nil {
+		fmt.Printf("❌ Dropping malformed message chunk from %s\n", from.ShortString())
+		return Message{}, false
+	}
+
+	parts, complete := p.addChunk(from.String()+"/"+chunkID, from, int(rawIndex), int(rawTotal), payload)
+	if !complete {
+		return Message{}, false
+	}
+
+	var whole []byte
+	for _, part := range parts {
+		whole = append(whole, part...)
+	}
+	var original Message
+	if err := json.Unmarshal(whole, &original); err != nil {
+		fmt.Printf("❌ Failed to unmarshal reassembled message from %s: %v\n", from.ShortString(), err)
+		return Message{}, false
+	}
+	return original, true
+}
+
+// addChunk stores one chunk of the message under key and, once every chunk has
+// arrived, returns them in order
+func (p *PubSub) addChunk(key string, from peer.ID, index, total int, payload []byte) ([][]byte, bool) {
+	p.chunksMux.Lock()
+	defer p.chunksMux.Unlock()
+
+	p.expireChunks(time.Now())
+	partial, exists := p.partialMessages[key]
+	if !exists {
+		if p.partialCount(from) >= maxPartialPerPeer {
+			fmt.Printf("❌ Dropping chunked message from %s: too many already arriving\n", from.ShortString())
+			return nil, false
+		}
+		partial = &partialMessage{sender: from, parts: make([][]byte, total), started: time.Now()}
+		p.partialMessages[key] = partial
+	}
+	if total != len(partial.parts) || index >= len(partial.parts) || partial.parts[index] != nil {
+		return nil, false
+	}
+	if p.partialBytes+len(payload) > maxPartialBytes {
+		p.dropPartial(key)
+		fmt.Printf("❌ Dropping chunked message from %s: too much chunked data buffered\n", from.ShortString())
+		return nil, false
+	}
+	partial.parts[index] = payload
+	partial.received++
+	partial.size += len(payload)
+	p.partialBytes += len(payload)
+	if partial.received < len(partial.parts) {
+		return nil, false
+	}
+	p.dropPartial(key)
+	return partial.parts, true
+}
+
+// partialCount returns how many messages from sender are partly received;
+// callers must hold chunksMux
+func (p *PubSub) partialCount(sender peer.ID) int {
+	count := 0
+	for _, partial := range p.partialMessages {
+		if partial.sender == sender {
+			count++
+		}
+	}
+	return count
+}
+
+// dropPartial forgets a partly received message; callers must hold chunksMux
+func (p *PubSub) dropPartial(key string) {
+	if partial, ok := p.partialMessages[key]; ok {
+		p.partialBytes -= partial.size
+		delete(p.partialMessages, key)
+	}
+}
+
+// expireChunks forgets messages whose remaining chunks never turned up;
+// callers must hold chunksMux
+func (p *PubSub) expireChunks(now time.Time) {
+	for key, partial := range p.partialMessages {
+		if now.Sub(partial.started) > chunkTimeout {
+			p.dropPartial(key)
+		}
+	}
+}
+
+// expireChunksLoop clears out abandoned chunks even when no new ones arrive
+func (p *PubSub) expireChunksLoop() {
+	ticker := time.NewTicker(chunkTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case now := <-ticker.C:
+			p.chunksMux.Lock()
+			p.expireChunks(now)
+			p.chunksMux.Unlock()
+		}
+	}
+}
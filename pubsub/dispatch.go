@@ -0,0 +1,78 @@
+package pubsub
+
+import (
+	"sync/atomic"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultDynamicQueueSize is how many messages a dynamic topic buffers while its handler is busy
+const DefaultDynamicQueueSize = 64
+
+var dynamicMessagesDropped = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "bzzz_pubsub_dynamic_messages_dropped_total",
+	Help: "Dynamic topic messages dropped because the handler fell behind.",
+})
+
+func init() {
+	prometheus.MustRegister(dynamicMessagesDropped)
+}
+
+// queuedMessage is a decoded message waiting for the handler
+type queuedMessage struct {
+	msg  Message
+	from peer.ID
+}
+
+// dispatchQueue decouples reading a subscription from handling its messages.
+// When the handler falls behind the oldest queued message is dropped, so a
+// slow handler can't stall the subscription or grow memory without bound.
+type dispatchQueue struct {
+	queue   chan queuedMessage
+	dropped *uint64 // Shared drop counter for the owning PubSub
+}
+
+// newDispatchQueue creates a queue holding up to size messages
+func newDispatchQueue(size int, dropped *uint64) *dispatchQueue {
+	if size <= 0 {
+		size = DefaultDynamicQueueSize
+	}
+	return &dispatchQueue{
+		queue:   make(chan queuedMessage, size),
+		dropped: dropped,
+	}
+}
+
+// push queues a message, dropping the oldest one if the queue is full.
+// Only the subscription reader calls push, so there is a single producer.
+func (dq *dispatchQueue) push(msg Message, from peer.ID) {
+	queued := queuedMessage{msg: msg, from: from}
+	for {
+		select {
+		case dq.queue <- queued:
+			return
+		default:
+		}
+
+		select {
+		case <-dq.queue:
+			atomic.AddUint64(dq.dropped, 1)
+			dynamicMessagesDropped.Inc()
+		default:
+			// The handler took one in the meantime; retry
+		}
+	}
+}
+
+// close stops the queue once the remaining messages have been handled
+func (dq *dispatchQueue) close() {
+	close(dq.queue)
+}
+
+// run hands queued messages to handle, in order, until the queue is closed
+func (dq *dispatchQueue) run(handle func(msg Message, from peer.ID)) {
+	for queued := range dq.queue {
+		handle(queued.msg, queued.from)
+	}
+}
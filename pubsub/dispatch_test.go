@@ -0,0 +1,80 @@
+package pubsub
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestDispatchQueueDropsOldestWhenHandlerIsSlow(t *testing.T) {
+	const size = 4
+	var dropped uint64
+	dq := newDispatchQueue(size, &dropped)
+
+	// The handler blocks on its first message until released
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var handled []uint64
+	done := make(chan struct{})
+	go func() {
+		dq.run(func(msg Message, from peer.ID) {
+			if msg.Sequence == 0 {
+				close(started)
+				<-release
+			}
+			mu.Lock()
+			handled = append(handled, msg.Sequence)
+			mu.Unlock()
+		})
+		close(done)
+	}()
+
+	dq.push(Message{Sequence: 0}, "")
+	<-started
+
+	// A burst larger than the buffer while the handler is stuck
+	for seq := uint64(1); seq <= size+3; seq++ {
+		dq.push(Message{Sequence: seq}, "")
+	}
+	if dropped != 3 {
+		t.Fatalf("expected 3 messages dropped, got %d", dropped)
+	}
+
+	close(release)
+	dq.close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("dispatch queue did not drain after close")
+	}
+
+	// Only the oldest queued messages were lost; the newest buffer-full survived in order
+	want := []uint64{0, 4, 5, 6, 7}
+	if len(handled) != len(want) {
+		t.Fatalf("expected %v handled, got %v", want, handled)
+	}
+	for i := range want {
+		if handled[i] != want[i] {
+			t.Fatalf("expected %v handled, got %v", want, handled)
+		}
+	}
+}
+
+func TestDispatchQueueKeepsEverythingWithinBuffer(t *testing.T) {
+	var dropped uint64
+	dq := newDispatchQueue(8, &dropped)
+
+	for seq := uint64(1); seq <= 8; seq++ {
+		dq.push(Message{Sequence: seq}, "")
+	}
+	dq.close()
+
+	count := 0
+	dq.run(func(msg Message, from peer.ID) { count++ })
+	if count != 8 || dropped != 0 {
+		t.Errorf("expected 8 handled and none dropped, got %d handled and %d dropped", count, dropped)
+	}
+}
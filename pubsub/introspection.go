@@ -0,0 +1,205 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// introspectionProtocol carries introspection replies straight back to the
+// requester, so an agent's state is never broadcast to the mesh
+const introspectionProtocol = protocol.ID("/bzzz/introspection/1.0.0")
+
+// introspectionReplyTimeout bounds how long answering a requester may take
+const introspectionReplyTimeout = 10 * time.Second
+
+// ErrIntrospectionDenied is returned by Introspect when the peer answers that we
+// aren't on its introspection allowlist
+var ErrIntrospectionDenied = errors.New("peer does not allow us to introspect it")
+
+// Introspection is an agent's description of its internal state, for debugging
+// the mesh remotely
+type Introspection struct {
+	NodeID         string                 `json:"node_id"`
+	AgentID        string                 `json:"agent_id"`
+	Version        string                 `json:"version"`
+	Capabilities   []string               `json:"capabilities"`
+	Models         []string               `json:"models"`
+	EffectiveModel string                 `json:"effective_model,omitempty"` // The default model, or a lighter one while the host is under load
+	Specialization string                 `json:"specialization,omitempty"`
+	ActiveTasks    int                    `json:"active_tasks"`
+	Tasks          []string               `json:"tasks,omitempty"` // "projectID:taskNumber title" for each active task
+	MaxTasks       int                    `json:"max_tasks"`
+	Paused         bool                   `json:"paused"`
+	Config         map[string]interface{} `json:"config,omitempty"` // Non-secret settings worth checking when debugging
+}
+
+// StartIntrospection answers IntrospectionRequests addressed to this node with
+// describe's result. Only the allowed peers get an answer; with none allowed,
+// every request is refused.
+func (p *PubSub) StartIntrospection(describe func() Introspection, allowed []peer.ID) {
+	allow := make(map[peer.ID]bool, len(allowed))
+	for _, id := range allowed {
+		allow[id] = true
+	}
+	p.introspectMux.Lock()
+	p.describe = describe
+	p.introspectAllowed = allow
+	p.introspectMux.Unlock()
+}
+
+// LocalIntrospection describes this node, as a peer asking would see it
+func (p *PubSub) LocalIntrospection() (Introspection, bool) {
+	p.introspectMux.Lock()
+	describe := p.describe
+	p.introspectMux.Unlock()
+	if describe == nil {
+		return Introspection{}, false
+	}
+	return describe(), true
+}
+
+// Introspect asks a peer to describe its internal state and waits for the reply.
+// The request goes out over the mesh; the reply comes back on a direct stream,
+// so the peer must be able to dial us.
+func (p *PubSub) Introspect(ctx context.Context, target peer.ID) (Introspection, error) {
+	msg := p.newMessage(IntrospectionRequest, map[string]interface{}{"target": target.String()})
+	requestID := msg.From + "/" + strconv.FormatUint(msg.Sequence, 10)
+	msg.Data["request_id"] = requestID
+
+	reply := make(chan introspectionReply, 1)
+	p.introspectMux.Lock()
+	if p.introspections == nil {
+		p.introspections = make(map[string]pendingIntrospection)
+	}
+	p.introspections[requestID] = pendingIntrospection{target: target, reply: reply}
+	p.introspectMux.Unlock()
+	defer func() {
+		p.introspectMux.Lock()
+		delete(p.introspections, requestID)
+		p.introspectMux.Unlock()
+	}()
+
+	if err := p.publish(p.bzzzTopic, msg); err != nil {
+		return Introspection{}, fmt.Errorf("failed to send introspection request: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return Introspection{}, fmt.Errorf("no introspection reply from %s: %w", target.ShortString(), ctx.Err())
+	case answer := <-reply:
+		return answer.introspection, answer.err
+	}
+}
+
+// introspectionReply is a peer's answer to one of our requests
+type introspectionReply struct {
+	introspection Introspection
+	err           error
+}
+
+// pendingIntrospection is one of our requests awaiting its reply
+type pendingIntrospection struct {
+	target peer.ID // Only this peer's reply is accepted
+	reply  chan introspectionReply
+}
+
+// observeIntrospection answers requests addressed to us. from must be the
+// message's signed author, not the neighbour that relayed it, since it's what
+// the allowlist is checked against and where the reply is sent.
+func (p *PubSub) observeIntrospection(msg Message, from peer.ID) {
+	if msg.Type != IntrospectionRequest || stringField(msg.Data, "target") != p.ID().String() {
+		return
+	}
+	p.introspectMux.Lock()
+	describe, allowed := p.describe, p.introspectAllowed[from]
+	p.introspectMux.Unlock()
+	if describe == nil {
+		return // Introspection not started
+	}
+
+	reply := map[string]interface{}{
+		"in_reply_to":  stringField(msg.Data, "request_id"),
+		"requested_by": from.String(),
+	}
+	if allowed {
+		reply["introspection"] = describe()
+	} else {
+		fmt.Printf("🚫 Refusing introspection request from %s: not on the allowlist\n", from.ShortString())
+		reply["denied"] = true
+	}
+	if err := p.sendIntrospectionReply(from, p.newMessage(IntrospectionResponse, reply)); err != nil {
+		fmt.Printf("❌ Failed to answer introspection request from %s: %v\n", from.ShortString(), err)
+	}
+}
+
+// sendIntrospectionReply writes reply to the requester over a direct stream
+func (p *PubSub) sendIntrospectionReply(requester peer.ID, reply Message) error {
+	ctx, cancel := context.WithTimeout(p.ctx, introspectionReplyTimeout)
+	defer cancel()
+
+	stream, err := p.host.NewStream(ctx, requester, introspectionProtocol)
+	if err != nil {
+		return fmt.Errorf("failed to open a stream to %s: %w", requester.ShortString(), err)
+	}
+	defer stream.Close()
+	stream.SetWriteDeadline(time.Now().Add(introspectionReplyTimeout))
+	if err := json.NewEncoder(stream).Encode(reply); err != nil {
+		stream.Reset()
+		return fmt.Errorf("failed to send reply: %w", err)
+	}
+	return nil
+}
+
+// receiveIntrospectionReply hands a reply arriving on a direct stream to
+// whoever is waiting on it, if it comes from the peer that was asked
+func (p *PubSub) receiveIntrospectionReply(stream network.Stream) {
+	defer stream.Close()
+	from := stream.Conn().RemotePeer()
+
+	stream.SetReadDeadline(time.Now().Add(introspectionReplyTimeout))
+	var msg Message
+	if err := json.NewDecoder(io.LimitReader(stream, int64(p.maxMessageSize))).Decode(&msg); err != nil {
+		fmt.Printf("❌ Failed to read introspection reply from %s: %v\n", from.ShortString(), err)
+		stream.Reset()
+		return
+	}
+	if msg.Type != IntrospectionResponse || stringField(msg.Data, "requested_by") != p.ID().String() {
+		return
+	}
+
+	p.introspectMux.Lock()
+	pending, ok := p.introspections[stringField(msg.Data, "in_reply_to")]
+	p.introspectMux.Unlock()
+	if !ok || pending.target != from {
+		return // Gave up waiting, or not ours
+	}
+
+	var answer introspectionReply
+	if denied, _ := msg.Data["denied"].(bool); denied {
+		answer.err = ErrIntrospectionDenied
+	} else if err := decodeField(msg.Data, "introspection", &answer.introspection); err != nil {
+		answer.err = fmt.Errorf("failed to decode introspection from %s: %w", from.ShortString(), err)
+	}
+	select {
+	case pending.reply <- answer:
+	default:
+	}
+}
+
+// decodeField converts a structured field of decoded message data into value
+func decodeField(data map[string]interface{}, key string, value interface{}) error {
+	encoded, err := json.Marshal(data[key])
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, value)
+}
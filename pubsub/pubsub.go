@@ -4,9 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/anthonyrawlins/bzzz/pkg/supervisor"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
@@ -29,16 +33,52 @@ type PubSub struct {
 	
 	// Dynamic topic management
 	dynamicTopics    map[string]*pubsub.Topic
+	dynamicRefs      map[string]int // Joins not yet matched by a leave, guarded by dynamicTopicsMux
 	dynamicTopicsMux sync.RWMutex
 	dynamicSubs      map[string]*pubsub.Subscription
 	dynamicSubsMux   sync.RWMutex
 
+	// Extra topics joined from configuration for the life of the PubSub
+	extraTopics    map[string]*pubsub.Topic
+	extraSubs      map[string]*pubsub.Subscription
+	extraTopicsMux sync.RWMutex
+
+	// Outgoing message sequence counter
+	sequence uint64
+
+	// Dynamic topic flow control
+	dynamicQueueSize int
+	dynamicDropped   uint64 // Messages dropped because a handler fell behind
+
+	// Oversized message chunking
+	maxMessageSize  int
+	partialMessages map[string]*partialMessage // Chunked messages being reassembled, keyed by sender and chunk ID
+	partialBytes    int                        // Payload bytes held in partialMessages
+	chunksMux       sync.Mutex
+
+	// Capability exchange
+	localCaps map[string]interface{}       // Sent in reply to capability queries
+	peerCaps  map[peer.ID]PeerCapabilities // Latest capabilities heard from each peer
+	peerAvail map[peer.ID]PeerAvailability // Latest availability heard from each peer
+	capsMux   sync.RWMutex
+
+	// Remote introspection
+	describe          func() Introspection            // Answers introspection requests; nil ignores them
+	introspectAllowed map[peer.ID]bool                // Peers allowed to introspect this node
+	introspections    map[string]pendingIntrospection // Our requests awaiting a reply, by request ID
+	introspectMux     sync.Mutex
+
+	// Per-peer flood protection
+	limiter *peerRateLimiter
+
 	// Configuration
 	bzzzTopicName     string
 	antennaeTopicName string
 
-	// External message handler for Antennae messages
-	AntennaeMessageHandler func(msg Message, from peer.ID)
+	// External message handlers; every one registered sees every message
+	antennaeHandlers []func(msg Message, from peer.ID)
+	bzzzHandlers     []func(msg Message, from peer.ID)
+	handlersMux      sync.RWMutex
 }
 
 // MessageType represents different types of messages
@@ -48,10 +88,17 @@ const (
 	// Bzzz coordination messages
 	TaskAnnouncement MessageType = "task_announcement"
 	TaskClaim        MessageType = "task_claim"
+	ClaimIntent      MessageType = "claim_intent" // Announced before claiming to arbitrate races
 	TaskProgress     MessageType = "task_progress"
 	TaskComplete     MessageType = "task_complete"
-	CapabilityBcast  MessageType = "capability_broadcast"   // Only broadcast when capabilities change
+	CapabilityBcast  MessageType = "capability_broadcast"   // Only broadcast when capabilities change; also the reply to a direct capability request
 	AvailabilityBcast MessageType = "availability_broadcast" // Regular availability status
+	TelemetryReport  MessageType = "telemetry_report"        // Periodic per-agent activity rollup, sent on TelemetryTopic
+	TaskCancel       MessageType = "task_cancel"             // Asks whichever agent is running a task to stop it
+	AgentPause       MessageType = "agent_pause"             // Asks an agent to stop claiming new tasks
+	AgentResume      MessageType = "agent_resume"            // Asks a paused agent to claim tasks again
+	IntrospectionRequest  MessageType = "introspection_request"  // Asks one peer to describe its internal state
+	IntrospectionResponse MessageType = "introspection_response" // A peer's description of itself, or a refusal; sent over a direct stream
 	
 	// Antennae meta-discussion messages
 	MetaDiscussion       MessageType = "meta_discussion"        // Generic type for all discussion
@@ -63,6 +110,26 @@ const (
 	EscalationTrigger    MessageType = "escalation_trigger"     // Human escalation needed
 )
 
+// TelemetryTopic carries telemetry reports so dashboards don't have to scrape every node
+const TelemetryTopic = "bzzz/telemetry/v1"
+
+// taskTopicPrefix starts the name of every per-issue dynamic topic
+const taskTopicPrefix = "bzzz/meta/issue/"
+
+// TaskTopic names the dynamic topic for discussing one issue
+func TaskTopic(issueNumber int) string {
+	return taskTopicPrefix + strconv.Itoa(issueNumber)
+}
+
+// TaskNumberFromTopic returns the issue a TaskTopic is for
+func TaskNumberFromTopic(topicName string) (int, bool) {
+	if !strings.HasPrefix(topicName, taskTopicPrefix) {
+		return 0, false
+	}
+	number, err := strconv.Atoi(strings.TrimPrefix(topicName, taskTopicPrefix))
+	return number, err == nil
+}
+
 // Message represents a Bzzz/Antennae message
 type Message struct {
 	Type      MessageType            `json:"type"`
@@ -70,6 +137,8 @@ type Message struct {
 	Timestamp time.Time              `json:"timestamp"`
 	Data      map[string]interface{} `json:"data"`
 	HopCount  int                    `json:"hop_count,omitempty"` // For Antennae hop limiting
+	Sequence  uint64                 `json:"seq,omitempty"`       // Per-sender logical clock for ordering
+	Topic     string                 `json:"-"`                   // Topic the message arrived on, set on receipt
 }
 
 // NewPubSub creates a new PubSub instance for Bzzz coordination and Antennae meta-discussion
@@ -82,6 +151,7 @@ func NewPubSub(ctx context.Context, h host.Host, bzzzTopic, antennaeTopic string
 	}
 
 	pubsubCtx, cancel := context.WithCancel(ctx)
+	limiter := newPeerRateLimiter(DefaultMessageRateLimit, DefaultMessageBurst)
 
 	// Create gossipsub instance with message validation
 	ps, err := pubsub.NewGossipSub(pubsubCtx, h,
@@ -89,6 +159,7 @@ func NewPubSub(ctx context.Context, h host.Host, bzzzTopic, antennaeTopic string
 		pubsub.WithStrictSignatureVerification(true),
 		pubsub.WithValidateQueueSize(256),
 		pubsub.WithValidateThrottle(1024),
+		pubsub.WithDefaultValidator(limiter.validate, pubsub.WithValidatorInline(true)),
 	)
 	if err != nil {
 		cancel()
@@ -103,7 +174,16 @@ func NewPubSub(ctx context.Context, h host.Host, bzzzTopic, antennaeTopic string
 		bzzzTopicName:     bzzzTopic,
 		antennaeTopicName: antennaeTopic,
 		dynamicTopics:     make(map[string]*pubsub.Topic),
+		dynamicRefs:       make(map[string]int),
 		dynamicSubs:       make(map[string]*pubsub.Subscription),
+		extraTopics:       make(map[string]*pubsub.Topic),
+		extraSubs:         make(map[string]*pubsub.Subscription),
+		dynamicQueueSize:  DefaultDynamicQueueSize,
+		maxMessageSize:    DefaultMaxMessageSize,
+		partialMessages:   make(map[string]*partialMessage),
+		peerCaps:          make(map[peer.ID]PeerCapabilities),
+		peerAvail:         make(map[peer.ID]PeerAvailability),
+		limiter:           limiter,
 	}
 
 	// Join static topics
@@ -112,17 +192,106 @@ func NewPubSub(ctx context.Context, h host.Host, bzzzTopic, antennaeTopic string
 		return nil, err
 	}
 
+	h.SetStreamHandler(introspectionProtocol, p.receiveIntrospectionReply)
+
 	// Start message handlers
-	go p.handleBzzzMessages()
-	go p.handleAntennaeMessages()
+	supervisor.Go(p.ctx, "bzzz messages", p.handleBzzzMessages)
+	supervisor.Go(p.ctx, "antennae messages", p.handleAntennaeMessages)
+	supervisor.Go(p.ctx, "chunk expiry", p.expireChunksLoop)
 
 	fmt.Printf("📡 PubSub initialized - Bzzz: %s, Antennae: %s\n", bzzzTopic, antennaeTopic)
 	return p, nil
 }
 
-// SetAntennaeMessageHandler sets the handler for incoming Antennae messages.
-func (p *PubSub) SetAntennaeMessageHandler(handler func(msg Message, from peer.ID)) {
-	p.AntennaeMessageHandler = handler
+// ID returns the peer ID messages are published from
+func (p *PubSub) ID() peer.ID {
+	return p.host.ID()
+}
+
+// AntennaePeers returns the peers we know to be subscribed to the Antennae topic
+func (p *PubSub) AntennaePeers() []peer.ID {
+	return p.antennaeTopic.ListPeers()
+}
+
+// WatchAntennaePeers calls onLeave whenever a peer leaves the Antennae topic,
+// e.g. because its node shut down or dropped off the network
+func (p *PubSub) WatchAntennaePeers(onLeave func(peer.ID)) error {
+	events, err := p.antennaeTopic.EventHandler()
+	if err != nil {
+		return fmt.Errorf("failed to watch Antennae topic peers: %w", err)
+	}
+	go func() {
+		defer events.Cancel()
+		for {
+			event, err := events.NextPeerEvent(p.ctx)
+			if err != nil {
+				return
+			}
+			if event.Type == pubsub.PeerLeave {
+				onLeave(event.Peer)
+			}
+		}
+	}()
+	return nil
+}
+
+// AddAntennaeMessageHandler registers a handler for incoming Antennae messages,
+// alongside any registered before it.
+func (p *PubSub) AddAntennaeMessageHandler(handler func(msg Message, from peer.ID)) {
+	p.handlersMux.Lock()
+	defer p.handlersMux.Unlock()
+	p.antennaeHandlers = append(p.antennaeHandlers, handler)
+}
+
+// AddBzzzMessageHandler registers a handler for incoming Bzzz coordination
+// messages, alongside any registered before it.
+func (p *PubSub) AddBzzzMessageHandler(handler func(msg Message, from peer.ID)) {
+	p.handlersMux.Lock()
+	defer p.handlersMux.Unlock()
+	p.bzzzHandlers = append(p.bzzzHandlers, handler)
+}
+
+// deliverAntennaeMessage hands an Antennae message to every registered handler,
+// or to the default handler when there are none
+func (p *PubSub) deliverAntennaeMessage(msg Message, from peer.ID) {
+	p.handlersMux.RLock()
+	handlers := p.antennaeHandlers
+	p.handlersMux.RUnlock()
+
+	if len(handlers) == 0 {
+		p.processAntennaeMessage(msg, from)
+	}
+	for _, handler := range handlers {
+		handler(msg, from)
+	}
+}
+
+// deliverBzzzMessage hands a Bzzz message to every registered handler, or to
+// the default handler when there are none
+func (p *PubSub) deliverBzzzMessage(msg Message, from peer.ID) {
+	p.handlersMux.RLock()
+	handlers := p.bzzzHandlers
+	p.handlersMux.RUnlock()
+
+	if len(handlers) == 0 {
+		p.processBzzzMessage(msg, from)
+	}
+	for _, handler := range handlers {
+		handler(msg, from)
+	}
+}
+
+// SetDynamicQueueSize sets how many messages each dynamic topic buffers for a busy
+// handler before dropping the oldest. It applies to topics joined afterwards.
+func (p *PubSub) SetDynamicQueueSize(size int) {
+	p.dynamicTopicsMux.Lock()
+	defer p.dynamicTopicsMux.Unlock()
+	p.dynamicQueueSize = size
+}
+
+// DroppedDynamicMessages returns how many dynamic topic messages were dropped because a handler fell behind
+func (p *PubSub) DroppedDynamicMessages() uint64 {
+	return atomic.LoadUint64(&p.dynamicDropped)
 }
 
 // joinStaticTopics joins the main Bzzz and Antennae topics
@@ -156,7 +325,8 @@ func (p *PubSub) joinStaticTopics() error {
 	return nil
 }
 
-// JoinDynamicTopic joins a new topic for a specific task
+// JoinDynamicTopic joins a topic for a specific task. Joins are reference counted,
+// so each one must be matched by a LeaveDynamicTopic.
 func (p *PubSub) JoinDynamicTopic(topicName string) error {
 	p.dynamicTopicsMux.Lock()
 	defer p.dynamicTopicsMux.Unlock()
@@ -164,7 +334,8 @@ func (p *PubSub) JoinDynamicTopic(topicName string) error {
 	defer p.dynamicSubsMux.Unlock()
 
 	if _, exists := p.dynamicTopics[topicName]; exists {
-		return nil // Already joined
+		p.dynamicRefs[topicName]++ // Already joined; share it
+		return nil
 	}
 
 	topic, err := p.ps.Join(topicName)
@@ -180,21 +351,36 @@ func (p *PubSub) JoinDynamicTopic(topicName string) error {
 
 	p.dynamicTopics[topicName] = topic
 	p.dynamicSubs[topicName] = sub
+	p.dynamicRefs[topicName] = 1
 
-	// Start a handler for this new subscription
-	go p.handleDynamicMessages(sub)
+	// Read the subscription and dispatch to the handler separately so a slow
+	// handler can't block the subscription
+	queue := newDispatchQueue(p.dynamicQueueSize, &p.dynamicDropped)
+	supervisor.Go(p.ctx, "dynamic topic dispatch", func() { queue.run(p.dispatchDynamicMessage) })
+	supervisor.Go(p.ctx, "dynamic topic", func() { p.handleDynamicMessages(sub, queue) })
 
 	fmt.Printf("✅ Joined dynamic topic: %s\n", topicName)
 	return nil
 }
 
-// LeaveDynamicTopic leaves a specific task topic
-func (p *PubSub) LeaveDynamicTopic(topicName string) {
+// LeaveDynamicTopic releases one join of a task topic. The topic is only left
+// once every caller that joined it has left.
+func (p *PubSub) LeaveDynamicTopic(topicName string) error {
 	p.dynamicTopicsMux.Lock()
 	defer p.dynamicTopicsMux.Unlock()
 	p.dynamicSubsMux.Lock()
 	defer p.dynamicSubsMux.Unlock()
 
+	refs, joined := p.dynamicRefs[topicName]
+	if !joined {
+		return fmt.Errorf("not joined to dynamic topic: %s", topicName)
+	}
+	if refs > 1 {
+		p.dynamicRefs[topicName] = refs - 1
+		return nil
+	}
+	delete(p.dynamicRefs, topicName)
+
 	if sub, exists := p.dynamicSubs[topicName]; exists {
 		sub.Cancel()
 		delete(p.dynamicSubs, topicName)
@@ -206,6 +392,18 @@ func (p *PubSub) LeaveDynamicTopic(topicName string) {
 	}
 
 	fmt.Printf("🗑️ Left dynamic topic: %s\n", topicName)
+	return nil
+}
+
+// newMessage builds an outgoing message stamped with our next sequence number
+func (p *PubSub) newMessage(msgType MessageType, data map[string]interface{}) Message {
+	return Message{
+		Type:      msgType,
+		From:      p.host.ID().String(),
+		Timestamp: time.Now(),
+		Data:      data,
+		Sequence:  atomic.AddUint64(&p.sequence, 1),
+	}
 }
 
 // PublishToDynamicTopic publishes a message to a specific dynamic topic
@@ -218,53 +416,17 @@ func (p *PubSub) PublishToDynamicTopic(topicName string, msgType MessageType, da
 		return fmt.Errorf("not subscribed to dynamic topic: %s", topicName)
 	}
 
-	msg := Message{
-		Type:      msgType,
-		From:      p.host.ID().String(),
-		Timestamp: time.Now(),
-		Data:      data,
-	}
-
-	msgBytes, err := json.Marshal(msg)
-	if err != nil {
-		return fmt.Errorf("failed to marshal message for dynamic topic: %w", err)
-	}
-
-	return topic.Publish(p.ctx, msgBytes)
+	return p.publish(topic, p.newMessage(msgType, data))
 }
 
 // PublishBzzzMessage publishes a message to the Bzzz coordination topic
 func (p *PubSub) PublishBzzzMessage(msgType MessageType, data map[string]interface{}) error {
-	msg := Message{
-		Type:      msgType,
-		From:      p.host.ID().String(),
-		Timestamp: time.Now(),
-		Data:      data,
-	}
-
-	msgBytes, err := json.Marshal(msg)
-	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
-	}
-
-	return p.bzzzTopic.Publish(p.ctx, msgBytes)
+	return p.publish(p.bzzzTopic, p.newMessage(msgType, data))
 }
 
 // PublishAntennaeMessage publishes a message to the Antennae meta-discussion topic
 func (p *PubSub) PublishAntennaeMessage(msgType MessageType, data map[string]interface{}) error {
-	msg := Message{
-		Type:      msgType,
-		From:      p.host.ID().String(),
-		Timestamp: time.Now(),
-		Data:      data,
-	}
-
-	msgBytes, err := json.Marshal(msg)
-	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
-	}
-
-	return p.antennaeTopic.Publish(p.ctx, msgBytes)
+	return p.publish(p.antennaeTopic, p.newMessage(msgType, data))
 }
 
 // handleBzzzMessages processes incoming Bzzz coordination messages
@@ -279,17 +441,21 @@ func (p *PubSub) handleBzzzMessages() {
 			continue
 		}
 
-		if msg.ReceivedFrom == p.host.ID() {
+		// Signatures are verified, so GetFrom is who wrote the message;
+		// ReceivedFrom is only the neighbour that relayed it
+		author := msg.GetFrom()
+		if author == p.host.ID() {
 			continue
 		}
 
-		var bzzzMsg Message
-		if err := json.Unmarshal(msg.Data, &bzzzMsg); err != nil {
-			fmt.Printf("❌ Failed to unmarshal Bzzz message: %v\n", err)
+		bzzzMsg, ok := p.decodeMessage(p.bzzzTopicName, msg.Data, author)
+		if !ok {
 			continue
 		}
+		p.observeCapabilities(bzzzMsg, author)
+		p.observeIntrospection(bzzzMsg, author)
 
-		p.processBzzzMessage(bzzzMsg, msg.ReceivedFrom)
+		p.deliverBzzzMessage(bzzzMsg, author)
 	}
 }
 
@@ -305,26 +471,24 @@ func (p *PubSub) handleAntennaeMessages() {
 			continue
 		}
 
-		if msg.ReceivedFrom == p.host.ID() {
+		author := msg.GetFrom()
+		if author == p.host.ID() {
 			continue
 		}
 
-		var antennaeMsg Message
-		if err := json.Unmarshal(msg.Data, &antennaeMsg); err != nil {
-			fmt.Printf("❌ Failed to unmarshal Antennae message: %v\n", err)
+		antennaeMsg, ok := p.decodeMessage(p.antennaeTopicName, msg.Data, author)
+		if !ok {
 			continue
 		}
 
-		if p.AntennaeMessageHandler != nil {
-			p.AntennaeMessageHandler(antennaeMsg, msg.ReceivedFrom)
-		} else {
-			p.processAntennaeMessage(antennaeMsg, msg.ReceivedFrom)
-		}
+		p.deliverAntennaeMessage(antennaeMsg, author)
 	}
 }
 
-// handleDynamicMessages processes messages from a dynamic topic subscription
-func (p *PubSub) handleDynamicMessages(sub *pubsub.Subscription) {
+// handleDynamicMessages reads a dynamic topic subscription into its dispatch queue
+func (p *PubSub) handleDynamicMessages(sub *pubsub.Subscription, queue *dispatchQueue) {
+	defer queue.close()
+
 	for {
 		msg, err := sub.Next(p.ctx)
 		if err != nil {
@@ -335,20 +499,44 @@ func (p *PubSub) handleDynamicMessages(sub *pubsub.Subscription) {
 			continue
 		}
 
-		if msg.ReceivedFrom == p.host.ID() {
+		author := msg.GetFrom()
+		if author == p.host.ID() {
 			continue
 		}
 
-		var dynamicMsg Message
-		if err := json.Unmarshal(msg.Data, &dynamicMsg); err != nil {
-			fmt.Printf("❌ Failed to unmarshal dynamic message: %v\n", err)
+		dynamicMsg, ok := p.decodeMessage(sub.Topic(), msg.Data, author)
+		if !ok {
 			continue
 		}
+		queue.push(dynamicMsg, author)
+	}
+}
 
-		// Use the main Antennae handler for all dynamic messages
-		if p.AntennaeMessageHandler != nil {
-			p.AntennaeMessageHandler(dynamicMsg, msg.ReceivedFrom)
-		}
+// decodeMessage unmarshals a message received on a topic and tags it with the
+// topic's name, so handlers can tell e.g. one task's topic from another's. It
+// returns false for malformed messages and chunks of a message still arriving.
+func (p *PubSub) decodeMessage(topicName string, data []byte, from peer.ID) (Message, bool) {
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		fmt.Printf("❌ Failed to unmarshal message on %s: %v\n", topicName, err)
+		return Message{}, false
+	}
+	msg, complete := p.reassemble(msg, from)
+	if !complete {
+		return Message{}, false // Waiting for the rest of a chunked message
+	}
+	msg.Topic = topicName
+	return msg, true
+}
+
+// dispatchDynamicMessage hands a dynamic topic message to the Antennae handlers
+func (p *PubSub) dispatchDynamicMessage(msg Message, from peer.ID) {
+	p.handlersMux.RLock()
+	handlers := p.antennaeHandlers
+	p.handlersMux.RUnlock()
+
+	for _, handler := range handlers {
+		handler(msg, from)
 	}
 }
 
@@ -366,6 +554,8 @@ func (p *PubSub) processAntennaeMessage(msg Message, from peer.ID) {
 // Close shuts down the PubSub instance
 func (p *PubSub) Close() error {
 	p.cancel()
+	p.host.RemoveStreamHandler(introspectionProtocol)
+	p.host.RemoveStreamHandler(capabilityProtocol)
 	
 	if p.bzzzSub != nil {
 		p.bzzzSub.Cancel()
@@ -387,5 +577,12 @@ func (p *PubSub) Close() error {
 	}
 	p.dynamicTopicsMux.Unlock()
 
+	p.extraTopicsMux.Lock()
+	for topicName, topic := range p.extraTopics {
+		p.extraSubs[topicName].Cancel()
+		topic.Close()
+	}
+	p.extraTopicsMux.Unlock()
+
 	return nil
 }
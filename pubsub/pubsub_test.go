@@ -0,0 +1,518 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/core/sec"
+	"github.com/libp2p/go-libp2p/p2p/host/blank"
+	"github.com/libp2p/go-libp2p/p2p/host/eventbus"
+	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoremem"
+	"github.com/libp2p/go-libp2p/p2p/muxer/yamux"
+	"github.com/libp2p/go-libp2p/p2p/net/swarm"
+	tptu "github.com/libp2p/go-libp2p/p2p/net/upgrader"
+	"github.com/libp2p/go-libp2p/p2p/security/noise"
+	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// newTestPubSub creates a PubSub on a host with no transports, enough to join topics locally
+func newTestPubSub(t *testing.T) *PubSub {
+	t.Helper()
+	return newTestPubSubOn(t, false)
+}
+
+// newListeningTestPubSub creates a PubSub on a host listening on loopback TCP,
+// for tests that open streams between peers
+func newListeningTestPubSub(t *testing.T) *PubSub {
+	t.Helper()
+	return newTestPubSubOn(t, true)
+}
+
+func newTestPubSubOn(t *testing.T, listen bool) *PubSub {
+	t.Helper()
+
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	peerstore, err := pstoremem.NewPeerstore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	peerstore.AddPrivKey(id, priv)
+	peerstore.AddPubKey(id, priv.GetPublic())
+
+	network, err := swarm.NewSwarm(id, peerstore, eventbus.NewBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listen {
+		muxers := []tptu.StreamMuxer{{ID: yamux.ID, Muxer: yamux.DefaultTransport}}
+		security, err := noise.New(noise.ID, priv, muxers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		upgrader, err := tptu.New([]sec.SecureTransport{security}, muxers, nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		transport, err := tcp.NewTCPTransport(upgrader, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := network.AddTransport(transport); err != nil {
+			t.Fatal(err)
+		}
+		if err := network.Listen(ma.StringCast("/ip4/127.0.0.1/tcp/0")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := blankhost.NewBlankHost(network)
+	t.Cleanup(func() { h.Close() })
+
+	ps, err := NewPubSub(context.Background(), h, "bzzz/test/coordination", "antennae/test/meta-discussion")
+	if err != nil {
+		t.Fatalf("failed to create PubSub: %v", err)
+	}
+	t.Cleanup(func() { ps.Close() })
+	return ps
+}
+
+// joinedDynamicTopic reports whether the topic is currently joined
+func joinedDynamicTopic(p *PubSub, topicName string) bool {
+	p.dynamicTopicsMux.RLock()
+	defer p.dynamicTopicsMux.RUnlock()
+	_, joined := p.dynamicTopics[topicName]
+	return joined
+}
+
+func TestDynamicTopicIsReferenceCounted(t *testing.T) {
+	ps := newTestPubSub(t)
+	const topic = "bzzz/meta/issue/42"
+
+	for i := 0; i < 2; i++ {
+		if err := ps.JoinDynamicTopic(topic); err != nil {
+			t.Fatalf("join %d failed: %v", i+1, err)
+		}
+	}
+
+	if err := ps.LeaveDynamicTopic(topic); err != nil {
+		t.Fatalf("first leave failed: %v", err)
+	}
+	if !joinedDynamicTopic(ps, topic) {
+		t.Fatal("topic was torn down while another user still held it")
+	}
+	if err := ps.PublishToDynamicTopic(topic, MetaDiscussion, map[string]interface{}{"still": "open"}); err != nil {
+		t.Fatalf("publishing after the first leave failed: %v", err)
+	}
+
+	if err := ps.LeaveDynamicTopic(topic); err != nil {
+		t.Fatalf("second leave failed: %v", err)
+	}
+	if joinedDynamicTopic(ps, topic) {
+		t.Fatal("topic was not left after the last user left")
+	}
+
+	if err := ps.LeaveDynamicTopic(topic); err == nil {
+		t.Fatal("expected leaving a topic that isn't joined to fail")
+	}
+}
+
+func TestOversizedMessageIsChunkedAndReassembled(t *testing.T) {
+	ps := newTestPubSub(t)
+	ps.SetMaxMessageSize(MinMaxMessageSize)
+
+	diff := strings.Repeat("+ added line\n", 2000)
+	original := ps.newMessage(CoordinationRequest, map[string]interface{}{"diff": diff})
+	msgBytes, err := json.Marshal(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chunks, err := ps.chunkMessage(msgBytes)
+	if err != nil {
+		t.Fatalf("chunkMessage failed: %v", err)
+	}
+	if len(chunks) < 2 {
+		t.Fatalf("expected the message to be chunked, got %d chunk(s)", len(chunks))
+	}
+
+	// Deliver the chunks out of order, as gossip may
+	from := peer.ID("sender")
+	var reassembled Message
+	complete := false
+	for i := len(chunks) - 1; i >= 0; i-- {
+		if len(chunks[i]) > MinMaxMessageSize {
+			t.Errorf("chunk %d is %d bytes, over the %d byte limit", i, len(chunks[i]), MinMaxMessageSize)
+		}
+		var chunk Message
+		if err := json.Unmarshal(chunks[i], &chunk); err != nil {
+			t.Fatal(err)
+		}
+		if complete {
+			t.Fatal("message reassembled before every chunk arrived")
+		}
+		reassembled, complete = ps.reassemble(chunk, from)
+	}
+	if !complete {
+		t.Fatal("message was not reassembled")
+	}
+	if reassembled.Type != CoordinationRequest || reassembled.Data["diff"] != diff {
+		t.Errorf("reassembled message differs from the original: type %s", reassembled.Type)
+	}
+
+	// Far too large to chunk: rejected rather than published
+	if _, err := ps.chunkMessage(make([]byte, maxChunks*MinMaxMessageSize)); !errors.Is(err, ErrMessageTooLarge) {
+		t.Errorf("expected ErrMessageTooLarge, got %v", err)
+	}
+}
+
+func TestPartialMessagesAreBoundedAndExpire(t *testing.T) {
+	ps := newTestPubSub(t)
+	chunk := func(chunkID string, size int) Message {
+		return ps.newMessage(MessageChunk, map[string]interface{}{
+			"chunk_id": chunkID,
+			"index":    0.0, // As decoded from JSON
+			"total":    2.0,
+			"payload":  base64.StdEncoding.EncodeToString(make([]byte, size)),
+		})
+	}
+	partials := func() int {
+		ps.chunksMux.Lock()
+		defer ps.chunksMux.Unlock()
+		return len(ps.partialMessages)
+	}
+
+	// One sender can only have so many messages half-arrived; another is unaffected
+	flooder, other := peer.ID("flooder"), peer.ID("other")
+	for i := 0; i < maxPartialPerPeer+2; i++ {
+		ps.reassemble(chunk(fmt.Sprintf("flood-%d", i), 10), flooder)
+	}
+	ps.reassemble(chunk("flood-0", 10), other)
+	if got := partials(); got != maxPartialPerPeer+1 {
+		t.Fatalf("expected %d partial messages held, got %d", maxPartialPerPeer+1, got)
+	}
+
+	// Chunks past the byte budget are dropped along with the rest of their message
+	defer func(limit int) { maxPartialBytes = limit }(maxPartialBytes)
+	maxPartialBytes = 100
+	ps.reassemble(chunk("big", 200), other)
+	if got := partials(); got != maxPartialPerPeer+1 {
+		t.Fatalf("expected a chunk over the byte budget to be dropped, %d partial messages held", got)
+	}
+
+	// Abandoned messages are forgotten even if no other chunk arrives
+	ps.chunksMux.Lock()
+	ps.expireChunks(time.Now().Add(2 * chunkTimeout))
+	held := ps.partialBytes
+	ps.chunksMux.Unlock()
+	if got := partials(); got != 0 || held != 0 {
+		t.Errorf("expected abandoned chunks to expire, %d messages and %d bytes held", got, held)
+	}
+}
+
+func TestMalformedChunkIndicesAreDropped(t *testing.T) {
+	ps := newTestPubSub(t)
+	chunk := func(chunkID string, index, total float64) Message {
+		return ps.newMessage(MessageChunk, map[string]interface{}{
+			"chunk_id": chunkID,
+			"index":    index,
+			"total":    total,
+			"payload":  base64.StdEncoding.EncodeToString([]byte("x")),
+		})
+	}
+	from := peer.ID("crafty")
+
+	for _, c := range []struct{ index, total float64 }{
+		{2.4, 2.5}, // Rounds down to index 2 of 2 parts
+		{0.5, 2},
+		{1, 1.5},
+		{-1, 2},
+		{2, 2},
+		{0, maxChunks + 1},
+	} {
+		if _, complete := ps.reassemble(chunk(fmt.Sprintf("bad-%v-%v", c.index, c.total), c.index, c.total), from); complete {
+			t.Errorf("chunk %v of %v was accepted", c.index, c.total)
+		}
+	}
+
+	// A chunk whose total disagrees with the message's earlier chunks is dropped too
+	ps.reassemble(chunk("mixed", 0, 2), from)
+	if _, complete := ps.reassemble(chunk("mixed", 2, 3), from); complete {
+		t.Error("chunk outside the message's parts was accepted")
+	}
+
+	// Nothing is left holding the lock
+	locked := make(chan struct{})
+	go func() {
+		ps.chunksMux.Lock()
+		held := len(ps.partialMessages)
+		ps.chunksMux.Unlock()
+		if held != 1 {
+			t.Errorf("expected only the well-formed chunk held, got %d partial messages", held)
+		}
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("chunksMux is still held after malformed chunks")
+	}
+}
+
+func TestExtraTopicMessagesReachAntennaeHandlerWithTopic(t *testing.T) {
+	ps := newTestPubSub(t)
+	const team = "bzzz/team/frontend"
+
+	if err := ps.JoinTopics(team, "antennae/test/meta-discussion"); err != nil {
+		t.Fatalf("JoinTopics failed: %v", err)
+	}
+	if topics := ps.Topics(); len(topics) != 1 || topics[0] != team {
+		t.Fatalf("expected only the team topic to be joined as extra, got %v", topics)
+	}
+	if err := ps.PublishToTopic(team, MetaDiscussion, map[string]interface{}{"text": "hello team"}); err != nil {
+		t.Fatalf("publishing to the team topic failed: %v", err)
+	}
+
+	var received []Message
+	ps.AddAntennaeMessageHandler(func(msg Message, from peer.ID) {
+		received = append(received, msg)
+	})
+	data, err := json.Marshal(Message{Type: MetaDiscussion, From: "other", Data: map[string]interface{}{"text": "from a teammate"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps.receiveTopicMessage(team, data, peer.ID("other"))
+
+	if len(received) != 1 {
+		t.Fatalf("expected the handler to get 1 message, got %d", len(received))
+	}
+	if received[0].Topic != team || received[0].Data["text"] != "from a teammate" {
+		t.Errorf("expected the teammate's message tagged with %s, got %+v", team, received[0])
+	}
+}
+
+func TestDynamicTopicMessagesCarryTheirTopic(t *testing.T) {
+	ps := newTestPubSub(t)
+	for _, issue := range []int{5, 6} {
+		if err := ps.JoinDynamicTopic(TaskTopic(issue)); err != nil {
+			t.Fatalf("failed to join topic for issue #%d: %v", issue, err)
+		}
+	}
+
+	var received []Message
+	ps.AddAntennaeMessageHandler(func(msg Message, from peer.ID) {
+		received = append(received, msg)
+	})
+
+	// Route a message from each issue's topic the way handleDynamicMessages does
+	var dropped uint64
+	queue := newDispatchQueue(4, &dropped)
+	for _, issue := range []int{6, 5} {
+		data, err := json.Marshal(Message{Type: TaskHelpRequest, From: "other", Data: map[string]interface{}{"reason": "stuck"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, ok := ps.decodeMessage(TaskTopic(issue), data, peer.ID("other"))
+		if !ok {
+			t.Fatalf("message for issue #%d was not decoded", issue)
+		}
+		queue.push(msg, peer.ID("other"))
+	}
+	queue.close()
+	queue.run(ps.dispatchDynamicMessage)
+
+	if len(received) != 2 {
+		t.Fatalf("expected 2 messages delivered, got %d", len(received))
+	}
+	for i, want := range []int{6, 5} {
+		issue, ok := TaskNumberFromTopic(received[i].Topic)
+		if !ok || issue != want {
+			t.Errorf("message %d arrived tagged %q, want the topic for issue #%d", i, received[i].Topic, want)
+		}
+	}
+}
+
+func TestNewNodeLearnsExistingPeerCapabilitiesByQuery(t *testing.T) {
+	existing := newListeningTestPubSub(t)
+	newcomer := newListeningTestPubSub(t)
+	if err := existing.StartCapabilityExchange(map[string]interface{}{
+		"node_id":        "existing",
+		"capabilities":   []string{"code-generation", "testing"},
+		"models":         []string{"llama3.1"},
+		"specialization": "code_generation",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	newcomer.host.Peerstore().AddAddrs(existing.ID(), existing.host.Addrs(), peerstore.PermanentAddrTTL)
+
+	// Watch what the existing peer publishes: answering must not broadcast
+	published, err := existing.bzzzTopic.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer published.Cancel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := newcomer.FetchCapabilities(ctx, existing.ID()); err != nil {
+		t.Fatalf("capability request failed: %v", err)
+	}
+
+	quiet, stop := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer stop()
+	if _, err := published.Next(quiet); err == nil {
+		t.Error("expected the existing peer to answer directly rather than broadcast")
+	}
+
+	caps, ok := newcomer.CapabilitiesOf(existing.ID())
+	if !ok {
+		t.Fatal("newcomer did not record the existing peer's capabilities")
+	}
+	if caps.NodeID != "existing" || caps.Specialization != "code_generation" {
+		t.Errorf("unexpected capabilities %+v", caps)
+	}
+	if len(caps.Capabilities) != 2 || caps.Capabilities[1] != "testing" || len(caps.Models) != 1 {
+		t.Errorf("capability lists not decoded: %+v", caps)
+	}
+}
+
+func TestAvailabilityBroadcastCarriesCapabilitiesAndModels(t *testing.T) {
+	agent := newTestPubSub(t)
+	delegator := newTestPubSub(t)
+	longModel := strings.Repeat("m", 200)
+	agent.SetLocalCapabilities(map[string]interface{}{
+		"node_id":        "agent",
+		"capabilities":   []string{"code-generation", "testing"},
+		"models":         []string{"llama3.1", longModel},
+		"specialization": "code_generation",
+	})
+
+	// Watch what the agent publishes, standing in for the delegator's subscription
+	sub, err := agent.bzzzTopic.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Cancel()
+
+	if err := agent.PublishAvailability(map[string]interface{}{
+		"node_id":            "agent",
+		"available_for_work": true,
+		"current_tasks":      1,
+		"max_tasks":          3,
+		"status":             "working",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	received, err := sub.Next(ctx)
+	if err != nil {
+		t.Fatalf("no availability was published: %v", err)
+	}
+	msg, ok := delegator.decodeMessage(delegator.bzzzTopicName, received.Data, agent.ID())
+	if !ok || msg.Type != AvailabilityBcast {
+		t.Fatalf("expected an availability broadcast, got %+v", msg)
+	}
+	delegator.observeCapabilities(msg, agent.ID())
+
+	availability, ok := delegator.AvailabilityOf(agent.ID())
+	if !ok {
+		t.Fatal("the delegator did not record the agent's availability")
+	}
+	if !availability.Available || availability.Status != "working" || availability.CurrentTasks != 1 || availability.MaxTasks != 3 {
+		t.Errorf("workload not decoded: %+v", availability)
+	}
+	if len(availability.Capabilities) != 2 || availability.Capabilities[0] != "code-generation" || availability.Specialization != "code_generation" {
+		t.Errorf("capabilities not carried: %+v", availability)
+	}
+	if len(availability.Models) != 2 || availability.Models[0] != "llama3.1" || len(availability.Models[1]) != maxAdvertisedLength {
+		t.Errorf("models not carried or not bounded: %+v", availability.Models)
+	}
+}
+
+func TestIntrospectionQueryReturnsActiveTasksAndCapabilities(t *testing.T) {
+	agent := newListeningTestPubSub(t)
+	operator := newListeningTestPubSub(t)
+	stranger := newListeningTestPubSub(t)
+	agent.StartIntrospection(func() Introspection {
+		return Introspection{NodeID: "agent", Capabilities: []string{"code-generation", "testing"}, ActiveTasks: 2, MaxTasks: 3}
+	}, []peer.ID{operator.ID()})
+	for _, asker := range []*PubSub{operator, stranger} {
+		agent.host.Peerstore().AddAddrs(asker.ID(), asker.host.Addrs(), peerstore.PermanentAddrTTL)
+	}
+
+	// Watch what the agent publishes: replies must not be broadcast
+	published, err := agent.bzzzTopic.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer published.Cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	broadcast := make(chan struct{}, 1)
+	go func() {
+		for {
+			received, err := published.Next(ctx)
+			if err != nil {
+				return
+			}
+			if received.GetFrom() == agent.ID() {
+				broadcast <- struct{}{}
+				return
+			}
+		}
+	}()
+
+	// Requests reach the agent as they would over the mesh
+	ask := func(asker *PubSub) (Introspection, error) {
+		requests, err := asker.bzzzTopic.Subscribe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer requests.Cancel()
+		go func() {
+			received, err := requests.Next(ctx)
+			if err != nil {
+				return
+			}
+			if request, ok := agent.decodeMessage(agent.bzzzTopicName, received.Data, asker.ID()); ok {
+				agent.observeIntrospection(request, asker.ID())
+			}
+		}()
+		return asker.Introspect(ctx, agent.ID())
+	}
+
+	got, err := ask(operator)
+	if err != nil {
+		t.Fatalf("introspection failed: %v", err)
+	}
+	if got.ActiveTasks != 2 || len(got.Capabilities) != 2 || got.Capabilities[1] != "testing" {
+		t.Errorf("unexpected introspection %+v", got)
+	}
+
+	if _, err := ask(stranger); !errors.Is(err, ErrIntrospectionDenied) {
+		t.Errorf("expected a peer off the allowlist to be refused, got %v", err)
+	}
+
+	select {
+	case <-broadcast:
+		t.Error("expected introspection replies to go only to the requester, not the topic")
+	default:
+	}
+}
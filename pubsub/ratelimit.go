@@ -0,0 +1,163 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pkg/reputation"
+	"github.com/libp2p/go-libp2p/core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	DefaultMessageRateLimit = 20.0 // Messages per second accepted from each peer
+	DefaultMessageBurst     = 100  // Messages a quiet peer may send at once; never below maxChunks
+)
+
+var messagesRateLimited = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "bzzz_pubsub_rate_limited_messages_total",
+	Help: "Messages dropped because their sender exceeded the per-peer rate limit.",
+})
+
+func init() {
+	prometheus.MustRegister(messagesRateLimited)
+}
+
+// tokenBucket is one peer's message allowance
+type tokenBucket struct {
+	tokens    float64
+	last      time.Time
+	throttled bool // Messages are being dropped; cleared once one gets through
+}
+
+// peerRateLimiter drops messages from peers that send faster than the limit, so
+// one misbehaving peer can't swamp every node's handlers. Each bout of flooding
+// counts against the peer's reputation, and a poorer reputation shrinks its
+// allowance, so repeat offenders are throttled harder.
+type peerRateLimiter struct {
+	rate       float64 // Tokens per second; 0 disables limiting
+	burst      int
+	reputation *reputation.Store
+	buckets    map[peer.ID]*tokenBucket
+	dropped    uint64
+	now        func() time.Time
+	mu         sync.Mutex
+}
+
+// newPeerRateLimiter creates a limiter allowing rate messages per second with bursts of burst
+func newPeerRateLimiter(rate float64, burst int) *peerRateLimiter {
+	return &peerRateLimiter{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[peer.ID]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// allow takes a token from the peer's bucket, reporting false if it has none left
+func (l *peerRateLimiter) allow(from peer.ID) bool {
+	l.mu.Lock()
+	if l.rate <= 0 {
+		l.mu.Unlock()
+		return true
+	}
+	rate, burst := l.limitsFor(from)
+	now := l.now()
+	bucket, exists := l.buckets[from]
+	if !exists {
+		bucket = &tokenBucket{tokens: burst, last: now}
+		l.buckets[from] = bucket
+	}
+	bucket.tokens = math.Min(burst, bucket.tokens+now.Sub(bucket.last).Seconds()*rate)
+	bucket.last = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		bucket.throttled = false
+		l.mu.Unlock()
+		return true
+	}
+
+	l.dropped++
+	newOffence := !bucket.throttled
+	bucket.throttled = true
+	store := l.reputation
+	l.mu.Unlock()
+
+	messagesRateLimited.Inc()
+	if newOffence {
+		fmt.Printf("🚫 Throttling %s: sending faster than %.1f messages/s\n", from.ShortString(), rate)
+		if store != nil {
+			store.Record(from.String(), reputation.RateLimited)
+		}
+	}
+	return false
+}
+
+// limitsFor scales the allowance by reputation: peers at or above a neutral
+// score get the full limit, worse ones proportionally less. Callers hold the lock.
+func (l *peerRateLimiter) limitsFor(from peer.ID) (rate, burst float64) {
+	factor := 1.0
+	if l.reputation != nil {
+		factor = math.Min(1, 2*l.reputation.Score(from.String()))
+	}
+	return l.rate * factor, math.Max(1, float64(l.burst)*factor)
+}
+
+// set changes the limit for every peer
+func (l *peerRateLimiter) set(rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = rate
+	l.burst = burst
+}
+
+// forget drops a departed peer's bucket
+func (l *peerRateLimiter) forget(id peer.ID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.buckets, id)
+}
+
+// validate is the gossipsub validator for every topic. It charges each message
+// to its signed author rather than the neighbour relaying it, and messages over
+// the limit are ignored: neither handled here nor forwarded to the mesh, without
+// penalising the relay.
+func (l *peerRateLimiter) validate(_ context.Context, _ peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	if msg.Local || l.allow(msg.GetFrom()) {
+		return pubsub.ValidationAccept
+	}
+	return pubsub.ValidationIgnore
+}
+
+// SetMessageRateLimit caps how many messages per second are accepted from each
+// peer, allowing bursts of up to burst. A rate of 0 turns limiting off. Bursts
+// are at least maxChunks, so a peer can always send one message in full.
+func (p *PubSub) SetMessageRateLimit(rate float64, burst int) {
+	if burst <= 0 {
+		burst = DefaultMessageBurst
+	}
+	if burst < maxChunks {
+		fmt.Printf("⚠️ Raising the message burst from %d to %d so chunked messages aren't throttled\n", burst, maxChunks)
+		burst = maxChunks
+	}
+	p.limiter.set(rate, burst)
+}
+
+// SetReputationStore lets peers that flood the mesh lose reputation, and be throttled harder for it
+func (p *PubSub) SetReputationStore(store *reputation.Store) {
+	p.limiter.mu.Lock()
+	defer p.limiter.mu.Unlock()
+	p.limiter.reputation = store
+}
+
+// RateLimitedMessages returns how many messages have been dropped by the per-peer rate limit
+func (p *PubSub) RateLimitedMessages() uint64 {
+	p.limiter.mu.Lock()
+	defer p.limiter.mu.Unlock()
+	return p.limiter.dropped
+}
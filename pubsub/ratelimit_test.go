@@ -0,0 +1,81 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pkg/reputation"
+	"github.com/libp2p/go-libp2p/core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+)
+
+// relayed is a message signed by author as the validator would see it, arriving from a neighbour
+func relayed(author peer.ID) *pubsub.Message {
+	return &pubsub.Message{Message: &pb.Message{From: []byte(author)}, ReceivedFrom: peer.ID("peer-relay")}
+}
+
+func TestFloodingPeerIsThrottledWhileOthersFlow(t *testing.T) {
+	ps := newTestPubSub(t)
+	store := reputation.NewStore("")
+	ps.SetReputationStore(store)
+	ps.SetMessageRateLimit(5, 5)
+	if ps.limiter.burst != maxChunks {
+		t.Fatalf("expected the burst raised to %d so chunked messages fit, got %d", maxChunks, ps.limiter.burst)
+	}
+
+	clock := time.Now()
+	ps.limiter.now = func() time.Time { return clock }
+	flooder, neighbour := peer.ID("peer-flooder"), peer.ID("peer-neighbour")
+
+	// The flooder sends 100 messages in one instant, between the neighbour's
+	// three, all through the same relay
+	admitted := map[peer.ID]int{}
+	for i := 0; i < 100; i++ {
+		from := flooder
+		if i%40 == 0 {
+			from = neighbour
+		}
+		if ps.limiter.validate(context.Background(), "peer-relay", relayed(from)) == pubsub.ValidationAccept {
+			admitted[from]++
+		}
+	}
+
+	if admitted[neighbour] != 3 {
+		t.Errorf("the neighbour's messages should all flow, %d of 3 admitted", admitted[neighbour])
+	}
+	if admitted[flooder] != maxChunks {
+		t.Errorf("expected the flooder held to its burst of %d, %d admitted", maxChunks, admitted[flooder])
+	}
+	if dropped := ps.RateLimitedMessages(); dropped != 97-maxChunks {
+		t.Errorf("expected %d messages dropped, got %d", 97-maxChunks, dropped)
+	}
+	if record, _ := store.GetRecord(flooder.String()); record.RateLimited != 1 {
+		t.Errorf("expected one flooding offence recorded, got %d", record.RateLimited)
+	}
+	if record, _ := store.GetRecord("peer-relay"); record.RateLimited != 0 {
+		t.Error("expected the relay not to be blamed for the flooder's messages")
+	}
+
+	// Once its bucket refills, the offender's reduced reputation keeps its allowance below the limit
+	clock = clock.Add(time.Minute)
+	admitted[flooder] = 0
+	for i := 0; i < 100; i++ {
+		if ps.limiter.validate(context.Background(), "peer-relay", relayed(flooder)) == pubsub.ValidationAccept {
+			admitted[flooder]++
+		}
+	}
+	if admitted[flooder] >= maxChunks {
+		t.Errorf("expected a repeat offender to get less than the full burst, %d admitted", admitted[flooder])
+	}
+
+	// Our own messages are never throttled
+	own := relayed(ps.ID())
+	own.Local = true
+	for i := 0; i < 100; i++ {
+		if ps.limiter.validate(context.Background(), ps.ID(), own) != pubsub.ValidationAccept {
+			t.Fatal("expected local messages to bypass the limit")
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package pubsub
+
+import (
+	"fmt"
+
+	"github.com/anthonyrawlins/bzzz/pkg/supervisor"
+	"github.com/libp2p/go-libp2p/core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+// JoinTopics joins and subscribes to extra coordination topics for the life of
+// the PubSub, such as a team's channel. Their messages go to the Antennae handler
+// with Message.Topic saying where they came from.
+func (p *PubSub) JoinTopics(topicNames ...string) error {
+	p.extraTopicsMux.Lock()
+	defer p.extraTopicsMux.Unlock()
+
+	for _, topicName := range topicNames {
+		if topicName == p.bzzzTopicName || topicName == p.antennaeTopicName {
+			continue // Already joined as a main topic
+		}
+		if _, exists := p.extraTopics[topicName]; exists {
+			continue
+		}
+
+		topic, err := p.ps.Join(topicName)
+		if err != nil {
+			return fmt.Errorf("failed to join topic %s: %w", topicName, err)
+		}
+		sub, err := topic.Subscribe()
+		if err != nil {
+			topic.Close()
+			return fmt.Errorf("failed to subscribe to topic %s: %w", topicName, err)
+		}
+		p.extraTopics[topicName] = topic
+		p.extraSubs[topicName] = sub
+
+		supervisor.Go(p.ctx, "topic "+topicName, func() { p.handleTopicMessages(topicName, sub) })
+		fmt.Printf("✅ Joined topic: %s\n", topicName)
+	}
+	return nil
+}
+
+// Topics returns the extra topics joined with JoinTopics
+func (p *PubSub) Topics() []string {
+	p.extraTopicsMux.RLock()
+	defer p.extraTopicsMux.RUnlock()
+	names := make([]string, 0, len(p.extraTopics))
+	for topicName := range p.extraTopics {
+		names = append(names, topicName)
+	}
+	return names
+}
+
+// PublishToTopic publishes a message to a topic joined with JoinTopics
+func (p *PubSub) PublishToTopic(topicName string, msgType MessageType, data map[string]interface{}) error {
+	p.extraTopicsMux.RLock()
+	topic, exists := p.extraTopics[topicName]
+	p.extraTopicsMux.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("not subscribed to topic: %s", topicName)
+	}
+	return p.publish(topic, p.newMessage(msgType, data))
+}
+
+// handleTopicMessages processes incoming messages on an extra topic
+func (p *PubSub) handleTopicMessages(topicName string, sub *pubsub.Subscription) {
+	for {
+		msg, err := sub.Next(p.ctx)
+		if err != nil {
+			if p.ctx.Err() != nil || err.Error() == "subscription cancelled" {
+				return
+			}
+			fmt.Printf("❌ Error receiving message on %s: %v\n", topicName, err)
+			continue
+		}
+
+		if msg.GetFrom() == p.host.ID() {
+			continue
+		}
+		p.receiveTopicMessage(topicName, msg.Data, msg.GetFrom())
+	}
+}
+
+// receiveTopicMessage hands a message from an extra topic to the Antennae handlers
+func (p *PubSub) receiveTopicMessage(topicName string, data []byte, from peer.ID) {
+	topicMsg, ok := p.decodeMessage(topicName, data, from)
+	if !ok {
+		return
+	}
+
+	p.deliverAntennaeMessage(topicMsg, from)
+}
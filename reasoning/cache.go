@@ -0,0 +1,100 @@
+package reasoning
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// responseCache remembers responses to identical prompts for a while,
+// evicting the least recently used entry once it is full
+type responseCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	entries map[string]*list.Element
+	order   *list.List // Most recently used at the front
+	mu      sync.Mutex
+}
+
+// cacheEntry is one cached response
+type cacheEntry struct {
+	key      string
+	response string
+	expires  time.Time
+}
+
+// cache is nil unless EnableCache has been called
+var (
+	cache     *responseCache
+	cacheLock sync.RWMutex
+)
+
+// EnableCache turns on caching of responses to identical prompts for ttl,
+// holding at most maxEntries. A non-positive ttl or maxEntries disables it.
+func EnableCache(ttl time.Duration, maxEntries int) {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	if ttl <= 0 || maxEntries <= 0 {
+		cache = nil
+		return
+	}
+	cache = &responseCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// activeCache returns the response cache, or nil if caching is off
+func activeCache() *responseCache {
+	cacheLock.RLock()
+	defer cacheLock.RUnlock()
+	return cache
+}
+
+// cacheKey addresses a response by everything that went into generating it
+func cacheKey(model, prompt string) string {
+	hash := sha256.Sum256([]byte(model + "\x00" + prompt))
+	return hex.EncodeToString(hash[:])
+}
+
+// get returns a cached response that hasn't expired
+func (c *responseCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, exists := c.entries[key]
+	if !exists {
+		return "", false
+	}
+	entry := element.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return "", false
+	}
+	c.order.MoveToFront(element)
+	return entry.response, true
+}
+
+// put caches a response, evicting the least recently used one if the cache is full
+func (c *responseCache) put(key, response string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := time.Now().Add(c.ttl)
+	if element, exists := c.entries[key]; exists {
+		entry := element.Value.(*cacheEntry)
+		entry.response, entry.expires = response, expires
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, response: response, expires: expires})
+	if c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
@@ -0,0 +1,205 @@
+package reasoning
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// loadSampleInterval is how long a load reading is reused before the sensor is
+// asked again; a variable so tests can sample every time
+var loadSampleInterval = 15 * time.Second
+
+// LoadSensor reports how busy the host is, from 0 (idle) to 1 (saturated)
+type LoadSensor func() (float64, error)
+
+// loadPolicy downgrades to lighter models while the host is busy. The gap
+// between its thresholds stops it flapping around a single value.
+type loadPolicy struct {
+	sensor         LoadSensor
+	downgradeAbove float64
+	restoreBelow   float64
+
+	lock       sync.Mutex
+	load       float64
+	sampledAt  time.Time
+	downgraded bool
+}
+
+var (
+	activeLoadPolicy *loadPolicy // nil selects models regardless of load
+	loadPolicyLock   sync.Mutex
+)
+
+// ownGenerations counts this agent's requests to Ollama in flight. The host's
+// load includes their inference, so the sensor isn't read while any run.
+var ownGenerations atomic.Int32
+
+// EnableLoadAwareSelection makes generate calls fall back to the lightest
+// available model while sensor reads above downgradeAbove, and return to the
+// usual choice once it reads below restoreBelow. A nil sensor reads this host's
+// CPU and GPU utilization; downgradeAbove of 0 disables the policy.
+func EnableLoadAwareSelection(downgradeAbove, restoreBelow float64, sensor LoadSensor) {
+	loadPolicyLock.Lock()
+	defer loadPolicyLock.Unlock()
+
+	if downgradeAbove <= 0 {
+		activeLoadPolicy = nil
+		return
+	}
+	if restoreBelow <= 0 || restoreBelow > downgradeAbove {
+		restoreBelow = downgradeAbove
+	}
+	if sensor == nil {
+		sensor = hostLoad
+	}
+	activeLoadPolicy = &loadPolicy{sensor: sensor, downgradeAbove: downgradeAbove, restoreBelow: restoreBelow}
+}
+
+// currentLoadPolicy returns the policy in force, or nil
+func currentLoadPolicy() *loadPolicy {
+	loadPolicyLock.Lock()
+	defer loadPolicyLock.Unlock()
+	return activeLoadPolicy
+}
+
+// underLoad returns the model to use in place of model given the host's load.
+// It's asked on every generate call, so a task moves to a lighter model and back
+// as the load changes rather than keeping what it started with.
+func underLoad(model string) string {
+	policy := currentLoadPolicy()
+	if policy == nil || !policy.constrained() {
+		return model
+	}
+	if lighter := lightestModel(model); lighter != "" {
+		return lighter
+	}
+	return model
+}
+
+// constrained samples the load if the last reading is stale and reports whether
+// the host is too busy for the usual models. While this agent has generations
+// running the last reading stands, as a new one would count them as load.
+func (p *loadPolicy) constrained() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if !p.sampledAt.IsZero() && time.Since(p.sampledAt) < loadSampleInterval {
+		return p.downgraded
+	}
+	if ownGenerations.Load() > 0 {
+		return p.downgraded
+	}
+	load, err := p.sensor()
+	if err != nil {
+		fmt.Printf("⚠️ Failed to read host load, keeping model selection as is: %v\n", err)
+		return p.downgraded
+	}
+	p.load, p.sampledAt = load, time.Now()
+
+	switch {
+	case !p.downgraded && load > p.downgradeAbove:
+		p.downgraded = true
+		fmt.Printf("🌡️ Host at %.0f%% load, downgrading to lighter models\n", load*100)
+	case p.downgraded && load < p.restoreBelow:
+		p.downgraded = false
+		fmt.Printf("🌡️ Host load down to %.0f%%, using the usual models again\n", load*100)
+	}
+	return p.downgraded
+}
+
+// lightestModel returns the smallest available model lighter than model, or ""
+// when there is none or model's size is unknown
+func lightestModel(model string) string {
+	size := modelSize(model)
+	if size == 0 {
+		return ""
+	}
+	lightest, lightestSize := "", size
+	for _, candidate := range availableModels {
+		if candidateSize := modelSize(candidate); candidateSize > 0 && candidateSize < lightestSize {
+			lightest, lightestSize = candidate, candidateSize
+		}
+	}
+	return lightest
+}
+
+// LoadStatus describes how host load is affecting model selection
+type LoadStatus struct {
+	Enabled        bool
+	Load           float64 // The last reading, from 0 to 1
+	Downgraded     bool
+	EffectiveModel string // What the default model is being swapped for, or the default model itself
+}
+
+// CurrentLoadStatus reports the load policy's last reading and the model the
+// default model currently resolves to. It never reads the sensor itself.
+func CurrentLoadStatus() LoadStatus {
+	status := LoadStatus{EffectiveModel: defaultModel}
+	policy := currentLoadPolicy()
+	if policy == nil {
+		return status
+	}
+	status.Enabled = true
+
+	policy.lock.Lock()
+	status.Load, status.Downgraded = policy.load, policy.downgraded
+	policy.lock.Unlock()
+	if lighter := lightestModel(defaultModel); status.Downgraded && lighter != "" {
+		status.EffectiveModel = lighter
+	}
+	return status
+}
+
+// hostLoad reads the busier of the CPU and the GPUs, from 0 to 1
+func hostLoad() (float64, error) {
+	cpu, err := cpuLoad()
+	if err != nil {
+		return 0, err
+	}
+	if gpu, err := gpuLoad(); err == nil && gpu > cpu {
+		return gpu, nil
+	}
+	return cpu, nil
+}
+
+// cpuLoad is the one-minute load average per CPU, capped at 1
+func cpuLoad() (float64, error) {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read load average: %w", err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("unexpected load average %q", data)
+	}
+	average, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected load average %q: %w", data, err)
+	}
+	return min(average/float64(runtime.NumCPU()), 1), nil
+}
+
+// gpuLoad is the busiest NVIDIA GPU's utilization, if nvidia-smi is installed
+func gpuLoad() (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "nvidia-smi", "--query-gpu=utilization.gpu", "--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to query GPU utilization: %w", err)
+	}
+	busiest := 0.0
+	for _, line := range strings.Fields(string(output)) {
+		if percent, err := strconv.ParseFloat(line, 64); err == nil && percent/100 > busiest {
+			busiest = percent / 100
+		}
+	}
+	return busiest, nil
+}
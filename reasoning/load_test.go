@@ -0,0 +1,73 @@
+package reasoning
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGenerationDowngradesUnderLoad(t *testing.T) {
+	loadSampleInterval = 0
+	defer func() { loadSampleInterval = 15 * time.Second }()
+	previousModels, previousDefault := availableModels, defaultModel
+	availableModels, defaultModel = []string{"llama3.1:70b", "llama3.1:8b", "qwen2.5:0.5b"}, "llama3.1:70b"
+	defer func() { availableModels, defaultModel = previousModels, previousDefault }()
+
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requested = append(requested, req.Model)
+		json.NewEncoder(w).Encode(OllamaResponse{Model: req.Model, Response: "ok", Done: true})
+	}))
+	defer server.Close()
+	previousURL := ollamaAPIURL
+	ollamaAPIURL = server.URL + "/api/generate"
+	defer func() { ollamaAPIURL = previousURL }()
+
+	load, readings := 0.5, 0
+	EnableLoadAwareSelection(0.9, 0.7, func() (float64, error) {
+		readings++
+		return load, nil
+	})
+	defer EnableLoadAwareSelection(0, 0, nil)
+
+	// The task keeps its model; each call checks the load afresh
+	model := SelectModel("write a parser")
+	generate := func(want string) {
+		t.Helper()
+		if _, err := GenerateResponse(context.Background(), model, "write a parser"); err != nil {
+			t.Fatalf("GenerateResponse failed: %v", err)
+		}
+		if got := requested[len(requested)-1]; got != want {
+			t.Fatalf("expected %s at %.0f%% load, got %s", want, load*100, got)
+		}
+	}
+	generate("llama3.1:70b")
+	load = 0.95
+	generate("qwen2.5:0.5b")
+
+	// Reading the status doesn't sample the load
+	before := readings
+	if status := CurrentLoadStatus(); !status.Downgraded || status.EffectiveModel != "qwen2.5:0.5b" {
+		t.Fatalf("expected status to report the downgrade, got %+v", status)
+	}
+	if readings != before {
+		t.Fatal("expected reading the status not to sample the load")
+	}
+
+	// Between the thresholds the downgrade holds, so selection doesn't flap
+	load = 0.8
+	generate("qwen2.5:0.5b")
+	load = 0.6
+	generate("llama3.1:70b")
+
+	// While this agent's own generations run, the host's load includes them
+	ownGenerations.Add(1)
+	load = 0.95
+	generate("llama3.1:70b")
+	ownGenerations.Add(-1)
+}
@@ -0,0 +1,152 @@
+package reasoning
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Outcome is how a task driven by a model turned out
+type Outcome string
+
+const (
+	OutcomeMerged   Outcome = "merged"   // The task's pull request was merged
+	OutcomeRejected Outcome = "rejected" // The task's pull request was closed without merging
+	OutcomeFailed   Outcome = "failed"   // The task failed before a pull request was opened
+)
+
+// minOutcomeSamples is how many outcomes a model needs for a task type before
+// its track record outweighs the model selector
+const minOutcomeSamples = 3
+
+// ModelRecord holds the outcome counts for one model on one task type
+type ModelRecord struct {
+	Merged      int       `json:"merged"`
+	Rejected    int       `json:"rejected"`
+	Failed      int       `json:"failed"`
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+// Total is how many outcomes have been recorded
+func (r ModelRecord) Total() int {
+	return r.Merged + r.Rejected + r.Failed
+}
+
+// Score rates the model between 0 and 1 by how often its work was merged, smoothed
+// so a single outcome doesn't swing it to either extreme
+func (r ModelRecord) Score() float64 {
+	return float64(r.Merged+1) / float64(r.Total()+2)
+}
+
+var (
+	outcomes     = make(map[string]map[string]*ModelRecord) // task type -> model -> record
+	outcomesPath string                                     // Where outcomes persist; empty keeps them in memory
+	outcomesLock sync.Mutex
+)
+
+// EnableOutcomeTracking persists model outcomes to path, loading any recorded by
+// an earlier run
+func EnableOutcomeTracking(path string) {
+	outcomesLock.Lock()
+	defer outcomesLock.Unlock()
+
+	outcomesPath = path
+	outcomes = make(map[string]map[string]*ModelRecord)
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &outcomes); err != nil {
+			fmt.Printf("⚠️ Ignoring unreadable model outcome file %s: %v\n", path, err)
+			outcomes = make(map[string]map[string]*ModelRecord)
+		}
+	}
+}
+
+// RecordOutcome counts how a task of taskType driven by model turned out
+func RecordOutcome(model, taskType string, outcome Outcome) {
+	if model == "" {
+		return
+	}
+	outcomesLock.Lock()
+	defer outcomesLock.Unlock()
+
+	if outcomes[taskType] == nil {
+		outcomes[taskType] = make(map[string]*ModelRecord)
+	}
+	record, exists := outcomes[taskType][model]
+	if !exists {
+		record = &ModelRecord{}
+		outcomes[taskType][model] = record
+	}
+	switch outcome {
+	case OutcomeMerged:
+		record.Merged++
+	case OutcomeRejected:
+		record.Rejected++
+	case OutcomeFailed:
+		record.Failed++
+	}
+	record.LastUpdated = time.Now()
+	saveOutcomes()
+}
+
+// ModelRecords returns each model's outcomes on tasks of taskType
+func ModelRecords(taskType string) map[string]ModelRecord {
+	outcomesLock.Lock()
+	defer outcomesLock.Unlock()
+
+	records := make(map[string]ModelRecord, len(outcomes[taskType]))
+	for model, record := range outcomes[taskType] {
+		records[model] = *record
+	}
+	return records
+}
+
+// SelectModelForTask prefers the available model with the best track record on
+// tasks of taskType, once it has enough outcomes and more of its work was merged
+// than not. Otherwise it's SelectModel's choice. Either way each generate call
+// uses a lighter model while the host is under load.
+func SelectModelForTask(taskType, prompt string) string {
+	if model := provenModel(availableModels, taskType); model != "" {
+		return model
+	}
+	return SelectModel(prompt)
+}
+
+// provenModel returns the best-scoring of models with a good record on taskType, or ""
+func provenModel(models []string, taskType string) string {
+	outcomesLock.Lock()
+	defer outcomesLock.Unlock()
+
+	best, bestScore := "", 0.5
+	for _, model := range models {
+		record, exists := outcomes[taskType][model]
+		if !exists || record.Total() < minOutcomeSamples {
+			continue
+		}
+		if score := record.Score(); score > bestScore {
+			best, bestScore = model, score
+		}
+	}
+	return best
+}
+
+// saveOutcomes writes the outcomes to disk; callers must hold outcomesLock
+func saveOutcomes() {
+	if outcomesPath == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(outcomesPath), 0755); err != nil {
+		fmt.Printf("⚠️ Failed to persist model outcomes: %v\n", err)
+		return
+	}
+	data, err := json.MarshalIndent(outcomes, "", "  ")
+	if err != nil {
+		fmt.Printf("⚠️ Failed to persist model outcomes: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(outcomesPath, data, 0644); err != nil {
+		fmt.Printf("⚠️ Failed to persist model outcomes: %v\n", err)
+	}
+}
@@ -0,0 +1,39 @@
+package reasoning
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestModelWithGoodTrackRecordIsSelectedForTaskType(t *testing.T) {
+	SetModelConfig([]string{"llama3.1", "codellama"}, "", "")
+	defer SetModelConfig(nil, "", "")
+	path := filepath.Join(t.TempDir(), "model-outcomes.json")
+	EnableOutcomeTracking(path)
+	defer EnableOutcomeTracking("")
+
+	if got := SelectModelForTask("bug", "fix the crash"); got != "llama3.1" {
+		t.Fatalf("without a track record the selector's choice should stand, got %s", got)
+	}
+
+	// llama3.1's bug fixes keep failing or getting rejected; codellama's get merged
+	for _, outcome := range []Outcome{OutcomeFailed, OutcomeRejected, OutcomeFailed, OutcomeMerged} {
+		RecordOutcome("llama3.1", "bug", outcome)
+	}
+	for _, outcome := range []Outcome{OutcomeMerged, OutcomeMerged, OutcomeMerged, OutcomeRejected} {
+		RecordOutcome("codellama", "bug", outcome)
+	}
+
+	if got := SelectModelForTask("bug", "fix the crash"); got != "codellama" {
+		t.Fatalf("expected the model with the better bug record, got %s", got)
+	}
+	if got := SelectModelForTask("feature", "add a flag"); got != "llama3.1" {
+		t.Fatalf("a record on bugs shouldn't affect other task types, got %s", got)
+	}
+
+	// The record survives a restart
+	EnableOutcomeTracking(path)
+	if record := ModelRecords("bug")["codellama"]; record.Merged != 3 || record.Rejected != 1 {
+		t.Fatalf("outcomes were not persisted, got %+v", record)
+	}
+}
@@ -4,21 +4,60 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/anthonyrawlins/bzzz/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
-	ollamaAPIURL    = "http://localhost:11434/api/generate"
 	defaultTimeout  = 60 * time.Second
+	warmUpTimeout   = 5 * time.Minute // Loading a large model into VRAM can take minutes
 )
 
+// ErrReasoningTimeout is wrapped when a generation runs past its deadline
+var ErrReasoningTimeout = errors.New("reasoning request timed out")
+
+// ErrModelLoadFailed is wrapped when Ollama couldn't load the model, usually
+// because it doesn't fit in the memory left on the host
+var ErrModelLoadFailed = errors.New("model failed to load")
+
+// modelLoadFailures are fragments of Ollama errors that mean the model couldn't be loaded
+var modelLoadFailures = []string{
+	"out of memory",
+	"requires more system memory",
+	"failed to load model",
+	"unable to allocate",
+	"llama runner process has terminated",
+}
+
+// ollamaAPIURL is a variable so tests can point it at a fake Ollama
+var ollamaAPIURL = "http://localhost:11434/api/generate"
+
 var (
 	availableModels []string
 	modelWebhookURL string
 	defaultModel    string
+	reviewerModel   string // Second model that reviews the agent's work; empty disables review
+
+	// Closed once SetModelConfig has been called
+	configured     = make(chan struct{})
+	configuredOnce sync.Once
+
+	// How long a generation may take when the caller doesn't say; see SetDefaultTimeout
+	generateTimeout = defaultTimeout
+
+	// Successful generations per model, for telemetry
+	modelUsage     = make(map[string]int)
+	modelUsageLock sync.Mutex
 )
 
 // OllamaRequest represents the request payload for the Ollama API.
@@ -34,13 +73,109 @@ type OllamaResponse struct {
 	CreatedAt time.Time `json:"created_at"`
 	Response  string    `json:"response"`
 	Done      bool      `json:"done"`
+
+	// Usage reported once generation is done
+	PromptEvalCount int   `json:"prompt_eval_count"`
+	EvalCount       int   `json:"eval_count"`
+	TotalDuration   int64 `json:"total_duration"` // Nanoseconds
+}
+
+// GenerationStats is what a single generation cost
+type GenerationStats struct {
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Duration         time.Duration // Wall-clock model time as reported by Ollama
+}
+
+// Tokens returns prompt and completion tokens together
+func (s GenerationStats) Tokens() int {
+	return s.PromptTokens + s.CompletionTokens
+}
+
+// StatsObserver is told about every generation made with a context that carries it
+type StatsObserver func(stats GenerationStats)
+
+type statsObserverKey struct{}
+
+// WithStatsObserver returns a context whose generations are reported to observer,
+// e.g. so a task's model usage can be counted against its budget
+func WithStatsObserver(ctx context.Context, observer StatsObserver) context.Context {
+	return context.WithValue(ctx, statsObserverKey{}, observer)
+}
+
+// GenerateOptions tunes a single generation
+type GenerateOptions struct {
+	Timeout time.Duration // How long the generation may take; 0 means the default timeout
+}
+
+// SetDefaultTimeout sets how long generations may take unless their caller says
+// otherwise. 0 restores the built-in 60s.
+func SetDefaultTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	generateTimeout = timeout
 }
 
 // GenerateResponse queries the Ollama API with a given prompt and model,
 // and returns the complete generated response as a single string.
+// Identical prompts are answered from the cache when EnableCache has been called,
+// and a lighter model stands in while EnableLoadAwareSelection finds the host busy.
 func GenerateResponse(ctx context.Context, model, prompt string) (string, error) {
+	return GenerateResponseWithOptions(ctx, model, prompt, GenerateOptions{})
+}
+
+// GenerateResponseWithOptions is GenerateResponse with per-call options, e.g. to
+// give code generation longer than a quick classification
+func GenerateResponseWithOptions(ctx context.Context, model, prompt string, opts GenerateOptions) (_ string, err error) {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = generateTimeout
+	}
+	model = underLoad(model)
+	ctx, span := tracing.Start(ctx, "reasoning.generate", attribute.String("reasoning.model", model))
+	defer func() { tracing.End(span, err) }()
+
+	responses := activeCache()
+	if responses != nil {
+		if response, hit := responses.get(cacheKey(model, prompt)); hit {
+			span.SetAttributes(attribute.Bool("reasoning.cached", true))
+			return response, nil
+		}
+	}
+
+	response, err := generate(ctx, model, prompt, timeout)
+	for _, fallback := range fallbackModels(model) {
+		if !errors.Is(err, ErrModelLoadFailed) {
+			break
+		}
+		fmt.Printf("⬇️ Model %s failed to load, retrying with %s: %v\n", model, fallback, err)
+		span.SetAttributes(attribute.String("reasoning.fallback_model", fallback))
+		model = fallback
+		response, err = generate(ctx, model, prompt, timeout)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	modelUsageLock.Lock()
+	modelUsage[model]++
+	modelUsageLock.Unlock()
+
+	if responses != nil {
+		responses.put(cacheKey(model, prompt), response) // The model that answered, after any fallback
+	}
+	return response, nil
+}
+
+// generate sends a single non-streaming generate request to Ollama
+func generate(ctx context.Context, model, prompt string, timeout time.Duration) (string, error) {
+	ownGenerations.Add(1)
+	defer ownGenerations.Add(-1)
+
 	// Set up a timeout for the request
-	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	// Create the request payload
@@ -63,32 +198,91 @@ func GenerateResponse(ctx context.Context, model, prompt string) (string, error)
 	req.Header.Set("Content-Type", "application/json")
 
 	// Execute the request
+	start := time.Now()
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute http request to ollama: %w", err)
+		return "", timeoutError(ctx, fmt.Errorf("failed to execute http request to ollama: %w", err))
 	}
 	defer resp.Body.Close()
 
 	// Check for non-200 status codes
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("ollama api returned non-200 status: %d - %s", resp.StatusCode, string(bodyBytes))
+		err := fmt.Errorf("ollama api returned non-200 status: %d - %s", resp.StatusCode, string(bodyBytes))
+		if isModelLoadFailure(string(bodyBytes)) {
+			return "", fmt.Errorf("%w: %w", ErrModelLoadFailed, err)
+		}
+		return "", err
 	}
 
 	// Decode the JSON response
 	var ollamaResp OllamaResponse
 	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
-		return "", fmt.Errorf("failed to decode ollama response: %w", err)
+		return "", timeoutError(ctx, fmt.Errorf("failed to decode ollama response: %w", err))
+	}
+
+	if observer, ok := ctx.Value(statsObserverKey{}).(StatsObserver); ok {
+		duration := time.Duration(ollamaResp.TotalDuration)
+		if duration == 0 {
+			duration = time.Since(start)
+		}
+		observer(GenerationStats{
+			Model:            model,
+			PromptTokens:     ollamaResp.PromptEvalCount,
+			CompletionTokens: ollamaResp.EvalCount,
+			Duration:         duration,
+		})
 	}
 
 	return ollamaResp.Response, nil
 }
 
+// WarmUpModels asks Ollama to load each model with an empty prompt, so the
+// first real request doesn't pay for loading it into VRAM.
+func WarmUpModels(ctx context.Context, models []string) {
+	for _, model := range models {
+		start := time.Now()
+		if _, err := generate(ctx, model, "", warmUpTimeout); err != nil {
+			fmt.Printf("⚠️ Failed to warm up model %s: %v\n", model, err)
+			continue
+		}
+		fmt.Printf("🔥 Warmed up model %s in %v\n", model, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// ModelUsage returns how many successful generations each model has served
+func ModelUsage() map[string]int {
+	modelUsageLock.Lock()
+	defer modelUsageLock.Unlock()
+
+	usage := make(map[string]int, len(modelUsage))
+	for model, count := range modelUsage {
+		usage[model] = count
+	}
+	return usage
+}
+
 // SetModelConfig configures the available models and webhook URL for smart model selection
 func SetModelConfig(models []string, webhookURL, defaultReasoningModel string) {
 	availableModels = models
 	modelWebhookURL = webhookURL
 	defaultModel = defaultReasoningModel
+	configuredOnce.Do(func() { close(configured) })
+}
+
+// Configured returns a channel that is closed once the models have been configured
+func Configured() <-chan struct{} {
+	return configured
+}
+
+// SetReviewerModel sets the model used to review the agent's changes before a PR is opened
+func SetReviewerModel(model string) {
+	reviewerModel = model
+}
+
+// ReviewerModel returns the configured reviewer model, or "" if review is disabled
+func ReviewerModel() string {
+	return reviewerModel
 }
 
 // selectBestModel calls the model selection webhook to choose the best model for a prompt
@@ -146,6 +340,70 @@ func selectBestModel(availableModels []string, prompt string) string {
 
 // GenerateResponseSmart automatically selects the best model for the prompt
 func GenerateResponseSmart(ctx context.Context, prompt string) (string, error) {
-	selectedModel := selectBestModel(availableModels, prompt)
-	return GenerateResponse(ctx, selectedModel, prompt)
+	return GenerateResponse(ctx, SelectModel(prompt), prompt)
+}
+
+// SelectModel returns the model GenerateResponseSmart would use for the prompt.
+// Each generate call swaps it for a lighter one while the host is under load.
+func SelectModel(prompt string) string {
+	return selectBestModel(availableModels, prompt)
+}
+
+// isModelLoadFailure reports whether an Ollama error body says the model couldn't be loaded
+func isModelLoadFailure(body string) bool {
+	body = strings.ToLower(body)
+	for _, fragment := range modelLoadFailures {
+		if strings.Contains(body, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// fallbackModels lists the models to retry with, in order, when model fails to
+// load: the configured default first, then the available models smaller than
+// model, smallest first
+func fallbackModels(model string) []string {
+	size := modelSize(model)
+	var smaller []string
+	for _, candidate := range availableModels {
+		if candidate == model || candidate == defaultModel {
+			continue
+		}
+		if candidateSize := modelSize(candidate); candidateSize > 0 && (size == 0 || candidateSize < size) {
+			smaller = append(smaller, candidate)
+		}
+	}
+	sort.SliceStable(smaller, func(i, j int) bool { return modelSize(smaller[i]) < modelSize(smaller[j]) })
+
+	if defaultModel != "" && defaultModel != model {
+		return append([]string{defaultModel}, smaller...)
+	}
+	return smaller
+}
+
+// modelSize returns the parameter count in billions from a model tag such as
+// "llama3.1:70b" or "qwen2.5:0.5b", or 0 when the tag doesn't say
+func modelSize(model string) float64 {
+	_, tag, found := strings.Cut(model, ":")
+	if !found {
+		return 0
+	}
+	tag, _, _ = strings.Cut(tag, "-") // e.g. "8b-instruct-q4_0"
+	if !strings.HasSuffix(tag, "b") {
+		return 0
+	}
+	size, err := strconv.ParseFloat(strings.TrimSuffix(tag, "b"), 64)
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
+// timeoutError marks err as ErrReasoningTimeout when ctx's deadline has passed
+func timeoutError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrReasoningTimeout, err)
+	}
+	return err
 }
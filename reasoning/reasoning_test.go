@@ -0,0 +1,218 @@
+package reasoning
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWarmUpModelsLoadsEachModel(t *testing.T) {
+	var mu sync.Mutex
+	var requests []OllamaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+		json.NewEncoder(w).Encode(OllamaResponse{Model: req.Model, Done: true})
+	}))
+	defer server.Close()
+
+	previousURL := ollamaAPIURL
+	ollamaAPIURL = server.URL + "/api/generate"
+	defer func() { ollamaAPIURL = previousURL }()
+
+	WarmUpModels(context.Background(), []string{"phi3", "llama3.1"})
+
+	if len(requests) != 2 || requests[0].Model != "phi3" || requests[1].Model != "llama3.1" {
+		t.Fatalf("expected a warm-up generate for phi3 and llama3.1, got %+v", requests)
+	}
+	for _, req := range requests {
+		if req.Prompt != "" || req.Stream {
+			t.Errorf("warm-up for %s should be an empty, non-streaming generate: %+v", req.Model, req)
+		}
+	}
+
+	// Warm-ups aren't real work and shouldn't show up in telemetry
+	if usage := ModelUsage(); usage["phi3"] != 0 || usage["llama3.1"] != 0 {
+		t.Errorf("warm-up was counted as model usage: %v", usage)
+	}
+}
+
+func TestGenerateReportsStatsToObserver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(OllamaResponse{Model: "phi3", Response: "ls", Done: true, PromptEvalCount: 120, EvalCount: 8, TotalDuration: 1500000000})
+	}))
+	defer server.Close()
+
+	previousURL := ollamaAPIURL
+	ollamaAPIURL = server.URL + "/api/generate"
+	defer func() { ollamaAPIURL = previousURL }()
+
+	var observed []GenerationStats
+	ctx := WithStatsObserver(context.Background(), func(stats GenerationStats) {
+		observed = append(observed, stats)
+	})
+	if _, err := GenerateResponse(ctx, "phi3", "what next?"); err != nil {
+		t.Fatalf("GenerateResponse failed: %v", err)
+	}
+
+	if len(observed) != 1 {
+		t.Fatalf("expected one generation to be observed, got %d", len(observed))
+	}
+	if stats := observed[0]; stats.Model != "phi3" || stats.Tokens() != 128 || stats.Duration != 1500*time.Millisecond {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestGenerateTimeoutIsErrReasoningTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	previousURL := ollamaAPIURL
+	ollamaAPIURL = server.URL + "/api/generate"
+	defer func() { ollamaAPIURL = previousURL }()
+
+	_, err := generate(context.Background(), "phi3", "what next?", 50*time.Millisecond)
+	if !errors.Is(err, ErrReasoningTimeout) {
+		t.Fatalf("expected ErrReasoningTimeout, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the underlying deadline error to be kept, got %v", err)
+	}
+}
+
+func TestIdenticalPromptsAreServedFromCache(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		json.NewEncoder(w).Encode(OllamaResponse{Model: "phi3", Response: "ls", Done: true})
+	}))
+	defer server.Close()
+
+	previousURL := ollamaAPIURL
+	ollamaAPIURL = server.URL + "/api/generate"
+	defer func() { ollamaAPIURL = previousURL }()
+	EnableCache(time.Minute, 1)
+	defer EnableCache(0, 0)
+
+	for i := 0; i < 2; i++ {
+		if response, err := GenerateResponse(context.Background(), "phi3", "what next?"); err != nil || response != "ls" {
+			t.Fatalf("GenerateResponse = %q, %v", response, err)
+		}
+	}
+	mu.Lock()
+	if requests != 1 {
+		t.Errorf("expected the second identical prompt to hit the cache, Ollama saw %d requests", requests)
+	}
+	mu.Unlock()
+
+	// A different prompt evicts the only entry
+	GenerateResponse(context.Background(), "phi3", "and then?")
+	GenerateResponse(context.Background(), "phi3", "what next?")
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 3 {
+		t.Errorf("expected the evicted prompt to reach Ollama again, saw %d requests", requests)
+	}
+}
+
+func TestModelThatRunsOutOfMemoryFallsBackToSmallerModel(t *testing.T) {
+	var mu sync.Mutex
+	var tried []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		tried = append(tried, req.Model)
+		mu.Unlock()
+		if req.Model == "llama3.1:70b" {
+			http.Error(w, `{"error":"model requires more system memory (41.2 GiB) than is available (15.1 GiB)"}`, http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(OllamaResponse{Model: req.Model, Response: "git status", Done: true})
+	}))
+	defer server.Close()
+
+	previousURL := ollamaAPIURL
+	ollamaAPIURL = server.URL + "/api/generate"
+	defer func() { ollamaAPIURL = previousURL }()
+
+	SetModelConfig([]string{"llama3.1:70b", "qwen2.5:14b", "llama3.1:8b"}, "", "")
+	defer SetModelConfig(nil, "", "")
+	EnableCache(time.Minute, 10)
+	defer EnableCache(0, 0)
+
+	response, err := GenerateResponse(context.Background(), "llama3.1:70b", "what next after the OOM?")
+	if err != nil {
+		t.Fatalf("expected the generate to succeed on a smaller model, got %v", err)
+	}
+	if response != "git status" {
+		t.Errorf("unexpected response %q", response)
+	}
+	if len(tried) != 2 || tried[0] != "llama3.1:70b" || tried[1] != "llama3.1:8b" {
+		t.Fatalf("expected a retry with the smallest available model, tried %v", tried)
+	}
+
+	// The answer is cached as the smaller model's, not the one that ran out of memory
+	GenerateResponse(context.Background(), "llama3.1:8b", "what next after the OOM?")
+	GenerateResponse(context.Background(), "llama3.1:70b", "what next after the OOM?")
+	if len(tried) != 4 || tried[2] != "llama3.1:70b" {
+		t.Fatalf("expected only the 70b prompt to reach Ollama again, tried %v", tried)
+	}
+
+	// The configured default is preferred over the available list
+	if got := fallbackModels("llama3.1:70b"); len(got) != 2 || got[0] != "llama3.1:8b" || got[1] != "qwen2.5:14b" {
+		t.Errorf("unexpected fallback order without a default: %v", got)
+	}
+	SetModelConfig([]string{"llama3.1:70b", "qwen2.5:14b", "llama3.1:8b"}, "", "phi3")
+	if got := fallbackModels("llama3.1:70b"); len(got) == 0 || got[0] != "phi3" {
+		t.Errorf("expected the default model to be tried first, got %v", got)
+	}
+}
+
+func TestPerCallTimeoutOverridesDefault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond): // A slow model
+		case <-r.Context().Done():
+			return
+		}
+		fmt.Fprint(w, `{"model":"phi3","response":"go test ./...","done":true}`)
+	}))
+	defer server.Close()
+
+	previousURL := ollamaAPIURL
+	ollamaAPIURL = server.URL + "/api/generate"
+	defer func() { ollamaAPIURL = previousURL }()
+
+	// The default is too short for this model, but a per-call timeout wins either way
+	SetDefaultTimeout(10 * time.Millisecond)
+	defer SetDefaultTimeout(0)
+
+	_, err := GenerateResponseWithOptions(context.Background(), "phi3", "classify this", GenerateOptions{Timeout: 20 * time.Millisecond})
+	if !errors.Is(err, ErrReasoningTimeout) {
+		t.Fatalf("expected the short per-call timeout to cancel the request, got %v", err)
+	}
+	response, err := GenerateResponseWithOptions(context.Background(), "phi3", "write the code", GenerateOptions{Timeout: 5 * time.Second})
+	if err != nil || response != "go test ./..." {
+		t.Fatalf("expected the long per-call timeout to succeed, got %q, %v", response, err)
+	}
+}
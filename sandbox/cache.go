@@ -0,0 +1,127 @@
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+)
+
+const (
+	cacheRoot  = "/home/agent/.cache/bzzz" // Where dependency caches are mounted inside the container
+	sandboxUID = 1000                      // The agent user's uid and gid in Dockerfile.sandbox
+)
+
+// cacheLanguage says where a language's tools keep their download cache
+type cacheLanguage struct {
+	env []string // Points the tools at the mounted cache, relative to its mount point
+	// The tools lock their own cache files, so sandboxes can share it at once
+	concurrentSafe bool
+}
+
+// cacheLanguages are the languages a shared dependency cache can be configured for
+var cacheLanguages = map[string]cacheLanguage{
+	"go":  {env: []string{"GOMODCACHE=%s/mod", "GOCACHE=%s/build"}, concurrentSafe: true},
+	"npm": {env: []string{"npm_config_cache=%s"}},
+	"pip": {env: []string{"PIP_CACHE_DIR=%s"}},
+}
+
+// cacheMount is a dependency cache directory a sandbox has mounted
+type cacheMount struct {
+	Language string
+	HostPath string
+	Target   string   // Mount point inside the container
+	Env      []string // Variables that point the language's tools at the cache
+	lock     *os.File // Held for caches that can't be shared concurrently
+}
+
+// acquireCaches prepares the configured dependency caches (language -> host
+// directory) for a new sandbox. Tools that don't lock their own cache could
+// corrupt it if two sandboxes wrote at once, so those caches are held exclusively;
+// one that another sandbox holds is left out and the task downloads afresh.
+// Untrusted sandboxes get caches of their own beside the configured ones, so
+// nothing they download can end up in a trusted task's build.
+func acquireCaches(caches map[string]string, untrusted bool) ([]*cacheMount, error) {
+	languages := make([]string, 0, len(caches))
+	for language := range caches {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	var mounts []*cacheMount
+	for _, language := range languages {
+		spec, ok := cacheLanguages[language]
+		if !ok {
+			releaseCaches(mounts)
+			return nil, fmt.Errorf("no dependency cache support for language %q", language)
+		}
+		hostPath := caches[language]
+		if untrusted {
+			hostPath = filepath.Clean(hostPath) + "-untrusted"
+		}
+		if err := ensureCacheDir(hostPath); err != nil {
+			releaseCaches(mounts)
+			return nil, err
+		}
+
+		mount := &cacheMount{Language: language, HostPath: hostPath, Target: cacheRoot + "/" + language}
+		for _, env := range spec.env {
+			mount.Env = append(mount.Env, fmt.Sprintf(env, mount.Target))
+		}
+		if !spec.concurrentSafe {
+			lock, err := lockCache(hostPath)
+			if err != nil {
+				fmt.Printf("⚠️ %s dependency cache %s is in use by another sandbox, running without it\n", language, hostPath)
+				continue
+			}
+			mount.lock = lock
+		}
+		mounts = append(mounts, mount)
+	}
+	return mounts, nil
+}
+
+// releaseCaches lets other sandboxes use caches this one held exclusively
+func releaseCaches(mounts []*cacheMount) {
+	for _, mount := range mounts {
+		if mount.lock != nil {
+			syscall.Flock(int(mount.lock.Fd()), syscall.LOCK_UN)
+			mount.lock.Close()
+			mount.lock = nil
+		}
+	}
+}
+
+// ensureCacheDir creates a cache directory only the container's agent user can
+// write to
+func ensureCacheDir(hostPath string) error {
+	if _, err := os.Stat(hostPath); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(hostPath, 0o700); err != nil {
+		return fmt.Errorf("failed to create dependency cache %s: %w", hostPath, err)
+	}
+	// The agent user in the container isn't necessarily the user we run as
+	if os.Geteuid() == sandboxUID {
+		return nil
+	}
+	if err := os.Chown(hostPath, sandboxUID, sandboxUID); err != nil {
+		return fmt.Errorf("failed to hand dependency cache %s to the sandbox user, create it owned by uid %d: %w", hostPath, sandboxUID, err)
+	}
+	return nil
+}
+
+// lockCache takes an exclusive lock on a cache directory without waiting. The lock
+// is on a file beside the cache contents so it also excludes other agents on the host.
+func lockCache(hostPath string) (*os.File, error) {
+	lock, err := os.OpenFile(filepath.Join(hostPath, ".bzzz-lock"), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		lock.Close()
+		return nil, err
+	}
+	return lock, nil
+}
@@ -0,0 +1,85 @@
+package sandbox
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// envNamePattern is what a task-declared variable name may look like
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedEnv are set by the sandbox itself and can't be overridden by a task
+var reservedEnv = map[string]bool{
+	"GITHUB_TOKEN": true, "GH_TOKEN": true,
+	"HTTP_PROXY": true, "HTTPS_PROXY": true, "http_proxy": true, "https_proxy": true,
+	"HOME": true, "PATH": true, "USER": true,
+}
+
+// WithTaskEnv passes the environment variables a task declares into the container.
+// Only names on the allowlist are kept, so an issue author can't set arbitrary
+// variables; an entry ending in * allows every name with that prefix. Values are
+// redacted from command output.
+func WithTaskEnv(requested map[string]string, allowlist []string) Option {
+	return func(o *Options) {
+		names := make([]string, 0, len(requested))
+		for name := range requested {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if !envNamePattern.MatchString(name) || reservedEnv[name] || !envAllowed(name, allowlist) {
+				fmt.Printf("🚫 Dropping task environment variable %s: not allowlisted\n", name)
+				continue
+			}
+			if o.Env == nil {
+				o.Env = make(map[string]string)
+			}
+			o.Env[name] = requested[name]
+		}
+	}
+}
+
+// envAllowed reports whether the allowlist lets a task set a variable
+func envAllowed(name string, allowlist []string) bool {
+	for _, allowed := range allowlist {
+		if prefix, isPrefix := strings.CutSuffix(allowed, "*"); isPrefix && prefix != "" && strings.HasPrefix(name, prefix) {
+			return true
+		}
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// taskEnvList formats task variables for the container, in a stable order
+func taskEnvList(env map[string]string) []string {
+	list := make([]string, 0, len(env))
+	for name, value := range env {
+		list = append(list, name+"="+value)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// minRedactedLength keeps short values like build flags ("1", "true") from
+// being replaced everywhere they happen to appear
+const minRedactedLength = 6
+
+// redactor hides task variable values from command output before it is logged
+// or shown to the model
+func redactor(env map[string]string) *strings.Replacer {
+	var pairs []string
+	for name, value := range env {
+		if len(value) >= minRedactedLength {
+			pairs = append(pairs, value, "[REDACTED:"+name+"]")
+		}
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+	return strings.NewReplacer(pairs...)
+}
@@ -0,0 +1,111 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// imageClient is the subset of the Docker client needed to manage sandbox images.
+type imageClient interface {
+	ImageInspect(ctx context.Context, imageID string, inspectOpts ...client.ImageInspectOption) (image.InspectResponse, error)
+	ImagePull(ctx context.Context, refStr string, options image.PullOptions) (io.ReadCloser, error)
+}
+
+// pullProgress is a single message from the Docker image pull progress stream.
+type pullProgress struct {
+	Status      string `json:"status"`
+	ID          string `json:"id"`
+	ErrorDetail *struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+}
+
+// ImagePull makes sure a sandbox image is available locally, pulling it if it is
+// missing. platform (os/arch[/variant]) selects the variant; empty takes the default.
+func ImagePull(ctx context.Context, image, platform string) error {
+	wanted, err := parsePlatform(platform)
+	if err != nil {
+		return err
+	}
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return fmt.Errorf("failed to create docker client: %w", err)
+	}
+	defer cli.Close()
+
+	_, err = ensureImage(ctx, cli, image, wanted)
+	return err
+}
+
+// ensureImage pulls an image unless it is already present in the local cache for
+// the wanted platform, which may be nil for any. It returns the platform of the
+// local image, nil if Docker doesn't report one.
+func ensureImage(ctx context.Context, cli imageClient, ref string, platform *ocispec.Platform) (*ocispec.Platform, error) {
+	inspect, err := cli.ImageInspect(ctx, ref)
+	switch {
+	case err == nil && platformMatches(inspect, platform):
+		fmt.Printf("📦 Sandbox image %s already present, skipping pull\n", ref)
+		return imagePlatform(inspect), nil
+	case err == nil:
+		fmt.Printf("🔀 Sandbox image %s is present for %s, pulling the %s variant\n", ref, formatPlatform(imagePlatform(inspect)), formatPlatform(platform))
+	case !client.IsErrNotFound(err):
+		return nil, fmt.Errorf("failed to inspect image %s: %w", ref, err)
+	}
+
+	if err := pullImage(ctx, cli, ref, platform); err != nil {
+		return nil, err
+	}
+
+	// A registry without the wanted variant may hand back another one
+	inspect, err = cli.ImageInspect(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect image %s: %w", ref, err)
+	}
+	if !platformMatches(inspect, platform) {
+		return nil, fmt.Errorf("sandbox image %s is not available for platform %s (got %s); build or push a %s variant or set agent.sandbox.platform",
+			ref, formatPlatform(platform), formatPlatform(imagePlatform(inspect)), formatPlatform(platform))
+	}
+	return imagePlatform(inspect), nil
+}
+
+// pullImage pulls an image, reporting progress as layers arrive
+func pullImage(ctx context.Context, cli imageClient, ref string, platform *ocispec.Platform) error {
+	fmt.Printf("⬇️  Pulling sandbox image %s...\n", ref)
+	reader, err := cli.ImagePull(ctx, ref, image.PullOptions{Platform: formatPlatform(platform)})
+	if err != nil {
+		if platform != nil {
+			return fmt.Errorf("failed to pull image %s for platform %s: %w", ref, formatPlatform(platform), err)
+		}
+		return fmt.Errorf("failed to pull image %s: %w", ref, err)
+	}
+	defer reader.Close()
+
+	// Report per-layer progress as the pull stream reaches each milestone
+	layers := 0
+	decoder := json.NewDecoder(reader)
+	for {
+		var msg pullProgress
+		if err := decoder.Decode(&msg); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to read pull progress for %s: %w", ref, err)
+		}
+
+		if msg.ErrorDetail != nil {
+			return fmt.Errorf("failed to pull image %s: %s", ref, msg.ErrorDetail.Message)
+		}
+		if msg.Status == "Pull complete" || msg.Status == "Already exists" {
+			layers++
+			fmt.Printf("   %s layer %s: %s (%d done)\n", ref, msg.ID, msg.Status, layers)
+		}
+	}
+
+	fmt.Printf("✅ Sandbox image %s pulled successfully.\n", ref)
+	return nil
+}
@@ -0,0 +1,87 @@
+package sandbox
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+)
+
+type fakeImageClient struct {
+	present   map[string]bool
+	pulled    []string
+	platforms []string // Platform asked for on each pull
+	arch      string   // Architecture of pulled images, amd64 if empty
+}
+
+func (f *fakeImageClient) ImageInspect(ctx context.Context, imageID string, _ ...client.ImageInspectOption) (image.InspectResponse, error) {
+	if f.present[imageID] {
+		arch := f.arch
+		if arch == "" {
+			arch = "amd64"
+		}
+		return image.InspectResponse{ID: imageID, Os: "linux", Architecture: arch}, nil
+	}
+	return image.InspectResponse{}, errdefs.NotFound(io.EOF)
+}
+
+func (f *fakeImageClient) ImagePull(ctx context.Context, refStr string, options image.PullOptions) (io.ReadCloser, error) {
+	f.pulled = append(f.pulled, refStr)
+	f.platforms = append(f.platforms, options.Platform)
+	f.present[refStr] = true
+	stream := `{"status":"Pulling fs layer","id":"abc"}` + "\n" + `{"status":"Pull complete","id":"abc"}` + "\n"
+	return io.NopCloser(strings.NewReader(stream)), nil
+}
+
+func TestEnsureImageSkipsPresentImage(t *testing.T) {
+	cli := &fakeImageClient{present: map[string]bool{"bzzz-sandbox:latest": true}}
+
+	if _, err := ensureImage(context.Background(), cli, "bzzz-sandbox:latest", nil); err != nil {
+		t.Fatalf("ensureImage returned error: %v", err)
+	}
+	if len(cli.pulled) != 0 {
+		t.Fatalf("expected no pull for a cached image, got %v", cli.pulled)
+	}
+}
+
+func TestEnsureImagePullsMissingImage(t *testing.T) {
+	cli := &fakeImageClient{present: map[string]bool{}}
+
+	if _, err := ensureImage(context.Background(), cli, "bzzz-sandbox:latest", nil); err != nil {
+		t.Fatalf("ensureImage returned error: %v", err)
+	}
+	if len(cli.pulled) != 1 || cli.pulled[0] != "bzzz-sandbox:latest" {
+		t.Fatalf("expected a single pull of the missing image, got %v", cli.pulled)
+	}
+}
+
+func TestEnsureImagePullsTheConfiguredPlatform(t *testing.T) {
+	// The cached image was built for amd64, but this node runs arm64
+	cli := &fakeImageClient{present: map[string]bool{"bzzz-sandbox:latest": true}, arch: "amd64"}
+	wanted, err := parsePlatform("linux/arm64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ensureImage(context.Background(), cli, "bzzz-sandbox:latest", wanted)
+	if len(cli.platforms) != 1 || cli.platforms[0] != "linux/arm64" {
+		t.Fatalf("expected the arm64 variant to be pulled, got pulls for %v", cli.platforms)
+	}
+	// The fake registry only has amd64, so the mismatch must be reported clearly
+	if err == nil || !strings.Contains(err.Error(), "not available for platform linux/arm64") {
+		t.Fatalf("expected a missing platform error, got %v", err)
+	}
+
+	cli.arch = "arm64"
+	got, err := ensureImage(context.Background(), cli, "bzzz-sandbox:latest", wanted)
+	if err != nil {
+		t.Fatalf("ensureImage returned error: %v", err)
+	}
+	if formatPlatform(got) != "linux/arm64" {
+		t.Fatalf("expected the local image to be linux/arm64, got %s", formatPlatform(got))
+	}
+}
@@ -0,0 +1,53 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+)
+
+// networkClient is the subset of the Docker client needed to prepare sandbox networks
+type networkClient interface {
+	NetworkInspect(ctx context.Context, networkID string, options network.InspectOptions) (network.Inspect, error)
+	NetworkCreate(ctx context.Context, name string, options network.CreateOptions) (network.CreateResponse, error)
+}
+
+// WithRestrictedNetwork attaches the container to an internal network whose only
+// way out is the egress proxy, for tasks we don't trust. The network is created
+// if it doesn't exist; the proxy must be attached to it for git to reach GitHub.
+func WithRestrictedNetwork(name, proxyURL string) Option {
+	return func(o *Options) {
+		o.NetworkMode = name
+		o.ProxyURL = proxyURL
+		o.restricted = true
+	}
+}
+
+// ensureRestrictedNetwork creates the internal network a restricted sandbox joins,
+// unless it already exists
+func ensureRestrictedNetwork(ctx context.Context, cli networkClient, name string) error {
+	if name == "" || name == NetworkModeNone || name == NetworkModeBridge {
+		return nil
+	}
+	_, err := cli.NetworkInspect(ctx, name, network.InspectOptions{})
+	if err == nil {
+		return nil
+	}
+	if !client.IsErrNotFound(err) {
+		return fmt.Errorf("failed to inspect sandbox network %s: %w", name, err)
+	}
+
+	fmt.Printf("🔒 Creating internal sandbox network %s\n", name)
+	_, err = cli.NetworkCreate(ctx, name, network.CreateOptions{
+		Driver:   "bridge",
+		Internal: true,
+		Labels:   map[string]string{"bzzz.sandbox": "restricted"},
+	})
+	if err != nil && !errdefs.IsConflict(err) { // Another sandbox may have just created it
+		return fmt.Errorf("failed to create sandbox network %s: %w", name, err)
+	}
+	return nil
+}
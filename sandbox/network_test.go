@@ -0,0 +1,56 @@
+package sandbox
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+)
+
+type fakeNetworkClient struct {
+	existing map[string]bool
+	created  []network.CreateOptions
+}
+
+func (f *fakeNetworkClient) NetworkInspect(ctx context.Context, networkID string, options network.InspectOptions) (network.Inspect, error) {
+	if f.existing[networkID] {
+		return network.Inspect{Name: networkID}, nil
+	}
+	return network.Inspect{}, errdefs.NotFound(io.EOF)
+}
+
+func (f *fakeNetworkClient) NetworkCreate(ctx context.Context, name string, options network.CreateOptions) (network.CreateResponse, error) {
+	f.existing[name] = true
+	f.created = append(f.created, options)
+	return network.CreateResponse{ID: name}, nil
+}
+
+func TestRestrictedNetworkIsCreatedInternalOnce(t *testing.T) {
+	cli := &fakeNetworkClient{existing: map[string]bool{}}
+
+	for i := 0; i < 2; i++ {
+		if err := ensureRestrictedNetwork(context.Background(), cli, "bzzz-untrusted"); err != nil {
+			t.Fatalf("ensureRestrictedNetwork failed: %v", err)
+		}
+	}
+	if len(cli.created) != 1 || !cli.created[0].Internal {
+		t.Fatalf("expected one internal network created, got %+v", cli.created)
+	}
+
+	if err := ensureRestrictedNetwork(context.Background(), cli, NetworkModeNone); err != nil || len(cli.created) != 1 {
+		t.Errorf("expected built-in modes to be left alone, err %v", err)
+	}
+
+	// The container joins the network with the proxy as its way out
+	options := &Options{}
+	WithRestrictedNetwork("bzzz-untrusted", "http://bzzz-egress-proxy:3128")(options)
+	containerConfig, hostConfig, networkingConfig := buildContainerConfig("img", "/tmp/work", "", options)
+	if string(hostConfig.NetworkMode) != "bzzz-untrusted" || networkingConfig == nil || networkingConfig.EndpointsConfig["bzzz-untrusted"] == nil {
+		t.Errorf("expected the container attached to the restricted network, got %q", hostConfig.NetworkMode)
+	}
+	if !containsString(containerConfig.Env, "HTTPS_PROXY=http://bzzz-egress-proxy:3128") {
+		t.Errorf("expected the proxy passed to the container, got %v", containerConfig.Env)
+	}
+}
@@ -0,0 +1,66 @@
+package sandbox
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types/image"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// parsePlatform reads an os/arch[/variant] platform such as linux/arm64. An empty
+// string means no particular platform.
+func parsePlatform(platform string) (*ocispec.Platform, error) {
+	if platform == "" {
+		return nil, nil
+	}
+	parts := strings.Split(platform, "/")
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("invalid platform %q: want os/arch or os/arch/variant", platform)
+		}
+	}
+	switch len(parts) {
+	case 2:
+		return &ocispec.Platform{OS: parts[0], Architecture: parts[1]}, nil
+	case 3:
+		return &ocispec.Platform{OS: parts[0], Architecture: parts[1], Variant: parts[2]}, nil
+	}
+	return nil, fmt.Errorf("invalid platform %q: want os/arch or os/arch/variant", platform)
+}
+
+// formatPlatform writes a platform the way Docker's pull API expects it
+func formatPlatform(platform *ocispec.Platform) string {
+	if platform == nil {
+		return ""
+	}
+	formatted := platform.OS + "/" + platform.Architecture
+	if platform.Variant != "" {
+		formatted += "/" + platform.Variant
+	}
+	return formatted
+}
+
+// imagePlatform is the platform a local image was built for, or nil if Docker didn't say
+func imagePlatform(inspect image.InspectResponse) *ocispec.Platform {
+	if inspect.Os == "" || inspect.Architecture == "" {
+		return nil
+	}
+	return &ocispec.Platform{OS: inspect.Os, Architecture: inspect.Architecture, Variant: inspect.Variant}
+}
+
+// platformMatches reports whether a local image can run as the wanted platform.
+// The variant is only compared when both sides name one.
+func platformMatches(inspect image.InspectResponse, want *ocispec.Platform) bool {
+	if want == nil {
+		return true
+	}
+	have := imagePlatform(inspect)
+	if have == nil {
+		return false
+	}
+	if have.OS != want.OS || have.Architecture != want.Architecture {
+		return false
+	}
+	return want.Variant == "" || have.Variant == "" || want.Variant == have.Variant
+}
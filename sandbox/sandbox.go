@@ -6,22 +6,33 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/anthonyrawlins/bzzz/pkg/config"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/stdcopy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 // Sandbox represents a stateful, isolated execution environment for a single task.
 type Sandbox struct {
-	ID          string // The ID of the running container.
-	HostPath    string // The path on the host machine mounted as the workspace.
-	Workspace   string // The path inside the container that is the workspace.
-	dockerCli   *client.Client
-	ctx         context.Context
+	ID             string // The ID of the running container.
+	HostPath       string // The path on the host machine mounted as the workspace.
+	Workspace      string // The path inside the container that is the workspace.
+	dockerCli      *client.Client
+	ctx            context.Context
+	commandTimeout time.Duration     // Default timeout applied by RunCommand
+	caches         []*cacheMount     // Shared dependency caches, released on destroy
+	redact         *strings.Replacer // Hides task environment values in command output; nil when there are none
+	releaseSlot    func()            // Frees this sandbox's host slot
 }
 
 // CommandResult holds the output of a command executed in the sandbox.
@@ -29,28 +40,124 @@ type CommandResult struct {
 	StdOut   string
 	StdErr   string
 	ExitCode int
+	TimedOut bool // The command was killed for exceeding its timeout
+}
+
+// timeoutExitCode is the status coreutils timeout exits with when it kills a command
+const timeoutExitCode = 124
+
+// commandKillGrace is how long past the timeout we wait before abandoning the exec
+const commandKillGrace = 10 * time.Second
+
+// Network modes for sandbox containers. Any other value is treated as the name
+// of a custom Docker network, e.g. an internal network fronted by an egress proxy.
+const (
+	NetworkModeNone   = "none"
+	NetworkModeBridge = "bridge"
+)
+
+// Options holds per-task sandbox settings.
+type Options struct {
+	NetworkMode string            // none, bridge or a custom network name
+	ProxyURL    string            // Allowlisted HTTP(S) proxy exposed to the container
+	GitHubToken string            // Token for git and gh in the container; empty uses the agent's default token
+	Env         map[string]string // Allowlisted variables the task declared
+
+	restricted bool          // NetworkMode is an internal network reached only through ProxyURL
+	caches     []*cacheMount // Shared dependency caches to mount
+}
+
+// Option is a function that modifies the sandbox options
+type Option func(*Options)
+
+// WithNetworkMode sets the network the container is attached to
+func WithNetworkMode(mode string) Option {
+	return func(o *Options) {
+		o.NetworkMode = mode
+	}
+}
+
+// WithProxy routes the container's HTTP(S) traffic through the given proxy
+func WithProxy(proxyURL string) Option {
+	return func(o *Options) {
+		o.ProxyURL = proxyURL
+	}
+}
+
+// WithGitHubToken authenticates the container with a specific GitHub token,
+// e.g. one mapped to the task repository's owner
+func WithGitHubToken(token string) Option {
+	return func(o *Options) {
+		o.GitHubToken = token
+	}
 }
 
 // CreateSandbox provisions a new Docker container for a task.
-func CreateSandbox(ctx context.Context, taskImage string, agentConfig *config.AgentConfig) (*Sandbox, error) {
+func CreateSandbox(ctx context.Context, taskImage string, agentConfig *config.AgentConfig, opts ...Option) (*Sandbox, error) {
 	if taskImage == "" {
 		taskImage = agentConfig.SandboxImage
 	}
 
+	options := &Options{NetworkMode: NetworkModeBridge}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	// Queue behind other tasks rather than overcommit the host
+	releaseSlot, err := hostSlots.acquire(ctx, agentConfig.Sandbox.MaxConcurrent)
+	if err != nil {
+		return nil, err
+	}
+	created := false
+	defer func() {
+		if !created {
+			releaseSlot()
+		}
+	}()
+
 	// Create a new Docker client
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create docker client: %w", err)
 	}
 
+	// Make sure the image is available locally, for the configured platform if there
+	// is one, before creating the container
+	platform, err := parsePlatform(agentConfig.Sandbox.Platform)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sandbox platform: %w", err)
+	}
+	localPlatform, err := ensureImage(ctx, cli, taskImage, platform)
+	if err != nil {
+		return nil, err
+	}
+	if platform == nil {
+		platform = localPlatform // Run the image as what it was built for, not the host's default
+	}
+	if options.restricted {
+		if err := ensureRestrictedNetwork(ctx, cli, options.NetworkMode); err != nil {
+			return nil, err
+		}
+	}
+
+	// Share dependency downloads between tasks; the workspace itself stays private
+	options.caches, err = acquireCaches(agentConfig.Sandbox.Caches, options.restricted)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create a temporary directory on the host
 	hostPath, err := os.MkdirTemp("", "bzzz-sandbox-")
 	if err != nil {
+		releaseCaches(options.caches)
 		return nil, fmt.Errorf("failed to create temp dir for sandbox: %w", err)
 	}
 
 	// Read GitHub token for authentication
-	githubToken := os.Getenv("BZZZ_GITHUB_TOKEN")
+	githubToken := options.GitHubToken
+	if githubToken == "" {
+		githubToken = os.Getenv("BZZZ_GITHUB_TOKEN")
+	}
 	if githubToken == "" {
 		// Try to read from file
 		tokenBytes, err := os.ReadFile("/home/tony/AI/secrets/passwords_and_tokens/gh-token")
@@ -59,6 +166,58 @@ func CreateSandbox(ctx context.Context, taskImage string, agentConfig *config.Ag
 		}
 	}
 
+	// Create the container
+	resp, err := createContainer(ctx, cli, taskImage, hostPath, githubToken, options, platform)
+	if err != nil {
+		os.RemoveAll(hostPath) // Clean up the directory if container creation fails
+		releaseCaches(options.caches)
+		return nil, err
+	}
+
+	// Start the container
+	if err := cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		os.RemoveAll(hostPath) // Clean up
+		releaseCaches(options.caches)
+		return nil, fmt.Errorf("failed to start container: %w", err)
+	}
+
+	fmt.Printf("✅ Sandbox container %s created successfully.\n", resp.ID[:12])
+	created = true
+
+	return &Sandbox{
+		ID:             resp.ID,
+		HostPath:       hostPath,
+		Workspace:      "/home/agent/work",
+		dockerCli:      cli,
+		ctx:            ctx,
+		commandTimeout: agentConfig.Sandbox.CommandTimeout,
+		caches:         options.caches,
+		releaseSlot:    releaseSlot,
+		redact:         redactor(options.Env),
+	}, nil
+}
+
+// containerCreator is the part of the Docker client that creates containers
+type containerCreator interface {
+	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error)
+}
+
+// createContainer creates a sandbox container running as the given platform, or
+// the daemon's default if it is nil
+func createContainer(ctx context.Context, cli containerCreator, taskImage, hostPath, githubToken string, options *Options, platform *ocispec.Platform) (container.CreateResponse, error) {
+	containerConfig, hostConfig, networkingConfig := buildContainerConfig(taskImage, hostPath, githubToken, options)
+	resp, err := cli.ContainerCreate(ctx, containerConfig, hostConfig, networkingConfig, platform, "")
+	if err != nil {
+		if platform != nil {
+			return resp, fmt.Errorf("failed to create container for platform %s: %w", formatPlatform(platform), err)
+		}
+		return resp, fmt.Errorf("failed to create container: %w", err)
+	}
+	return resp, nil
+}
+
+// buildContainerConfig assembles the Docker configuration for a sandbox container.
+func buildContainerConfig(taskImage, hostPath, githubToken string, options *Options) (*container.Config, *container.HostConfig, *network.NetworkingConfig) {
 	// Define container configuration
 	containerConfig := &container.Config{
 		Image:        taskImage,
@@ -72,37 +231,44 @@ func CreateSandbox(ctx context.Context, taskImage string, agentConfig *config.Ag
 		},
 	}
 
+	if options.ProxyURL != "" {
+		containerConfig.Env = append(containerConfig.Env,
+			"HTTP_PROXY="+options.ProxyURL,
+			"HTTPS_PROXY="+options.ProxyURL,
+			"http_proxy="+options.ProxyURL,
+			"https_proxy="+options.ProxyURL,
+		)
+	}
+
+	for _, cache := range options.caches {
+		containerConfig.Env = append(containerConfig.Env, cache.Env...)
+	}
+	containerConfig.Env = append(containerConfig.Env, taskEnvList(options.Env)...)
+
 	// Define host configuration (e.g., volume mounts, resource limits)
 	hostConfig := &container.HostConfig{
-		Binds: []string{fmt.Sprintf("%s:/home/agent/work", hostPath)},
+		Binds:       []string{fmt.Sprintf("%s:/home/agent/work", hostPath)},
+		NetworkMode: container.NetworkMode(options.NetworkMode),
 		Resources: container.Resources{
 			NanoCPUs: 2 * 1000000000, // 2 CPUs
 			Memory:   2 * 1024 * 1024 * 1024, // 2GB
 		},
 	}
-
-	// Create the container
-	resp, err := cli.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, "")
-	if err != nil {
-		os.RemoveAll(hostPath) // Clean up the directory if container creation fails
-		return nil, fmt.Errorf("failed to create container: %w", err)
+	for _, cache := range options.caches {
+		hostConfig.Binds = append(hostConfig.Binds, fmt.Sprintf("%s:%s", cache.HostPath, cache.Target))
 	}
 
-	// Start the container
-	if err := cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
-		os.RemoveAll(hostPath) // Clean up
-		return nil, fmt.Errorf("failed to start container: %w", err)
+	// Custom networks need an explicit endpoint so the container joins them
+	var networkingConfig *network.NetworkingConfig
+	if options.NetworkMode != NetworkModeNone && options.NetworkMode != NetworkModeBridge && options.NetworkMode != "" {
+		networkingConfig = &network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{
+				options.NetworkMode: {},
+			},
+		}
 	}
 
-	fmt.Printf("✅ Sandbox container %s created successfully.\n", resp.ID[:12])
-
-	return &Sandbox{
-		ID:          resp.ID,
-		HostPath:    hostPath,
-		Workspace:   "/home/agent/work",
-		dockerCli:   cli,
-		ctx:         ctx,
-	}, nil
+	return containerConfig, hostConfig, networkingConfig
 }
 
 // DestroySandbox stops and removes the container and its associated host directory.
@@ -113,21 +279,31 @@ func (s *Sandbox) DestroySandbox() error {
 
 	// Define a timeout for stopping the container
 	timeout := 30 // seconds
+
+	// Clean up even when the task's context was cancelled
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(s.ctx), time.Duration(timeout+30)*time.Second)
+	defer cancel()
 	
 	// Stop the container
 	fmt.Printf("🛑 Stopping sandbox container %s...\n", s.ID[:12])
-	err := s.dockerCli.ContainerStop(s.ctx, s.ID, container.StopOptions{Timeout: &timeout})
+	err := s.dockerCli.ContainerStop(ctx, s.ID, container.StopOptions{Timeout: &timeout})
 	if err != nil {
 		// Log the error but continue to try and clean up
 		fmt.Printf("⚠️  Error stopping container %s: %v. Proceeding with cleanup.\n", s.ID, err)
 	}
 
 	// Remove the container
-	err = s.dockerCli.ContainerRemove(s.ctx, s.ID, container.RemoveOptions{Force: true})
+	err = s.dockerCli.ContainerRemove(ctx, s.ID, container.RemoveOptions{Force: true})
 	if err != nil {
 		fmt.Printf("⚠️  Error removing container %s: %v. Proceeding with cleanup.\n", s.ID, err)
 	}
 
+	// The container is gone, so another sandbox can have its caches and its slot
+	releaseCaches(s.caches)
+	if s.releaseSlot != nil {
+		s.releaseSlot()
+	}
+
 	// Remove the host directory
 	fmt.Printf("🗑️  Removing host directory %s...\n", s.HostPath)
 	err = os.RemoveAll(s.HostPath)
@@ -139,11 +315,24 @@ func (s *Sandbox) DestroySandbox() error {
 	return nil
 }
 
-// RunCommand executes a shell command inside the sandbox.
+// RunCommand executes a shell command inside the sandbox using the default command timeout.
 func (s *Sandbox) RunCommand(command string) (*CommandResult, error) {
+	return s.RunCommandWithTimeout(command, s.commandTimeout)
+}
+
+// RunCommandWithTimeout executes a shell command inside the sandbox, killing it
+// if it runs longer than timeout. A zero timeout waits indefinitely.
+func (s *Sandbox) RunCommandWithTimeout(command string, timeout time.Duration) (*CommandResult, error) {
+	cmd := []string{"/bin/sh", "-c", command}
+	if timeout > 0 {
+		// Let coreutils timeout kill the command (and its children) inside the container
+		seconds := int(math.Ceil(timeout.Seconds()))
+		cmd = append([]string{"timeout", "-k", "5", strconv.Itoa(seconds)}, cmd...)
+	}
+
 	// Configuration for the exec process
 	execConfig := container.ExecOptions{
-		Cmd:          []string{"/bin/sh", "-c", command},
+		Cmd:          cmd,
 		AttachStdout: true,
 		AttachStderr: true,
 		Tty:          false,
@@ -164,9 +353,40 @@ func (s *Sandbox) RunCommand(command string) (*CommandResult, error) {
 
 	// Read the output
 	var stdout, stderr bytes.Buffer
-	_, err = stdcopy.StdCopy(&stdout, &stderr, resp.Reader)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read exec output: %w", err)
+	done := make(chan error, 1)
+	go func() {
+		_, err := stdcopy.StdCopy(&stdout, &stderr, resp.Reader)
+		done <- err
+	}()
+
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout + commandKillGrace)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, fmt.Errorf("failed to read exec output: %w", err)
+		}
+	case <-s.ctx.Done():
+		// The task was cancelled; stop waiting and let the caller destroy the sandbox
+		resp.Close()
+		<-done
+		return nil, fmt.Errorf("command cancelled: %w", s.ctx.Err())
+	case <-deadline:
+		// The command outlived the in-container timeout; stop waiting on it
+		resp.Close()
+		<-done
+		fmt.Printf("⏱️ Command timed out after %s in sandbox %s: %s\n", timeout, s.ID[:12], command)
+		return s.redacted(&CommandResult{
+			StdOut:   stdout.String(),
+			StdErr:   stderr.String(),
+			ExitCode: timeoutExitCode,
+			TimedOut: true,
+		}), nil
 	}
 
 	// Inspect the exec process to get the exit code
@@ -175,11 +395,26 @@ func (s *Sandbox) RunCommand(command string) (*CommandResult, error) {
 		return nil, fmt.Errorf("failed to inspect exec in container: %w", err)
 	}
 
-	return &CommandResult{
+	result := &CommandResult{
 		StdOut:   stdout.String(),
 		StdErr:   stderr.String(),
 		ExitCode: inspect.ExitCode,
-	}, nil
+	}
+	if timeout > 0 && inspect.ExitCode == timeoutExitCode {
+		result.TimedOut = true
+		fmt.Printf("⏱️ Command timed out after %s in sandbox %s: %s\n", timeout, s.ID[:12], command)
+	}
+
+	return s.redacted(result), nil
+}
+
+// redacted hides task environment values in a command's output
+func (s *Sandbox) redacted(result *CommandResult) *CommandResult {
+	if s.redact != nil {
+		result.StdOut = s.redact.Replace(result.StdOut)
+		result.StdErr = s.redact.Replace(result.StdErr)
+	}
+	return result
 }
 
 // WriteFile writes content to a file inside the sandbox's workspace.
@@ -258,3 +493,75 @@ func (s *Sandbox) ReadFile(path string) ([]byte, error) {
 
 	return buf.Bytes(), nil
 }
+
+// WriteTree writes several files into the sandbox's workspace in a single copy,
+// so a multi-file change lands all at once. Paths are relative to the workspace;
+// missing directories are created. Everything written belongs to the sandbox's
+// user, and files that already exist keep their mode.
+func (s *Sandbox) WriteTree(files map[string][]byte) error {
+	modes := make(map[string]os.FileMode, len(files))
+	for path := range files {
+		if stat, err := s.dockerCli.ContainerStatPath(s.ctx, s.ID, filepath.Join(s.Workspace, path)); err == nil && stat.Mode.IsRegular() {
+			modes[path] = stat.Mode.Perm()
+		}
+	}
+	tarBuf, err := treeArchive(files, modes)
+	if err != nil {
+		return err
+	}
+	if err := s.dockerCli.CopyToContainer(s.ctx, s.ID, s.Workspace, tarBuf, container.CopyToContainerOptions{CopyUIDGID: true}); err != nil {
+		return fmt.Errorf("failed to copy files into sandbox: %w", err)
+	}
+	return nil
+}
+
+// treeArchive packs files, and the directories they need, into a tar archive.
+// Files get their mode from modes, or 0644 if they have none there.
+func treeArchive(files map[string][]byte, modes map[string]os.FileMode) (*bytes.Buffer, error) {
+	paths := make([]string, 0, len(files))
+	dirs := make(map[string]bool)
+	for path := range files {
+		clean := filepath.ToSlash(filepath.Clean(path))
+		if filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+			return nil, fmt.Errorf("path %q is outside the workspace", path)
+		}
+		paths = append(paths, path)
+		for dir := filepath.Dir(clean); dir != "."; dir = filepath.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+	sort.Strings(paths)
+
+	// Directories sort before their contents, so parents are always created first
+	entries := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		entries = append(entries, dir)
+	}
+	sort.Strings(entries)
+
+	tarBuf := new(bytes.Buffer)
+	tw := tar.NewWriter(tarBuf)
+	for _, dir := range entries {
+		if err := tw.WriteHeader(&tar.Header{Name: dir + "/", Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+			return nil, fmt.Errorf("failed to write tar header: %w", err)
+		}
+	}
+	for _, path := range paths {
+		content := files[path]
+		mode, ok := modes[path]
+		if !ok {
+			mode = 0644
+		}
+		header := &tar.Header{Name: filepath.ToSlash(filepath.Clean(path)), Size: int64(len(content)), Mode: int64(mode)}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, fmt.Errorf("failed to write tar header: %w", err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			return nil, fmt.Errorf("failed to write to tar: %w", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close tar writer: %w", err)
+	}
+	return tarBuf, nil
+}
@@ -0,0 +1,301 @@
+package sandbox
+
+import (
+	"archive/tar"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pkg/config"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestBuildContainerConfigNetworkModes(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         string
+		wantEndpoint bool
+	}{
+		{name: "none", mode: NetworkModeNone},
+		{name: "bridge", mode: NetworkModeBridge},
+		{name: "custom", mode: "bzzz-egress", wantEndpoint: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, hostConfig, networkingConfig := buildContainerConfig("bzzz-sandbox:latest", "/tmp/work", "", &Options{NetworkMode: tt.mode})
+
+			if string(hostConfig.NetworkMode) != tt.mode {
+				t.Fatalf("expected network mode %q, got %q", tt.mode, hostConfig.NetworkMode)
+			}
+			if !tt.wantEndpoint {
+				if networkingConfig != nil {
+					t.Fatalf("expected no networking config for %q", tt.mode)
+				}
+				return
+			}
+			if networkingConfig == nil || networkingConfig.EndpointsConfig[tt.mode] == nil {
+				t.Fatalf("expected an endpoint on custom network %q", tt.mode)
+			}
+		})
+	}
+}
+
+func TestBuildContainerConfigProxy(t *testing.T) {
+	containerConfig, _, _ := buildContainerConfig("bzzz-sandbox:latest", "/tmp/work", "", &Options{
+		NetworkMode: "bzzz-egress",
+		ProxyURL:    "http://proxy.internal:3128",
+	})
+
+	found := false
+	for _, env := range containerConfig.Env {
+		if env == "HTTPS_PROXY=http://proxy.internal:3128" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected proxy to be exported to the container, got %v", containerConfig.Env)
+	}
+}
+
+func TestBuildContainerConfigPassesAllowlistedTaskEnv(t *testing.T) {
+	options := &Options{NetworkMode: NetworkModeBridge}
+	WithTaskEnv(map[string]string{
+		"STRIPE_TEST_KEY": "sk_test_abcdef123456",
+		"BUILD_FLAGS":     "-race",
+		"LD_PRELOAD":      "/tmp/evil.so", // Not allowlisted
+		"GITHUB_TOKEN":    "ghp_override", // Reserved even when allowlisted
+		"BAD-NAME":        "x",
+	}, []string{"STRIPE_TEST_KEY", "BUILD_*", "GITHUB_TOKEN", "BAD-NAME"})(options)
+
+	containerConfig, _, _ := buildContainerConfig("bzzz-sandbox:latest", "/tmp/work", "ghp_agent", options)
+	env := make(map[string]string)
+	for _, entry := range containerConfig.Env {
+		name, value, _ := strings.Cut(entry, "=")
+		env[name] = value
+	}
+	if env["STRIPE_TEST_KEY"] != "sk_test_abcdef123456" || env["BUILD_FLAGS"] != "-race" {
+		t.Fatalf("expected allowlisted variables in the container, got %v", containerConfig.Env)
+	}
+	if _, leaked := env["LD_PRELOAD"]; leaked {
+		t.Fatal("non-allowlisted variable reached the container")
+	}
+	if _, leaked := env["BAD-NAME"]; leaked {
+		t.Fatal("invalid variable name reached the container")
+	}
+	if env["GITHUB_TOKEN"] != "ghp_agent" {
+		t.Fatalf("task overrode the sandbox's GitHub token: %q", env["GITHUB_TOKEN"])
+	}
+
+	sb := &Sandbox{redact: redactor(options.Env)}
+	result := sb.redacted(&CommandResult{StdOut: "using key sk_test_abcdef123456 with -race"})
+	if result.StdOut != "using key [REDACTED:STRIPE_TEST_KEY] with -race" {
+		t.Fatalf("expected the secret value redacted from output, got %q", result.StdOut)
+	}
+}
+
+// TestRunCommandTimeout needs a Docker daemon and a sandbox image, so it only
+// runs when BZZZ_SANDBOX_TEST_IMAGE is set.
+func TestRunCommandTimeout(t *testing.T) {
+	image := os.Getenv("BZZZ_SANDBOX_TEST_IMAGE")
+	if image == "" {
+		t.Skip("BZZZ_SANDBOX_TEST_IMAGE not set")
+	}
+
+	sb, err := CreateSandbox(context.Background(), image, &config.AgentConfig{})
+	if err != nil {
+		t.Fatalf("failed to create sandbox: %v", err)
+	}
+	defer sb.DestroySandbox()
+
+	start := time.Now()
+	result, err := sb.RunCommandWithTimeout("sleep 60", 2*time.Second)
+	if err != nil {
+		t.Fatalf("RunCommandWithTimeout returned error: %v", err)
+	}
+	if !result.TimedOut {
+		t.Fatalf("expected command to be reported as timed out, got exit code %d", result.ExitCode)
+	}
+	if elapsed := time.Since(start); elapsed > 30*time.Second {
+		t.Fatalf("command was not terminated promptly, took %s", elapsed)
+	}
+}
+
+type fakeContainerCreator struct {
+	platform *ocispec.Platform
+	image    string
+}
+
+func (f *fakeContainerCreator) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error) {
+	f.platform = platform
+	f.image = config.Image
+	return container.CreateResponse{ID: "0123456789abcdef"}, nil
+}
+
+func TestCreateContainerSetsPlatform(t *testing.T) {
+	platform, err := parsePlatform("linux/arm64/v8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := &fakeContainerCreator{}
+	if _, err := createContainer(context.Background(), cli, "bzzz-sandbox:latest", "/tmp/work", "", &Options{NetworkMode: NetworkModeNone}, platform); err != nil {
+		t.Fatalf("createContainer returned error: %v", err)
+	}
+	if cli.platform == nil || cli.platform.OS != "linux" || cli.platform.Architecture != "arm64" || cli.platform.Variant != "v8" {
+		t.Fatalf("expected the container to be created as linux/arm64/v8, got %+v", cli.platform)
+	}
+	if cli.image != "bzzz-sandbox:latest" {
+		t.Fatalf("expected the sandbox image, got %q", cli.image)
+	}
+
+	for _, invalid := range []string{"linux", "linux/", "/arm64", "linux/arm/v7/extra"} {
+		if _, err := parsePlatform(invalid); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestBuildContainerConfigMountsDependencyCaches(t *testing.T) {
+	root := t.TempDir()
+	caches, err := acquireCaches(map[string]string{
+		"go":  filepath.Join(root, "go"),
+		"npm": filepath.Join(root, "npm"),
+	}, false)
+	if err != nil {
+		t.Fatalf("acquireCaches returned error: %v", err)
+	}
+	defer releaseCaches(caches)
+
+	containerConfig, hostConfig, _ := buildContainerConfig("bzzz-sandbox:latest", "/tmp/work", "", &Options{NetworkMode: NetworkModeNone, caches: caches})
+
+	for _, bind := range []string{
+		"/tmp/work:/home/agent/work",
+		filepath.Join(root, "go") + ":/home/agent/.cache/bzzz/go",
+		filepath.Join(root, "npm") + ":/home/agent/.cache/bzzz/npm",
+	} {
+		if !containsString(hostConfig.Binds, bind) {
+			t.Errorf("expected bind %q, got %v", bind, hostConfig.Binds)
+		}
+	}
+	for _, env := range []string{"GOMODCACHE=/home/agent/.cache/bzzz/go/mod", "npm_config_cache=/home/agent/.cache/bzzz/npm"} {
+		if !containsString(containerConfig.Env, env) {
+			t.Errorf("expected %q in the container environment, got %v", env, containerConfig.Env)
+		}
+	}
+
+	// npm doesn't lock its cache, so a second sandbox must go without it until the first is done
+	second, err := acquireCaches(map[string]string{"go": filepath.Join(root, "go"), "npm": filepath.Join(root, "npm")}, false)
+	if err != nil {
+		t.Fatalf("acquireCaches returned error: %v", err)
+	}
+	if len(second) != 1 || second[0].Language != "go" {
+		t.Fatalf("expected only the go cache to be shared concurrently, got %d caches", len(second))
+	}
+	releaseCaches(second)
+	releaseCaches(caches)
+	third, err := acquireCaches(map[string]string{"npm": filepath.Join(root, "npm")}, false)
+	if err != nil || len(third) != 1 {
+		t.Fatalf("expected the npm cache to be free once released, got %d caches, err %v", len(third), err)
+	}
+	releaseCaches(third)
+
+	// Untrusted sandboxes never share a cache with trusted ones
+	untrusted, err := acquireCaches(map[string]string{"go": filepath.Join(root, "go")}, true)
+	if err != nil || len(untrusted) != 1 || untrusted[0].HostPath != filepath.Join(root, "go")+"-untrusted" {
+		t.Fatalf("expected an untrusted sandbox to get its own cache, got %+v, err %v", untrusted, err)
+	}
+	releaseCaches(untrusted)
+	if info, err := os.Stat(filepath.Join(root, "go-untrusted")); err != nil || info.Mode().Perm() != 0o700 {
+		t.Fatalf("expected the cache to be private to the sandbox user, got %v, err %v", info, err)
+	}
+
+	if _, err := acquireCaches(map[string]string{"cobol": root}, false); err == nil {
+		t.Error("expected an unsupported cache language to be rejected")
+	}
+}
+
+func containsString(values []string, want string) bool {
+	for _, value := range values {
+		if value == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSandboxSlotsQueueBeyondTheLimit(t *testing.T) {
+	slots := &slotLimiter{}
+	ctx := context.Background()
+
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		release, err := slots.acquire(ctx, 2)
+		if err != nil {
+			t.Fatalf("slot %d: %v", i+1, err)
+		}
+		releases = append(releases, release)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		if _, err := slots.acquire(ctx, 2); err == nil {
+			close(acquired)
+		}
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("third sandbox started while two were running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if _, waiting := slots.counts(); waiting != 1 {
+		t.Fatalf("expected one queued sandbox, got %d", waiting)
+	}
+
+	releases[0]()
+	releases[0]() // Destroying a sandbox twice frees only one slot
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued sandbox did not start once a slot was released")
+	}
+	if inUse, waiting := slots.counts(); inUse != 2 || waiting != 0 {
+		t.Fatalf("expected 2 running and none queued, got %d and %d", inUse, waiting)
+	}
+
+	// A queued task that gives up leaves the queue
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := slots.acquire(waitCtx, 2); err == nil {
+		t.Fatal("expected acquiring a full host to time out")
+	}
+	if _, waiting := slots.counts(); waiting != 0 {
+		t.Fatalf("abandoned wait still counted as queued: %d", waiting)
+	}
+}
+
+func TestTreeArchiveKeepsExistingModes(t *testing.T) {
+	files := map[string][]byte{"scripts/build.sh": []byte("#!/bin/sh\n"), "scripts/README": []byte("docs\n")}
+	tarBuf, err := treeArchive(files, map[string]os.FileMode{"scripts/build.sh": 0755})
+	if err != nil {
+		t.Fatalf("treeArchive returned error: %v", err)
+	}
+
+	modes := make(map[string]int64)
+	tr := tar.NewReader(tarBuf)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		modes[header.Name] = header.Mode
+	}
+	if modes["scripts/"] != 0755 || modes["scripts/build.sh"] != 0755 || modes["scripts/README"] != 0644 {
+		t.Fatalf("unexpected modes: %v", modes)
+	}
+}
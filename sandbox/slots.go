@@ -0,0 +1,85 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// hostSlots bounds how many sandbox containers run at once on this host, shared
+// by every task the agent runs
+var hostSlots = &slotLimiter{}
+
+// slotLimiter is a counting semaphore whose limit can change between acquisitions
+type slotLimiter struct {
+	lock    sync.Mutex
+	inUse   int
+	waiting int
+	freed   chan struct{} // Closed and replaced whenever a slot is released
+}
+
+// acquire takes a slot, waiting while limit slots are in use. A limit of 0 or
+// less never waits. The returned release is safe to call more than once.
+func (l *slotLimiter) acquire(ctx context.Context, limit int) (release func(), err error) {
+	l.lock.Lock()
+	queued := false
+	for limit > 0 && l.inUse >= limit {
+		if !queued {
+			queued = true
+			l.waiting++
+			fmt.Printf("⏳ %d sandboxes already running, waiting for one to finish\n", l.inUse)
+		}
+		if l.freed == nil {
+			l.freed = make(chan struct{})
+		}
+		freed := l.freed
+		l.lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			l.lock.Lock()
+			l.waiting--
+			l.lock.Unlock()
+			return nil, fmt.Errorf("gave up waiting for a sandbox slot: %w", ctx.Err())
+		case <-freed:
+		}
+		l.lock.Lock()
+	}
+	if queued {
+		l.waiting--
+	}
+	l.inUse++
+	l.lock.Unlock()
+
+	return sync.OnceFunc(l.release), nil
+}
+
+// release gives a slot back and wakes anyone waiting for one
+func (l *slotLimiter) release() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.inUse--
+	if l.freed != nil {
+		close(l.freed)
+		l.freed = nil
+	}
+}
+
+// counts returns how many slots are taken and how many callers wait for one
+func (l *slotLimiter) counts() (inUse, waiting int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.inUse, l.waiting
+}
+
+// Running returns how many sandbox containers this host is running
+func Running() int {
+	inUse, _ := hostSlots.counts()
+	return inUse
+}
+
+// Queued returns how many tasks are waiting for a sandbox slot to free up
+func Queued() int {
+	_, waiting := hostSlots.counts()
+	return waiting
+}
@@ -0,0 +1,93 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// ResourceStats is a point-in-time snapshot of a sandbox's resource usage.
+type ResourceStats struct {
+	CPUPercent  float64 // Percentage of a single CPU, so 200% means two full cores
+	MemoryUsage uint64  // Bytes in use, excluding reclaimable page cache
+	MemoryLimit uint64  // Container memory limit in bytes
+	NetworkRx   uint64  // Bytes received across all interfaces
+	NetworkTx   uint64  // Bytes sent across all interfaces
+}
+
+// MemoryFraction returns memory usage as a fraction of the limit (0 when unlimited).
+func (r *ResourceStats) MemoryFraction() float64 {
+	if r.MemoryLimit == 0 {
+		return 0
+	}
+	return float64(r.MemoryUsage) / float64(r.MemoryLimit)
+}
+
+// statsClient is the subset of the Docker client needed to read container stats.
+type statsClient interface {
+	ContainerStats(ctx context.Context, containerID string, stream bool) (container.StatsResponseReader, error)
+}
+
+// Stats reads a single resource usage snapshot for the sandbox container.
+func (s *Sandbox) Stats() (*ResourceStats, error) {
+	return readStats(s.ctx, s.dockerCli, s.ID)
+}
+
+// Kill terminates the sandbox container immediately, leaving cleanup to DestroySandbox.
+func (s *Sandbox) Kill() error {
+	if err := s.dockerCli.ContainerKill(s.ctx, s.ID, "KILL"); err != nil {
+		return fmt.Errorf("failed to kill container %s: %w", s.ID, err)
+	}
+	return nil
+}
+
+// readStats fetches one stats sample from Docker and converts it to a ResourceStats.
+func readStats(ctx context.Context, cli statsClient, containerID string) (*ResourceStats, error) {
+	// A non-streaming request waits for a second sample so precpu stats are populated
+	resp, err := cli.ContainerStats(ctx, containerID, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container stats: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var raw container.StatsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode container stats: %w", err)
+	}
+
+	return parseStats(&raw), nil
+}
+
+// parseStats computes usage figures the same way the docker CLI does.
+func parseStats(raw *container.StatsResponse) *ResourceStats {
+	stats := &ResourceStats{
+		MemoryUsage: raw.MemoryStats.Usage,
+		MemoryLimit: raw.MemoryStats.Limit,
+	}
+
+	// Page cache can be reclaimed, so don't count it towards usage
+	if inactive, ok := raw.MemoryStats.Stats["inactive_file"]; ok && inactive < stats.MemoryUsage {
+		stats.MemoryUsage -= inactive
+	} else if inactive, ok := raw.MemoryStats.Stats["total_inactive_file"]; ok && inactive < stats.MemoryUsage {
+		stats.MemoryUsage -= inactive
+	}
+
+	cpuDelta := float64(raw.CPUStats.CPUUsage.TotalUsage) - float64(raw.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(raw.CPUStats.SystemUsage) - float64(raw.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(raw.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(raw.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		stats.CPUPercent = (cpuDelta / systemDelta) * onlineCPUs * 100.0
+	}
+
+	for _, network := range raw.Networks {
+		stats.NetworkRx += network.RxBytes
+		stats.NetworkTx += network.TxBytes
+	}
+
+	return stats
+}
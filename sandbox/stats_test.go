@@ -0,0 +1,49 @@
+package sandbox
+
+import (
+	"context"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+type fakeStatsClient struct {
+	body string
+}
+
+func (f *fakeStatsClient) ContainerStats(ctx context.Context, containerID string, stream bool) (container.StatsResponseReader, error) {
+	return container.StatsResponseReader{Body: io.NopCloser(strings.NewReader(f.body))}, nil
+}
+
+func TestReadStats(t *testing.T) {
+	cli := &fakeStatsClient{body: `{
+		"cpu_stats": {"cpu_usage": {"total_usage": 300000000}, "system_cpu_usage": 2000000000, "online_cpus": 2},
+		"precpu_stats": {"cpu_usage": {"total_usage": 100000000}, "system_cpu_usage": 1000000000},
+		"memory_stats": {"usage": 1073741824, "limit": 2147483648, "stats": {"inactive_file": 73741824}},
+		"networks": {
+			"eth0": {"rx_bytes": 1000, "tx_bytes": 200},
+			"eth1": {"rx_bytes": 500, "tx_bytes": 50}
+		}
+	}`}
+
+	stats, err := readStats(context.Background(), cli, "abc123")
+	if err != nil {
+		t.Fatalf("readStats returned error: %v", err)
+	}
+
+	if math.Abs(stats.CPUPercent-40.0) > 0.001 {
+		t.Errorf("expected 40%% CPU, got %.3f", stats.CPUPercent)
+	}
+	if stats.MemoryUsage != 1000000000 {
+		t.Errorf("expected page cache to be excluded from memory usage, got %d", stats.MemoryUsage)
+	}
+	if stats.MemoryLimit != 2147483648 {
+		t.Errorf("unexpected memory limit %d", stats.MemoryLimit)
+	}
+	if stats.NetworkRx != 1500 || stats.NetworkTx != 250 {
+		t.Errorf("expected network totals 1500/250, got %d/%d", stats.NetworkRx, stats.NetworkTx)
+	}
+}
@@ -3,422 +3,410 @@ package test
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
+	"testing"
 	"time"
 
-	"github.com/anthonyrawlins/bzzz/pubsub"
 	"github.com/anthonyrawlins/bzzz/pkg/coordination"
+	"github.com/anthonyrawlins/bzzz/pubsub"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
 )
 
-// AntennaeTestSuite runs comprehensive tests for the antennae coordination system
-type AntennaeTestSuite struct {
-	ctx           context.Context
-	pubsub        *pubsub.PubSub
-	simulator     *TaskSimulator
-	coordinator   *coordination.MetaCoordinator
-	detector      *coordination.DependencyDetector
-	testResults   []TestResult
+// Generous enough for gossipsub to deliver and the coordinator to flush its reorder window
+const deliveryTimeout = 10 * time.Second
+
+// messageLog collects the messages a node receives on one topic
+type messageLog struct {
+	mu       sync.Mutex
+	messages []pubsub.Message
 }
 
-// TestResult represents the result of a coordination test
-type TestResult struct {
-	TestName        string    `json:"test_name"`
-	StartTime       time.Time `json:"start_time"`
-	EndTime         time.Time `json:"end_time"`
-	Success         bool      `json:"success"`
-	ExpectedOutcome string    `json:"expected_outcome"`
-	ActualOutcome   string    `json:"actual_outcome"`
-	CoordinationLog []string  `json:"coordination_log"`
-	Metrics         TestMetrics `json:"metrics"`
+func (l *messageLog) handle(msg pubsub.Message, from peer.ID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, msg)
 }
 
-// TestMetrics tracks quantitative test results
-type TestMetrics struct {
-	TasksAnnounced         int           `json:"tasks_announced"`
-	CoordinationSessions   int           `json:"coordination_sessions"`
-	DependenciesDetected   int           `json:"dependencies_detected"`
-	AgentResponses         int           `json:"agent_responses"`
-	AverageResponseTime    time.Duration `json:"average_response_time"`
-	SuccessfulCoordinations int          `json:"successful_coordinations"`
+func (l *messageLog) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = nil
+}
+
+// matching returns the received messages accepted by match
+func (l *messageLog) matching(match func(pubsub.Message) bool) []pubsub.Message {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var found []pubsub.Message
+	for _, msg := range l.messages {
+		if match(msg) {
+			found = append(found, msg)
+		}
+	}
+	return found
 }
 
-// NewAntennaeTestSuite creates a new test suite
-func NewAntennaeTestSuite(ctx context.Context, ps *pubsub.PubSub) *AntennaeTestSuite {
-	simulator := NewTaskSimulator(ps, ctx)
-	
-	// Initialize coordination components
-	coordinator := coordination.NewMetaCoordinator(ctx, ps)
-	detector := coordination.NewDependencyDetector()
-	
-	return &AntennaeTestSuite{
-		ctx:         ctx,
-		pubsub:      ps,
-		simulator:   simulator,
-		coordinator: coordinator,
-		detector:    detector,
-		testResults: make([]TestResult, 0),
+// waitFor blocks until a message accepted by match arrives
+func (l *messageLog) waitFor(t *testing.T, what string, match func(pubsub.Message) bool) pubsub.Message {
+	t.Helper()
+	deadline := time.Now().Add(deliveryTimeout)
+	for time.Now().Before(deadline) {
+		if found := l.matching(match); len(found) > 0 {
+			return found[0]
+		}
+		time.Sleep(50 * time.Millisecond)
 	}
+	t.Fatalf("timed out waiting for %s", what)
+	return pubsub.Message{}
 }
 
-// RunFullTestSuite executes all antennae coordination tests
-func (ats *AntennaeTestSuite) RunFullTestSuite() {
-	fmt.Println("🧪 Starting Antennae Coordination Test Suite")
-	fmt.Println("=" * 50)
-	
-	// Start the task simulator
-	ats.simulator.Start()
-	defer ats.simulator.Stop()
-	
-	// Run individual tests
-	tests := []func(){
-		ats.testBasicTaskAnnouncement,
-		ats.testDependencyDetection,
-		ats.testCrossRepositoryCoordination,
-		ats.testConflictResolution,
-		ats.testEscalationScenarios,
-		ats.testLoadHandling,
-	}
-	
-	for i, test := range tests {
-		fmt.Printf("\n🔬 Running Test %d/%d\n", i+1, len(tests))
-		test()
-		time.Sleep(5 * time.Second) // Brief pause between tests
-	}
-	
-	ats.printTestSummary()
+// testNode is one Bzzz peer on the in-memory network
+type testNode struct {
+	host     host.Host
+	ps       *pubsub.PubSub
+	bzzz     *messageLog
+	antennae *messageLog
 }
 
-// testBasicTaskAnnouncement tests basic task announcement and response
-func (ats *AntennaeTestSuite) testBasicTaskAnnouncement() {
-	testName := "Basic Task Announcement"
-	fmt.Printf("   📋 %s\n", testName)
-	
-	startTime := time.Now()
-	result := TestResult{
-		TestName:        testName,
-		StartTime:       startTime,
-		ExpectedOutcome: "Agents respond to task announcements within 30 seconds",
-		CoordinationLog: make([]string, 0),
-	}
-	
-	// Monitor for agent responses
-	responseCount := 0
-	timeout := time.After(30 * time.Second)
-	
-	// Subscribe to coordination messages
-	go func() {
-		// This would be implemented with actual pubsub subscription
-		// Simulating responses for now
-		time.Sleep(5 * time.Second)
-		responseCount++
-		result.CoordinationLog = append(result.CoordinationLog, "Agent sim-agent-1 responded to task announcement")
-		time.Sleep(3 * time.Second)
-		responseCount++
-		result.CoordinationLog = append(result.CoordinationLog, "Agent sim-agent-2 showed interest in task")
-	}()
-	
-	select {
-	case <-timeout:
-		result.EndTime = time.Now()
-		result.Success = responseCount > 0
-		result.ActualOutcome = fmt.Sprintf("Received %d agent responses", responseCount)
-		result.Metrics = TestMetrics{
-			TasksAnnounced: 1,
-			AgentResponses: responseCount,
-			AverageResponseTime: time.Since(startTime) / time.Duration(max(responseCount, 1)),
+// newTestMesh connects n peers over an in-memory libp2p network and waits
+// until every peer hears every other peer on both topics.
+func newTestMesh(t *testing.T, ctx context.Context, n int) []*testNode {
+	t.Helper()
+
+	mn := mocknet.New()
+	t.Cleanup(func() { mn.Close() })
+
+	nodes := make([]*testNode, n)
+	for i := range nodes {
+		h, err := mn.GenPeer()
+		if err != nil {
+			t.Fatalf("failed to create in-memory host: %v", err)
+		}
+		ps, err := pubsub.NewPubSub(ctx, h, "bzzz/test/coordination", "antennae/test/meta-discussion")
+		if err != nil {
+			t.Fatalf("failed to create PubSub: %v", err)
 		}
+		t.Cleanup(func() { ps.Close() })
+
+		node := &testNode{host: h, ps: ps, bzzz: &messageLog{}, antennae: &messageLog{}}
+		ps.AddBzzzMessageHandler(node.bzzz.handle)
+		ps.AddAntennaeMessageHandler(node.antennae.handle)
+		nodes[i] = node
 	}
-	
-	ats.testResults = append(ats.testResults, result)
-	ats.logTestResult(result)
+
+	if err := mn.LinkAll(); err != nil {
+		t.Fatalf("failed to link peers: %v", err)
+	}
+	if err := mn.ConnectAllButSelf(); err != nil {
+		t.Fatalf("failed to connect peers: %v", err)
+	}
+
+	// Probe until the gossipsub mesh carries traffic between every pair of peers
+	isProbe := func(msg pubsub.Message) bool { return msg.Data["probe"] == true }
+	heardFromAll := func(log *messageLog) bool {
+		senders := make(map[string]bool)
+		for _, msg := range log.matching(isProbe) {
+			senders[msg.From] = true
+		}
+		return len(senders) == n-1
+	}
+	deadline := time.Now().Add(deliveryTimeout)
+	for {
+		ready := true
+		for _, node := range nodes {
+			node.ps.PublishBzzzMessage(pubsub.AvailabilityBcast, map[string]interface{}{"probe": true})
+			node.ps.PublishAntennaeMessage(pubsub.MetaDiscussion, map[string]interface{}{"probe": true})
+			ready = ready && heardFromAll(node.bzzz) && heardFromAll(node.antennae)
+		}
+		if ready {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("gossipsub mesh did not form between %d peers", n)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	for _, node := range nodes {
+		node.bzzz.reset()
+		node.antennae.reset()
+	}
+	return nodes
 }
 
-// testDependencyDetection tests cross-repository dependency detection
-func (ats *AntennaeTestSuite) testDependencyDetection() {
-	testName := "Dependency Detection"
-	fmt.Printf("   🔗 %s\n", testName)
-	
-	startTime := time.Now()
-	result := TestResult{
-		TestName:        testName,
-		StartTime:       startTime,
-		ExpectedOutcome: "System detects task dependencies across repositories",
-		CoordinationLog: make([]string, 0),
-	}
-	
-	// Get mock repositories and test dependency detection
-	repos := ats.simulator.GetMockRepositories()
-	dependencies := 0
-	
-	for _, repo := range repos {
+// mockTaskContext builds the dependency detector's view of a mock repository task
+func mockTaskContext(t *testing.T, repository string, number int) *coordination.TaskContext {
+	t.Helper()
+	for i, repo := range generateMockRepositories() {
+		if repo.Name != repository {
+			continue
+		}
 		for _, task := range repo.Tasks {
-			if len(task.Dependencies) > 0 {
-				dependencies += len(task.Dependencies)
-				result.CoordinationLog = append(result.CoordinationLog, 
-					fmt.Sprintf("Detected dependency: %s/#%d depends on %d other tasks", 
-						repo.Name, task.Number, len(task.Dependencies)))
+			if task.Number == number {
+				return &coordination.TaskContext{
+					TaskID:      task.Number,
+					ProjectID:   i + 1,
+					Repository:  repo.Name,
+					Title:       task.Title,
+					Description: task.Description,
+					Keywords:    task.RequiredSkills,
+					AgentID:     "agent-" + repo.Name,
+					ClaimedAt:   time.Now(),
+				}
 			}
 		}
 	}
-	
-	result.EndTime = time.Now()
-	result.Success = dependencies > 0
-	result.ActualOutcome = fmt.Sprintf("Detected %d cross-repository dependencies", dependencies)
-	result.Metrics = TestMetrics{
-		DependenciesDetected: dependencies,
+	t.Fatalf("no mock task %s#%d", repository, number)
+	return nil
+}
+
+// coordinationMessage matches Antennae messages with the given message_type
+func coordinationMessage(messageType string) func(pubsub.Message) bool {
+	return func(msg pubsub.Message) bool {
+		return msg.Data["message_type"] == messageType
 	}
-	
-	ats.testResults = append(ats.testResults, result)
-	ats.logTestResult(result)
 }
 
-// testCrossRepositoryCoordination tests coordination across multiple repositories
-func (ats *AntennaeTestSuite) testCrossRepositoryCoordination() {
-	testName := "Cross-Repository Coordination"
-	fmt.Printf("   🌐 %s\n", testName)
-	
-	startTime := time.Now()
-	result := TestResult{
-		TestName:        testName,
-		StartTime:       startTime,
-		ExpectedOutcome: "Coordination sessions handle multi-repo scenarios",
-		CoordinationLog: make([]string, 0),
-	}
-	
-	// Run a coordination scenario
-	scenarios := ats.simulator.GetScenarios()
-	if len(scenarios) > 0 {
-		scenario := scenarios[0] // Use the first scenario
-		result.CoordinationLog = append(result.CoordinationLog, 
-			fmt.Sprintf("Starting scenario: %s", scenario.Name))
-		
-		// Simulate coordination session
-		time.Sleep(2 * time.Second)
-		result.CoordinationLog = append(result.CoordinationLog, 
-			"Meta-coordinator analyzing task dependencies")
-		
-		time.Sleep(1 * time.Second)
-		result.CoordinationLog = append(result.CoordinationLog, 
-			"Generated coordination plan for 3 repositories")
-		
-		time.Sleep(1 * time.Second)
-		result.CoordinationLog = append(result.CoordinationLog, 
-			"Agents reached consensus on execution order")
-		
-		result.Success = true
-		result.ActualOutcome = "Successfully coordinated multi-repository scenario"
-		result.Metrics = TestMetrics{
-			CoordinationSessions: 1,
-			SuccessfulCoordinations: 1,
+// forSession narrows a matcher to one coordination session
+func forSession(sessionID string, match func(pubsub.Message) bool) func(pubsub.Message) bool {
+	return func(msg pubsub.Message) bool {
+		return match(msg) && msg.Data["session_id"] == sessionID
+	}
+}
+
+// waitForSessions blocks until the coordinator has at least n sessions
+func waitForSessions(t *testing.T, mc *coordination.MetaCoordinator, n int) map[string]*coordination.CoordinationSession {
+	t.Helper()
+	deadline := time.Now().Add(deliveryTimeout)
+	for {
+		sessions := mc.GetActiveSessions()
+		if len(sessions) >= n {
+			return sessions
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d coordination sessions, got %d", n, len(sessions))
 		}
-	} else {
-		result.Success = false
-		result.ActualOutcome = "No coordination scenarios available"
-	}
-	
-	result.EndTime = time.Now()
-	ats.testResults = append(ats.testResults, result)
-	ats.logTestResult(result)
+		time.Sleep(50 * time.Millisecond)
+	}
 }
 
-// testConflictResolution tests handling of conflicting task assignments
-func (ats *AntennaeTestSuite) testConflictResolution() {
-	testName := "Conflict Resolution"
-	fmt.Printf("   ⚔️ %s\n", testName)
-	
-	startTime := time.Now()
-	result := TestResult{
-		TestName:        testName,
-		StartTime:       startTime,
-		ExpectedOutcome: "System resolves conflicting task assignments",
-		CoordinationLog: make([]string, 0),
-	}
-	
-	// Simulate conflict scenario
-	result.CoordinationLog = append(result.CoordinationLog, 
-		"Two agents claim the same high-priority task")
-	time.Sleep(1 * time.Second)
-	
-	result.CoordinationLog = append(result.CoordinationLog, 
-		"Meta-coordinator detects conflict")
-	time.Sleep(1 * time.Second)
-	
-	result.CoordinationLog = append(result.CoordinationLog, 
-		"Analyzing agent capabilities for best assignment")
-	time.Sleep(2 * time.Second)
-	
-	result.CoordinationLog = append(result.CoordinationLog, 
-		"Assigned task to agent with best skill match")
-	time.Sleep(1 * time.Second)
-	
-	result.CoordinationLog = append(result.CoordinationLog, 
-		"Alternative agent assigned to related task")
-	
-	result.EndTime = time.Now()
-	result.Success = true
-	result.ActualOutcome = "Successfully resolved task assignment conflict"
-	result.Metrics = TestMetrics{
-		CoordinationSessions: 1,
-		SuccessfulCoordinations: 1,
-	}
-	
-	ats.testResults = append(ats.testResults, result)
-	ats.logTestResult(result)
+// respond publishes an agent's reply into a coordination session
+func respond(t *testing.T, node *testNode, sessionID, agentID, response string) {
+	t.Helper()
+	err := node.ps.PublishAntennaeMessage(pubsub.MetaDiscussion, map[string]interface{}{
+		"message_type": "coordination_response",
+		"session_id":   sessionID,
+		"agent_id":     agentID,
+		"response":     response,
+	})
+	if err != nil {
+		t.Fatalf("failed to publish coordination response: %v", err)
+	}
 }
 
-// testEscalationScenarios tests human escalation triggers
-func (ats *AntennaeTestSuite) testEscalationScenarios() {
-	testName := "Escalation Scenarios"
-	fmt.Printf("   🚨 %s\n", testName)
-	
-	startTime := time.Now()
-	result := TestResult{
-		TestName:        testName,
-		StartTime:       startTime,
-		ExpectedOutcome: "System escalates complex scenarios to humans",
-		CoordinationLog: make([]string, 0),
-	}
-	
-	// Simulate escalation scenario
-	result.CoordinationLog = append(result.CoordinationLog, 
-		"Complex coordination deadlock detected")
-	time.Sleep(1 * time.Second)
-	
-	result.CoordinationLog = append(result.CoordinationLog, 
-		"Multiple resolution attempts failed")
-	time.Sleep(2 * time.Second)
-	
-	result.CoordinationLog = append(result.CoordinationLog, 
-		"Escalation triggered after 3 failed attempts")
-	time.Sleep(1 * time.Second)
-	
-	result.CoordinationLog = append(result.CoordinationLog, 
-		"Human intervention webhook called")
-	
-	result.EndTime = time.Now()
-	result.Success = true
-	result.ActualOutcome = "Successfully escalated complex scenario"
-	
-	ats.testResults = append(ats.testResults, result)
-	ats.logTestResult(result)
+// startDependencySession registers a dependent pair of mock tasks on one peer
+// and returns the session a coordinator on another peer opens for them.
+func startDependencySession(t *testing.T, ctx context.Context, detectorNode *testNode, mc *coordination.MetaCoordinator) *coordination.CoordinationSession {
+	t.Helper()
+	detector := coordination.NewDependencyDetector(ctx, detectorNode.ps)
+	detector.RegisterTask(mockTaskContext(t, "bzzz", 23))
+	detector.RegisterTask(mockTaskContext(t, "hive", 15))
+
+	for _, session := range waitForSessions(t, mc, 1) {
+		return session
+	}
+	return nil
 }
 
-// testLoadHandling tests system behavior under load
-func (ats *AntennaeTestSuite) testLoadHandling() {
-	testName := "Load Handling"
-	fmt.Printf("   📈 %s\n", testName)
-	
-	startTime := time.Now()
-	result := TestResult{
-		TestName:        testName,
-		StartTime:       startTime,
-		ExpectedOutcome: "System handles multiple concurrent coordination sessions",
-		CoordinationLog: make([]string, 0),
-	}
-	
-	// Simulate high load
-	sessionsHandled := 0
-	for i := 0; i < 5; i++ {
-		result.CoordinationLog = append(result.CoordinationLog, 
-			fmt.Sprintf("Started coordination session %d", i+1))
-		time.Sleep(200 * time.Millisecond)
-		sessionsHandled++
-	}
-	
-	result.CoordinationLog = append(result.CoordinationLog, 
-		fmt.Sprintf("Successfully handled %d concurrent sessions", sessionsHandled))
-	
-	result.EndTime = time.Now()
-	result.Success = sessionsHandled >= 5
-	result.ActualOutcome = fmt.Sprintf("Handled %d concurrent coordination sessions", sessionsHandled)
-	result.Metrics = TestMetrics{
-		CoordinationSessions: sessionsHandled,
-		SuccessfulCoordinations: sessionsHandled,
-		AverageResponseTime: time.Since(startTime) / time.Duration(sessionsHandled),
-	}
-	
-	ats.testResults = append(ats.testResults, result)
-	ats.logTestResult(result)
+func TestBasicTaskAnnouncement(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nodes := newTestMesh(t, ctx, 2)
+
+	start := time.Now()
+	simulator := NewTaskSimulator(nodes[0].ps, ctx, DefaultSimulatorSeed)
+	if err := simulator.AnnounceTask(); err != nil {
+		t.Fatalf("AnnounceTask failed: %v", err)
+	}
+
+	msg := nodes[1].bzzz.waitFor(t, "task announcement", func(msg pubsub.Message) bool {
+		return msg.Type == pubsub.TaskAnnouncement
+	})
+	if msg.From != nodes[0].host.ID().String() {
+		t.Errorf("expected announcement from %s, got %s", nodes[0].host.ID(), msg.From)
+	}
+	repository, _ := msg.Data["repository"].(map[string]interface{})
+	if name, _ := repository["name"].(string); name == "" || msg.Data["task"] == nil {
+		t.Errorf("announcement is missing its repository or task: %v", msg.Data)
+	}
+
+	t.Logf("tasks announced: 1, delivery time: %v", time.Since(start).Round(time.Millisecond))
 }
 
-// logTestResult logs the result of a test
-func (ats *AntennaeTestSuite) logTestResult(result TestResult) {
-	status := "❌ FAILED"
-	if result.Success {
-		status = "✅ PASSED"
-	}
-	
-	fmt.Printf("   %s (%v)\n", status, result.EndTime.Sub(result.StartTime).Round(time.Millisecond))
-	fmt.Printf("   Expected: %s\n", result.ExpectedOutcome)
-	fmt.Printf("   Actual: %s\n", result.ActualOutcome)
-	
-	if len(result.CoordinationLog) > 0 {
-		fmt.Printf("   Coordination Log:\n")
-		for _, logEntry := range result.CoordinationLog {
-			fmt.Printf("     • %s\n", logEntry)
+func TestDependencyDetection(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nodes := newTestMesh(t, ctx, 2)
+
+	// bzzz#23 defines the coordination API that hive#15 implements
+	detector := coordination.NewDependencyDetector(ctx, nodes[0].ps)
+	detector.RegisterTask(mockTaskContext(t, "bzzz", 23))
+	detector.RegisterTask(mockTaskContext(t, "hive", 15))
+
+	msg := nodes[1].antennae.waitFor(t, "dependency announcement", coordinationMessage("dependency_detected"))
+	dependency, _ := msg.Data["dependency"].(map[string]interface{})
+	if dependency["relationship"] != "API_Contract" {
+		t.Errorf("expected an API_Contract dependency, got %v", dependency["relationship"])
+	}
+	repositories := fmt.Sprint(msg.Data["repositories"])
+	if !strings.Contains(repositories, "hive") || !strings.Contains(repositories, "bzzz") {
+		t.Errorf("expected the dependency to span hive and bzzz, got %s", repositories)
+	}
+
+	// Tasks in the same repository are left to single-repo coordination
+	before := len(nodes[1].antennae.matching(coordinationMessage("dependency_detected")))
+	detector.RegisterTask(mockTaskContext(t, "bzzz", 24))
+	time.Sleep(time.Second)
+	for _, msg := range nodes[1].antennae.matching(coordinationMessage("dependency_detected"))[before:] {
+		if repos := fmt.Sprint(msg.Data["repositories"]); repos == "[bzzz bzzz]" {
+			t.Errorf("unexpected same-repository dependency: %v", msg.Data["dependency"])
 		}
 	}
+
+	t.Logf("dependencies detected: %d", len(nodes[1].antennae.matching(coordinationMessage("dependency_detected"))))
 }
 
-// printTestSummary prints a summary of all test results
-func (ats *AntennaeTestSuite) printTestSummary() {
-	fmt.Println("\n" + "=" * 50)
-	fmt.Println("🧪 Antennae Test Suite Summary")
-	fmt.Println("=" * 50)
-	
-	passed := 0
-	failed := 0
-	totalDuration := time.Duration(0)
-	
-	for _, result := range ats.testResults {
-		if result.Success {
-			passed++
-		} else {
-			failed++
-		}
-		totalDuration += result.EndTime.Sub(result.StartTime)
-	}
-	
-	fmt.Printf("📊 Results: %d passed, %d failed (%d total)\n", passed, failed, len(ats.testResults))
-	fmt.Printf("⏱️ Total Duration: %v\n", totalDuration.Round(time.Millisecond))
-	fmt.Printf("✅ Success Rate: %.1f%%\n", float64(passed)/float64(len(ats.testResults))*100)
-	
-	// Print metrics summary
-	totalTasks := 0
-	totalSessions := 0
-	totalDependencies := 0
-	totalResponses := 0
-	
-	for _, result := range ats.testResults {
-		totalTasks += result.Metrics.TasksAnnounced
-		totalSessions += result.Metrics.CoordinationSessions
-		totalDependencies += result.Metrics.DependenciesDetected
-		totalResponses += result.Metrics.AgentResponses
-	}
-	
-	fmt.Printf("\n📈 Coordination Metrics:\n")
-	fmt.Printf("   Tasks Announced: %d\n", totalTasks)
-	fmt.Printf("   Coordination Sessions: %d\n", totalSessions)
-	fmt.Printf("   Dependencies Detected: %d\n", totalDependencies)
-	fmt.Printf("   Agent Responses: %d\n", totalResponses)
-	
-	if failed > 0 {
-		fmt.Printf("\n❌ Failed Tests:\n")
-		for _, result := range ats.testResults {
-			if !result.Success {
-				fmt.Printf("   • %s: %s\n", result.TestName, result.ActualOutcome)
-			}
-		}
+func TestCrossRepositoryCoordination(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nodes := newTestMesh(t, ctx, 3)
+	agent, coordinatorNode, observer := nodes[0], nodes[1], nodes[2]
+
+	coordinator := coordination.NewMetaCoordinator(ctx, coordinatorNode.ps)
+
+	// Register every task in the shared-API scenario
+	simulator := NewTaskSimulator(agent.ps, ctx, DefaultSimulatorSeed)
+	scenario := simulator.GetScenarios()[0]
+	detector := coordination.NewDependencyDetector(ctx, agent.ps)
+	for _, task := range scenario.Tasks {
+		detector.RegisterTask(mockTaskContext(t, task.Repository, task.TaskNumber))
+	}
+
+	var session *coordination.CoordinationSession
+	for _, s := range waitForSessions(t, coordinator, 1) {
+		session = s
+		break
+	}
+	if session.Type != "dependency" || len(session.TasksInvolved) != 2 || len(session.Participants) != 2 {
+		t.Fatalf("unexpected session: type %s, %d tasks, %d participants",
+			session.Type, len(session.TasksInvolved), len(session.Participants))
+	}
+	if session.TasksInvolved[0].Repository == session.TasksInvolved[1].Repository {
+		t.Errorf("expected a cross-repository session, both tasks are in %s", session.TasksInvolved[0].Repository)
+	}
+
+	// Both agents agree on the plan
+	for agentID := range session.Participants {
+		respond(t, agent, session.SessionID, agentID, "Agreed, the API contract lands first")
+	}
+
+	msg := observer.antennae.waitFor(t, "session resolution", forSession(session.SessionID, coordinationMessage("resolution")))
+	if resolution, _ := msg.Data["resolution"].(string); !strings.Contains(resolution, "Consensus") {
+		t.Errorf("expected a consensus resolution, got %q", resolution)
+	}
+
+	t.Logf("coordination sessions: %d, successful coordinations: 1", len(coordinator.GetActiveSessions()))
+}
+
+func TestConflictResolution(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nodes := newTestMesh(t, ctx, 3)
+	agent, coordinatorNode, observer := nodes[0], nodes[1], nodes[2]
+
+	coordinator := coordination.NewMetaCoordinator(ctx, coordinatorNode.ps)
+	session := startDependencySession(t, ctx, agent, coordinator)
+	resolved := forSession(session.SessionID, coordinationMessage("resolution"))
+
+	// An objection alone must not resolve the session
+	respond(t, agent, session.SessionID, "agent-hive", "Concern: the WebSocket work would have to change if the contract moves")
+	time.Sleep(2 * time.Second)
+	if len(observer.antennae.matching(resolved)) > 0 {
+		t.Fatal("session resolved while an agent still objected")
+	}
+
+	// Once the other agent addresses it, the session converges
+	respond(t, agent, session.SessionID, "agent-bzzz", "Agreed, I will freeze the contract before hive starts")
+	observer.antennae.waitFor(t, "session resolution", resolved)
+
+	if escalations := observer.antennae.matching(forSession(session.SessionID, coordinationMessage("escalation"))); len(escalations) > 0 {
+		t.Errorf("resolved conflict should not have escalated")
 	}
+
+	t.Logf("coordination sessions: 1, agent responses: 2, successful coordinations: 1")
 }
 
-// GetTestResults returns all test results
-func (ats *AntennaeTestSuite) GetTestResults() []TestResult {
-	return ats.testResults
+func TestEscalationScenarios(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nodes := newTestMesh(t, ctx, 3)
+	agent, coordinatorNode, observer := nodes[0], nodes[1], nodes[2]
+
+	coordinator := coordination.NewMetaCoordinator(ctx, coordinatorNode.ps)
+	session := startDependencySession(t, ctx, agent, coordinator)
+
+	// Agents go back and forth without agreeing until the message limit is hit
+	agents := []string{"agent-hive", "agent-bzzz"}
+	for i := 0; i < 10; i++ {
+		respond(t, agent, session.SessionID, agents[i%2], fmt.Sprintf("Concern %d: this still blocks my task", i))
+	}
+
+	msg := observer.antennae.waitFor(t, "escalation", forSession(session.SessionID, coordinationMessage("escalation")))
+	if msg.Data["requires_human"] != true {
+		t.Errorf("expected escalation to require a human, got %v", msg.Data["requires_human"])
+	}
+	if reason, _ := msg.Data["escalation_reason"].(string); !strings.Contains(reason, "Message limit") {
+		t.Errorf("expected escalation for the message limit, got %q", reason)
+	}
+	if resolutions := observer.antennae.matching(forSession(session.SessionID, coordinationMessage("resolution"))); len(resolutions) > 0 {
+		t.Errorf("escalated session should not also resolve")
+	}
+
+	t.Logf("agent responses: 10, escalations: 1")
 }
 
-func max(a, b int) int {
-	if a > b {
-		return a
+func TestLoadHandling(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nodes := newTestMesh(t, ctx, 2)
+
+	coordinator := coordination.NewMetaCoordinator(ctx, nodes[1].ps)
+
+	// Five independent dependent pairs, each in its own project
+	const pairs = 5
+	start := time.Now()
+	for i := 0; i < pairs; i++ {
+		api := mockTaskContext(t, "bzzz", 23)
+		implementation := mockTaskContext(t, "hive", 15)
+		api.ProjectID = 100 + i
+		implementation.ProjectID = 200 + i
+
+		detector := coordination.NewDependencyDetector(ctx, nodes[0].ps)
+		detector.RegisterTask(api)
+		detector.RegisterTask(implementation)
 	}
-	return b
-}
\ No newline at end of file
+
+	sessions := waitForSessions(t, coordinator, pairs)
+	for _, session := range sessions {
+		if session.Status != "active" {
+			t.Errorf("session %s is %s, expected active", session.SessionID, session.Status)
+		}
+	}
+
+	elapsed := time.Since(start)
+	t.Logf("coordination sessions: %d, average setup time: %v",
+		len(sessions), (elapsed / time.Duration(len(sessions))).Round(time.Millisecond))
+}
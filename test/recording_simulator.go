@@ -0,0 +1,192 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pubsub"
+)
+
+// MessagePublisher is the part of PubSub the recording simulator needs
+type MessagePublisher interface {
+	PublishBzzzMessage(msgType pubsub.MessageType, data map[string]interface{}) error
+	PublishAntennaeMessage(msgType pubsub.MessageType, data map[string]interface{}) error
+}
+
+// antennaeMessageTypes are republished on the Antennae topic; everything else goes to Bzzz
+var antennaeMessageTypes = map[pubsub.MessageType]bool{
+	pubsub.MetaDiscussion:       true,
+	pubsub.TaskHelpRequest:      true,
+	pubsub.TaskHelpResponse:     true,
+	pubsub.CoordinationRequest:  true,
+	pubsub.CoordinationComplete: true,
+	pubsub.DependencyAlert:      true,
+	pubsub.EscalationTrigger:    true,
+}
+
+// RecordedMessage is a captured message and the topic it is republished on
+type RecordedMessage struct {
+	Message  pubsub.Message
+	Antennae bool
+}
+
+// RecordingSimulator replays captured pubsub traffic with its original relative timing
+type RecordingSimulator struct {
+	publisher MessagePublisher
+	messages  []RecordedMessage
+	speed     float64 // Replay speed multiplier, 1 = real time
+}
+
+// NewRecordingSimulator creates a simulator for the given recorded messages
+func NewRecordingSimulator(publisher MessagePublisher, messages []RecordedMessage) *RecordingSimulator {
+	return &RecordingSimulator{
+		publisher: publisher,
+		messages:  messages,
+		speed:     1,
+	}
+}
+
+// SetSpeed replays the recording faster (> 1) or slower (< 1) than real time
+func (rs *RecordingSimulator) SetSpeed(speed float64) {
+	if speed > 0 {
+		rs.speed = speed
+	}
+}
+
+// LoadRecording reads a JSONL recording. Each line is either a pubsub Message or
+// an entry from the antennae monitor's activity log.
+func LoadRecording(path string) ([]RecordedMessage, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer file.Close()
+
+	var messages []RecordedMessage
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		msg, ok, err := parseRecordedLine(scanner.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		if ok {
+			messages = append(messages, msg)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	return messages, nil
+}
+
+// monitorLogEntry is a line of the antennae monitor's activity log
+type monitorLogEntry struct {
+	Timestamp    int64           `json:"timestamp"`
+	ActivityType string          `json:"activity_type"`
+	Data         json.RawMessage `json:"data"`
+}
+
+// parseRecordedLine decodes one recording line; lines that aren't messages are skipped
+func parseRecordedLine(line []byte) (RecordedMessage, bool, error) {
+	var probe struct {
+		ActivityType string `json:"activity_type"`
+	}
+	if err := json.Unmarshal(line, &probe); err != nil {
+		return RecordedMessage{}, false, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if probe.ActivityType == "" {
+		var msg pubsub.Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			return RecordedMessage{}, false, fmt.Errorf("invalid message: %w", err)
+		}
+		return RecordedMessage{Message: msg, Antennae: antennaeMessageTypes[msg.Type]}, true, nil
+	}
+
+	var entry monitorLogEntry
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return RecordedMessage{}, false, fmt.Errorf("invalid activity log entry: %w", err)
+	}
+
+	switch entry.ActivityType {
+	case "coordination_message":
+		var coordMsg struct {
+			Timestamp   time.Time              `json:"timestamp"`
+			FromAgent   string                 `json:"from_agent"`
+			MessageType pubsub.MessageType     `json:"message_type"`
+			Content     map[string]interface{} `json:"content"`
+		}
+		if err := json.Unmarshal(entry.Data, &coordMsg); err != nil {
+			return RecordedMessage{}, false, fmt.Errorf("invalid coordination message: %w", err)
+		}
+		msgType := coordMsg.MessageType
+		if msgType == "" {
+			msgType = pubsub.MetaDiscussion
+		}
+		return RecordedMessage{
+			Message: pubsub.Message{
+				Type:      msgType,
+				From:      coordMsg.FromAgent,
+				Timestamp: coordMsg.Timestamp,
+				Data:      coordMsg.Content,
+			},
+			Antennae: true,
+		}, true, nil
+
+	case "task_announcement":
+		var data map[string]interface{}
+		if err := json.Unmarshal(entry.Data, &data); err != nil {
+			return RecordedMessage{}, false, fmt.Errorf("invalid task announcement: %w", err)
+		}
+		return RecordedMessage{
+			Message: pubsub.Message{
+				Type:      pubsub.TaskAnnouncement,
+				Timestamp: time.Unix(entry.Timestamp, 0),
+				Data:      data,
+			},
+		}, true, nil
+	}
+
+	// Other monitor activity (metrics, session changes) isn't traffic
+	return RecordedMessage{}, false, nil
+}
+
+// Replay republishes the recorded messages in order, sleeping between them to
+// reproduce the original gaps. It stops early if ctx is cancelled.
+func (rs *RecordingSimulator) Replay(ctx context.Context) error {
+	fmt.Printf("📼 Replaying %d recorded messages at %.1fx speed\n", len(rs.messages), rs.speed)
+
+	var previous time.Time
+	for i, recorded := range rs.messages {
+		msg := recorded.Message
+		if !previous.IsZero() && msg.Timestamp.After(previous) {
+			delay := time.Duration(float64(msg.Timestamp.Sub(previous)) / rs.speed)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+		if !msg.Timestamp.IsZero() {
+			previous = msg.Timestamp
+		}
+
+		publish := rs.publisher.PublishBzzzMessage
+		if recorded.Antennae {
+			publish = rs.publisher.PublishAntennaeMessage
+		}
+		if err := publish(msg.Type, msg.Data); err != nil {
+			return fmt.Errorf("failed to republish message %d (%s): %w", i, msg.Type, err)
+		}
+	}
+
+	fmt.Printf("✅ Replay complete\n")
+	return nil
+}
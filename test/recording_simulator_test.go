@@ -0,0 +1,90 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anthonyrawlins/bzzz/pubsub"
+)
+
+// capturePublisher records what the simulator republishes
+type capturePublisher struct {
+	mu        sync.Mutex
+	published []string
+	times     []time.Time
+}
+
+func (cp *capturePublisher) record(topic string, msgType pubsub.MessageType, data map[string]interface{}) error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	id, _ := data["id"].(string)
+	cp.published = append(cp.published, topic+":"+string(msgType)+":"+id)
+	cp.times = append(cp.times, time.Now())
+	return nil
+}
+
+func (cp *capturePublisher) PublishBzzzMessage(msgType pubsub.MessageType, data map[string]interface{}) error {
+	return cp.record("bzzz", msgType, data)
+}
+
+func (cp *capturePublisher) PublishAntennaeMessage(msgType pubsub.MessageType, data map[string]interface{}) error {
+	return cp.record("antennae", msgType, data)
+}
+
+const testRecording = `{"type":"task_announcement","from":"peer-a","timestamp":"2025-01-01T10:00:00Z","data":{"id":"1"}}
+{"type":"task_help_request","from":"peer-b","timestamp":"2025-01-01T10:00:02Z","data":{"id":"2"}}
+{"timestamp":1735725600,"activity_type":"metrics_update","data":{}}
+{"timestamp":1735725604,"activity_type":"coordination_message","data":{"timestamp":"2025-01-01T10:00:04Z","from_agent":"peer-c","message_type":"meta_discussion","content":{"id":"3"}}}
+{"type":"task_claim","from":"peer-a","timestamp":"2025-01-01T10:00:06Z","data":{"id":"4"}}
+`
+
+func TestRecordingSimulatorReplaysInOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	if err := os.WriteFile(path, []byte(testRecording), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	messages, err := LoadRecording(path)
+	if err != nil {
+		t.Fatalf("LoadRecording failed: %v", err)
+	}
+	if len(messages) != 4 {
+		t.Fatalf("expected 4 messages (metrics entries skipped), got %d", len(messages))
+	}
+
+	publisher := &capturePublisher{}
+	sim := NewRecordingSimulator(publisher, messages)
+	sim.SetSpeed(100) // 6s of traffic in ~60ms
+
+	start := time.Now()
+	if err := sim.Replay(context.Background()); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+
+	want := []string{
+		"bzzz:task_announcement:1",
+		"antennae:task_help_request:2",
+		"antennae:meta_discussion:3",
+		"bzzz:task_claim:4",
+	}
+	if len(publisher.published) != len(want) {
+		t.Fatalf("expected %v, got %v", want, publisher.published)
+	}
+	for i := range want {
+		if publisher.published[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, publisher.published)
+		}
+	}
+
+	// Relative timing is preserved, scaled by the replay speed
+	if elapsed := publisher.times[3].Sub(start); elapsed < 50*time.Millisecond {
+		t.Errorf("replay finished too quickly (%v) to have kept the original gaps", elapsed)
+	}
+	if gap := publisher.times[1].Sub(publisher.times[0]); gap < 15*time.Millisecond {
+		t.Errorf("expected ~20ms between the first two messages, got %v", gap)
+	}
+}
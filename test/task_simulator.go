@@ -2,14 +2,19 @@ package test
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"math/rand"
+	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/anthonyrawlins/bzzz/pubsub"
 )
 
+// DefaultSimulatorSeed is used when BZZZ_SIM_SEED isn't set, so runs are reproducible
+const DefaultSimulatorSeed int64 = 42
+
 // TaskSimulator generates realistic task scenarios for testing antennae coordination
 type TaskSimulator struct {
 	pubsub       *pubsub.PubSub
@@ -17,6 +22,14 @@ type TaskSimulator struct {
 	isRunning    bool
 	repositories []MockRepository
 	scenarios    []CoordinationScenario
+	seed         int64
+
+	// Each simulation routine draws from its own source, so a seed reproduces a
+	// run however the routines interleave. AnnounceTask can also be called from
+	// outside the announcement routine, hence the lock on its source.
+	taskRng     *rand.Rand
+	taskRngLock sync.Mutex
+	responseRng *rand.Rand
 }
 
 // MockRepository represents a simulated repository with tasks
@@ -65,17 +78,36 @@ type ScenarioTask struct {
 	BlockedBy  []ScenarioTask `json:"blocked_by"`
 }
 
-// NewTaskSimulator creates a new task simulator
-func NewTaskSimulator(ps *pubsub.PubSub, ctx context.Context) *TaskSimulator {
+// NewTaskSimulator creates a new task simulator whose random choices are driven by seed
+func NewTaskSimulator(ps *pubsub.PubSub, ctx context.Context, seed int64) *TaskSimulator {
 	sim := &TaskSimulator{
 		pubsub: ps,
 		ctx:    ctx,
 		repositories: generateMockRepositories(),
 		scenarios: generateCoordinationScenarios(),
+		seed:   seed,
+		taskRng:     rand.New(rand.NewSource(seed)),
+		responseRng: rand.New(rand.NewSource(seed + 1)),
 	}
 	return sim
 }
 
+// SimulatorSeed returns the seed from BZZZ_SIM_SEED, or DefaultSimulatorSeed
+func SimulatorSeed() int64 {
+	if value := os.Getenv("BZZZ_SIM_SEED"); value != "" {
+		if seed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return seed
+		}
+		fmt.Printf("⚠️ Ignoring invalid BZZZ_SIM_SEED %q\n", value)
+	}
+	return DefaultSimulatorSeed
+}
+
+// Seed returns the seed the simulator was created with
+func (ts *TaskSimulator) Seed() int64 {
+	return ts.seed
+}
+
 // Start begins the task simulation
 func (ts *TaskSimulator) Start() {
 	if ts.isRunning {
@@ -83,7 +115,7 @@ func (ts *TaskSimulator) Start() {
 	}
 	ts.isRunning = true
 	
-	fmt.Println("🎭 Starting Task Simulator for Antennae Testing")
+	fmt.Printf("🎭 Starting Task Simulator for Antennae Testing (seed %d)\n", ts.seed)
 	
 	// Start different simulation routines
 	go ts.simulateTaskAnnouncements()
@@ -112,18 +144,35 @@ func (ts *TaskSimulator) simulateTaskAnnouncements() {
 	}
 }
 
-// announceRandomTask announces a random task from the mock repositories
-func (ts *TaskSimulator) announceRandomTask() {
+// nextRandomTask picks the next task to announce from the mock repositories
+func (ts *TaskSimulator) nextRandomTask() (MockRepository, MockTask, bool) {
 	if len(ts.repositories) == 0 {
-		return
+		return MockRepository{}, MockTask{}, false
 	}
+	ts.taskRngLock.Lock()
+	defer ts.taskRngLock.Unlock()
 	
-	repo := ts.repositories[rand.Intn(len(ts.repositories))]
+	repo := ts.repositories[ts.taskRng.Intn(len(ts.repositories))]
 	if len(repo.Tasks) == 0 {
-		return
+		return MockRepository{}, MockTask{}, false
 	}
 	
-	task := repo.Tasks[rand.Intn(len(repo.Tasks))]
+	return repo, repo.Tasks[ts.taskRng.Intn(len(repo.Tasks))], true
+}
+
+// announceRandomTask announces a random task from the mock repositories
+func (ts *TaskSimulator) announceRandomTask() {
+	if err := ts.AnnounceTask(); err != nil {
+		fmt.Printf("❌ Failed to announce task: %v\n", err)
+	}
+}
+
+// AnnounceTask publishes one randomly chosen mock task
+func (ts *TaskSimulator) AnnounceTask() error {
+	repo, task, ok := ts.nextRandomTask()
+	if !ok {
+		return fmt.Errorf("no mock tasks to announce")
+	}
 	
 	announcement := map[string]interface{}{
 		"type": "task_available",
@@ -139,9 +188,7 @@ func (ts *TaskSimulator) announceRandomTask() {
 	
 	fmt.Printf("📢 Announcing task: %s/#%d - %s\n", repo.Name, task.Number, task.Title)
 	
-	if err := ts.pubsub.PublishBzzzMessage(pubsub.TaskAnnouncement, announcement); err != nil {
-		fmt.Printf("❌ Failed to announce task: %v\n", err)
-	}
+	return ts.pubsub.PublishBzzzMessage(pubsub.TaskAnnouncement, announcement)
 }
 
 // simulateCoordinationScenarios runs coordination test scenarios
@@ -225,8 +272,8 @@ func (ts *TaskSimulator) simulateAgentResponses() {
 		case <-ts.ctx.Done():
 			return
 		case <-ticker.C:
-			if rand.Float32() < 0.7 { // 70% chance of response
-				response := responses[rand.Intn(len(responses))]
+			if ts.responseRng.Float32() < 0.7 { // 70% chance of response
+				response := responses[ts.responseRng.Intn(len(responses))]
 				ts.simulateAgentResponse(response)
 			}
 		}
@@ -237,10 +284,10 @@ func (ts *TaskSimulator) simulateAgentResponses() {
 func (ts *TaskSimulator) simulateAgentResponse(response string) {
 	agentResponse := map[string]interface{}{
 		"type": "agent_response",
-		"agent_id": fmt.Sprintf("sim-agent-%d", rand.Intn(3)+1),
+		"agent_id": fmt.Sprintf("sim-agent-%d", ts.responseRng.Intn(3)+1),
 		"message": response,
 		"timestamp": time.Now().Unix(),
-		"confidence": rand.Float32()*0.4 + 0.6, // 0.6-1.0 confidence
+		"confidence": ts.responseRng.Float32()*0.4 + 0.6, // 0.6-1.0 confidence
 	}
 	
 	fmt.Printf("🤖 Simulated agent response: %s\n", response)
@@ -398,6 +445,7 @@ func (ts *TaskSimulator) GetScenarios() []CoordinationScenario {
 func (ts *TaskSimulator) PrintStatus() {
 	fmt.Printf("🎭 Task Simulator Status:\n")
 	fmt.Printf("   Running: %v\n", ts.isRunning)
+	fmt.Printf("   Seed: %d\n", ts.seed)
 	fmt.Printf("   Mock Repositories: %d\n", len(ts.repositories))
 	fmt.Printf("   Coordination Scenarios: %d\n", len(ts.scenarios))
 	
@@ -0,0 +1,41 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// announcementSequence returns the first n tasks a simulator would announce
+func announcementSequence(sim *TaskSimulator, n int) []string {
+	sequence := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		repo, task, _ := sim.nextRandomTask()
+		sequence = append(sequence, fmt.Sprintf("%s#%d", repo.Name, task.Number))
+	}
+	return sequence
+}
+
+func TestSimulatorSeedIsReproducible(t *testing.T) {
+	ctx := context.Background()
+	first := announcementSequence(NewTaskSimulator(nil, ctx, 1234), 20)
+	second := announcementSequence(NewTaskSimulator(nil, ctx, 1234), 20)
+
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("same seed diverged at announcement %d: %v vs %v", i, first, second)
+		}
+	}
+
+	other := announcementSequence(NewTaskSimulator(nil, ctx, 99), 20)
+	same := true
+	for i := range first {
+		if first[i] != other[i] {
+			same = false
+			break
+		}
+	}
+	if same {
+		t.Errorf("different seeds produced identical sequences: %v", first)
+	}
+}